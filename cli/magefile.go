@@ -17,6 +17,8 @@ import (
 
 const (
 	websiteDir = "../web"
+	// cliDocsDir receives the generated CLI reference, served by the website.
+	cliDocsDir = websiteDir + "/public/cli"
 )
 
 const (
@@ -385,8 +387,19 @@ func preflightSpellCheck() error {
 	return nil
 }
 
+// Docs generates the CLI reference (one Markdown page per command plus
+// metadata.json) from the live command tree into the website.
+func Docs() error {
+	fmt.Println("Generating CLI reference...")
+	return sh.RunV("go", "run", "./"+srcDir, "docs", "generate", "--dir", cliDocsDir)
+}
+
 // preflightWebsiteBuild installs web dependencies and builds the Astro site.
 func preflightWebsiteBuild() error {
+	if err := Docs(); err != nil {
+		return fmt.Errorf("CLI reference generation failed: %w", err)
+	}
+
 	fmt.Println("   Installing website dependencies...")
 	if err := sh.RunV("pnpm", "install", "--dir", websiteDir); err != nil {
 		return fmt.Errorf("pnpm install failed for website: %w", err)
@@ -3,16 +3,32 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/jongio/azd-rest/src/internal/cmd"
 )
 
 func main() {
+	// Ctrl-C cancels in-flight requests so bulk runs, workflows, and
+	// repeats can write what they have and a summary. A second Ctrl-C
+	// exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	rootCmd := cmd.NewRootCmd()
-	if err := rootCmd.Execute(); err != nil {
+	args, err := cmd.ExpandAlias(rootCmd, os.Args[1:])
+	if err == nil {
+		rootCmd.SetArgs(args)
+		err = rootCmd.ExecuteContext(ctx)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		exitCode := 1
 		var coder cmd.ExitCoder
@@ -0,0 +1,220 @@
+// Package audit writes a tamper-evident, append-only log of write requests.
+// Each entry records the hash of the entry before it, so editing, removing,
+// or reordering an entry breaks the chain from that point on. When a key is
+// set in AZD_REST_AUDIT_KEY the hashes are HMAC-SHA256 signatures, so the
+// chain cannot be rebuilt after an edit without the key.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// KeyEnv names the environment variable holding the optional signing key.
+const KeyEnv = "AZD_REST_AUDIT_KEY"
+
+// Hash algorithms recorded in Entry.Alg.
+const (
+	AlgSHA256     = "sha256"
+	AlgHMACSHA256 = "hmac-sha256"
+)
+
+// lastEntryBlock is how much of the log lastEntry reads at a time, backwards
+// from the end.
+const lastEntryBlock = 4096
+
+// appendMu serializes appends from concurrent requests, such as bulk mode,
+// so each entry links to the one written just before it. A lock on the log's
+// .lock file does the same across azd rest processes sharing the log.
+var appendMu sync.Mutex
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Seq        int       `json:"seq"`
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Alg        string    `json:"alg"`
+	// Prev is the hash of the previous entry, empty for the first one.
+	Prev string `json:"prev"`
+	// Hash covers every other field of the entry, including Prev.
+	Hash string `json:"hash"`
+}
+
+// Key returns the signing key from AZD_REST_AUDIT_KEY, or nil when unset.
+func Key() []byte {
+	if v := os.Getenv(KeyEnv); v != "" {
+		return []byte(v)
+	}
+	return nil
+}
+
+// Append adds e to the log at path, creating the file if needed. Seq, Alg,
+// Prev, and Hash are filled in from the last entry in the file, which is read
+// and appended to under an OS lock on path.lock.
+func Append(path string, key []byte, e Entry) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600) //nolint:gosec // G304: path is the user's --audit-log
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	last, err := lastEntry(f)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	e.Seq = 1
+	e.Prev = ""
+	if last != nil {
+		e.Seq = last.Seq + 1
+		e.Prev = last.Hash
+	}
+	e.Alg = AlgSHA256
+	if key != nil {
+		e.Alg = AlgHMACSHA256
+	}
+	e.Time = e.Time.UTC()
+	if e.Hash, err = entryHash(e, key); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Sync()
+}
+
+// Verify checks every entry read from r and returns the number of entries
+// that verified. The error names the first line where the chain is broken.
+// With a nil key only unsigned logs verify; with a key every entry must be
+// signed with it.
+func Verify(r io.Reader, key []byte) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var prev *Entry
+	n := 0
+	for scanner.Scan() {
+		n++
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return n - 1, fmt.Errorf("line %d: not a valid audit entry: %w", n, err)
+		}
+		if err := verifyEntry(e, prev, n, key); err != nil {
+			return n - 1, err
+		}
+		prev = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// verifyEntry checks one entry against its predecessor.
+func verifyEntry(e Entry, prev *Entry, line int, key []byte) error {
+	wantSeq, wantPrev := 1, ""
+	if prev != nil {
+		wantSeq, wantPrev = prev.Seq+1, prev.Hash
+	}
+	if e.Seq != wantSeq {
+		return fmt.Errorf("line %d: sequence %d, want %d (an entry was removed or reordered)", line, e.Seq, wantSeq)
+	}
+	if e.Prev != wantPrev {
+		return fmt.Errorf("line %d: previous hash does not match line %d", line, line-1)
+	}
+	// With a key every entry must be signed, so a forged entry cannot fall
+	// back to a plain hash that anyone can compute.
+	switch {
+	case e.Alg != AlgSHA256 && e.Alg != AlgHMACSHA256:
+		return fmt.Errorf("line %d: unknown hash algorithm %q", line, e.Alg)
+	case e.Alg == AlgHMACSHA256 && key == nil:
+		return fmt.Errorf("line %d: entry is signed; set %s to verify it", line, KeyEnv)
+	case e.Alg == AlgSHA256 && key != nil:
+		return fmt.Errorf("line %d: entry is not signed", line)
+	}
+	want, err := entryHash(e, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(e.Hash)) {
+		return fmt.Errorf("line %d: hash mismatch (the entry was modified)", line)
+	}
+	return nil
+}
+
+// entryHash hashes e with its Hash field cleared, keyed when key is set.
+func entryHash(e Entry, key []byte) (string, error) {
+	e.Hash = ""
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	var h hash.Hash
+	if key != nil {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lastEntry returns the final entry in f, or nil for an empty file. It reads
+// backwards from the end until it has the whole last line, so an append does
+// not get slower as the log grows.
+func lastEntry(f *os.File) (*Entry, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for off := size; off > 0; {
+		n := min(int64(lastEntryBlock), off)
+		off -= n
+		block := make([]byte, n)
+		if _, err := f.ReadAt(block, off); err != nil {
+			return nil, err
+		}
+		data = bytes.TrimRight(append(block, data...), "\n")
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+			break
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, errors.New("last line is not a valid audit entry")
+	}
+	return &e, nil
+}
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLog appends n entries to a new log and returns its path.
+func writeLog(t *testing.T, key []byte, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < n; i++ {
+		require.NoError(t, Append(path, key, Entry{
+			Time:       time.Date(2026, 1, 2, 3, 4, i, 0, time.UTC),
+			Method:     "PUT",
+			URL:        "https://management.azure.com/subscriptions/sub/resourceGroups/rg" + string(rune('a'+i)),
+			StatusCode: 201,
+		}))
+	}
+	return path
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+func verifyLines(lines []string, key []byte) (int, error) {
+	return Verify(strings.NewReader(strings.Join(lines, "\n")+"\n"), key)
+}
+
+func TestAppend_ChainsEntries(t *testing.T) {
+	path := writeLog(t, nil, 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	n, err := Verify(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	lines := readLines(t, path)
+	assert.Contains(t, lines[0], `"seq":1`)
+	assert.Contains(t, lines[0], `"prev":""`)
+	assert.Contains(t, lines[2], `"seq":3`)
+	assert.Contains(t, lines[2], `"alg":"sha256"`)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	lines := readLines(t, writeLog(t, nil, 3))
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"modified", []string{lines[0], strings.Replace(lines[1], `"statusCode":201`, `"statusCode":200`, 1), lines[2]}, "line 2: hash mismatch"},
+		{"removed", []string{lines[0], lines[2]}, "line 2: sequence 3, want 2"},
+		{"reordered", []string{lines[1], lines[0], lines[2]}, "line 1: sequence 2, want 1"},
+		{"garbage", []string{lines[0], "not json"}, "line 2: not a valid audit entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := verifyLines(tt.lines, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.Less(t, n, len(tt.lines))
+		})
+	}
+}
+
+func TestVerify_SignedLog(t *testing.T) {
+	key := []byte("audit-signing-key")
+	lines := readLines(t, writeLog(t, key, 2))
+	assert.Contains(t, lines[0], `"alg":"hmac-sha256"`)
+
+	n, err := verifyLines(lines, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = verifyLines(lines, []byte("wrong-key"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hash mismatch")
+
+	_, err = verifyLines(lines, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), KeyEnv)
+}
+
+func TestVerify_KeyRejectsUnsignedEntries(t *testing.T) {
+	lines := readLines(t, writeLog(t, nil, 1))
+
+	_, err := verifyLines(lines, []byte("audit-signing-key"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not signed")
+}
+
+func TestAppend_RejectsCorruptTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("oops\n"), 0o600))
+
+	err := Append(path, nil, Entry{Method: "DELETE", URL: "https://example.com"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a valid audit entry")
+}
+
+func TestAppend_LongEntriesSpanBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	long := "https://example.com/" + strings.Repeat("a", 3*lastEntryBlock)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, Append(path, nil, Entry{Method: "PUT", URL: long + string(rune('a'+i))}))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	n, err := Verify(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestAppend_WaitsForOtherProcessLock(t *testing.T) {
+	path := writeLog(t, nil, 1)
+	other := flock.New(path + ".lock")
+	require.NoError(t, other.Lock())
+
+	done := make(chan error, 1)
+	go func() { done <- Append(path, nil, Entry{Method: "DELETE", URL: "https://example.com"}) }()
+	select {
+	case <-done:
+		t.Fatal("Append wrote while another process held the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, other.Unlock())
+	require.NoError(t, <-done)
+	assert.Len(t, readLines(t, path), 2)
+}
+
+func TestKey(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	assert.Nil(t, Key())
+	t.Setenv(KeyEnv, "k")
+	assert.Equal(t, []byte("k"), Key())
+}
@@ -0,0 +1,145 @@
+// Package azdcontext resolves the azd environment an invocation runs in: the
+// environment name and the subscription, tenant, and location stored in it.
+// When azd rest runs as an azd extension, the values come from the azd
+// extension gRPC server; otherwise they fall back to environment variables.
+package azdcontext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+)
+
+// ServerEnv names the variable azd sets to the address of its extension gRPC
+// server when it launches an extension.
+const ServerEnv = "AZD_SERVER"
+
+// Well-known azd environment keys.
+const (
+	EnvNameKey        = "AZURE_ENV_NAME"
+	SubscriptionIDKey = "AZURE_SUBSCRIPTION_ID"
+	TenantIDKey       = "AZURE_TENANT_ID"
+	LocationKey       = "AZURE_LOCATION"
+)
+
+// Sources a Context can be resolved from.
+const (
+	SourceAzd = "azd"
+	SourceEnv = "environment variables"
+)
+
+// ErrNotExtension is returned by Connect when azd rest is not running under
+// azd, so there is no extension server to talk to.
+var ErrNotExtension = errors.New("not running as an azd extension (run through azd rest)")
+
+// Context is the resolved azd environment.
+type Context struct {
+	EnvironmentName string `json:"environment,omitempty"`
+	SubscriptionID  string `json:"subscriptionId,omitempty"`
+	TenantID        string `json:"tenantId,omitempty"`
+	Location        string `json:"location,omitempty"`
+	// Source is SourceAzd or SourceEnv.
+	Source string `json:"source"`
+}
+
+// InExtension reports whether azd rest was launched by azd as an extension.
+func InExtension() bool {
+	return os.Getenv(ServerEnv) != ""
+}
+
+// Connect opens a client to the azd extension server. Calls on it need a
+// context carrying the azd access token (see azdext.WithAccessToken), which the
+// extension root command sets up. The caller must Close the client.
+func Connect() (*azdext.AzdClient, error) {
+	if !InExtension() {
+		return nil, ErrNotExtension
+	}
+	c, err := azdext.NewAzdClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to azd: %w", err)
+	}
+	return c, nil
+}
+
+// Load resolves the current azd environment from the extension server, or
+// from environment variables when azd rest is not running under azd.
+func Load(ctx context.Context) (Context, error) {
+	if !InExtension() {
+		return FromEnv(os.LookupEnv), nil
+	}
+	c, err := Connect()
+	if err != nil {
+		return Context{}, err
+	}
+	defer c.Close()
+	return LoadFrom(ctx, c.Environment())
+}
+
+// LoadFrom resolves the current azd environment and its values through an
+// environment service client. A project with no environment selected yields a
+// Context with only Source set.
+func LoadFrom(ctx context.Context, envs azdext.EnvironmentServiceClient) (Context, error) {
+	name, values, err := CurrentValues(ctx, envs)
+	if err != nil {
+		return Context{}, err
+	}
+	res := Context{Source: SourceAzd, EnvironmentName: name}
+	for key, value := range values {
+		res.set(key, value)
+	}
+	return res, nil
+}
+
+// CurrentValues returns the name of the current azd environment and all of its
+// values. Both are empty when no environment is selected.
+func CurrentValues(ctx context.Context, envs azdext.EnvironmentServiceClient) (string, map[string]string, error) {
+	current, err := envs.GetCurrent(ctx, &azdext.EmptyRequest{})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get the current azd environment: %w", err)
+	}
+	name := current.GetEnvironment().GetName()
+	if name == "" {
+		return "", nil, nil
+	}
+
+	res, err := envs.GetValues(ctx, &azdext.GetEnvironmentRequest{Name: name})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read azd environment %s: %w", name, err)
+	}
+	values := make(map[string]string, len(res.GetKeyValues()))
+	for _, kv := range res.GetKeyValues() {
+		values[kv.GetKey()] = kv.GetValue()
+	}
+	return name, values, nil
+}
+
+// FromEnv resolves the context from environment variables, which azd also
+// exports to hooks and to the processes it launches.
+func FromEnv(lookupEnv func(string) (string, bool)) Context {
+	res := Context{Source: SourceEnv}
+	for _, key := range []string{EnvNameKey, SubscriptionIDKey, TenantIDKey, LocationKey} {
+		if v, ok := lookupEnv(key); ok {
+			res.set(key, v)
+		}
+	}
+	return res
+}
+
+// set records a well-known key's value; other keys are ignored.
+func (c *Context) set(key, value string) {
+	switch key {
+	case EnvNameKey:
+		if c.EnvironmentName == "" {
+			c.EnvironmentName = value
+		}
+	case SubscriptionIDKey:
+		c.SubscriptionID = value
+	case TenantIDKey:
+		c.TenantID = value
+	case LocationKey:
+		c.Location = value
+	}
+}
@@ -0,0 +1,83 @@
+package azdcontext
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeEnvironments serves a fixed environment; unimplemented methods panic
+// through the embedded nil interface.
+type fakeEnvironments struct {
+	azdext.EnvironmentServiceClient
+	name   string
+	values map[string]string
+	err    error
+}
+
+func (f *fakeEnvironments) GetCurrent(context.Context, *azdext.EmptyRequest, ...grpc.CallOption) (*azdext.EnvironmentResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &azdext.EnvironmentResponse{Environment: &azdext.Environment{Name: f.name}}, nil
+}
+
+func (f *fakeEnvironments) GetValues(_ context.Context, req *azdext.GetEnvironmentRequest, _ ...grpc.CallOption) (*azdext.KeyValueListResponse, error) {
+	if req.GetName() != f.name {
+		return nil, errors.New("unknown environment")
+	}
+	res := &azdext.KeyValueListResponse{}
+	for k, v := range f.values {
+		res.KeyValues = append(res.KeyValues, &azdext.KeyValue{Key: k, Value: v})
+	}
+	return res, nil
+}
+
+func TestLoadFrom(t *testing.T) {
+	got, err := LoadFrom(context.Background(), &fakeEnvironments{
+		name: "dev",
+		values: map[string]string{
+			SubscriptionIDKey: "sub1",
+			TenantIDKey:       "tenant1",
+			LocationKey:       "eastus2",
+			EnvNameKey:        "ignored",
+			"OTHER":           "x",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Context{EnvironmentName: "dev", SubscriptionID: "sub1", TenantID: "tenant1", Location: "eastus2", Source: SourceAzd}, got)
+}
+
+func TestLoadFrom_NoEnvironment(t *testing.T) {
+	got, err := LoadFrom(context.Background(), &fakeEnvironments{})
+	require.NoError(t, err)
+	assert.Equal(t, Context{Source: SourceAzd}, got)
+}
+
+func TestLoadFrom_Error(t *testing.T) {
+	_, err := LoadFrom(context.Background(), &fakeEnvironments{err: errors.New("unavailable")})
+	assert.ErrorContains(t, err, "failed to get the current azd environment")
+}
+
+func TestLoad_FallsBackToEnv(t *testing.T) {
+	t.Setenv(ServerEnv, "")
+	t.Setenv(EnvNameKey, "ci")
+	t.Setenv(SubscriptionIDKey, "sub2")
+	t.Setenv(TenantIDKey, "")
+	t.Setenv(LocationKey, "westus")
+
+	got, err := Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Context{EnvironmentName: "ci", SubscriptionID: "sub2", Location: "westus", Source: SourceEnv}, got)
+}
+
+func TestConnect_NotExtension(t *testing.T) {
+	t.Setenv(ServerEnv, "")
+	_, err := Connect()
+	assert.ErrorIs(t, err, ErrNotExtension)
+}
@@ -0,0 +1,209 @@
+// Package cache stores HTTP responses on disk for azd rest --cache. Entries
+// are partitioned by azd environment name, one directory per environment, so
+// switching environments never serves a response fetched for another one.
+// Each partition keeps hit and miss counters for azd rest cache stats.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// statsFileName holds a partition's counters, next to its entries.
+const statsFileName = "stats.json"
+
+// defaultPartition holds entries made outside any azd environment.
+const defaultPartition = "_none"
+
+// partitionPattern matches an environment name usable as a directory name
+// as is. azd environment names always match.
+var partitionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// statsMu serializes counter updates from concurrent requests in one process.
+var statsMu sync.Mutex
+
+// Entry is one cached response.
+type Entry struct {
+	StatusCode int         `json:"statusCode"`
+	Status     string      `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	StoredAt   time.Time   `json:"storedAt"`
+}
+
+// Stats describes one environment's partition.
+type Stats struct {
+	Environment string  `json:"environment"`
+	Entries     int     `json:"entries"`
+	Bytes       int64   `json:"bytes"`
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	HitRate     float64 `json:"hitRate"`
+}
+
+// counters is the content of a partition's stats file.
+type counters struct {
+	Environment string `json:"environment"`
+	Hits        int64  `json:"hits"`
+	Misses      int64  `json:"misses"`
+}
+
+// Dir returns the response cache directory, cache/responses in the azd rest
+// config directory.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", "responses"), nil
+}
+
+// Key returns the cache key for the parts that identify a request.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// partitionDir returns the directory under root for env. A name that is not
+// safe as a directory name is hashed.
+func partitionDir(root, env string) string {
+	switch {
+	case env == "":
+		return filepath.Join(root, defaultPartition)
+	case partitionPattern.MatchString(env):
+		return filepath.Join(root, env)
+	default:
+		return filepath.Join(root, "env-"+Key(env)[:16])
+	}
+}
+
+// Get returns the entry for key in env's partition when it is younger than
+// maxAge, and counts a hit or a miss. A missing, expired, or unreadable entry
+// is a miss.
+func Get(root, env, key string, maxAge time.Duration) (Entry, bool) {
+	dir := partitionDir(root, env)
+	var e Entry
+	raw, err := os.ReadFile(filepath.Join(dir, key+".json")) // #nosec G304 -- Path is under the cache directory and the key is a hex hash.
+	hit := err == nil && json.Unmarshal(raw, &e) == nil && time.Since(e.StoredAt) < maxAge
+	count(dir, env, hit)
+	return e, hit
+}
+
+// Put stores e for key in env's partition.
+func Put(root, env, key string, e Entry) error {
+	dir := partitionDir(root, env)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// count adds a hit or a miss to the partition's counters. Counting is best
+// effort: a stats file that cannot be written never fails a request.
+func count(dir, env string, hit bool) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	c := readCounters(dir)
+	c.Environment = env
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+	raw, err := json.Marshal(c)
+	if err != nil || os.MkdirAll(dir, 0o700) != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(dir, statsFileName), raw, 0o600)
+}
+
+// readCounters returns the partition's counters, or zero counters when the
+// stats file is missing or unreadable.
+func readCounters(dir string) counters {
+	var c counters
+	raw, err := os.ReadFile(filepath.Join(dir, statsFileName)) // #nosec G304 -- Fixed file under the cache directory.
+	if err == nil {
+		_ = json.Unmarshal(raw, &c)
+	}
+	return c
+}
+
+// ReadStats returns the stats of every partition under root, sorted by
+// environment name. A missing root yields no stats.
+func ReadStats(root string) ([]Stats, error) {
+	dirs, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	var all []Stats
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, d.Name())
+		c := readCounters(dir)
+		st := Stats{Environment: c.Environment, Hits: c.Hits, Misses: c.Misses}
+		if d.Name() != defaultPartition && st.Environment == "" {
+			st.Environment = d.Name()
+		}
+		if total := c.Hits + c.Misses; total > 0 {
+			st.HitRate = float64(c.Hits) / float64(total)
+		}
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache directory: %w", err)
+		}
+		for _, f := range files {
+			if f.IsDir() || f.Name() == statsFileName || !strings.HasSuffix(f.Name(), ".json") {
+				continue
+			}
+			if info, err := f.Info(); err == nil {
+				st.Entries++
+				st.Bytes += info.Size()
+			}
+		}
+		all = append(all, st)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Environment < all[j].Environment })
+	return all, nil
+}
+
+// Clear removes env's partition, entries and counters, or every partition
+// when all is set.
+func Clear(root, env string, all bool) error {
+	target := partitionDir(root, env)
+	if all {
+		target = root
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to clear the response cache: %w", err)
+	}
+	return nil
+}
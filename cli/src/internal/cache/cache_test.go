@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutGet_PartitionedByEnvironment(t *testing.T) {
+	root := t.TempDir()
+	key := Key("GET", "https://example.com/items")
+	entry := Entry{StatusCode: 200, Status: "200 OK", Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"env":"dev"}`), StoredAt: time.Now()}
+	require.NoError(t, Put(root, "dev", key, entry))
+
+	got, ok := Get(root, "dev", key, time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, entry.Body, got.Body)
+	assert.Equal(t, "application/json", got.Headers.Get("Content-Type"))
+
+	_, ok = Get(root, "prod", key, time.Minute)
+	assert.False(t, ok, "another environment never sees dev's entry")
+	_, ok = Get(root, "", key, time.Minute)
+	assert.False(t, ok)
+}
+
+func TestGet_Expired(t *testing.T) {
+	root := t.TempDir()
+	key := Key("GET", "https://example.com")
+	require.NoError(t, Put(root, "dev", key, Entry{StatusCode: 200, StoredAt: time.Now().Add(-2 * time.Minute)}))
+	_, ok := Get(root, "dev", key, time.Minute)
+	assert.False(t, ok)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("a", "b"), Key("a", "b"))
+	assert.NotEqual(t, Key("ab", ""), Key("a", "b"))
+}
+
+func TestReadStats(t *testing.T) {
+	root := t.TempDir()
+	stats, err := ReadStats(filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+
+	key := Key("GET", "https://example.com")
+	require.NoError(t, Put(root, "dev", key, Entry{StatusCode: 200, Body: []byte("{}"), StoredAt: time.Now()}))
+	Get(root, "dev", key, time.Minute)
+	Get(root, "dev", key, time.Minute)
+	Get(root, "dev", Key("other"), time.Minute)
+	Get(root, "", key, time.Minute)
+	Get(root, "weird/name", key, time.Minute)
+
+	stats, err = ReadStats(root)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Empty(t, stats[0].Environment)
+	assert.Equal(t, int64(1), stats[0].Misses)
+
+	dev := stats[1]
+	assert.Equal(t, "dev", dev.Environment)
+	assert.Equal(t, 1, dev.Entries)
+	assert.Positive(t, dev.Bytes)
+	assert.Equal(t, int64(2), dev.Hits)
+	assert.Equal(t, int64(1), dev.Misses)
+	assert.InDelta(t, 2.0/3.0, dev.HitRate, 0.001)
+
+	assert.Equal(t, "weird/name", stats[2].Environment, "a hashed partition keeps its environment name")
+}
+
+func TestClear(t *testing.T) {
+	root := t.TempDir()
+	key := Key("GET", "https://example.com")
+	for _, env := range []string{"dev", "prod"} {
+		require.NoError(t, Put(root, env, key, Entry{StatusCode: 200, StoredAt: time.Now()}))
+	}
+
+	require.NoError(t, Clear(root, "dev", false))
+	_, ok := Get(root, "prod", key, time.Minute)
+	assert.True(t, ok)
+	_, err := os.Stat(filepath.Join(root, "dev"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, Clear(root, "", true))
+	_, err = os.Stat(root)
+	assert.True(t, os.IsNotExist(err))
+}
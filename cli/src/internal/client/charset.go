@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// UTF-16 byte order marks.
+var (
+	utf16BEBOM = []byte{0xFE, 0xFF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+)
+
+// decodeCharset returns body transcoded to UTF-8 from the charset named in
+// contentType, and that charset's name. A body that starts with a UTF-16 byte
+// order mark is decoded as UTF-16 even without a charset. ok is false, and
+// body is returned unchanged, when the body is already UTF-8, has a binary
+// content type, or names a charset that is not known.
+func decodeCharset(body []byte, contentType string) (decoded []byte, charset string, ok bool) {
+	if len(body) == 0 || DetectContentType(nil, contentType) {
+		return body, "", false
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = strings.ToLower(strings.TrimSpace(params["charset"]))
+	}
+	if charset == "" && (bytes.HasPrefix(body, utf16BEBOM) || bytes.HasPrefix(body, utf16LEBOM)) {
+		charset = "utf-16"
+	}
+	enc := charsetEncoding(charset)
+	if enc == nil {
+		return body, "", false
+	}
+	out, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body, "", false
+	}
+	return out, charset, true
+}
+
+// charsetEncoding returns the decoder for a charset name, or nil for UTF-8,
+// US-ASCII, no charset, or an unknown name. UTF-16 without a byte order mark
+// is big-endian unless the name says otherwise, and a byte order mark, which
+// is dropped, always wins.
+func charsetEncoding(charset string) encoding.Encoding {
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return nil
+	case "utf-16", "utf-16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case "utf-16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil || enc == unicode.UTF8 {
+		return nil
+	}
+	return enc
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		charset     string
+	}{
+		{"latin1", []byte("caf\xe9"), "text/plain; charset=ISO-8859-1", "café", "iso-8859-1"},
+		{"windows-1252", []byte("\x93hi\x94"), "text/plain; charset=windows-1252", "“hi”", "windows-1252"},
+		{"utf-16le bom without charset", []byte{0xFF, 0xFE, '{', 0, '}', 0}, "application/json", "{}", "utf-16"},
+		{"utf-16 defaults to big-endian", []byte{0, '{', 0, '}'}, "application/json; charset=utf-16", "{}", "utf-16"},
+		{"utf-16le", []byte{'o', 0, 'k', 0}, "text/plain; charset=UTF-16LE", "ok", "utf-16le"},
+		{"shift_jis", []byte{0x82, 0xa0}, "text/plain; charset=Shift_JIS", "あ", "shift_jis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, charset, ok := decodeCharset(tt.body, tt.contentType)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.charset, charset)
+		})
+	}
+
+	for _, tc := range []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{"utf-8", []byte("café"), "application/json; charset=utf-8"},
+		{"no charset", []byte("caf\xe9"), "text/plain"},
+		{"unknown charset", []byte("abc"), "text/plain; charset=x-made-up"},
+		{"binary type", []byte{0xFF, 0xFE, 0, 0}, "image/png"},
+		{"empty", nil, "text/plain; charset=iso-8859-1"},
+	} {
+		got, _, ok := decodeCharset(tc.body, tc.contentType)
+		assert.False(t, ok, tc.name)
+		assert.Equal(t, tc.body, got, tc.name)
+	}
+}
+
+func TestExecute_TranscodesCharset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=iso-8859-1")
+		_, _ = w.Write([]byte("{\"name\":\"Jos\xe9\"}"))
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL, SkipAuth: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"José"}`, string(resp.Body))
+
+	resp, err = NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL, SkipAuth: true, RawBytes: true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Jos`+"\xe9"+`"}`, string(resp.Body))
+}
+
+func TestExecute_BodyHashSeesBytesBeforeTranscoding(t *testing.T) {
+	raw := "{\"name\":\"Jos\xe9\"}"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=iso-8859-1")
+		_, _ = w.Write([]byte(raw))
+	}))
+	defer srv.Close()
+
+	var hashed bytes.Buffer
+	resp, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL, SkipAuth: true, BodyHash: &hashed})
+	require.NoError(t, err)
+	assert.Equal(t, raw, hashed.String())
+	assert.JSONEq(t, `{"name":"José"}`, string(resp.Body))
+}
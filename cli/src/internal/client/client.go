@@ -1,38 +1,453 @@
 // Package client provides HTTP client functionality for the azd rest extension.
-// It re-exports types and functions from github.com/jongio/azd-core/httpclient.
+// The request executor is a local adaptation of github.com/jongio/azd-core/httpclient
+// so the extension can extend header redaction and own the transport; response
+// formatting is still re-exported from azd-core.
 package client
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/jongio/azd-core/httpclient"
 	"github.com/jongio/azd-rest/src/internal/version"
 )
 
+// UserAgent is the User-Agent header sent when the caller does not set one.
+var UserAgent = fmt.Sprintf("azd-rest/%s (azd extension)", version.Version)
+
 func init() {
-	httpclient.UserAgent = fmt.Sprintf("azd-rest/%s (azd extension)", version.Version)
+	httpclient.UserAgent = UserAgent
 }
 
-// Client is the HTTP client for making authenticated Azure REST API requests.
-type Client = httpclient.Client
+// defaultMaxResponseSize is the response size limit applied when the caller
+// does not set one.
+const defaultMaxResponseSize = 100 * 1024 * 1024 // 100MB
+
+// TokenProvider obtains OAuth tokens for authenticating HTTP requests.
+type TokenProvider interface {
+	GetToken(ctx context.Context, scope string) (string, error)
+}
+
+// MockTokenProvider is a test double for TokenProvider.
+type MockTokenProvider = httpclient.MockTokenProvider
 
 // RequestOptions configures individual HTTP request parameters.
-type RequestOptions = httpclient.RequestOptions
+type RequestOptions struct {
+	Method          string
+	URL             string
+	Body            io.Reader
+	Headers         http.Header
+	Scope           string
+	SkipAuth        bool
+	Verbose         bool
+	Timeout         time.Duration
+	Insecure        bool
+	FollowRedirects bool
+	MaxRedirects    int
+	// RedirectPolicy validates each redirect hop; the zero value refuses
+	// metadata endpoints and public-to-private redirects.
+	RedirectPolicy  RedirectPolicy
+	OutputFile      string
+	Format          string
+	TokenProvider   TokenProvider
+	Binary          bool
+	Retry           int
+	MaxResponseSize int64
+	Paginate        bool
+	// MaxPages caps the pages fetched when paginating, including the first;
+	// zero means the package limit. MaxItems caps the merged value items; zero
+	// means no cap.
+	MaxPages int
+	MaxItems int
+	// PageSink, when set with Paginate, receives the merged pages as compact
+	// JSON as they arrive instead of collecting them in memory. The returned
+	// Response then has a nil Body. A first page that is not a JSON object is
+	// returned in the Body as usual.
+	PageSink io.Writer
+	// OnPage, when set with PageSink, is called with the body of each page as
+	// it arrives, starting with the first. An error stops pagination and is
+	// returned from Execute.
+	OnPage func(body []byte) error
+	// BodyHash, when set, is fed the final response body as it is read from
+	// the network, before any charset transcoding.
+	BodyHash io.Writer
+	// MaxHeaderSize and MaxHeaderCount cap the response headers; zero means
+	// the package default. The transport refuses anything over
+	// MaxHeaderSizeLimit regardless.
+	MaxHeaderSize  int64
+	MaxHeaderCount int
+	// PinnedPublicKeys holds SHA-256 digests of accepted server public keys
+	// (see ParsePinnedPublicKeys). When set, every TLS connection, including
+	// redirect hops, must present one of them, and plain HTTP is refused.
+	PinnedPublicKeys [][]byte
+	// SecretHeaders names headers whose values are always fully redacted in
+	// verbose output, such as values resolved from the OS keychain.
+	SecretHeaders []string
+	// SecretValues holds values resolved from secret references anywhere in
+	// the request. Verbose output replaces them wherever they appear.
+	SecretValues []string
+	// RetryNonIdempotent allows a POST or PATCH to be retried after a network
+	// error that may have come after the server received it. Without it, such
+	// a request is retried only when it carries an Idempotency-Key header.
+	RetryNonIdempotent bool
+	// RawBytes keeps the response body as received instead of transcoding a
+	// non-UTF-8 charset to UTF-8.
+	RawBytes bool
+	// Stats, when set, receives the attempts, backoff, statuses, and
+	// rate-limit headers of the request.
+	Stats *RetryStats
+	// VerboseLevel is the -v count. VerboseBody adds the request body and
+	// retry decisions, and VerboseTrace adds transport events. Verbose is set
+	// whenever VerboseLevel is at least 1.
+	VerboseLevel int
+}
 
 // Response wraps an HTTP response with parsed body content.
 type Response = httpclient.Response
 
-// TokenProvider obtains OAuth tokens for authenticating HTTP requests.
-type TokenProvider = httpclient.TokenProvider
-
-// MockTokenProvider is a test double for TokenProvider.
-type MockTokenProvider = httpclient.MockTokenProvider
+// Client is the HTTP client for making authenticated Azure REST API requests.
+type Client struct {
+	httpClient    *http.Client
+	tokenProvider TokenProvider
+}
 
 // NewClient creates a new HTTP client configured for Azure REST API calls.
-var NewClient = httpclient.NewClient
+// Clients share one connection pool per TLS verification mode (see
+// ConfigureTransport), so creating a Client per request stays cheap and
+// keeps connections warm.
+func NewClient(tokenProvider TokenProvider, insecure bool, timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Transport: sharedTransport(insecure),
+			Timeout:   timeout,
+		},
+		tokenProvider: tokenProvider,
+	}
+}
+
+// Execute performs an HTTP request with the given options.
+func (c *Client) Execute(ctx context.Context, opts RequestOptions) (*Response, error) {
+	startTime := time.Now()
+
+	// Configure redirect handling
+	maxRedirects := opts.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = 10 // Default max redirects
+	}
+
+	transport := c.httpClient.Transport
+	if len(opts.PinnedPublicKeys) > 0 {
+		if err := requireHTTPSForPins(opts.URL); err != nil {
+			return nil, err
+		}
+		// Pins are checked on this process's own connections.
+		if f, ok := transport.(*forwardingTransport); ok {
+			transport = f.local
+		}
+		pinned, err := sharedPinnedTransport(transport, opts.PinnedPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		transport = pinned
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   c.httpClient.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !opts.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(opts.PinnedPublicKeys) > 0 {
+				if err := requireHTTPSForPins(req.URL.String()); err != nil {
+					return err
+				}
+			}
+			// via contains all previous requests including the original, so len(via) is the redirect count
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if err := opts.RedirectPolicy.checkHop(via[0].URL, req.URL); err != nil {
+				return err
+			}
+			opts.RedirectPolicy.applyHopHeaders(req, via[0], opts.SecretHeaders, opts.Verbose)
+			return c.authorizeHop(req, opts)
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.URL, opts.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range opts.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if !opts.SkipAuth && opts.Scope != "" && c.tokenProvider != nil {
+		token, err := c.tokenProvider.GetToken(ctx, opts.Scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get authentication token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "> %s %s\n", opts.Method, RedactSecrets(RedactValues(RedactURL(opts.URL), opts.SecretValues)))
+		for key, values := range req.Header {
+			for _, value := range values {
+				if isSecretHeader(key, opts.SecretHeaders) {
+					value = redactedValue
+				} else {
+					value = RedactHeaderValue(key, RedactValues(value, opts.SecretValues))
+				}
+				fmt.Fprintf(os.Stderr, "> %s: %s\n", key, value)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "> \n")
+	}
 
-// ShouldSkipAuth determines whether authentication should be skipped for a given URL.
-var ShouldSkipAuth = httpclient.ShouldSkipAuth
+	maxRetries := opts.Retry
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if maxRetries == 0 {
+		maxRetries = 3 // Default retries
+	}
+
+	bodyReader := opts.Body
+
+	// If a body is provided and we might retry, buffer it so each attempt can
+	// resend it.
+	if opts.Body != nil && maxRetries > 0 {
+		const maxBodySizeForRetry = 10 * 1024 * 1024
+		bodyBytes, readErr := io.ReadAll(io.LimitReader(opts.Body, maxBodySizeForRetry+1))
+		if readErr == nil && int64(len(bodyBytes)) <= maxBodySizeForRetry {
+			br := bytes.NewReader(bodyBytes)
+			bodyReader = br
+			req.Body = io.NopCloser(br)
+			req.ContentLength = int64(len(bodyBytes))
+			req.GetBody = func() (io.ReadCloser, error) {
+				_, _ = br.Seek(0, io.SeekStart)
+				return io.NopCloser(br), nil
+			}
+			if opts.VerboseLevel >= VerboseBody {
+				writeVerboseBody(os.Stderr, []byte(RedactValues(string(bodyBytes), opts.SecretValues)), req.Header.Get("Content-Type"))
+			}
+		} else {
+			if opts.VerboseLevel >= VerboseBody {
+				fmt.Fprintf(os.Stderr, "> [request body not shown: over %d bytes]\n", maxBodySizeForRetry)
+			}
+			if seeker, ok := opts.Body.(io.Seeker); ok {
+				if _, seekErr := seeker.Seek(0, io.SeekStart); seekErr == nil {
+					bodyReader = opts.Body
+				}
+			}
+		}
+	}
+
+	if opts.VerboseLevel >= VerboseTrace {
+		req = req.WithContext(withVerboseTrace(req.Context(), os.Stderr))
+	}
+
+	var resp *http.Response
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff: 1s, 2s, 4s, etc.
+			backoff := retryDelay(attempt)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+			case <-time.After(backoff):
+			}
+			opts.Stats.recordBackoff(backoff)
+			rewindBody(req, bodyReader)
+		}
+
+		resp, lastErr = client.Do(req)
+		opts.Stats.recordAttempt(resp)
+		if lastErr == nil {
+			// Retry 5xx responses until the attempts are exhausted.
+			if resp.StatusCode >= 500 && resp.StatusCode < 600 && attempt < maxRetries {
+				_ = resp.Body.Close()
+				if opts.VerboseLevel >= VerboseBody {
+					fmt.Fprintf(os.Stderr, "* HTTP %d on attempt %d of %d; retrying in %s\n", resp.StatusCode, attempt+1, maxRetries+1, retryDelay(attempt+1))
+				}
+				continue
+			}
+			if resp.StatusCode >= 500 && resp.StatusCode < 600 && opts.VerboseLevel >= VerboseBody {
+				fmt.Fprintf(os.Stderr, "* HTTP %d on attempt %d of %d; no retries left\n", resp.StatusCode, attempt+1, maxRetries+1)
+			}
+			break
+		}
+
+		if !isRetryableError(lastErr) {
+			if opts.VerboseLevel >= VerboseBody {
+				fmt.Fprintf(os.Stderr, "* Attempt %d failed with an error that is not retried: %s\n", attempt+1, RedactSecrets(lastErr.Error()))
+			}
+			return nil, fmt.Errorf("request failed: %w", lastErr)
+		}
+		if attempt == maxRetries {
+			return nil, fmt.Errorf("request failed after %d retries: %w", maxRetries, lastErr)
+		}
+		if !retrySafe(req, opts, lastErr) {
+			if opts.VerboseLevel >= VerboseBody {
+				fmt.Fprintf(os.Stderr, "* Attempt %d failed: %s; a %s request may have reached the server, so it is not retried\n", attempt+1, RedactSecrets(lastErr.Error()), req.Method)
+			}
+			return nil, fmt.Errorf("request failed and was not retried because %s is not idempotent (send an %s header or pass --retry-non-idempotent to allow it): %w", req.Method, IdempotencyKeyHeader, lastErr)
+		}
+		if opts.VerboseLevel >= VerboseBody {
+			fmt.Fprintf(os.Stderr, "* Attempt %d of %d failed: %s; retrying in %s\n", attempt+1, maxRetries+1, RedactSecrets(lastErr.Error()), retryDelay(attempt+1))
+		}
+	}
+
+	if resp == nil {
+		return nil, fmt.Errorf("request failed: %w", lastErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := checkResponseHeaders(resp.Header, opts.MaxHeaderSize, opts.MaxHeaderCount); err != nil {
+		return nil, err
+	}
+
+	maxSize := opts.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	var body io.Reader = io.LimitReader(resp.Body, maxSize)
+	if opts.BodyHash != nil {
+		body = io.TeeReader(body, opts.BodyHash)
+	}
+	responseBody, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(responseBody)) >= maxSize {
+		return nil, fmt.Errorf("response body exceeds maximum size of %d bytes", maxSize)
+	}
+
+	// A body in another charset, such as ISO-8859-1 or UTF-16 from a legacy
+	// service, is transcoded to UTF-8 so it formats as text.
+	if !opts.Binary && !opts.RawBytes {
+		if decoded, charset, ok := decodeCharset(responseBody, resp.Header.Get("Content-Type")); ok {
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "* Transcoded the response body from %s to UTF-8\n", charset)
+			}
+			responseBody = decoded
+		}
+	}
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    resp.Header,
+		Body:       responseBody,
+		Duration:   time.Since(startTime),
+	}
+
+	if opts.Paginate && opts.PageSink != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		streamed, err := streamPagination(ctx, client, opts, response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write paginated response: %w", err)
+		}
+		if streamed {
+			response.Body = nil
+			return response, nil
+		}
+	}
+
+	if opts.Paginate && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		paginatedBody, err := handlePagination(ctx, client, opts, response)
+		if err != nil {
+			if opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Warning: Pagination failed: %s\n", RedactSecrets(err.Error()))
+			}
+			return response, nil
+		}
+		if paginatedBody != nil {
+			response.Body = paginatedBody
+		}
+	}
+
+	return response, nil
+}
+
+// rewindBody resets the request body before a retry so the next attempt sends
+// the full payload again.
+func rewindBody(req *http.Request, bodyReader io.Reader) {
+	if bodyReader == nil {
+		return
+	}
+	if seeker, ok := bodyReader.(io.ReadSeeker); ok {
+		_, _ = seeker.Seek(0, io.SeekStart)
+		req.Body = io.NopCloser(seeker)
+		req.GetBody = func() (io.ReadCloser, error) {
+			_, _ = seeker.Seek(0, io.SeekStart)
+			return io.NopCloser(seeker), nil
+		}
+		return
+	}
+	req.Body = io.NopCloser(bodyReader)
+}
+
+// isRetryableError reports whether a transport error is worth retrying.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// A connection the server closed or reset before responding.
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	errStr := strings.ToLower(err.Error())
+	retryablePatterns := []string{
+		"timeout",
+		"connection refused",
+		"connection reset",
+		"no such host",
+		"network is unreachable",
+		"temporary failure",
+		"i/o timeout",
+		"context deadline exceeded",
+	}
+	for _, pattern := range retryablePatterns {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldSkipAuth determines whether authentication should be skipped for a
+// given URL: when skipAuth is set, headers already carry an Authorization
+// header, or the URL is plain HTTP.
+func ShouldSkipAuth(url string, headers http.Header, skipAuth bool) bool {
+	for key := range headers {
+		if strings.EqualFold(key, "Authorization") {
+			return true
+		}
+	}
+	return httpclient.ShouldSkipAuth(url, nil, skipAuth)
+}
 
 // DetectContentType infers the content type of the given request body.
 var DetectContentType = httpclient.DetectContentType
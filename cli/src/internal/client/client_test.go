@@ -112,9 +112,9 @@ func TestClient_Execute_WithCustomHeaders(t *testing.T) {
 	opts := RequestOptions{
 		Method: "GET",
 		URL:    server.URL + "/test",
-		Headers: map[string]string{
-			"Content-Type":    "application/json",
-			"X-Custom-Header": "custom-value",
+		Headers: http.Header{
+			"Content-Type":    {"application/json"},
+			"X-Custom-Header": {"custom-value"},
 		},
 		SkipAuth: true,
 	}
@@ -125,6 +125,27 @@ func TestClient_Execute_WithCustomHeaders(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestClient_Execute_RepeatedHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"application/json", "text/plain"}, r.Header.Values("Accept"))
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(&auth.MockTokenProvider{Token: "test-token"}, false, 30*time.Second)
+
+	resp, err := client.Execute(context.Background(), RequestOptions{
+		Method:   "GET",
+		URL:      server.URL + "/test",
+		Headers:  http.Header{"Accept": {"application/json", "text/plain"}},
+		SkipAuth: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
 func TestClient_Execute_UserAgent(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userAgent := r.Header.Get("User-Agent")
@@ -238,42 +259,42 @@ func TestShouldSkipAuth(t *testing.T) {
 	tests := []struct {
 		name     string
 		url      string
-		headers  map[string]string
+		headers  http.Header
 		skipAuth bool
 		expected bool
 	}{
 		{
 			name:     "Explicit skip flag",
 			url:      "https://management.azure.com/subscriptions",
-			headers:  map[string]string{},
+			headers:  http.Header{},
 			skipAuth: true,
 			expected: true,
 		},
 		{
 			name:     "Authorization header present",
 			url:      "https://management.azure.com/subscriptions",
-			headers:  map[string]string{"Authorization": "Bearer token"},
+			headers:  http.Header{"Authorization": {"Bearer token"}},
 			skipAuth: false,
 			expected: true,
 		},
 		{
 			name:     "Authorization header case insensitive",
 			url:      "https://management.azure.com/subscriptions",
-			headers:  map[string]string{"authorization": "Bearer token"},
+			headers:  http.Header{"authorization": {"Bearer token"}},
 			skipAuth: false,
 			expected: true,
 		},
 		{
 			name:     "HTTP URL",
 			url:      "http://example.com/api",
-			headers:  map[string]string{},
+			headers:  http.Header{},
 			skipAuth: false,
 			expected: true,
 		},
 		{
 			name:     "HTTPS URL without skip or auth header",
 			url:      "https://management.azure.com/subscriptions",
-			headers:  map[string]string{},
+			headers:  http.Header{},
 			skipAuth: false,
 			expected: false,
 		},
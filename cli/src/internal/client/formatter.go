@@ -18,8 +18,8 @@ const (
 // NewFormatter creates a Formatter for the given OutputFormat.
 var NewFormatter = httpclient.NewFormatter
 
-// RedactSensitiveHeader replaces sensitive HTTP header values with redacted placeholders.
-var RedactSensitiveHeader = httpclient.RedactSensitiveHeader
+// RedactURL replaces the values of secret-bearing query parameters in a URL.
+var RedactURL = httpclient.RedactURL
 
 // RedactToken replaces bearer token values with redacted placeholders.
 var RedactToken = httpclient.RedactToken
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Response header limits. The transport aborts any response whose header
+// block is larger than MaxHeaderSizeLimit before it is buffered. The
+// per-request caps in RequestOptions are checked once the response arrives and
+// default to the values below.
+const (
+	MaxHeaderSizeLimit    = 1024 * 1024 // 1MB
+	defaultMaxHeaderSize  = 256 * 1024  // 256KB
+	defaultMaxHeaderCount = 200
+)
+
+// headerFootprint returns the number of header lines in h and their size as
+// sent on the wire ("Key: value\r\n").
+func headerFootprint(h http.Header) (count int, size int64) {
+	for key, values := range h {
+		for _, value := range values {
+			count++
+			size += int64(len(key) + len(": ") + len(value) + len("\r\n"))
+		}
+	}
+	return count, size
+}
+
+// checkResponseHeaders rejects a response with more headers, or larger
+// headers, than the caps allow. A cap of zero or less uses the default.
+func checkResponseHeaders(h http.Header, maxSize int64, maxCount int) error {
+	if maxSize <= 0 {
+		maxSize = defaultMaxHeaderSize
+	}
+	if maxCount <= 0 {
+		maxCount = defaultMaxHeaderCount
+	}
+	count, size := headerFootprint(h)
+	if count > maxCount {
+		return fmt.Errorf("response has %d headers, more than the maximum of %d", count, maxCount)
+	}
+	if size > maxSize {
+		return fmt.Errorf("response headers are %d bytes, more than the maximum of %d bytes", size, maxSize)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderFootprint(t *testing.T) {
+	h := http.Header{"X-A": {"1", "22"}, "Content-Type": {"application/json"}}
+	count, size := headerFootprint(h)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, int64(len("X-A: 1\r\nX-A: 22\r\nContent-Type: application/json\r\n")), size)
+}
+
+func TestCheckResponseHeaders(t *testing.T) {
+	h := http.Header{}
+	for i := range 5 {
+		h.Set(fmt.Sprintf("X-Header-%d", i), strings.Repeat("v", 100))
+	}
+
+	assert.NoError(t, checkResponseHeaders(h, 0, 0))
+	assert.NoError(t, checkResponseHeaders(h, 1024, 5))
+
+	err := checkResponseHeaders(h, 1024, 4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 headers")
+
+	err = checkResponseHeaders(h, 256, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum of 256 bytes")
+}
+
+func TestClient_Execute_HeaderLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range 50 {
+			w.Header().Set(fmt.Sprintf("X-Noise-%d", i), strings.Repeat("n", 200))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(nil, false, 5*time.Second)
+	opts := RequestOptions{Method: "GET", URL: server.URL, SkipAuth: true}
+
+	_, err := client.Execute(context.Background(), opts)
+	require.NoError(t, err)
+
+	opts.MaxHeaderCount = 20
+	_, err = client.Execute(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum of 20")
+
+	opts.MaxHeaderCount = 0
+	opts.MaxHeaderSize = 4096
+	_, err = client.Execute(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum of 4096 bytes")
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+)
+
+// IdempotencyKeyHeader carries a key the server uses to recognize a repeated
+// POST or PATCH and apply it only once.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// nonIdempotentMethods can change server state again when they are repeated.
+var nonIdempotentMethods = map[string]bool{
+	http.MethodPost:  true,
+	http.MethodPatch: true,
+}
+
+// retrySafe reports whether a request that failed with err can be sent again
+// without risking a duplicate side effect. Idempotent methods always can. A
+// POST or PATCH can when the error shows the request never left, such as a
+// failed DNS lookup or dial, when it carries an Idempotency-Key header, or
+// when opts.RetryNonIdempotent is set.
+func retrySafe(req *http.Request, opts RequestOptions, err error) bool {
+	if !nonIdempotentMethods[req.Method] || opts.RetryNonIdempotent || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
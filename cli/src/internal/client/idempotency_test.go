@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resettingServer returns a server that drops the connection of the first
+// request without responding and answers later ones, and its request count.
+func resettingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_Execute_DoesNotRetryPostAfterConnectionDrop(t *testing.T) {
+	server, calls := resettingServer(t)
+	_, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: http.MethodPost, URL: server.URL, SkipAuth: true, Retry: 1,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not retried because POST is not idempotent")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_Execute_RetriesAfterConnectionDrop(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RequestOptions
+	}{
+		{"idempotent method", RequestOptions{Method: http.MethodPut}},
+		{"idempotency key", RequestOptions{Method: http.MethodPost, Headers: http.Header{IdempotencyKeyHeader: {"k1"}}}},
+		{"opt in", RequestOptions{Method: http.MethodPatch, RetryNonIdempotent: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := resettingServer(t)
+			opts := tt.opts
+			opts.URL, opts.SkipAuth, opts.Retry = server.URL, true, 1
+			resp, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), opts)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
+
+func TestRetrySafe_RequestNeverSent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://example.com", nil)
+	dial := &net.OpError{Op: "dial", Err: &net.AddrError{Err: "connection refused"}}
+	assert.True(t, retrySafe(req, RequestOptions{}, dial))
+	assert.True(t, retrySafe(req, RequestOptions{}, &net.DNSError{Err: "no such host", Name: "example.com"}))
+	assert.False(t, retrySafe(req, RequestOptions{}, &net.OpError{Op: "read", Err: &net.AddrError{Err: "connection reset"}}))
+}
@@ -47,9 +47,9 @@ func TestIntegration_FullRequestLifecycle(t *testing.T) {
 	opts := RequestOptions{
 		Method: "POST",
 		URL:    server.URL + "/api/v1/resources",
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-			"X-Custom":     "test-value",
+		Headers: http.Header{
+			"Content-Type": {"application/json"},
+			"X-Custom":     {"test-value"},
 		},
 		Body:     strings.NewReader(`{"name":"my-resource"}`),
 		SkipAuth: false,
@@ -203,13 +203,13 @@ func TestIntegration_RetryThenSucceed(t *testing.T) {
 func TestIntegration_CustomHeaders(t *testing.T) {
 	tests := []struct {
 		name    string
-		headers map[string]string
+		headers http.Header
 		checks  func(t *testing.T, r *http.Request)
 	}{
 		{
 			name: "Content-Type override",
-			headers: map[string]string{
-				"Content-Type": "application/xml",
+			headers: http.Header{
+				"Content-Type": {"application/xml"},
 			},
 			checks: func(t *testing.T, r *http.Request) {
 				assert.Equal(t, "application/xml", r.Header.Get("Content-Type"))
@@ -217,10 +217,10 @@ func TestIntegration_CustomHeaders(t *testing.T) {
 		},
 		{
 			name: "Multiple custom headers",
-			headers: map[string]string{
-				"X-Correlation-Id": "abc-123",
-				"X-Request-Source": "azd-rest-test",
-				"Accept":           "application/json",
+			headers: http.Header{
+				"X-Correlation-Id": {"abc-123"},
+				"X-Request-Source": {"azd-rest-test"},
+				"Accept":           {"application/json"},
 			},
 			checks: func(t *testing.T, r *http.Request) {
 				assert.Equal(t, "abc-123", r.Header.Get("X-Correlation-Id"))
@@ -230,8 +230,8 @@ func TestIntegration_CustomHeaders(t *testing.T) {
 		},
 		{
 			name: "API version header",
-			headers: map[string]string{
-				"x-ms-version": "2024-01-01",
+			headers: http.Header{
+				"x-ms-version": {"2024-01-01"},
 			},
 			checks: func(t *testing.T, r *http.Request) {
 				assert.Equal(t, "2024-01-01", r.Header.Get("x-ms-version"))
@@ -0,0 +1,323 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// maxPaginationPages caps the pages fetched for one paginated request.
+const maxPaginationPages = 1000
+
+// reLinkNext matches Link header with rel=next (case-insensitive on rel part only).
+var reLinkNext = regexp.MustCompile(`(?i)<([^>]+)>;\s*rel=["']?next["']?`)
+
+// parseLinkHeader parses the Link header and extracts the next URL.
+func parseLinkHeader(linkHeader string) (string, bool) {
+	if linkHeader == "" {
+		return "", false
+	}
+	matches := reLinkNext.FindStringSubmatch(linkHeader)
+	if len(matches) > 1 {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// nextLinkKeys are the body fields that carry the next page URL.
+var nextLinkKeys = []string{"nextLink", "@odata.nextLink", "@odata.next"}
+
+// extractNextLinkFromBody extracts nextLink from a JSON response body (Azure API format).
+func extractNextLinkFromBody(body []byte) (string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return "", false
+	}
+	return nextLinkFromFields(fields)
+}
+
+// nextLinkFromFields returns the first non-empty string next link field.
+func nextLinkFromFields(fields map[string]json.RawMessage) (string, bool) {
+	for _, key := range nextLinkKeys {
+		var next string
+		if raw, ok := fields[key]; ok && json.Unmarshal(raw, &next) == nil && next != "" {
+			return next, true
+		}
+	}
+	return "", false
+}
+
+// continuationTokenHeader carries the Azure DevOps continuation token. The
+// next page is the original request with the token in the continuationToken
+// query parameter.
+const continuationTokenHeader = "X-Ms-Continuationtoken"
+
+// continuationURL returns originalURL with its continuationToken query
+// parameter set to token.
+func continuationURL(originalURL *url.URL, token string) string {
+	next := *originalURL
+	query := next.Query()
+	query.Set("continuationToken", token)
+	next.RawQuery = query.Encode()
+	return next.String()
+}
+
+// nextLinkFor returns the next page URL from the body fields, the Link
+// header, or an Azure DevOps continuation token.
+func nextLinkFor(fields map[string]json.RawMessage, headers http.Header, originalURL *url.URL) string {
+	if next, ok := nextLinkFromFields(fields); ok {
+		return next
+	}
+	if next, ok := parseLinkHeader(headers.Get("Link")); ok {
+		return next
+	}
+	if token := headers.Get(continuationTokenHeader); token != "" {
+		return continuationURL(originalURL, token)
+	}
+	return ""
+}
+
+// handlePagination follows next links and merges every page's value array into
+// one response. It enforces same-origin checks to prevent SSRF via
+// server-controlled nextLink URLs. A first page that cannot be merged, or a
+// merge that found no items, returns the first page unchanged.
+func handlePagination(ctx context.Context, client *http.Client, opts RequestOptions, firstResponse *Response) ([]byte, error) {
+	var buf bytes.Buffer
+	items, err := mergePages(ctx, client, opts, firstResponse, &buf)
+	if err != nil {
+		return firstResponse.Body, err
+	}
+	if items <= 0 {
+		return firstResponse.Body, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// streamPagination merges every page into opts.PageSink as pages arrive. It
+// reports false, writing nothing, when the first page is not a JSON object,
+// so the caller handles the response as usual.
+func streamPagination(ctx context.Context, client *http.Client, opts RequestOptions, firstResponse *Response) (bool, error) {
+	items, err := mergePages(ctx, client, opts, firstResponse, opts.PageSink)
+	return items >= 0, err
+}
+
+// mergePages writes one JSON object to w holding every page's value items,
+// followed by the first page's other fields minus its next link. Items are
+// copied as raw JSON and written page by page, so memory stays bounded by
+// one page rather than the whole result. It returns the number of items
+// written, or -1 with nothing written when the first page is not a JSON
+// object.
+func mergePages(ctx context.Context, client *http.Client, opts RequestOptions, firstResponse *Response, w io.Writer) (int, error) {
+	originalURL, err := url.Parse(opts.URL)
+	if err != nil {
+		//nolint:nilerr // intentionally returning the first page when the URL cannot be parsed
+		return -1, nil
+	}
+	var firstData map[string]json.RawMessage
+	if err := json.Unmarshal(firstResponse.Body, &firstData); err != nil {
+		//nolint:nilerr // intentionally returning the first page when it is not a JSON object
+		return -1, nil
+	}
+
+	maxResponseSize := opts.MaxResponseSize
+	if maxResponseSize <= 0 {
+		maxResponseSize = defaultMaxResponseSize
+	}
+	maxPages := maxPaginationPages
+	if opts.MaxPages > 0 && opts.MaxPages < maxPages {
+		maxPages = opts.MaxPages
+	}
+
+	// The first page's other fields keep their sorted position around
+	// "value", matching an encoded map. An Azure DevOps "count" is the size
+	// of one page, so it is dropped once pages are merged.
+	continued := firstResponse.Headers.Get(continuationTokenHeader) != ""
+	keys := []string{"value"}
+	for key := range firstData {
+		if key != "value" && !slices.Contains(nextLinkKeys, key) && (!continued || key != "count") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	mw := &mergeWriter{w: w, maxItems: opts.MaxItems, onPage: opts.OnPage}
+	mw.page(firstResponse.Body)
+	mw.writeString("{")
+	for i, key := range keys {
+		if i > 0 {
+			mw.writeString(",")
+		}
+		mw.writeJSON(key)
+		mw.writeString(":")
+		if key != "value" {
+			mw.writeCompact(firstData[key])
+			continue
+		}
+
+		mw.writeString("[")
+		if value, ok := firstData["value"]; ok && isJSONArray(value) {
+			mw.writeItems(value)
+		} else {
+			mw.writeItem(firstResponse.Body)
+		}
+		nextURL := nextLinkFor(firstData, firstResponse.Headers, originalURL)
+		for pageCount := 1; nextURL != "" && pageCount < maxPages && !mw.full() && mw.err == nil; pageCount++ {
+			body, fields, headers, ok := fetchPage(ctx, client, opts, originalURL, nextURL, maxResponseSize)
+			if !ok {
+				break
+			}
+			mw.page(body)
+			if value, ok := fields["value"]; ok && isJSONArray(value) {
+				mw.writeItems(value)
+			}
+			nextURL = nextLinkFor(fields, headers, originalURL)
+		}
+		mw.writeString("]")
+	}
+	mw.writeString("}")
+	return mw.items, mw.err
+}
+
+// fetchPage requests one next page and returns its body and decoded top-level
+// fields. It reports false when the page should end pagination: a
+// cross-origin or invalid link, a failed request, or a body that is not a
+// JSON object.
+func fetchPage(ctx context.Context, client *http.Client, opts RequestOptions, originalURL *url.URL, nextURL string, maxResponseSize int64) ([]byte, map[string]json.RawMessage, http.Header, bool) {
+	nextURLParsed, err := url.Parse(nextURL)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+	resolvedURL := originalURL.ResolveReference(nextURLParsed)
+
+	// SECURITY: Enforce same-origin to prevent SSRF via server-controlled nextLink.
+	// An attacker could inject a cross-origin URL to exfiltrate the bearer token.
+	if resolvedURL.Scheme != originalURL.Scheme || resolvedURL.Host != originalURL.Host {
+		return nil, nil, nil, false
+	}
+
+	resolvedURLStr := resolvedURL.String()
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "> Following pagination link: %s\n", RedactSecrets(RedactURL(resolvedURLStr)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, opts.Method, resolvedURLStr, nil)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+	for key, values := range opts.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if !opts.SkipAuth && opts.Scope != "" && opts.TokenProvider != nil {
+		token, err := opts.TokenProvider.GetToken(ctx, opts.Scope)
+		if err != nil {
+			return nil, nil, nil, false
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, nil, nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, nil, nil, false
+	}
+	return body, fields, resp.Header, true
+}
+
+// isJSONArray reports whether raw holds a JSON array.
+func isJSONArray(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// mergeWriter writes the merged document, keeping the first write error and
+// counting value items against the --max-items cap.
+type mergeWriter struct {
+	w        io.Writer
+	maxItems int
+	items    int
+	onPage   func([]byte) error
+	scratch  bytes.Buffer
+	err      error
+}
+
+// page hands a page body to the OnPage callback, if any.
+func (m *mergeWriter) page(body []byte) {
+	if m.onPage != nil && m.err == nil {
+		m.err = m.onPage(body)
+	}
+}
+
+func (m *mergeWriter) full() bool { return m.maxItems > 0 && m.items >= m.maxItems }
+
+func (m *mergeWriter) writeString(s string) {
+	if m.err == nil {
+		_, m.err = io.WriteString(m.w, s)
+	}
+}
+
+func (m *mergeWriter) writeJSON(v any) {
+	if m.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		m.err = err
+		return
+	}
+	_, m.err = m.w.Write(data)
+}
+
+// writeCompact writes raw with insignificant whitespace removed.
+func (m *mergeWriter) writeCompact(raw []byte) {
+	if m.err != nil {
+		return
+	}
+	m.scratch.Reset()
+	if m.err = json.Compact(&m.scratch, raw); m.err == nil {
+		_, m.err = m.w.Write(m.scratch.Bytes())
+	}
+}
+
+// writeItems writes each element of the JSON array raw as a value item.
+func (m *mergeWriter) writeItems(raw json.RawMessage) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return
+	}
+	for _, item := range items {
+		if m.full() || m.err != nil {
+			return
+		}
+		m.writeItem(item)
+	}
+}
+
+func (m *mergeWriter) writeItem(raw []byte) {
+	if m.items > 0 {
+		m.writeString(",")
+	}
+	m.writeCompact(raw)
+	m.items++
+}
@@ -1,10 +1,14 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -180,3 +184,150 @@ func TestPagination_NoPagination(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, 1, len(valueArray), "Should have only first page items")
 }
+
+// newPagedServer serves pages of two items each, forever, and counts requests.
+func newPagedServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"value":    []any{requests*2 - 1, requests * 2},
+			"nextLink": srv.URL + "/?page=" + strconv.Itoa(requests+1),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPagination_MaxPages(t *testing.T) {
+	srv, requests := newPagedServer(t)
+	client := NewClient(nil, false, 30*time.Second)
+
+	resp, err := client.Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, MaxPages: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, *requests)
+	assert.JSONEq(t, `{"value":[1,2,3,4,5,6]}`, string(resp.Body))
+}
+
+func TestPagination_MaxItems(t *testing.T) {
+	srv, requests := newPagedServer(t)
+	client := NewClient(nil, false, 30*time.Second)
+
+	resp, err := client.Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, MaxItems: 3,
+	})
+	require.NoError(t, err)
+	// The second page is needed to reach three items; no third page is fetched.
+	assert.Equal(t, 2, *requests)
+	assert.JSONEq(t, `{"value":[1,2,3]}`, string(resp.Body))
+}
+
+func TestPagination_KeepsItemsAsReceived(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			_, _ = w.Write([]byte(`{"count":2,"value":[{"z":12345678901234567890,"a":1}],"nextLink":"` + srv.URL + `/?page=2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"value":[ {"b": 2} ]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true,
+	})
+	require.NoError(t, err)
+	// Items are copied as raw JSON: key order and large numbers survive.
+	assert.Equal(t, `{"count":2,"value":[{"z":12345678901234567890,"a":1},{"b":2}]}`, string(resp.Body))
+}
+
+func TestPagination_ContinuationToken(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("continuationToken") {
+		case "":
+			w.Header().Set("x-ms-continuationtoken", "a=b&2")
+			_, _ = w.Write([]byte(`{"count":1,"value":[{"id":1}]}`))
+		case "a=b&2":
+			w.Header().Set("x-ms-continuationtoken", "3")
+			_, _ = w.Write([]byte(`{"count":1,"value":[{"id":2}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"count":1,"value":[{"id":3}]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL + "/_apis/projects?api-version=7.1", SkipAuth: true, Paginate: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"api-version=7.1",
+		"api-version=7.1&continuationToken=a%3Db%262",
+		"api-version=7.1&continuationToken=3",
+	}, queries, "each token is set on the original URL")
+	// The per-page count would be wrong for the merged listing.
+	assert.Equal(t, `{"value":[{"id":1},{"id":2},{"id":3}]}`, string(resp.Body))
+}
+
+func TestPagination_PageSink(t *testing.T) {
+	srv, requests := newPagedServer(t)
+	var sink bytes.Buffer
+
+	resp, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, MaxPages: 3, PageSink: &sink,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, *requests)
+	assert.Nil(t, resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"value":[1,2,3,4,5,6]}`, sink.String())
+}
+
+func TestPagination_OnPage(t *testing.T) {
+	srv, _ := newPagedServer(t)
+	var pages []string
+
+	_, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, MaxPages: 2, PageSink: io.Discard,
+		OnPage: func(body []byte) error {
+			pages = append(pages, string(body))
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	assert.Contains(t, pages[0], `"value":[1,2]`)
+	assert.Contains(t, pages[1], `"value":[3,4]`)
+
+	_, err = NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, PageSink: io.Discard,
+		OnPage: func([]byte) error { return errors.New("disk full") },
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+}
+
+func TestPagination_PageSinkSkipsNonObjectPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[1,2]`))
+	}))
+	t.Cleanup(srv.Close)
+	var sink bytes.Buffer
+
+	resp, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+		Method: "GET", URL: srv.URL, SkipAuth: true, Paginate: true, PageSink: &sink,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[1,2]`, string(resp.Body))
+	assert.Zero(t, sink.Len())
+}
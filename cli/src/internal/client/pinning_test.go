@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePinnedPublicKeys(t *testing.T) {
+	const pin = "sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	pins, err := ParsePinnedPublicKeys(pin + "; " + pin)
+	require.NoError(t, err)
+	assert.Len(t, pins, 2)
+	assert.Len(t, pins[0], 32)
+
+	for _, bad := range []string{"", ";", "md5//abc", "sha256//not-base64!", "sha256//YWJj"} {
+		_, err := ParsePinnedPublicKeys(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestClient_Execute_PinnedPublicKey(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	goodPin := PublicKeyPin(server.Certificate())
+
+	// The test server is self-signed, so chain verification is skipped; the
+	// pin check still runs.
+	client := NewClient(nil, true, 5*time.Second)
+	execute := func(pin string) error {
+		pins, err := ParsePinnedPublicKeys(pin)
+		require.NoError(t, err)
+		_, err = client.Execute(context.Background(), RequestOptions{
+			Method:           "GET",
+			URL:              server.URL,
+			SkipAuth:         true,
+			PinnedPublicKeys: pins,
+		})
+		return err
+	}
+
+	require.NoError(t, execute(goodPin))
+	require.NoError(t, execute("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=;"+goodPin))
+
+	err := execute("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pinned public key mismatch")
+	assert.Contains(t, err.Error(), goodPin)
+}
+
+func TestClient_Execute_PinnedPublicKeyRequiresHTTPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pins, err := ParsePinnedPublicKeys("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	require.NoError(t, err)
+	_, err = NewClient(nil, false, 5*time.Second).Execute(context.Background(), RequestOptions{
+		Method:           "GET",
+		URL:              server.URL,
+		SkipAuth:         true,
+		PinnedPublicKeys: pins,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires an https URL")
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/jongio/azd-core/httpclient"
+)
+
+// AuxiliaryAuthorizationHeader carries bearer tokens for additional tenants on
+// cross-tenant Azure Resource Manager requests (see --aux-tenant).
+const AuxiliaryAuthorizationHeader = "x-ms-authorization-auxiliary"
+
+// redactedValue is the placeholder written in place of a short secret.
+const redactedValue = "***REDACTED***"
+
+// extraSensitiveHeaders lists headers that carry credentials but are not in
+// azd-core's redaction list. Names are lower case.
+var extraSensitiveHeaders = map[string]bool{
+	AuxiliaryAuthorizationHeader: true,
+	"proxy-authorization":        true,
+}
+
+// RedactSensitiveHeader replaces sensitive HTTP header values with redacted
+// placeholders. It extends azd-core's list with the headers azd rest itself
+// can send, such as the auxiliary tenant authorization header.
+func RedactSensitiveHeader(key, value string) string {
+	if !extraSensitiveHeaders[strings.ToLower(key)] {
+		return httpclient.RedactSensitiveHeader(key, value)
+	}
+	// Each comma separated entry may be its own bearer token, so redact them
+	// individually rather than exposing the head of the first and the tail of
+	// the last.
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if token, ok := cutBearer(part); ok {
+			parts[i] = "Bearer " + httpclient.RedactToken(token)
+			continue
+		}
+		parts[i] = redactedValue
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cutBearer returns the token from a "Bearer <token>" value.
+func cutBearer(value string) (string, bool) {
+	if len(value) > len("bearer ") && strings.EqualFold(value[:len("bearer ")], "bearer ") {
+		return value[len("bearer "):], true
+	}
+	return "", false
+}
+
+// isSecretHeader reports whether key is one of the named secret headers.
+func isSecretHeader(key string, secretHeaders []string) bool {
+	for _, name := range secretHeaders {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
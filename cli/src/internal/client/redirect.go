@@ -0,0 +1,172 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+)
+
+// RedirectPolicy decides which redirect targets the client may follow. Every
+// hop is checked before it is requested, so a public endpoint cannot bounce a
+// request into a cloud metadata service or a private network after the
+// initial URL was accepted.
+//
+// Metadata endpoints such as 169.254.169.254 are always refused. Loopback,
+// private, and link-local targets are refused unless AllowPrivateNetworks is
+// set or the original request already went to such an address, so a local
+// development server can still redirect to itself.
+type RedirectPolicy struct {
+	// AllowHosts limits redirect targets to hosts matching these patterns,
+	// using the rules of HostMatchesPattern. Empty allows any host.
+	AllowHosts []string
+	// AllowPrivateNetworks permits redirects from a public host to loopback,
+	// private, and link-local addresses.
+	AllowPrivateNetworks bool
+	// LocationTrusted sends credential headers to redirect targets on a
+	// different origin, like curl --location-trusted. By default they are
+	// removed so a redirect cannot leak a token to another host.
+	LocationTrusted bool
+	// ReacquireToken requests a new token when a redirect that still carries
+	// the Authorization header lands on an Azure host with a different scope.
+	// By default such a redirect is refused so a token is never replayed to
+	// a service it was not issued for.
+	ReacquireToken bool
+}
+
+// crossOriginHeaders lists the credential headers removed when a redirect
+// leaves the original origin. Headers resolved from the OS keychain are
+// removed as well.
+var crossOriginHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	AuxiliaryAuthorizationHeader,
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"Api-Key",
+	"Ocp-Apim-Subscription-Key",
+	"X-Functions-Key",
+}
+
+// checkHop validates one redirect from the original request URL to target.
+// Hostnames are resolved and every address is checked; a name that does not
+// resolve is refused.
+func (p RedirectPolicy) checkHop(original, target *url.URL) error {
+	host := target.Hostname()
+	if len(p.AllowHosts) > 0 && !HostMatchesPattern(host, p.AllowHosts) {
+		return fmt.Errorf("redirect to %s blocked: host is not in the allowlist", host)
+	}
+	if err := azdext.NewMCPSecurityPolicy().BlockMetadataEndpoints().CheckURL(target.String()); err != nil {
+		return fmt.Errorf("redirect to %s blocked: %w", host, err)
+	}
+	if p.AllowPrivateNetworks || isPrivateURL(original) {
+		return nil
+	}
+	if err := azdext.NewMCPSecurityPolicy().BlockPrivateNetworks().CheckURL(target.String()); err != nil {
+		return fmt.Errorf("redirect to %s blocked: %w", host, err)
+	}
+	return nil
+}
+
+// sameOrigin reports whether a and b share scheme, host, and port.
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// applyHopHeaders sets the credential headers for a redirect hop. A hop that
+// stays on the original origin is left alone. On any other origin the
+// credential headers are removed, or, with LocationTrusted, restored from the
+// original request because net/http drops some of them across domains.
+func (p RedirectPolicy) applyHopHeaders(req, original *http.Request, secretHeaders []string, verbose bool) {
+	if sameOrigin(original.URL, req.URL) {
+		return
+	}
+	names := append(append([]string{}, crossOriginHeaders...), secretHeaders...)
+	var dropped []string
+	for _, name := range names {
+		values := original.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if p.LocationTrusted {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+			continue
+		}
+		req.Header.Del(name)
+		dropped = append(dropped, http.CanonicalHeaderKey(name))
+	}
+	if verbose && len(dropped) > 0 {
+		fmt.Fprintf(os.Stderr, "> Redirected to %s; not sending %s (use --location-trusted to keep them)\n",
+			req.URL.Host, strings.Join(dropped, ", "))
+	}
+}
+
+// authorizeHop checks the bearer token on a redirect hop against the scope
+// detected for the new host. It applies only to tokens the client acquired
+// itself, and only when the hop still carries the Authorization header, that
+// is, on the same origin or with LocationTrusted. A host with no detected scope
+// is left to the caller's LocationTrusted choice.
+func (c *Client) authorizeHop(req *http.Request, opts RequestOptions) error {
+	if opts.SkipAuth || opts.Scope == "" || c.tokenProvider == nil || req.Header.Get("Authorization") == "" {
+		return nil
+	}
+	scope, err := DetectScope(req.URL.String())
+	if err != nil {
+		return fmt.Errorf("redirect to %s blocked: %w", req.URL.Host, err)
+	}
+	if scope == "" || strings.EqualFold(scope, opts.Scope) {
+		return nil
+	}
+	if !opts.RedirectPolicy.ReacquireToken {
+		return fmt.Errorf("redirect to %s blocked: it needs a token for %s but the request token is for %s (use --redirect-scope reacquire to request a new token)",
+			req.URL.Host, scope, opts.Scope)
+	}
+	token, err := c.tokenProvider.GetToken(req.Context(), scope)
+	if err != nil {
+		return fmt.Errorf("failed to get authentication token for redirect to %s: %w", req.URL.Host, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	// Auxiliary tenant tokens are issued for the original scope only.
+	req.Header.Del(AuxiliaryAuthorizationHeader)
+	return nil
+}
+
+// isPrivateURL reports whether u points at a loopback, private, or link-local
+// address.
+func isPrivateURL(u *url.URL) bool {
+	return azdext.NewMCPSecurityPolicy().BlockPrivateNetworks().CheckURL(u.String()) != nil
+}
+
+// HostMatchesPattern reports whether host matches any of patterns. Matching
+// is case insensitive. A pattern that begins with "*." matches any subdomain of
+// the remaining suffix, for example "*.vault.azure.net" matches
+// "kv.vault.azure.net" but not the bare "vault.azure.net". Any other pattern
+// must match the host exactly. Blank patterns are ignored.
+func HostMatchesPattern(host string, patterns []string) bool {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		p := strings.ToLower(strings.TrimSpace(pattern))
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(p, "*.") {
+			suffix := p[1:]
+			if len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-core/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestRedirectPolicy_CheckHop(t *testing.T) {
+	public := "https://20.1.2.3/start"
+	cases := []struct {
+		name     string
+		policy   RedirectPolicy
+		original string
+		target   string
+		blocked  bool
+	}{
+		{"public to public", RedirectPolicy{}, public, "https://20.1.2.4/next", false},
+		{"public to metadata", RedirectPolicy{}, public, "http://169.254.169.254/metadata/instance", true},
+		{"public to private", RedirectPolicy{}, public, "http://10.0.0.5/admin", true},
+		{"public to loopback", RedirectPolicy{}, public, "http://127.0.0.1:8080/", true},
+		{"public to private allowed", RedirectPolicy{AllowPrivateNetworks: true}, public, "http://10.0.0.5/admin", false},
+		{"metadata always blocked", RedirectPolicy{AllowPrivateNetworks: true}, public, "http://169.254.169.254/", true},
+		{"loopback to loopback", RedirectPolicy{}, "http://127.0.0.1:8080/a", "http://127.0.0.1:8081/b", false},
+		{"loopback to metadata", RedirectPolicy{}, "http://127.0.0.1:8080/a", "http://169.254.169.254/", true},
+		{"allowlist match", RedirectPolicy{AllowHosts: []string{"20.1.2.4"}}, public, "https://20.1.2.4/next", false},
+		{"allowlist miss", RedirectPolicy{AllowHosts: []string{"20.1.2.3"}}, public, "https://20.1.2.4/next", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.checkHop(mustParseURL(t, tc.original), mustParseURL(t, tc.target))
+			if tc.blocked {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "redirect to")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_Execute_RedirectToMetadataBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/metadata/identity/oauth2/token", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(nil, false, 5*time.Second)
+	_, err := client.Execute(context.Background(), RequestOptions{
+		Method:          "GET",
+		URL:             server.URL,
+		SkipAuth:        true,
+		FollowRedirects: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "169.254.169.254")
+}
+
+func TestClient_Execute_RedirectOutsideAllowlistBlocked(t *testing.T) {
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer final.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, final.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(nil, false, 5*time.Second)
+	_, err := client.Execute(context.Background(), RequestOptions{
+		Method:          "GET",
+		URL:             server.URL,
+		SkipAuth:        true,
+		FollowRedirects: true,
+		RedirectPolicy:  RedirectPolicy{AllowHosts: []string{"localhost"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allowlist")
+}
+
+func TestHostMatchesPattern(t *testing.T) {
+	patterns := []string{"management.azure.com", "*.vault.azure.net", " "}
+	assert.True(t, HostMatchesPattern("Management.Azure.com", patterns))
+	assert.True(t, HostMatchesPattern("kv.vault.azure.net", patterns))
+	assert.False(t, HostMatchesPattern("vault.azure.net", patterns))
+	assert.False(t, HostMatchesPattern("", patterns))
+}
+
+// redirectHeaderServers returns a server that records the headers it receives
+// and a second server, on another origin, that redirects to it.
+func redirectHeaderServers(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(final.Close)
+	start := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, final.URL+"/final", http.StatusFound)
+	}))
+	t.Cleanup(start.Close)
+	return start, &got
+}
+
+func TestClient_Execute_CrossOriginRedirectDropsCredentials(t *testing.T) {
+	start, got := redirectHeaderServers(t)
+
+	client := NewClient(&auth.MockTokenProvider{Token: "secret-token"}, false, 5*time.Second)
+	_, err := client.Execute(context.Background(), RequestOptions{
+		Method:          "GET",
+		URL:             start.URL,
+		Scope:           "https://management.azure.com/.default",
+		FollowRedirects: true,
+		Headers: http.Header{
+			"X-Api-Key":     {"key-123"},
+			"X-From-Vault":  {"vault-value"},
+			"X-Request-Tag": {"keep-me"},
+		},
+		SecretHeaders: []string{"X-From-Vault"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, got.Get("Authorization"))
+	assert.Empty(t, got.Get("X-Api-Key"))
+	assert.Empty(t, got.Get("X-From-Vault"))
+	assert.Equal(t, "keep-me", got.Get("X-Request-Tag"))
+}
+
+func TestClient_Execute_LocationTrustedKeepsCredentials(t *testing.T) {
+	start, got := redirectHeaderServers(t)
+
+	client := NewClient(&auth.MockTokenProvider{Token: "secret-token"}, false, 5*time.Second)
+	_, err := client.Execute(context.Background(), RequestOptions{
+		Method:          "GET",
+		URL:             start.URL,
+		Scope:           "https://management.azure.com/.default",
+		FollowRedirects: true,
+		Headers:         http.Header{"X-Api-Key": {"key-123"}},
+		RedirectPolicy:  RedirectPolicy{LocationTrusted: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-token", got.Get("Authorization"))
+	assert.Equal(t, "key-123", got.Get("X-Api-Key"))
+}
+
+func TestClient_Execute_SameOriginRedirectKeepsCredentials(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/final" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+			return
+		}
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(&auth.MockTokenProvider{Token: "secret-token"}, false, 5*time.Second)
+	_, err := client.Execute(context.Background(), RequestOptions{
+		Method:          "GET",
+		URL:             server.URL + "/start",
+		Scope:           "https://management.azure.com/.default",
+		FollowRedirects: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-token", got.Get("Authorization"))
+}
+
+// scopedTokenProvider returns a token that names the scope it was issued for.
+type scopedTokenProvider struct{}
+
+func (scopedTokenProvider) GetToken(_ context.Context, scope string) (string, error) {
+	return "token-for-" + scope, nil
+}
+
+func newHopRequest(t *testing.T, rawURL string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), "GET", rawURL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token-for-arm")
+	req.Header.Set(AuxiliaryAuthorizationHeader, "Bearer aux")
+	return req
+}
+
+func TestClient_AuthorizeHop(t *testing.T) {
+	const armScope = "https://management.azure.com/.default"
+	client := NewClient(scopedTokenProvider{}, false, 5*time.Second)
+
+	t.Run("same scope keeps token", func(t *testing.T) {
+		req := newHopRequest(t, "https://management.azure.com/subscriptions/x")
+		require.NoError(t, client.authorizeHop(req, RequestOptions{Scope: armScope}))
+		assert.Equal(t, "Bearer token-for-arm", req.Header.Get("Authorization"))
+	})
+
+	t.Run("different scope refused by default", func(t *testing.T) {
+		req := newHopRequest(t, "https://myvault.vault.azure.net/secrets/x")
+		err := client.authorizeHop(req, RequestOptions{Scope: armScope})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "https://vault.azure.net/.default")
+		assert.Contains(t, err.Error(), "--redirect-scope reacquire")
+	})
+
+	t.Run("different scope reacquired", func(t *testing.T) {
+		req := newHopRequest(t, "https://myvault.vault.azure.net/secrets/x")
+		opts := RequestOptions{Scope: armScope, RedirectPolicy: RedirectPolicy{ReacquireToken: true}}
+		require.NoError(t, client.authorizeHop(req, opts))
+		assert.Equal(t, "Bearer token-for-https://vault.azure.net/.default", req.Header.Get("Authorization"))
+		assert.Empty(t, req.Header.Get(AuxiliaryAuthorizationHeader))
+	})
+
+	t.Run("unknown host left to location-trusted", func(t *testing.T) {
+		req := newHopRequest(t, "https://example.com/x")
+		require.NoError(t, client.authorizeHop(req, RequestOptions{Scope: armScope}))
+		assert.Equal(t, "Bearer token-for-arm", req.Header.Get("Authorization"))
+	})
+
+	t.Run("dropped token not checked", func(t *testing.T) {
+		req := newHopRequest(t, "https://myvault.vault.azure.net/secrets/x")
+		req.Header.Del("Authorization")
+		require.NoError(t, client.authorizeHop(req, RequestOptions{Scope: armScope}))
+		assert.Empty(t, req.Header.Get("Authorization"))
+	})
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// resolvedAddrsKey is the context key for addresses pinned by
+// WithResolvedAddrs.
+type resolvedAddrsKey struct{}
+
+// resolvedAddrs pins the addresses dialed for one host.
+type resolvedAddrs struct {
+	host  string
+	addrs []string
+}
+
+// WithResolvedAddrs returns a context whose requests dial only addrs when
+// connecting to host. A caller that has checked a host's addresses against a
+// security policy uses it so the connection cannot go to a different address
+// returned by a later lookup (DNS rebinding). Other hosts, such as a proxy,
+// are dialed as usual.
+func WithResolvedAddrs(ctx context.Context, host string, addrs []string) context.Context {
+	if len(addrs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, resolvedAddrsKey{}, resolvedAddrs{host: host, addrs: addrs})
+}
+
+// dialResolved dials address, substituting the pinned addresses from ctx
+// when its host matches. Each pinned address is tried in order.
+func dialResolved(ctx context.Context, dialer *net.Dialer, network, address string) (net.Conn, error) {
+	pinned, ok := ctx.Value(resolvedAddrsKey{}).(resolvedAddrs)
+	if !ok {
+		return dialer.DialContext(ctx, network, address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || !strings.EqualFold(host, pinned.host) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	var errs []error
+	for _, addr := range pinned.addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResolvedAddrs_DialsPinnedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	// "pinned.invalid" never resolves; the request succeeds only because
+	// the dialer uses the pinned loopback address.
+	ctx := WithResolvedAddrs(context.Background(), "pinned.invalid", []string{u.Hostname()})
+	resp, err := NewClient(nil, false, 0).Execute(ctx, RequestOptions{
+		Method: http.MethodGet, URL: "http://pinned.invalid:" + u.Port(), SkipAuth: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pinned.invalid:"+u.Port(), string(resp.Body))
+}
+
+func TestWithResolvedAddrs_OtherHostsUnaffected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := WithResolvedAddrs(context.Background(), "elsewhere.invalid", []string{"192.0.2.1"})
+	resp, err := NewClient(nil, false, 0).Execute(ctx, RequestOptions{
+		Method: http.MethodGet, URL: srv.URL, SkipAuth: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(resp.Body))
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryStats records how the attempts of one request went. Execute fills it
+// in when RequestOptions.Stats is set, whether or not the request succeeds.
+type RetryStats struct {
+	// Attempts counts the requests sent, including the first.
+	Attempts int
+	// Backoff is the total time spent waiting between attempts.
+	Backoff time.Duration
+	// Statuses holds the status of each attempt in order, or 0 for an
+	// attempt that got no response.
+	Statuses []int
+	// RateLimit holds the last value seen of each rate-limit header, such as
+	// Retry-After and x-ms-ratelimit-remaining-subscription-reads.
+	RateLimit http.Header
+}
+
+// rateLimitHeaderPrefixes are the lower-cased prefixes of headers that report
+// throttling state: ARM's x-ms-ratelimit-*, the IETF RateLimit-* draft, and
+// the common X-RateLimit-*.
+var rateLimitHeaderPrefixes = []string{"x-ms-ratelimit-", "ratelimit", "x-ratelimit-"}
+
+// recordAttempt adds one attempt and its response, or nil when it got none.
+func (s *RetryStats) recordAttempt(resp *http.Response) {
+	if s == nil {
+		return
+	}
+	s.Attempts++
+	if resp == nil {
+		s.Statuses = append(s.Statuses, 0)
+		return
+	}
+	s.Statuses = append(s.Statuses, resp.StatusCode)
+	for key, values := range resp.Header {
+		lower := strings.ToLower(key)
+		if lower != "retry-after" && !hasAnyPrefix(lower, rateLimitHeaderPrefixes) {
+			continue
+		}
+		if s.RateLimit == nil {
+			s.RateLimit = http.Header{}
+		}
+		s.RateLimit[key] = append([]string(nil), values...)
+	}
+}
+
+// recordBackoff adds a wait between attempts.
+func (s *RetryStats) recordBackoff(d time.Duration) {
+	if s != nil {
+		s.Backoff += d
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecute_RecordsRetryStats(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("x-ms-ratelimit-remaining-subscription-reads", "11999")
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("x-ms-ratelimit-remaining-subscription-reads", "11998")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	stats := &RetryStats{}
+	resp, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{
+		Method: http.MethodGet, URL: srv.URL, SkipAuth: true, Stats: stats,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, stats.Attempts)
+	assert.Equal(t, time.Second, stats.Backoff)
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusOK}, stats.Statuses)
+	assert.Equal(t, "11998", stats.RateLimit.Get("x-ms-ratelimit-remaining-subscription-reads"))
+	assert.Equal(t, "1", stats.RateLimit.Get("Retry-After"))
+	assert.Empty(t, stats.RateLimit.Get("Content-Type"))
+}
+
+func TestRetryStats_NoResponse(t *testing.T) {
+	stats := &RetryStats{}
+	stats.recordAttempt(nil)
+	assert.Equal(t, []int{0}, stats.Statuses)
+
+	var nilStats *RetryStats
+	nilStats.recordAttempt(nil)
+	nilStats.recordBackoff(time.Second)
+}
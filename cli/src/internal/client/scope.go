@@ -0,0 +1,49 @@
+package client
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jongio/azd-core/auth"
+)
+
+// extraScopeSuffixes maps host suffixes that azd-core's scope detection does
+// not cover to their OAuth scopes. Entries here take precedence.
+var extraScopeSuffixes = map[string]string{
+	".ingest.monitor.azure.com":  "https://monitor.azure.com/.default",
+	".metrics.monitor.azure.com": "https://metrics.monitor.azure.com/.default",
+}
+
+// ARMHosts are the Azure Resource Manager hosts in the public and sovereign
+// clouds.
+var ARMHosts = []string{
+	"management.azure.com",
+	"management.usgovcloudapi.net",
+	"management.chinacloudapi.cn",
+}
+
+// IsARMHost reports whether host is an Azure Resource Manager host.
+func IsARMHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range ARMHosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectScope returns the OAuth scope for a URL, or "" when the host is not a
+// known Azure service. It extends auth.DetectScope with hosts azd-core does
+// not yet recognize.
+func DetectScope(rawURL string) (string, error) {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		host := strings.ToLower(parsed.Hostname())
+		for suffix, scope := range extraScopeSuffixes {
+			if strings.HasSuffix(host, suffix) {
+				return scope, nil
+			}
+		}
+	}
+	return auth.DetectScope(rawURL)
+}
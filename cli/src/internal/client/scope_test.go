@@ -0,0 +1,29 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectScope(t *testing.T) {
+	cases := map[string]string{
+		"https://my-dce-abcd.eastus-1.ingest.monitor.azure.com/dataCollectionRules/dcr-1/streams/Custom-T": "https://monitor.azure.com/.default",
+		"https://eastus.metrics.monitor.azure.com/subscriptions/x/metrics:getBatch":                        "https://metrics.monitor.azure.com/.default",
+		"https://management.azure.com/subscriptions":                                                       "https://management.azure.com/.default",
+		"https://example.com/": "",
+	}
+	for u, want := range cases {
+		got, err := DetectScope(u)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, u)
+	}
+}
+
+func TestIsARMHost(t *testing.T) {
+	assert.True(t, IsARMHost("management.azure.com"))
+	assert.True(t, IsARMHost("Management.UsGovCloudApi.net"))
+	assert.False(t, IsARMHost("graph.microsoft.com"))
+	assert.False(t, IsARMHost("management.azure.com.evil.example"))
+}
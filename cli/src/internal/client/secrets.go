@@ -0,0 +1,71 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+)
+
+// secretPattern finds one kind of secret in free text. When keep is set, the
+// first submatch (such as "AccountKey=") is kept and only the rest is replaced.
+type secretPattern struct {
+	re   *regexp.Regexp
+	keep bool
+}
+
+// secretPatterns lists the secret shapes RedactSecrets recognizes. Header
+// names alone cannot catch these: a token can arrive in a custom header, a
+// URL, an error message, or a response body echoed into verbose output.
+var secretPatterns = []secretPattern{
+	// PEM private keys, matched first so their base64 lines are removed whole.
+	{re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	// Bearer tokens of any shape.
+	{re: regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/-]{16,}=*`), keep: true},
+	// JSON Web Tokens: base64url header and payload, both starting with '{'.
+	{re: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)},
+	// Key-value secrets in connection strings, SAS query strings, and JSON.
+	// The key must start a word, so design=... or newPwd=... is left alone.
+	{re: regexp.MustCompile(`(?i)(\b(?:AccountKey|SharedAccessKey|SharedAccessSignature|AccessKey|ClientSecret|Password|Pwd|sig)\s*=\s*)[^;&\s"',]+`), keep: true},
+	{re: regexp.MustCompile(`(?i)("(?:accountKey|primaryKey|secondaryKey|primaryMasterKey|secondaryMasterKey|clientSecret|password|connectionString|access_token|refresh_token|id_token)"\s*:\s*")[^"]+`), keep: true},
+	// Azure storage and Cosmos DB account keys: 64 random bytes in base64.
+	{re: regexp.MustCompile(`\b[A-Za-z0-9+/]{86}==`)},
+	// Microsoft Entra client secrets, for example "abc8Q~...".
+	{re: regexp.MustCompile(`\b[A-Za-z0-9_~.-]{3}\dQ~[A-Za-z0-9_~.-]{31,34}\b`)},
+	// GitHub tokens.
+	{re: regexp.MustCompile(`\b(?:gh[pousr]|github_pat)_[A-Za-z0-9_]{30,}\b`)},
+}
+
+// RedactSecrets replaces JWTs, bearer tokens, connection string keys, account
+// keys, client secrets, and private keys found anywhere in s. It is applied to
+// verbose and diagnostic output, where a secret can appear in any header value
+// or echoed response text, not only in the headers redacted by name.
+func RedactSecrets(s string) string {
+	for _, p := range secretPatterns {
+		if p.keep {
+			s = p.re.ReplaceAllString(s, "${1}"+redactedValue)
+			continue
+		}
+		s = p.re.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
+
+// RedactHeaderValue redacts a header value for display. Known credential
+// headers are redacted by name; any other value is scanned by RedactSecrets.
+func RedactHeaderValue(key, value string) string {
+	if redacted := RedactSensitiveHeader(key, value); redacted != value {
+		return redacted
+	}
+	return RedactSecrets(value)
+}
+
+// RedactValues replaces every occurrence of the given literal values in s.
+// It covers secrets resolved at run time, such as workflow secret references,
+// whose shape RedactSecrets cannot recognize. Empty values are ignored.
+func RedactValues(s string, values []string) string {
+	for _, v := range values {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, redactedValue)
+		}
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	"github.com/jongio/azd-core/auth"
+	"golang.org/x/sync/singleflight"
+)
+
+// dedupedTokenProvider shares one in-flight GetToken call per scope among
+// concurrent callers.
+type dedupedTokenProvider struct {
+	tokens TokenProvider
+	group  singleflight.Group
+}
+
+// DedupeTokenRequests wraps tokens so concurrent requests for the same scope,
+// such as MCP tool calls or bulk fan-out racing an empty cache, make one
+// credential round-trip instead of one each. Later calls still go to tokens,
+// which caches the result.
+func DedupeTokenRequests(tokens TokenProvider) TokenProvider {
+	return &dedupedTokenProvider{tokens: tokens}
+}
+
+// GetToken returns a token for scope, joining a request already in flight.
+// The shared request is not canceled when one caller gives up; each caller
+// stops waiting when its own context ends.
+func (p *dedupedTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	ch := p.group.DoChan(scope, func() (any, error) {
+		return p.tokens.GetToken(context.WithoutCancel(ctx), scope)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// NewAzureTokenProvider returns azd-core's Azure token provider with
+// concurrent requests for one scope deduplicated and tokens refreshed before
+// they expire.
+func NewAzureTokenProvider() (TokenProvider, error) {
+	tokens, err := auth.NewAzureTokenProvider()
+	if err != nil {
+		return nil, err
+	}
+	return RefreshTokensEarly(DedupeTokenRequests(tokens)), nil
+}
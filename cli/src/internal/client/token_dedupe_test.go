@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedTokens blocks each GetToken until release is closed.
+type gatedTokens struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (g *gatedTokens) GetToken(_ context.Context, scope string) (string, error) {
+	g.calls.Add(1)
+	<-g.release
+	if g.err != nil {
+		return "", g.err
+	}
+	return "token-for-" + scope, nil
+}
+
+func TestDedupeTokenRequests_SharesInFlightCall(t *testing.T) {
+	tokens := &gatedTokens{release: make(chan struct{})}
+	tp := DedupeTokenRequests(tokens)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := tp.GetToken(context.Background(), "scope-a")
+			assert.NoError(t, err)
+			results[i] = token
+		}()
+	}
+	require.Eventually(t, func() bool { return tokens.calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(tokens.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), tokens.calls.Load())
+	for _, token := range results {
+		assert.Equal(t, "token-for-scope-a", token)
+	}
+}
+
+func TestDedupeTokenRequests_ScopesAreIndependent(t *testing.T) {
+	tokens := &gatedTokens{release: make(chan struct{})}
+	close(tokens.release)
+	tp := DedupeTokenRequests(tokens)
+
+	a, err := tp.GetToken(context.Background(), "scope-a")
+	require.NoError(t, err)
+	b, err := tp.GetToken(context.Background(), "scope-b")
+	require.NoError(t, err)
+	assert.Equal(t, "token-for-scope-a", a)
+	assert.Equal(t, "token-for-scope-b", b)
+	assert.Equal(t, int32(2), tokens.calls.Load())
+}
+
+func TestDedupeTokenRequests_Errors(t *testing.T) {
+	tokens := &gatedTokens{release: make(chan struct{}), err: errors.New("no accounts")}
+	close(tokens.release)
+
+	_, err := DedupeTokenRequests(tokens).GetToken(context.Background(), "scope")
+	assert.EqualError(t, err, "no accounts")
+}
+
+func TestDedupeTokenRequests_CallerCancel(t *testing.T) {
+	tokens := &gatedTokens{release: make(chan struct{})}
+	tp := DedupeTokenRequests(tokens)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := tp.GetToken(ctx, "scope")
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return tokens.calls.Load() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	// A caller that keeps waiting still gets the shared result.
+	waiting := make(chan string, 1)
+	go func() {
+		token, _ := tp.GetToken(context.Background(), "scope")
+		waiting <- token
+	}()
+	close(tokens.release)
+	assert.Equal(t, "token-for-scope", <-waiting)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshWindow is how close to expiry a cached token starts being
+	// refreshed in the background.
+	tokenRefreshWindow = 5 * time.Minute
+	// tokenMinValidity is the least time left for a cached token to be used
+	// without waiting for a new one.
+	tokenMinValidity = 30 * time.Second
+	// upstreamTokenSkew matches the azd-core provider's own cache, which keeps
+	// returning a token until it is this close to expiry.
+	upstreamTokenSkew = 2 * time.Minute
+	// tokenRefreshRetry spaces background attempts after a failure.
+	tokenRefreshRetry   = 30 * time.Second
+	tokenRefreshTimeout = 30 * time.Second
+)
+
+// cachedToken is a token with its expiry read from the JWT exp claim.
+type cachedToken struct {
+	token       string
+	expires     time.Time
+	refreshing  bool
+	nextRefresh time.Time
+}
+
+// refreshingTokenProvider hands out cached tokens and renews them in the
+// background as they near expiry, so a long run such as a many-page
+// --paginate or an MCP session never waits on a token mid-way.
+type refreshingTokenProvider struct {
+	tokens TokenProvider
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*cachedToken
+	// refreshes tracks background refreshes so tests can wait for them.
+	refreshes sync.WaitGroup
+}
+
+// RefreshTokensEarly wraps tokens so a token within a few minutes of expiry
+// is still returned immediately while a replacement is fetched in the
+// background. Tokens whose expiry cannot be read are not cached here.
+func RefreshTokensEarly(tokens TokenProvider) TokenProvider {
+	return &refreshingTokenProvider{tokens: tokens, now: time.Now, cache: make(map[string]*cachedToken)}
+}
+
+// GetToken returns a token for scope, from the cache when it has at least
+// tokenMinValidity left.
+func (p *refreshingTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	now := p.now()
+	p.mu.Lock()
+	if c, ok := p.cache[scope]; ok && now.Add(tokenMinValidity).Before(c.expires) {
+		if !c.refreshing && !now.Before(c.expires.Add(-tokenRefreshWindow)) && !now.Before(c.nextRefresh) {
+			c.refreshing = true
+			p.refreshes.Add(1)
+			go p.refresh(scope)
+		}
+		token := c.token
+		p.mu.Unlock()
+		return token, nil
+	}
+	p.mu.Unlock()
+
+	token, err := p.tokens.GetToken(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if expires, ok := tokenExpiry(token); ok {
+		p.cache[scope] = &cachedToken{token: token, expires: expires}
+	} else {
+		delete(p.cache, scope)
+	}
+	return token, nil
+}
+
+// refresh fetches a replacement for the cached token for scope. A failure
+// leaves the current token in place until it runs out.
+func (p *refreshingTokenProvider) refresh(scope string) {
+	defer p.refreshes.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+	token, err := p.tokens.GetToken(ctx, scope)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.cache[scope]
+	if !ok {
+		return
+	}
+	c.refreshing = false
+	expires, parsed := tokenExpiry(token)
+	switch {
+	case err != nil || !parsed:
+		c.nextRefresh = p.now().Add(tokenRefreshRetry)
+	case !expires.After(c.expires):
+		// The upstream cache handed back the same token. It fetches a new
+		// one once the token is inside its own skew, so try again then.
+		c.nextRefresh = c.expires.Add(-upstreamTokenSkew)
+	default:
+		p.cache[scope] = &cachedToken{token: token, expires: expires}
+	}
+}
+
+// tokenExpiry reads the exp claim of a JWT access token.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Int64()
+	if err != nil || exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(exp, 0), true
+}
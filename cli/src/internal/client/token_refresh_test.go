@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenAt returns an unsigned JWT that expires at exp.
+func testTokenAt(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, `{"exp":%d}`, exp.Unix()))
+	return "eyJhbGciOiJub25lIn0." + payload + ".sig"
+}
+
+// scriptedTokens returns the next queued token or error on each call.
+type scriptedTokens struct {
+	mu      sync.Mutex
+	calls   int
+	results []any
+}
+
+func (s *scriptedTokens) GetToken(context.Context, string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.results[min(s.calls, len(s.results)-1)]
+	s.calls++
+	if err, ok := r.(error); ok {
+		return "", err
+	}
+	return r.(string), nil
+}
+
+func (s *scriptedTokens) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newRefreshingProvider(tokens TokenProvider, now *time.Time) *refreshingTokenProvider {
+	p := RefreshTokensEarly(tokens).(*refreshingTokenProvider)
+	p.now = func() time.Time { return *now }
+	return p
+}
+
+func TestRefreshTokensEarly(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	first, second := testTokenAt(now.Add(time.Hour)), testTokenAt(now.Add(2*time.Hour))
+	tokens := &scriptedTokens{results: []any{first, second}}
+	p := newRefreshingProvider(tokens, &now)
+	ctx := context.Background()
+
+	got, err := p.GetToken(ctx, "scope")
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+
+	// Well before expiry the cached token is used with no refresh.
+	now = now.Add(30 * time.Minute)
+	got, _ = p.GetToken(ctx, "scope")
+	p.refreshes.Wait()
+	assert.Equal(t, first, got)
+	assert.Equal(t, 1, tokens.count())
+
+	// Inside the refresh window the caller still gets the cached token at
+	// once, and the replacement arrives in the background.
+	now = now.Add(26 * time.Minute)
+	got, _ = p.GetToken(ctx, "scope")
+	assert.Equal(t, first, got)
+	p.refreshes.Wait()
+	assert.Equal(t, 2, tokens.count())
+
+	got, _ = p.GetToken(ctx, "scope")
+	assert.Equal(t, second, got)
+}
+
+func TestRefreshTokensEarly_UpstreamStillCached(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	first, second := testTokenAt(now.Add(time.Hour)), testTokenAt(now.Add(2*time.Hour))
+	tokens := &scriptedTokens{results: []any{first, first, second}}
+	p := newRefreshingProvider(tokens, &now)
+	ctx := context.Background()
+
+	_, _ = p.GetToken(ctx, "scope")
+	now = now.Add(56 * time.Minute)
+	_, _ = p.GetToken(ctx, "scope")
+	p.refreshes.Wait()
+	assert.Equal(t, 2, tokens.count(), "the upstream cache returned the same token")
+
+	// No new attempt until the upstream cache lets the token go.
+	now = now.Add(time.Minute)
+	_, _ = p.GetToken(ctx, "scope")
+	p.refreshes.Wait()
+	assert.Equal(t, 2, tokens.count())
+
+	now = now.Add(time.Minute)
+	got, _ := p.GetToken(ctx, "scope")
+	assert.Equal(t, first, got)
+	p.refreshes.Wait()
+	assert.Equal(t, 3, tokens.count())
+	got, _ = p.GetToken(ctx, "scope")
+	assert.Equal(t, second, got)
+}
+
+func TestRefreshTokensEarly_RefreshFailureKeepsToken(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	first := testTokenAt(now.Add(time.Hour))
+	tokens := &scriptedTokens{results: []any{first, errors.New("network down")}}
+	p := newRefreshingProvider(tokens, &now)
+	ctx := context.Background()
+
+	_, _ = p.GetToken(ctx, "scope")
+	now = now.Add(57 * time.Minute)
+	got, err := p.GetToken(ctx, "scope")
+	p.refreshes.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+
+	// Once the token is nearly expired the caller waits for a new one and
+	// sees the error.
+	now = now.Add(2*time.Minute + 45*time.Second)
+	_, err = p.GetToken(ctx, "scope")
+	assert.EqualError(t, err, "network down")
+}
+
+func TestRefreshTokensEarly_OpaqueTokensPassThrough(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tokens := &scriptedTokens{results: []any{"opaque-token"}}
+	p := newRefreshingProvider(tokens, &now)
+
+	for range 3 {
+		got, err := p.GetToken(context.Background(), "scope")
+		require.NoError(t, err)
+		assert.Equal(t, "opaque-token", got)
+	}
+	assert.Equal(t, 3, tokens.count())
+}
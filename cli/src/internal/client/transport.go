@@ -0,0 +1,236 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOptions tunes the connection pool shared by every Client in the
+// process. Zero fields take the defaults from DefaultTransportOptions.
+type TransportOptions struct {
+	// MaxIdleConns caps idle keep-alive connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle keep-alive connections to one host. Go's
+	// default of 2 makes concurrent requests to the same host reconnect.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps all connections to one host; zero means no cap.
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	TLSHandshakeTimeout time.Duration
+	// ConnectTimeout bounds establishing a TCP connection, including the
+	// fallback from IPv6 to IPv4 addresses.
+	ConnectTimeout time.Duration
+	// Network restricts dialing to "tcp4" or "tcp6". Empty dials both
+	// families, falling back between them as Go's dialer does (happy
+	// eyeballs).
+	Network string
+}
+
+// DefaultTransportOptions returns the pool settings used when none are
+// configured. MaxIdleConnsPerHost matches the bulk concurrency cap, so every
+// in-flight request can keep its connection for the next one.
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ConnectTimeout:      30 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields from DefaultTransportOptions.
+func (o TransportOptions) withDefaults() TransportOptions {
+	d := DefaultTransportOptions()
+	if o.MaxIdleConns == 0 {
+		o.MaxIdleConns = d.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost == 0 {
+		o.MaxIdleConnsPerHost = d.MaxIdleConnsPerHost
+	}
+	if o.IdleConnTimeout == 0 {
+		o.IdleConnTimeout = d.IdleConnTimeout
+	}
+	if o.TLSHandshakeTimeout == 0 {
+		o.TLSHandshakeTimeout = d.TLSHandshakeTimeout
+	}
+	if o.ConnectTimeout == 0 {
+		o.ConnectTimeout = d.ConnectTimeout
+	}
+	return o
+}
+
+// transports holds one *http.Transport per TLS verification mode, so pages,
+// retries, repeats, and bulk requests reuse warm connections instead of each
+// Client dialing and handshaking again.
+var transports = struct {
+	sync.Mutex
+	opts   TransportOptions
+	byMode map[bool]*http.Transport
+	pinned map[pinnedKey]http.RoundTripper
+}{opts: DefaultTransportOptions()}
+
+// pinnedKey identifies a pinned copy of a base transport.
+type pinnedKey struct {
+	base *http.Transport
+	pins string
+}
+
+// ConfigureTransport sets the pool settings for the shared transports. It
+// drops transports built with earlier settings, so call it before sending
+// requests.
+func ConfigureTransport(opts TransportOptions) {
+	transports.Lock()
+	defer transports.Unlock()
+	opts = opts.withDefaults()
+	if opts == transports.opts {
+		return
+	}
+	for _, t := range transports.byMode {
+		t.CloseIdleConnections()
+	}
+	for k, t := range transports.pinned {
+		t.(*http.Transport).CloseIdleConnections()
+		delete(transports.pinned, k)
+	}
+	transports.opts = opts
+	transports.byMode = nil
+}
+
+// sharedTransport returns the process-wide transport for the given TLS
+// verification mode, building it on first use.
+func sharedTransport(insecure bool) *http.Transport {
+	transports.Lock()
+	defer transports.Unlock()
+	if t, ok := transports.byMode[insecure]; ok {
+		return t
+	}
+	if transports.byMode == nil {
+		transports.byMode = make(map[bool]*http.Transport)
+	}
+	t := newTransport(insecure, transports.opts)
+	transports.byMode[insecure] = t
+	return t
+}
+
+// newTransport builds a transport with the given pool settings.
+func newTransport(insecure bool, opts TransportOptions) *http.Transport {
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: insecure, //nolint:gosec // G402: InsecureSkipVerify is intentionally configurable
+		},
+		// Use proxy from environment variables (HTTP_PROXY, HTTPS_PROXY, NO_PROXY)
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if opts.Network != "" {
+				network = opts.Network
+			}
+			return dialResolved(ctx, dialer, network, address)
+		},
+		MaxResponseHeaderBytes: MaxHeaderSizeLimit,
+		MaxIdleConns:           opts.MaxIdleConns,
+		MaxIdleConnsPerHost:    opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:        opts.MaxConnsPerHost,
+		IdleConnTimeout:        opts.IdleConnTimeout,
+		TLSHandshakeTimeout:    opts.TLSHandshakeTimeout,
+	}
+}
+
+// PooledTransport returns the process-wide transport that verifies TLS, with
+// the default pool settings unless ConfigureTransport changed them. A
+// long-lived process such as the azd rest daemon sends forwarded requests
+// through it, so its connections stay warm between CLI runs.
+func PooledTransport() http.RoundTripper {
+	return sharedTransport(false)
+}
+
+// NewForwardingClient returns a Client like NewClient whose requests go
+// through the RoundTripper that forward wraps around the shared transport,
+// such as one that sends them through the azd rest daemon. Only requests the
+// forwarder would send the same way are forwarded: with insecure set, or pool
+// settings other than DefaultTransportOptions, the client is a plain one, and
+// pinned requests, requests through a proxy, and requests whose addresses are
+// pinned by WithResolvedAddrs use the shared transport.
+func NewForwardingClient(tokenProvider TokenProvider, insecure bool, timeout time.Duration, forward func(local http.RoundTripper) http.RoundTripper) *Client {
+	c := NewClient(tokenProvider, insecure, timeout)
+	transports.Lock()
+	defaults := transports.opts == DefaultTransportOptions()
+	transports.Unlock()
+	if insecure || !defaults {
+		return c
+	}
+	local := c.httpClient.Transport.(*http.Transport)
+	c.httpClient.Transport = &forwardingTransport{forward: forward(local), local: local}
+	return c
+}
+
+// forwardingTransport sends requests through forward, except those that
+// depend on settings of this process, which go through local.
+type forwardingTransport struct {
+	forward http.RoundTripper
+	local   *http.Transport
+}
+
+func (t *forwardingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, pinned := req.Context().Value(resolvedAddrsKey{}).(resolvedAddrs); pinned {
+		return t.local.RoundTrip(req)
+	}
+	if proxy, err := t.local.Proxy(req); err != nil || proxy != nil {
+		return t.local.RoundTrip(req)
+	}
+	return t.forward.RoundTrip(req)
+}
+
+// NewTokenExchangeClient returns an *http.Client for a token exchange a
+// request makes with its own host, such as a container registry's. It sends
+// through the request's shared transport, with its TLS verification mode and
+// pinned public keys, and never follows a redirect, so the exchange cannot
+// reach a host the request itself may not.
+func NewTokenExchangeClient(opts RequestOptions, timeout time.Duration) (*http.Client, error) {
+	var transport http.RoundTripper = sharedTransport(opts.Insecure)
+	if len(opts.PinnedPublicKeys) > 0 {
+		pinned, err := sharedPinnedTransport(transport, opts.PinnedPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		transport = pinned
+	}
+	return &http.Client{
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}, nil
+}
+
+// sharedPinnedTransport returns the pinned copy of base for pins, building
+// it on first use, so repeated pinned requests share a pool as well.
+func sharedPinnedTransport(base http.RoundTripper, pins [][]byte) (http.RoundTripper, error) {
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return pinTransport(base, pins)
+	}
+	key := pinnedKey{base: t}
+	for _, pin := range pins {
+		key.pins += hex.EncodeToString(pin) + ";"
+	}
+	transports.Lock()
+	defer transports.Unlock()
+	if pinned, ok := transports.pinned[key]; ok {
+		return pinned, nil
+	}
+	pinned, err := pinTransport(t, pins)
+	if err != nil {
+		return nil, err
+	}
+	if transports.pinned == nil {
+		transports.pinned = make(map[pinnedKey]http.RoundTripper)
+	}
+	transports.pinned[key] = pinned
+	return pinned, nil
+}
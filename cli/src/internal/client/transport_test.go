@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetTransports restores the default pool settings after a test.
+func resetTransports(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { ConfigureTransport(DefaultTransportOptions()) })
+}
+
+// countingServer returns a test server and a counter of the TCP
+// connections it accepted.
+func countingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func TestNewClient_SharesTransport(t *testing.T) {
+	resetTransports(t)
+	a := NewClient(nil, false, 0)
+	b := NewClient(nil, false, time.Second)
+	insecure := NewClient(nil, true, 0)
+
+	assert.Same(t, a.httpClient.Transport, b.httpClient.Transport)
+	assert.NotSame(t, a.httpClient.Transport, insecure.httpClient.Transport)
+	assert.Equal(t, 64, a.httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestNewClient_ReusesConnectionsAcrossClients(t *testing.T) {
+	resetTransports(t)
+	ConfigureTransport(TransportOptions{MaxIdleConns: 7}) // start from an empty pool
+	srv, conns := countingServer(t)
+
+	for range 5 {
+		_, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{
+			Method: http.MethodGet, URL: srv.URL, SkipAuth: true,
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), conns.Load())
+}
+
+func TestNewClient_ConcurrentRequestsKeepConnections(t *testing.T) {
+	resetTransports(t)
+	ConfigureTransport(TransportOptions{MaxIdleConns: 8})
+	srv, conns := countingServer(t)
+
+	// Two rounds of 8 concurrent requests: with Go's default of 2 idle
+	// connections per host the second round would dial again.
+	for range 2 {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{
+					Method: http.MethodGet, URL: srv.URL, SkipAuth: true,
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+	assert.LessOrEqual(t, conns.Load(), int32(8))
+}
+
+func TestConfigureTransport(t *testing.T) {
+	resetTransports(t)
+	before := NewClient(nil, false, 0).httpClient.Transport
+
+	ConfigureTransport(TransportOptions{MaxIdleConnsPerHost: 4, IdleConnTimeout: time.Minute})
+	tr := NewClient(nil, false, 0).httpClient.Transport.(*http.Transport)
+	assert.NotSame(t, before, tr)
+	assert.Equal(t, 4, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	// Unset fields keep their defaults.
+	assert.Equal(t, 100, tr.MaxIdleConns)
+	assert.Equal(t, 10*time.Second, tr.TLSHandshakeTimeout)
+
+	// The same settings keep the existing pool.
+	ConfigureTransport(TransportOptions{MaxIdleConnsPerHost: 4, IdleConnTimeout: time.Minute})
+	assert.Same(t, tr, NewClient(nil, false, 0).httpClient.Transport)
+}
+
+func TestConfigureTransport_Network(t *testing.T) {
+	resetTransports(t)
+	srv, _ := countingServer(t)
+
+	ConfigureTransport(TransportOptions{Network: "tcp4", ConnectTimeout: 5 * time.Second})
+	resp, err := NewClient(nil, false, 0).Execute(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL, SkipAuth: true})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The IPv4 test server cannot be reached over IPv6 only.
+	ConfigureTransport(TransportOptions{Network: "tcp6"})
+	tr := NewClient(nil, false, 0).httpClient.Transport.(*http.Transport)
+	assert.Equal(t, 30*time.Second, DefaultTransportOptions().ConnectTimeout)
+	_, err = tr.DialContext(context.Background(), "tcp", srv.Listener.Addr().String())
+	assert.ErrorContains(t, err, "tcp6")
+}
+
+func TestSharedPinnedTransport(t *testing.T) {
+	resetTransports(t)
+	base := sharedTransport(false)
+	pins := [][]byte{make([]byte, 32)}
+
+	a, err := sharedPinnedTransport(base, pins)
+	require.NoError(t, err)
+	b, err := sharedPinnedTransport(base, pins)
+	require.NoError(t, err)
+	assert.Same(t, a, b)
+	assert.NotSame(t, base, a)
+
+	other, err := sharedPinnedTransport(base, [][]byte{make([]byte, 31)})
+	require.NoError(t, err)
+	assert.NotSame(t, a, other)
+}
+
+func TestNewTokenExchangeClient(t *testing.T) {
+	resetTransports(t)
+	other := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("the exchange followed a redirect")
+	}))
+	defer other.Close()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	// The test server is self-signed, so it is reached with --insecure, as
+	// the request would be.
+	c, err := NewTokenExchangeClient(RequestOptions{Insecure: true}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Same(t, sharedTransport(true), c.Transport)
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	pins, err := ParsePinnedPublicKeys("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	require.NoError(t, err)
+	c, err = NewTokenExchangeClient(RequestOptions{Insecure: true, PinnedPublicKeys: pins}, 5*time.Second)
+	require.NoError(t, err)
+	_, err = c.Get(server.URL)
+	assert.ErrorContains(t, err, "pinned public key mismatch")
+}
+
+// recordingForwarder counts the requests it forwards and sends them through
+// local.
+type recordingForwarder struct {
+	local http.RoundTripper
+	calls atomic.Int32
+}
+
+func (f *recordingForwarder) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls.Add(1)
+	return f.local.RoundTrip(req)
+}
+
+func TestNewForwardingClient(t *testing.T) {
+	resetTransports(t)
+	srv, _ := countingServer(t)
+	fwd := &recordingForwarder{}
+	wrap := func(local http.RoundTripper) http.RoundTripper {
+		fwd.local = local
+		return fwd
+	}
+	get := func(ctx context.Context, c *Client) {
+		t.Helper()
+		_, err := c.Execute(ctx, RequestOptions{Method: http.MethodGet, URL: srv.URL, SkipAuth: true})
+		require.NoError(t, err)
+	}
+
+	get(context.Background(), NewForwardingClient(nil, false, 0, wrap))
+	assert.Equal(t, int32(1), fwd.calls.Load())
+
+	// Addresses pinned for a security check are dialed by this process.
+	get(WithResolvedAddrs(context.Background(), "127.0.0.1", []string{"127.0.0.1"}), NewForwardingClient(nil, false, 0, wrap))
+	assert.Equal(t, int32(1), fwd.calls.Load())
+
+	c := NewForwardingClient(nil, true, 0, wrap)
+	assert.Same(t, sharedTransport(true), c.httpClient.Transport, "--insecure is not forwarded")
+
+	ConfigureTransport(TransportOptions{Network: "tcp4"})
+	c = NewForwardingClient(nil, false, 0, wrap)
+	assert.Same(t, sharedTransport(false), c.httpClient.Transport, "pool settings of the run are not forwarded")
+}
+
+func TestNewForwardingClient_PinnedRequestsConnectDirectly(t *testing.T) {
+	resetTransports(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	fwd := &recordingForwarder{}
+	c := NewForwardingClient(nil, false, 0, func(local http.RoundTripper) http.RoundTripper {
+		fwd.local = local
+		return fwd
+	})
+
+	pins, err := ParsePinnedPublicKeys("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	require.NoError(t, err)
+	_, err = c.Execute(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL, SkipAuth: true, PinnedPublicKeys: pins})
+	require.Error(t, err)
+	assert.Zero(t, fwd.calls.Load())
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"strings"
+	"time"
+)
+
+// Verbosity levels selected by repeating -v.
+const (
+	// VerboseBody adds the outgoing request body and retry decisions.
+	VerboseBody = 2
+	// VerboseTrace adds DNS, connection, and TLS handshake events.
+	VerboseTrace = 3
+)
+
+// maxVerboseBody caps the request body bytes printed at VerboseBody.
+const maxVerboseBody = 64 * 1024
+
+// writeVerboseBody prints a request body with secrets redacted, one "> " line
+// per body line. Binary content is summarized rather than printed, and a long
+// body is cut off at maxVerboseBody.
+func writeVerboseBody(w io.Writer, body []byte, contentType string) {
+	if len(body) == 0 {
+		return
+	}
+	if DetectContentType(body, contentType) {
+		fmt.Fprintf(w, "> [%d bytes of binary data]\n", len(body))
+		return
+	}
+	shown := body
+	if len(shown) > maxVerboseBody {
+		shown = shown[:maxVerboseBody]
+	}
+	for _, line := range strings.Split(strings.TrimRight(RedactSecrets(string(shown)), "\n"), "\n") {
+		fmt.Fprintf(w, "> %s\n", line)
+	}
+	if len(body) > len(shown) {
+		fmt.Fprintf(w, "> ... (%d more bytes)\n", len(body)-len(shown))
+	}
+}
+
+// withVerboseTrace returns ctx with an httptrace.ClientTrace that prints DNS
+// lookups, connection setup and reuse, and TLS handshakes as "* " lines, the
+// way curl reports transport events.
+func withVerboseTrace(ctx context.Context, w io.Writer) context.Context {
+	var dnsStart, connectStart, tlsStart time.Time
+	elapsed := func(start time.Time) string { return time.Since(start).Round(time.Microsecond).String() }
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			fmt.Fprintf(w, "* Getting connection to %s\n", hostPort)
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+			fmt.Fprintf(w, "* Resolving %s\n", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err != nil {
+				fmt.Fprintf(w, "* DNS lookup failed after %s: %v\n", elapsed(dnsStart), info.Err)
+				return
+			}
+			addrs := make([]string, len(info.Addrs))
+			for i, a := range info.Addrs {
+				addrs[i] = a.String()
+			}
+			fmt.Fprintf(w, "* Resolved to %s in %s\n", strings.Join(addrs, ", "), elapsed(dnsStart))
+		},
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+			fmt.Fprintf(w, "* Connecting to %s (%s)\n", addr, network)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				fmt.Fprintf(w, "* Connection to %s failed after %s: %v\n", addr, elapsed(connectStart), err)
+				return
+			}
+			fmt.Fprintf(w, "* Connected to %s in %s\n", addr, elapsed(connectStart))
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+			fmt.Fprintf(w, "* TLS handshake started\n")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				fmt.Fprintf(w, "* TLS handshake failed after %s: %v\n", elapsed(tlsStart), err)
+				return
+			}
+			fmt.Fprintf(w, "* TLS handshake done in %s: %s, %s", elapsed(tlsStart), tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+			if state.NegotiatedProtocol != "" {
+				fmt.Fprintf(w, ", ALPN %s", state.NegotiatedProtocol)
+			}
+			fmt.Fprintln(w)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				fmt.Fprintf(w, "* Server certificate: %s (issuer %s, expires %s)\n", cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				fmt.Fprintf(w, "* Reusing connection to %s (idle %s)\n", info.Conn.RemoteAddr(), info.IdleTime.Round(time.Millisecond))
+				return
+			}
+			fmt.Fprintf(w, "* Using new connection to %s\n", info.Conn.RemoteAddr())
+		},
+	})
+}
+
+// retryDelay is the backoff before the given retry attempt (1-based): 1s,
+// 2s, 4s, and so on.
+func retryDelay(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt-1)) * time.Second //nolint:gosec // G115: safe conversion, attempt count is small
+}
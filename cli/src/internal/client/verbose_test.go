@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStderr runs fn with os.Stderr redirected to a temp file and returns
+// what was written.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stderr-*.txt")
+	require.NoError(t, err)
+	old := os.Stderr
+	os.Stderr = f
+	defer func() { os.Stderr = old }()
+	fn()
+	_ = f.Close()
+	data, err := os.ReadFile(f.Name()) // #nosec G304 -- test-controlled temp path
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestWriteVerboseBody(t *testing.T) {
+	var b bytes.Buffer
+	writeVerboseBody(&b, []byte("{\n  \"name\": \"x\",\n  \"password\": \"hunter2\"\n}\n"), "application/json")
+	assert.Equal(t, "> {\n>   \"name\": \"x\",\n>   \"password\": \""+redactedValue+"\"\n> }\n", b.String())
+
+	b.Reset()
+	writeVerboseBody(&b, []byte{0x89, 'P', 'N', 'G', 0, 1, 2}, "image/png")
+	assert.Equal(t, "> [7 bytes of binary data]\n", b.String())
+
+	b.Reset()
+	writeVerboseBody(&b, bytes.Repeat([]byte("a"), maxVerboseBody+10), "text/plain")
+	assert.True(t, strings.HasSuffix(b.String(), "> ... (10 more bytes)\n"))
+
+	b.Reset()
+	writeVerboseBody(&b, nil, "")
+	assert.Empty(t, b.String())
+}
+
+func TestClient_Execute_VerboseLevels(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	run := func(level int, url string) string {
+		attempts = 0
+		return captureStderr(t, func() {
+			_, err := NewClient(nil, false, 30*time.Second).Execute(context.Background(), RequestOptions{
+				Method:       "POST",
+				URL:          url,
+				Body:         strings.NewReader(`{"clientSecret":"abc123"}`),
+				Headers:      http.Header{"Content-Type": {"application/json"}},
+				SkipAuth:     true,
+				Verbose:      level > 0,
+				VerboseLevel: level,
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	out := run(1, server.URL)
+	assert.Contains(t, out, "> POST ")
+	assert.NotContains(t, out, "clientSecret")
+	assert.NotContains(t, out, "retrying")
+
+	out = run(VerboseBody, server.URL)
+	assert.Contains(t, out, `> {"clientSecret":"`+redactedValue+`"}`)
+	assert.Contains(t, out, "* HTTP 503 on attempt 1 of 4; retrying in 1s")
+	assert.NotContains(t, out, "* Connecting to")
+
+	// A new server forces a new connection, since the transport is shared.
+	fresh := httptest.NewServer(server.Config.Handler)
+	defer fresh.Close()
+	out = run(VerboseTrace, fresh.URL)
+	assert.Contains(t, out, "* Connecting to ")
+	assert.Contains(t, out, "* Connected to ")
+	assert.Contains(t, out, "* Reusing connection to ")
+}
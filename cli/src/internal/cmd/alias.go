@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// aliasFilePath returns the file that holds user-defined aliases. It is a
+// package variable so tests can use a temp directory.
+var aliasFilePath = func() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "aliases.yaml"), nil
+}
+
+// aliasNamePattern matches a valid alias name.
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// NewAliasCommand returns the alias subcommand, which manages short names for
+// frequent requests.
+func NewAliasCommand() *cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage short names for frequent requests",
+		Long: `Manage aliases: short names that expand to an azd rest command line.
+
+An alias is used in place of a command, and any arguments after it are
+appended to the expansion. Built-in commands always take precedence.
+
+Examples:
+  # Define an alias (quote the expansion as one argument)
+  azd rest alias set subs 'get https://management.azure.com/subscriptions?api-version=2022-12-01'
+
+  # Use it, with extra flags appended
+  azd rest subs --query 'value[].displayName'
+
+  # List aliases
+  azd rest alias list`,
+	}
+	aliasCmd.AddCommand(
+		newAliasSetCommand(),
+		newAliasListCommand(),
+		newAliasDeleteCommand(),
+	)
+	return aliasCmd
+}
+
+func newAliasSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name> <expansion>",
+		Short: "Define or replace an alias",
+		Long: `Define <name> as an alias for <expansion>. The expansion is split into
+arguments the way a shell would, so quote it as a single argument; several
+arguments are taken as the expansion already split.
+
+The expansion must start with a built-in command, such as get or bulk.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if !aliasNamePattern.MatchString(name) {
+				return fmt.Errorf("invalid alias name %q (use letters, digits, '-', and '_')", name)
+			}
+			root := cmd.Root()
+			if isBuiltinCommand(root, name) {
+				return fmt.Errorf("%q is a built-in command and cannot be an alias", name)
+			}
+			expansion := args[1:]
+			if len(expansion) == 1 {
+				var err error
+				if expansion, err = splitShellWords(expansion[0]); err != nil {
+					return fmt.Errorf("invalid expansion: %w", err)
+				}
+			}
+			if len(expansion) == 0 || !isBuiltinCommand(root, expansion[0]) {
+				return fmt.Errorf("the expansion must start with a built-in command, such as get")
+			}
+
+			aliases, err := loadAliases()
+			if err != nil {
+				return err
+			}
+			aliases[name] = expansion
+			if err := saveAliases(aliases); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Alias %s expands to: %s\n", name, joinShellWords(expansion))
+			return nil
+		},
+	}
+}
+
+func newAliasListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List aliases and their expansions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			aliases, err := loadAliases()
+			if err != nil {
+				return err
+			}
+			return writeAliases(cmd.OutOrStdout(), aliases, outputFormat)
+		},
+	}
+}
+
+func newAliasDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete an alias",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases, err := loadAliases()
+			if err != nil {
+				return err
+			}
+			if _, ok := aliases[args[0]]; !ok {
+				return fmt.Errorf("no alias named %q", args[0])
+			}
+			delete(aliases, args[0])
+			if err := saveAliases(aliases); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Deleted alias %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// ExpandAlias replaces an alias in the first argument with its expansion,
+// keeping the arguments that follow it. Arguments that start with a flag or a
+// built-in command are returned unchanged without reading the alias file.
+func ExpandAlias(root *cobra.Command, args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(root, args[0]) {
+		return args, nil
+	}
+	aliases, err := loadAliases()
+	if err != nil {
+		return nil, err
+	}
+	expansion, ok := aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	return append(append([]string(nil), expansion...), args[1:]...), nil
+}
+
+// isBuiltinCommand reports whether name is a subcommand of root, or one of the
+// help and completion commands cobra adds when the command runs.
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	switch name {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAliases reads the alias file. A missing file yields no aliases.
+func loadAliases() (map[string][]string, error) {
+	path, err := aliasFilePath()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(path) // #nosec G304 -- Path is under the azd rest config directory.
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+	aliases := map[string][]string{}
+	if err := yaml.Unmarshal(raw, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to parse aliases file %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// saveAliases writes the alias file, creating the config directory if needed.
+func saveAliases(aliases map[string][]string) error {
+	path, err := aliasFilePath()
+	if err != nil {
+		return err
+	}
+	raw, err := yaml.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write aliases: %w", err)
+	}
+	return nil
+}
+
+// writeAliases prints one "name: expansion" line per alias sorted by name, or
+// a JSON object with --format json.
+func writeAliases(out io.Writer, aliases map[string][]string, format string) error {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.EqualFold(format, "json") {
+		expanded := make(map[string]string, len(aliases))
+		for name, args := range aliases {
+			expanded[name] = joinShellWords(args)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(expanded)
+	}
+	for _, name := range names {
+		fmt.Fprintf(out, "%s: %s\n", name, joinShellWords(aliases[name]))
+	}
+	return nil
+}
+
+// splitShellWords splits s into arguments the way a POSIX shell does, without
+// expanding variables: single quotes preserve everything, double quotes allow
+// backslash escapes, and unquoted whitespace separates arguments.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\$`+"`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// joinShellWords is the inverse of splitShellWords: it single-quotes any
+// argument that a shell would otherwise split or expand.
+func joinShellWords(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
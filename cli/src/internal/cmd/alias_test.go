@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTempAliasFile points the alias file at a temp directory for one test.
+func useTempAliasFile(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rest", "aliases.yaml")
+	orig := aliasFilePath
+	aliasFilePath = func() (string, error) { return path, nil }
+	t.Cleanup(func() { aliasFilePath = orig })
+}
+
+// runAliasCommand executes azd rest alias with args from a fresh root command.
+func runAliasCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	rootCmd := NewRootCmd()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"alias"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestAliasCommand_SetListExpandDelete(t *testing.T) {
+	useTempAliasFile(t)
+	outputFormat = ""
+	defer func() { outputFormat = "" }()
+
+	_, err := runAliasCommand(t, "set", "subs", "get 'https://management.azure.com/subscriptions?api-version=2022-12-01' --query \"value[].id\"")
+	require.NoError(t, err)
+
+	out, err := runAliasCommand(t, "list")
+	require.NoError(t, err)
+	assert.Equal(t, "subs: get 'https://management.azure.com/subscriptions?api-version=2022-12-01' --query 'value[].id'\n", out)
+
+	args, err := ExpandAlias(NewRootCmd(), []string{"subs", "-f", "table"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"get", "https://management.azure.com/subscriptions?api-version=2022-12-01", "--query", "value[].id", "-f", "table"}, args)
+
+	_, err = runAliasCommand(t, "delete", "subs")
+	require.NoError(t, err)
+	args, err = ExpandAlias(NewRootCmd(), []string{"subs"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subs"}, args)
+}
+
+func TestAliasCommand_SetValidation(t *testing.T) {
+	useTempAliasFile(t)
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"set", "get", "post https://example.com"}, "built-in command"},
+		{[]string{"set", "bad name", "get https://example.com"}, "invalid alias name"},
+		{[]string{"set", "x", "subs --verbose"}, "must start with a built-in command"},
+		{[]string{"set", "x", "get 'unterminated"}, "unterminated single quote"},
+	} {
+		_, err := runAliasCommand(t, tc.args...)
+		require.Error(t, err, tc.args)
+		assert.Contains(t, err.Error(), tc.want)
+	}
+	_, err := runAliasCommand(t, "delete", "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no alias named "missing"`)
+}
+
+func TestExpandAlias_BuiltinsAndFlagsUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.yaml")
+	orig := aliasFilePath
+	// A directory in place of the file makes any read fail, proving the file
+	// is not read for built-in commands or flags.
+	aliasFilePath = func() (string, error) { return filepath.Dir(path), nil }
+	defer func() { aliasFilePath = orig }()
+
+	for _, args := range [][]string{nil, {"get", "https://example.com"}, {"--help"}, {"help"}} {
+		got, err := ExpandAlias(NewRootCmd(), args)
+		require.NoError(t, err)
+		assert.Equal(t, args, got)
+	}
+	_, err := ExpandAlias(NewRootCmd(), []string{"subs"})
+	assert.Error(t, err)
+}
+
+func TestSplitShellWords(t *testing.T) {
+	got, err := splitShellWords(`post https://x --data '{"a": 1}' -H "X-Name: a \"b\"" back\ slash`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"post", "https://x", "--data", `{"a": 1}`, "-H", `X-Name: a "b"`, "back slash"}, got)
+
+	round, err := splitShellWords(joinShellWords(got))
+	require.NoError(t, err)
+	assert.Equal(t, got, round)
+
+	_, err = splitShellWords(`get "open`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,518 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// appConfigAPIVersion is the App Configuration data-plane api-version.
+	appConfigAPIVersion = "1.0"
+	// appConfigKVType is the media type of a key-value body.
+	appConfigKVType = "application/vnd.microsoft.appconfig.kv+json"
+	// syncTokenHeader carries the store's sync tokens in both directions.
+	syncTokenHeader = "Sync-Token"
+	// syncTokensFile holds the latest sync tokens per store, so a read in a
+	// later invocation observes a write made by an earlier one.
+	syncTokensFile = "appconfig-sync-tokens.json"
+)
+
+// appConfigTokenProviderFactory builds the token provider used by the
+// appconfig commands. It is a package variable so tests can inject a stub
+// provider.
+var appConfigTokenProviderFactory = service.DefaultTokenProviderFactory
+
+// appConfigStorePattern matches an App Configuration store name.
+var appConfigStorePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{3,48}[a-zA-Z0-9]$`)
+
+// keyValue is one App Configuration key-value.
+type keyValue struct {
+	Key          string            `json:"key"`
+	Label        *string           `json:"label"`
+	Value        *string           `json:"value"`
+	ContentType  *string           `json:"content_type,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	Locked       bool              `json:"locked,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// keyValuePage is one page of a List Key-Values response.
+type keyValuePage struct {
+	Items    []keyValue `json:"items"`
+	NextLink string     `json:"@nextLink"`
+}
+
+// appConfigError is the problem details body of an App Configuration error.
+type appConfigError struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// NewAppConfigCommand returns the appconfig subcommand, which groups Azure
+// App Configuration helpers.
+func NewAppConfigCommand() *cobra.Command {
+	appConfigCmd := &cobra.Command{
+		Use:   "appconfig",
+		Short: "Read and write Azure App Configuration key-values",
+		Long: `Read and write Azure App Configuration key-values through the data-plane REST
+API.
+
+--store is the store name, such as myconfig for https://myconfig.azconfig.io,
+or its endpoint URL. Requests authenticate with the App Configuration scope
+unless --no-auth is set.
+
+Every response's Sync-Token header is recorded and sent back on later
+requests to the same store, including from later invocations, so a read
+right after a write sees the new value.`,
+	}
+	kvCmd := &cobra.Command{
+		Use:   "kv",
+		Short: "List, get, and set key-values",
+	}
+	kvCmd.AddCommand(newAppConfigListCommand(), newAppConfigGetCommand(), newAppConfigSetCommand())
+	appConfigCmd.AddCommand(kvCmd)
+	return appConfigCmd
+}
+
+func newAppConfigListCommand() *cobra.Command {
+	var store, key, label string
+	cmd := &cobra.Command{
+		Use:   "list --store <name>",
+		Short: "List key-values, optionally filtered by key and label",
+		Long: `List key-values, following every page. --key and --label take the service's
+filter syntax: a trailing * matches a prefix, commas separate alternatives,
+and --label '\0' matches key-values without a label.
+
+Prints key=value lines (key[label]=value for a labeled key-value), or the
+full key-values with --format json.`,
+		Example: `  azd rest appconfig kv list --store myconfig --key 'app:*' --label prod
+  azd rest appconfig kv list --store https://myconfig.azconfig.io --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ac, err := newAppConfigClient(store)
+			if err != nil {
+				return err
+			}
+			items, err := ac.list(commandContext(cmd), key, label)
+			if err != nil {
+				return err
+			}
+			return writeKeyValues(cmd.OutOrStdout(), items, outputFormat)
+		},
+	}
+	cmd.Flags().StringVar(&store, "store", "", "App Configuration store name or endpoint URL")
+	cmd.Flags().StringVar(&key, "key", "", "Key filter, e.g. app:* (default: all keys)")
+	cmd.Flags().StringVar(&label, "label", "", `Label filter, or \0 for no label (default: all labels)`)
+	_ = cmd.MarkFlagRequired("store")
+	return cmd
+}
+
+func newAppConfigGetCommand() *cobra.Command {
+	var store, label string
+	cmd := &cobra.Command{
+		Use:   "get <key> --store <name>",
+		Short: "Print the value of a key-value",
+		Long: `Print the value of a key-value, or the full key-value with --format json.
+Without --label, the key-value without a label is read.`,
+		Example: `  azd rest appconfig kv get app:color --store myconfig --label prod`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, err := newAppConfigClient(store)
+			if err != nil {
+				return err
+			}
+			kv, err := ac.get(commandContext(cmd), args[0], label)
+			if err != nil {
+				return err
+			}
+			return writeKeyValue(cmd.OutOrStdout(), kv, outputFormat)
+		},
+	}
+	cmd.Flags().StringVar(&store, "store", "", "App Configuration store name or endpoint URL")
+	cmd.Flags().StringVar(&label, "label", "", "Label of the key-value (default: no label)")
+	_ = cmd.MarkFlagRequired("store")
+	return cmd
+}
+
+func newAppConfigSetCommand() *cobra.Command {
+	var store, label, contentType string
+	cmd := &cobra.Command{
+		Use:   "set <key> <value> --store <name>",
+		Short: "Create or update a key-value",
+		Long: `Create or update a key-value. Pass @file to read the value from a file.
+With --format json the stored key-value is printed.`,
+		Example: `  azd rest appconfig kv set app:color blue --store myconfig --label prod
+  azd rest appconfig kv set app:settings @settings.json --store myconfig --content-type application/json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value := args[1]
+			if strings.HasPrefix(value, "@") {
+				raw, err := os.ReadFile(strings.TrimPrefix(value, "@")) // #nosec G304 -- User-specified value file is intentional.
+				if err != nil {
+					return fmt.Errorf("failed to read value file: %w", err)
+				}
+				value = string(raw)
+			}
+			ac, err := newAppConfigClient(store)
+			if err != nil {
+				return err
+			}
+			kv, err := ac.set(commandContext(cmd), args[0], label, value, contentType)
+			if err != nil {
+				return err
+			}
+			if strings.EqualFold(outputFormat, "json") {
+				return writeKeyValue(cmd.OutOrStdout(), kv, outputFormat)
+			}
+			if !silent {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Set %s\n", keyValueName(kv))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&store, "store", "", "App Configuration store name or endpoint URL")
+	cmd.Flags().StringVar(&label, "label", "", "Label of the key-value (default: no label)")
+	cmd.Flags().StringVar(&contentType, "content-type", "", "Content type stored with the value")
+	_ = cmd.MarkFlagRequired("store")
+	return cmd
+}
+
+// appConfigClient sends App Configuration requests with the global auth and
+// transport settings, and keeps the store's sync tokens.
+type appConfigClient struct {
+	c        *client.Client
+	endpoint *url.URL
+	scope    string
+	noAuth   bool
+	retry    int
+	auditLog string
+	tokens   syncTokens
+	// tokenFile is where the sync tokens are persisted; empty disables it.
+	tokenFile string
+}
+
+func newAppConfigClient(store string) (*appConfigClient, error) {
+	endpoint, err := appConfigEndpoint(store)
+	if err != nil {
+		return nil, err
+	}
+	cfg := snapshotConfig()
+	ac := &appConfigClient{endpoint: endpoint, scope: cfg.Scope, noAuth: cfg.NoAuth, retry: cfg.Retry, auditLog: cfg.AuditLog}
+	var tp client.TokenProvider
+	if !cfg.NoAuth {
+		if tp, err = appConfigTokenProviderFactory(); err != nil {
+			return nil, fmt.Errorf("failed to create token provider: %w", err)
+		}
+	}
+	ac.c = client.NewClient(tp, cfg.Insecure, cfg.Timeout)
+	if dir, err := config.Dir(); err == nil {
+		ac.tokenFile = filepath.Join(dir, syncTokensFile)
+	}
+	ac.tokens = loadSyncTokens(ac.tokenFile, endpoint.Host)
+	return ac, nil
+}
+
+// appConfigEndpoint returns the endpoint of a store given as a name or URL.
+func appConfigEndpoint(store string) (*url.URL, error) {
+	store = strings.TrimSpace(store)
+	switch {
+	case store == "":
+		return nil, fmt.Errorf("--store is required")
+	case strings.Contains(store, "://"):
+		u, err := url.Parse(strings.TrimRight(store, "/"))
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid --store URL %q", store)
+		}
+		return u, nil
+	case appConfigStorePattern.MatchString(store):
+		return &url.URL{Scheme: "https", Host: store + ".azconfig.io"}, nil
+	default:
+		return nil, fmt.Errorf("invalid --store %q (expected a store name or an endpoint URL)", store)
+	}
+}
+
+// kvURL returns the URL of path on the store with the api-version and the
+// given query parameters set.
+func (ac *appConfigClient) kvURL(path string, params url.Values) string {
+	u := *ac.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = ""
+	q := url.Values{}
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q[k] = v
+		}
+	}
+	q.Set("api-version", appConfigAPIVersion)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// keyPath returns the /kv/<key> path with the key escaped, so a key that
+// contains slashes stays one segment.
+func keyPath(key string) string {
+	return "/kv/" + url.PathEscape(key)
+}
+
+// do sends one request with the current sync tokens, records the sync tokens
+// of the response and any write in --audit-log, and returns an error for any
+// non-2xx status.
+func (ac *appConfigClient) do(ctx context.Context, method, rawURL string, headers http.Header, body []byte) (*client.Response, error) {
+	opts := client.RequestOptions{
+		Method:  method,
+		URL:     rawURL,
+		Headers: make(http.Header),
+		Retry:   ac.retry,
+	}
+	if body != nil {
+		opts.Body = bytes.NewReader(body)
+	}
+	for k, v := range headers {
+		opts.Headers[k] = v
+	}
+	if tokens := ac.tokens.header(); tokens != "" {
+		opts.Headers.Set(syncTokenHeader, tokens)
+	}
+	opts.SkipAuth = ac.noAuth
+	if !opts.SkipAuth {
+		opts.Scope = ac.scope
+		if opts.Scope == "" {
+			scope, err := client.DetectScope(rawURL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to detect scope: %w", err)
+			}
+			opts.Scope = scope
+		}
+	}
+	started := time.Now()
+	resp, err := ac.c.Execute(ctx, opts)
+	if auditErr := service.RecordAudit(ac.auditLog, opts, started, resp, err); auditErr != nil {
+		return nil, fmt.Errorf("request sent but not recorded in the audit log: %w", auditErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ac.tokens.update(resp.Headers.Values(syncTokenHeader)) {
+		saveSyncTokens(ac.tokenFile, ac.endpoint.Host, ac.tokens)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var ae appConfigError
+		if json.Unmarshal(resp.Body, &ae) == nil && (ae.Title != "" || ae.Detail != "") {
+			return nil, fmt.Errorf("app configuration returned %s: %s", resp.Status, strings.TrimSuffix(strings.TrimSpace(ae.Title+" "+ae.Detail), "."))
+		}
+		return nil, fmt.Errorf("app configuration returned %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// list returns every key-value matching the filters, following @nextLink.
+func (ac *appConfigClient) list(ctx context.Context, key, label string) ([]keyValue, error) {
+	items := []keyValue{}
+	next := ac.kvURL("/kv", url.Values{"key": {key}, "label": {label}})
+	for next != "" {
+		resp, err := ac.do(ctx, http.MethodGet, next, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page keyValuePage
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse List Key-Values response: %w", err)
+		}
+		items = append(items, page.Items...)
+		next = ""
+		if page.NextLink != "" {
+			ref, err := url.Parse(page.NextLink)
+			if err != nil {
+				return nil, fmt.Errorf("invalid @nextLink %q: %w", page.NextLink, err)
+			}
+			next = ac.endpoint.ResolveReference(ref).String()
+		}
+	}
+	return items, nil
+}
+
+// get returns one key-value.
+func (ac *appConfigClient) get(ctx context.Context, key, label string) (keyValue, error) {
+	var kv keyValue
+	resp, err := ac.do(ctx, http.MethodGet, ac.kvURL(keyPath(key), url.Values{"label": {label}}), nil, nil)
+	if err != nil {
+		return kv, err
+	}
+	if err := json.Unmarshal(resp.Body, &kv); err != nil {
+		return kv, fmt.Errorf("failed to parse key-value: %w", err)
+	}
+	return kv, nil
+}
+
+// set creates or updates a key-value and returns it as stored.
+func (ac *appConfigClient) set(ctx context.Context, key, label, value, contentType string) (keyValue, error) {
+	kv := keyValue{Value: &value}
+	if contentType != "" {
+		kv.ContentType = &contentType
+	}
+	body, err := json.Marshal(struct {
+		Value       *string `json:"value"`
+		ContentType *string `json:"content_type,omitempty"`
+	}{kv.Value, kv.ContentType})
+	if err != nil {
+		return kv, fmt.Errorf("failed to build key-value body: %w", err)
+	}
+	headers := http.Header{"Content-Type": {appConfigKVType}}
+	resp, err := ac.do(ctx, http.MethodPut, ac.kvURL(keyPath(key), url.Values{"label": {label}}), headers, body)
+	if err != nil {
+		return kv, err
+	}
+	if err := json.Unmarshal(resp.Body, &kv); err != nil {
+		return kv, fmt.Errorf("failed to parse key-value: %w", err)
+	}
+	return kv, nil
+}
+
+// syncToken is the latest value of one sync token and its sequence number.
+type syncToken struct {
+	Value string `json:"value"`
+	Seq   int64  `json:"sn"`
+}
+
+// syncTokens holds a store's sync tokens by token ID.
+type syncTokens map[string]syncToken
+
+// update records the tokens of Sync-Token response headers, each a
+// comma-separated list of <id>=<value>;sn=<sn>. A token only replaces one
+// with a lower sequence number. It reports whether anything changed.
+func (t syncTokens) update(headers []string) bool {
+	changed := false
+	for _, header := range headers {
+		for _, part := range strings.Split(header, ",") {
+			token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			id, value, ok := strings.Cut(token, "=")
+			if !ok || id == "" {
+				continue
+			}
+			var seq int64
+			for _, param := range strings.Split(params, ";") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(param), "sn="); ok {
+					seq, _ = strconv.ParseInt(v, 10, 64)
+				}
+			}
+			if cur, ok := t[id]; ok && cur.Seq >= seq {
+				continue
+			}
+			t[id] = syncToken{Value: value, Seq: seq}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// header returns the Sync-Token request header, <id>=<value> pairs in ID
+// order, or "" when there are none.
+func (t syncTokens) header() string {
+	ids := make([]string, 0, len(t))
+	for id := range t {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	pairs := make([]string, len(ids))
+	for i, id := range ids {
+		pairs[i] = id + "=" + t[id].Value
+	}
+	return strings.Join(pairs, ",")
+}
+
+// loadSyncTokens returns the persisted sync tokens of the store at host. A
+// missing or unreadable file yields no tokens.
+func loadSyncTokens(path, host string) syncTokens {
+	tokens := syncTokens{}
+	if path == "" {
+		return tokens
+	}
+	raw, err := os.ReadFile(path) // #nosec G304 -- Path is under the azd rest config directory.
+	if err != nil {
+		return tokens
+	}
+	var stores map[string]syncTokens
+	if json.Unmarshal(raw, &stores) == nil && stores[host] != nil {
+		tokens = stores[host]
+	}
+	return tokens
+}
+
+// saveSyncTokens persists the sync tokens of the store at host alongside
+// those of other stores. Failures are ignored: the tokens only tighten
+// consistency, and the request itself already succeeded or failed.
+func saveSyncTokens(path, host string, tokens syncTokens) {
+	if path == "" {
+		return
+	}
+	stores := map[string]syncTokens{}
+	if raw, err := os.ReadFile(path); err == nil { // #nosec G304 -- Path is under the azd rest config directory.
+		_ = json.Unmarshal(raw, &stores)
+	}
+	stores[host] = tokens
+	raw, err := json.Marshal(stores)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+		_ = os.WriteFile(path, raw, 0o600)
+	}
+}
+
+// keyValueName returns key, or key[label] for a labeled key-value.
+func keyValueName(kv keyValue) string {
+	if kv.Label != nil && *kv.Label != "" {
+		return kv.Key + "[" + *kv.Label + "]"
+	}
+	return kv.Key
+}
+
+// writeKeyValues prints one key=value line per key-value, or the key-values
+// as JSON with --format json.
+func writeKeyValues(out io.Writer, items []keyValue, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+	for _, kv := range items {
+		value := ""
+		if kv.Value != nil {
+			value = *kv.Value
+		}
+		fmt.Fprintf(out, "%s=%s\n", keyValueName(kv), value)
+	}
+	return nil
+}
+
+// writeKeyValue prints a key-value's value, or the key-value as JSON with
+// --format json.
+func writeKeyValue(out io.Writer, kv keyValue, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(kv)
+	}
+	if kv.Value != nil {
+		fmt.Fprintln(out, *kv.Value)
+	}
+	return nil
+}
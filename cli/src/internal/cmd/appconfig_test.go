@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAppConfig stores key-values by key and label, hands out a new sync
+// token sequence number on every write, and records the Sync-Token header
+// of every request.
+type fakeAppConfig struct {
+	mu         sync.Mutex
+	values     map[string]keyValue
+	seq        int
+	syncTokens []string
+}
+
+func newFakeAppConfig(t *testing.T) (*fakeAppConfig, *httptest.Server) {
+	t.Helper()
+	f := &fakeAppConfig{values: map[string]keyValue{}}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAppConfig) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.syncTokens = append(f.syncTokens, r.Header.Get(syncTokenHeader))
+	w.Header().Set(syncTokenHeader, "jtqGc1I4=MDoyOA==;sn="+strconv.Itoa(f.seq))
+	q := r.URL.Query()
+	label := q.Get("label")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/kv":
+		if q.Get("after") == "" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"items": []keyValue{f.values["app:a"]}, "@nextLink": "/kv?after=app%3Aa&api-version=1.0"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": []keyValue{f.values["app:b|prod"]}})
+	case r.Method == http.MethodPut:
+		if r.Header.Get("Content-Type") != appConfigKVType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		var kv keyValue
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &kv)
+		kv.Key = r.URL.Path[len("/kv/"):]
+		kv.Label = &label
+		id := kv.Key
+		if label != "" {
+			id += "|" + label
+		}
+		f.values[id] = kv
+		f.seq++
+		w.Header().Set(syncTokenHeader, "jtqGc1I4=MDoyOA==;sn="+strconv.Itoa(f.seq))
+		_ = json.NewEncoder(w).Encode(kv)
+	case r.Method == http.MethodGet:
+		kv, ok := f.values[r.URL.Path[len("/kv/"):]]
+		if !ok {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"https://azconfig.io/errors/key-not-found","title":"Key not found","status":404}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(kv)
+	}
+}
+
+func runAppConfigCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewAppConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestAppConfigKV_SetGetList(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	silent = true
+	t.Setenv("AZD_CONFIG_DIR", t.TempDir())
+	f, srv := newFakeAppConfig(t)
+
+	_, err := runAppConfigCommand(t, "kv", "set", "app:a", "red", "--store", srv.URL)
+	require.NoError(t, err)
+	_, err = runAppConfigCommand(t, "kv", "set", "app:b", "blue", "--store", srv.URL, "--label", "prod")
+	require.NoError(t, err)
+
+	out, err := runAppConfigCommand(t, "kv", "get", "app:a", "--store", srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "red\n", out)
+
+	out, err = runAppConfigCommand(t, "kv", "list", "--store", srv.URL, "--key", "app:*")
+	require.NoError(t, err)
+	assert.Equal(t, "app:a=red\napp:b[prod]=blue\n", out, "every page is followed")
+
+	assert.Equal(t, []string{"", "jtqGc1I4=MDoyOA==", "jtqGc1I4=MDoyOA==", "jtqGc1I4=MDoyOA==", "jtqGc1I4=MDoyOA=="}, f.syncTokens,
+		"the sync token of a write is sent by later invocations")
+
+	_, err = runAppConfigCommand(t, "kv", "get", "missing", "--store", srv.URL)
+	assert.EqualError(t, err, "app configuration returned 404 Not Found: Key not found")
+}
+
+func TestAppConfigKVSet_RecordsAuditLog(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	noAuth = true
+	silent = true
+	t.Setenv("AZD_CONFIG_DIR", t.TempDir())
+	auditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+	_, srv := newFakeAppConfig(t)
+
+	_, err := runAppConfigCommand(t, "kv", "set", "app:a", "red", "--store", srv.URL)
+	require.NoError(t, err)
+	_, err = runAppConfigCommand(t, "kv", "get", "app:a", "--store", srv.URL)
+	require.NoError(t, err)
+
+	entries := readAuditLog(t, auditLog)
+	require.Len(t, entries, 1, "the GET is not recorded")
+	assert.Equal(t, http.MethodPut, entries[0].Method)
+	assert.Equal(t, http.StatusOK, entries[0].StatusCode)
+	assert.Contains(t, entries[0].URL, "/kv/app:a")
+}
+
+func TestSyncTokens(t *testing.T) {
+	tokens := syncTokens{}
+	assert.True(t, tokens.update([]string{"b=v1;sn=3", "a=x==;sn=1, b=v0;sn=2"}))
+	assert.Equal(t, "a=x==,b=v1", tokens.header(), "a lower sequence number does not replace a token")
+	assert.False(t, tokens.update([]string{"a=x==;sn=1"}))
+	assert.True(t, tokens.update([]string{"a=y==;sn=5"}))
+	assert.Equal(t, "a=y==,b=v1", tokens.header())
+}
+
+func TestAppConfigEndpoint(t *testing.T) {
+	u, err := appConfigEndpoint("myconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "https://myconfig.azconfig.io", u.String())
+
+	u, err = appConfigEndpoint("https://myconfig.azconfig.io/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://myconfig.azconfig.io", u.String())
+
+	_, err = appConfigEndpoint("my config")
+	assert.EqualError(t, err, `invalid --store "my config" (expected a store name or an endpoint URL)`)
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// providersAPIVersion is the Resource Providers API version used by the
+	// arm discovery commands.
+	providersAPIVersion = "2021-04-01"
+	// armCacheTTL is how long a cached Providers API response is reused.
+	armCacheTTL = 24 * time.Hour
+)
+
+var (
+	// armEndpoint is the Resource Manager endpoint the arm commands query. It
+	// is a package variable so tests can point it at a local server.
+	armEndpoint = "https://management.azure.com"
+	// armTokenProviderFactory builds the token provider used by the arm
+	// commands. It is a package variable so tests can inject a stub provider.
+	armTokenProviderFactory = service.DefaultTokenProviderFactory
+	// armCacheDir returns the directory that holds cached Providers API
+	// responses. It is a package variable so tests can use a temp directory.
+	armCacheDir = func() (string, error) {
+		dir, err := config.Dir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "cache", "arm"), nil
+	}
+)
+
+// armProvider is the subset of a Providers API provider used here.
+type armProvider struct {
+	Namespace     string            `json:"namespace"`
+	ResourceTypes []armResourceType `json:"resourceTypes"`
+}
+
+// armResourceType is the subset of a provider resource type used here.
+type armResourceType struct {
+	ResourceType string   `json:"resourceType"`
+	APIVersions  []string `json:"apiVersions"`
+}
+
+// apiVersionsResult is the JSON payload emitted by arm api-versions with
+// --format json.
+type apiVersionsResult struct {
+	ResourceType string   `json:"resourceType"`
+	LatestStable string   `json:"latestStable,omitempty"`
+	APIVersions  []string `json:"apiVersions"`
+}
+
+// NewARMCommand returns the arm subcommand, which groups Azure Resource
+// Manager discovery, tagging, and deployment inspection helpers.
+func NewARMCommand() *cobra.Command {
+	var refresh bool
+	armCmd := &cobra.Command{
+		Use:   "arm",
+		Short: "Discover Azure Resource Manager providers and api-versions, manage tags, and inspect deployments",
+		Long: `Discover Azure Resource Manager resource providers and the api-version values
+each resource type accepts, without opening the REST API docs, list, add, or
+remove resource tags, and inspect deployments and the activity log after a
+failed azd provision.
+
+Responses from the Providers API are cached for 24 hours under the azd rest
+config directory. Pass --refresh to bypass the cache.`,
+	}
+	armCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "Ignore cached provider data and query the Providers API")
+
+	armCmd.AddCommand(
+		&cobra.Command{
+			Use:   "providers",
+			Short: "List resource provider namespaces",
+			Example: `  azd rest arm providers
+  azd rest arm providers --format json`,
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				var list struct {
+					Value []armProvider `json:"value"`
+				}
+				if err := fetchProviders(commandContext(cmd), "/providers", "providers.json", refresh, &list); err != nil {
+					return err
+				}
+				return writeProviders(cmd.OutOrStdout(), list.Value, outputFormat)
+			},
+		},
+		&cobra.Command{
+			Use:   "api-versions <resourceType>",
+			Short: "List the api-version values for a resource type",
+			Example: `  azd rest arm api-versions Microsoft.Web/sites
+  azd rest arm api-versions Microsoft.Storage/storageAccounts/blobServices --format json`,
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				namespace, resourceType, ok := strings.Cut(args[0], "/")
+				if !ok || namespace == "" || resourceType == "" {
+					return fmt.Errorf("invalid resource type %q (expected <Namespace>/<type>, e.g. Microsoft.Web/sites)", args[0])
+				}
+				var provider armProvider
+				cacheName := "provider-" + strings.ToLower(namespace) + ".json"
+				if err := fetchProviders(commandContext(cmd), "/providers/"+url.PathEscape(namespace), cacheName, refresh, &provider); err != nil {
+					return err
+				}
+				res, err := findAPIVersions(provider, args[0], resourceType)
+				if err != nil {
+					return err
+				}
+				return writeAPIVersions(cmd.OutOrStdout(), res, outputFormat)
+			},
+		},
+		newARMTagCommand(),
+		newARMDeploymentsCommand(),
+		newARMActivityCommand(),
+	)
+	return armCmd
+}
+
+// commandContext returns the command's context, or a background context when
+// the command runs without one (as in tests).
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// fetchProviders decodes the Providers API response for path into v. A cached
+// copy younger than armCacheTTL is used unless refresh is set; a fresh response
+// is written back to the cache. A cache that cannot be read or written only
+// costs a network call, so cache errors are not fatal.
+func fetchProviders(ctx context.Context, path, cacheName string, refresh bool, v any) error {
+	var cachePath string
+	if dir, err := armCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, cacheName)
+	}
+	if cachePath != "" && !refresh {
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < armCacheTTL {
+			if raw, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(raw, v) == nil { // #nosec G304 -- Path is built from the config dir and a fixed name.
+				return nil
+			}
+		}
+	}
+
+	raw, err := armGet(ctx, path, providersAPIVersion, v)
+	if err != nil {
+		return err
+	}
+
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err == nil {
+			_ = os.WriteFile(cachePath, raw, 0o600)
+		}
+	}
+	return nil
+}
+
+// armGet sends an authenticated GET for an ARM path, decodes the JSON response
+// into v, and returns the raw body.
+func armGet(ctx context.Context, path, apiVersion string, v any) ([]byte, error) {
+	return armDo(ctx, http.MethodGet, path, apiVersion, nil, v)
+}
+
+// armDo sends an authenticated request for an ARM path with an optional JSON
+// body, decodes the JSON response into v, and returns the raw body. The path
+// may carry query parameters of its own. Any status outside 2xx is an error,
+// and v is left as it is when the response has no body.
+func armDo(ctx context.Context, method, path, apiVersion string, body any, v any) ([]byte, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return armRequest(ctx, method, armEndpoint+path+sep+"api-version="+apiVersion, path, body, v)
+}
+
+// armList returns the value entries of every page of an ARM list, following
+// nextLink.
+func armList(ctx context.Context, path, apiVersion string) ([]json.RawMessage, error) {
+	var page struct {
+		Value    []json.RawMessage `json:"value"`
+		NextLink string            `json:"nextLink"`
+	}
+	if _, err := armGet(ctx, path, apiVersion, &page); err != nil {
+		return nil, err
+	}
+	values := page.Value
+	for page.NextLink != "" {
+		next := page.NextLink
+		page.Value, page.NextLink = nil, ""
+		if _, err := armRequest(ctx, http.MethodGet, next, path, nil, &page); err != nil {
+			return nil, err
+		}
+		values = append(values, page.Value...)
+	}
+	return values, nil
+}
+
+// armRequest sends an authenticated request for a full ARM URL with the
+// global timeout, retry, and TLS settings, and records writes in --audit-log.
+// label names the request in errors.
+func armRequest(ctx context.Context, method, rawURL, label string, body any, v any) ([]byte, error) {
+	cfg := snapshotConfig()
+	opts, err := getRequestService().BaseRequestOptions(cfg, method, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.Scope = managementScope
+	tp, err := armTokenProviderFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token provider: %w", err)
+	}
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		opts.Body = bytes.NewReader(raw)
+		opts.Headers = http.Header{"Content-Type": {"application/json"}}
+	}
+	started := time.Now()
+	resp, err := client.NewClient(tp, cfg.Insecure, cfg.Timeout).Execute(ctx, opts)
+	if auditErr := service.RecordAudit(cfg.AuditLog, opts, started, resp, err); auditErr != nil {
+		return nil, fmt.Errorf("request sent but not recorded in the audit log: %w", auditErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s returned %s: %s", method, label, resp.Status, strings.TrimSpace(string(resp.Body)))
+	}
+	if len(bytes.TrimSpace(resp.Body)) == 0 {
+		return resp.Body, nil
+	}
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return nil, fmt.Errorf("failed to parse response from %s: %w", label, err)
+	}
+	return resp.Body, nil
+}
+
+// findAPIVersions looks up resourceType (the part after the namespace, such
+// as "sites/slots") in provider, case-insensitively.
+func findAPIVersions(provider armProvider, fullType, resourceType string) (apiVersionsResult, error) {
+	for _, rt := range provider.ResourceTypes {
+		if !strings.EqualFold(rt.ResourceType, resourceType) {
+			continue
+		}
+		res := apiVersionsResult{
+			ResourceType: provider.Namespace + "/" + rt.ResourceType,
+			APIVersions:  rt.APIVersions,
+		}
+		for _, v := range rt.APIVersions {
+			if !strings.Contains(strings.ToLower(v), "preview") && v > res.LatestStable {
+				res.LatestStable = v
+			}
+		}
+		return res, nil
+	}
+	return apiVersionsResult{}, fmt.Errorf("resource type %s not found in provider %s", fullType, provider.Namespace)
+}
+
+// writeProviders prints one namespace per line, or the namespaces with their
+// resource type names as JSON with --format json.
+func writeProviders(out io.Writer, providers []armProvider, format string) error {
+	if strings.EqualFold(format, "json") {
+		type entry struct {
+			Namespace     string   `json:"namespace"`
+			ResourceTypes []string `json:"resourceTypes"`
+		}
+		entries := make([]entry, 0, len(providers))
+		for _, p := range providers {
+			e := entry{Namespace: p.Namespace, ResourceTypes: make([]string, 0, len(p.ResourceTypes))}
+			for _, rt := range p.ResourceTypes {
+				e.ResourceTypes = append(e.ResourceTypes, rt.ResourceType)
+			}
+			entries = append(entries, e)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	for _, p := range providers {
+		fmt.Fprintln(out, p.Namespace)
+	}
+	return nil
+}
+
+// writeAPIVersions prints one api-version per line as the Providers API
+// orders them (newest first), or the full result as JSON with --format json.
+func writeAPIVersions(out io.Writer, res apiVersionsResult, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	for _, v := range res.APIVersions {
+		fmt.Fprintln(out, v)
+	}
+	return nil
+}
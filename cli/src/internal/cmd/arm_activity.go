@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// activityLogAPIVersion is the Activity Log API version used by arm
+	// activity.
+	activityLogAPIVersion = "2015-04-01"
+	// activityLogRetention is how far back the activity log goes.
+	activityLogRetention = 90 * 24 * time.Hour
+	// activityLogSelect limits the returned fields to the ones shown.
+	activityLogSelect = "eventTimestamp,level,status,subStatus,operationName,resourceId,resourceType,caller,correlationId,properties"
+)
+
+// activityEvent is the subset of an activity log event used here.
+type activityEvent struct {
+	EventTimestamp string            `json:"eventTimestamp"`
+	Level          string            `json:"level"`
+	Status         localizableString `json:"status"`
+	SubStatus      localizableString `json:"subStatus"`
+	OperationName  localizableString `json:"operationName"`
+	ResourceID     string            `json:"resourceId"`
+	Caller         string            `json:"caller"`
+	CorrelationID  string            `json:"correlationId"`
+	Properties     struct {
+		StatusMessage json.RawMessage `json:"statusMessage"`
+	} `json:"properties"`
+}
+
+// localizableString is an activity log value with its display text.
+type localizableString struct {
+	Value string `json:"value"`
+}
+
+// activitySummary is an activity log event as printed with --format json.
+type activitySummary struct {
+	Timestamp     string `json:"timestamp"`
+	Level         string `json:"level"`
+	Status        string `json:"status"`
+	Operation     string `json:"operation"`
+	ResourceID    string `json:"resourceId,omitempty"`
+	Caller        string `json:"caller,omitempty"`
+	CorrelationID string `json:"correlationId"`
+	Message       string `json:"message,omitempty"`
+}
+
+// newARMActivityCommand returns the arm activity subcommand, which reads the
+// subscription activity log.
+func newARMActivityCommand() *cobra.Command {
+	var (
+		subscription  string
+		correlationID string
+		resourceGroup string
+		offset        time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "activity --correlation-id <id>",
+		Short: "Show the activity log events of an operation or resource group",
+		Long: `Show the activity log events of one operation, by its correlation ID, or of a
+resource group, oldest first, with the error message of each failed event.
+
+A deployment's correlation ID is shown by azd rest arm deployments list and
+show; every write ARM made for the deployment shares it. The subscription is
+--subscription or AZURE_SUBSCRIPTION_ID from the azd environment. --offset
+sets how far back to look, up to the 90 days the activity log keeps.`,
+		Example: `  azd rest arm activity --correlation-id 9a2f7c4e-0000-0000-0000-000000000000
+  azd rest arm activity --resource-group rg-myapp-dev --offset 2h --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if correlationID == "" && resourceGroup == "" {
+				return fmt.Errorf("--correlation-id or --resource-group is required")
+			}
+			if offset <= 0 || offset > activityLogRetention {
+				return fmt.Errorf("--offset must be between 1s and %s, got %s", activityLogRetention, offset)
+			}
+			ctx := commandContext(cmd)
+			subscription, err := armSubscription(ctx, subscription)
+			if err != nil {
+				return err
+			}
+			filter := activityFilter(time.Now().Add(-offset), correlationID, resourceGroup)
+			path := "/subscriptions/" + url.PathEscape(subscription) + "/providers/Microsoft.Insights/eventtypes/management/values" +
+				"?$filter=" + url.QueryEscape(filter) + "&$select=" + url.QueryEscape(activityLogSelect)
+			raw, err := armList(ctx, path, activityLogAPIVersion)
+			if err != nil {
+				return err
+			}
+			events := make([]activityEvent, 0, len(raw))
+			for _, r := range raw {
+				var e activityEvent
+				if err := json.Unmarshal(r, &e); err != nil {
+					return fmt.Errorf("failed to parse activity log event: %w", err)
+				}
+				events = append(events, e)
+			}
+			return writeActivity(cmd.OutOrStdout(), events, outputFormat)
+		},
+	}
+	cmd.Flags().StringVar(&subscription, "subscription", "", "Subscription ID (default: AZURE_SUBSCRIPTION_ID from the azd environment)")
+	cmd.Flags().StringVar(&correlationID, "correlation-id", "", "Correlation ID of the operation, such as a deployment's")
+	cmd.Flags().StringVarP(&resourceGroup, "resource-group", "g", "", "Only events in this resource group")
+	cmd.Flags().DurationVar(&offset, "offset", 24*time.Hour, "How far back to look, e.g. 2h or 168h")
+	return cmd
+}
+
+// activityFilter returns the $filter of an activity log query. Values are
+// quoted OData strings, so a quote in one is doubled.
+func activityFilter(since time.Time, correlationID, resourceGroup string) string {
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	filter := "eventTimestamp ge " + quote(since.UTC().Format(time.RFC3339))
+	if correlationID != "" {
+		filter += " and correlationId eq " + quote(correlationID)
+	}
+	if resourceGroup != "" {
+		filter += " and resourceGroupName eq " + quote(resourceGroup)
+	}
+	return filter
+}
+
+// summarizeActivity flattens the events for output, oldest first.
+func summarizeActivity(events []activityEvent) []activitySummary {
+	out := make([]activitySummary, 0, len(events))
+	for _, e := range events {
+		status := e.Status.Value
+		if e.SubStatus.Value != "" {
+			status += " (" + e.SubStatus.Value + ")"
+		}
+		out = append(out, activitySummary{
+			Timestamp:     e.EventTimestamp,
+			Level:         e.Level,
+			Status:        status,
+			Operation:     e.OperationName.Value,
+			ResourceID:    e.ResourceID,
+			Caller:        e.Caller,
+			CorrelationID: e.CorrelationID,
+			Message:       statusMessage(e.Properties.StatusMessage),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	return out
+}
+
+// writeActivity prints the events as a table, or as JSON with --format json.
+// The table shows each resource by name; the JSON output has the full ID.
+func writeActivity(out io.Writer, events []activityEvent, format string) error {
+	summaries := summarizeActivity(events)
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		resource := s.ResourceID[strings.LastIndex(s.ResourceID, "/")+1:]
+		rows = append(rows, []string{s.Timestamp, s.Level, s.Status, s.Operation, resource, tableCell(s.Message)})
+	}
+	_, err := io.WriteString(out, service.FormatTable([]string{"timestamp", "level", "status", "operation", "resource", "message"}, rows))
+	return err
+}
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// deploymentsAPIVersion is the Deployments API version used by the arm
+	// deployments commands.
+	deploymentsAPIVersion = "2021-04-01"
+	// armMessageWidth is the widest message shown in a table cell; the full
+	// text is in the --format json output.
+	armMessageWidth = 120
+)
+
+// isoDurationPattern matches the ISO 8601 durations ARM reports, such as
+// PT1M23.456S.
+var isoDurationPattern = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// armError is the ARM error object, whose details nest to any depth.
+type armError struct {
+	Code    string     `json:"code"`
+	Message string     `json:"message"`
+	Details []armError `json:"details"`
+}
+
+// deployment is the subset of a deployment used here.
+type deployment struct {
+	Name       string `json:"name"`
+	Properties struct {
+		ProvisioningState string    `json:"provisioningState"`
+		Timestamp         string    `json:"timestamp"`
+		Duration          string    `json:"duration"`
+		CorrelationID     string    `json:"correlationId"`
+		Error             *armError `json:"error"`
+	} `json:"properties"`
+}
+
+// deploymentOperation is the subset of a deployment operation used here.
+type deploymentOperation struct {
+	Properties struct {
+		ProvisioningState     string          `json:"provisioningState"`
+		ProvisioningOperation string          `json:"provisioningOperation"`
+		Timestamp             string          `json:"timestamp"`
+		StatusCode            string          `json:"statusCode"`
+		StatusMessage         json.RawMessage `json:"statusMessage"`
+		TargetResource        struct {
+			ID           string `json:"id"`
+			ResourceType string `json:"resourceType"`
+			ResourceName string `json:"resourceName"`
+		} `json:"targetResource"`
+	} `json:"properties"`
+}
+
+// deploymentSummary is a deployment as printed with --format json.
+type deploymentSummary struct {
+	Name          string `json:"name"`
+	State         string `json:"state"`
+	Timestamp     string `json:"timestamp"`
+	Duration      string `json:"duration,omitempty"`
+	CorrelationID string `json:"correlationId"`
+	Error         string `json:"error,omitempty"`
+}
+
+// operationSummary is a deployment operation as printed with --format json.
+type operationSummary struct {
+	State        string `json:"state"`
+	Operation    string `json:"operation,omitempty"`
+	ResourceType string `json:"resourceType,omitempty"`
+	ResourceName string `json:"resourceName,omitempty"`
+	ResourceID   string `json:"resourceId,omitempty"`
+	StatusCode   string `json:"statusCode,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// newARMDeploymentsCommand returns the arm deployments subcommand, which
+// lists deployments and shows one with its operations.
+func newARMDeploymentsCommand() *cobra.Command {
+	var subscription, resourceGroup string
+	deploymentsCmd := &cobra.Command{
+		Use:   "deployments",
+		Short: "List deployments and show their failed operations",
+		Long: `List Resource Manager deployments and show one with its operations, failed
+ones first, to find out why an azd provision failed.
+
+Without --resource-group the subscription-scope deployments are used, which is
+where azd provision deploys by default. The subscription is --subscription or
+AZURE_SUBSCRIPTION_ID from the azd environment.`,
+	}
+	deploymentsCmd.PersistentFlags().StringVar(&subscription, "subscription", "", "Subscription ID (default: AZURE_SUBSCRIPTION_ID from the azd environment)")
+	deploymentsCmd.PersistentFlags().StringVarP(&resourceGroup, "resource-group", "g", "", "Resource group of the deployments (default: subscription scope)")
+
+	var top int
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deployments, newest first",
+		Example: `  azd rest arm deployments list
+  azd rest arm deployments list --resource-group rg-myapp-dev --top 5 --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if top < 0 {
+				return fmt.Errorf("--top must not be negative, got %d", top)
+			}
+			ctx := commandContext(cmd)
+			scope, err := deploymentScope(ctx, subscription, resourceGroup)
+			if err != nil {
+				return err
+			}
+			deployments, err := listDeployments(ctx, scope)
+			if err != nil {
+				return err
+			}
+			if top > 0 && len(deployments) > top {
+				deployments = deployments[:top]
+			}
+			return writeDeployments(cmd.OutOrStdout(), deployments, outputFormat)
+		},
+	}
+	listCmd.Flags().IntVar(&top, "top", 20, "Show at most this many deployments (0 for all)")
+
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show a deployment and its operations (default: the newest deployment)",
+		Example: `  azd rest arm deployments show
+  azd rest arm deployments show dev-1717171717 --resource-group rg-myapp-dev --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext(cmd)
+			scope, err := deploymentScope(ctx, subscription, resourceGroup)
+			if err != nil {
+				return err
+			}
+			var d deployment
+			if len(args) == 1 {
+				if _, err := armGet(ctx, scope+"/"+url.PathEscape(args[0]), deploymentsAPIVersion, &d); err != nil {
+					return err
+				}
+			} else {
+				deployments, err := listDeployments(ctx, scope)
+				if err != nil {
+					return err
+				}
+				if len(deployments) == 0 {
+					return fmt.Errorf("no deployments found at %s", strings.TrimSuffix(scope, "/providers/Microsoft.Resources/deployments"))
+				}
+				d = deployments[0]
+			}
+			raw, err := armList(ctx, scope+"/"+url.PathEscape(d.Name)+"/operations", deploymentsAPIVersion)
+			if err != nil {
+				return err
+			}
+			ops := make([]deploymentOperation, 0, len(raw))
+			for _, r := range raw {
+				var op deploymentOperation
+				if err := json.Unmarshal(r, &op); err != nil {
+					return fmt.Errorf("failed to parse deployment operation: %w", err)
+				}
+				ops = append(ops, op)
+			}
+			return writeDeployment(cmd.OutOrStdout(), d, ops, outputFormat)
+		},
+	}
+
+	deploymentsCmd.AddCommand(listCmd, showCmd)
+	return deploymentsCmd
+}
+
+// armSubscription returns the subscription flag, or AZURE_SUBSCRIPTION_ID
+// from the azd environment.
+func armSubscription(ctx context.Context, subscription string) (string, error) {
+	if subscription = strings.TrimSpace(subscription); subscription != "" {
+		return subscription, nil
+	}
+	azdCtx, err := azdContextLoader(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the azd subscription: %w", err)
+	}
+	if azdCtx.SubscriptionID == "" {
+		return "", fmt.Errorf("--subscription is required when %s is not set in the azd environment", azdcontext.SubscriptionIDKey)
+	}
+	return azdCtx.SubscriptionID, nil
+}
+
+// deploymentScope returns the Deployments API path at the subscription or
+// resource group scope.
+func deploymentScope(ctx context.Context, subscription, resourceGroup string) (string, error) {
+	subscription, err := armSubscription(ctx, subscription)
+	if err != nil {
+		return "", err
+	}
+	scope := "/subscriptions/" + url.PathEscape(subscription)
+	if resourceGroup != "" {
+		scope += "/resourceGroups/" + url.PathEscape(resourceGroup)
+	}
+	return scope + "/providers/Microsoft.Resources/deployments", nil
+}
+
+// listDeployments returns every deployment at scope, newest first.
+func listDeployments(ctx context.Context, scope string) ([]deployment, error) {
+	raw, err := armList(ctx, scope, deploymentsAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]deployment, 0, len(raw))
+	for _, r := range raw {
+		var d deployment
+		if err := json.Unmarshal(r, &d); err != nil {
+			return nil, fmt.Errorf("failed to parse deployment: %w", err)
+		}
+		deployments = append(deployments, d)
+	}
+	// RFC 3339 timestamps in UTC sort as strings.
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].Properties.Timestamp > deployments[j].Properties.Timestamp
+	})
+	return deployments, nil
+}
+
+// summarizeDeployment flattens a deployment for output.
+func summarizeDeployment(d deployment) deploymentSummary {
+	s := deploymentSummary{
+		Name:          d.Name,
+		State:         d.Properties.ProvisioningState,
+		Timestamp:     d.Properties.Timestamp,
+		Duration:      formatISODuration(d.Properties.Duration),
+		CorrelationID: d.Properties.CorrelationID,
+	}
+	if d.Properties.Error != nil {
+		s.Error = armErrorMessage(*d.Properties.Error)
+	}
+	return s
+}
+
+// summarizeOperations flattens deployment operations for output, failed
+// operations first and otherwise in the order ARM returned them. An
+// operation on the nested deployment itself has no resource name, so the
+// last segment of its target ID is used.
+func summarizeOperations(ops []deploymentOperation) []operationSummary {
+	out := make([]operationSummary, 0, len(ops))
+	for _, op := range ops {
+		p := op.Properties
+		s := operationSummary{
+			State:        p.ProvisioningState,
+			Operation:    p.ProvisioningOperation,
+			ResourceType: p.TargetResource.ResourceType,
+			ResourceName: p.TargetResource.ResourceName,
+			ResourceID:   p.TargetResource.ID,
+			StatusCode:   p.StatusCode,
+			Message:      statusMessage(p.StatusMessage),
+		}
+		if s.ResourceName == "" && s.ResourceID != "" {
+			s.ResourceName = s.ResourceID[strings.LastIndex(s.ResourceID, "/")+1:]
+		}
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.EqualFold(out[i].State, "Failed") && !strings.EqualFold(out[j].State, "Failed")
+	})
+	return out
+}
+
+// armErrorMessage returns code: message for the innermost errors, which
+// carry the actual cause; the outer ones only say that something failed.
+func armErrorMessage(e armError) string {
+	var leaves []string
+	var walk func(armError)
+	walk = func(e armError) {
+		if len(e.Details) == 0 {
+			switch {
+			case e.Code != "" && e.Message != "":
+				leaves = append(leaves, e.Code+": "+strings.TrimSpace(e.Message))
+			case e.Code != "" || e.Message != "":
+				leaves = append(leaves, strings.TrimSpace(e.Code+e.Message))
+			}
+			return
+		}
+		for _, d := range e.Details {
+			walk(d)
+		}
+	}
+	walk(e)
+	return strings.Join(leaves, "; ")
+}
+
+// statusMessage returns the message of an operation or activity log status
+// message, which is an ARM error object, an object wrapping one, a JSON
+// string holding either, or plain text.
+func statusMessage(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		if !strings.HasPrefix(strings.TrimSpace(text), "{") {
+			return text
+		}
+		raw = json.RawMessage(text)
+	}
+	var wrapper struct {
+		Error   *armError `json:"error"`
+		Message string    `json:"message"`
+		Code    string    `json:"code"`
+		Status  string    `json:"status"`
+	}
+	if json.Unmarshal(raw, &wrapper) != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	switch {
+	case wrapper.Error != nil:
+		return armErrorMessage(*wrapper.Error)
+	case wrapper.Message != "":
+		return armErrorMessage(armError{Code: wrapper.Code, Message: wrapper.Message})
+	default:
+		return wrapper.Status
+	}
+}
+
+// formatISODuration turns an ARM duration such as PT1M23.456S into 1m23s.
+// Anything else is returned as is.
+func formatISODuration(iso string) string {
+	m := isoDurationPattern.FindStringSubmatch(iso)
+	if m == nil || iso == "PT" {
+		return iso
+	}
+	var d time.Duration
+	if m[1] != "" {
+		h, _ := strconv.Atoi(m[1])
+		d += time.Duration(h) * time.Hour
+	}
+	if m[2] != "" {
+		mins, _ := strconv.Atoi(m[2])
+		d += time.Duration(mins) * time.Minute
+	}
+	if m[3] != "" {
+		secs, _ := strconv.ParseFloat(m[3], 64)
+		d += time.Duration(secs * float64(time.Second))
+	}
+	return d.Round(time.Second).String()
+}
+
+// tableCell shortens a message to one line of at most armMessageWidth runes.
+func tableCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > armMessageWidth {
+		return string(r[:armMessageWidth-3]) + "..."
+	}
+	return s
+}
+
+// writeDeployments prints the deployments as a table, or as JSON with
+// --format json.
+func writeDeployments(out io.Writer, deployments []deployment, format string) error {
+	summaries := make([]deploymentSummary, 0, len(deployments))
+	for _, d := range deployments {
+		summaries = append(summaries, summarizeDeployment(d))
+	}
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		rows = append(rows, []string{s.Name, s.State, s.Timestamp, s.Duration, s.CorrelationID})
+	}
+	_, err := io.WriteString(out, service.FormatTable([]string{"name", "state", "timestamp", "duration", "correlation id"}, rows))
+	return err
+}
+
+// writeDeployment prints a deployment's summary followed by a table of its
+// operations, or both as JSON with --format json.
+func writeDeployment(out io.Writer, d deployment, ops []deploymentOperation, format string) error {
+	summary := summarizeDeployment(d)
+	operations := summarizeOperations(ops)
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			deploymentSummary
+			Operations []operationSummary `json:"operations"`
+		}{summary, operations})
+	}
+	for _, field := range [][2]string{
+		{"Name", summary.Name},
+		{"State", summary.State},
+		{"Timestamp", summary.Timestamp},
+		{"Duration", summary.Duration},
+		{"Correlation ID", summary.CorrelationID},
+		{"Error", summary.Error},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(out, "%-15s %s\n", field[0]+":", field[1])
+		}
+	}
+	if len(operations) == 0 {
+		return nil
+	}
+	rows := make([][]string, 0, len(operations))
+	for _, op := range operations {
+		rows = append(rows, []string{op.State, op.Operation, op.ResourceType, op.ResourceName, op.StatusCode, tableCell(op.Message)})
+	}
+	_, err := io.WriteString(out, "\n"+service.FormatTable([]string{"state", "operation", "resource type", "resource name", "status", "message"}, rows))
+	return err
+}
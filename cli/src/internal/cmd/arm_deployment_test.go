@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFailedDeployment = `{"name":"dev-2","properties":{"provisioningState":"Failed","timestamp":"2024-06-02T10:00:00Z","duration":"PT1M23.456S","correlationId":"corr-2",
+		"error":{"code":"DeploymentFailed","message":"At least one resource deployment operation failed.","details":[{"code":"Conflict","message":"Website with given name app-x already exists."}]}}}`
+	testDeploymentOperations = `{"value":[
+		{"properties":{"provisioningState":"Succeeded","provisioningOperation":"Create","statusCode":"OK","targetResource":{"id":"/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Web/serverfarms/plan-x","resourceType":"Microsoft.Web/serverfarms","resourceName":"plan-x"}}},
+		{"properties":{"provisioningState":"Failed","provisioningOperation":"Create","statusCode":"Conflict",
+			"statusMessage":{"status":"Failed","error":{"code":"Conflict","message":"Website with given name app-x already exists."}},
+			"targetResource":{"id":"/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Web/sites/app-x","resourceType":"Microsoft.Web/sites","resourceName":"app-x"}}}
+	]}`
+)
+
+// withDeploymentServer points the arm commands at a fake Resource Manager
+// serving two pages of subscription-scope deployments, their operations, and
+// the activity log, and returns the $filter of the last activity query.
+func withDeploymentServer(t *testing.T) *string {
+	t.Helper()
+	var filter string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch strings.ToLower(r.URL.Path) {
+		case "/subscriptions/sub-1/providers/microsoft.resources/deployments":
+			if q.Get("page") == "" {
+				_, _ = w.Write([]byte(`{"value":[{"name":"dev-1","properties":{"provisioningState":"Succeeded","timestamp":"2024-06-01T10:00:00Z","duration":"PT2M","correlationId":"corr-1"}}],
+					"nextLink":"` + srv.URL + r.URL.Path + `?page=2&api-version=` + deploymentsAPIVersion + `"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"value":[` + testFailedDeployment + `]}`))
+		case "/subscriptions/sub-1/providers/microsoft.resources/deployments/dev-2":
+			_, _ = w.Write([]byte(testFailedDeployment))
+		case "/subscriptions/sub-1/providers/microsoft.resources/deployments/dev-2/operations":
+			_, _ = w.Write([]byte(testDeploymentOperations))
+		case "/subscriptions/sub-1/providers/microsoft.insights/eventtypes/management/values":
+			assert.Equal(t, activityLogAPIVersion, q.Get("api-version"))
+			filter = q.Get("$filter")
+			_, _ = w.Write([]byte(`{"value":[
+				{"eventTimestamp":"2024-06-02T10:01:00Z","level":"Error","status":{"value":"Failed"},"subStatus":{"value":"Conflict"},"operationName":{"value":"Microsoft.Web/sites/write"},
+				 "resourceId":"/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Web/sites/app-x","correlationId":"corr-2",
+				 "properties":{"statusMessage":"{\"error\":{\"code\":\"Conflict\",\"message\":\"Website with given name app-x already exists.\"}}"}},
+				{"eventTimestamp":"2024-06-02T10:00:05Z","level":"Informational","status":{"value":"Started"},"operationName":{"value":"Microsoft.Web/sites/write"},
+				 "resourceId":"/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Web/sites/app-x","correlationId":"corr-2","properties":{}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"DeploymentNotFound"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	origEndpoint, origFactory, origLoader := armEndpoint, armTokenProviderFactory, azdContextLoader
+	armEndpoint = srv.URL
+	armTokenProviderFactory = func() (client.TokenProvider, error) {
+		return &client.MockTokenProvider{Token: "arm-token"}, nil
+	}
+	azdContextLoader = func(context.Context) (azdcontext.Context, error) {
+		return azdcontext.Context{SubscriptionID: "sub-1"}, nil
+	}
+	t.Cleanup(func() {
+		armEndpoint, armTokenProviderFactory, azdContextLoader = origEndpoint, origFactory, origLoader
+		outputFormat = ""
+	})
+	return &filter
+}
+
+func TestARMDeploymentsList(t *testing.T) {
+	withDeploymentServer(t)
+	outputFormat = ""
+
+	out, err := runARMCommand(t, "deployments", "list")
+	require.NoError(t, err)
+	assert.Equal(t, `NAME   STATE      TIMESTAMP             DURATION  CORRELATION ID
+-----  ---------  --------------------  --------  --------------
+dev-2  Failed     2024-06-02T10:00:00Z  1m23s     corr-2
+dev-1  Succeeded  2024-06-01T10:00:00Z  2m0s      corr-1
+`, out)
+
+	outputFormat = "json"
+	out, err = runARMCommand(t, "deployments", "list", "--top", "1")
+	require.NoError(t, err)
+	var summaries []deploymentSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &summaries))
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "Conflict: Website with given name app-x already exists.", summaries[0].Error)
+}
+
+func TestARMDeploymentsShow(t *testing.T) {
+	withDeploymentServer(t)
+	outputFormat = ""
+
+	out, err := runARMCommand(t, "deployments", "show")
+	require.NoError(t, err, "the newest deployment is shown by default")
+	assert.Equal(t, `Name:           dev-2
+State:          Failed
+Timestamp:      2024-06-02T10:00:00Z
+Duration:       1m23s
+Correlation ID: corr-2
+Error:          Conflict: Website with given name app-x already exists.
+
+STATE      OPERATION  RESOURCE TYPE              RESOURCE NAME  STATUS    MESSAGE
+---------  ---------  -------------------------  -------------  --------  -------------------------------------------------------
+Failed     Create     Microsoft.Web/sites        app-x          Conflict  Conflict: Website with given name app-x already exists.
+Succeeded  Create     Microsoft.Web/serverfarms  plan-x         OK
+`, out)
+
+	_, err = runARMCommand(t, "deployments", "show", "missing")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestARMActivity(t *testing.T) {
+	filter := withDeploymentServer(t)
+	outputFormat = ""
+
+	out, err := runARMCommand(t, "activity", "--correlation-id", "corr-2", "--offset", "2h")
+	require.NoError(t, err)
+	assert.Equal(t, `TIMESTAMP             LEVEL          STATUS             OPERATION                  RESOURCE  MESSAGE
+--------------------  -------------  -----------------  -------------------------  --------  -------------------------------------------------------
+2024-06-02T10:00:05Z  Informational  Started            Microsoft.Web/sites/write  app-x
+2024-06-02T10:01:00Z  Error          Failed (Conflict)  Microsoft.Web/sites/write  app-x     Conflict: Website with given name app-x already exists.
+`, out)
+	assert.Contains(t, *filter, "eventTimestamp ge '")
+	assert.True(t, strings.HasSuffix(*filter, " and correlationId eq 'corr-2'"), *filter)
+
+	_, err = runARMCommand(t, "activity")
+	assert.EqualError(t, err, "--correlation-id or --resource-group is required")
+	_, err = runARMCommand(t, "activity", "--resource-group", "rg", "--offset", "2400h")
+	assert.EqualError(t, err, "--offset must be between 1s and 2160h0m0s, got 2400h0m0s")
+}
+
+func TestActivityFilter(t *testing.T) {
+	since := time.Date(2024, 6, 2, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, "eventTimestamp ge '2024-06-02T08:00:00Z' and resourceGroupName eq 'rg''x'", activityFilter(since, "", "rg'x"))
+}
+
+func TestFormatISODuration(t *testing.T) {
+	assert.Equal(t, "1h2m3s", formatISODuration("PT1H2M3.4S"))
+	assert.Equal(t, "45s", formatISODuration("PT44.6S"))
+	assert.Equal(t, "P1D", formatISODuration("P1D"))
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// tagsAPIVersion is the Tags API version used by the arm tag commands.
+const tagsAPIVersion = "2021-04-01"
+
+// Tags API PATCH operations.
+const (
+	tagOperationMerge  = "Merge"
+	tagOperationDelete = "Delete"
+)
+
+// tagsResource is the Tags API resource at a scope.
+type tagsResource struct {
+	Properties struct {
+		Tags map[string]string `json:"tags"`
+	} `json:"properties"`
+}
+
+// tagsPatch is the Tags API PATCH request body.
+type tagsPatch struct {
+	Operation  string `json:"operation"`
+	Properties struct {
+		Tags map[string]string `json:"tags"`
+	} `json:"properties"`
+}
+
+// newARMTagCommand returns the arm tag subcommand, which reads and updates the
+// tags at a resource, resource group, or subscription scope.
+func newARMTagCommand() *cobra.Command {
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: "List, add, or remove tags on a resource",
+		Long: `List, add, or remove tags through the Resource Manager Tags API.
+
+The target is a resource ID, resource group ID, or subscription ID. add and
+remove only touch the named tags; every other tag is left as it is.`,
+	}
+	tagCmd.AddCommand(
+		&cobra.Command{
+			Use:   "list <resourceId>",
+			Short: "List the tags on a resource",
+			Example: `  azd rest arm tag list /subscriptions/{sub}/resourceGroups/{rg}
+  azd rest arm tag list /subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Web/sites/{name} --format json`,
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				scope, err := tagScope(args[0])
+				if err != nil {
+					return err
+				}
+				tags, err := getTags(commandContext(cmd), scope)
+				if err != nil {
+					return err
+				}
+				return writeTags(cmd.OutOrStdout(), tags, outputFormat)
+			},
+		},
+		&cobra.Command{
+			Use:     "add <resourceId> <key=value>...",
+			Short:   "Add or update tags on a resource",
+			Example: `  azd rest arm tag add /subscriptions/{sub}/resourceGroups/{rg} env=dev owner=platform`,
+			Args:    cobra.MinimumNArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				scope, err := tagScope(args[0])
+				if err != nil {
+					return err
+				}
+				tags := make(map[string]string, len(args)-1)
+				for _, arg := range args[1:] {
+					key, value, ok := strings.Cut(arg, "=")
+					if !ok || strings.TrimSpace(key) == "" {
+						return fmt.Errorf("invalid tag %q (expected key=value)", arg)
+					}
+					tags[strings.TrimSpace(key)] = value
+				}
+				updated, err := patchTags(commandContext(cmd), scope, tagOperationMerge, tags)
+				if err != nil {
+					return err
+				}
+				return writeTags(cmd.OutOrStdout(), updated, outputFormat)
+			},
+		},
+		&cobra.Command{
+			Use:     "remove <resourceId> <key>...",
+			Short:   "Remove tags from a resource",
+			Example: `  azd rest arm tag remove /subscriptions/{sub}/resourceGroups/{rg} env owner`,
+			Args:    cobra.MinimumNArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				scope, err := tagScope(args[0])
+				if err != nil {
+					return err
+				}
+				ctx := commandContext(cmd)
+				current, err := getTags(ctx, scope)
+				if err != nil {
+					return err
+				}
+				// The Delete operation removes a tag only when its value matches,
+				// so look up the current value of each named tag. Tag names are
+				// case-insensitive.
+				remove := make(map[string]string)
+				for _, key := range args[1:] {
+					for name, value := range current {
+						if strings.EqualFold(name, key) {
+							remove[name] = value
+						}
+					}
+				}
+				if len(remove) == 0 {
+					return writeTags(cmd.OutOrStdout(), current, outputFormat)
+				}
+				updated, err := patchTags(ctx, scope, tagOperationDelete, remove)
+				if err != nil {
+					return err
+				}
+				return writeTags(cmd.OutOrStdout(), updated, outputFormat)
+			},
+		},
+	)
+	return tagCmd
+}
+
+// tagScope validates a resource ID and returns the Tags API path for it.
+func tagScope(resourceID string) (string, error) {
+	id := strings.TrimRight(strings.TrimSpace(resourceID), "/")
+	if !strings.HasPrefix(strings.ToLower(id), "/subscriptions/") {
+		return "", fmt.Errorf("invalid resource ID %q (expected /subscriptions/...)", resourceID)
+	}
+	return id + "/providers/Microsoft.Resources/tags/default", nil
+}
+
+// getTags returns the tags at a Tags API path.
+func getTags(ctx context.Context, path string) (map[string]string, error) {
+	var res tagsResource
+	if _, err := armGet(ctx, path, tagsAPIVersion, &res); err != nil {
+		return nil, err
+	}
+	return res.Properties.Tags, nil
+}
+
+// patchTags applies a Merge or Delete operation and returns the resulting tags.
+// When the PATCH is accepted without a body, as with 202 or 204, the tags are
+// read back.
+func patchTags(ctx context.Context, path, operation string, tags map[string]string) (map[string]string, error) {
+	body := tagsPatch{Operation: operation}
+	body.Properties.Tags = tags
+	var res tagsResource
+	raw, err := armDo(ctx, http.MethodPatch, path, tagsAPIVersion, body, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return getTags(ctx, path)
+	}
+	return res.Properties.Tags, nil
+}
+
+// writeTags prints key=value lines sorted by key, or the tags as a JSON object
+// with --format json.
+func writeTags(out io.Writer, tags map[string]string, format string) error {
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tags)
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(out, "%s=%s\n", k, tags[k])
+	}
+	return nil
+}
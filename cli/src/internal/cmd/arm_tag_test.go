@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTagRG = "/subscriptions/sub1/resourceGroups/rg1"
+
+// withTagServer points the arm commands at a local Tags API that keeps the
+// tags of testTagRG in memory. It returns the PATCH bodies it received.
+func withTagServer(t *testing.T, initial map[string]string) *[]tagsPatch {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		tags    = initial
+		patches []tagsPatch
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, tagsAPIVersion, r.URL.Query().Get("api-version"))
+		if r.URL.Path != testTagRG+"/providers/Microsoft.Resources/tags/default" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var p tagsPatch
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			patches = append(patches, p)
+			for k, v := range p.Properties.Tags {
+				switch p.Operation {
+				case tagOperationMerge:
+					tags[k] = v
+				case tagOperationDelete:
+					if tags[k] == v {
+						delete(tags, k)
+					}
+				}
+			}
+		}
+		var res tagsResource
+		res.Properties.Tags = tags
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+
+	origEndpoint, origFactory := armEndpoint, armTokenProviderFactory
+	armEndpoint = srv.URL
+	armTokenProviderFactory = func() (client.TokenProvider, error) {
+		return &client.MockTokenProvider{Token: "arm-token"}, nil
+	}
+	t.Cleanup(func() {
+		armEndpoint, armTokenProviderFactory = origEndpoint, origFactory
+		outputFormat = ""
+	})
+	return &patches
+}
+
+func TestARMTagList(t *testing.T) {
+	withTagServer(t, map[string]string{"owner": "me", "env": "prod"})
+
+	out, err := runARMCommand(t, "tag", "list", testTagRG+"/")
+	require.NoError(t, err)
+	assert.Equal(t, "env=prod\nowner=me\n", out)
+
+	outputFormat = "json"
+	out, err = runARMCommand(t, "tag", "list", testTagRG)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"owner":"me","env":"prod"}`, out)
+}
+
+func TestARMTagAdd(t *testing.T) {
+	patches := withTagServer(t, map[string]string{"owner": "me"})
+
+	out, err := runARMCommand(t, "tag", "add", testTagRG, "env=dev", "note=a=b")
+	require.NoError(t, err)
+	assert.Equal(t, "env=dev\nnote=a=b\nowner=me\n", out)
+	require.Len(t, *patches, 1)
+	assert.Equal(t, tagOperationMerge, (*patches)[0].Operation)
+	assert.Equal(t, map[string]string{"env": "dev", "note": "a=b"}, (*patches)[0].Properties.Tags)
+}
+
+func TestARMTagRemove(t *testing.T) {
+	patches := withTagServer(t, map[string]string{"Owner": "me", "env": "prod"})
+
+	out, err := runARMCommand(t, "tag", "remove", testTagRG, "owner", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, "env=prod\n", out)
+	require.Len(t, *patches, 1)
+	assert.Equal(t, tagOperationDelete, (*patches)[0].Operation)
+	assert.Equal(t, map[string]string{"Owner": "me"}, (*patches)[0].Properties.Tags, "the current value is sent so Delete matches")
+
+	_, err = runARMCommand(t, "tag", "remove", testTagRG, "missing")
+	require.NoError(t, err)
+	assert.Len(t, *patches, 1, "nothing to remove sends no PATCH")
+}
+
+func TestARMTag_Errors(t *testing.T) {
+	withTagServer(t, map[string]string{})
+
+	_, err := runARMCommand(t, "tag", "list", "rg1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected /subscriptions/")
+
+	_, err = runARMCommand(t, "tag", "add", testTagRG, "novalue")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected key=value")
+
+	_, err = runARMCommand(t, "tag", "list", "/subscriptions/other")
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "404"), err.Error())
+}
+
+func TestARMTagAdd_AcceptedWithoutBody(t *testing.T) {
+	for _, status := range []int{http.StatusAccepted, http.StatusNoContent} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var patched bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPatch {
+					patched = true
+					w.WriteHeader(status)
+					return
+				}
+				var res tagsResource
+				res.Properties.Tags = map[string]string{"env": "dev"}
+				_ = json.NewEncoder(w).Encode(res)
+			}))
+			defer srv.Close()
+			origEndpoint, origFactory := armEndpoint, armTokenProviderFactory
+			armEndpoint = srv.URL
+			armTokenProviderFactory = func() (client.TokenProvider, error) {
+				return &client.MockTokenProvider{Token: "arm-token"}, nil
+			}
+			t.Cleanup(func() { armEndpoint, armTokenProviderFactory = origEndpoint, origFactory })
+
+			out, err := runARMCommand(t, "tag", "add", testTagRG, "env=dev")
+			require.NoError(t, err)
+			assert.True(t, patched)
+			assert.Equal(t, "env=dev\n", out, "the tags are read back")
+		})
+	}
+}
+
+func TestARMTag_UsesGlobalTransportSettings(t *testing.T) {
+	patches := withTagServer(t, map[string]string{})
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	blockPrivate = true
+
+	_, err := runARMCommand(t, "tag", "add", testTagRG, "env=dev")
+	assert.ErrorContains(t, err, "--block-private-networks")
+	assert.Empty(t, *patches, "the PATCH is not sent")
+}
+
+func TestARMTagAdd_RecordsAuditLog(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	withTagServer(t, map[string]string{})
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	auditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+
+	_, err := runARMCommand(t, "tag", "list", testTagRG)
+	require.NoError(t, err)
+	_, err = runARMCommand(t, "tag", "add", testTagRG, "env=dev")
+	require.NoError(t, err)
+
+	entries := readAuditLog(t, auditLog)
+	require.Len(t, entries, 1, "the GET is not recorded")
+	assert.Equal(t, http.MethodPatch, entries[0].Method)
+	assert.Equal(t, http.StatusOK, entries[0].StatusCode)
+	assert.Contains(t, entries[0].URL, "/providers/Microsoft.Resources/tags/default")
+}
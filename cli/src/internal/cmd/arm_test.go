@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebProvider = `{
+  "namespace": "Microsoft.Web",
+  "resourceTypes": [
+    {"resourceType": "sites", "apiVersions": ["2024-04-01-preview", "2023-12-01", "2022-09-01"]},
+    {"resourceType": "sites/slots", "apiVersions": ["2023-12-01"]}
+  ]
+}`
+
+// withARMServer points the arm commands at a local server and a temp cache
+// directory, and returns a counter of requests the server received.
+func withARMServer(t *testing.T) *int32 {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "Bearer arm-token", r.Header.Get("Authorization"))
+		assert.Equal(t, providersAPIVersion, r.URL.Query().Get("api-version"))
+		switch strings.ToLower(r.URL.Path) { // ARM paths are case-insensitive
+		case "/providers":
+			_, _ = w.Write([]byte(`{"value": [` + testWebProvider + `, {"namespace": "Microsoft.Storage", "resourceTypes": []}]}`))
+		case "/providers/microsoft.web":
+			_, _ = w.Write([]byte(testWebProvider))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"InvalidResourceNamespace"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cacheDir := t.TempDir()
+	origEndpoint, origFactory, origCache := armEndpoint, armTokenProviderFactory, armCacheDir
+	armEndpoint = srv.URL
+	armTokenProviderFactory = func() (client.TokenProvider, error) {
+		return &client.MockTokenProvider{Token: "arm-token"}, nil
+	}
+	armCacheDir = func() (string, error) { return cacheDir, nil }
+	t.Cleanup(func() {
+		armEndpoint, armTokenProviderFactory, armCacheDir = origEndpoint, origFactory, origCache
+		outputFormat = ""
+	})
+	return &calls
+}
+
+func runARMCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewARMCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestARMProviders(t *testing.T) {
+	withARMServer(t)
+	outputFormat = ""
+
+	out, err := runARMCommand(t, "providers")
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft.Web\nMicrosoft.Storage\n", out)
+}
+
+func TestARMAPIVersions(t *testing.T) {
+	withARMServer(t)
+
+	outputFormat = ""
+	out, err := runARMCommand(t, "api-versions", "microsoft.web/SITES")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-04-01-preview\n2023-12-01\n2022-09-01\n", out)
+
+	outputFormat = "json"
+	out, err = runARMCommand(t, "api-versions", "Microsoft.Web/sites/slots")
+	require.NoError(t, err)
+	var res apiVersionsResult
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, "Microsoft.Web/sites/slots", res.ResourceType)
+	assert.Equal(t, "2023-12-01", res.LatestStable)
+}
+
+func TestARMAPIVersions_UsesCache(t *testing.T) {
+	calls := withARMServer(t)
+
+	_, err := runARMCommand(t, "api-versions", "Microsoft.Web/sites")
+	require.NoError(t, err)
+	_, err = runARMCommand(t, "api-versions", "Microsoft.Web/sites/slots")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "second lookup is served from the cache")
+
+	_, err = runARMCommand(t, "api-versions", "Microsoft.Web/sites", "--refresh")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls), "--refresh bypasses the cache")
+}
+
+func TestARMAPIVersions_Errors(t *testing.T) {
+	withARMServer(t)
+
+	_, err := runARMCommand(t, "api-versions", "sites")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected <Namespace>/<type>")
+
+	_, err = runARMCommand(t, "api-versions", "Microsoft.Web/widgets")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found in provider Microsoft.Web")
+
+	_, err = runARMCommand(t, "api-versions", "Microsoft.Nope/things")
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "404"), err.Error())
+}
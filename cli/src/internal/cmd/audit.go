@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCommand returns the audit subcommand, which checks logs written by
+// --audit-log.
+func NewAuditCommand() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Verify audit logs written by --audit-log",
+		Long: `Verify audit logs written by --audit-log.
+
+With --audit-log <file>, every write request (PUT, POST, PATCH, DELETE) is
+appended to the file as a JSON line that includes the hash of the line before
+it. This covers the writes of arm tag, appconfig kv, blob put, and monitor
+ingest as well as plain requests; requests sent by MCP tools are not recorded. Editing, removing, or reordering an entry breaks the chain. Set
+AZD_REST_AUDIT_KEY to sign the entries with HMAC-SHA256 so the chain cannot be
+rebuilt without the key.
+
+Examples:
+  # Record write requests
+  azd rest put https://management.azure.com/... --data-file rg.json --audit-log audit.jsonl
+
+  # Check the log has not been tampered with
+  azd rest audit verify audit.jsonl`,
+	}
+	auditCmd.AddCommand(newAuditVerifyCommand())
+	return auditCmd
+}
+
+func newAuditVerifyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify <file>",
+		Short: "Check the hash chain of an audit log",
+		Long: `Check the hash chain of an audit log. Signed logs are verified with the key in
+AZD_REST_AUDIT_KEY. The first broken entry is reported and the command exits
+non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open audit log: %w", err)
+			}
+			defer f.Close()
+			n, err := audit.Verify(f, audit.Key())
+			if err != nil {
+				return fmt.Errorf("audit log %s failed verification after %d valid entries: %w", args[0], n, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "OK: %d entries verified\n", n)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAuditLog verifies the hash chain of the audit log at path and returns
+// its entries.
+func readAuditLog(t *testing.T, path string) []audit.Entry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	_, err = audit.Verify(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	var entries []audit.Entry
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		var e audit.Entry
+		require.NoError(t, json.Unmarshal(line, &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditVerifyCommand(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, audit.Append(path, nil, audit.Entry{Method: "PUT", URL: "https://management.azure.com/x"}))
+	require.NoError(t, audit.Append(path, nil, audit.Entry{Method: "DELETE", URL: "https://management.azure.com/x"}))
+
+	run := func() (string, error) {
+		cmd := NewAuditCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"verify", path})
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Equal(t, "OK: 2 entries verified\n", out)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, bytes.Replace(data, []byte("DELETE"), []byte("PATCH"), 1), 0o600))
+
+	_, err = run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 1 valid entries")
+	assert.Contains(t, err.Error(), "line 2: hash mismatch")
+}
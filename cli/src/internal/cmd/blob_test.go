@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlobService records uploads and serves a two-page List Blobs response.
+type fakeBlobService struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	blobTypes map[string]string
+	staged    map[string][]byte
+	requests  []string
+}
+
+func newFakeBlobService(t *testing.T) (*fakeBlobService, *httptest.Server) {
+	t.Helper()
+	f := &fakeBlobService{blobs: map[string][]byte{}, blobTypes: map[string]string{}, staged: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeBlobService) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := r.URL.Query()
+	f.requests = append(f.requests, r.Method+" "+q.Get("comp"))
+	if r.Header.Get("x-ms-version") != storageAPIVersion {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<Error><Code>MissingRequiredHeader</Code><Message>x-ms-version</Message></Error>`))
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodGet && q.Get("comp") == "list":
+		if q.Get("marker") == "" {
+			_, _ = w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>a.txt</Name><Properties><Content-Length>3</Content-Length></Properties></Blob></Blobs><NextMarker>m2</NextMarker></EnumerationResults>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>b.txt</Name></Blob></Blobs><NextMarker/></EnumerationResults>`))
+	case r.Method == http.MethodPut && q.Get("comp") == "block":
+		f.staged[q.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		_ = xml.Unmarshal(body, &list)
+		var assembled []byte
+		for _, id := range list.Latest {
+			assembled = append(assembled, f.staged[id]...)
+		}
+		f.blobs[r.URL.Path] = assembled
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		f.blobs[r.URL.Path] = body
+		f.blobTypes[r.URL.Path] = r.Header.Get("x-ms-blob-type")
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>BlobNotFound</Code><Message>The specified blob does not exist.</Message></Error>`))
+	}
+}
+
+func runBlobCommand(t *testing.T, stdin io.Reader, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewBlobCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	if stdin != nil {
+		cmd.SetIn(stdin)
+	}
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestBlobList_FollowsMarkers(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	_, srv := newFakeBlobService(t)
+
+	out, err := runBlobCommand(t, nil, "list", srv.URL+"/c")
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt\nb.txt\n", out)
+
+	outputFormat = "json"
+	out, err = runBlobCommand(t, nil, "list", srv.URL+"/c")
+	require.NoError(t, err)
+	var items []blobItem
+	require.NoError(t, json.Unmarshal([]byte(out), &items))
+	require.Len(t, items, 2)
+	assert.Equal(t, int64(3), items[0].Properties.ContentLength)
+}
+
+func TestBlobPut_SingleBlob(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	silent = true
+	f, srv := newFakeBlobService(t)
+	path := filepath.Join(t.TempDir(), "small.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1}`), 0o600))
+
+	_, err := runBlobCommand(t, nil, "put", srv.URL+"/c/small.json", "--file", path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(f.blobs["/c/small.json"]))
+	assert.Equal(t, "BlockBlob", f.blobTypes["/c/small.json"])
+	assert.Equal(t, []string{"PUT "}, f.requests)
+}
+
+func TestBlobPut_RecordsAuditLog(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	noAuth = true
+	silent = true
+	auditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+	_, srv := newFakeBlobService(t)
+	path := filepath.Join(t.TempDir(), "small.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1}`), 0o600))
+
+	_, err := runBlobCommand(t, nil, "put", srv.URL+"/c/small.json?sv=2024&sig=secret", "--file", path)
+	require.NoError(t, err)
+
+	entries := readAuditLog(t, auditLog)
+	require.Len(t, entries, 1)
+	assert.Equal(t, http.MethodPut, entries[0].Method)
+	assert.Equal(t, http.StatusCreated, entries[0].StatusCode)
+	assert.Contains(t, entries[0].URL, "/c/small.json")
+	assert.NotContains(t, entries[0].URL, "secret", "the SAS signature is redacted")
+}
+
+func TestBlobPut_ChunksLargeUploads(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	silent = true
+	f, srv := newFakeBlobService(t)
+	content := strings.Repeat("0123456789", 25) // 250 bytes, 3 blocks of 100
+
+	_, err := runBlobCommand(t, strings.NewReader(content), "put", srv.URL+"/c/big.bin", "--file", "-", "--block-size", "100")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(f.blobs["/c/big.bin"]))
+	assert.Equal(t, []string{"PUT block", "PUT block", "PUT block", "PUT blocklist"}, f.requests)
+	for id := range f.staged {
+		raw, err := base64.StdEncoding.DecodeString(id)
+		require.NoError(t, err)
+		assert.Len(t, raw, len("block-00000000"), "block IDs share one length")
+	}
+}
+
+func TestBlobPut_Errors(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	_, srv := newFakeBlobService(t)
+
+	_, err := runBlobCommand(t, strings.NewReader("x"), "put", srv.URL+"/c/x", "--file", "-", "--block-size", "0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--block-size")
+
+	_, err = runBlobCommand(t, nil, "put", srv.URL+"/c/x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file")
+}
+
+func TestBlobList_ServiceError(t *testing.T) {
+	resetGlobalFlags()
+	noAuth = true
+	_, srv := newFakeBlobService(t)
+
+	_, err := runBlobCommand(t, nil, "list", srv.URL+"/c?comp=ignored&restype=x")
+	require.NoError(t, err, "restype and comp are overwritten for list")
+
+	bc, err := newBlobClient()
+	require.NoError(t, err)
+	_, err = bc.do(t.Context(), http.MethodDelete, srv.URL+"/c/x", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BlobNotFound")
+}
+
+func TestHasSASSignature(t *testing.T) {
+	assert.True(t, hasSASSignature("https://a.blob.core.windows.net/c/b?sv=2023&sig=abc"))
+	assert.False(t, hasSASSignature("https://a.blob.core.windows.net/c/b"))
+}
+
+func TestBlobOutputSink_UploadsWithSASEndpoint(t *testing.T) {
+	resetGlobalFlags()
+	f, srv := newFakeBlobService(t)
+	file := filepath.Join(t.TempDir(), "resp.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"ok":true}`), 0o600))
+
+	dest, err := url.Parse("az://archive/2026/10/vms.json")
+	require.NoError(t, err)
+	cfg := config.Config{StorageAccount: srv.URL + "/?sv=2023&sig=abc"}
+	require.NoError(t, blobOutputSink{}.Deliver(t.Context(), cfg, dest, file))
+	assert.Equal(t, `{"ok":true}`, string(f.blobs["/archive/2026/10/vms.json"]))
+}
+
+func TestOutputBlobURL(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	dest := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+
+	got, err := outputBlobURL("myaccount", dest("az://logs/a b.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://myaccount.blob.core.windows.net/logs/a%20b.json", got)
+
+	got, err = outputBlobURL("http://127.0.0.1:10000/devstoreaccount1", dest("az://logs/x.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:10000/devstoreaccount1/logs/x.json", got)
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "fromenv")
+	got, err = outputBlobURL("", dest("az://logs/x.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://fromenv.blob.core.windows.net/logs/x.json", got)
+
+	_, err = outputBlobURL("myaccount", dest("az://logs"))
+	assert.ErrorContains(t, err, "needs a container and blob name")
+	_, err = outputBlobURL("My_Account", dest("az://logs/x"))
+	assert.ErrorContains(t, err, "invalid --storage-account")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	_, err = outputBlobURL("", dest("az://logs/x"))
+	assert.ErrorContains(t, err, "needs --storage-account or AZURE_STORAGE_ACCOUNT")
+}
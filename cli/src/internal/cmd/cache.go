@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+// responseCacheDir returns the response cache directory. It is a package
+// variable so tests can use a temp directory.
+var responseCacheDir = cache.Dir
+
+// NewCacheCommand returns the cache subcommand, which inspects and clears the
+// response cache used by --cache.
+func NewCacheCommand() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clear the response cache used by --cache",
+		Long: `Inspect and clear the response cache used by --cache.
+
+Responses are cached per azd environment, so switching environments with
+azd env select never serves a response fetched for another environment.`,
+	}
+	cacheCmd.AddCommand(newCacheStatsCommand(), newCacheClearCommand())
+	return cacheCmd
+}
+
+func newCacheStatsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show cached entries and hit rates per azd environment",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, err := responseCacheDir()
+			if err != nil {
+				return err
+			}
+			stats, err := cache.ReadStats(dir)
+			if err != nil {
+				return err
+			}
+			return writeCacheStats(cmd.OutOrStdout(), stats, outputFormat)
+		},
+	}
+}
+
+func newCacheClearCommand() *cobra.Command {
+	var env string
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove cached responses and their counters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, err := responseCacheDir()
+			if err != nil {
+				return err
+			}
+			all := !cmd.Flags().Changed("env")
+			if err := cache.Clear(dir, env, all); err != nil {
+				return err
+			}
+			if all {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Cleared the response cache")
+			} else {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Cleared the response cache for %s\n", cacheEnvironmentLabel(env))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&env, "env", "", "Clear only this azd environment's responses (empty for requests made outside an environment)")
+	return cmd
+}
+
+// cacheEnvironmentLabel names a partition for display.
+func cacheEnvironmentLabel(env string) string {
+	if env == "" {
+		return "(no environment)"
+	}
+	return env
+}
+
+// writeCacheStats prints one line per environment, or a JSON array with
+// --format json.
+func writeCacheStats(out io.Writer, stats []cache.Stats, format string) error {
+	if strings.EqualFold(format, "json") {
+		if stats == nil {
+			stats = []cache.Stats{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	if len(stats) == 0 {
+		fmt.Fprintln(out, "The response cache is empty")
+		return nil
+	}
+	for _, st := range stats {
+		fmt.Fprintf(out, "%s: %d entries, %d bytes, %d hits, %d misses, %.1f%% hit rate\n",
+			cacheEnvironmentLabel(st.Environment), st.Entries, st.Bytes, st.Hits, st.Misses, st.HitRate*100)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTempCacheDir points the response cache at a temp directory for one test.
+func useTempCacheDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := responseCacheDir
+	responseCacheDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { responseCacheDir = orig })
+	return dir
+}
+
+// runCacheCommand executes azd rest cache with args from a fresh root command.
+func runCacheCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	rootCmd := NewRootCmd()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"cache"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestCacheCommand_StatsAndClear(t *testing.T) {
+	dir := useTempCacheDir(t)
+
+	out, err := runCacheCommand(t, "stats")
+	require.NoError(t, err)
+	assert.Equal(t, "The response cache is empty\n", out)
+
+	key := cache.Key("GET", "https://example.com")
+	require.NoError(t, cache.Put(dir, "dev", key, cache.Entry{StatusCode: 200, Body: []byte("{}"), StoredAt: time.Now()}))
+	cache.Get(dir, "dev", key, time.Minute)
+	cache.Get(dir, "prod", key, time.Minute)
+
+	out, err = runCacheCommand(t, "stats")
+	require.NoError(t, err)
+	assert.Contains(t, out, "dev: 1 entries, ")
+	assert.Contains(t, out, "1 hits, 0 misses, 100.0% hit rate")
+	assert.Contains(t, out, "prod: 0 entries, 0 bytes, 0 hits, 1 misses, 0.0% hit rate")
+
+	out, err = runCacheCommand(t, "stats", "--format", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"environment": "dev"`)
+
+	_, err = runCacheCommand(t, "clear", "--env", "prod")
+	require.NoError(t, err)
+	out, err = runCacheCommand(t, "stats")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "prod")
+
+	_, err = runCacheCommand(t, "clear")
+	require.NoError(t, err)
+	out, err = runCacheCommand(t, "stats")
+	require.NoError(t, err)
+	assert.Equal(t, "The response cache is empty\n", out)
+}
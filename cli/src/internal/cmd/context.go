@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// azdContextLoader resolves the azd environment for the context command. It is
+// a package variable so tests can inject a fixed context.
+var azdContextLoader = azdcontext.Load
+
+// contextResult is the JSON payload emitted by the context command with
+// --format json.
+type contextResult struct {
+	Environment    string   `json:"environment,omitempty"`
+	SubscriptionID string   `json:"subscriptionId,omitempty"`
+	TenantID       string   `json:"tenantId,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	ContextSource  string   `json:"contextSource"`
+	URL            string   `json:"url,omitempty"`
+	Scope          string   `json:"scope,omitempty"`
+	ScopeSource    string   `json:"scopeSource"`
+	Credential     string   `json:"credential"`
+	Notes          []string `json:"notes,omitempty"`
+}
+
+// NewContextCommand returns the context subcommand, which prints the azd
+// environment and the authentication azd rest would use.
+func NewContextCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "context [url]",
+		Short: "Show the resolved azd environment and credential",
+		Long: `Show the azd environment azd rest resolves (name, subscription, tenant, and
+location), where the OAuth scope comes from, and which credential a request
+would use. Use it when a request unexpectedly goes to the wrong tenant or
+subscription.
+
+Pass a URL to preview the scope for that request. The command respects the
+global auth flags (--scope, --no-auth, --auth, and so on) and makes no
+request; use whoami to see the identity behind the Azure credential.`,
+		Example: `  azd rest context
+  azd rest context https://graph.microsoft.com/v1.0/me
+  azd rest context --auth oauth2:partner --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			azdCtx, err := azdContextLoader(commandContext(cmd))
+			if err != nil {
+				return err
+			}
+			var rawURL string
+			if len(args) == 1 {
+				rawURL = args[0]
+			}
+			res, err := buildContextResult(azdCtx, snapshotConfig(), rawURL, os.LookupEnv)
+			if err != nil {
+				return err
+			}
+			return writeContextResult(cmd.OutOrStdout(), res, outputFormat)
+		},
+	}
+}
+
+// buildContextResult combines the azd environment with the scope and
+// credential selection for cfg. rawURL may be empty.
+func buildContextResult(azdCtx azdcontext.Context, cfg config.Config, rawURL string, lookupEnv func(string) (string, bool)) (contextResult, error) {
+	res := contextResult{
+		Environment:    azdCtx.EnvironmentName,
+		SubscriptionID: azdCtx.SubscriptionID,
+		TenantID:       azdCtx.TenantID,
+		Location:       azdCtx.Location,
+		ContextSource:  azdCtx.Source,
+		URL:            rawURL,
+	}
+
+	switch {
+	case rawURL != "":
+		preview, err := resolveScope(rawURL, cfg.Scope, cfg.NoAuth, cfg.Headers)
+		if err != nil {
+			return contextResult{}, err
+		}
+		res.Scope = preview.Scope
+		switch {
+		case preview.AuthMode == authModeNone:
+			res.ScopeSource = preview.Reason
+		case cfg.Scope != "":
+			res.ScopeSource = "--scope flag"
+		case preview.Scope != "":
+			res.ScopeSource = "detected from the URL host"
+		default:
+			res.ScopeSource = "none detected for this host"
+		}
+	case cfg.Scope != "":
+		res.Scope = cfg.Scope
+		res.ScopeSource = "--scope flag"
+	default:
+		res.ScopeSource = "detected per request from the URL host (pass a URL to preview)"
+	}
+
+	res.Credential, res.Notes = describeCredential(cfg, lookupEnv)
+	if envTenant, ok := lookupEnv("AZURE_TENANT_ID"); ok && envTenant != "" && res.TenantID != "" && !strings.EqualFold(envTenant, res.TenantID) {
+		res.Notes = append(res.Notes, fmt.Sprintf("AZURE_TENANT_ID in the process environment (%s) differs from the azd environment tenant", envTenant))
+	}
+	return res, nil
+}
+
+// describeCredential names the credential a request would use under cfg, in
+// the same precedence the request pipeline applies, with notes on anything
+// that changes which identity the Azure credential chain picks.
+func describeCredential(cfg config.Config, lookupEnv func(string) (string, bool)) (string, []string) {
+	mode := strings.TrimSpace(cfg.Auth)
+	switch {
+	case cfg.NoAuth:
+		return "none (--no-auth)", nil
+	case strings.HasPrefix(mode, "oauth2:"):
+		return fmt.Sprintf("OAuth2 client credentials (profile %s)", strings.TrimPrefix(mode, "oauth2:")), nil
+	case strings.HasPrefix(mode, "sas:"):
+		return fmt.Sprintf("shared access signature (profile %s)", strings.TrimPrefix(mode, "sas:")), nil
+	case strings.HasPrefix(mode, "exec:"):
+		return fmt.Sprintf("credential plugin (%s)", strings.TrimPrefix(mode, "exec:")), nil
+	case cfg.User != "":
+		return "basic auth (--user)", nil
+	case cfg.BearerEnv != "":
+		return fmt.Sprintf("bearer token from $%s (--bearer-env)", cfg.BearerEnv), nil
+	case cfg.GitHubAuth:
+		return "GitHub token (--github-auth)", nil
+	}
+	for _, h := range cfg.Headers {
+		if name, _, ok := strings.Cut(h, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "authorization") {
+			return "Authorization header (-H)", nil
+		}
+	}
+
+	var notes []string
+	env := func(key string) string {
+		v, _ := lookupEnv(key)
+		return v
+	}
+	if env("AZURE_CLIENT_ID") != "" && env("AZURE_TENANT_ID") != "" && (env("AZURE_CLIENT_SECRET") != "" || env("AZURE_CLIENT_CERTIFICATE_PATH") != "") {
+		notes = append(notes, fmt.Sprintf("environment credential configured for client %s in tenant %s; it is tried only if azd and Azure CLI sign-ins fail", env("AZURE_CLIENT_ID"), env("AZURE_TENANT_ID")))
+	}
+	if env("AZURE_FEDERATED_TOKEN_FILE") != "" {
+		notes = append(notes, "workload identity configured (AZURE_FEDERATED_TOKEN_FILE)")
+	}
+	if len(cfg.AuxTenants) > 0 {
+		notes = append(notes, "auxiliary tenants: "+strings.Join(cfg.AuxTenants, ", "))
+	}
+	return "Azure credential chain (azd, Azure CLI, environment, workload identity, managed identity; first to succeed)", notes
+}
+
+// writeContextResult renders a contextResult as aligned text or, when format
+// is json, as indented JSON.
+func writeContextResult(w io.Writer, res contextResult, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	orUnset := func(v string) string {
+		if v == "" {
+			return "(not set)"
+		}
+		return v
+	}
+	rows := [][2]string{
+		{"Environment", orUnset(res.Environment)},
+		{"Subscription", orUnset(res.SubscriptionID)},
+		{"Tenant", orUnset(res.TenantID)},
+		{"Location", orUnset(res.Location)},
+		{"Loaded from", res.ContextSource},
+	}
+	if res.URL != "" {
+		rows = append(rows, [2]string{"URL", res.URL})
+	}
+	if res.Scope != "" {
+		rows = append(rows, [2]string{"Scope", res.Scope})
+	}
+	rows = append(rows,
+		[2]string{"Scope source", res.ScopeSource},
+		[2]string{"Credential", res.Credential},
+	)
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-13s %s\n", r[0]+":", r[1])
+	}
+	for _, n := range res.Notes {
+		fmt.Fprintf(w, "%-13s %s\n", "Note:", n)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAzdContext = azdcontext.Context{
+	EnvironmentName: "dev",
+	SubscriptionID:  "sub1",
+	TenantID:        "tenant1",
+	Location:        "eastus2",
+	Source:          azdcontext.SourceAzd,
+}
+
+func noEnv(string) (string, bool) { return "", false }
+
+func TestBuildContextResult_Defaults(t *testing.T) {
+	res, err := buildContextResult(testAzdContext, config.Defaults(), "", noEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", res.Environment)
+	assert.Equal(t, "sub1", res.SubscriptionID)
+	assert.Contains(t, res.ScopeSource, "detected per request")
+	assert.Contains(t, res.Credential, "Azure credential chain")
+	assert.Empty(t, res.Notes)
+}
+
+func TestBuildContextResult_ScopeSources(t *testing.T) {
+	cfg := config.Defaults()
+	res, err := buildContextResult(testAzdContext, cfg, "https://graph.microsoft.com/v1.0/me", noEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "https://graph.microsoft.com/.default", res.Scope)
+	assert.Equal(t, "detected from the URL host", res.ScopeSource)
+
+	cfg.Scope = "api://custom/.default"
+	res, err = buildContextResult(testAzdContext, cfg, "https://graph.microsoft.com/v1.0/me", noEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "api://custom/.default", res.Scope)
+	assert.Equal(t, "--scope flag", res.ScopeSource)
+
+	cfg = config.Defaults()
+	cfg.NoAuth = true
+	res, err = buildContextResult(testAzdContext, cfg, "https://graph.microsoft.com/v1.0/me", noEnv)
+	require.NoError(t, err)
+	assert.Empty(t, res.Scope)
+	assert.Contains(t, res.ScopeSource, "--no-auth")
+	assert.Equal(t, "none (--no-auth)", res.Credential)
+}
+
+func TestDescribeCredential(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(*config.Config)
+		want string
+	}{
+		{"oauth2", func(c *config.Config) { c.Auth = "oauth2:partner" }, "OAuth2 client credentials (profile partner)"},
+		{"sas", func(c *config.Config) { c.Auth = "sas:orders" }, "shared access signature (profile orders)"},
+		{"exec", func(c *config.Config) { c.Auth = "exec:broker" }, "credential plugin (broker)"},
+		{"basic", func(c *config.Config) { c.User = "me:pw" }, "basic auth (--user)"},
+		{"bearer env", func(c *config.Config) { c.BearerEnv = "TOKEN" }, "bearer token from $TOKEN (--bearer-env)"},
+		{"github", func(c *config.Config) { c.GitHubAuth = true }, "GitHub token (--github-auth)"},
+		{"header", func(c *config.Config) { c.Headers = []string{"authorization: Bearer x"} }, "Authorization header (-H)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Defaults()
+			tt.cfg(&cfg)
+			got, _ := describeCredential(cfg, noEnv)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuildContextResult_Notes(t *testing.T) {
+	env := map[string]string{
+		"AZURE_CLIENT_ID":            "app1",
+		"AZURE_TENANT_ID":            "tenant2",
+		"AZURE_CLIENT_SECRET":        "s",
+		"AZURE_FEDERATED_TOKEN_FILE": "/var/run/token",
+	}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	cfg := config.Defaults()
+	cfg.AuxTenants = []string{"tenant3"}
+
+	res, err := buildContextResult(testAzdContext, cfg, "", lookup)
+	require.NoError(t, err)
+	require.Len(t, res.Notes, 4)
+	assert.Contains(t, res.Notes[0], "client app1 in tenant tenant2")
+	assert.Contains(t, res.Notes[1], "workload identity")
+	assert.Contains(t, res.Notes[2], "tenant3")
+	assert.Contains(t, res.Notes[3], "differs from the azd environment tenant")
+}
+
+func TestContextCommand(t *testing.T) {
+	resetGlobalFlags()
+	orig := azdContextLoader
+	azdContextLoader = func(context.Context) (azdcontext.Context, error) { return testAzdContext, nil }
+	t.Cleanup(func() {
+		azdContextLoader = orig
+		resetGlobalFlags()
+	})
+
+	cmd := NewContextCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"https://management.azure.com/subscriptions"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Environment:  dev\n")
+	assert.Contains(t, out.String(), "Scope:        https://management.azure.com/.default\n")
+
+	outputFormat = "json"
+	out.Reset()
+	cmd = NewContextCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	var res contextResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+	assert.Equal(t, "tenant1", res.TenantID)
+	assert.Equal(t, azdcontext.SourceAzd, res.ContextSource)
+}
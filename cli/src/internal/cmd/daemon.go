@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+// daemonStartWait bounds how long daemon start waits for the new process to
+// answer on its socket.
+const daemonStartWait = 5 * time.Second
+
+// NewDaemonCommand returns the daemon command group, which manages the
+// background process that keeps Azure tokens and connections warm between
+// invocations.
+func NewDaemonCommand() *cobra.Command {
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage a background daemon that reuses Azure tokens and connections across runs",
+		Long: `Manage a background daemon that reuses Azure tokens and connections across
+runs.
+
+Each azd rest run normally builds the Azure credential chain, acquires a
+token, and opens a new TLS connection before sending its request. While the
+daemon is running, requests get their token from the daemon over a local
+socket instead, and the daemon sends them over connections it keeps open,
+which skips all three steps after the first request to a host. Requests with
+--insecure, --pinnedpubkey, a proxy, or transport settings of their own
+connect directly.
+
+The socket is created in the azd rest config directory (or at
+$AZD_REST_DAEMON_SOCKET) and only the current user can connect to it. Restart
+the daemon after switching accounts with azd auth login.
+
+Examples:
+  azd rest daemon start
+  azd rest daemon status
+  azd rest daemon stop`,
+	}
+
+	var idleTimeout time.Duration
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon in the background",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return startDaemon(commandContext(cmd), cmd, idleTimeout)
+		},
+	}
+	runCmd := &cobra.Command{
+		Use:    "run",
+		Short:  "Run the daemon in the foreground",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runDaemon(commandContext(cmd), idleTimeout)
+		},
+	}
+	for _, c := range []*cobra.Command{startCmd, runCmd} {
+		c.Flags().DurationVar(&idleTimeout, "idle-timeout", daemon.DefaultIdleTimeout, "Exit after this long without a token or forwarded request (0 runs until stopped)")
+	}
+
+	daemonCmd.AddCommand(startCmd, runCmd, newDaemonStatusCommand(), newDaemonStopCommand())
+	return daemonCmd
+}
+
+func newDaemonStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			status, err := c.Status(commandContext(cmd))
+			if errors.Is(err, daemon.ErrNotRunning) {
+				fmt.Fprintln(cmd.OutOrStdout(), "Not running")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if outputFormat == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(status)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Running (pid %d) on %s since %s, %d token requests and %d forwarded requests served\n",
+				status.PID, status.Socket, status.StartedAt.Local().Format(time.RFC3339), status.TokenRequests, status.ForwardedRequests)
+			return nil
+		},
+	}
+}
+
+func newDaemonStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			err = c.Shutdown(commandContext(cmd))
+			if errors.Is(err, daemon.ErrNotRunning) {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Daemon is not running")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "Daemon stopped")
+			return nil
+		},
+	}
+}
+
+// daemonClient returns a client for the default socket.
+func daemonClient() (*daemon.Client, error) {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	return daemon.NewClient(socket), nil
+}
+
+// startDaemon launches "daemon run" as a detached process and waits until it
+// answers on its socket.
+func startDaemon(ctx context.Context, cmd *cobra.Command, idleTimeout time.Duration) error {
+	c, err := daemonClient()
+	if err != nil {
+		return err
+	}
+	if status, err := c.Status(ctx); err == nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Daemon already running (pid %d)\n", status.PID)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the azd rest executable: %w", err)
+	}
+	//nolint:gosec // G204: runs this executable with fixed arguments
+	child := exec.Command(exe, "daemon", "run", "--idle-timeout", idleTimeout.String())
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	_ = child.Process.Release()
+
+	deadline := time.Now().Add(daemonStartWait)
+	for time.Now().Before(deadline) {
+		if status, err := c.Status(ctx); err == nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Daemon started (pid %d) on %s\n", status.PID, status.Socket)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon did not start within %s", daemonStartWait)
+}
+
+// runDaemon serves tokens from an Azure token provider, and forwards requests
+// through the process's pooled transport, until interrupted, stopped, or
+// idle.
+func runDaemon(ctx context.Context, idleTimeout time.Duration) error {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+	tokens, err := client.NewAzureTokenProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create token provider: %w", err)
+	}
+	l, err := daemon.Listen(socket)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	return daemon.NewServer(tokens, client.PooledTransport(), socket, idleTimeout).Serve(ctx, l)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docsMetadataFile is the structured command metadata written next to the
+// generated Markdown pages.
+const docsMetadataFile = "metadata.json"
+
+// NewDocsCommand returns the hidden docs command group, which generates the
+// CLI reference from the live command tree.
+func NewDocsCommand() *cobra.Command {
+	docsCmd := &cobra.Command{
+		Use:    "docs",
+		Short:  "Documentation commands",
+		Hidden: true,
+	}
+
+	var dir string
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the CLI reference as Markdown",
+		Long: `Generate one Markdown page per command, plus metadata.json with the output
+of the metadata command, from the actual command tree. Hidden commands are
+skipped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := generateDocs(cmd.Root(), dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote CLI reference to %s\n", dir)
+			return nil
+		},
+	}
+	generateCmd.Flags().StringVar(&dir, "dir", "", "Directory to write the generated files to (created if missing)")
+	_ = generateCmd.MarkFlagRequired("dir")
+
+	docsCmd.AddCommand(generateCmd)
+	return docsCmd
+}
+
+// generateDocs writes the Markdown tree and metadata.json for root into dir.
+func generateDocs(root *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+	// A date stamp on every page would change the output on each run.
+	root.DisableAutoGenTag = true
+	if err := doc.GenMarkdownTree(root, dir); err != nil {
+		return fmt.Errorf("failed to generate Markdown: %w", err)
+	}
+
+	data, err := json.MarshalIndent(buildExtensionMetadata(root), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode command metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, docsMetadataFile), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", docsMetadataFile, err)
+	}
+	return nil
+}
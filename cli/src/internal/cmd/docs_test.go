@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	resetGlobalFlags()
+	dir := filepath.Join(t.TempDir(), "reference")
+
+	require.NoError(t, generateDocs(NewRootCmd(), dir))
+
+	get, err := os.ReadFile(filepath.Join(dir, "rest_get.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(get), "## rest get")
+	assert.Contains(t, string(get), "--audit-log")
+	assert.NotContains(t, string(get), "Auto generated by spf13/cobra")
+
+	// Hidden commands are not documented.
+	_, err = os.Stat(filepath.Join(dir, "rest_mcp.md"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "rest_docs.md"))
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := os.ReadFile(filepath.Join(dir, docsMetadataFile))
+	require.NoError(t, err)
+	var metadata extensions.ExtensionCommandMetadata
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	assert.Equal(t, "jongio.azd.rest", metadata.ID)
+	assert.NotEmpty(t, metadata.Commands)
+}
+
+func TestDocsGenerateRequiresDir(t *testing.T) {
+	resetGlobalFlags()
+	root := NewRootCmd()
+	root.SetArgs([]string{"docs", "generate"})
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+
+	err := root.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"dir" not set`)
+}
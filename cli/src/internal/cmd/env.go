@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/pflag"
@@ -39,17 +40,43 @@ func envVarName(flagName string) string {
 	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
 }
 
+// flagAliases maps an alias flag to the flag whose value it shares. The two
+// are one setting, so an environment default for either is skipped once the
+// other was set on the command line.
+var flagAliases = map[string]string{
+	"body-format": "data-format",
+	"credential":  "auth",
+}
+
+// aliasChanged reports whether a flag sharing name's value was already set.
+func aliasChanged(flags *pflag.FlagSet, name string) bool {
+	for alias, target := range flagAliases {
+		other := ""
+		switch name {
+		case alias:
+			other = target
+		case target:
+			other = alias
+		}
+		if f := flags.Lookup(other); other != "" && f != nil && f.Changed {
+			return true
+		}
+	}
+	return false
+}
+
 // applyEnvDefaults applies AZD_REST_<FLAG> environment variables to the named
 // persistent flags that were not set on the command line. Precedence is command
 // line over environment over built-in default: a flag already set on the command
-// line is left untouched, and only a non-empty environment value is applied.
+// line is left untouched, as is an alias of one (see flagAliases), and only a
+// non-empty environment value is applied.
 // The lookup function is injectable so tests can supply values without touching
 // the real process environment. An invalid value is returned as a configError so
 // the process exits with code 2 and no request is made.
 func applyEnvDefaults(flags *pflag.FlagSet, names []string, lookup func(string) (string, bool)) error {
 	for _, name := range names {
 		flag := flags.Lookup(name)
-		if flag == nil || flag.Changed {
+		if flag == nil || flag.Changed || aliasChanged(flags, name) {
 			continue
 		}
 		value, ok := lookup(envVarName(name))
@@ -102,3 +129,36 @@ func applyAllowedHostsEnv(flags *pflag.FlagSet, lookup func(string) (string, boo
 	}
 	return nil
 }
+
+// verbosityValue is the --verbose flag. Each bare -v adds one level, like a
+// pflag count flag, but it also accepts true and false, so --verbose=true and
+// AZD_REST_VERBOSE=true keep working as they did for the boolean flag, and a
+// number sets the level directly.
+type verbosityValue int
+
+func (v *verbosityValue) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "+1":
+		*v++
+		return nil
+	case "true":
+		if *v < 1 {
+			*v = 1
+		}
+		return nil
+	case "false":
+		*v = 0
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected true, false, or a verbosity level, got %q", s)
+	}
+	*v = verbosityValue(n)
+	return nil
+}
+
+func (v *verbosityValue) String() string { return strconv.Itoa(int(*v)) }
+
+// Type reports count so the help text renders the flag like a count flag.
+func (v *verbosityValue) Type() string { return "count" }
@@ -104,6 +104,56 @@ func TestApplyEnvDefaults_UpdatesBoundGlobal(t *testing.T) {
 	assert.Equal(t, 9, snapshotConfig().Retry, "env default should reach the config snapshot")
 }
 
+// TestApplyEnvDefaults_DataFormatAlias verifies an environment default
+// for one name of an aliased flag does not override the other name set on the
+// command line.
+func TestApplyEnvDefaults_DataFormatAlias(t *testing.T) {
+	cases := []struct {
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{[]string{"--data-format", "json"}, map[string]string{"AZD_REST_BODY_FORMAT": "yaml"}, "json"},
+		{[]string{"--body-format", "json"}, map[string]string{"AZD_REST_DATA_FORMAT": "yaml"}, "json"},
+		{nil, map[string]string{"AZD_REST_BODY_FORMAT": "yaml"}, "yaml"},
+	}
+	for _, tc := range cases {
+		resetGlobalFlags()
+		root := NewRootCmd()
+		flags := root.PersistentFlags()
+		require.NoError(t, flags.Parse(tc.args))
+		lookup := func(k string) (string, bool) { v, ok := tc.env[k]; return v, ok }
+
+		require.NoError(t, applyEnvDefaults(flags, []string{"data-format", "body-format"}, lookup))
+		assert.Equal(t, tc.want, dataFormat, "args %v env %v", tc.args, tc.env)
+	}
+}
+
+// TestApplyEnvDefaults_CredentialAlias verifies --auth and --credential are
+// one setting for environment defaults.
+func TestApplyEnvDefaults_CredentialAlias(t *testing.T) {
+	cases := []struct {
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{[]string{"--credential", "exec:cli"}, map[string]string{"AZD_REST_AUTH": "oauth2:envprofile"}, "exec:cli"},
+		{[]string{"--auth", "exec:cli"}, map[string]string{"AZD_REST_CREDENTIAL": "oauth2:envprofile"}, "exec:cli"},
+		{nil, map[string]string{"AZD_REST_CREDENTIAL": "oauth2:envprofile"}, "oauth2:envprofile"},
+	}
+	for _, tc := range cases {
+		resetGlobalFlags()
+		root := NewRootCmd()
+		flags := root.PersistentFlags()
+		require.NoError(t, flags.Parse(tc.args))
+		lookup := func(k string) (string, bool) { v, ok := tc.env[k]; return v, ok }
+
+		require.NoError(t, applyEnvDefaults(flags, []string{"auth", "credential"}, lookup))
+		assert.Equal(t, tc.want, authMode, "args %v env %v", tc.args, tc.env)
+		assert.Equal(t, tc.want, snapshotConfig().Auth)
+	}
+}
+
 // newAllowHostFlags builds an isolated flag set with just the repeatable
 // --allow-host flag so applyAllowedHostsEnv can be unit tested.
 func newAllowHostFlags() (*pflag.FlagSet, *[]string) {
@@ -181,3 +231,34 @@ func TestApplyAllowedHostsEnv_UpdatesSnapshot(t *testing.T) {
 	assert.Equal(t, []string{"management.azure.com", "*.vault.azure.net"}, allowHosts)
 	assert.Equal(t, []string{"management.azure.com", "*.vault.azure.net"}, snapshotConfig().AllowedHosts)
 }
+
+func TestVerboseFlag_AcceptsBooleansAndCounts(t *testing.T) {
+	parse := func(args ...string) int {
+		resetGlobalFlags()
+		root := NewRootCmd()
+		require.NoError(t, root.PersistentFlags().Parse(args))
+		return verbose
+	}
+	assert.Equal(t, 1, parse("-v"))
+	assert.Equal(t, 3, parse("-vvv"))
+	assert.Equal(t, 2, parse("-v", "--verbose"))
+	assert.Equal(t, 1, parse("--verbose=true"))
+	assert.Equal(t, 0, parse("--verbose=false"))
+	assert.Equal(t, 2, parse("--verbose=2"))
+
+	resetGlobalFlags()
+	root := NewRootCmd()
+	assert.Error(t, root.PersistentFlags().Parse([]string{"--verbose=loud"}))
+}
+
+func TestApplyEnvDefaults_VerboseBoolean(t *testing.T) {
+	for value, want := range map[string]int{"true": 1, "false": 0, "3": 3} {
+		resetGlobalFlags()
+		root := NewRootCmd()
+		lookup := func(k string) (string, bool) { return value, k == "AZD_REST_VERBOSE" }
+
+		require.NoError(t, applyEnvDefaults(root.PersistentFlags(), []string{"verbose"}, lookup), value)
+		assert.Equal(t, want, verbose, value)
+		assert.Equal(t, want > 0, snapshotConfig().Verbose, value)
+	}
+}
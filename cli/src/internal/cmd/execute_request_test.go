@@ -194,7 +194,7 @@ func TestExecuteRequest_VerboseOutput(t *testing.T) {
 
 	resetGlobalFlags()
 	noAuth = true
-	verbose = true
+	verbose = 1
 	outputFormat = "json"
 	tmpDir := t.TempDir()
 	outputFile = filepath.Join(tmpDir, "verbose-output.txt")
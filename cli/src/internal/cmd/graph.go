@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jongio/azd-rest/src/internal/service"
 	"github.com/spf13/cobra"
 )
 
@@ -112,11 +113,12 @@ func runGraph(cmd *cobra.Command, query string, subscriptions, managementGroups
 	cfg := snapshotConfig()
 	cfg.Data = body
 	cfg.DataFile = ""
+	cfg.DataRaw, cfg.DataBinary, cfg.JSONData, cfg.DataURLEncode = "", "", "", nil
 	if cfg.APIVersion == "" {
 		cfg.APIVersion = graphAPIVersion
 	}
-	// Prepend so an explicit --header Content-Type still wins.
-	cfg.Headers = append([]string{"Content-Type: application/json"}, cfg.Headers...)
+	// An explicit --header Content-Type still wins.
+	cfg.Headers = service.WithDefaultHeader(cfg.Headers, "Content-Type", "application/json")
 
 	ctx := cmd.Context()
 	if ctx == nil {
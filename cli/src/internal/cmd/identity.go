@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// NewIdentityCommand returns the identity subcommand, which groups managed
+// identity helpers.
+func NewIdentityCommand() *cobra.Command {
+	identityCmd := &cobra.Command{
+		Use:   "identity",
+		Short: "Managed identity helpers",
+	}
+	identityCmd.AddCommand(newIdentityProbeCommand())
+	return identityCmd
+}
+
+func newIdentityProbeCommand() *cobra.Command {
+	var (
+		resource  string
+		clientIDs []string
+	)
+	cmd := &cobra.Command{
+		Use:   "probe",
+		Short: "Report which managed identities are available on this machine",
+		Long: `Request a token from the local managed identity endpoint and report which
+identities can get one, with the client ID, object ID, tenant, and resource
+ID read from each token. The tokens themselves are never printed.
+
+On App Service, Functions, and Container Apps the endpoint comes from
+IDENTITY_ENDPOINT and IDENTITY_HEADER, and must be a loopback address. Anywhere
+else the Azure Instance Metadata Service (IMDS) at 169.254.169.254 is used.
+
+The default identity is always probed, then AZURE_CLIENT_ID when it is set,
+then each --client-id. The command exits non-zero when no identity is
+available.
+
+IMDS is a cloud metadata address that redirects, the MCP server, and
+--block-private-networks refuse. This command is the one exemption: it only
+calls the fixed identity endpoints, never follows redirects, and never uses a
+proxy. It is not available as an MCP tool.`,
+		Example: `  azd rest identity probe
+  azd rest identity probe --client-id 00000000-0000-0000-0000-000000000000 --format json
+  azd rest identity probe --resource https://vault.azure.net`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := snapshotConfig()
+			return service.ProbeIdentities(commandContext(cmd), service.IdentityProbeOptions{
+				Resource:  resource,
+				ClientIDs: clientIDs,
+				Timeout:   cfg.Timeout,
+				JSON:      strings.EqualFold(cfg.OutputFormat, "json"),
+			}, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&resource, "resource", "", "Resource or scope to request tokens for (default: https://management.azure.com/)")
+	cmd.Flags().StringSliceVar(&clientIDs, "client-id", nil, "Client ID of a user-assigned identity to probe (repeatable)")
+	return cmd
+}
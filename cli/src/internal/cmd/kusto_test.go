@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kustoV2Response = `[
+ {"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},
+ {"FrameType":"DataTable","TableId":0,"TableKind":"QueryProperties","TableName":"@ExtendedProperties","Columns":[{"ColumnName":"Value","ColumnType":"dynamic"}],"Rows":[[{"Visualization":null}]]},
+ {"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"State","ColumnType":"string"},{"ColumnName":"count_","ColumnType":"long"}],"Rows":[["TEXAS",4701],["KANSAS",3166]]},
+ {"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestKustoPrimaryResult(t *testing.T) {
+	rows, columns, err := kustoPrimaryResult([]byte(kustoV2Response))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"State", "count_"}, columns)
+	assert.Equal(t, `[{"State":"TEXAS","count_":4701},{"State":"KANSAS","count_":3166}]`, string(rows))
+
+	_, _, err = kustoPrimaryResult([]byte(`[{"FrameType":"DataSetCompletion","HasErrors":true,"OneApiErrors":[{"error":{"code":"LimitsExceeded","message":"Request is invalid and cannot be executed."}}]}]`))
+	assert.EqualError(t, err, "kusto query failed: LimitsExceeded: Request is invalid and cannot be executed.")
+
+	_, _, err = kustoPrimaryResult([]byte(`[{"FrameType":"DataSetHeader"}]`))
+	assert.EqualError(t, err, "the Kusto response has no primary result table")
+
+	_, _, err = kustoPrimaryResult([]byte(`{"Tables":[]}`))
+	assert.ErrorContains(t, err, "unexpected Kusto response (expected v2 frames)")
+}
+
+func TestBuildKustoRequestBody(t *testing.T) {
+	body, err := buildKustoRequestBody("Samples", "StormEvents | take 5")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"db":"Samples","csl":"StormEvents | take 5"}`, body)
+
+	path := filepath.Join(t.TempDir(), "query.kql")
+	require.NoError(t, os.WriteFile(path, []byte("StormEvents\n| count\n"), 0o600))
+	body, err = buildKustoRequestBody("Samples", "@"+path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"db":"Samples","csl":"StormEvents\n| count\n"}`, body)
+
+	_, err = buildKustoRequestBody("Samples", " ")
+	assert.EqualError(t, err, "query cannot be empty")
+}
+
+func TestKustoQueryURL(t *testing.T) {
+	assert.Equal(t, "https://help.kusto.windows.net/v2/rest/query", kustoQueryURL("https://help.kusto.windows.net/"))
+	assert.Equal(t, "https://mycluster.westus.kusto.windows.net/v2/rest/query", kustoQueryURL("mycluster.westus.kusto.windows.net"))
+}
@@ -1,2 +1,52 @@
-// listen command is now provided by azdext.NewListenCommand() in root.go.
 package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// newListenCommand returns the hidden listen command azd runs to deliver
+// lifecycle events. It registers a handler for each supported event that runs
+// the requests declared for it under rest.hooks in azure.yaml.
+func newListenCommand() *cobra.Command {
+	return azdext.NewListenCommand(func(host *azdext.ExtensionHost) {
+		for _, event := range service.HookEvents {
+			host.WithProjectEventHandler(event, func(ctx context.Context, _ *azdext.ProjectEventArgs) error {
+				azdClient := host.Client()
+				return runProjectHooks(ctx, event, azdClient.Project(), azdClient.Environment(), os.Stderr)
+			})
+		}
+	})
+}
+
+// runProjectHooks reads rest.hooks from the project config and runs the
+// requests declared for event, expanding ${NAME} references from the current
+// azd environment. A project without the section is a no-op.
+func runProjectHooks(ctx context.Context, event string, project azdext.ProjectServiceClient, envs azdext.EnvironmentServiceClient, log io.Writer) error {
+	res, err := project.GetConfigSection(ctx, &azdext.GetProjectConfigSectionRequest{Path: service.HookSectionPath})
+	if err != nil {
+		return fmt.Errorf("failed to read %s from azure.yaml: %w", service.HookSectionPath, err)
+	}
+	if !res.GetFound() {
+		return nil
+	}
+	hooks, err := service.ParseHooks(res.GetSection().AsMap())
+	if err != nil {
+		return err
+	}
+	if len(hooks[event]) == 0 {
+		return nil
+	}
+	_, values, err := azdcontext.CurrentValues(ctx, envs)
+	if err != nil {
+		return err
+	}
+	return getRequestService().RunHooks(ctx, event, hooks[event], values, log)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fakeProjectConfig serves a fixed rest.hooks section.
+type fakeProjectConfig struct {
+	azdext.ProjectServiceClient
+	section map[string]any
+}
+
+func (f *fakeProjectConfig) GetConfigSection(_ context.Context, req *azdext.GetProjectConfigSectionRequest, _ ...grpc.CallOption) (*azdext.GetProjectConfigSectionResponse, error) {
+	if f.section == nil || req.GetPath() != "rest.hooks" {
+		return &azdext.GetProjectConfigSectionResponse{}, nil
+	}
+	s, err := structpb.NewStruct(f.section)
+	if err != nil {
+		return nil, err
+	}
+	return &azdext.GetProjectConfigSectionResponse{Section: s, Found: true}, nil
+}
+
+// fakeEnvValues serves a single "dev" environment.
+type fakeEnvValues struct {
+	azdext.EnvironmentServiceClient
+	values map[string]string
+}
+
+func (f *fakeEnvValues) GetCurrent(context.Context, *azdext.EmptyRequest, ...grpc.CallOption) (*azdext.EnvironmentResponse, error) {
+	return &azdext.EnvironmentResponse{Environment: &azdext.Environment{Name: "dev"}}, nil
+}
+
+func (f *fakeEnvValues) GetValues(context.Context, *azdext.GetEnvironmentRequest, ...grpc.CallOption) (*azdext.KeyValueListResponse, error) {
+	res := &azdext.KeyValueListResponse{}
+	for k, v := range f.values {
+		res.KeyValues = append(res.KeyValues, &azdext.KeyValue{Key: k, Value: v})
+	}
+	return res, nil
+}
+
+func TestRunProjectHooks(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	}))
+	defer srv.Close()
+
+	project := &fakeProjectConfig{section: map[string]any{
+		"postprovision": []any{map[string]any{"name": "health", "url": "${API}/health", "noAuth": true}},
+		"postdeploy":    []any{map[string]any{"method": "POST", "url": "${API}/warm", "noAuth": true}},
+	}}
+	envs := &fakeEnvValues{values: map[string]string{"API": srv.URL}}
+
+	var log bytes.Buffer
+	require.NoError(t, runProjectHooks(context.Background(), "postprovision", project, envs, &log))
+	assert.Equal(t, []string{"GET /health"}, calls)
+	assert.Contains(t, log.String(), "rest postprovision hook health: GET")
+
+	require.NoError(t, runProjectHooks(context.Background(), "postdeploy", project, envs, &log))
+	assert.Equal(t, []string{"GET /health", "POST /warm"}, calls)
+}
+
+func TestRunProjectHooks_NoSection(t *testing.T) {
+	err := runProjectHooks(context.Background(), "postprovision", &fakeProjectConfig{}, &fakeEnvValues{}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRunProjectHooks_InvalidSection(t *testing.T) {
+	project := &fakeProjectConfig{section: map[string]any{"preprovision": []any{}}}
+	err := runProjectHooks(context.Background(), "postprovision", project, &fakeEnvValues{}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "unsupported event")
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// logsQueryEndpoint is the Log Analytics query API host.
+const logsQueryEndpoint = "https://api.loganalytics.io"
+
+// logsRequest is the JSON body of a workspace query.
+type logsRequest struct {
+	Query    string `json:"query"`
+	Timespan string `json:"timespan,omitempty"`
+}
+
+// logsError is the error object of a query response. A query that ran but
+// did not complete cleanly returns its tables together with an error whose
+// code is PartialError.
+type logsError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+// logsResponse holds the fields of a query response used here.
+type logsResponse struct {
+	Tables []struct {
+		Name    string `json:"name"`
+		Columns []struct {
+			Name string `json:"name"`
+		} `json:"columns"`
+		Rows []json.RawMessage `json:"rows"`
+	} `json:"tables"`
+	Error *logsError `json:"error"`
+}
+
+// String formats the error code and message, followed by the first detail
+// that adds information.
+func (e *logsError) String() string {
+	msg := strings.TrimSpace(e.Code + ": " + e.Message)
+	for _, d := range e.Details {
+		if d.Message != "" && d.Message != e.Message {
+			return msg + " (" + d.Message + ")"
+		}
+	}
+	return msg
+}
+
+// NewLogsCommand returns the logs subcommand, which groups Log Analytics
+// helpers.
+func NewLogsCommand() *cobra.Command {
+	logsCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Log Analytics workspace helpers",
+	}
+	logsCmd.AddCommand(newLogsQueryCommand())
+	return logsCmd
+}
+
+func newLogsQueryCommand() *cobra.Command {
+	var workspace, timespan string
+	cmd := &cobra.Command{
+		Use:   "query --workspace <id> <kql>",
+		Short: "Run a KQL query against a Log Analytics workspace",
+		Long: `Run a Kusto Query Language (KQL) query through the Log Analytics query API
+(api.loganalytics.io) and print the primary result table as rows.
+
+--workspace is the workspace ID (customer ID) GUID shown on the workspace
+overview, not its ARM resource ID. --timespan limits the query to an ISO 8601
+duration or interval, such as PT1H or 2024-01-01/2024-01-02; without it the
+query's own time filter applies. Pass @file to read the query from a file.
+
+A partial result, such as a query that hit a row or time limit, is printed
+with a warning on stderr. The rows keep the query's column order with
+--format table and csv.`,
+		Example: `  azd rest logs query --workspace 00000000-0000-0000-0000-000000000000 \
+    "AppRequests | where Success == false | take 20" --timespan PT1H --format table
+
+  azd rest logs query --workspace $WORKSPACE_ID @errors.kql --timespan P1D --format csv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if workspace == "" {
+				return fmt.Errorf("--workspace is required")
+			}
+			body, err := buildLogsRequestBody(args[0], timespan)
+			if err != nil {
+				return err
+			}
+
+			cfg := snapshotConfig()
+			if cfg.Repeat > 1 || cfg.Watch > 0 {
+				return fmt.Errorf("logs query cannot be combined with --repeat or --watch")
+			}
+			cfg.Data = body
+			cfg.DataFile = ""
+			cfg.DataRaw, cfg.DataBinary, cfg.JSONData, cfg.DataURLEncode = "", "", "", nil
+			cfg.Paginate = false
+			cfg.Headers = service.WithDefaultHeader(cfg.Headers, "Content-Type", "application/json")
+
+			warnings := io.Discard
+			if !cfg.Silent {
+				warnings = cmd.ErrOrStderr()
+			}
+			transform := func(body []byte) ([]byte, []string, error) {
+				return logsPrimaryResult(body, warnings)
+			}
+			return getRequestService().ExecuteTransform(commandContext(cmd), cfg, "POST", logsQueryURL(workspace), transform)
+		},
+	}
+	cmd.Flags().StringVar(&workspace, "workspace", "", "Log Analytics workspace ID (GUID)")
+	cmd.Flags().StringVar(&timespan, "timespan", "", "ISO 8601 duration or interval to query, e.g. PT1H or P7D")
+	return cmd
+}
+
+// logsQueryURL returns the query endpoint of a workspace.
+func logsQueryURL(workspace string) string {
+	return logsQueryEndpoint + "/v1/workspaces/" + url.PathEscape(strings.TrimSpace(workspace)) + "/query"
+}
+
+// buildLogsRequestBody builds the query body. A query of the form @path is
+// read from that file.
+func buildLogsRequestBody(query, timespan string) (string, error) {
+	query, err := readQueryArg(query)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(logsRequest{Query: query, Timespan: strings.TrimSpace(timespan)})
+	if err != nil {
+		return "", fmt.Errorf("failed to build query body: %w", err)
+	}
+	return string(body), nil
+}
+
+// logsPrimaryResult turns a query response into a JSON array with one object
+// per row of the primary result table, and returns its column names. A
+// partial error is written to warnings; an error without tables fails.
+func logsPrimaryResult(body []byte, warnings io.Writer) ([]byte, []string, error) {
+	var resp logsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unexpected Log Analytics response: %w", err)
+	}
+	if len(resp.Tables) == 0 {
+		if resp.Error != nil {
+			return nil, nil, fmt.Errorf("log analytics query failed: %s", resp.Error)
+		}
+		return nil, nil, fmt.Errorf("the Log Analytics response has no tables")
+	}
+	if resp.Error != nil {
+		_, _ = fmt.Fprintf(warnings, "Warning: partial query result: %s\n", resp.Error)
+	}
+
+	table := resp.Tables[0]
+	for _, t := range resp.Tables {
+		if t.Name == "PrimaryResult" {
+			table = t
+			break
+		}
+	}
+	columns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = col.Name
+	}
+	rows, err := tableRowsJSON(columns, table.Rows)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unexpected Log Analytics %w", err)
+	}
+	return rows, columns, nil
+}
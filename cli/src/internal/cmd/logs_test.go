@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogsPrimaryResult(t *testing.T) {
+	body := `{"tables":[{"name":"PrimaryResult","columns":[{"name":"TimeGenerated","type":"datetime"},{"name":"ResultCode","type":"string"}],
+		"rows":[["2024-05-01T10:00:00Z","500"],["2024-05-01T10:01:00Z","404"]]}]}`
+	var warnings bytes.Buffer
+	rows, columns, err := logsPrimaryResult([]byte(body), &warnings)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TimeGenerated", "ResultCode"}, columns)
+	assert.Equal(t, `[{"TimeGenerated":"2024-05-01T10:00:00Z","ResultCode":"500"},{"TimeGenerated":"2024-05-01T10:01:00Z","ResultCode":"404"}]`, string(rows))
+	assert.Empty(t, warnings.String())
+
+	partial := `{"tables":[{"name":"PrimaryResult","columns":[{"name":"n","type":"long"}],"rows":[[1]]}],
+		"error":{"code":"PartialError","message":"There were some errors when processing your query.",
+		"details":[{"code":"EngineError","message":"Query result set has exceeded the internal data size limit"}]}}`
+	rows, _, err = logsPrimaryResult([]byte(partial), &warnings)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"n":1}]`, string(rows))
+	assert.Equal(t, "Warning: partial query result: PartialError: There were some errors when processing your query. (Query result set has exceeded the internal data size limit)\n", warnings.String())
+
+	_, _, err = logsPrimaryResult([]byte(`{"error":{"code":"BadArgumentError","message":"The request had some invalid properties"}}`), &warnings)
+	assert.EqualError(t, err, "log analytics query failed: BadArgumentError: The request had some invalid properties")
+
+	_, _, err = logsPrimaryResult([]byte(`{"tables":[]}`), &warnings)
+	assert.EqualError(t, err, "the Log Analytics response has no tables")
+}
+
+func TestBuildLogsRequestBody(t *testing.T) {
+	body, err := buildLogsRequestBody("AppRequests | take 5", " PT1H ")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":"AppRequests | take 5","timespan":"PT1H"}`, body)
+
+	body, err = buildLogsRequestBody("AppRequests | take 5", "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":"AppRequests | take 5"}`, body)
+
+	_, err = buildLogsRequestBody("", "PT1H")
+	assert.EqualError(t, err, "query cannot be empty")
+}
+
+func TestLogsQueryURL(t *testing.T) {
+	assert.Equal(t, "https://api.loganalytics.io/v1/workspaces/00000000-0000-0000-0000-000000000000/query", logsQueryURL(" 00000000-0000-0000-0000-000000000000 "))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
@@ -27,13 +28,27 @@ const (
 	mcpMaxTimeoutSeconds   = 600
 	mcpMaxRetry            = 10
 	mcpMaxResponseSizeCap  = 50 * 1024 * 1024
+	mcpDefaultMaxPages     = 100
+	mcpMaxPages            = 1000
+	mcpMaxItems            = 100000
+	// Response header caps are tighter than the CLI defaults because the
+	// headers are returned into the agent's context.
+	mcpMaxHeaderSize  = 64 * 1024
+	mcpMaxHeaderCount = 100
 )
 
 type mcpRequestControls struct {
 	Timeout         time.Duration
 	Retry           int
 	MaxResponseSize int64
+	PageSize        int
+	MaxBinarySize   int
 	NoAuth          bool
+	// Paginate follows nextLink for GET requests, up to MaxPages pages and
+	// MaxItems merged items (zero means no item cap).
+	Paginate bool
+	MaxPages int
+	MaxItems int
 }
 
 func defaultMCPRequestControls() mcpRequestControls {
@@ -41,6 +56,9 @@ func defaultMCPRequestControls() mcpRequestControls {
 		Timeout:         mcpDefaultTimeout,
 		Retry:           mcpDefaultRetry,
 		MaxResponseSize: mcpMaxResponseSize,
+		PageSize:        mcpDefaultPageSize,
+		MaxBinarySize:   mcpDefaultBinarySize,
+		MaxPages:        mcpDefaultMaxPages,
 	}
 }
 
@@ -64,7 +82,7 @@ func getOrCreateTokenProvider() (auth.TokenProvider, error) {
 	if cachedTokenProvider != nil {
 		return cachedTokenProvider, nil
 	}
-	tp, err := auth.NewAzureTokenProvider()
+	tp, err := client.NewAzureTokenProvider()
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +110,7 @@ func getOrCreateHTTPClient(tp auth.TokenProvider, timeout time.Duration) *client
 // It allows the request URL to be a subdomain of the scope host (e.g., scope
 // management.azure.com allows sub.management.azure.com). Cross-domain Azure
 // scope mappings (e.g., storage.azure.com scope for *.blob.core.windows.net)
-// are handled by the auto-detection path in auth.DetectScope and do not go
+// are handled by the auto-detection path in client.DetectScope and do not go
 // through this validation — this function only checks explicit scope overrides.
 //
 // Security note: subdomain matching has a theoretical risk where an attacker
@@ -128,9 +146,18 @@ func validateScopeURLMatch(scope, rawURL string) error {
 
 // mcpResponse is the JSON structure returned by MCP tool handlers.
 type mcpResponse struct {
-	StatusCode int               `json:"statusCode"`
-	Headers    map[string]string `json:"headers,omitempty"`
-	Body       string            `json:"body,omitempty"`
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// Truncated is set when Body holds only the first page of a larger
+	// response; pass Continuation to rest_get_more for the next page.
+	Truncated    bool   `json:"truncated,omitempty"`
+	TotalBytes   int    `json:"totalBytes,omitempty"`
+	Continuation string `json:"continuation,omitempty"`
+	// Encoding is "base64" when Body holds an encoded binary body.
+	// BodyOmitted is set when a binary body exceeded maxBinaryBytes.
+	Encoding    string `json:"encoding,omitempty"`
+	BodyOmitted bool   `json:"bodyOmitted,omitempty"`
 }
 
 func parseMCPRequestControls(args azdext.ToolArgs) (mcpRequestControls, error) {
@@ -169,7 +196,52 @@ func parseMCPRequestControls(args azdext.ToolArgs) (mcpRequestControls, error) {
 		controls.MaxResponseSize = int64(maxResponseSize)
 	}
 
+	if args.Has("pageSizeBytes") {
+		pageSize, err := args.RequireInt("pageSizeBytes")
+		if err != nil {
+			return controls, err
+		}
+		if pageSize < mcpMinPageSize || pageSize > mcpMaxResponseSize {
+			return controls, fmt.Errorf("pageSizeBytes must be between %d and %d", mcpMinPageSize, mcpMaxResponseSize)
+		}
+		controls.PageSize = pageSize
+	}
+
+	if args.Has("maxBinaryBytes") {
+		maxBinary, err := args.RequireInt("maxBinaryBytes")
+		if err != nil {
+			return controls, err
+		}
+		if maxBinary < 1 || maxBinary > mcpMaxResponseSize {
+			return controls, fmt.Errorf("maxBinaryBytes must be between 1 and %d", mcpMaxResponseSize)
+		}
+		controls.MaxBinarySize = maxBinary
+	}
+
+	if args.Has("maxPages") {
+		maxPages, err := args.RequireInt("maxPages")
+		if err != nil {
+			return controls, err
+		}
+		if maxPages < 1 || maxPages > mcpMaxPages {
+			return controls, fmt.Errorf("maxPages must be between 1 and %d", mcpMaxPages)
+		}
+		controls.MaxPages = maxPages
+	}
+
+	if args.Has("maxItems") {
+		maxItems, err := args.RequireInt("maxItems")
+		if err != nil {
+			return controls, err
+		}
+		if maxItems < 1 || maxItems > mcpMaxItems {
+			return controls, fmt.Errorf("maxItems must be between 1 and %d", mcpMaxItems)
+		}
+		controls.MaxItems = maxItems
+	}
+
 	controls.NoAuth = args.OptionalBool("noAuth", false)
+	controls.Paginate = args.OptionalBool("paginate", false)
 	return controls, nil
 }
 
@@ -206,11 +278,24 @@ func setSecurityPolicyForTest(p *azdext.MCPSecurityPolicy) {
 	})
 }
 
-// executeMCPRequest performs an authenticated HTTP request for MCP tools.
+// executeMCPRequest performs an authenticated HTTP request for MCP tools and
+// records it in the request history.
 func executeMCPRequest(
 	ctx context.Context,
 	method, reqURL, body, scopeOverride string,
-	customHeaders map[string]string,
+	customHeaders http.Header,
+	controlOverrides ...mcpRequestControls,
+) (*mcpResponse, error) {
+	started := time.Now()
+	resp, err := doMCPRequest(ctx, method, reqURL, body, scopeOverride, customHeaders, controlOverrides...)
+	mcpHistory.record(method, reqURL, resp, err, started)
+	return resp, err
+}
+
+func doMCPRequest(
+	ctx context.Context,
+	method, reqURL, body, scopeOverride string,
+	customHeaders http.Header,
 	controlOverrides ...mcpRequestControls,
 ) (*mcpResponse, error) {
 	controls := defaultMCPRequestControls()
@@ -219,27 +304,43 @@ func executeMCPRequest(
 	}
 
 	policy := getMCPSecurityPolicy()
-	if err := policy.CheckURL(reqURL); err != nil {
+	addrs, err := mcpDNS.checkURL(ctx, policy, reqURL)
+	if err != nil {
 		return nil, fmt.Errorf("requests to cloud metadata endpoints are blocked: %w", err)
 	}
+	if u, err := url.Parse(reqURL); err == nil {
+		// Dial only the addresses that were checked.
+		ctx = client.WithResolvedAddrs(ctx, u.Hostname(), addrs)
+	}
 
 	opts := client.RequestOptions{
 		Method:  method,
 		URL:     reqURL,
-		Headers: make(map[string]string),
+		Headers: make(http.Header),
 		Timeout: controls.Timeout,
 		// Redirects are intentionally disabled for MCP requests.
 		// Following redirects in an AI-controlled context could enable SSRF
 		// attacks where a server redirects to an internal metadata endpoint
-		// after the URL check has already passed.
+		// after the URL check has already passed. The client also checks
+		// each hop, but MCP returns the 3xx so any follow-up request goes
+		// through the full security policy again.
 		FollowRedirects: false,
 		MaxRedirects:    mcpDefaultMaxRedirects,
 		Retry:           controls.Retry,
 		MaxResponseSize: controls.MaxResponseSize,
-	}
-
-	for k, v := range customHeaders {
-		opts.Headers[k] = v
+		MaxHeaderSize:   mcpMaxHeaderSize,
+		MaxHeaderCount:  mcpMaxHeaderCount,
+		// Only GET is paginated; a nextLink replayed with another method
+		// could repeat a write.
+		Paginate: controls.Paginate && method == "GET",
+		MaxPages: controls.MaxPages,
+		MaxItems: controls.MaxItems,
+	}
+
+	for k, values := range customHeaders {
+		for _, v := range values {
+			opts.Headers.Add(k, v)
+		}
 	}
 
 	if body != "" {
@@ -250,7 +351,7 @@ func executeMCPRequest(
 	if !controls.NoAuth {
 		detectedScope := scopeOverride
 		if detectedScope == "" {
-			s, err := auth.DetectScope(reqURL)
+			s, err := client.DetectScope(reqURL)
 			if err != nil {
 				return nil, fmt.Errorf("failed to detect scope: %w", err)
 			}
@@ -283,13 +384,25 @@ func executeMCPRequest(
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	respHeaders := make(map[string]string)
+	respHeaders := make(http.Header, len(resp.Headers))
 	for key, values := range resp.Headers {
-		if len(values) > 0 {
-			respHeaders[key] = client.RedactSensitiveHeader(key, values[0])
+		for _, value := range values {
+			respHeaders.Add(key, client.RedactSensitiveHeader(key, value))
 		}
 	}
 
+	result := &mcpResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    respHeaders,
+	}
+
+	// Binary bodies would be corrupted by the string conversion below, so
+	// they are base64-encoded whole and never truncated or paged.
+	if isBinaryBody(resp.Body, resp.Headers.Get("Content-Type")) {
+		encodeBinaryBody(result, resp.Body, controls.MaxBinarySize)
+		return result, nil
+	}
+
 	// Guard against a double-allocation spike: if the body is at or near the
 	// limit, converting to string would temporarily hold two copies in memory.
 	// Truncate with a clear marker so callers know the response was cut.
@@ -299,11 +412,10 @@ func executeMCPRequest(
 		bodyBytes = append(bodyBytes[:mcpMaxResponseSize-len(truncMsg)], truncMsg...)
 	}
 
-	return &mcpResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    respHeaders,
-		Body:       string(bodyBytes),
-	}, nil
+	if err := paginateMCPResponse(result, bodyBytes, controls.PageSize); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func mcpRequestControlToolOptions() []mcp.ToolOption {
@@ -311,6 +423,8 @@ func mcpRequestControlToolOptions() []mcp.ToolOption {
 		mcp.WithInteger("timeoutSeconds", mcp.Description("Request timeout in seconds, from 1 to 600")),
 		mcp.WithInteger("retry", mcp.Description("Retry attempts for transient errors, from 1 to 10")),
 		mcp.WithInteger("maxResponseSizeBytes", mcp.Description("Maximum response size in bytes, from 1 to 52428800")),
+		mcp.WithInteger("maxBinaryBytes", mcp.Description("Maximum binary body size in bytes, from 1 to 10485760 (default 1048576); binary bodies are returned base64-encoded")),
+		mcp.WithInteger("pageSizeBytes", mcp.Description("Maximum body bytes returned per call, from 1024 to 10485760 (default 65536); larger bodies return a continuation for rest_get_more")),
 		mcp.WithBoolean("noAuth", mcp.Description("Skip Azure bearer token authentication for this request")),
 	}
 }
@@ -319,24 +433,39 @@ func mcpNoBodyToolOptions() []mcp.ToolOption {
 	opts := []mcp.ToolOption{
 		mcp.WithString("url", mcp.Required(), mcp.Description("The request URL")),
 		mcp.WithString("scope", mcp.Description("OAuth scope override (auto-detected if omitted)")),
-		mcp.WithObject("headers", mcp.Description("Custom HTTP headers as key-value pairs")),
+		mcp.WithObject("headers", mcp.Description(mcpHeadersDescription)),
+		mcp.WithOutputSchema[mcpStructuredResponse](),
 	}
 	return append(opts, mcpRequestControlToolOptions()...)
 }
 
+// mcpGetToolOptions adds the pagination arguments, which only rest_get takes.
+func mcpGetToolOptions() []mcp.ToolOption {
+	return append(mcpNoBodyToolOptions(),
+		mcp.WithBoolean("paginate", mcp.Description("Follow nextLink, Link headers, and Azure DevOps continuation tokens and merge every page's value array into one response")),
+		mcp.WithInteger("maxPages", mcp.Description("Maximum pages to fetch when paginate is set, from 1 to 1000 (default 100)")),
+		mcp.WithInteger("maxItems", mcp.Description("Maximum merged items to return when paginate is set, from 1 to 100000")),
+	)
+}
+
 func mcpBodyToolOptions() []mcp.ToolOption {
 	opts := []mcp.ToolOption{
 		mcp.WithString("url", mcp.Required(), mcp.Description("The request URL")),
 		mcp.WithString("body", mcp.Description("Request body (JSON string)")),
 		mcp.WithString("scope", mcp.Description("OAuth scope override (auto-detected if omitted)")),
-		mcp.WithObject("headers", mcp.Description("Custom HTTP headers as key-value pairs")),
+		mcp.WithObject("headers", mcp.Description(mcpHeadersDescription)),
+		mcp.WithOutputSchema[mcpStructuredResponse](),
 	}
 	return append(opts, mcpRequestControlToolOptions()...)
 }
 
-// parseHeaders extracts custom headers from MCP tool arguments.
-func parseHeaders(args azdext.ToolArgs) (map[string]string, error) {
-	headers := make(map[string]string)
+// mcpHeadersDescription documents the headers argument of the request tools.
+const mcpHeadersDescription = "Custom HTTP headers as key-value pairs; use an array of strings to send a header more than once"
+
+// parseHeaders extracts custom headers from MCP tool arguments. A value may be
+// a string or an array of strings, which sends the header once per element.
+func parseHeaders(args azdext.ToolArgs) (http.Header, error) {
+	headers := make(http.Header)
 	policy := getMCPSecurityPolicy()
 	if args.Has("headers") {
 		raw := args.Raw()
@@ -346,8 +475,15 @@ func parseHeaders(args azdext.ToolArgs) (map[string]string, error) {
 					if policy.IsHeaderBlocked(k) {
 						return nil, fmt.Errorf("header %q is not allowed", k)
 					}
-					if s, ok := v.(string); ok {
-						headers[k] = s
+					switch v := v.(type) {
+					case string:
+						headers.Add(k, v)
+					case []any:
+						for _, item := range v {
+							if s, ok := item.(string); ok {
+								headers.Add(k, s)
+							}
+						}
 					}
 				}
 			}
@@ -364,6 +500,62 @@ func formatResponse(resp *mcpResponse) string {
 	return string(data)
 }
 
+// Body formats reported in structured MCP results.
+const (
+	mcpBodyFormatJSON   = "json"
+	mcpBodyFormatText   = "text"
+	mcpBodyFormatBase64 = "base64"
+)
+
+// mcpStructuredResponse is the structured content of a request tool result. A
+// complete JSON body is embedded as JSON rather than as an escaped string, so
+// clients can read it without parsing twice.
+type mcpStructuredResponse struct {
+	StatusCode   int         `json:"statusCode"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         any         `json:"body,omitempty"`
+	BodyFormat   string      `json:"bodyFormat,omitempty"`
+	Truncated    bool        `json:"truncated,omitempty"`
+	TotalBytes   int         `json:"totalBytes,omitempty"`
+	Continuation string      `json:"continuation,omitempty"`
+	BodyOmitted  bool        `json:"bodyOmitted,omitempty"`
+}
+
+// structureResponse converts resp to its structured form. A truncated page is
+// never valid JSON on its own, so it stays text.
+func structureResponse(resp *mcpResponse) mcpStructuredResponse {
+	res := mcpStructuredResponse{
+		StatusCode:   resp.StatusCode,
+		Headers:      resp.Headers,
+		Truncated:    resp.Truncated,
+		TotalBytes:   resp.TotalBytes,
+		Continuation: resp.Continuation,
+		BodyOmitted:  resp.BodyOmitted,
+	}
+	if resp.Body == "" {
+		return res
+	}
+	if resp.Encoding == mcpEncodingBase64 {
+		res.Body = resp.Body
+		res.BodyFormat = mcpBodyFormatBase64
+		return res
+	}
+	if !resp.Truncated && json.Valid([]byte(resp.Body)) {
+		res.Body = json.RawMessage(resp.Body)
+		res.BodyFormat = mcpBodyFormatJSON
+		return res
+	}
+	res.Body = resp.Body
+	res.BodyFormat = mcpBodyFormatText
+	return res
+}
+
+// responseResult builds a tool result carrying resp as structured content,
+// with the JSON text form kept as a fallback for clients that only read text.
+func responseResult(resp *mcpResponse) *mcp.CallToolResult {
+	return mcp.NewToolResultStructured(structureResponse(resp), formatResponse(resp))
+}
+
 // Tool handler for methods with a body (POST, PUT, PATCH)
 func handleBodyMethod(method string) azdext.MCPToolHandler {
 	return mcpHandlerFactory(method, true, false)
@@ -419,40 +611,133 @@ func mcpHandlerFactory(method string, hasBody, stripResponseBody bool) azdext.MC
 			resp.Body = ""
 		}
 
-		return azdext.MCPTextResult("%s", formatResponse(resp)), nil
+		return responseResult(resp), nil
+	}
+}
+
+// mcpScopeResult is the JSON structure returned by the rest_detect_scope tool.
+type mcpScopeResult struct {
+	URL           string `json:"url"`
+	Scope         string `json:"scope,omitempty"`
+	IsAzureHost   bool   `json:"isAzureHost"`
+	Authenticated bool   `json:"authenticated"`
+	Note          string `json:"note,omitempty"`
+}
+
+// detectMCPScope reports the scope a request tool would use for rawURL and
+// whether it would carry a bearer token, without making a request.
+func detectMCPScope(rawURL string) (*mcpScopeResult, error) {
+	scope, err := client.DetectScope(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect scope: %w", err)
+	}
+	res := &mcpScopeResult{
+		URL:         rawURL,
+		Scope:       scope,
+		IsAzureHost: auth.IsAzureHost(rawURL),
+	}
+	switch {
+	case client.ShouldSkipAuth(rawURL, nil, false):
+		res.Note = "plain HTTP URLs are sent without authentication"
+	case scope != "":
+		res.Authenticated = true
+	case res.IsAzureHost:
+		res.Note = "Azure host with no known scope; pass scope explicitly or set noAuth"
+	default:
+		res.Note = "not a known Azure host; requests are sent without a token unless scope is set"
+	}
+	return res, nil
+}
+
+// handleDetectScope returns the detected OAuth scope for a URL so agents can
+// reason about authentication before issuing a request.
+func handleDetectScope(_ context.Context, args azdext.ToolArgs) (*mcp.CallToolResult, error) {
+	rawURL, err := args.RequireString("url")
+	if err != nil {
+		//nolint:nilerr // intentional: surface validation error as MCP tool result, not Go error
+		return azdext.MCPErrorResult("missing required argument: url"), nil
+	}
+	res, err := detectMCPScope(rawURL)
+	if err != nil {
+		return azdext.MCPErrorResult("%s", err.Error()), nil
 	}
+	return azdext.MCPJSONResult(res), nil
+}
+
+// handleAzdContext returns the current azd environment name, subscription,
+// tenant, and location so agents can compose ARM URLs without guessing the
+// subscription ID. Only these well-known values are exposed; other environment
+// values may hold secrets and are never returned.
+func handleAzdContext(ctx context.Context, _ azdext.ToolArgs) (*mcp.CallToolResult, error) {
+	// The tool call context comes from the MCP server, not the extension root
+	// command, so it carries no azd access token yet.
+	azdCtx, err := azdContextLoader(azdext.WithAccessToken(ctx))
+	if err != nil {
+		return azdext.MCPErrorResult("failed to resolve azd context: %s", err.Error()), nil
+	}
+	return azdext.MCPJSONResult(azdCtx), nil
 }
 
 const mcpInstructions = `You are an Azure REST API assistant powered by the azd-rest extension.
 You can execute authenticated HTTP requests against Azure and other REST APIs.
 OAuth scopes are automatically detected from the URL for known Azure services
 (management.azure.com, graph.microsoft.com, etc.). Use the scope parameter
-to override when needed. All requests include Azure bearer token authentication
+to override when needed, and rest_detect_scope to check a URL's scope before
+calling it. All requests include Azure bearer token authentication
 by default. Use timeoutSeconds, retry, maxResponseSizeBytes, and noAuth to
-tune one request when needed.`
+tune one request when needed. Set paginate on rest_get to fetch a complete
+ARM or Graph list in one call, bounded by maxPages and maxItems. When a write
+returns 201 or 202 with an Azure-AsyncOperation or Location header, pass that
+URL to rest_wait_operation instead of polling with rest_get. Binary bodies are returned base64-encoded with
+encoding set to "base64". Bodies larger than pageSizeBytes are truncated;
+pass the continuation to rest_get_more to read the rest. Call azd_context to get the current azd
+environment's subscription, tenant, and location before composing ARM URLs.`
+
+// mcpServerOptions configures the tools the MCP server exposes.
+type mcpServerOptions struct {
+	// ReadOnly omits the mutating tools.
+	ReadOnly bool
+	// ConfirmWrites asks the user, through MCP elicitation, to approve each
+	// PUT, PATCH, and DELETE against Azure Resource Manager.
+	ConfirmWrites bool
+}
 
-func newMCPServer(readOnly bool) *server.MCPServer {
+func newMCPServer(opts mcpServerOptions) *server.MCPServer {
 	policy := getMCPSecurityPolicy()
 	builder := azdext.NewMCPServerBuilder("azd-rest", version.Version).
-		WithRateLimit(10, 1.0).
 		WithInstructions(mcpInstructions).
-		WithSecurityPolicy(policy)
+		WithSecurityPolicy(policy).
+		WithPromptCapabilities(false).
+		WithResourceCapabilities(false, false).
+		AddResources(mcpHistoryResource(), mcpDNSCacheResource())
+
+	confirm := func(_ string, handler azdext.MCPToolHandler) azdext.MCPToolHandler {
+		return handler
+	}
+	if opts.ConfirmWrites {
+		builder.WithServerOption(server.WithElicitation())
+		confirm = confirmWrite
+	}
+
+	// Rate limits are keyed per tool and per destination host instead of one
+	// limiter shared by every call.
+	limiter := newKeyedRateLimiter()
 
 	// GET - readonly
 	builder.AddTool(
-		"rest_get", handleNoBodyMethod("GET"),
+		"rest_get", requestRateLimited(limiter, "rest_get", mcpReadRateLimit, handleNoBodyMethod("GET")),
 		azdext.MCPToolOptions{
 			Description: "Execute an authenticated GET request against an Azure or REST API endpoint",
 			ReadOnly:    true,
 		},
-		mcpNoBodyToolOptions()...,
+		mcpGetToolOptions()...,
 	)
 
 	// Mutating tools are skipped in read-only mode so that no write tool exists
 	// at the tool surface, not merely guarded at call time (#170).
-	if !readOnly {
+	if !opts.ReadOnly {
 		// POST
-		builder.AddTool("rest_post", handleBodyMethod("POST"),
+		builder.AddTool("rest_post", requestRateLimited(limiter, "rest_post", mcpWriteRateLimit, handleBodyMethod("POST")),
 			azdext.MCPToolOptions{
 				Description: "Execute an authenticated POST request against an Azure or REST API endpoint",
 				Destructive: true,
@@ -461,7 +746,7 @@ func newMCPServer(readOnly bool) *server.MCPServer {
 		)
 
 		// PUT
-		builder.AddTool("rest_put", handleBodyMethod("PUT"),
+		builder.AddTool("rest_put", requestRateLimited(limiter, "rest_put", mcpWriteRateLimit, confirm("PUT", handleBodyMethod("PUT"))),
 			azdext.MCPToolOptions{
 				Description: "Execute an authenticated PUT request against an Azure or REST API endpoint",
 				Idempotent:  true,
@@ -470,7 +755,7 @@ func newMCPServer(readOnly bool) *server.MCPServer {
 		)
 
 		// PATCH
-		builder.AddTool("rest_patch", handleBodyMethod("PATCH"),
+		builder.AddTool("rest_patch", requestRateLimited(limiter, "rest_patch", mcpWriteRateLimit, confirm("PATCH", handleBodyMethod("PATCH"))),
 			azdext.MCPToolOptions{
 				Description: "Execute an authenticated PATCH request against an Azure or REST API endpoint",
 				Destructive: true,
@@ -479,7 +764,7 @@ func newMCPServer(readOnly bool) *server.MCPServer {
 		)
 
 		// DELETE - destructive
-		builder.AddTool("rest_delete", handleNoBodyMethod("DELETE"),
+		builder.AddTool("rest_delete", requestRateLimited(limiter, "rest_delete", mcpWriteRateLimit, confirm("DELETE", handleNoBodyMethod("DELETE"))),
 			azdext.MCPToolOptions{
 				Description: "Execute an authenticated DELETE request against an Azure or REST API endpoint",
 				Destructive: true,
@@ -490,7 +775,7 @@ func newMCPServer(readOnly bool) *server.MCPServer {
 
 	// HEAD - readonly
 	builder.AddTool(
-		"rest_head", handleHead,
+		"rest_head", requestRateLimited(limiter, "rest_head", mcpReadRateLimit, handleHead),
 		azdext.MCPToolOptions{
 			Description: "Execute an authenticated HEAD request to retrieve response headers without body",
 			ReadOnly:    true,
@@ -498,7 +783,52 @@ func newMCPServer(readOnly bool) *server.MCPServer {
 		mcpNoBodyToolOptions()...,
 	)
 
-	return builder.Build()
+	// Long-running operation polling - readonly
+	builder.AddTool(
+		"rest_wait_operation", requestRateLimited(limiter, "rest_wait_operation", mcpReadRateLimit, handleWaitOperation),
+		azdext.MCPToolOptions{
+			Description: "Poll an Azure-AsyncOperation or Location URL until the long-running operation succeeds, fails, or is canceled",
+			ReadOnly:    true,
+			Idempotent:  true,
+		},
+		mcpWaitOperationToolOptions()...,
+	)
+
+	// Next page of a truncated response - readonly, makes no HTTP request
+	builder.AddTool(
+		"rest_get_more", rateLimited(limiter, "rest_get_more", mcpReadRateLimit, handleGetMore),
+		azdext.MCPToolOptions{
+			Description: "Return the next page of a truncated response body using the continuation from a previous call",
+			ReadOnly:    true,
+			Idempotent:  true,
+		},
+		mcp.WithString("continuation", mcp.Required(), mcp.Description("The continuation value from a truncated response or a previous rest_get_more call")),
+	)
+
+	// Scope detection - readonly, makes no HTTP request
+	builder.AddTool(
+		"rest_detect_scope", rateLimited(limiter, "rest_detect_scope", mcpReadRateLimit, handleDetectScope),
+		azdext.MCPToolOptions{
+			Description: "Return the OAuth scope detected for a URL and whether it is an Azure host, without making a request",
+			ReadOnly:    true,
+			Idempotent:  true,
+		},
+		mcp.WithString("url", mcp.Required(), mcp.Description("The request URL to inspect")),
+	)
+
+	// azd context - readonly, makes no HTTP request
+	builder.AddTool(
+		"azd_context", rateLimited(limiter, "azd_context", mcpReadRateLimit, handleAzdContext),
+		azdext.MCPToolOptions{
+			Description: "Return the current azd environment name, subscription ID, tenant ID, and location (no secrets)",
+			ReadOnly:    true,
+			Idempotent:  true,
+		},
+	)
+
+	s := builder.Build()
+	s.AddPrompts(mcpPrompts()...)
+	return s
 }
 
 // NewMCPCommand creates the MCP server command group.
@@ -509,18 +839,20 @@ func NewMCPCommand() *cobra.Command {
 		Hidden: true,
 	}
 
-	var readOnly bool
+	var opts mcpServerOptions
 	serveCmd := &cobra.Command{
 		Use:    "serve",
 		Short:  "Start MCP stdio server",
 		Hidden: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			s := newMCPServer(readOnly)
+			s := newMCPServer(opts)
 			return server.ServeStdio(s)
 		},
 	}
-	serveCmd.Flags().BoolVar(&readOnly, "read-only", false,
+	serveCmd.Flags().BoolVar(&opts.ReadOnly, "read-only", false,
 		"Expose only read-only tools (rest_get, rest_head); omit the mutating POST, PUT, PATCH, and DELETE tools")
+	serveCmd.Flags().BoolVar(&opts.ConfirmWrites, "confirm-writes", false,
+		"Ask the user to approve each PUT, PATCH, and DELETE against Azure Resource Manager through MCP elicitation")
 
 	mcpCmd.AddCommand(serveCmd)
 	return mcpCmd
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"unicode/utf8"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// Binary response bodies are returned base64-encoded. Encoding grows the body
+// by a third, so the default cap is well below the text response limit.
+const (
+	mcpDefaultBinarySize = 1024 * 1024
+	mcpEncodingBase64    = "base64"
+)
+
+// isBinaryBody reports whether body must be encoded to survive as a JSON
+// string: a binary content type, a NUL byte in the first bytes, or invalid
+// UTF-8.
+func isBinaryBody(body []byte, contentType string) bool {
+	if len(body) == 0 {
+		return false
+	}
+	return client.DetectContentType(body, contentType) || !utf8.Valid(body)
+}
+
+// encodeBinaryBody sets resp's body to the base64 encoding of body. A body
+// larger than maxSize is omitted instead; TotalBytes reports its size so the
+// caller can retry with a larger maxBinaryBytes.
+func encodeBinaryBody(resp *mcpResponse, body []byte, maxSize int) {
+	if len(body) > maxSize {
+		resp.BodyOmitted = true
+		resp.TotalBytes = len(body)
+		return
+	}
+	resp.Body = base64.StdEncoding.EncodeToString(body)
+	resp.Encoding = mcpEncodingBase64
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0x00}
+
+func TestIsBinaryBody(t *testing.T) {
+	assert.True(t, isBinaryBody([]byte("abc"), "image/png"))
+	assert.True(t, isBinaryBody(pngHeader, ""))
+	assert.True(t, isBinaryBody([]byte{0xff, 0xfe, 'a'}, "text/plain"))
+	assert.False(t, isBinaryBody([]byte(`{"a":1}`), "application/json"))
+	assert.False(t, isBinaryBody(nil, "image/png"))
+}
+
+func TestEncodeBinaryBody(t *testing.T) {
+	resp := &mcpResponse{}
+	encodeBinaryBody(resp, pngHeader, 1024)
+	assert.Equal(t, mcpEncodingBase64, resp.Encoding)
+	decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, decoded)
+
+	resp = &mcpResponse{}
+	encodeBinaryBody(resp, pngHeader, 4)
+	assert.Empty(t, resp.Body)
+	assert.Empty(t, resp.Encoding)
+	assert.True(t, resp.BodyOmitted)
+	assert.Equal(t, len(pngHeader), resp.TotalBytes)
+}
+
+func TestStructureResponse_Base64(t *testing.T) {
+	got := structureResponse(&mcpResponse{StatusCode: 200, Body: "iVBORw==", Encoding: mcpEncodingBase64})
+	assert.Equal(t, "iVBORw==", got.Body)
+	assert.Equal(t, mcpBodyFormatBase64, got.BodyFormat)
+}
+
+func TestExecuteMCPRequest_BinaryBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngHeader)
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	resp, err := executeMCPRequest(context.Background(), "GET", srv.URL, "", "", nil, controls)
+	require.NoError(t, err)
+	assert.Equal(t, mcpEncodingBase64, resp.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pngHeader), resp.Body)
+
+	controls.MaxBinarySize = 4
+	resp, err = executeMCPRequest(context.Background(), "GET", srv.URL, "", "", nil, controls)
+	require.NoError(t, err)
+	assert.True(t, resp.BodyOmitted)
+	assert.Empty(t, resp.Body)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpConfirmField is the boolean the user sets to approve a write.
+const mcpConfirmField = "confirm"
+
+// mcpElicit sends an elicitation request to the MCP client. It is a package
+// variable so tests can answer for the user.
+var mcpElicit = func(ctx context.Context, req mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	s := server.ServerFromContext(ctx)
+	if s == nil {
+		return nil, server.ErrNoActiveSession
+	}
+	return s.RequestElicitation(ctx, req)
+}
+
+// confirmWrite wraps a mutating tool handler so a call against Azure Resource
+// Manager runs only after the user approves it through MCP elicitation. Calls
+// to other hosts run unchanged. When the client cannot ask the user, the call
+// is refused: --confirm-writes fails closed.
+func confirmWrite(method string, handler azdext.MCPToolHandler) azdext.MCPToolHandler {
+	return func(ctx context.Context, args azdext.ToolArgs) (*mcp.CallToolResult, error) {
+		rawURL := args.OptionalString("url", "")
+		parsed, err := url.Parse(rawURL)
+		if err != nil || !client.IsARMHost(parsed.Hostname()) {
+			return handler(ctx, args)
+		}
+
+		approved, err := requestWriteConfirmation(ctx, method, rawURL)
+		if err != nil {
+			return azdext.MCPErrorResult("%s %s requires user confirmation (--confirm-writes), but the client could not ask: %s", method, rawURL, err.Error()), nil
+		}
+		if !approved {
+			return azdext.MCPErrorResult("the user did not approve %s %s", method, rawURL), nil
+		}
+		return handler(ctx, args)
+	}
+}
+
+// requestWriteConfirmation asks the user whether method may be sent to rawURL.
+// Only an accepted response with the confirm box checked counts as approval.
+func requestWriteConfirmation(ctx context.Context, method, rawURL string) (bool, error) {
+	req := mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: fmt.Sprintf("An assistant wants to send %s %s through azd rest. Allow this request?", method, rawURL),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					mcpConfirmField: map[string]any{
+						"type":        "boolean",
+						"title":       "Allow " + method,
+						"description": "Check to send the request to Azure Resource Manager",
+					},
+				},
+				"required": []string{mcpConfirmField},
+			},
+		},
+	}
+	res, err := mcpElicit(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	if res.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+	content, ok := res.Content.(map[string]any)
+	if !ok {
+		return false, nil
+	}
+	confirmed, _ := content[mcpConfirmField].(bool)
+	return confirmed, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withElicit answers elicitation requests with fn for one test and records
+// whether the user was asked.
+func withElicit(t *testing.T, fn func(mcp.ElicitationRequest) (*mcp.ElicitationResult, error)) *bool {
+	t.Helper()
+	asked := false
+	orig := mcpElicit
+	mcpElicit = func(_ context.Context, req mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		asked = true
+		return fn(req)
+	}
+	t.Cleanup(func() { mcpElicit = orig })
+	return &asked
+}
+
+func elicitResult(action mcp.ElicitationResponseAction, content any) *mcp.ElicitationResult {
+	return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: action, Content: content}}
+}
+
+const armDeleteURL = "https://management.azure.com/subscriptions/sub1/resourceGroups/rg1?api-version=2021-04-01"
+
+func TestConfirmWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *mcp.ElicitationResult
+		err     error
+		allowed bool
+		message string
+	}{
+		{"approved", elicitResult(mcp.ElicitationResponseActionAccept, map[string]any{"confirm": true}), nil, true, ""},
+		{"unchecked", elicitResult(mcp.ElicitationResponseActionAccept, map[string]any{"confirm": false}), nil, false, "did not approve"},
+		{"declined", elicitResult(mcp.ElicitationResponseActionDecline, nil), nil, false, "did not approve"},
+		{"cancelled", elicitResult(mcp.ElicitationResponseActionCancel, nil), nil, false, "did not approve"},
+		{"unsupported", nil, errors.New("session does not support elicitation"), false, "could not ask"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			withElicit(t, func(req mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+				prompt = req.Params.Message
+				return tt.result, tt.err
+			})
+
+			ran := false
+			handler := confirmWrite("DELETE", func(context.Context, azdext.ToolArgs) (*mcp.CallToolResult, error) {
+				ran = true
+				return azdext.MCPTextResult("ok"), nil
+			})
+			result, err := handler(context.Background(), newToolArgs(map[string]any{"url": armDeleteURL}))
+			require.NoError(t, err)
+			assert.Contains(t, prompt, "DELETE "+armDeleteURL)
+			assert.Equal(t, tt.allowed, ran)
+			assert.Equal(t, !tt.allowed, result.IsError)
+			if tt.message != "" {
+				assert.Contains(t, resultText(t, result), tt.message)
+			}
+		})
+	}
+}
+
+func TestConfirmWrite_NonARMHostSkipsConfirmation(t *testing.T) {
+	asked := withElicit(t, func(mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		return elicitResult(mcp.ElicitationResponseActionDecline, nil), nil
+	})
+
+	handler := confirmWrite("PUT", okHandler)
+	result, err := handler(context.Background(), newToolArgs(map[string]any{"url": "https://myvault.vault.azure.net/secrets/s1"}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.False(t, *asked)
+}
+
+func TestNewMCPServer_ConfirmWritesKeepsTools(t *testing.T) {
+	tools := newMCPServer(mcpServerOptions{ConfirmWrites: true}).ListTools()
+	for _, name := range []string{"rest_put", "rest_patch", "rest_delete"} {
+		_, ok := tools[name]
+		assert.True(t, ok, "tool %q should be registered", name)
+	}
+}
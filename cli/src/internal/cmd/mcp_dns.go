@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Host checks for MCP requests are cached so an agent making many calls to
+// the same host does not wait on DNS for each one. The cached addresses are
+// the ones the request dials, so a cached check cannot be bypassed by DNS
+// rebinding.
+const (
+	mcpDNSCacheTTL        = 60 * time.Second
+	mcpDNSCacheMaxEntries = 1024
+	mcpDNSCacheURI        = "azd-rest://dns-cache"
+)
+
+// mcpHostCheck is a host that passed a security policy and the addresses it
+// resolved to.
+type mcpHostCheck struct {
+	policy  *azdext.MCPSecurityPolicy
+	addrs   []string
+	expires time.Time
+}
+
+// mcpDNSCache caches host checks for a fixed TTL and counts hits and misses.
+type mcpDNSCache struct {
+	ttl    time.Duration
+	now    func() time.Time
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]mcpHostCheck
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// mcpDNSCacheStats is the body of the DNS cache resource.
+type mcpDNSCacheStats struct {
+	TTLSeconds int     `json:"ttlSeconds"`
+	Entries    int     `json:"entries"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hitRate"`
+}
+
+func newMCPDNSCache(ttl time.Duration) *mcpDNSCache {
+	return &mcpDNSCache{
+		ttl:     ttl,
+		now:     time.Now,
+		lookup:  net.DefaultResolver.LookupHost,
+		entries: make(map[string]mcpHostCheck),
+	}
+}
+
+// mcpDNS holds the host checks made by this MCP server process.
+var mcpDNS = newMCPDNSCache(mcpDNSCacheTTL)
+
+// checkURL applies policy to rawURL and returns the addresses the request
+// must dial, or nil when the host is an IP literal. A host that passed within
+// the TTL is not checked or resolved again; blocked hosts are never cached.
+func (c *mcpDNSCache) checkURL(ctx context.Context, policy *azdext.MCPSecurityPolicy, rawURL string) ([]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" || net.ParseIP(u.Hostname()) != nil {
+		return nil, policy.CheckURL(rawURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	key := strings.ToLower(u.Scheme) + "://" + host
+	if addrs, ok := c.get(key, policy); ok {
+		c.hits.Add(1)
+		return addrs, nil
+	}
+	c.misses.Add(1)
+
+	// The policy check covers the scheme, blocked host names, and the
+	// addresses from its own lookup. The addresses the request will dial are
+	// resolved separately, so check them as well.
+	if err := policy.CheckURL(rawURL); err != nil {
+		return nil, err
+	}
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("DNS resolution failed for host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		literal := *u
+		literal.Host = addr
+		if port := u.Port(); port != "" {
+			literal.Host = net.JoinHostPort(addr, port)
+		} else if strings.Contains(addr, ":") {
+			literal.Host = "[" + addr + "]"
+		}
+		if err := policy.CheckURL(literal.String()); err != nil {
+			return nil, fmt.Errorf("%w (resolved from %s)", err, host)
+		}
+	}
+	c.put(key, policy, addrs)
+	return addrs, nil
+}
+
+func (c *mcpDNSCache) get(key string, policy *azdext.MCPSecurityPolicy) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.policy != policy || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.addrs, true
+}
+
+func (c *mcpDNSCache) put(key string, policy *azdext.MCPSecurityPolicy, addrs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= mcpDNSCacheMaxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= mcpDNSCacheMaxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = mcpHostCheck{policy: policy, addrs: addrs, expires: now.Add(c.ttl)}
+}
+
+// stats reports the cache size and hit rate.
+func (c *mcpDNSCache) stats() mcpDNSCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+	s := mcpDNSCacheStats{
+		TTLSeconds: int(c.ttl / time.Second),
+		Entries:    entries,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+// mcpDNSCacheResource returns the DNS cache metrics resource.
+func mcpDNSCacheResource() server.ServerResource {
+	return server.ServerResource{
+		Resource: mcp.NewResource(mcpDNSCacheURI, "DNS cache",
+			mcp.WithResourceDescription("Hit rate and size of the host resolution cache used by azd rest MCP tools"),
+			mcp.WithMIMEType("application/json"),
+		),
+		Handler: func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			data, err := json.MarshalIndent(mcpDNS.stats(), "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: mcpDNSCacheURI, MIMEType: "application/json", Text: string(data)},
+			}, nil
+		},
+	}
+}
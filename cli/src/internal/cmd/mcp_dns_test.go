@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDNSCache returns a cache whose lookups and clock are controlled by
+// the test. The policy check itself still resolves the host, so tests use
+// localhost, which resolves without a DNS server.
+func newTestDNSCache(addrs ...string) (*mcpDNSCache, *int, *time.Time) {
+	lookups := 0
+	now := time.Unix(1_700_000_000, 0)
+	c := newMCPDNSCache(time.Minute)
+	c.now = func() time.Time { return now }
+	c.lookup = func(context.Context, string) ([]string, error) {
+		lookups++
+		return addrs, nil
+	}
+	return c, &lookups, &now
+}
+
+func TestMCPDNSCache_CachesChecks(t *testing.T) {
+	c, lookups, now := newTestDNSCache("127.0.0.1")
+	policy := azdext.NewMCPSecurityPolicy()
+	ctx := context.Background()
+
+	for range 3 {
+		addrs, err := c.checkURL(ctx, policy, "http://localhost:8080/path")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	}
+	assert.Equal(t, 1, *lookups)
+
+	*now = now.Add(2 * time.Minute)
+	_, err := c.checkURL(ctx, policy, "http://localhost:8080/other")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *lookups, "an expired entry is resolved again")
+
+	stats := c.stats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.InDelta(t, 0.5, stats.HitRate, 0.001)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, 60, stats.TTLSeconds)
+}
+
+func TestMCPDNSCache_ChecksDialedAddresses(t *testing.T) {
+	c, _, _ := newTestDNSCache("169.254.169.254")
+	policy := azdext.NewMCPSecurityPolicy().BlockMetadataEndpoints()
+
+	for range 2 {
+		_, err := c.checkURL(context.Background(), policy, "http://localhost/")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "resolved from localhost")
+	}
+	assert.Zero(t, c.stats().Entries, "blocked hosts are not cached")
+}
+
+func TestMCPDNSCache_PolicyChangeMisses(t *testing.T) {
+	c, lookups, _ := newTestDNSCache("127.0.0.1")
+	ctx := context.Background()
+
+	_, err := c.checkURL(ctx, azdext.NewMCPSecurityPolicy(), "http://localhost/")
+	require.NoError(t, err)
+	_, err = c.checkURL(ctx, azdext.NewMCPSecurityPolicy().BlockPrivateNetworks(), "http://localhost/")
+	require.Error(t, err)
+	assert.Equal(t, 1, *lookups)
+}
+
+func TestMCPDNSCache_IPLiteralsSkipCache(t *testing.T) {
+	c, lookups, _ := newTestDNSCache()
+
+	addrs, err := c.checkURL(context.Background(), azdext.NewMCPSecurityPolicy(), "http://127.0.0.1:9/")
+	require.NoError(t, err)
+	assert.Nil(t, addrs)
+
+	_, err = c.checkURL(context.Background(), azdext.DefaultMCPSecurityPolicy(), "https://169.254.169.254/")
+	require.Error(t, err)
+	assert.Zero(t, *lookups)
+	assert.Zero(t, c.stats().Misses)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MCP request history, exposed as a resource so agent hosts can show users
+// which API calls were made during a session.
+const (
+	mcpHistoryURI        = "azd-rest://history"
+	mcpMaxHistoryEntries = 100
+)
+
+// mcpHistoryEntry records one request made by an MCP tool. The URL has
+// secret-bearing query parameters redacted; headers and bodies are not kept.
+type mcpHistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// mcpRequestHistory keeps the most recent entries in memory.
+type mcpRequestHistory struct {
+	mu      sync.Mutex
+	max     int
+	entries []mcpHistoryEntry
+}
+
+// mcpHistory holds the requests made by this MCP server process.
+var mcpHistory = &mcpRequestHistory{max: mcpMaxHistoryEntries}
+
+// record appends the outcome of one request, dropping the oldest entry when
+// the history is full.
+func (h *mcpRequestHistory) record(method, rawURL string, resp *mcpResponse, err error, started time.Time) {
+	redacted := client.RedactURL(rawURL)
+	entry := mcpHistoryEntry{
+		Time:       started.UTC(),
+		Method:     method,
+		URL:        redacted,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		// Transport errors quote the full URL; keep only the redacted form.
+		entry.Error = client.RedactSecrets(strings.ReplaceAll(err.Error(), rawURL, redacted))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+}
+
+// recent returns the entries, newest first.
+func (h *mcpRequestHistory) recent() []mcpHistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	res := make([]mcpHistoryEntry, len(h.entries))
+	for i, e := range h.entries {
+		res[len(h.entries)-1-i] = e
+	}
+	return res
+}
+
+// mcpHistoryResource returns the request history resource.
+func mcpHistoryResource() server.ServerResource {
+	return server.ServerResource{
+		Resource: mcp.NewResource(mcpHistoryURI, "Request history",
+			mcp.WithResourceDescription("The most recent HTTP requests made by azd rest MCP tools, newest first, with secrets redacted"),
+			mcp.WithMIMEType("application/json"),
+		),
+		Handler: func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			data, err := json.MarshalIndent(mcpHistory.recent(), "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: mcpHistoryURI, MIMEType: "application/json", Text: string(data)},
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withHistory replaces the request history for one test.
+func withHistory(t *testing.T, maxEntries int) *mcpRequestHistory {
+	t.Helper()
+	orig := mcpHistory
+	mcpHistory = &mcpRequestHistory{max: maxEntries}
+	t.Cleanup(func() { mcpHistory = orig })
+	return mcpHistory
+}
+
+func TestRequestHistory_NewestFirstAndCapped(t *testing.T) {
+	h := withHistory(t, 2)
+	for i := 1; i <= 3; i++ {
+		h.record("GET", fmt.Sprintf("https://example.com/%d", i), &mcpResponse{StatusCode: 200}, nil, time.Now())
+	}
+
+	entries := h.recent()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "https://example.com/3", entries[0].URL)
+	assert.Equal(t, "https://example.com/2", entries[1].URL)
+	assert.Equal(t, 200, entries[0].StatusCode)
+}
+
+func TestRequestHistory_RedactsURL(t *testing.T) {
+	h := withHistory(t, 10)
+	rawURL := "https://acct.blob.core.windows.net/c/b?sv=2022-11-02&sig=supersecret"
+	h.record("GET", rawURL, nil, errors.New(`Get "`+rawURL+`": connection refused`), time.Now())
+
+	entry := h.recent()[0]
+	assert.NotContains(t, entry.URL, "supersecret")
+	assert.NotContains(t, entry.Error, "supersecret")
+	assert.Contains(t, entry.Error, "connection refused")
+	assert.Zero(t, entry.StatusCode)
+}
+
+func TestExecuteMCPRequest_RecordsHistory(t *testing.T) {
+	h := withHistory(t, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	_, err := executeMCPRequest(context.Background(), "DELETE", srv.URL+"/items/1", "", "", nil, controls)
+	require.NoError(t, err)
+
+	entries := h.recent()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DELETE", entries[0].Method)
+	assert.Equal(t, srv.URL+"/items/1", entries[0].URL)
+	assert.Equal(t, http.StatusNoContent, entries[0].StatusCode)
+	assert.Empty(t, entries[0].Error)
+}
+
+func TestNewMCPServer_HistoryResource(t *testing.T) {
+	h := withHistory(t, 10)
+	h.record("GET", "https://example.com/a", &mcpResponse{StatusCode: 200}, nil, time.Now())
+
+	res, ok := newMCPServer(mcpServerOptions{}).ListResources()[mcpHistoryURI]
+	require.True(t, ok)
+	contents, err := res.Handler(context.Background(), mcp.ReadResourceRequest{})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+
+	text, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+	var entries []mcpHistoryEntry
+	require.NoError(t, json.Unmarshal([]byte(text.Text), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "https://example.com/a", entries[0].URL)
+}
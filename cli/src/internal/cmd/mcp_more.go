@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Limits for MCP response paging. Bodies larger than the page size are split:
+// the first page is returned inline and the full body is kept in memory for a
+// short time so rest_get_more can return the remaining pages.
+const (
+	mcpDefaultPageSize  = 64 * 1024
+	mcpMinPageSize      = 1024
+	mcpContinuationTTL  = 5 * time.Minute
+	mcpMaxStoredBodies  = 8
+	mcpContinuationSep  = "."
+	mcpContinuationSize = 16 // random bytes in a continuation handle
+)
+
+// storedBody is a response body held for paging.
+type storedBody struct {
+	data     []byte
+	pageSize int
+	expires  time.Time
+}
+
+// mcpBodyStore is a short-lived in-memory store of response bodies keyed by a
+// random handle. Entries expire after ttl, and the oldest entry is evicted when
+// the store is full, so memory stays bounded.
+type mcpBodyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	now     func() time.Time
+	entries map[string]*storedBody
+	order   []string
+}
+
+func newMCPBodyStore(ttl time.Duration, maxEntries int) *mcpBodyStore {
+	return &mcpBodyStore{
+		ttl:     ttl,
+		max:     maxEntries,
+		now:     time.Now,
+		entries: make(map[string]*storedBody),
+	}
+}
+
+// mcpBodies holds the bodies of paged MCP responses.
+var mcpBodies = newMCPBodyStore(mcpContinuationTTL, mcpMaxStoredBodies)
+
+// put stores data and returns its handle.
+func (s *mcpBodyStore) put(data []byte, pageSize int) (string, error) {
+	buf := make([]byte, mcpContinuationSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to create continuation handle: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	for len(s.order) >= s.max {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.entries[id] = &storedBody{data: data, pageSize: pageSize, expires: s.now().Add(s.ttl)}
+	s.order = append(s.order, id)
+	return id, nil
+}
+
+// get returns the stored body for id, or false when it is unknown or expired.
+func (s *mcpBodyStore) get(id string) (*storedBody, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	body, ok := s.entries[id]
+	return body, ok
+}
+
+// expireLocked drops expired entries. The caller must hold s.mu.
+func (s *mcpBodyStore) expireLocked() {
+	now := s.now()
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if now.After(s.entries[id].expires) {
+			delete(s.entries, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+// pageEnd returns the end of the page of data starting at offset, moved back to
+// a UTF-8 rune boundary so a page never splits a character.
+func pageEnd(data []byte, offset, pageSize int) int {
+	end := offset + pageSize
+	if end >= len(data) {
+		return len(data)
+	}
+	for i := end; i > offset && end-i < utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			return i
+		}
+	}
+	return end
+}
+
+// continuationToken encodes a handle and the offset of the next page.
+func continuationToken(id string, offset int) string {
+	return id + mcpContinuationSep + strconv.Itoa(offset)
+}
+
+// parseContinuationToken splits a token produced by continuationToken.
+func parseContinuationToken(token string) (string, int, error) {
+	id, rawOffset, ok := strings.Cut(token, mcpContinuationSep)
+	offset, err := strconv.Atoi(rawOffset)
+	if !ok || id == "" || err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("invalid continuation %q", token)
+	}
+	return id, offset, nil
+}
+
+// paginateMCPResponse replaces an oversized body with its first page and a
+// continuation for rest_get_more.
+func paginateMCPResponse(resp *mcpResponse, body []byte, pageSize int) error {
+	resp.Body = string(body)
+	if len(body) <= pageSize {
+		return nil
+	}
+	id, err := mcpBodies.put(body, pageSize)
+	if err != nil {
+		return err
+	}
+	end := pageEnd(body, 0, pageSize)
+	resp.Body = string(body[:end])
+	resp.Truncated = true
+	resp.TotalBytes = len(body)
+	resp.Continuation = continuationToken(id, end)
+	return nil
+}
+
+// mcpMoreResult is the JSON structure returned by the rest_get_more tool.
+type mcpMoreResult struct {
+	Body         string `json:"body"`
+	Offset       int    `json:"offset"`
+	TotalBytes   int    `json:"totalBytes"`
+	Continuation string `json:"continuation,omitempty"`
+}
+
+// handleGetMore returns the next page of a response that a request tool
+// truncated.
+func handleGetMore(_ context.Context, args azdext.ToolArgs) (*mcp.CallToolResult, error) {
+	token, err := args.RequireString("continuation")
+	if err != nil {
+		//nolint:nilerr // intentional: surface validation error as MCP tool result, not Go error
+		return azdext.MCPErrorResult("missing required argument: continuation"), nil
+	}
+	id, offset, err := parseContinuationToken(token)
+	if err != nil {
+		return azdext.MCPErrorResult("%s", err.Error()), nil
+	}
+	body, ok := mcpBodies.get(id)
+	if !ok {
+		return azdext.MCPErrorResult("continuation has expired or is unknown; repeat the original request"), nil
+	}
+	if offset >= len(body.data) {
+		return azdext.MCPErrorResult("continuation offset %d is past the end of the %d byte response", offset, len(body.data)), nil
+	}
+
+	end := pageEnd(body.data, offset, body.pageSize)
+	res := mcpMoreResult{
+		Body:       string(body.data[offset:end]),
+		Offset:     offset,
+		TotalBytes: len(body.data),
+	}
+	if end < len(body.data) {
+		res.Continuation = continuationToken(id, end)
+	}
+	return azdext.MCPJSONResult(res), nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withBodyStore replaces the paging store for one test.
+func withBodyStore(t *testing.T, store *mcpBodyStore) {
+	t.Helper()
+	orig := mcpBodies
+	mcpBodies = store
+	t.Cleanup(func() { mcpBodies = orig })
+}
+
+func TestPageEnd(t *testing.T) {
+	data := []byte("abcdef")
+	assert.Equal(t, 4, pageEnd(data, 0, 4))
+	assert.Equal(t, 6, pageEnd(data, 4, 4))
+
+	// "é" is two bytes; a page never splits it.
+	data = []byte("abé")
+	assert.Equal(t, 2, pageEnd(data, 0, 3))
+}
+
+func TestParseContinuationToken(t *testing.T) {
+	id, offset, err := parseContinuationToken(continuationToken("abc", 42))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	assert.Equal(t, 42, offset)
+
+	for _, token := range []string{"", "abc", "abc.x", ".5", "abc.-1"} {
+		_, _, err := parseContinuationToken(token)
+		assert.Error(t, err, "token %q", token)
+	}
+}
+
+func TestMCPBodyStore_EvictsOldest(t *testing.T) {
+	store := newMCPBodyStore(time.Minute, 2)
+	first, err := store.put([]byte("1"), 1)
+	require.NoError(t, err)
+	_, err = store.put([]byte("2"), 1)
+	require.NoError(t, err)
+	third, err := store.put([]byte("3"), 1)
+	require.NoError(t, err)
+
+	_, ok := store.get(first)
+	assert.False(t, ok)
+	body, ok := store.get(third)
+	require.True(t, ok)
+	assert.Equal(t, []byte("3"), body.data)
+}
+
+func TestMCPBodyStore_Expires(t *testing.T) {
+	now := time.Now()
+	store := newMCPBodyStore(time.Minute, 2)
+	store.now = func() time.Time { return now }
+	id, err := store.put([]byte("body"), 1)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, ok := store.get(id)
+	assert.False(t, ok)
+}
+
+func TestPaginateMCPResponse_SmallBody(t *testing.T) {
+	withBodyStore(t, newMCPBodyStore(time.Minute, 2))
+	resp := &mcpResponse{StatusCode: http.StatusOK}
+	require.NoError(t, paginateMCPResponse(resp, []byte("small"), 1024))
+	assert.Equal(t, "small", resp.Body)
+	assert.False(t, resp.Truncated)
+	assert.Empty(t, resp.Continuation)
+}
+
+func TestHandleGetMore_ReadsAllPages(t *testing.T) {
+	withBodyStore(t, newMCPBodyStore(time.Minute, 2))
+	body := strings.Repeat("0123456789", 25)
+
+	resp := &mcpResponse{StatusCode: http.StatusOK}
+	require.NoError(t, paginateMCPResponse(resp, []byte(body), 100))
+	require.True(t, resp.Truncated)
+	assert.Equal(t, len(body), resp.TotalBytes)
+
+	got := resp.Body
+	continuation := resp.Continuation
+	for continuation != "" {
+		result, err := handleGetMore(context.Background(), newToolArgs(map[string]any{"continuation": continuation}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		var page mcpMoreResult
+		require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &page))
+		assert.Equal(t, len(got), page.Offset)
+		got += page.Body
+		continuation = page.Continuation
+	}
+	assert.Equal(t, body, got)
+}
+
+func TestHandleGetMore_Errors(t *testing.T) {
+	withBodyStore(t, newMCPBodyStore(time.Minute, 2))
+	id, err := mcpBodies.put([]byte("body"), 1024)
+	require.NoError(t, err)
+
+	tests := map[string]map[string]any{
+		"missing required argument": nil,
+		"invalid continuation":      {"continuation": "nope"},
+		"expired or is unknown":     {"continuation": continuationToken("unknown", 0)},
+		"past the end":              {"continuation": continuationToken(id, 4)},
+	}
+	for want, args := range tests {
+		result, err := handleGetMore(context.Background(), newToolArgs(args))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), want)
+	}
+}
+
+func TestExecuteMCPRequest_PagesLargeBody(t *testing.T) {
+	withBodyStore(t, newMCPBodyStore(time.Minute, 2))
+	body := strings.Repeat("x", 3000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	controls.PageSize = mcpMinPageSize
+	resp, err := executeMCPRequest(context.Background(), "GET", srv.URL, "", "", nil, controls)
+	require.NoError(t, err)
+	assert.Len(t, resp.Body, mcpMinPageSize)
+	assert.True(t, resp.Truncated)
+	assert.Equal(t, len(body), resp.TotalBytes)
+	assert.NotEmpty(t, resp.Continuation)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Polling bounds for rest_wait_operation. The interval comes from Retry-After
+// when the service sends one, clamped to these limits.
+const (
+	mcpDefaultOperationWait = 5 * time.Minute
+	mcpMaxOperationWait     = 10 * time.Minute
+	mcpDefaultPollInterval  = 5 * time.Second
+	mcpMinPollInterval      = 1 * time.Second
+	mcpMaxPollInterval      = time.Minute
+)
+
+// Terminal states of an ARM long-running operation.
+var terminalOperationStates = map[string]bool{
+	"succeeded": true,
+	"failed":    true,
+	"canceled":  true,
+	"cancelled": true,
+}
+
+// mcpOperationSleep waits between polls. It is a package variable so tests
+// do not wait in real time.
+var mcpOperationSleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// mcpOperationResult is the JSON structure returned by rest_wait_operation.
+type mcpOperationResult struct {
+	// Status is the operation status from the last poll, such as Succeeded,
+	// Failed, or InProgress.
+	Status string `json:"status"`
+	// Done is false when maxWaitSeconds elapsed before a terminal state.
+	Done           bool                  `json:"done"`
+	Polls          int                   `json:"polls"`
+	ElapsedSeconds float64               `json:"elapsedSeconds"`
+	Response       mcpStructuredResponse `json:"response"`
+}
+
+// operationState reads the state of a long-running operation from one poll.
+// An Azure-AsyncOperation URL returns a body with a status field, a resource
+// URL carries properties.provisioningState, and a Location URL returns 202
+// until the operation completes. Any error status is terminal.
+func operationState(resp *mcpResponse) (string, bool) {
+	if resp.StatusCode == http.StatusAccepted {
+		return "InProgress", false
+	}
+	if resp.StatusCode >= 400 {
+		return "Failed", true
+	}
+
+	var body struct {
+		Status     string `json:"status"`
+		Properties struct {
+			ProvisioningState string `json:"provisioningState"`
+		} `json:"properties"`
+	}
+	if resp.Body != "" && !resp.Truncated {
+		_ = json.Unmarshal([]byte(resp.Body), &body)
+	}
+	status := body.Status
+	if status == "" {
+		status = body.Properties.ProvisioningState
+	}
+	if status == "" {
+		// A Location URL answers with the final result once it stops
+		// returning 202.
+		return "Succeeded", true
+	}
+	return status, terminalOperationStates[strings.ToLower(status)]
+}
+
+// pollInterval returns the delay before the next poll from Retry-After.
+func pollInterval(headers http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(headers.Get("Retry-After"))); err == nil {
+		d := time.Duration(seconds) * time.Second
+		return min(max(d, mcpMinPollInterval), mcpMaxPollInterval)
+	}
+	return mcpDefaultPollInterval
+}
+
+// waitForOperation polls rawURL with GET until the operation reaches a
+// terminal state or maxWait elapses. Running out of time is not an error: the
+// result reports Done false so the caller can wait again.
+func waitForOperation(
+	ctx context.Context,
+	rawURL, scopeOverride string,
+	headers http.Header,
+	controls mcpRequestControls,
+	maxWait time.Duration,
+) (*mcpOperationResult, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	start := time.Now()
+	var result *mcpOperationResult
+	for polls := 1; ; polls++ {
+		resp, err := executeMCPRequest(waitCtx, "GET", rawURL, "", scopeOverride, headers, controls)
+		if err != nil {
+			if result != nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+				return result, nil
+			}
+			return nil, err
+		}
+		status, done := operationState(resp)
+		result = &mcpOperationResult{
+			Status:         status,
+			Done:           done,
+			Polls:          polls,
+			ElapsedSeconds: time.Since(start).Round(time.Millisecond).Seconds(),
+			Response:       structureResponse(resp),
+		}
+		if done {
+			return result, nil
+		}
+		if err := mcpOperationSleep(waitCtx, pollInterval(resp.Headers)); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return result, nil
+		}
+	}
+}
+
+func mcpWaitOperationToolOptions() []mcp.ToolOption {
+	opts := []mcp.ToolOption{
+		mcp.WithString("url", mcp.Required(), mcp.Description("The Azure-AsyncOperation or Location URL returned by the request that started the operation")),
+		mcp.WithInteger("maxWaitSeconds", mcp.Description("Maximum time to wait, from 1 to 600 seconds (default 300)")),
+		mcp.WithString("scope", mcp.Description("OAuth scope override (auto-detected if omitted)")),
+		mcp.WithObject("headers", mcp.Description(mcpHeadersDescription)),
+		mcp.WithOutputSchema[mcpOperationResult](),
+	}
+	return append(opts, mcpRequestControlToolOptions()...)
+}
+
+// handleWaitOperation polls an ARM long-running operation until it finishes,
+// so agents do not spend turns issuing repeated rest_get calls.
+func handleWaitOperation(ctx context.Context, args azdext.ToolArgs) (*mcp.CallToolResult, error) {
+	rawURL, err := args.RequireString("url")
+	if err != nil {
+		//nolint:nilerr // intentional: surface validation error as MCP tool result, not Go error
+		return azdext.MCPErrorResult("missing required argument: url"), nil
+	}
+	maxWait := mcpDefaultOperationWait
+	if args.Has("maxWaitSeconds") {
+		seconds, err := args.RequireInt("maxWaitSeconds")
+		if err != nil {
+			return azdext.MCPErrorResult("%s", err.Error()), nil
+		}
+		maxWait = time.Duration(seconds) * time.Second
+		if maxWait < time.Second || maxWait > mcpMaxOperationWait {
+			return azdext.MCPErrorResult("maxWaitSeconds must be between 1 and %d", int(mcpMaxOperationWait.Seconds())), nil
+		}
+	}
+	headers, err := parseHeaders(args)
+	if err != nil {
+		return azdext.MCPErrorResult("%s", err.Error()), nil
+	}
+	controls, err := parseMCPRequestControls(args)
+	if err != nil {
+		return azdext.MCPErrorResult("%s", err.Error()), nil
+	}
+
+	result, err := waitForOperation(ctx, rawURL, args.OptionalString("scope", ""), headers, controls, maxWait)
+	if err != nil {
+		return azdext.MCPErrorResult("failed to poll operation: %s", err.Error()), nil
+	}
+	return mcp.NewToolResultStructuredOnly(result), nil
+}
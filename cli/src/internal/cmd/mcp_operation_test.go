@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOperationSleep replaces the poll delay for one test and records the
+// requested intervals.
+func withOperationSleep(t *testing.T, fn func(context.Context, time.Duration) error) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	orig := mcpOperationSleep
+	mcpOperationSleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return fn(ctx, d)
+	}
+	t.Cleanup(func() { mcpOperationSleep = orig })
+	return &waits
+}
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestOperationState(t *testing.T) {
+	tests := []struct {
+		name   string
+		resp   mcpResponse
+		status string
+		done   bool
+	}{
+		{"location accepted", mcpResponse{StatusCode: 202}, "InProgress", false},
+		{"location done", mcpResponse{StatusCode: 200, Body: `{"id":"/x"}`}, "Succeeded", true},
+		{"location no content", mcpResponse{StatusCode: 204}, "Succeeded", true},
+		{"async running", mcpResponse{StatusCode: 200, Body: `{"status":"InProgress"}`}, "InProgress", false},
+		{"async succeeded", mcpResponse{StatusCode: 200, Body: `{"status":"Succeeded"}`}, "Succeeded", true},
+		{"async failed", mcpResponse{StatusCode: 200, Body: `{"status":"Failed","error":{"code":"X"}}`}, "Failed", true},
+		{"async canceled", mcpResponse{StatusCode: 200, Body: `{"status":"Canceled"}`}, "Canceled", true},
+		{"provisioning", mcpResponse{StatusCode: 200, Body: `{"properties":{"provisioningState":"Updating"}}`}, "Updating", false},
+		{"error status", mcpResponse{StatusCode: 404, Body: `{"error":{}}`}, "Failed", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, done := operationState(&tt.resp)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.done, done)
+		})
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	assert.Equal(t, mcpDefaultPollInterval, pollInterval(nil))
+	assert.Equal(t, 10*time.Second, pollInterval(http.Header{"Retry-After": {"10"}}))
+	assert.Equal(t, mcpMinPollInterval, pollInterval(http.Header{"Retry-After": {"0"}}))
+	assert.Equal(t, mcpMaxPollInterval, pollInterval(http.Header{"Retry-After": {"3600"}}))
+	assert.Equal(t, mcpDefaultPollInterval, pollInterval(http.Header{"Retry-After": {"Wed, 21 Oct 2026 07:28:00 GMT"}}))
+}
+
+// newOperationServer reports InProgress with Retry-After 2 for the first
+// pending polls, then Succeeded.
+func newOperationServer(t *testing.T, pending int) *httptest.Server {
+	t.Helper()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		w.Header().Set("Content-Type", "application/json")
+		if polls <= pending {
+			w.Header().Set("Retry-After", "2")
+			_, _ = w.Write([]byte(`{"status":"InProgress"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"Succeeded"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	t.Cleanup(resetSecurityPolicyForTest)
+	return srv
+}
+
+func TestWaitForOperation_Succeeds(t *testing.T) {
+	waits := withOperationSleep(t, noSleep)
+	srv := newOperationServer(t, 2)
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	result, err := waitForOperation(context.Background(), srv.URL, "", nil, controls, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Done)
+	assert.Equal(t, "Succeeded", result.Status)
+	assert.Equal(t, 3, result.Polls)
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, *waits)
+}
+
+func TestWaitForOperation_TimesOut(t *testing.T) {
+	withOperationSleep(t, func(context.Context, time.Duration) error { return context.DeadlineExceeded })
+	srv := newOperationServer(t, 10)
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	result, err := waitForOperation(context.Background(), srv.URL, "", nil, controls, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Done)
+	assert.Equal(t, "InProgress", result.Status)
+	assert.Equal(t, 1, result.Polls)
+}
+
+func TestHandleWaitOperation(t *testing.T) {
+	withOperationSleep(t, noSleep)
+	srv := newOperationServer(t, 1)
+
+	result, err := handleWaitOperation(context.Background(), newToolArgs(map[string]any{
+		"url":    srv.URL,
+		"noAuth": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+	op, ok := result.StructuredContent.(*mcpOperationResult)
+	require.True(t, ok)
+	assert.True(t, op.Done)
+	assert.Equal(t, 2, op.Polls)
+}
+
+func TestHandleWaitOperation_InvalidArgs(t *testing.T) {
+	tests := map[string]map[string]any{
+		"missing required argument: url": nil,
+		"maxWaitSeconds must be between": {"url": "https://management.azure.com/x", "maxWaitSeconds": 0},
+	}
+	for want, args := range tests {
+		result, err := handleWaitOperation(context.Background(), newToolArgs(args))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), want)
+	}
+}
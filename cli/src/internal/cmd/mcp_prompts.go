@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// API versions used in the MCP prompts.
+const (
+	subscriptionsAPIVersion  = "2022-12-01"
+	resourcesAPIVersion      = "2021-04-01"
+	armManagementURLTemplate = "https://management.azure.com/subscriptions/%s"
+)
+
+// mcpPrompt describes one prompt: render builds the user message from the
+// prompt arguments.
+type mcpPrompt struct {
+	name        string
+	description string
+	args        []mcp.PromptOption
+	render      func(args map[string]string) (string, error)
+}
+
+// mcpPrompts returns the prompts for common Azure workflows. Each pairs the
+// right URLs and api-versions with the rest_* tools.
+func mcpPrompts() []server.ServerPrompt {
+	subscriptionArg := mcp.WithArgument("subscriptionId",
+		mcp.ArgumentDescription("Subscription ID (defaults to the azd environment subscription from azd_context)"))
+	resourceGroupArg := mcp.WithArgument("resourceGroup",
+		mcp.RequiredArgument(), mcp.ArgumentDescription("Resource group name"))
+
+	prompts := []mcpPrompt{
+		{
+			name:        "list_subscriptions",
+			description: "List the Azure subscriptions the signed-in identity can access",
+			render:      listSubscriptionsPrompt,
+		},
+		{
+			name:        "inspect_resource_group",
+			description: "Summarize a resource group and the resources in it",
+			args:        []mcp.PromptOption{resourceGroupArg, subscriptionArg},
+			render:      inspectResourceGroupPrompt,
+		},
+		{
+			name:        "deployment_status",
+			description: "Check the status of an ARM deployment and explain any failure",
+			args: []mcp.PromptOption{
+				resourceGroupArg,
+				mcp.WithArgument("deploymentName",
+					mcp.ArgumentDescription("Deployment name (defaults to the most recent deployment)")),
+				subscriptionArg,
+			},
+			render: deploymentStatusPrompt,
+		},
+		{
+			name:        "graph_me",
+			description: "Show the Microsoft Graph profile of the signed-in user",
+			render:      graphMePrompt,
+		},
+	}
+
+	res := make([]server.ServerPrompt, 0, len(prompts))
+	for _, p := range prompts {
+		opts := append([]mcp.PromptOption{mcp.WithPromptDescription(p.description)}, p.args...)
+		res = append(res, server.ServerPrompt{
+			Prompt:  mcp.NewPrompt(p.name, opts...),
+			Handler: promptHandler(p.description, p.render),
+		})
+	}
+	return res
+}
+
+// promptHandler adapts a function that renders prompt text from its
+// arguments to a prompt handler returning one user message.
+func promptHandler(description string, render func(args map[string]string) (string, error)) server.PromptHandlerFunc {
+	return func(_ context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		text, err := render(req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
+
+// subscriptionStep tells the agent where the subscription ID comes from and
+// returns the ARM URL prefix to use.
+func subscriptionStep(args map[string]string) (string, string) {
+	if sub := strings.TrimSpace(args["subscriptionId"]); sub != "" {
+		return "", fmt.Sprintf(armManagementURLTemplate, sub)
+	}
+	return "First call azd_context and use its subscriptionId in place of {subscriptionId} below.\n",
+		fmt.Sprintf(armManagementURLTemplate, "{subscriptionId}")
+}
+
+// requiredPromptArg returns a trimmed required argument.
+func requiredPromptArg(args map[string]string, name string) (string, error) {
+	v := strings.TrimSpace(args[name])
+	if v == "" {
+		return "", fmt.Errorf("missing required argument: %s", name)
+	}
+	return v, nil
+}
+
+func listSubscriptionsPrompt(map[string]string) (string, error) {
+	return fmt.Sprintf(`List my Azure subscriptions.
+Call rest_get with url https://management.azure.com/subscriptions?api-version=%s and paginate set to true.
+Show a table of displayName, subscriptionId, and state, and mark the subscription azd_context reports as current.`,
+		subscriptionsAPIVersion), nil
+}
+
+func inspectResourceGroupPrompt(args map[string]string) (string, error) {
+	rg, err := requiredPromptArg(args, "resourceGroup")
+	if err != nil {
+		return "", err
+	}
+	step, base := subscriptionStep(args)
+	return fmt.Sprintf(`Inspect the Azure resource group %[1]s.
+%[2]s1. Call rest_get with url %[3]s/resourceGroups/%[1]s?api-version=%[4]s for its location, tags, and provisioningState.
+2. Call rest_get with url %[3]s/resourceGroups/%[1]s/resources?api-version=%[4]s and paginate set to true.
+Summarize the resources grouped by type, and call out any resource whose provisioningState is not Succeeded.`,
+		rg, step, base, resourcesAPIVersion), nil
+}
+
+func deploymentStatusPrompt(args map[string]string) (string, error) {
+	rg, err := requiredPromptArg(args, "resourceGroup")
+	if err != nil {
+		return "", err
+	}
+	step, base := subscriptionStep(args)
+	deployments := fmt.Sprintf("%s/resourceGroups/%s/providers/Microsoft.Resources/deployments", base, rg)
+
+	find := fmt.Sprintf("1. Call rest_get with url %s?api-version=%s and paginate set to true, and pick the deployment with the latest properties.timestamp.", deployments, resourcesAPIVersion)
+	name := "{deploymentName}"
+	if n := strings.TrimSpace(args["deploymentName"]); n != "" {
+		find = fmt.Sprintf("1. Call rest_get with url %s/%s?api-version=%s.", deployments, n, resourcesAPIVersion)
+		name = n
+	}
+	return fmt.Sprintf(`Check the status of an ARM deployment in resource group %s.
+%s%s
+Report its provisioningState, timestamp, and duration.
+2. If it failed, call rest_get with url %s/%s/operations?api-version=%s and paginate set to true,
+and explain each operation whose provisioningState is Failed using its statusMessage.`,
+		rg, step, find, deployments, name, resourcesAPIVersion), nil
+}
+
+func graphMePrompt(map[string]string) (string, error) {
+	return `Show my Microsoft Graph profile.
+Call rest_get with url https://graph.microsoft.com/v1.0/me and summarize displayName, userPrincipalName, mail, and jobTitle.`, nil
+}
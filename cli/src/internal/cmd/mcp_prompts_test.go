@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getPrompt renders a registered prompt and returns its message text.
+func getPrompt(t *testing.T, name string, args map[string]string) (string, error) {
+	t.Helper()
+	prompt, ok := newMCPServer(mcpServerOptions{}).ListPrompts()[name]
+	require.True(t, ok, "prompt %q should be registered", name)
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := prompt.Handler(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	require.Len(t, res.Messages, 1)
+	text, ok := res.Messages[0].Content.(mcp.TextContent)
+	require.True(t, ok)
+	return text.Text, nil
+}
+
+func TestNewMCPServer_RegistersPrompts(t *testing.T) {
+	prompts := newMCPServer(mcpServerOptions{ReadOnly: true}).ListPrompts()
+	for _, name := range []string{"list_subscriptions", "inspect_resource_group", "deployment_status", "graph_me"} {
+		p, ok := prompts[name]
+		require.True(t, ok, "prompt %q should be registered", name)
+		assert.NotEmpty(t, p.Prompt.Description)
+	}
+}
+
+func TestPrompt_ListSubscriptions(t *testing.T) {
+	text, err := getPrompt(t, "list_subscriptions", nil)
+	require.NoError(t, err)
+	assert.Contains(t, text, "https://management.azure.com/subscriptions?api-version="+subscriptionsAPIVersion)
+}
+
+func TestPrompt_InspectResourceGroup(t *testing.T) {
+	text, err := getPrompt(t, "inspect_resource_group", map[string]string{"resourceGroup": "rg1", "subscriptionId": "sub1"})
+	require.NoError(t, err)
+	assert.Contains(t, text, "https://management.azure.com/subscriptions/sub1/resourceGroups/rg1/resources?api-version="+resourcesAPIVersion)
+	assert.NotContains(t, text, "azd_context")
+
+	// Without a subscription, the agent is pointed at azd_context.
+	text, err = getPrompt(t, "inspect_resource_group", map[string]string{"resourceGroup": "rg1"})
+	require.NoError(t, err)
+	assert.Contains(t, text, "call azd_context")
+	assert.Contains(t, text, "/subscriptions/{subscriptionId}/resourceGroups/rg1?")
+
+	_, err = getPrompt(t, "inspect_resource_group", nil)
+	assert.ErrorContains(t, err, "missing required argument: resourceGroup")
+}
+
+func TestPrompt_DeploymentStatus(t *testing.T) {
+	text, err := getPrompt(t, "deployment_status", map[string]string{
+		"resourceGroup": "rg1", "deploymentName": "main", "subscriptionId": "sub1",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, text, "/resourceGroups/rg1/providers/Microsoft.Resources/deployments/main?api-version=")
+	assert.Contains(t, text, "/deployments/main/operations?api-version=")
+
+	text, err = getPrompt(t, "deployment_status", map[string]string{"resourceGroup": "rg1", "subscriptionId": "sub1"})
+	require.NoError(t, err)
+	assert.Contains(t, text, "latest properties.timestamp")
+}
+
+func TestPrompt_GraphMe(t *testing.T) {
+	text, err := getPrompt(t, "graph_me", nil)
+	require.NoError(t, err)
+	assert.Contains(t, text, "https://graph.microsoft.com/v1.0/me")
+}
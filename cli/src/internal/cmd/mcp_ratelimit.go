@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/time/rate"
+)
+
+// mcpRateLimit is a token bucket: Burst calls at once, refilled at PerSecond.
+type mcpRateLimit struct {
+	Burst     int
+	PerSecond float64
+}
+
+// MCP rate limits. Each tool has its own bucket, so a burst of calls to one
+// tool does not starve the others, and writes refill more slowly than reads.
+// Request tools also draw from a bucket per destination host.
+var (
+	mcpReadRateLimit  = mcpRateLimit{Burst: 10, PerSecond: 1}
+	mcpWriteRateLimit = mcpRateLimit{Burst: 3, PerSecond: 0.2}
+	mcpHostRateLimit  = mcpRateLimit{Burst: 10, PerSecond: 1}
+)
+
+// mcpMaxHostLimiters bounds the number of per-host buckets kept in memory.
+const mcpMaxHostLimiters = 256
+
+// rateLimitKey names a bucket and the limit it is created with.
+type rateLimitKey struct {
+	name  string
+	limit mcpRateLimit
+}
+
+// keyedRateLimiter holds one token bucket per key.
+type keyedRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newKeyedRateLimiter() *keyedRateLimiter {
+	return &keyedRateLimiter{limiters: make(map[string]*rate.Limiter)}
+}
+
+// allow takes one token from every key's bucket, or from none of them. It
+// returns the name of the first exhausted bucket, or "" when the call may
+// proceed.
+func (k *keyedRateLimiter) allow(keys ...rateLimitKey) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	taken := make([]*rate.Reservation, 0, len(keys))
+	for _, key := range keys {
+		r := k.limiterLocked(key, now).ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			r.CancelAt(now)
+			for _, prev := range taken {
+				prev.CancelAt(now)
+			}
+			return key.name
+		}
+		taken = append(taken, r)
+	}
+	return ""
+}
+
+// limiterLocked returns the bucket for key, creating it if needed. When the
+// map is full, buckets that have refilled completely are dropped first; they
+// carry no state a new bucket would not. The caller must hold k.mu.
+func (k *keyedRateLimiter) limiterLocked(key rateLimitKey, now time.Time) *rate.Limiter {
+	if lim, ok := k.limiters[key.name]; ok {
+		return lim
+	}
+	if len(k.limiters) >= mcpMaxHostLimiters {
+		for name, lim := range k.limiters {
+			if lim.TokensAt(now) >= float64(lim.Burst()) {
+				delete(k.limiters, name)
+			}
+		}
+	}
+	lim := rate.NewLimiter(rate.Limit(key.limit.PerSecond), key.limit.Burst)
+	k.limiters[key.name] = lim
+	return lim
+}
+
+// rateLimited wraps handler so each call takes a token from the tool's bucket.
+func rateLimited(limiter *keyedRateLimiter, tool string, limit mcpRateLimit, handler azdext.MCPToolHandler) azdext.MCPToolHandler {
+	return limitedHandler(limiter, tool, limit, false, handler)
+}
+
+// requestRateLimited wraps a request tool's handler so each call also takes a
+// token from the bucket of the url argument's host.
+func requestRateLimited(limiter *keyedRateLimiter, tool string, limit mcpRateLimit, handler azdext.MCPToolHandler) azdext.MCPToolHandler {
+	return limitedHandler(limiter, tool, limit, true, handler)
+}
+
+func limitedHandler(limiter *keyedRateLimiter, tool string, limit mcpRateLimit, perHost bool, handler azdext.MCPToolHandler) azdext.MCPToolHandler {
+	return func(ctx context.Context, args azdext.ToolArgs) (*mcp.CallToolResult, error) {
+		keys := []rateLimitKey{{name: "tool " + tool, limit: limit}}
+		if perHost {
+			if parsed, err := url.Parse(args.OptionalString("url", "")); err == nil && parsed.Hostname() != "" {
+				keys = append(keys, rateLimitKey{name: "host " + strings.ToLower(parsed.Hostname()), limit: mcpHostRateLimit})
+			}
+		}
+		if exhausted := limiter.allow(keys...); exhausted != "" {
+			return azdext.MCPErrorResult("rate limit exceeded for %s, please retry", exhausted), nil
+		}
+		return handler(ctx, args)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowLimit allows burst calls and effectively never refills during a test.
+func slowLimit(burst int) mcpRateLimit {
+	return mcpRateLimit{Burst: burst, PerSecond: 0.001}
+}
+
+func okHandler(context.Context, azdext.ToolArgs) (*mcp.CallToolResult, error) {
+	return azdext.MCPTextResult("ok"), nil
+}
+
+func callLimited(t *testing.T, handler azdext.MCPToolHandler, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	result, err := handler(context.Background(), newToolArgs(args))
+	require.NoError(t, err)
+	return result
+}
+
+func TestKeyedRateLimiter_KeysAreIndependent(t *testing.T) {
+	limiter := newKeyedRateLimiter()
+	a := rateLimitKey{name: "tool a", limit: slowLimit(1)}
+	b := rateLimitKey{name: "tool b", limit: slowLimit(1)}
+
+	assert.Empty(t, limiter.allow(a))
+	assert.Equal(t, "tool a", limiter.allow(a))
+	assert.Empty(t, limiter.allow(b))
+}
+
+func TestKeyedRateLimiter_AllOrNothing(t *testing.T) {
+	limiter := newKeyedRateLimiter()
+	tool := rateLimitKey{name: "tool a", limit: slowLimit(2)}
+	host := rateLimitKey{name: "host example.com", limit: slowLimit(1)}
+
+	assert.Empty(t, limiter.allow(tool, host))
+	assert.Equal(t, "host example.com", limiter.allow(tool, host))
+
+	// The denied call returned its tool token, so one is still left.
+	assert.Empty(t, limiter.allow(tool))
+	assert.Equal(t, "tool a", limiter.allow(tool))
+}
+
+func TestKeyedRateLimiter_DropsIdleBucketsWhenFull(t *testing.T) {
+	limiter := newKeyedRateLimiter()
+	busy := rateLimitKey{name: "host busy", limit: slowLimit(1)}
+	assert.Empty(t, limiter.allow(busy))
+
+	// Fill the map with buckets that were created but never drawn from.
+	limiter.mu.Lock()
+	for i := len(limiter.limiters); i < mcpMaxHostLimiters; i++ {
+		limiter.limiterLocked(rateLimitKey{name: fmt.Sprintf("host h%d", i), limit: slowLimit(1)}, time.Now())
+	}
+	limiter.mu.Unlock()
+
+	assert.Empty(t, limiter.allow(rateLimitKey{name: "host new", limit: slowLimit(1)}))
+
+	// Full buckets were dropped; the exhausted one kept its state.
+	limiter.mu.Lock()
+	assert.Len(t, limiter.limiters, 2)
+	limiter.mu.Unlock()
+	assert.Equal(t, "host busy", limiter.allow(busy))
+}
+
+func TestRequestRateLimited_PerHost(t *testing.T) {
+	orig := mcpHostRateLimit
+	mcpHostRateLimit = slowLimit(1)
+	t.Cleanup(func() { mcpHostRateLimit = orig })
+
+	limiter := newKeyedRateLimiter()
+	get := requestRateLimited(limiter, "rest_get", slowLimit(10), okHandler)
+
+	assert.False(t, callLimited(t, get, map[string]any{"url": "https://a.example.com/x"}).IsError)
+	result := callLimited(t, get, map[string]any{"url": "https://A.example.com/y"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "host a.example.com")
+
+	// A different host has its own bucket.
+	assert.False(t, callLimited(t, get, map[string]any{"url": "https://b.example.com/"}).IsError)
+}
+
+func TestRateLimited_WritesDoNotStarveReads(t *testing.T) {
+	limiter := newKeyedRateLimiter()
+	del := requestRateLimited(limiter, "rest_delete", slowLimit(1), okHandler)
+	get := requestRateLimited(limiter, "rest_get", slowLimit(10), okHandler)
+	ctxTool := rateLimited(limiter, "azd_context", slowLimit(1), okHandler)
+
+	assert.False(t, callLimited(t, del, map[string]any{"url": "https://a.example.com/1"}).IsError)
+	result := callLimited(t, del, map[string]any{"url": "https://b.example.com/2"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "tool rest_delete")
+
+	assert.False(t, callLimited(t, get, map[string]any{"url": "https://c.example.com/"}).IsError)
+	assert.False(t, callLimited(t, ctxTool, nil).IsError)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
 	"github.com/jongio/azd-core/auth"
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,8 +37,8 @@ func TestParseHeaders_Valid(t *testing.T) {
 	headers, err := parseHeaders(args)
 
 	require.NoError(t, err)
-	assert.Equal(t, "application/json", headers["Content-Type"])
-	assert.Equal(t, "value", headers["X-Custom"])
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "value", headers.Get("X-Custom"))
 }
 
 func TestParseMCPRequestControls_Defaults(t *testing.T) {
@@ -47,6 +48,11 @@ func TestParseMCPRequestControls_Defaults(t *testing.T) {
 	assert.Equal(t, mcpDefaultTimeout, controls.Timeout)
 	assert.Equal(t, mcpDefaultRetry, controls.Retry)
 	assert.Equal(t, int64(mcpMaxResponseSize), controls.MaxResponseSize)
+	assert.Equal(t, mcpDefaultPageSize, controls.PageSize)
+	assert.Equal(t, mcpDefaultBinarySize, controls.MaxBinarySize)
+	assert.False(t, controls.Paginate)
+	assert.Equal(t, mcpDefaultMaxPages, controls.MaxPages)
+	assert.Zero(t, controls.MaxItems)
 	assert.False(t, controls.NoAuth)
 }
 
@@ -55,14 +61,22 @@ func TestParseMCPRequestControls_CustomValues(t *testing.T) {
 		"timeoutSeconds":       120,
 		"retry":                1,
 		"maxResponseSizeBytes": 1024,
+		"pageSizeBytes":        4096,
 		"noAuth":               true,
+		"paginate":             true,
+		"maxPages":             5,
+		"maxItems":             50,
 	}))
 
 	require.NoError(t, err)
 	assert.Equal(t, 120, int(controls.Timeout.Seconds()))
 	assert.Equal(t, 1, controls.Retry)
 	assert.Equal(t, int64(1024), controls.MaxResponseSize)
+	assert.Equal(t, 4096, controls.PageSize)
 	assert.True(t, controls.NoAuth)
+	assert.True(t, controls.Paginate)
+	assert.Equal(t, 5, controls.MaxPages)
+	assert.Equal(t, 50, controls.MaxItems)
 }
 
 func TestParseMCPRequestControls_ValidatesRanges(t *testing.T) {
@@ -73,6 +87,14 @@ func TestParseMCPRequestControls_ValidatesRanges(t *testing.T) {
 		{"retry": mcpMaxRetry + 1},
 		{"maxResponseSizeBytes": 0},
 		{"maxResponseSizeBytes": mcpMaxResponseSizeCap + 1},
+		{"pageSizeBytes": mcpMinPageSize - 1},
+		{"pageSizeBytes": mcpMaxResponseSize + 1},
+		{"maxBinaryBytes": 0},
+		{"maxBinaryBytes": mcpMaxResponseSize + 1},
+		{"maxPages": 0},
+		{"maxPages": mcpMaxPages + 1},
+		{"maxItems": 0},
+		{"maxItems": mcpMaxItems + 1},
 	}
 
 	for _, args := range tests {
@@ -96,11 +118,18 @@ func TestParseHeaders_BlockedHeaders(t *testing.T) {
 	}
 }
 
+// mcpUtilityTools are MCP tools that make no HTTP request, so they take no url
+// or request controls.
+var mcpUtilityTools = map[string]bool{"azd_context": true, "rest_detect_scope": true, "rest_get_more": true}
+
 func TestNewMCPServer_ToolsExposeRequestControls(t *testing.T) {
-	s := newMCPServer(false)
+	s := newMCPServer(mcpServerOptions{})
 	tools := s.ListTools()
 
 	for name, tool := range tools {
+		if mcpUtilityTools[name] {
+			continue
+		}
 		props := tool.Tool.InputSchema.Properties
 		require.NotNil(t, props, "tool %q should have properties", name)
 		for _, prop := range []string{"timeoutSeconds", "retry", "maxResponseSizeBytes", "noAuth"} {
@@ -130,7 +159,7 @@ func TestValidateScopeURLMatch(t *testing.T) {
 func TestFormatResponse(t *testing.T) {
 	resp := &mcpResponse{
 		StatusCode: 200,
-		Headers:    map[string]string{"Content-Type": "application/json"},
+		Headers:    http.Header{"Content-Type": {"application/json"}},
 		Body:       `{"id":1}`,
 	}
 
@@ -140,7 +169,7 @@ func TestFormatResponse(t *testing.T) {
 	err := json.Unmarshal([]byte(out), &parsed)
 	require.NoError(t, err)
 	assert.Equal(t, 200, parsed.StatusCode)
-	assert.Equal(t, "application/json", parsed.Headers["Content-Type"])
+	assert.Equal(t, "application/json", parsed.Headers.Get("Content-Type"))
 	assert.Equal(t, `{"id":1}`, parsed.Body)
 }
 
@@ -334,6 +363,17 @@ func TestParseHeaders_NoHeadersArgument(t *testing.T) {
 	assert.Empty(t, headers)
 }
 
+func TestParseHeaders_RepeatedValues(t *testing.T) {
+	args := newToolArgs(map[string]any{
+		"headers": map[string]any{
+			"Accept": []any{"application/json", "text/plain", 1},
+		},
+	})
+	headers, err := parseHeaders(args)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/json", "text/plain"}, headers.Values("Accept"))
+}
+
 func TestParseHeaders_NonStringValues(t *testing.T) {
 	args := newToolArgs(map[string]any{
 		"headers": map[string]any{
@@ -345,7 +385,7 @@ func TestParseHeaders_NonStringValues(t *testing.T) {
 	})
 	headers, err := parseHeaders(args)
 	require.NoError(t, err)
-	assert.Equal(t, "value", headers["X-Valid"])
+	assert.Equal(t, "value", headers.Get("X-Valid"))
 	assert.Len(t, headers, 1, "only string-typed header values should be included")
 }
 
@@ -386,7 +426,7 @@ func TestParseHeaders_BlockedHeaderCaseInsensitive(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestFormatResponse_EmptyBody(t *testing.T) {
-	resp := &mcpResponse{StatusCode: 204, Headers: map[string]string{}}
+	resp := &mcpResponse{StatusCode: 204, Headers: http.Header{}}
 	out := formatResponse(resp)
 	var parsed mcpResponse
 	require.NoError(t, json.Unmarshal([]byte(out), &parsed))
@@ -440,7 +480,7 @@ func TestExecuteMCPRequest_CustomHeaders(t *testing.T) {
 	// Test that custom headers are passed through.
 	// May succeed or fail depending on auth state — we only verify no panic.
 	_, _ = executeMCPRequest(context.Background(), "POST",
-		"https://management.azure.com/test", `{"key":"val"}`, "", map[string]string{"X-Custom": "value"})
+		"https://management.azure.com/test", `{"key":"val"}`, "", http.Header{"X-Custom": {"value"}})
 }
 
 func TestExecuteMCPRequest_WithBody(t *testing.T) {
@@ -560,12 +600,13 @@ func TestHandleHead_BlockedHeader(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestNewMCPServer_RegistersAllTools(t *testing.T) {
-	s := newMCPServer(false)
+	s := newMCPServer(mcpServerOptions{})
 	tools := s.ListTools()
 
 	expectedTools := []string{
 		"rest_get", "rest_post", "rest_put",
 		"rest_patch", "rest_delete", "rest_head",
+		"rest_wait_operation", "rest_get_more", "rest_detect_scope", "azd_context",
 	}
 
 	assert.Len(t, tools, len(expectedTools))
@@ -576,11 +617,11 @@ func TestNewMCPServer_RegistersAllTools(t *testing.T) {
 }
 
 func TestNewMCPServer_ReadOnly_OnlyReadTools(t *testing.T) {
-	s := newMCPServer(true)
+	s := newMCPServer(mcpServerOptions{ReadOnly: true})
 	tools := s.ListTools()
 
 	// Only the read-only tools are present.
-	expectedTools := []string{"rest_get", "rest_head"}
+	expectedTools := []string{"rest_get", "rest_head", "rest_wait_operation", "rest_get_more", "rest_detect_scope", "azd_context"}
 	assert.Len(t, tools, len(expectedTools))
 	for _, name := range expectedTools {
 		_, exists := tools[name]
@@ -595,7 +636,7 @@ func TestNewMCPServer_ReadOnly_OnlyReadTools(t *testing.T) {
 }
 
 func TestNewMCPServer_ToolsHaveDescriptions(t *testing.T) {
-	s := newMCPServer(false)
+	s := newMCPServer(mcpServerOptions{})
 	tools := s.ListTools()
 
 	for name, tool := range tools {
@@ -605,10 +646,13 @@ func TestNewMCPServer_ToolsHaveDescriptions(t *testing.T) {
 }
 
 func TestNewMCPServer_ToolsRequireURL(t *testing.T) {
-	s := newMCPServer(false)
+	s := newMCPServer(mcpServerOptions{})
 	tools := s.ListTools()
 
 	for name, tool := range tools {
+		if mcpUtilityTools[name] {
+			continue
+		}
 		props := tool.Tool.InputSchema.Properties
 		require.NotNil(t, props, "tool %q should have properties", name)
 		_, hasURL := props["url"]
@@ -679,7 +723,7 @@ func TestExecuteMCPRequest_SuccessPath(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Contains(t, resp.Body, `"result":"ok"`)
-	assert.Equal(t, "application/json", resp.Headers["Content-Type"])
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
 }
 
 func TestExecuteMCPRequest_PostWithBody(t *testing.T) {
@@ -792,11 +836,233 @@ func TestExecuteMCPRequest_RedactsSensitiveResponseHeaders(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
 	// Set-Cookie is sensitive — should be redacted (not the raw value).
-	cookie := resp.Headers["Set-Cookie"]
+	cookie := resp.Headers.Get("Set-Cookie")
 	assert.NotEqual(t, "session=super-secret-value-1234567890", cookie, "Set-Cookie should be redacted")
 	assert.NotEmpty(t, cookie, "Set-Cookie should still be present (redacted)")
 
 	// Non-sensitive headers should pass through unchanged.
-	assert.Equal(t, "application/json", resp.Headers["Content-Type"])
-	assert.Equal(t, "req-abc-123", resp.Headers["X-Request-Id"])
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, "req-abc-123", resp.Headers.Get("X-Request-Id"))
+}
+
+func TestExecuteMCPRequest_KeepsRepeatedResponseHeaders(t *testing.T) {
+	var gotAccept []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAccept = r.Header.Values("Accept")
+		w.Header().Add("Link", "</page/2>; rel=\"next\"")
+		w.Header().Add("Link", "</page/9>; rel=\"last\"")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	resp, err := executeMCPRequest(context.Background(), "GET", srv.URL+"/api/test", "", "",
+		http.Header{"Accept": {"application/json", "text/plain"}}, controls)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/json", "text/plain"}, gotAccept)
+	assert.Equal(t, []string{"</page/2>; rel=\"next\"", "</page/9>; rel=\"last\""}, resp.Headers.Values("Link"))
+}
+
+// ---------------------------------------------------------------------------
+// azd_context tool
+// ---------------------------------------------------------------------------
+
+func TestHandleAzdContext(t *testing.T) {
+	orig := azdContextLoader
+	azdContextLoader = func(context.Context) (azdcontext.Context, error) { return testAzdContext, nil }
+	t.Cleanup(func() { azdContextLoader = orig })
+
+	result, err := handleAzdContext(context.Background(), newToolArgs(nil))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var got azdcontext.Context
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &got))
+	assert.Equal(t, testAzdContext, got)
+}
+
+func TestHandleAzdContext_Error(t *testing.T) {
+	orig := azdContextLoader
+	azdContextLoader = func(context.Context) (azdcontext.Context, error) {
+		return azdcontext.Context{}, azdcontext.ErrNotExtension
+	}
+	t.Cleanup(func() { azdContextLoader = orig })
+
+	result, err := handleAzdContext(context.Background(), newToolArgs(nil))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "failed to resolve azd context")
+}
+
+// ---------------------------------------------------------------------------
+// rest_detect_scope tool
+// ---------------------------------------------------------------------------
+
+func TestDetectMCPScope(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		scope         string
+		azure         bool
+		authenticated bool
+		note          string
+	}{
+		{"arm", "https://management.azure.com/subscriptions", "https://management.azure.com/.default", true, true, ""},
+		{"graph", "https://graph.microsoft.com/v1.0/me", "https://graph.microsoft.com/.default", true, true, ""},
+		{"non-azure", "https://api.github.com/user", "", false, false, "not a known Azure host"},
+		{"plain http", "http://localhost:8080/api", "", false, false, "plain HTTP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := detectMCPScope(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.url, res.URL)
+			assert.Equal(t, tt.scope, res.Scope)
+			assert.Equal(t, tt.azure, res.IsAzureHost)
+			assert.Equal(t, tt.authenticated, res.Authenticated)
+			if tt.note == "" {
+				assert.Empty(t, res.Note)
+			} else {
+				assert.Contains(t, res.Note, tt.note)
+			}
+		})
+	}
+}
+
+func TestHandleDetectScope(t *testing.T) {
+	result, err := handleDetectScope(context.Background(), newToolArgs(map[string]any{
+		"url": "https://management.azure.com/subscriptions",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var got mcpScopeResult
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &got))
+	assert.Equal(t, "https://management.azure.com/.default", got.Scope)
+	assert.True(t, got.IsAzureHost)
+	assert.True(t, got.Authenticated)
+}
+
+func TestHandleDetectScope_MissingURL(t *testing.T) {
+	result, err := handleDetectScope(context.Background(), newToolArgs(nil))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "missing required argument: url")
+}
+
+// ---------------------------------------------------------------------------
+// Structured tool results
+// ---------------------------------------------------------------------------
+
+func TestStructureResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		resp   mcpResponse
+		body   any
+		format string
+	}{
+		{"json", mcpResponse{StatusCode: 200, Body: `{"id":1}`}, json.RawMessage(`{"id":1}`), mcpBodyFormatJSON},
+		{"text", mcpResponse{StatusCode: 200, Body: "hello"}, "hello", mcpBodyFormatText},
+		{"truncated json", mcpResponse{StatusCode: 200, Body: `{"id"`, Truncated: true}, `{"id"`, mcpBodyFormatText},
+		{"empty", mcpResponse{StatusCode: 204}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := structureResponse(&tt.resp)
+			assert.Equal(t, tt.resp.StatusCode, got.StatusCode)
+			assert.Equal(t, tt.body, got.Body)
+			assert.Equal(t, tt.format, got.BodyFormat)
+		})
+	}
+}
+
+func TestNewMCPServer_RequestToolsDeclareOutputSchema(t *testing.T) {
+	for name, tool := range newMCPServer(mcpServerOptions{}).ListTools() {
+		if mcpUtilityTools[name] {
+			continue
+		}
+		assert.Equal(t, "object", tool.Tool.OutputSchema.Type, "tool %q should declare an output schema", name)
+	}
+}
+
+func TestHandleNoBodyMethod_StructuredResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":[{"name":"rg1"}]}`))
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	result, err := handleNoBodyMethod("GET")(context.Background(), newToolArgs(map[string]any{
+		"url":    srv.URL,
+		"noAuth": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+
+	structured, ok := result.StructuredContent.(mcpStructuredResponse)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusOK, structured.StatusCode)
+	assert.Equal(t, mcpBodyFormatJSON, structured.BodyFormat)
+
+	// The structured content serializes the body as JSON, not as a string.
+	raw, err := json.Marshal(result.StructuredContent)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"body":{"value":[{"name":"rg1"}]}`)
+
+	// The text fallback keeps the original format.
+	var text mcpResponse
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &text))
+	assert.Equal(t, `{"value":[{"name":"rg1"}]}`, text.Body)
+}
+
+// ---------------------------------------------------------------------------
+// rest_get pagination
+// ---------------------------------------------------------------------------
+
+func TestNewMCPServer_OnlyGetTakesPagination(t *testing.T) {
+	tools := newMCPServer(mcpServerOptions{}).ListTools()
+	for _, prop := range []string{"paginate", "maxPages", "maxItems"} {
+		_, ok := tools["rest_get"].Tool.InputSchema.Properties[prop]
+		assert.True(t, ok, "rest_get should expose %s", prop)
+		_, ok = tools["rest_delete"].Tool.InputSchema.Properties[prop]
+		assert.False(t, ok, "rest_delete should not expose %s", prop)
+	}
+}
+
+func TestExecuteMCPRequest_Paginate(t *testing.T) {
+	requests := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := map[string]any{"value": []int{requests}}
+		if requests < 5 {
+			page["nextLink"] = srv.URL + "/next"
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	setSecurityPolicyForTest(azdext.NewMCPSecurityPolicy())
+	defer resetSecurityPolicyForTest()
+
+	controls := defaultMCPRequestControls()
+	controls.NoAuth = true
+	controls.Paginate = true
+	controls.MaxPages = 3
+	resp, err := executeMCPRequest(context.Background(), "GET", srv.URL, "", "", nil, controls)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value":[1,2,3]}`, resp.Body)
+
+	// Pagination never applies to other methods.
+	requests = 0
+	_, err = executeMCPRequest(context.Background(), "DELETE", srv.URL, "", "", nil, controls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
 }
@@ -1,2 +1,132 @@
-// metadata command is now provided by azdext.NewMetadataCommand() in root.go.
 package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/azure/azure-dev/cli/azd/pkg/extensions"
+	"github.com/jongio/azd-rest/src/internal/version"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// metadataSchemaVersion is the azd extension metadata schema version.
+const metadataSchemaVersion = "1.0"
+
+// extensionMetadata is the output of the metadata command: the azd command
+// metadata plus the tools the MCP server registers, so the docs site and azd
+// can describe agent capabilities as well as CLI commands.
+type extensionMetadata struct {
+	*extensions.ExtensionCommandMetadata
+	MCPTools []mcp.Tool `json:"mcpTools"`
+}
+
+// NewMetadataCommand returns the hidden metadata command. It replaces
+// azdext.NewMetadataCommand so the output can include the MCP tools.
+func NewMetadataCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    "metadata",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			data, err := json.MarshalIndent(buildExtensionMetadata(NewRootCmd()), "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+}
+
+// buildExtensionMetadata describes root and the MCP server's tools. Commands
+// and flags are read from the live command tree, so the metadata cannot drift
+// from root.go. Tools are taken from a server with default options, which
+// registers every tool, and are sorted by name for stable output.
+func buildExtensionMetadata(root *cobra.Command) extensionMetadata {
+	registered := newMCPServer(mcpServerOptions{}).ListTools()
+	tools := make([]mcp.Tool, 0, len(registered))
+	for _, t := range registered {
+		tools = append(tools, t.Tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	commands := azdext.GenerateExtensionMetadata(metadataSchemaVersion, version.Info.ExtensionID, root)
+	annotateCommands(commands.Commands, root)
+	return extensionMetadata{
+		ExtensionCommandMetadata: commands,
+		MCPTools:                 tools,
+	}
+}
+
+// annotateCommands fills in what azdext.GenerateExtensionMetadata leaves out:
+// positional arguments, parsed from each command's Use line, and required
+// flags.
+func annotateCommands(commands []extensions.Command, parent *cobra.Command) {
+	for i := range commands {
+		cmd := findSubcommand(parent, commands[i].Name[len(commands[i].Name)-1])
+		if cmd == nil {
+			continue
+		}
+		commands[i].Args = usageArgs(cmd.Use)
+		for j := range commands[i].Flags {
+			if f := cmd.Flags().Lookup(commands[i].Flags[j].Name); f != nil && isRequiredFlag(f) {
+				commands[i].Flags[j].Required = true
+			}
+		}
+		annotateCommands(commands[i].Subcommands, cmd)
+	}
+}
+
+// findSubcommand returns the direct child of parent called name.
+func findSubcommand(parent *cobra.Command, name string) *cobra.Command {
+	for _, c := range parent.Commands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// usageArgs parses the positional arguments from a Use line such as
+// "add <resourceId> <key=value>...": <name> is required, [name] is optional,
+// and a trailing ... accepts several values. Flags named in the line, with
+// their values, are skipped.
+func usageArgs(use string) []extensions.Argument {
+	var args []extensions.Argument
+	fields := strings.Fields(use)
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		if strings.HasPrefix(field, "-") {
+			if i+1 < len(fields) && strings.HasPrefix(fields[i+1], "<") {
+				i++
+			}
+			continue
+		}
+		variadic := strings.HasSuffix(field, "...")
+		field = strings.TrimSuffix(field, "...")
+		arg := extensions.Argument{Variadic: variadic}
+		switch {
+		case strings.HasPrefix(field, "<") && strings.HasSuffix(field, ">"):
+			arg.Name, arg.Required = strings.Trim(field, "<>"), true
+		case strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]"):
+			arg.Name = strings.Trim(field, "[]")
+		default:
+			continue
+		}
+		if arg.Name == "flags" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// isRequiredFlag reports whether f was marked with MarkFlagRequired.
+func isRequiredFlag(f *pflag.Flag) bool {
+	required := f.Annotations[cobra.BashCompOneRequiredFlag]
+	return len(required) == 1 && required[0] == "true"
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/extensions"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCommand_IncludesMCPTools(t *testing.T) {
+	resetGlobalFlags()
+	cmd := NewMetadataCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	require.NoError(t, cmd.Execute())
+
+	var metadata struct {
+		ID       string            `json:"id"`
+		Commands []json.RawMessage `json:"commands"`
+		MCPTools []struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			InputSchema map[string]any `json:"inputSchema"`
+		} `json:"mcpTools"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &metadata))
+	assert.Equal(t, "jongio.azd.rest", metadata.ID)
+	assert.NotEmpty(t, metadata.Commands)
+
+	registered := newMCPServer(mcpServerOptions{}).ListTools()
+	require.Len(t, metadata.MCPTools, len(registered))
+
+	names := make([]string, 0, len(metadata.MCPTools))
+	for _, tool := range metadata.MCPTools {
+		names = append(names, tool.Name)
+		require.Contains(t, registered, tool.Name)
+		assert.NotEmpty(t, tool.Description, tool.Name)
+		assert.Equal(t, "object", tool.InputSchema["type"], tool.Name)
+	}
+	assert.True(t, sort.StringsAreSorted(names), "tools are sorted by name")
+	assert.Contains(t, names, "rest_get")
+}
+
+// TestMetadata_MatchesCommandTree walks the real command tree and checks that
+// every command, flag, default, and required marker appears in the metadata,
+// so the metadata cannot drift from root.go.
+func TestMetadata_MatchesCommandTree(t *testing.T) {
+	resetGlobalFlags()
+	root := NewRootCmd()
+	metadata := buildExtensionMetadata(root)
+
+	var walk func(parent *cobra.Command, commands []extensions.Command)
+	walk = func(parent *cobra.Command, commands []extensions.Command) {
+		byName := make(map[string]extensions.Command, len(commands))
+		for _, c := range commands {
+			byName[c.Name[len(c.Name)-1]] = c
+		}
+		for _, cmd := range parent.Commands() {
+			meta, ok := byName[cmd.Name()]
+			if !assert.True(t, ok, "command %q is missing from the metadata", cmd.CommandPath()) {
+				continue
+			}
+			assert.Equal(t, cmd.Short, meta.Short, cmd.CommandPath())
+			assert.Equal(t, cmd.Hidden, meta.Hidden, cmd.CommandPath())
+
+			flags := make(map[string]extensions.Flag, len(meta.Flags))
+			for _, f := range meta.Flags {
+				flags[f.Name] = f
+			}
+			cmd.Flags().VisitAll(func(f *pflag.Flag) {
+				// azdext leaves out the standard --output flag it adds to
+				// every extension.
+				if f.Name == "output" {
+					return
+				}
+				got, ok := flags[f.Name]
+				if !assert.True(t, ok, "%s: flag --%s is missing from the metadata", cmd.CommandPath(), f.Name) {
+					return
+				}
+				if f.DefValue != "" {
+					assert.Equal(t, f.DefValue, got.Default, "%s --%s default", cmd.CommandPath(), f.Name)
+				}
+				assert.Equal(t, isRequiredFlag(f), got.Required, "%s --%s required", cmd.CommandPath(), f.Name)
+			})
+			cmd.Flags().VisitAll(func(f *pflag.Flag) { delete(flags, f.Name) })
+			assert.Empty(t, flags, "%s: metadata has flags the command does not", cmd.CommandPath())
+
+			walk(cmd, meta.Subcommands)
+		}
+		assert.Len(t, commands, len(parent.Commands()), "%s: metadata has commands the tree does not", parent.CommandPath())
+	}
+	walk(root, metadata.Commands)
+}
+
+func TestUsageArgs(t *testing.T) {
+	tests := []struct {
+		use  string
+		want []extensions.Argument
+	}{
+		{"get <url>", []extensions.Argument{{Name: "url", Required: true}}},
+		{"context [url]", []extensions.Argument{{Name: "url"}}},
+		{"add <resourceId> <key=value>...", []extensions.Argument{
+			{Name: "resourceId", Required: true},
+			{Name: "key=value", Required: true, Variadic: true},
+		}},
+		{"put <blob-url> --file <path>", []extensions.Argument{{Name: "blob-url", Required: true}}},
+		{"bulk --input <file|->", nil},
+		{"graph <kql-query> [flags]", []extensions.Argument{{Name: "kql-query", Required: true}}},
+		{"doctor", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, usageArgs(tt.use), tt.use)
+	}
+}
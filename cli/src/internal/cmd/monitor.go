@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// logsIngestionAPIVersion is the Logs Ingestion API version.
+	logsIngestionAPIVersion = "2023-01-01"
+	// dcrAPIVersion is the ARM API version used to look up a data collection
+	// rule or endpoint by resource ID.
+	dcrAPIVersion = "2023-03-11"
+	// maxIngestionBatchBytes keeps each Logs Ingestion call under the API's
+	// 1 MB request limit, measured before compression.
+	maxIngestionBatchBytes = 1_000_000
+)
+
+// monitorTokenProviderFactory builds the token provider used for Logs
+// Ingestion calls. It is a package variable so tests can inject a stub.
+var monitorTokenProviderFactory = service.DefaultTokenProviderFactory
+
+// NewMonitorCommand returns the monitor subcommand, which groups Azure
+// Monitor helpers.
+func NewMonitorCommand() *cobra.Command {
+	monitorCmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Azure Monitor helpers",
+	}
+	monitorCmd.AddCommand(newMonitorIngestCommand())
+	return monitorCmd
+}
+
+func newMonitorIngestCommand() *cobra.Command {
+	var (
+		dcr      string
+		stream   string
+		endpoint string
+	)
+	cmd := &cobra.Command{
+		Use:   "ingest --dcr <id> --stream <name> --data <json|@file>",
+		Short: "Send rows to a Log Analytics table through the Logs Ingestion API",
+		Long: `Send a JSON array of rows to a data collection rule stream through the Azure
+Monitor Logs Ingestion API.
+
+--dcr is either the rule's immutable ID (dcr-...), which needs --endpoint, or
+its ARM resource ID, from which the immutable ID and ingestion endpoint are
+looked up. Rows are split into batches that stay under the API's 1 MB request
+limit, and each batch is gzip compressed.`,
+		Example: `  # Using the immutable ID and a data collection endpoint
+  azd rest monitor ingest --endpoint https://my-dce-a1b2.eastus-1.ingest.monitor.azure.com \
+    --dcr dcr-00000000000000000000000000000000 --stream Custom-MyTable_CL --data @rows.json
+
+  # Using the DCR resource ID
+  azd rest monitor ingest --dcr /subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Insights/dataCollectionRules/<name> \
+    --stream Custom-MyTable_CL --data @rows.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if dcr == "" || stream == "" {
+				return fmt.Errorf("--dcr and --stream are required")
+			}
+			cfg := snapshotConfig()
+			raw, err := readIngestRows(cfg.Data, cfg.DataFile)
+			if err != nil {
+				return err
+			}
+			batches, err := splitIngestBatches(raw, maxIngestionBatchBytes)
+			if err != nil {
+				return err
+			}
+
+			ctx := commandContext(cmd)
+			immutableID := dcr
+			if strings.HasPrefix(strings.ToLower(dcr), "/subscriptions/") {
+				if immutableID, endpoint, err = resolveDCR(ctx, dcr, endpoint); err != nil {
+					return err
+				}
+			}
+			if endpoint == "" {
+				return fmt.Errorf("--endpoint is required when --dcr is an immutable ID")
+			}
+			ingestURL := strings.TrimRight(endpoint, "/") + "/dataCollectionRules/" + url.PathEscape(immutableID) +
+				"/streams/" + url.PathEscape(stream) + "?api-version=" + logsIngestionAPIVersion
+
+			var tp client.TokenProvider
+			if !cfg.NoAuth {
+				if tp, err = monitorTokenProviderFactory(); err != nil {
+					return fmt.Errorf("failed to create token provider: %w", err)
+				}
+			}
+			scope := cfg.Scope
+			if scope == "" {
+				if scope, err = client.DetectScope(ingestURL); err != nil {
+					return fmt.Errorf("failed to detect scope: %w", err)
+				}
+			}
+			c := client.NewClient(tp, cfg.Insecure, cfg.Timeout)
+			rows := 0
+			for i, batch := range batches {
+				if err := sendIngestBatch(ctx, c, ingestURL, scope, cfg, batch.body); err != nil {
+					return fmt.Errorf("batch %d of %d (%d rows already sent): %w", i+1, len(batches), rows, err)
+				}
+				rows += batch.rows
+			}
+			if !silent {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Ingested %d row(s) in %d batch(es)\n", rows, len(batches))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dcr, "dcr", "", "Data collection rule immutable ID or ARM resource ID")
+	cmd.Flags().StringVar(&stream, "stream", "", "Stream name declared in the rule, e.g. Custom-MyTable_CL")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "Logs ingestion endpoint (data collection endpoint or DCR endpoint URL)")
+	return cmd
+}
+
+// readIngestRows returns the request body from --data (a JSON string, or
+// @path to read a file) or --data-file.
+func readIngestRows(data, dataFile string) ([]byte, error) {
+	if dataFile == "" && strings.HasPrefix(data, "@") {
+		dataFile = data
+	}
+	if dataFile != "" {
+		raw, err := os.ReadFile(strings.TrimPrefix(dataFile, "@")) // #nosec G304 -- User-specified file path via --data is intentional.
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data file: %w", err)
+		}
+		return raw, nil
+	}
+	if data == "" {
+		return nil, fmt.Errorf("--data is required (a JSON array of rows, or @file)")
+	}
+	return []byte(data), nil
+}
+
+// ingestBatch is one Logs Ingestion request body and its row count.
+type ingestBatch struct {
+	body []byte
+	rows int
+}
+
+// splitIngestBatches parses a JSON array of rows and packs the rows into JSON
+// array bodies no larger than limit bytes. A single row larger than the limit
+// is an error, since the API would reject it.
+func splitIngestBatches(raw []byte, limit int) ([]ingestBatch, error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("--data must be a JSON array of rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("--data contains no rows")
+	}
+
+	var batches []ingestBatch
+	var buf bytes.Buffer
+	count := 0
+	flush := func() {
+		buf.WriteByte(']')
+		batches = append(batches, ingestBatch{body: append([]byte(nil), buf.Bytes()...), rows: count})
+		buf.Reset()
+		count = 0
+	}
+	for i, row := range rows {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		if compact.Len()+2 > limit {
+			return nil, fmt.Errorf("row %d is %d bytes, over the %d byte request limit", i, compact.Len(), limit)
+		}
+		if count > 0 && buf.Len()+1+compact.Len()+1 > limit {
+			flush()
+		}
+		if count == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(compact.Bytes())
+		count++
+	}
+	flush()
+	return batches, nil
+}
+
+// sendIngestBatch gzips and posts one batch, expecting 204 No Content, and
+// records the post in --audit-log.
+func sendIngestBatch(ctx context.Context, c *client.Client, ingestURL, scope string, cfg config.Config, body []byte) error {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(body); err != nil {
+		return fmt.Errorf("failed to compress batch: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress batch: %w", err)
+	}
+
+	opts := client.RequestOptions{
+		Method: "POST",
+		URL:    ingestURL,
+		Body:   bytes.NewReader(gz.Bytes()),
+		Headers: http.Header{
+			"Content-Type":     {"application/json"},
+			"Content-Encoding": {"gzip"},
+		},
+		Scope:    scope,
+		SkipAuth: cfg.NoAuth,
+		Verbose:  cfg.Verbose,
+		Retry:    cfg.Retry,
+	}
+	started := time.Now()
+	resp, err := c.Execute(ctx, opts)
+	if auditErr := service.RecordAudit(cfg.AuditLog, opts, started, resp, err); auditErr != nil {
+		return fmt.Errorf("request sent but not recorded in the audit log: %w", auditErr)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("logs ingestion returned %s: %s", resp.Status, strings.TrimSpace(string(resp.Body)))
+	}
+	return nil
+}
+
+// resolveDCR looks up a data collection rule by ARM resource ID and returns
+// its immutable ID and logs ingestion endpoint. An explicit endpoint wins; a
+// rule without its own endpoint uses its data collection endpoint's.
+func resolveDCR(ctx context.Context, resourceID, endpoint string) (string, string, error) {
+	var rule struct {
+		Properties struct {
+			ImmutableID string `json:"immutableId"`
+			Endpoints   struct {
+				LogsIngestion string `json:"logsIngestion"`
+			} `json:"endpoints"`
+			DataCollectionEndpointID string `json:"dataCollectionEndpointId"`
+		} `json:"properties"`
+	}
+	if _, err := armGet(ctx, resourceID, dcrAPIVersion, &rule); err != nil {
+		return "", "", fmt.Errorf("failed to look up data collection rule: %w", err)
+	}
+	if rule.Properties.ImmutableID == "" {
+		return "", "", fmt.Errorf("data collection rule %s has no immutableId", resourceID)
+	}
+	if endpoint == "" {
+		endpoint = rule.Properties.Endpoints.LogsIngestion
+	}
+	if endpoint == "" && rule.Properties.DataCollectionEndpointID != "" {
+		var dce struct {
+			Properties struct {
+				LogsIngestion struct {
+					Endpoint string `json:"endpoint"`
+				} `json:"logsIngestion"`
+			} `json:"properties"`
+		}
+		if _, err := armGet(ctx, rule.Properties.DataCollectionEndpointID, dcrAPIVersion, &dce); err != nil {
+			return "", "", fmt.Errorf("failed to look up data collection endpoint: %w", err)
+		}
+		endpoint = dce.Properties.LogsIngestion.Endpoint
+	}
+	if endpoint == "" {
+		return "", "", fmt.Errorf("data collection rule %s has no logs ingestion endpoint; pass --endpoint", resourceID)
+	}
+	return rule.Properties.ImmutableID, endpoint, nil
+}
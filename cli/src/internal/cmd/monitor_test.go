@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIngestBatches(t *testing.T) {
+	raw := []byte(`[{"a": 1}, {"a": 2}, {"a": 3}]`)
+
+	batches, err := splitIngestBatches(raw, 1000)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, `[{"a":1},{"a":2},{"a":3}]`, string(batches[0].body))
+	assert.Equal(t, 3, batches[0].rows)
+
+	// Each compact row is 7 bytes; 17 bytes fits two rows per batch.
+	batches, err = splitIngestBatches(raw, 17)
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, `[{"a":1},{"a":2}]`, string(batches[0].body))
+	assert.Equal(t, `[{"a":3}]`, string(batches[1].body))
+	for _, b := range batches {
+		assert.LessOrEqual(t, len(b.body), 17)
+		assert.True(t, json.Valid(b.body))
+	}
+}
+
+func TestSplitIngestBatches_Errors(t *testing.T) {
+	_, err := splitIngestBatches([]byte(`{"a":1}`), 100)
+	assert.ErrorContains(t, err, "JSON array")
+
+	_, err = splitIngestBatches([]byte(`[]`), 100)
+	assert.ErrorContains(t, err, "no rows")
+
+	_, err = splitIngestBatches([]byte(`[{"a":"`+strings.Repeat("x", 50)+`"}]`), 20)
+	assert.ErrorContains(t, err, "over the 20 byte request limit")
+}
+
+func TestMonitorIngest_ResolvesDCRAndSendsGzip(t *testing.T) {
+	resetGlobalFlags()
+	silent = true
+
+	var mu sync.Mutex
+	var received [][]byte
+	ingest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/dataCollectionRules/dcr-123/streams/Custom-T_CL", r.URL.Path)
+		assert.Equal(t, logsIngestionAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "Bearer monitor-token", r.Header.Get("Authorization"))
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(zr)
+		mu.Lock()
+		received = append(received, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ingest.Close()
+
+	arm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/dataCollectionRules/rule"):
+			_, _ = w.Write([]byte(`{"properties":{"immutableId":"dcr-123","dataCollectionEndpointId":"/subscriptions/s/resourceGroups/g/providers/Microsoft.Insights/dataCollectionEndpoints/dce"}}`))
+		case strings.HasSuffix(r.URL.Path, "/dataCollectionEndpoints/dce"):
+			_, _ = w.Write([]byte(`{"properties":{"logsIngestion":{"endpoint":"` + ingest.URL + `"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer arm.Close()
+
+	origEndpoint, origARM, origMonitor := armEndpoint, armTokenProviderFactory, monitorTokenProviderFactory
+	defer func() {
+		armEndpoint, armTokenProviderFactory, monitorTokenProviderFactory = origEndpoint, origARM, origMonitor
+	}()
+	armEndpoint = arm.URL
+	armTokenProviderFactory = func() (client.TokenProvider, error) { return &client.MockTokenProvider{Token: "arm-token"}, nil }
+	monitorTokenProviderFactory = func() (client.TokenProvider, error) { return &client.MockTokenProvider{Token: "monitor-token"}, nil }
+	// Local test servers have no detectable scope, so pass one explicitly.
+	scope = "https://monitor.azure.com/.default"
+	data = `[{"Message":"one"},{"Message":"two"}]`
+
+	cmd := NewMonitorCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"ingest", "--dcr", "/subscriptions/s/resourceGroups/g/providers/Microsoft.Insights/dataCollectionRules/rule", "--stream", "Custom-T_CL"})
+	require.NoError(t, cmd.Execute())
+
+	require.Len(t, received, 1)
+	assert.JSONEq(t, `[{"Message":"one"},{"Message":"two"}]`, string(received[0]))
+}
+
+func TestMonitorIngest_RecordsAuditLog(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	silent = true
+	noAuth = true
+	auditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+	data = `[{"Message":"one"}]`
+
+	ingest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ingest.Close()
+
+	cmd := NewMonitorCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"ingest", "--dcr", "dcr-123", "--stream", "Custom-T_CL", "--endpoint", ingest.URL})
+	require.NoError(t, cmd.Execute())
+
+	entries := readAuditLog(t, auditLog)
+	require.Len(t, entries, 1)
+	assert.Equal(t, http.MethodPost, entries[0].Method)
+	assert.Equal(t, http.StatusNoContent, entries[0].StatusCode)
+	assert.Contains(t, entries[0].URL, "/dataCollectionRules/dcr-123/streams/Custom-T_CL")
+}
+
+func TestMonitorIngest_RequiresEndpointForImmutableID(t *testing.T) {
+	resetGlobalFlags()
+	data = `[{"a":1}]`
+
+	cmd := NewMonitorCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"ingest", "--dcr", "dcr-123", "--stream", "Custom-T_CL"})
+	assert.ErrorContains(t, cmd.Execute(), "--endpoint is required")
+}
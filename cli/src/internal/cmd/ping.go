@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// NewPingCommand returns the ping subcommand, a health check that GETs a URL
+// a few times and fails unless every attempt passes.
+func NewPingCommand() *cobra.Command {
+	var (
+		count        int
+		interval     time.Duration
+		expectStatus []int
+		report       string
+	)
+	cmd := &cobra.Command{
+		Use:   "ping <url>",
+		Short: "Health-check an endpoint with repeated GET requests",
+		Long: `Send a GET request to a URL a number of times and report the status and
+latency of each attempt.
+
+An attempt passes when the request succeeds and its status is one of
+--expect-status, or below 400 when --expect-status is not set. The command
+exits non-zero unless every attempt passes, which makes it usable as a
+post-deploy smoke test. Global flags such as --header, --scope, and --no-auth
+apply to every attempt, and --max-time bounds the whole run.
+
+Use -f json for a machine-readable report, and --report junit=<file> to write
+the attempts as JUnit XML test cases for Azure DevOps or GitHub Actions.`,
+		Example: `  azd rest ping https://myapp.azurewebsites.net/health --no-auth --count 5 --interval 2s --expect-status 200
+  azd rest ping https://myapp.azurewebsites.net/health --no-auth --report junit=ping-results.xml
+  azd rest ping "https://management.azure.com/subscriptions?api-version=2022-12-01" -f json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var junitPath string
+			if report != "" {
+				var err error
+				if junitPath, err = service.ParseReportSpec(report); err != nil {
+					return err
+				}
+			}
+			return getRequestService().ExecutePing(commandContext(cmd), snapshotConfig(), service.PingOptions{
+				URL:          args[0],
+				Count:        count,
+				Interval:     interval,
+				ExpectStatus: expectStatus,
+				JSON:         outputFormat == "json",
+				JUnitReport:  junitPath,
+			}, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().IntVar(&count, "count", 3, "Number of attempts")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "Wait between attempts")
+	cmd.Flags().IntSliceVar(&expectStatus, "expect-status", nil, "Status codes that pass (repeatable or comma-separated; default any status below 400)")
+	cmd.Flags().StringVar(&report, "report", "", "Also write the results as a test report: junit=<file>")
+	return cmd
+}
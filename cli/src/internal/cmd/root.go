@@ -25,6 +25,15 @@ import (
 var (
 	scope           string
 	noAuth          bool
+	tenant          string
+	auxTenants      []string
+	authMode        string
+	basicUser       string
+	bearerEnv       string
+	apimKeyEnv      string
+	apimKeySecret   string
+	githubAuth      bool
+	baseURL         string
 	apiVersion      string
 	clientRequestID string
 	urlParams       []string
@@ -32,38 +41,87 @@ var (
 	headerFile      string
 	data            string
 	dataFile        string
+	dataRaw         string
+	dataBinary      string
+	dataURLEncode   []string
+	jsonData        string
+	templateVars    []string
 	dataFormat      string
 	query           string
+	setAzdEnv       []string
+	ciOutput        string
+	ciVars          []string
 	formFields      []string
 	jsonFields      []string
 	jsonFieldsRaw   []string
 	outputFile      string
+	outputDir       string
+	outputMeta      bool
+	appendOutput    bool
+	storageAccount  string
+	noClobber       bool
+	fileMode        string
+	splitPages      bool
+	splitItems      bool
+	splitName       string
+	pipeCommand     string
 	outputFormat    string
-	verbose         bool
+	verbose         int
 	paginate        bool
 	flatten         bool
+	sortKeys        bool
+	dropNulls       bool
 	retry           int
+	retryAllMethods bool
+	idempotencyKey  string
+	hostLimits      []string
 	binary          bool
+	checksumAlgo    string
+	verifyChecksum  string
+	rawBytes        bool
 	insecure        bool
+	assumeYes       bool
+	pinnedPubKey    string
 	silent          bool
+	summary         bool
+	onlyStatus      bool
 	timeout         time.Duration
+	connectTimeout  time.Duration
+	forceIPv4       bool
+	forceIPv6       bool
+	cacheTTL        time.Duration
 	maxTime         time.Duration
 	followRedirects bool
 	maxRedirects    int
+	locationTrusted bool
+	redirectScope   string
+	autoScope       string
 	maxPages        int
 	maxResponseSize int64
+	maxHeaderSize   int64
+	maxHeaders      int
 	showThrottle    bool
 	repeat          int
+	watch           time.Duration
+	until           string
+	watchMaxErrors  int
+	notifyWebhook   string
 	colorMode       string
 	writeOut        string
 	include         bool
 	allowHosts      []string
+	blockPrivate    bool
 	redactPaths     []string
 	tableColumns    []string
+	csvColumns      []string
 	dumpHeaders     string
+	showHeaders     []string
+	auditLog        string
 	fail            bool
 	rawOutput       bool
 	compact         bool
+	mergePatch      string
+	strategicMerge  bool
 )
 
 // httpMethodDef defines one HTTP method subcommand for the table-driven factory (#68).
@@ -91,7 +149,7 @@ var httpMethods = []httpMethodDef{
 // structure; only the method string and descriptions differ.
 func newHTTPMethodCommand(def httpMethodDef) *cobra.Command {
 	method := def.Method // capture for closure
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   def.Use,
 		Short: def.Short,
 		Long:  def.Long,
@@ -100,6 +158,16 @@ func newHTTPMethodCommand(def httpMethodDef) *cobra.Command {
 			return executeRequest(cmd, method, args[0])
 		},
 	}
+	azdext.RegisterFlagOptions(cmd, azdext.FlagOptions{
+		Name:          "output",
+		AllowedValues: []string{"default", "clipboard", "editor"},
+		Usage:         "Where to send the response: stdout or --output-file by default, the clipboard, or $VISUAL/$EDITOR",
+	})
+	if method == "PATCH" {
+		cmd.Flags().StringVar(&mergePatch, "merge", "", "Send a JSON Merge Patch (RFC 7396) object with Content-Type application/merge-patch+json")
+		cmd.Flags().BoolVar(&strategicMerge, "strategic", false, "With --merge, GET the resource, merge the patch locally, and PUT the result (for APIs without PATCH)")
+	}
+	return cmd
 }
 
 // NewGetCommand returns the GET subcommand.
@@ -188,6 +256,16 @@ Examples:
 	// Extension-specific flags
 	rootCmd.PersistentFlags().StringVarP(&scope, "scope", "s", "", "OAuth scope for authentication (auto-detected if not provided)")
 	rootCmd.PersistentFlags().BoolVar(&noAuth, "no-auth", false, "Skip authentication (no bearer token)")
+	rootCmd.PersistentFlags().StringVar(&authMode, "auth", "", "Credential to use: azure (default), oauth2:<profile> for an OAuth2 client-credentials profile, sas:<profile> for a Service Bus/Event Hubs SAS profile from the config file, or exec:<name> for an external credential plugin")
+	rootCmd.PersistentFlags().StringVar(&authMode, "credential", "", "Alias for --auth")
+	rootCmd.PersistentFlags().StringVarP(&basicUser, "user", "u", "", "Send HTTP Basic credentials (format: name:password); disables Azure authentication")
+	rootCmd.PersistentFlags().StringVar(&bearerEnv, "bearer-env", "", "Send the token in the named environment variable as a bearer token; disables Azure authentication")
+	rootCmd.PersistentFlags().StringVar(&apimKeyEnv, "apim-key-env", "", "Send the API Management subscription key in the named environment variable as Ocp-Apim-Subscription-Key")
+	rootCmd.PersistentFlags().StringVar(&apimKeySecret, "apim-key-secret", "", "Send the API Management subscription key stored in the OS keychain under this name (see azd rest secret)")
+	rootCmd.PersistentFlags().BoolVar(&githubAuth, "github-auth", false, "Send a GitHub token from GH_TOKEN, GITHUB_TOKEN, or the gh CLI to https://api.github.com requests")
+	rootCmd.PersistentFlags().StringVar(&tenant, "tenant", "", "Acquire the Azure token from this tenant; with --verbose, a token from another tenant is reported")
+	rootCmd.PersistentFlags().StringArrayVar(&auxTenants, "aux-tenant", []string{}, "Acquire a token from an additional tenant and send it in x-ms-authorization-auxiliary for cross-tenant ARM calls (repeatable, up to 3)")
+	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Resolve a request path that starts with / against this URL (default from baseUrl in the config file)")
 	rootCmd.PersistentFlags().StringVar(&apiVersion, "api-version", "", "Set or replace the api-version query parameter")
 	rootCmd.PersistentFlags().StringVar(&clientRequestID, "client-request-id", "", "Set the x-ms-client-request-id header for Azure request correlation. Pass the flag without a value to generate a random ID.")
 	// Passing --client-request-id without a value generates a fresh ID for this invocation.
@@ -195,37 +273,87 @@ Examples:
 	rootCmd.PersistentFlags().StringArrayVar(&urlParams, "url-param", []string{}, "Set or append a URL query parameter (repeatable, format: key=value)")
 	rootCmd.PersistentFlags().StringArrayVarP(&headers, "header", "H", []string{}, "Custom headers (repeatable, format: Key:Value)")
 	rootCmd.PersistentFlags().StringVar(&headerFile, "header-file", "", "Read headers from a file (one Key: Value per line; blank lines and # comments ignored). -H overrides on conflict.")
-	rootCmd.PersistentFlags().StringVarP(&data, "data", "d", "", "Request body (JSON string)")
-	rootCmd.PersistentFlags().StringVar(&dataFile, "data-file", "", "Read request body from file (also accepts @{file} shorthand)")
-	rootCmd.PersistentFlags().StringVar(&dataFormat, "data-format", "json", "Interpret --data / --data-file as this format before sending: json or yaml. YAML is converted to a JSON body.")
+	rootCmd.PersistentFlags().StringVarP(&data, "data", "d", "", "Request body (JSON string; @- reads it from stdin)")
+	rootCmd.PersistentFlags().StringVar(&dataFile, "data-file", "", "Read request body from file (also accepts @{file} shorthand; - reads stdin)")
+	rootCmd.PersistentFlags().StringVar(&dataRaw, "data-raw", "", "Request body sent literally, even when it starts with @ (curl --data-raw)")
+	rootCmd.PersistentFlags().StringVar(&dataBinary, "data-binary", "", "Request body, or @file (@- for stdin) sent byte for byte (curl --data-binary)")
+	rootCmd.PersistentFlags().StringArrayVar(&dataURLEncode, "data-urlencode", []string{}, "URL-encode a form field into an application/x-www-form-urlencoded body (repeatable, curl syntax: name=value, name@file, =value, value)")
+	rootCmd.PersistentFlags().StringVar(&jsonData, "json", "", "JSON request body, or @file (@- for stdin); also sets Content-Type and Accept to application/json (curl --json)")
+	rootCmd.PersistentFlags().StringVar(&dataFormat, "data-format", "", "Interpret --data / --data-file as this format before sending: json or yaml (default json, or yaml for a .yaml/.yml --data-file). YAML is converted to a JSON body.")
+	rootCmd.PersistentFlags().StringVar(&dataFormat, "body-format", "", "Alias for --data-format")
+	rootCmd.PersistentFlags().StringArrayVar(&templateVars, "var", []string{}, "Render --data / --data-file / --json as a Go template with this value (repeatable, format: name=value); other names fall back to azd environment values")
 	rootCmd.PersistentFlags().StringVarP(&query, "query", "q", "", "JMESPath query to apply to JSON responses")
+	rootCmd.PersistentFlags().StringVar(&ciOutput, "ci-output", "", "Publish --ci-var values to the pipeline: github (step outputs in $GITHUB_OUTPUT) or azdo (##vso[task.setvariable] logging commands)")
+	rootCmd.PersistentFlags().StringArrayVar(&ciVars, "ci-var", []string{}, "With --ci-output, publish a value from a successful JSON response as a pipeline output (repeatable, format: NAME=<JMESPath>)")
+	rootCmd.PersistentFlags().StringArrayVar(&setAzdEnv, "set-azd-env", []string{}, "Store a value from a successful JSON response in the current azd environment (repeatable, format: KEY=<JMESPath>)")
 	rootCmd.PersistentFlags().StringArrayVar(&formFields, "form-field", []string{}, "Add an application/x-www-form-urlencoded field (repeatable, format: key=value)")
 	rootCmd.PersistentFlags().StringArrayVar(&jsonFields, "json-field", []string{}, "Add a string field to a JSON request body (repeatable, format: key=value; dotted keys nest)")
 	rootCmd.PersistentFlags().StringArrayVar(&jsonFieldsRaw, "json-field-raw", []string{}, "Add a raw JSON field to a JSON request body (repeatable, format: key:=json; dotted keys nest)")
-	rootCmd.PersistentFlags().StringVar(&outputFile, "output-file", "", "Write response to file (raw for binary content)")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", defaults.OutputFormat, "Output format: auto, json, raw, table, jsonl, yaml, csv")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output (show headers, timing)")
+	rootCmd.PersistentFlags().StringVar(&outputFile, "output-file", "", "Write response to file (raw for binary content), - for stdout, or az://container/path to upload to Azure Blob Storage")
+	rootCmd.PersistentFlags().StringVar(&storageAccount, "storage-account", "", "Storage account name or endpoint URL for an az://container/path --output-file (default $AZURE_STORAGE_ACCOUNT)")
+	rootCmd.PersistentFlags().BoolVar(&appendOutput, "append", false, "With --output-file, append the response to the file instead of replacing it")
+	rootCmd.PersistentFlags().BoolVar(&noClobber, "no-clobber", false, "With --output-file, fail instead of overwriting an existing file")
+	rootCmd.PersistentFlags().StringVar(&fileMode, "file-mode", "0600", "Octal permissions for a file written by --output-file")
+	rootCmd.PersistentFlags().BoolVar(&outputMeta, "output-meta", false, "With --output-file, also write <file>.meta.json with the request URL, status, headers, and timing")
+	rootCmd.PersistentFlags().StringVar(&outputDir, "output-dir", "", "Write the response to files in this directory, one per page (--split-pages) or per item (--split-items)")
+	rootCmd.PersistentFlags().BoolVar(&splitPages, "split-pages", false, "With --output-dir, write each page of the response to its own file")
+	rootCmd.PersistentFlags().BoolVar(&splitItems, "split-items", false, "With --output-dir, write each item of the response's value array to its own file")
+	rootCmd.PersistentFlags().StringVar(&splitName, "split-name", "", "Go template for --output-dir file names with .Page, .Index, and .Item (default page-{{printf \"%04d\" .Page}}.json or item-{{printf \"%06d\" .Index}}.json)")
+	rootCmd.PersistentFlags().StringVar(&pipeCommand, "pipe", "", "Send the formatted response to a shell command's stdin (e.g. 'jq .value[]'); --fail still sets the exit code")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", defaults.OutputFormat, "Output format: auto, json, raw, table, jsonl, yaml, csv, envelope")
+	rootCmd.PersistentFlags().VarP((*verbosityValue)(&verbose), "verbose", "v", "Verbose output (show headers, timing); -vv adds the request body and retry decisions, -vvv adds DNS, connection, and TLS events")
+	rootCmd.PersistentFlags().Lookup("verbose").NoOptDefVal = "+1"
 	rootCmd.PersistentFlags().BoolVar(&paginate, "paginate", false, "Follow continuation tokens/next links when supported")
 	rootCmd.PersistentFlags().BoolVar(&flatten, "flatten", false, "Flatten a JSON response into a single-level object keyed by dotted paths (e.g. properties.state, value[0].name)")
+	rootCmd.PersistentFlags().BoolVar(&sortKeys, "sort-keys", false, "Sort the keys of every JSON object in the response, for stable diffs")
+	rootCmd.PersistentFlags().BoolVar(&dropNulls, "drop-nulls", false, "Remove JSON object members whose value is null, at any depth")
 	rootCmd.PersistentFlags().IntVar(&retry, "retry", defaults.Retry, "Retry attempts with exponential backoff for transient errors")
+	rootCmd.PersistentFlags().BoolVar(&retryAllMethods, "retry-non-idempotent", false, "Retry POST and PATCH after a network error even without an Idempotency-Key header, risking a duplicate side effect")
+	rootCmd.PersistentFlags().StringVar(&idempotencyKey, "idempotency-key", "", "Send this Idempotency-Key header, which also lets POST and PATCH be retried after a network error (auto generates a random key, a new one for each --repeat)")
+	rootCmd.PersistentFlags().StringArrayVar(&hostLimits, "host-limit", []string{}, "Cap bulk and workflow requests to a host (repeatable, format: HOST=RPS[:IN_FLIGHT]; * matches other hosts; ARM defaults to 10:8)")
 	rootCmd.PersistentFlags().BoolVar(&binary, "binary", false, "Stream request/response as binary without transformation")
+	rootCmd.PersistentFlags().StringVar(&checksumAlgo, "checksum", "", "Print the sha256, sha384, or sha512 digest of the downloaded response body to stderr")
+	rootCmd.PersistentFlags().StringVar(&verifyChecksum, "verify-checksum", "", "Fail without writing the response unless its body matches this hex digest (optionally prefixed, e.g. sha256:<hex>)")
+	rootCmd.PersistentFlags().BoolVar(&rawBytes, "raw-bytes", false, "Keep the response body in its original charset instead of transcoding it to UTF-8")
 	rootCmd.PersistentFlags().BoolVarP(&insecure, "insecure", "k", false, "Skip TLS certificate verification (unsafe — do not use in production)")
+	rootCmd.PersistentFlags().BoolVar(&assumeYes, "yes", false, "Confirm risky operations, such as sending an Azure token with --insecure")
+	rootCmd.PersistentFlags().StringVar(&pinnedPubKey, "pinnedpubkey", "", "Require the server certificate public key to match a pin: sha256//<base64 hash>, several separated by ;")
 	rootCmd.PersistentFlags().BoolVar(&silent, "silent", false, "Suppress non-error diagnostic messages on stderr (warnings and notices)")
+	rootCmd.PersistentFlags().BoolVar(&summary, "summary", false, "Print a one-line summary of attempts, backoff, final status, and rate-limit headers to stderr (also shown with --verbose)")
 	rootCmd.PersistentFlags().DurationVarP(&timeout, "timeout", "t", defaults.Timeout, "Request timeout")
+	rootCmd.PersistentFlags().DurationVar(&connectTimeout, "connect-timeout", 0, "Maximum time to establish a connection, including the IPv6 to IPv4 fallback (0 uses 30s)")
+	rootCmd.PersistentFlags().BoolVar(&forceIPv4, "ipv4", false, "Connect over IPv4 only")
+	rootCmd.PersistentFlags().BoolVar(&forceIPv6, "ipv6", false, "Connect over IPv6 only")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache", 0, "Reuse a cached GET or HEAD response younger than this duration, per azd environment (0 disables the cache)")
 	rootCmd.PersistentFlags().DurationVar(&maxTime, "max-time", defaults.MaxTime, "Overall time budget across retries and pagination (0 disables the limit)")
 	rootCmd.PersistentFlags().BoolVar(&followRedirects, "follow-redirects", defaults.FollowRedirects, "Follow HTTP redirects")
 	rootCmd.PersistentFlags().IntVar(&maxRedirects, "max-redirects", defaults.MaxRedirects, "Maximum redirect hops")
+	rootCmd.PersistentFlags().BoolVar(&locationTrusted, "location-trusted", false, "Send Authorization and other credential headers to redirect targets on a different host (like curl --location-trusted)")
+	rootCmd.PersistentFlags().StringVar(&redirectScope, "redirect-scope", defaults.RedirectScope, "When a redirect that keeps the token lands on an Azure host with a different scope: refuse, or reacquire a token for the new scope")
+	rootCmd.PersistentFlags().StringVar(&autoScope, "auto-scope", defaults.AutoScope, "When a 401 challenge names the resource the API expects: suggest the scope, retry with a token for it, or off")
 	rootCmd.PersistentFlags().IntVar(&maxPages, "max-pages", defaults.MaxPages, "Maximum number of pages to fetch when paginating")
 	rootCmd.PersistentFlags().Int64Var(&maxResponseSize, "max-response-size", defaults.MaxResponseSize, "Maximum response size in bytes")
+	rootCmd.PersistentFlags().Int64Var(&maxHeaderSize, "max-header-size", defaults.MaxHeaderSize, "Maximum total size of the response headers in bytes (up to 1048576)")
+	rootCmd.PersistentFlags().IntVar(&maxHeaders, "max-headers", defaults.MaxHeaders, "Maximum number of response headers")
 	rootCmd.PersistentFlags().BoolVar(&showThrottle, "show-throttle", false, "Print Azure rate-limit and quota headers to stderr, with a low-quota warning")
 	rootCmd.PersistentFlags().IntVar(&repeat, "repeat", defaults.Repeat, "Send the request N times and report latency statistics")
+	rootCmd.PersistentFlags().DurationVar(&watch, "watch", 0, "Send the request again every interval until --until is met, --watch-max-errors is hit, or interrupted")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "With --watch, stop when this JMESPath expression over the response body is truthy")
+	rootCmd.PersistentFlags().IntVar(&watchMaxErrors, "watch-max-errors", 0, "With --watch, stop after this many consecutive failed requests (0 never stops)")
+	rootCmd.PersistentFlags().StringVar(&notifyWebhook, "notify-webhook", "", "With --watch, POST a message to this Teams or Slack incoming webhook when the watch stops on --until or --watch-max-errors")
 	rootCmd.PersistentFlags().StringVar(&colorMode, "color", defaults.Color, "Colorize JSON output: auto, always, never")
 	rootCmd.PersistentFlags().StringVarP(&writeOut, "write-out", "w", "", "Print curl-style response metadata to stderr after the request (e.g. \"%{http_code} %{time_total}\")")
 	rootCmd.PersistentFlags().BoolVarP(&include, "include", "i", false, "Include the HTTP status line and response headers in the output")
 	rootCmd.PersistentFlags().StringArrayVar(&allowHosts, "allow-host", []string{}, "Restrict requests to hosts matching a pattern (repeatable; leading *. matches subdomains). Env: AZD_REST_ALLOWED_HOSTS (comma separated)")
+	rootCmd.PersistentFlags().BoolVar(&blockPrivate, "block-private-networks", false, "Refuse requests and redirects to private, loopback, link-local, and cloud metadata addresses (config file: blockPrivateNetworks)")
 	rootCmd.PersistentFlags().StringArrayVar(&redactPaths, "redact", []string{}, "Mask a JSON response field before output (repeatable, dotted path, * matches array elements)")
 	rootCmd.PersistentFlags().StringSliceVar(&tableColumns, "table-columns", nil, "Comma-separated columns to show, in order, for --format table (ignored for other formats)")
+	rootCmd.PersistentFlags().StringSliceVar(&csvColumns, "columns", nil, "Comma-separated columns for --format csv, each NAME=<JMESPath> or a JMESPath expression evaluated against each row (e.g. name,state=properties.provisioningState)")
+	rootCmd.PersistentFlags().StringArrayVar(&showHeaders, "show-header", []string{}, "Print only the values of the named response header, one per line, instead of the body (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&onlyStatus, "only-status", false, "Print only the HTTP status code instead of the body (like curl -o /dev/null -w \"%{http_code}\")")
+	rootCmd.MarkFlagsMutuallyExclusive("only-status", "show-header")
 	rootCmd.PersistentFlags().StringVar(&dumpHeaders, "dump-headers", "", "Write response status line and headers to a file (use - for stderr)")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", "", "Append each write request (PUT, POST, PATCH, DELETE) to a hash-chained audit log file; set AZD_REST_AUDIT_KEY to sign entries")
 	rootCmd.PersistentFlags().BoolVar(&fail, "fail", false, "Exit with code 22 when the response status is 400 or higher (the response body is still printed)")
 	rootCmd.PersistentFlags().BoolVarP(&rawOutput, "raw-output", "r", false, "With --query, print a string result unquoted and an array of strings one per line (like jq -r)")
 	rootCmd.PersistentFlags().BoolVarP(&compact, "compact", "c", false, "Minify JSON output to a single line (applies to auto and json formats and --query results)")
@@ -247,12 +375,31 @@ Examples:
 	rootCmd.AddCommand(
 		NewScopeCommand(),
 		azdext.NewVersionCommand("jongio.azd.rest", version.Version, &outputFormat),
-		azdext.NewMetadataCommand("1.0", "jongio.azd.rest", NewRootCmd),
-		azdext.NewListenCommand(nil),
+		NewMetadataCommand(),
+		newListenCommand(),
 		NewMCPCommand(),
 		NewDoctorCommand(),
 		NewGraphCommand(),
 		NewWhoamiCommand(),
+		NewContextCommand(),
+		NewSecretCommand(),
+		NewSASCommand(),
+		NewARMCommand(),
+		NewBlobCommand(),
+		NewMonitorCommand(),
+		NewKustoCommand(),
+		NewLogsCommand(),
+		NewAppConfigCommand(),
+		NewIdentityCommand(),
+		NewBulkCommand(),
+		NewAuditCommand(),
+		NewDocsCommand(),
+		NewDaemonCommand(),
+		NewBenchCommand(),
+		NewPingCommand(),
+		NewAliasCommand(),
+		NewRunCommand(),
+		NewCacheCommand(),
 	)
 
 	return rootCmd
@@ -265,6 +412,15 @@ func snapshotConfig() config.Config {
 	return config.Config{
 		Scope:           scope,
 		NoAuth:          noAuth,
+		Tenant:          tenant,
+		AuxTenants:      auxTenants,
+		Auth:            authMode,
+		User:            basicUser,
+		BearerEnv:       bearerEnv,
+		APIMKeyEnv:      apimKeyEnv,
+		APIMKeySecret:   apimKeySecret,
+		GitHubAuth:      githubAuth,
+		BaseURL:         baseURL,
 		APIVersion:      apiVersion,
 		ClientRequestID: clientRequestID,
 		URLParams:       urlParams,
@@ -272,35 +428,85 @@ func snapshotConfig() config.Config {
 		HeaderFile:      headerFile,
 		Data:            data,
 		DataFile:        dataFile,
+		DataRaw:         dataRaw,
+		DataBinary:      dataBinary,
+		DataURLEncode:   dataURLEncode,
+		JSONData:        jsonData,
+		TemplateVars:    templateVars,
 		DataFormat:      dataFormat,
+		MergePatch:      mergePatch,
+		Strategic:       strategicMerge,
 		Query:           query,
+		SetAzdEnv:       setAzdEnv,
+		CIOutput:        ciOutput,
+		CIVars:          ciVars,
 		FormFields:      formFields,
 		JSONFields:      jsonFields,
 		JSONFieldsRaw:   jsonFieldsRaw,
 		OutputFile:      outputFile,
+		OutputDir:       outputDir,
+		OutputMeta:      outputMeta,
+		Append:          appendOutput,
+		StorageAccount:  storageAccount,
+		NoClobber:       noClobber,
+		FileMode:        fileMode,
+		SplitPages:      splitPages,
+		SplitItems:      splitItems,
+		SplitName:       splitName,
+		Pipe:            pipeCommand,
 		OutputFormat:    outputFormat,
-		Verbose:         verbose,
+		Verbose:         verbose > 0,
+		VerboseLevel:    verbose,
 		Flatten:         flatten,
+		SortKeys:        sortKeys,
+		DropNulls:       dropNulls,
 		Paginate:        paginate,
 		Retry:           retry,
+		RetryAllMethods: retryAllMethods,
+		IdempotencyKey:  idempotencyKey,
+		HostLimits:      hostLimits,
 		Binary:          binary,
+		Checksum:        checksumAlgo,
+		VerifyChecksum:  verifyChecksum,
+		RawBytes:        rawBytes,
 		Insecure:        insecure,
+		Yes:             assumeYes,
+		PinnedPubKey:    pinnedPubKey,
 		Silent:          silent,
+		Summary:         summary,
+		OnlyStatus:      onlyStatus,
 		Timeout:         timeout,
+		ConnectTimeout:  connectTimeout,
+		IPv4:            forceIPv4,
+		IPv6:            forceIPv6,
+		Cache:           cacheTTL,
 		MaxTime:         maxTime,
 		FollowRedirects: followRedirects,
 		MaxRedirects:    maxRedirects,
+		LocationTrusted: locationTrusted,
+		RedirectScope:   redirectScope,
+		AutoScope:       autoScope,
 		MaxPages:        maxPages,
 		MaxResponseSize: maxResponseSize,
+		MaxHeaderSize:   maxHeaderSize,
+		MaxHeaders:      maxHeaders,
 		ShowThrottle:    showThrottle,
 		Repeat:          repeat,
+		Watch:           watch,
+		Until:           until,
+		WatchMaxErrors:  watchMaxErrors,
+		NotifyWebhook:   notifyWebhook,
 		Color:           colorMode,
 		WriteOut:        writeOut,
 		Include:         include,
 		AllowedHosts:    allowHosts,
+		BlockPrivate:    blockPrivate,
 		Redact:          redactPaths,
 		TableColumns:    tableColumns,
+		Columns:         csvColumns,
 		DumpHeaders:     dumpHeaders,
+		ShowHeaders:     showHeaders,
+		AuditLog:        auditLog,
 		Fail:            fail,
 		RawOutput:       rawOutput,
 		Compact:         compact,
@@ -319,6 +525,7 @@ func getRequestService() *service.RequestService {
 		service.DefaultTokenProviderFactory,
 		service.DefaultHTTPClientFactory,
 	)
+	requestService.RegisterOutputSink("az", blobOutputSink{})
 	return requestService
 }
 
@@ -341,7 +548,7 @@ func buildRequestOptions(method string, url string) (client.RequestOptions, erro
 // It snapshots global flags into a Config (#80), then delegates to the
 // service layer (#42) which receives dependencies via injection (#43).
 func executeRequest(cmd *cobra.Command, method string, url string) error {
-	cfg := snapshotConfig()
+	cfg := requestConfig(cmd)
 	svc := getRequestService()
 
 	// Use command context for cancellation support (Ctrl+C)
@@ -353,5 +560,16 @@ func executeRequest(cmd *cobra.Command, method string, url string) error {
 	return svc.Execute(ctx, cfg, method, url)
 }
 
+// requestConfig snapshots the global flags for a single request, including
+// -o/--output. That flag is registered by the azd extension SDK; its default
+// value keeps the usual stdout or --output-file behavior.
+func requestConfig(cmd *cobra.Command) config.Config {
+	cfg := snapshotConfig()
+	if target, err := cmd.Flags().GetString("output"); err == nil && target != "default" {
+		cfg.OutputTarget = target
+	}
+	return cfg
+}
+
 // Ensure imports are used.
 var _ = auth.DetectScope
@@ -29,6 +29,17 @@ func resetGlobalFlags() {
 	defaults := config.Defaults()
 	scope = ""
 	noAuth = false
+	auxTenants = []string{}
+	tenant = ""
+	authMode = ""
+	basicUser = ""
+	bearerEnv = ""
+	apimKeyEnv = ""
+	apimKeySecret = ""
+	githubAuth = false
+	mergePatch = ""
+	strategicMerge = false
+	baseURL = ""
 	apiVersion = ""
 	clientRequestID = ""
 	urlParams = []string{}
@@ -36,27 +47,75 @@ func resetGlobalFlags() {
 	headerFile = ""
 	data = ""
 	dataFile = ""
+	dataFormat = ""
+	dataRaw = ""
+	dataBinary = ""
+	dataURLEncode = []string{}
+	jsonData = ""
+	templateVars = []string{}
 	query = ""
+	setAzdEnv = []string{}
+	ciOutput = ""
+	ciVars = []string{}
 	formFields = []string{}
 	outputFile = ""
+	outputDir = ""
+	outputMeta = false
+	appendOutput = false
+	storageAccount = ""
+	noClobber = false
+	fileMode = "0600"
+	checksumAlgo = ""
+	verifyChecksum = ""
+	splitPages = false
+	splitItems = false
+	splitName = ""
+	sortKeys = false
+	csvColumns = nil
+	dropNulls = false
+	pipeCommand = ""
 	outputFormat = defaults.OutputFormat
-	verbose = false
+	verbose = 0
 	paginate = false
 	retry = defaults.Retry
+	retryAllMethods = false
+	idempotencyKey = ""
+	hostLimits = []string{}
 	binary = false
+	rawBytes = false
 	insecure = false
+	assumeYes = false
+	pinnedPubKey = ""
 	silent = false
+	summary = false
+	onlyStatus = false
 	timeout = defaults.Timeout
+	connectTimeout = 0
+	forceIPv4 = false
+	forceIPv6 = false
+	cacheTTL = 0
+	watch = 0
+	until = ""
+	watchMaxErrors = 0
+	notifyWebhook = ""
 	maxTime = defaults.MaxTime
 	followRedirects = defaults.FollowRedirects
 	maxRedirects = defaults.MaxRedirects
+	locationTrusted = false
+	redirectScope = defaults.RedirectScope
+	autoScope = defaults.AutoScope
 	maxPages = defaults.MaxPages
 	maxResponseSize = defaults.MaxResponseSize
+	maxHeaderSize = defaults.MaxHeaderSize
+	maxHeaders = defaults.MaxHeaders
 	showThrottle = false
 	repeat = defaults.Repeat
 	writeOut = ""
 	include = false
 	allowHosts = []string{}
+	blockPrivate = false
+	auditLog = ""
+	showHeaders = []string{}
 }
 
 func TestNewRootCmd(t *testing.T) {
@@ -94,6 +153,15 @@ func TestNewRootCmd_SilentFlag(t *testing.T) {
 	assert.Empty(t, flag.Shorthand, "--silent should have no short alias")
 }
 
+func TestNewRootCmd_BodyFormatAlias(t *testing.T) {
+	resetGlobalFlags()
+	defer resetGlobalFlags()
+	cmd := NewRootCmd()
+
+	require.NoError(t, cmd.PersistentFlags().Parse([]string{"--body-format", "yaml"}))
+	assert.Equal(t, "yaml", snapshotConfig().DataFormat, "--body-format should set the same value as --data-format")
+}
+
 func TestSnapshotConfig_Silent(t *testing.T) {
 	resetGlobalFlags()
 	silent = true
@@ -111,8 +179,8 @@ func TestBuildRequestOptions_Headers(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "GET", opts.Method)
 	assert.Equal(t, "https://example.com", opts.URL)
-	assert.Equal(t, "value1", opts.Headers["X-Custom"])
-	assert.Equal(t, "Bearer token", opts.Headers["Authorization"])
+	assert.Equal(t, "value1", opts.Headers.Get("X-Custom"))
+	assert.Equal(t, "Bearer token", opts.Headers.Get("Authorization"))
 }
 
 func TestBuildRequestOptions_InvalidHeader(t *testing.T) {
@@ -290,7 +358,7 @@ func TestBuildRequestOptions_ClientRequestIDSetsHeader(t *testing.T) {
 	opts, err := buildRequestOptions("GET", "https://management.azure.com/subscriptions")
 
 	require.NoError(t, err)
-	assert.Equal(t, "my-correlation-id", opts.Headers[testCRIDHeader])
+	assert.Equal(t, "my-correlation-id", opts.Headers.Get(testCRIDHeader))
 }
 
 func TestBuildRequestOptions_ClientRequestIDOverridesHeaderFlag(t *testing.T) {
@@ -302,7 +370,7 @@ func TestBuildRequestOptions_ClientRequestIDOverridesHeaderFlag(t *testing.T) {
 	opts, err := buildRequestOptions("GET", "https://management.azure.com/subscriptions")
 
 	require.NoError(t, err)
-	assert.Equal(t, "flag-id", opts.Headers[testCRIDHeader])
+	assert.Equal(t, "flag-id", opts.Headers.Get(testCRIDHeader))
 }
 
 func TestBuildRequestOptions_NoClientRequestIDByDefault(t *testing.T) {
@@ -371,7 +439,7 @@ func TestBuildRequestOptions_AllFlags(t *testing.T) {
 	query = "test"
 	outputFile = "/tmp/output.json"
 	outputFormat = "json"
-	verbose = true
+	verbose = 1
 	paginate = true
 	retry = 5
 	binary = false
@@ -387,7 +455,7 @@ func TestBuildRequestOptions_AllFlags(t *testing.T) {
 	assert.Equal(t, "https://example.com?api-version=2024-01-01", opts.URL)
 	assert.Equal(t, "https://test.scope/.default", opts.Scope)
 	assert.True(t, opts.SkipAuth) // Because noAuth = true
-	assert.Equal(t, "value", opts.Headers["X-Test"])
+	assert.Equal(t, "value", opts.Headers.Get("X-Test"))
 	assert.Equal(t, "test", snapshotConfig().Query)
 	assert.Equal(t, "json", opts.Format)
 	assert.Equal(t, "/tmp/output.json", opts.OutputFile)
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// loadProject reads the current project's request directory. It is a package
+// variable so tests can use a temp directory.
+var loadProject = config.LoadProject
+
+// NewRunCommand returns the run subcommand, which sends a saved request or
+// runs a workflow from the project's .azd-rest directory.
+func NewRunCommand() *cobra.Command {
+	var report string
+	cmd := &cobra.Command{
+		Use:   "run [name]",
+		Short: "Run a saved request or workflow from the project's .azd-rest directory",
+		Long: `Run a saved request or workflow by name from the project's .azd-rest
+directory, which is found by walking up from the working directory. Without a
+name, list the saved requests and workflows.
+
+  .azd-rest/
+    requests/<name>.yaml    method, url, headers, body, query, scope
+    workflows/<name>.yaml   steps that run saved or inline requests in order
+    variables.yaml          values for ${NAME} references
+
+Files in subdirectories are named by their path, such as graph/me. Global
+flags apply as for any request and take precedence over the saved values.
+
+A workflow writes one NDJSON result per step and stops at the first step that
+fails or returns a 4xx or 5xx status, unless the step sets continueOnError. A
+step's capture values are JMESPath expressions evaluated against its response;
+later steps reference them as ${name}. A step runs only when its when
+expression over earlier results (steps.<name>.status, .body) is truthy, and
+repeats every interval until its until expression over the response is truthy.
+retry and timeout replace --retry and --timeout for one step. The steps of a
+parallel block run concurrently, at most concurrency at a time. A step's URL,
+headers, and body may reference {{secret "name"}} from the OS keychain or
+{{keyvault "https://<vault>.vault.azure.net/secrets/<name>"}}; values are
+read when the step is sent and redacted from its output. --report junit=<file>
+also writes a workflow's steps as JUnit XML test cases: failed steps as
+failures, and steps skipped by when or not run after a stop as skipped.`,
+		Example: `  # .azd-rest/requests/me.yaml:
+  #   url: https://graph.microsoft.com/v1.0/me
+  azd rest run me --query displayName
+
+  # .azd-rest/workflows/groups.yaml:
+  #   steps:
+  #     - request: me
+  #       capture: {userId: id}
+  #     - url: https://graph.microsoft.com/v1.0/users/${userId}/memberOf
+  azd rest run groups
+
+  # Run a workflow in CI and publish its steps as test results
+  azd rest run groups --report junit=groups-results.xml
+
+  # List saved requests and workflows
+  azd rest run`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			project, err := loadProject()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return project.Names(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := loadProject()
+			if err != nil {
+				return err
+			}
+			if project.Dir == "" {
+				return fmt.Errorf("no %s directory found in the working directory or its parents", config.RequestDirName)
+			}
+			if len(args) == 0 {
+				return writeProject(cmd.OutOrStdout(), project, outputFormat)
+			}
+
+			var opts service.WorkflowOptions
+			if report != "" {
+				if opts.JUnitReport, err = service.ParseReportSpec(report); err != nil {
+					return err
+				}
+			}
+			name := args[0]
+			svc := getRequestService()
+			if _, ok := project.Workflows[name]; ok {
+				return svc.ExecuteWorkflow(commandContext(cmd), snapshotConfig(), project, name, cmd.OutOrStdout(), opts)
+			}
+			req, ok := project.Requests[name]
+			if !ok {
+				return fmt.Errorf("no saved request or workflow named %q in %s", name, project.Dir)
+			}
+			if report != "" {
+				return fmt.Errorf("--report applies to workflows; %s is a saved request", name)
+			}
+			cfg, method, url, err := service.ApplySavedRequest(requestConfig(cmd), req)
+			if err != nil {
+				return err
+			}
+			return svc.Execute(commandContext(cmd), cfg, method, url)
+		},
+	}
+	cmd.Flags().StringVar(&report, "report", "", "Also write a workflow's steps as a test report: junit=<file>")
+	return cmd
+}
+
+// projectEntry is one saved request or workflow in the run listing.
+type projectEntry struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Method      string `json:"method,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Steps       int    `json:"steps,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// writeProject prints one line per saved request and workflow sorted by name,
+// or a JSON array with --format json.
+func writeProject(out io.Writer, project config.Project, format string) error {
+	entries := make([]projectEntry, 0, len(project.Requests)+len(project.Workflows))
+	for _, name := range project.Names() {
+		if wf, ok := project.Workflows[name]; ok {
+			entries = append(entries, projectEntry{Name: name, Kind: "workflow", Steps: len(wf.Steps), Description: wf.Description})
+			continue
+		}
+		req := project.Requests[name]
+		method := strings.ToUpper(req.Method)
+		if method == "" {
+			method = "GET"
+		}
+		entries = append(entries, projectEntry{Name: name, Kind: "request", Method: method, URL: req.URL, Description: req.Description})
+	}
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(out, "No saved requests or workflows in %s\n", project.Dir)
+		return nil
+	}
+	for _, e := range entries {
+		summary := e.Method + " " + e.URL
+		if e.Kind == "workflow" {
+			summary = fmt.Sprintf("workflow, %d step(s)", e.Steps)
+		}
+		if e.Description != "" {
+			summary += " - " + e.Description
+		}
+		fmt.Fprintf(out, "%s: %s\n", e.Name, summary)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useProject makes the run command see project for one test.
+func useProject(t *testing.T, project config.Project) {
+	t.Helper()
+	orig := loadProject
+	loadProject = func() (config.Project, error) { return project, nil }
+	t.Cleanup(func() { loadProject = orig })
+}
+
+// runRunCommand executes azd rest run with args from a fresh root command.
+func runRunCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetGlobalFlags()
+	t.Cleanup(resetGlobalFlags)
+	rootCmd := NewRootCmd()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"run"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestRunCommand_ListsRequestsAndWorkflows(t *testing.T) {
+	useProject(t, config.Project{
+		Dir: "/repo/.azd-rest",
+		Requests: map[string]config.SavedRequest{
+			"me":      {URL: "https://graph.microsoft.com/v1.0/me", Description: "Signed-in user"},
+			"arm/tag": {Method: "patch", URL: "https://management.azure.com/x"},
+		},
+		Workflows: map[string]config.Workflow{"groups": {Steps: make([]config.WorkflowStep, 2)}},
+	})
+
+	out, err := runRunCommand(t)
+	require.NoError(t, err)
+	assert.Equal(t, "arm/tag: PATCH https://management.azure.com/x\n"+
+		"groups: workflow, 2 step(s)\n"+
+		"me: GET https://graph.microsoft.com/v1.0/me - Signed-in user\n", out)
+
+	out, err = runRunCommand(t, "--format", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"kind": "workflow"`)
+	assert.Contains(t, out, `"steps": 2`)
+}
+
+func TestRunCommand_SendsSavedRequest(t *testing.T) {
+	var gotMethod, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotHeader = r.Method, r.Header.Get("X-Team")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"Ada"}`))
+	}))
+	defer server.Close()
+	useProject(t, config.Project{
+		Dir:      "/repo/.azd-rest",
+		Requests: map[string]config.SavedRequest{"me": {Method: "post", URL: server.URL, Headers: map[string]string{"X-Team": "platform"}, Query: "name"}},
+	})
+
+	outPath := filepath.Join(t.TempDir(), "out.json")
+	_, err := runRunCommand(t, "me", "--no-auth", "--output-file", outPath, "-H", "X-Team: ops")
+	require.NoError(t, err)
+	assert.Equal(t, "POST", gotMethod)
+	assert.Equal(t, "ops", gotHeader, "-H overrides the saved header")
+	written, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "Ada")
+}
+
+func TestRunCommand_WorkflowJUnitReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	useProject(t, config.Project{
+		Dir:      "/repo/.azd-rest",
+		Requests: map[string]config.SavedRequest{"me": {URL: server.URL}},
+		Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+			{Request: "me"},
+		}}},
+	})
+
+	report := filepath.Join(t.TempDir(), "wf.xml")
+	_, err := runRunCommand(t, "wf", "--no-auth", "--report", "junit="+report)
+	require.NoError(t, err)
+	written, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), `<testcase name="1" classname="azd-rest.workflow.wf"`)
+
+	_, err = runRunCommand(t, "wf", "--no-auth", "--report", "xml="+report)
+	assert.ErrorContains(t, err, `unsupported format "xml"`)
+	_, err = runRunCommand(t, "me", "--no-auth", "--report", "junit="+report)
+	assert.ErrorContains(t, err, "--report applies to workflows")
+}
+
+func TestRunCommand_Errors(t *testing.T) {
+	useProject(t, config.Project{})
+	_, err := runRunCommand(t, "me")
+	assert.ErrorContains(t, err, "no .azd-rest directory found")
+
+	useProject(t, config.Project{Dir: "/repo/.azd-rest"})
+	_, err = runRunCommand(t, "me")
+	assert.ErrorContains(t, err, `no saved request or workflow named "me"`)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/sas"
+	"github.com/jongio/azd-rest/src/internal/secrets"
+	"github.com/jongio/azd-rest/src/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// sasResult is the JSON payload emitted by the sas command with --format json.
+type sasResult struct {
+	Token     string    `json:"token"`
+	Resource  string    `json:"resource"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewSASCommand returns the sas subcommand, which generates a shared access
+// signature token for a Service Bus or Event Hubs resource.
+func NewSASCommand() *cobra.Command {
+	var (
+		profile          string
+		connectionString string
+		expiry           time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "sas [resource-url]",
+		Short: "Generate a Service Bus or Event Hubs SAS token",
+		Long: `Generate a shared access signature (SAS) token for a Service Bus or Event Hubs
+resource from a connection string, for namespaces that disable Microsoft Entra
+authentication for data operations.
+
+The connection string comes from a SAS profile in the config file (--profile)
+or from --connection-string, which accepts @secret:<name> to read it from the
+OS keychain. The token is signed for resource-url, or for the entity (or
+namespace) in the connection string when no URL is given.
+
+To sign requests automatically, use --auth sas:<profile> on any request command.
+
+Examples:
+  # Token for the entity named in a stored connection string
+  azd rest sas --connection-string @secret:orders-sb
+
+  # Token for a specific queue from a config profile, valid for 15 minutes
+  azd rest sas https://contoso.servicebus.windows.net/orders --profile orders --expiry 15m`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := sasConnectionString(profile, connectionString)
+			if err != nil {
+				return err
+			}
+			if expiry <= 0 {
+				return fmt.Errorf("--expiry must be positive, got %s", expiry)
+			}
+			resource := cs.ResourceURI()
+			if len(args) == 1 {
+				if resource, err = cs.ResourceForURL(args[0]); err != nil {
+					return err
+				}
+			}
+			expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+			res := sasResult{Token: cs.Token(resource, expiresAt), Resource: resource, ExpiresAt: expiresAt}
+			return writeSASResult(cmd.OutOrStdout(), res, outputFormat)
+		},
+	}
+	cmd.Flags().StringVar(&profile, "profile", "", "SAS profile from the config file")
+	cmd.Flags().StringVar(&connectionString, "connection-string", "", "Connection string, or @secret:<name> to read it from the OS keychain")
+	cmd.Flags().DurationVar(&expiry, "expiry", sas.DefaultExpiry, "Token lifetime")
+	cmd.MarkFlagsMutuallyExclusive("profile", "connection-string")
+	return cmd
+}
+
+// sasConnectionString resolves the connection string from exactly one of a
+// config profile or the --connection-string value.
+func sasConnectionString(profile, connectionString string) (sas.ConnectionString, error) {
+	switch {
+	case profile != "":
+		return service.LoadSASConnectionString(profile)
+	case connectionString == "":
+		return sas.ConnectionString{}, fmt.Errorf("either --profile or --connection-string is required")
+	case strings.HasPrefix(connectionString, "@secret:"):
+		value, err := secrets.Get(strings.TrimPrefix(connectionString, "@secret:"))
+		if err != nil {
+			return sas.ConnectionString{}, err
+		}
+		connectionString = value
+	}
+	return sas.ParseConnectionString(connectionString)
+}
+
+// writeSASResult prints the bare token, or the token with its resource and
+// expiry as JSON with --format json.
+func writeSASResult(out io.Writer, res sasResult, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(res)
+	}
+	_, err := fmt.Fprintln(out, res.Token)
+	return err
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+const testSASConnectionString = "Endpoint=sb://contoso.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=abc123;EntityPath=orders"
+
+func runSASCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewSASCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestSASCommand_JSON(t *testing.T) {
+	outputFormat = "json"
+	defer func() { outputFormat = "" }()
+
+	out, err := runSASCommand(t, "--connection-string", testSASConnectionString)
+	require.NoError(t, err)
+
+	var res sasResult
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, "https://contoso.servicebus.windows.net/orders", res.Resource)
+	assert.Contains(t, res.Token, "SharedAccessSignature sr=https%3A%2F%2Fcontoso.servicebus.windows.net%2Forders&")
+}
+
+func TestSASCommand_SecretConnectionString(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, keyring.Set("azd-rest", "orders-sb", testSASConnectionString))
+	outputFormat = ""
+
+	out, err := runSASCommand(t, "https://contoso.servicebus.windows.net/orders/messages", "--connection-string", "@secret:orders-sb")
+	require.NoError(t, err)
+	assert.Contains(t, out, "sr=https%3A%2F%2Fcontoso.servicebus.windows.net%2Forders%2Fmessages&")
+}
+
+func TestSASCommand_Errors(t *testing.T) {
+	_, err := runSASCommand(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--profile or --connection-string")
+
+	_, err = runSASCommand(t, "https://other.example.com/q", "--connection-string", testSASConnectionString)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+
+	_, err = runSASCommand(t, "--connection-string", testSASConnectionString, "--expiry", "0s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--expiry")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -96,7 +97,7 @@ func resolveScope(rawURL, scopeOverride string, noAuth bool, headerArgs []string
 		return res, nil
 	}
 
-	detected, err := auth.DetectScope(rawURL)
+	detected, err := client.DetectScope(rawURL)
 	if err != nil {
 		return scopeResult{}, fmt.Errorf("failed to detect scope: %w", err)
 	}
@@ -113,29 +114,27 @@ func resolveScope(rawURL, scopeOverride string, noAuth bool, headerArgs []string
 	return res, nil
 }
 
-// parseHeaderArgs converts repeatable Key:Value header flags into a map, matching
+// parseHeaderArgs converts repeatable Key:Value header flags into headers, matching
 // the parsing the request builder uses so the preview honors an Authorization header.
-func parseHeaderArgs(headerArgs []string) (map[string]string, error) {
-	headers := make(map[string]string)
+func parseHeaderArgs(headerArgs []string) (http.Header, error) {
+	headers := make(http.Header)
 	for _, h := range headerArgs {
 		parts := strings.SplitN(h, ":", 2)
 		if len(parts) != 2 {
 			return nil, fmt.Errorf("invalid header format: %s (expected Key:Value)", h)
 		}
-		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
 	}
 	return headers, nil
 }
 
 // skipReason explains why authentication is skipped for a URL.
-func skipReason(rawURL string, headers map[string]string, noAuth bool) string {
+func skipReason(rawURL string, headers http.Header, noAuth bool) string {
 	if noAuth {
 		return "authentication skipped (--no-auth)"
 	}
-	for key := range headers {
-		if strings.EqualFold(key, "authorization") {
-			return "authentication skipped (Authorization header supplied)"
-		}
+	if len(headers.Values("Authorization")) > 0 {
+		return "authentication skipped (Authorization header supplied)"
 	}
 	if strings.HasPrefix(strings.ToLower(rawURL), "http://") {
 		return "authentication skipped (non-HTTPS URL)"
@@ -164,6 +163,8 @@ func friendlyService(scope string) string {
 		"https://rest.media.azure.net/.default":              "Azure Media Services",
 		"https://servicebus.azure.net/.default":              "Azure Service Bus",
 		"https://eventhubs.azure.net/.default":               "Azure Event Hubs",
+		"https://monitor.azure.com/.default":                 "Azure Monitor Logs Ingestion",
+		"https://metrics.monitor.azure.com/.default":         "Azure Monitor Metrics",
 	}
 	if name, ok := names[scope]; ok {
 		return name
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// NewSecretCommand returns the secret subcommand, which manages API keys stored
+// in the OS credential manager for use in @secret:<name> header values.
+func NewSecretCommand() *cobra.Command {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage API keys stored in the OS keychain",
+		Long: `Manage API keys stored in the OS credential manager (Windows Credential
+Manager, macOS Keychain, or the Secret Service on Linux).
+
+A stored secret is referenced in a header value as @secret:<name> and is read
+from the keychain when the request is sent, so the key never appears in shell
+history or config files.
+
+Examples:
+  # Store a key (prompts without echo, or reads stdin when piped)
+  azd rest secret set myapi
+
+  # Use it in a request
+  azd rest get https://api.example.com/items -H "x-api-key: @secret:myapi"
+
+  # List stored names
+  azd rest secret list`,
+	}
+	secretCmd.AddCommand(
+		newSecretSetCommand(),
+		newSecretGetCommand(),
+		newSecretListCommand(),
+		newSecretDeleteCommand(),
+	)
+	return secretCmd
+}
+
+func newSecretSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret, reading the value from a prompt or stdin",
+		Long: `Store a secret under <name>. The value is never taken from the command line:
+on a terminal it is prompted for without echo, otherwise it is read from stdin.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := secrets.ValidateName(name); err != nil {
+				return err
+			}
+			value, err := readSecretValue(cmd.InOrStdin(), cmd.ErrOrStderr(), name)
+			if err != nil {
+				return err
+			}
+			if err := secrets.Set(name, value); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Stored secret %s. Reference it as @secret:%s\n", name, name)
+			return nil
+		},
+	}
+}
+
+func newSecretGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Print a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := secrets.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+func newSecretListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the names of stored secrets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			names, err := secrets.List()
+			if err != nil {
+				return err
+			}
+			return writeSecretNames(cmd.OutOrStdout(), names, outputFormat)
+		},
+	}
+}
+
+func newSecretDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := secrets.Delete(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Deleted secret %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// readSecretValue reads a secret value without echo when in is a terminal and
+// otherwise reads the first line of in. Surrounding whitespace, including the
+// trailing newline, is trimmed.
+func readSecretValue(in io.Reader, prompt io.Writer, name string) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(prompt, "Value for %s: ", name)
+		raw, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret value: %w", err)
+		}
+		return strings.TrimSpace(string(raw)), nil
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read secret value from stdin: %w", err)
+	}
+	value := strings.TrimSpace(line)
+	if value == "" {
+		return "", fmt.Errorf("no secret value provided on stdin")
+	}
+	return value, nil
+}
+
+// writeSecretNames prints one name per line, or a JSON array with --format json.
+func writeSecretNames(out io.Writer, names []string, format string) error {
+	if strings.EqualFold(format, "json") {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(names)
+	}
+	for _, name := range names {
+		fmt.Fprintln(out, name)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+// runSecretCommand executes the secret command tree with args and stdin.
+func runSecretCommand(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewSecretCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestSecretCommand_SetGetListDelete(t *testing.T) {
+	keyring.MockInit()
+	outputFormat = ""
+	defer func() { outputFormat = "" }()
+
+	_, err := runSecretCommand(t, "k-123\n", "set", "myapi")
+	require.NoError(t, err)
+
+	out, err := runSecretCommand(t, "", "get", "myapi")
+	require.NoError(t, err)
+	assert.Equal(t, "k-123\n", out)
+
+	out, err = runSecretCommand(t, "", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "myapi\n", out)
+
+	outputFormat = "json"
+	out, err = runSecretCommand(t, "", "list")
+	require.NoError(t, err)
+	assert.JSONEq(t, `["myapi"]`, out)
+
+	_, err = runSecretCommand(t, "", "delete", "myapi")
+	require.NoError(t, err)
+	_, err = runSecretCommand(t, "", "get", "myapi")
+	assert.Error(t, err)
+}
+
+func TestSecretCommand_SetRejectsEmptyValue(t *testing.T) {
+	keyring.MockInit()
+	_, err := runSecretCommand(t, "\n", "set", "myapi")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no secret value")
+}
+
+func TestSecretCommand_SetRejectsInvalidName(t *testing.T) {
+	keyring.MockInit()
+	_, err := runSecretCommand(t, "v\n", "set", "bad name")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid secret name")
+}
@@ -11,6 +11,15 @@ import "time"
 type Config struct {
 	Scope           string
 	NoAuth          bool
+	Tenant          string
+	AuxTenants      []string
+	Auth            string
+	User            string
+	BearerEnv       string
+	APIMKeyEnv      string
+	APIMKeySecret   string
+	GitHubAuth      bool
+	BaseURL         string
 	APIVersion      string
 	ClientRequestID string
 	URLParams       []string
@@ -18,35 +27,86 @@ type Config struct {
 	HeaderFile      string
 	Data            string
 	DataFile        string
+	DataRaw         string
+	DataBinary      string
+	DataURLEncode   []string
+	JSONData        string
+	TemplateVars    []string
 	DataFormat      string
+	MergePatch      string
+	Strategic       bool
 	Query           string
+	SetAzdEnv       []string
+	CIOutput        string
+	CIVars          []string
 	FormFields      []string
 	JSONFields      []string
 	JSONFieldsRaw   []string
 	OutputFile      string
+	OutputDir       string
+	OutputMeta      bool
+	Append          bool
+	StorageAccount  string
+	NoClobber       bool
+	FileMode        string
+	SplitPages      bool
+	SplitItems      bool
+	SplitName       string
+	OutputTarget    string
+	Pipe            string
 	OutputFormat    string
 	Verbose         bool
+	VerboseLevel    int
 	Flatten         bool
+	SortKeys        bool
+	DropNulls       bool
 	Paginate        bool
 	Retry           int
+	RetryAllMethods bool
+	IdempotencyKey  string
+	HostLimits      []string
 	Binary          bool
+	Checksum        string
+	VerifyChecksum  string
+	RawBytes        bool
 	Insecure        bool
+	Yes             bool
+	PinnedPubKey    string
 	Silent          bool
+	Summary         bool
+	OnlyStatus      bool
 	Timeout         time.Duration
+	ConnectTimeout  time.Duration
+	IPv4            bool
+	IPv6            bool
+	Cache           time.Duration
 	MaxTime         time.Duration
 	FollowRedirects bool
 	MaxRedirects    int
+	LocationTrusted bool
+	RedirectScope   string
+	AutoScope       string
 	MaxPages        int
 	MaxResponseSize int64
+	MaxHeaderSize   int64
+	MaxHeaders      int
 	ShowThrottle    bool
 	Repeat          int
+	Watch           time.Duration
+	Until           string
+	WatchMaxErrors  int
+	NotifyWebhook   string
 	Color           string
 	WriteOut        string
 	Include         bool
 	AllowedHosts    []string
+	BlockPrivate    bool
 	Redact          []string
 	TableColumns    []string
+	Columns         []string
 	DumpHeaders     string
+	ShowHeaders     []string
+	AuditLog        string
 	Fail            bool
 	RawOutput       bool
 	Compact         bool
@@ -60,8 +120,12 @@ func Defaults() Config {
 		Timeout:         30 * time.Second,
 		FollowRedirects: true,
 		MaxRedirects:    10,
+		RedirectScope:   "refuse",
+		AutoScope:       "suggest",
 		MaxPages:        100,
 		MaxResponseSize: 100 * 1024 * 1024, // 100MB
+		MaxHeaderSize:   256 * 1024,        // 256KB
+		MaxHeaders:      200,
 		Repeat:          1,
 		Color:           "auto",
 	}
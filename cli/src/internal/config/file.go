@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names an explicit configuration file, overriding the default location.
+const FileEnv = "AZD_REST_CONFIG"
+
+// File is the optional on-disk configuration for azd rest. Unlike Config, which
+// holds per-invocation flag values, File holds settings that persist across
+// invocations, such as named auth profiles.
+type File struct {
+	// OAuth2 maps a profile name to an OAuth2 client-credentials provider,
+	// selected with --auth oauth2:<profile>.
+	OAuth2 map[string]OAuth2Profile `yaml:"oauth2,omitempty"`
+	// SAS maps a profile name to a Service Bus or Event Hubs connection string
+	// source, selected with --auth sas:<profile>.
+	SAS map[string]SASProfile `yaml:"sas,omitempty"`
+	// Exec maps a name to an external credential plugin, selected with
+	// --auth exec:<name>.
+	Exec map[string]ExecCredential `yaml:"exec,omitempty"`
+	// APIM maps a host pattern, such as "*.azure-api.net", to the API
+	// Management subscription key sent to matching hosts.
+	APIM map[string]APIMKey `yaml:"apim,omitempty"`
+	// BlockPrivateNetworks turns on --block-private-networks for every
+	// request, so CI machines can enforce an egress policy without changing
+	// each script.
+	BlockPrivateNetworks bool `yaml:"blockPrivateNetworks,omitempty"`
+	// Transport tunes the HTTP connection pool shared by every request in a
+	// run, such as the pages of --paginate and the lines of bulk.
+	Transport TransportSettings `yaml:"transport,omitempty"`
+	// Headers are sent with every request unless a --header-file or --header
+	// sets the same name.
+	Headers HeaderSettings `yaml:"headers,omitempty"`
+	// BaseURL is the default for --base-url.
+	BaseURL string `yaml:"baseUrl,omitempty"`
+}
+
+// HeaderSettings holds default request headers. Default applies to every
+// request; Hosts maps a host pattern, such as "graph.microsoft.com" or
+// "*.azure-api.net", to headers for matching hosts only, which override
+// Default. Values may be @secret:<name> references.
+type HeaderSettings struct {
+	Default map[string]string            `yaml:"default,omitempty"`
+	Hosts   map[string]map[string]string `yaml:"hosts,omitempty"`
+}
+
+// TransportSettings tunes the shared HTTP connection pool. Zero values keep
+// the built-in defaults; durations use Go syntax such as "90s".
+type TransportSettings struct {
+	MaxIdleConns        int           `yaml:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost,omitempty"`
+	MaxConnsPerHost     int           `yaml:"maxConnsPerHost,omitempty"`
+	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout,omitempty"`
+	TLSHandshakeTimeout time.Duration `yaml:"tlsHandshakeTimeout,omitempty"`
+}
+
+// OAuth2Profile describes an OAuth2 client-credentials token endpoint for a
+// non-Entra API. The client ID may be inline or read from an environment
+// variable; the client secret is never stored inline and is read from an
+// environment variable or the OS keychain.
+type OAuth2Profile struct {
+	TokenURL           string   `yaml:"tokenUrl"`
+	ClientID           string   `yaml:"clientId,omitempty"`
+	ClientIDEnv        string   `yaml:"clientIdEnv,omitempty"`
+	ClientSecretEnv    string   `yaml:"clientSecretEnv,omitempty"`
+	ClientSecretSecret string   `yaml:"clientSecretSecret,omitempty"`
+	Audience           string   `yaml:"audience,omitempty"`
+	Scopes             []string `yaml:"scopes,omitempty"`
+}
+
+// SASProfile names where a Service Bus or Event Hubs connection string is
+// read from. Like an OAuth2 client secret, the connection string is never
+// stored inline: it comes from an environment variable or the OS keychain.
+type SASProfile struct {
+	ConnectionStringEnv    string `yaml:"connectionStringEnv,omitempty"`
+	ConnectionStringSecret string `yaml:"connectionStringSecret,omitempty"`
+}
+
+// APIMKey names where an API Management subscription key is read from. Like
+// an OAuth2 client secret, the key is never stored inline: it comes from an
+// environment variable or the OS keychain. Header overrides the header name
+// for an API that renames Ocp-Apim-Subscription-Key.
+type APIMKey struct {
+	KeyEnv    string `yaml:"keyEnv,omitempty"`
+	KeySecret string `yaml:"keySecret,omitempty"`
+	Header    string `yaml:"header,omitempty"`
+}
+
+// ExecCredential describes an external credential plugin: a program that
+// reads a token request as JSON on stdin and writes a token as JSON on
+// stdout. Env values are added to the plugin's environment, and Timeout
+// bounds each run.
+type ExecCredential struct {
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	Timeout time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// Dir returns the azd rest configuration directory: <azd config dir>/rest.
+// The azd config dir is $AZD_CONFIG_DIR when set, otherwise ~/.azd.
+func Dir() (string, error) {
+	if dir := os.Getenv("AZD_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "rest"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".azd", "rest"), nil
+}
+
+// FilePath returns the configuration file path: $AZD_REST_CONFIG when set,
+// otherwise config.yaml in Dir.
+func FilePath() (string, error) {
+	if path := os.Getenv(FileEnv); path != "" {
+		return path, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// LoadFile reads the configuration file. A missing file is not an error and
+// yields an empty File, so the file stays optional.
+func LoadFile() (File, error) {
+	path, err := FilePath()
+	if err != nil {
+		return File{}, err
+	}
+	return LoadFileFrom(path)
+}
+
+// LoadFileFrom reads the configuration file at path. A missing file yields an
+// empty File.
+func LoadFileFrom(path string) (File, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- Config path comes from the user's environment.
+	if errors.Is(err, os.ErrNotExist) {
+		return File{}, nil
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return File{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileFrom_MissingFileIsEmpty(t *testing.T) {
+	f, err := LoadFileFrom(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, f.OAuth2)
+}
+
+func TestLoadFileFrom_OAuth2Profiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`oauth2:
+  widgets:
+    tokenUrl: https://login.example.com/oauth/token
+    clientId: abc
+    clientSecretEnv: WIDGETS_SECRET
+    audience: https://api.example.com
+    scopes: [read, write]
+`), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	p := f.OAuth2["widgets"]
+	assert.Equal(t, "https://login.example.com/oauth/token", p.TokenURL)
+	assert.Equal(t, "abc", p.ClientID)
+	assert.Equal(t, "WIDGETS_SECRET", p.ClientSecretEnv)
+	assert.Equal(t, "https://api.example.com", p.Audience)
+	assert.Equal(t, []string{"read", "write"}, p.Scopes)
+}
+
+func TestLoadFileFrom_InvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("oauth2: [unclosed"), 0o600))
+
+	_, err := LoadFileFrom(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+}
+
+func TestFilePath_Overrides(t *testing.T) {
+	t.Setenv(FileEnv, "/tmp/custom.yaml")
+	path, err := FilePath()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.yaml", path)
+
+	t.Setenv(FileEnv, "")
+	t.Setenv("AZD_CONFIG_DIR", "/tmp/azd")
+	path, err = FilePath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/azd", "rest", "config.yaml"), path)
+}
+
+func TestLoadFileFrom_SASProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`sas:
+  orders:
+    connectionStringEnv: ORDERS_SB
+    connectionStringSecret: orders-sb
+`), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, SASProfile{ConnectionStringEnv: "ORDERS_SB", ConnectionStringSecret: "orders-sb"}, f.SAS["orders"])
+}
+
+func TestLoadFileFrom_BlockPrivateNetworks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("blockPrivateNetworks: true\n"), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.True(t, f.BlockPrivateNetworks)
+}
+
+func TestLoadFileFrom_Transport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`transport:
+  maxIdleConnsPerHost: 32
+  maxConnsPerHost: 8
+  idleConnTimeout: 2m
+  tlsHandshakeTimeout: 5s
+`), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, TransportSettings{
+		MaxIdleConnsPerHost: 32,
+		MaxConnsPerHost:     8,
+		IdleConnTimeout:     2 * time.Minute,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}, f.Transport)
+}
+
+func TestLoadFileFrom_Headers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`headers:
+  default:
+    X-Team: platform
+  hosts:
+    graph.microsoft.com:
+      ConsistencyLevel: eventual
+    "*.azure-api.net":
+      Ocp-Apim-Subscription-Key: "@secret:apim"
+`), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Team": "platform"}, f.Headers.Default)
+	assert.Equal(t, "eventual", f.Headers.Hosts["graph.microsoft.com"]["ConsistencyLevel"])
+	assert.Equal(t, "@secret:apim", f.Headers.Hosts["*.azure-api.net"]["Ocp-Apim-Subscription-Key"])
+}
+
+func TestLoadFileFrom_APIM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`apim:
+  "*.azure-api.net":
+    keyEnv: APIM_KEY
+  orders.contoso.com:
+    keySecret: orders-apim
+    header: X-Orders-Key
+`), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]APIMKey{
+		"*.azure-api.net":    {KeyEnv: "APIM_KEY"},
+		"orders.contoso.com": {KeySecret: "orders-apim", Header: "X-Orders-Key"},
+	}, f.APIM)
+}
+
+func TestLoadFileFrom_BaseURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("baseUrl: https://graph.microsoft.com\n"), 0o600))
+
+	f, err := LoadFileFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://graph.microsoft.com", f.BaseURL)
+}
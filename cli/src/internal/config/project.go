@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RequestDirName is the per-project directory of saved requests, workflows,
+// and variables. It is found by walking up from the working directory, the
+// way azd finds azure.yaml.
+const RequestDirName = ".azd-rest"
+
+// Layout of the request directory.
+const (
+	requestsDirName  = "requests"
+	workflowsDirName = "workflows"
+)
+
+// variablesFileNames are the names tried, in order, for the project's
+// variables file.
+var variablesFileNames = []string{"variables.yaml", "variables.yml"}
+
+// savedNamePattern matches one path segment of a saved request or workflow
+// name. Names in subdirectories are joined with "/", as in graph/me.
+var savedNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SavedRequest is one file under .azd-rest/requests. Body may be any YAML
+// value: a string is sent as-is and anything else is sent as JSON. Query and
+// Scope apply when --query and --scope are not given.
+type SavedRequest struct {
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Method      string            `yaml:"method,omitempty" json:"method,omitempty"`
+	URL         string            `yaml:"url,omitempty" json:"url,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body        any               `yaml:"body,omitempty" json:"body,omitempty"`
+	Query       string            `yaml:"query,omitempty" json:"query,omitempty"`
+	Scope       string            `yaml:"scope,omitempty" json:"scope,omitempty"`
+}
+
+// Workflow is one file under .azd-rest/workflows: steps run in order.
+type Workflow struct {
+	Description string         `yaml:"description,omitempty"`
+	Steps       []WorkflowStep `yaml:"steps"`
+}
+
+// WorkflowStep runs the saved request named by Request, or the request given
+// inline. Capture maps a variable name to a JMESPath expression evaluated
+// against the response, and later steps reference the value as ${name}.
+//
+// When is a JMESPath expression over the earlier steps' results; the step is
+// skipped unless it is truthy. Until repeats the request every Interval, up
+// to MaxAttempts times, until the expression over the response is truthy.
+// Retry and Timeout replace --retry and --timeout for the step, and
+// ContinueOnError keeps a failed step from stopping the workflow.
+//
+// A step with Parallel is a block of independent steps sent concurrently, at
+// most Concurrency at a time, instead of a request of its own.
+type WorkflowStep struct {
+	Name            string `yaml:"name,omitempty"`
+	Request         string `yaml:"request,omitempty"`
+	SavedRequest    `yaml:",inline"`
+	Capture         map[string]string `yaml:"capture,omitempty"`
+	When            string            `yaml:"when,omitempty"`
+	Until           string            `yaml:"until,omitempty"`
+	Interval        time.Duration     `yaml:"interval,omitempty"`
+	MaxAttempts     int               `yaml:"maxAttempts,omitempty"`
+	Retry           *int              `yaml:"retry,omitempty"`
+	Timeout         time.Duration     `yaml:"timeout,omitempty"`
+	ContinueOnError bool              `yaml:"continueOnError,omitempty"`
+	Parallel        []WorkflowStep    `yaml:"parallel,omitempty"`
+	Concurrency     int               `yaml:"concurrency,omitempty"`
+}
+
+// Project is the content of a request directory. Dir is the directory, or
+// empty when none was found.
+type Project struct {
+	Dir           string
+	Requests      map[string]SavedRequest
+	Workflows     map[string]Workflow
+	Variables     map[string]string
+	VariablesPath string
+}
+
+// Names returns the sorted names of the project's saved requests and
+// workflows.
+func (p Project) Names() []string {
+	names := make([]string, 0, len(p.Requests)+len(p.Workflows))
+	for name := range p.Requests {
+		names = append(names, name)
+	}
+	for name := range p.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindRequestDir returns the request directory for the current project: the
+// one in $AZD_REST_PROJECT_DIR when that is set, otherwise the nearest one
+// found by walking up from the working directory. It returns "" when there is
+// none.
+func FindRequestDir() string {
+	if projectDir := os.Getenv(ProjectDirEnv); projectDir != "" {
+		dir := filepath.Join(projectDir, RequestDirName)
+		if isDir(dir) {
+			return dir
+		}
+		return ""
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		dir := filepath.Join(wd, RequestDirName)
+		if isDir(dir) {
+			return dir
+		}
+		parent := filepath.Dir(wd)
+		if parent == wd {
+			return ""
+		}
+		wd = parent
+	}
+}
+
+// LoadProject reads the current project's request directory. A project
+// without one yields an empty Project.
+func LoadProject() (Project, error) {
+	dir := FindRequestDir()
+	if dir == "" {
+		return Project{}, nil
+	}
+	return LoadProjectFrom(dir)
+}
+
+// LoadProjectFrom reads the saved requests, workflows, and variables in the
+// request directory dir. Files in subdirectories of requests and workflows are
+// named by their relative path without the extension, such as graph/me.
+func LoadProjectFrom(dir string) (Project, error) {
+	p := Project{
+		Dir:       dir,
+		Requests:  map[string]SavedRequest{},
+		Workflows: map[string]Workflow{},
+	}
+	err := loadSavedFiles(filepath.Join(dir, requestsDirName), func(name, path string, raw []byte) error {
+		var req SavedRequest
+		if err := yaml.Unmarshal(raw, &req); err != nil {
+			return fmt.Errorf("failed to parse saved request %s: %w", path, err)
+		}
+		if strings.TrimSpace(req.URL) == "" {
+			return fmt.Errorf("saved request %s: url is required", path)
+		}
+		p.Requests[name] = req
+		return nil
+	})
+	if err != nil {
+		return Project{}, err
+	}
+	err = loadSavedFiles(filepath.Join(dir, workflowsDirName), func(name, path string, raw []byte) error {
+		if _, ok := p.Requests[name]; ok {
+			return fmt.Errorf("workflow %s: %q is also the name of a saved request", path, name)
+		}
+		var wf Workflow
+		if err := yaml.Unmarshal(raw, &wf); err != nil {
+			return fmt.Errorf("failed to parse workflow %s: %w", path, err)
+		}
+		if len(wf.Steps) == 0 {
+			return fmt.Errorf("workflow %s has no steps", path)
+		}
+		p.Workflows[name] = wf
+		return nil
+	})
+	if err != nil {
+		return Project{}, err
+	}
+
+	vars, err := loadProjectVarsFrom(dir)
+	if err != nil {
+		return Project{}, err
+	}
+	p.Variables, p.VariablesPath = vars.Values, vars.Path
+	return p, nil
+}
+
+// LoadProjectVars reads only the variables file of the current project's
+// request directory, without parsing its saved requests and workflows, so a
+// broken saved file cannot fail unrelated requests. A project without a
+// request directory or variables file yields empty Vars.
+func LoadProjectVars() (Vars, error) {
+	dir := FindRequestDir()
+	if dir == "" {
+		return Vars{}, nil
+	}
+	return loadProjectVarsFrom(dir)
+}
+
+// loadProjectVarsFrom reads the first variables file found in dir.
+func loadProjectVarsFrom(dir string) (Vars, error) {
+	for _, name := range variablesFileNames {
+		vars, err := LoadVarsFrom(filepath.Join(dir, name))
+		if err != nil || vars.Path != "" {
+			return vars, err
+		}
+	}
+	return Vars{}, nil
+}
+
+// loadSavedFiles calls add with the name, path, and content of each YAML file
+// under dir. A missing dir is not an error.
+func loadSavedFiles(dir string, add func(name, path string, raw []byte) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, strings.TrimSuffix(path, ext))
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		for _, segment := range strings.Split(name, "/") {
+			if !savedNamePattern.MatchString(segment) {
+				return fmt.Errorf("invalid name %q for %s (use letters, digits, '.', '-', and '_')", name, path)
+			}
+		}
+		raw, err := os.ReadFile(path) // #nosec G304 -- Path is under the project's request directory.
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return add(name, path, raw)
+	})
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProjectFile writes content to rel under dir, creating directories.
+func writeProjectFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestLoadProjectFrom(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), RequestDirName)
+	writeProjectFile(t, dir, "requests/me.yaml", "url: https://graph.microsoft.com/v1.0/me\nquery: displayName\n")
+	writeProjectFile(t, dir, "requests/arm/tag.yml", `method: patch
+url: https://management.azure.com/subscriptions//resourceGroups/rg?api-version=2021-04-01
+headers:
+  If-Match: "*"
+body:
+  tags: {env: dev}
+`)
+	writeProjectFile(t, dir, "requests/README.md", "ignored")
+	writeProjectFile(t, dir, "workflows/groups.yaml", `description: My groups
+steps:
+  - request: me
+    capture: {userId: id}
+  - name: groups
+    url: https://graph.microsoft.com/v1.0/users/${userId}/memberOf
+`)
+	writeProjectFile(t, dir, "variables.yaml", "HOST: api.example.com\nPORT: 8443\n")
+
+	p, err := LoadProjectFrom(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, p.Dir)
+	assert.Equal(t, []string{"arm/tag", "groups", "me"}, p.Names())
+	assert.Equal(t, "displayName", p.Requests["me"].Query)
+	assert.Equal(t, "patch", p.Requests["arm/tag"].Method)
+	assert.Equal(t, "*", p.Requests["arm/tag"].Headers["If-Match"])
+	assert.Equal(t, map[string]any{"tags": map[string]any{"env": "dev"}}, p.Requests["arm/tag"].Body)
+
+	wf := p.Workflows["groups"]
+	require.Len(t, wf.Steps, 2)
+	assert.Equal(t, "My groups", wf.Description)
+	assert.Equal(t, "me", wf.Steps[0].Request)
+	assert.Equal(t, map[string]string{"userId": "id"}, wf.Steps[0].Capture)
+	assert.Equal(t, "groups", wf.Steps[1].Name)
+	assert.Equal(t, "https://graph.microsoft.com/v1.0/users/${userId}/memberOf", wf.Steps[1].URL)
+
+	assert.Equal(t, map[string]string{"HOST": "api.example.com", "PORT": "8443"}, p.Variables)
+	assert.Equal(t, filepath.Join(dir, "variables.yaml"), p.VariablesPath)
+}
+
+func TestLoadProjectFrom_Errors(t *testing.T) {
+	tests := []struct {
+		name, rel, content, want string
+	}{
+		{"missing url", "requests/me.yaml", "method: GET\n", "url is required"},
+		{"bad name", "requests/my request.yaml", "url: https://example.com\n", "invalid name"},
+		{"no steps", "workflows/empty.yaml", "description: nothing\n", "has no steps"},
+		{"name clash", "workflows/me.yaml", "steps: [{url: https://example.com}]\n", "also the name of a saved request"},
+		{"bad yaml", "workflows/bad.yaml", "steps: [\n", "failed to parse workflow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeProjectFile(t, dir, "requests/me.yaml", "url: https://example.com/me\n")
+			writeProjectFile(t, dir, tt.rel, tt.content)
+			_, err := LoadProjectFrom(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestFindRequestDir_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, RequestDirName), 0o700))
+	sub := filepath.Join(root, "src", "app")
+	require.NoError(t, os.MkdirAll(sub, 0o700))
+	t.Setenv(ProjectDirEnv, "")
+	t.Chdir(sub)
+
+	got, err := filepath.EvalSymlinks(FindRequestDir())
+	require.NoError(t, err)
+	want, err := filepath.EvalSymlinks(filepath.Join(root, RequestDirName))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestFindRequestDir_ProjectDirEnv(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ProjectDirEnv, root)
+	assert.Empty(t, FindRequestDir())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, RequestDirName), 0o700))
+	assert.Equal(t, filepath.Join(root, RequestDirName), FindRequestDir())
+}
+
+func TestLoadProject_None(t *testing.T) {
+	t.Setenv(ProjectDirEnv, t.TempDir())
+	p, err := LoadProject()
+	require.NoError(t, err)
+	assert.Empty(t, p.Dir)
+	assert.Empty(t, p.Names())
+}
+
+func TestLoadProjectVars_IgnoresInvalidWorkflow(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ProjectDirEnv, root)
+	dir := filepath.Join(root, RequestDirName)
+	writeProjectFile(t, dir, "variables.yaml", "host: api.example.com\n")
+	writeProjectFile(t, dir, "workflows/wip.yaml", "steps: []\n")
+
+	_, err := LoadProject()
+	require.Error(t, err)
+
+	vars, err := LoadProjectVars()
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.com", vars.Values["host"])
+}
+
+func TestLoadProjectVars_None(t *testing.T) {
+	t.Setenv(ProjectDirEnv, t.TempDir())
+	vars, err := LoadProjectVars()
+	require.NoError(t, err)
+	assert.Empty(t, vars.Values)
+}
+
+func TestLoadProjectFrom_StepSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFile(t, dir, "workflows/poll.yaml", `steps:
+  - url: https://example.com/op
+    when: steps.create.status == `+"`202`"+`
+    until: status == 'Succeeded'
+    interval: 10s
+    maxAttempts: 30
+    retry: 0
+    timeout: 1m
+    continueOnError: true
+`)
+	p, err := LoadProjectFrom(dir)
+	require.NoError(t, err)
+	step := p.Workflows["poll"].Steps[0]
+	assert.Equal(t, "steps.create.status == `202`", step.When)
+	assert.Equal(t, "status == 'Succeeded'", step.Until)
+	assert.Equal(t, 10*time.Second, step.Interval)
+	assert.Equal(t, 30, step.MaxAttempts)
+	require.NotNil(t, step.Retry)
+	assert.Equal(t, 0, *step.Retry)
+	assert.Equal(t, time.Minute, step.Timeout)
+	assert.True(t, step.ContinueOnError)
+}
+
+func TestLoadProjectFrom_ParallelBlock(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFile(t, dir, "workflows/checks.yaml", `steps:
+  - name: checks
+    concurrency: 2
+    parallel:
+      - url: https://example.com/a
+      - request: b
+`)
+	p, err := LoadProjectFrom(dir)
+	require.NoError(t, err)
+	step := p.Workflows["checks"].Steps[0]
+	assert.Equal(t, 2, step.Concurrency)
+	require.Len(t, step.Parallel, 2)
+	assert.Equal(t, "https://example.com/a", step.Parallel[0].URL)
+	assert.Equal(t, "b", step.Parallel[1].Request)
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VarsFileName is the per-environment request variables file, read from
+// .azure/<environment>/ in the azd project.
+const VarsFileName = "azd-rest.env.yaml"
+
+// ProjectDirEnv names the azd project directory explicitly. azd sets it for
+// the MCP server; otherwise the project is found by walking up from the
+// working directory to the nearest azure.yaml.
+const ProjectDirEnv = "AZD_REST_PROJECT_DIR"
+
+// Vars holds request variables for the current azd environment. Path is the
+// file they were read from, or empty when there is none.
+type Vars struct {
+	Path   string
+	Values map[string]string
+}
+
+// LoadVars reads the variables file for the current azd project and
+// environment. The environment is $AZURE_ENV_NAME when set, otherwise the
+// project's default environment in .azure/config.json. A missing project,
+// environment, or file is not an error and yields empty Vars.
+func LoadVars() (Vars, error) {
+	projectDir := os.Getenv(ProjectDirEnv)
+	if projectDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return Vars{}, nil
+		}
+		projectDir = findProjectDir(wd)
+	}
+	if projectDir == "" {
+		return Vars{}, nil
+	}
+
+	envName := os.Getenv("AZURE_ENV_NAME")
+	if envName == "" {
+		envName = defaultEnvironment(projectDir)
+	}
+	if envName == "" || filepath.Base(envName) != envName {
+		return Vars{}, nil
+	}
+	return LoadVarsFrom(filepath.Join(projectDir, ".azure", envName, VarsFileName))
+}
+
+// LoadVarsFrom reads a variables file: a flat YAML mapping of names to scalar
+// values. A missing file yields empty Vars.
+func LoadVarsFrom(path string) (Vars, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- Path is built from the azd project and environment.
+	if errors.Is(err, os.ErrNotExist) {
+		return Vars{}, nil
+	}
+	if err != nil {
+		return Vars{}, fmt.Errorf("failed to read variables file: %w", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return Vars{}, fmt.Errorf("failed to parse variables file %s: %w", path, err)
+	}
+	values := make(map[string]string, len(doc))
+	for name, v := range doc {
+		switch v := v.(type) {
+		case string:
+			values[name] = v
+		case nil:
+			values[name] = ""
+		case map[string]any, []any:
+			return Vars{}, fmt.Errorf("variables file %s: %s must be a scalar value", path, name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return Vars{Path: path, Values: values}, nil
+}
+
+// findProjectDir walks up from dir to the nearest directory that holds an
+// azure.yaml, returning "" when there is none.
+func findProjectDir(dir string) string {
+	for {
+		for _, name := range []string{"azure.yaml", "azure.yml"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// defaultEnvironment returns the defaultEnvironment recorded by azd in
+// .azure/config.json, or "" when it is not set.
+func defaultEnvironment(projectDir string) string {
+	raw, err := os.ReadFile(filepath.Join(projectDir, ".azure", "config.json")) // #nosec G304 -- Fixed file under the azd project.
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		DefaultEnvironment string `json:"defaultEnvironment"`
+	}
+	if json.Unmarshal(raw, &cfg) != nil {
+		return ""
+	}
+	return cfg.DefaultEnvironment
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProject creates an azd project with a variables file for env and
+// returns the project directory.
+func writeProject(t *testing.T, env, vars string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "azure.yaml"), []byte("name: demo\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".azure", env), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".azure", env, VarsFileName), []byte(vars), 0o600))
+	return dir
+}
+
+func TestLoadVarsFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), VarsFileName)
+	require.NoError(t, os.WriteFile(path, []byte("host: api.dev.example.com\nport: 8443\ndebug: true\nempty:\n"), 0o600))
+
+	vars, err := LoadVarsFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, vars.Path)
+	assert.Equal(t, map[string]string{"host": "api.dev.example.com", "port": "8443", "debug": "true", "empty": ""}, vars.Values)
+}
+
+func TestLoadVarsFrom_Errors(t *testing.T) {
+	vars, err := LoadVarsFrom(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, vars.Values)
+
+	path := filepath.Join(t.TempDir(), VarsFileName)
+	require.NoError(t, os.WriteFile(path, []byte("nested:\n  a: 1\n"), 0o600))
+	_, err = LoadVarsFrom(path)
+	assert.ErrorContains(t, err, "nested must be a scalar value")
+}
+
+func TestLoadVars_UsesEnvironmentName(t *testing.T) {
+	dir := writeProject(t, "stage", "host: stage.example.com\n")
+	t.Setenv(ProjectDirEnv, dir)
+	t.Setenv("AZURE_ENV_NAME", "stage")
+
+	vars, err := LoadVars()
+	require.NoError(t, err)
+	assert.Equal(t, "stage.example.com", vars.Values["host"])
+}
+
+func TestLoadVars_DefaultEnvironmentFromSubdirectory(t *testing.T) {
+	dir := writeProject(t, "dev", "host: dev.example.com\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".azure", "config.json"), []byte(`{"version":1,"defaultEnvironment":"dev"}`), 0o600))
+	sub := filepath.Join(dir, "src", "api")
+	require.NoError(t, os.MkdirAll(sub, 0o700))
+	t.Setenv(ProjectDirEnv, "")
+	t.Setenv("AZURE_ENV_NAME", "")
+	t.Chdir(sub)
+
+	vars, err := LoadVars()
+	require.NoError(t, err)
+	assert.Equal(t, "dev.example.com", vars.Values["host"])
+}
+
+func TestLoadVars_NoProject(t *testing.T) {
+	t.Setenv(ProjectDirEnv, "")
+	t.Setenv("AZURE_ENV_NAME", "dev")
+	t.Chdir(t.TempDir())
+
+	vars, err := LoadVars()
+	require.NoError(t, err)
+	assert.Empty(t, vars.Values)
+}
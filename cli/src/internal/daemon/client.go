@@ -0,0 +1,162 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// probeTimeout bounds the check for a running daemon, so a CLI run without
+// one pays almost nothing.
+const probeTimeout = 200 * time.Millisecond
+
+// ErrNotRunning is returned when no daemon answers on the socket.
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Client talks to a daemon over its socket. It implements the CLI's token
+// provider interface and http.RoundTripper.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a Client for the daemon listening on socket.
+func NewClient(socket string) *Client {
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	return &Client{http: &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}}
+}
+
+// Connect returns a Client when a daemon is answering on the default socket,
+// or nil when none is.
+func Connect(ctx context.Context) *Client {
+	socket, err := SocketPath()
+	if err != nil {
+		return nil
+	}
+	c := NewClient(socket)
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	if _, err := c.Status(ctx); err != nil {
+		return nil
+	}
+	return c
+}
+
+// GetToken returns a token for scope from the daemon's warm provider.
+func (c *Client) GetToken(ctx context.Context, scope string) (string, error) {
+	var body tokenResponse
+	status, err := c.do(ctx, http.MethodGet, "/v1/token?scope="+url.QueryEscape(scope), &body)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("daemon: %s", body.Error)
+	}
+	return body.Token, nil
+}
+
+// RoundTrip sends req through the daemon's connection pool and returns the
+// upstream response. The error wraps ErrNotRunning only when no connection to
+// the daemon was made, so the request was not sent and may be sent another
+// way.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, w := io.Pipe()
+	go func() { _ = w.CloseWithError(req.WriteProxy(w)) }()
+
+	var connected atomic.Bool
+	ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected.Store(true) },
+	})
+	fwd, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://daemon/v1/forward", body)
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+	fwd.Header.Set("Content-Type", forwardContentType)
+	resp, err := c.http.Do(fwd)
+	if err != nil {
+		_ = body.CloseWithError(err)
+		if !connected.Load() {
+			return nil, fmt.Errorf("%w: %w", ErrNotRunning, err)
+		}
+		return nil, fmt.Errorf("daemon: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var failed tokenResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failed); err != nil || failed.Error == "" {
+			return nil, fmt.Errorf("daemon: forward failed with %s", resp.Status)
+		}
+		return nil, fmt.Errorf("daemon: %s", failed.Error)
+	}
+	upstream, err := http.ReadResponse(bufio.NewReader(resp.Body), req)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("daemon: invalid forwarded response: %w", err)
+	}
+	upstream.Body = forwardedBody{ReadCloser: upstream.Body, conn: resp.Body}
+	return upstream, nil
+}
+
+// forwardedBody is the body of an upstream response read from a daemon
+// response; closing it also releases the daemon connection.
+type forwardedBody struct {
+	io.ReadCloser
+	conn io.Closer
+}
+
+func (b forwardedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if cerr := b.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Status reports on the running daemon.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var s Status
+	if _, err := c.do(ctx, http.MethodGet, "/v1/status", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Shutdown asks the daemon to exit.
+func (c *Client) Shutdown(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/v1/shutdown", nil)
+	return err
+}
+
+// do sends one request and decodes the JSON response into v.
+func (c *Client) do(ctx context.Context, method, path string, v any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNotRunning, err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return resp.StatusCode, fmt.Errorf("daemon: invalid response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,265 @@
+// Package daemon runs an optional background process that keeps an Azure
+// token provider and an HTTP connection pool warm between azd rest
+// invocations. Each CLI run otherwise builds the credential chain, acquires a
+// token, and opens a TLS connection from scratch; with the daemon running it
+// asks the daemon for a token, and hands the request to the daemon to send
+// over one of its open connections, both over a local socket.
+//
+// A forwarded request travels in HTTP/1.1 wire format: the CLI writes it with
+// its absolute URL, the daemon sends it through its transport and writes the
+// response back. Requests that need settings of the CLI process, such as
+// --insecure or --pinnedpubkey, are not forwarded (see
+// client.NewForwardingClient).
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// SocketEnv overrides the socket path.
+const SocketEnv = "AZD_REST_DAEMON_SOCKET"
+
+// DefaultIdleTimeout stops a daemon that has served no token or request for
+// this long.
+const DefaultIdleTimeout = 30 * time.Minute
+
+// TokenProvider is the token source the daemon keeps warm.
+type TokenProvider interface {
+	GetToken(ctx context.Context, scope string) (string, error)
+}
+
+// Status describes a running daemon.
+type Status struct {
+	PID               int       `json:"pid"`
+	Socket            string    `json:"socket"`
+	StartedAt         time.Time `json:"startedAt"`
+	TokenRequests     int64     `json:"tokenRequests"`
+	ForwardedRequests int64     `json:"forwardedRequests"`
+}
+
+// forwardContentType marks a body that holds one HTTP/1.1 message.
+const forwardContentType = "application/http"
+
+// tokenResponse is the body of a token request, and of a failed forward.
+type tokenResponse struct {
+	Token string `json:"token,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// SocketPath returns the daemon socket: $AZD_REST_DAEMON_SOCKET when set,
+// otherwise daemon.sock in the azd rest configuration directory.
+func SocketPath() (string, error) {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path, nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// Listen creates the socket at path. The directory is created private to the
+// current user, and an existing one must be owned by the user and not
+// writable by anyone else. The socket is created private to the user, and a
+// stale socket left by a daemon that exited without cleaning up is replaced.
+// It fails when a daemon is already serving path.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := checkSocketDir(dir); err != nil {
+		return nil, err
+	}
+	if _, err := NewClient(path).Status(context.Background()); err == nil {
+		return nil, fmt.Errorf("a daemon is already running on %s", path)
+	}
+	_ = os.Remove(path)
+	l, err := listenPrivate(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// Only the current user may connect: the socket hands out tokens. The
+	// umask already ensured this on unix; the mode documents it everywhere.
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Server answers token, forward, status, and shutdown requests on a local
+// socket.
+type Server struct {
+	tokens      TokenProvider
+	transport   http.RoundTripper
+	socket      string
+	idleTimeout time.Duration
+	started     time.Time
+	requests    atomic.Int64
+	forwarded   atomic.Int64
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	stop     context.CancelFunc
+}
+
+// NewServer returns a Server that gets tokens from tokens and sends forwarded
+// requests through transport, whose connections it keeps between requests. A
+// nil transport refuses forwarded requests. A zero idleTimeout keeps the
+// daemon running until it is stopped.
+func NewServer(tokens TokenProvider, transport http.RoundTripper, socket string, idleTimeout time.Duration) *Server {
+	now := time.Now()
+	return &Server{tokens: tokens, transport: transport, socket: socket, idleTimeout: idleTimeout, started: now, lastUsed: now}
+}
+
+// Serve answers requests on l until ctx is canceled, a shutdown request
+// arrives, or the idle timeout passes. The socket file is removed on return.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.stop = cancel
+	s.mu.Unlock()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/token", s.handleToken)
+	mux.HandleFunc("POST /v1/forward", s.handleForward)
+	mux.HandleFunc("GET /v1/status", s.handleStatus)
+	mux.HandleFunc("POST /v1/shutdown", s.handleShutdown)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go s.watchIdle(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.Serve(l)
+	_ = os.Remove(s.socket)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// watchIdle cancels the server once no token or forwarded request has been
+// asked for in idleTimeout.
+func (s *Server) watchIdle(ctx context.Context) {
+	if s.idleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(s.idleTimeout, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			idle := time.Since(s.lastUsed)
+			s.mu.Unlock()
+			if idle >= s.idleTimeout {
+				s.stop()
+				return
+			}
+		}
+	}
+}
+
+// touch records activity for the idle timeout.
+func (s *Server) touch() {
+	s.mu.Lock()
+	s.lastUsed = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	s.touch()
+
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		writeJSON(w, http.StatusBadRequest, tokenResponse{Error: "missing scope"})
+		return
+	}
+	token, err := s.tokens.GetToken(r.Context(), scope)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, tokenResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, tokenResponse{Token: token})
+}
+
+// handleForward sends the request in the body through the daemon's
+// transport and writes the response back in the same wire format. A request
+// that cannot be sent is answered with 502 and the error.
+func (s *Server) handleForward(w http.ResponseWriter, r *http.Request) {
+	s.forwarded.Add(1)
+	s.touch()
+	if s.transport == nil {
+		writeJSON(w, http.StatusNotImplemented, tokenResponse{Error: "forwarding is disabled"})
+		return
+	}
+	req, err := http.ReadRequest(bufio.NewReader(r.Body))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, tokenResponse{Error: fmt.Sprintf("invalid forwarded request: %v", err)})
+		return
+	}
+	if (req.URL.Scheme != "https" && req.URL.Scheme != "http") || req.URL.Host == "" {
+		writeJSON(w, http.StatusBadRequest, tokenResponse{Error: "forwarded request needs an absolute http or https URL"})
+		return
+	}
+	req.RequestURI = ""
+	resp, err := s.transport.RoundTrip(req.WithContext(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, tokenResponse{Error: err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", forwardContentType)
+	w.WriteHeader(http.StatusOK)
+	_ = resp.Write(w)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, Status{
+		PID:               os.Getpid(),
+		Socket:            s.socket,
+		StartedAt:         s.started.UTC(),
+		TokenRequests:     s.requests.Load(),
+		ForwardedRequests: s.forwarded.Load(),
+	})
+}
+
+func (s *Server) handleShutdown(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokens counts calls and returns a token naming the scope.
+type fakeTokens struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (f *fakeTokens) GetToken(_ context.Context, scope string) (string, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return "", f.err
+	}
+	return "token-for-" + scope, nil
+}
+
+// socketPath returns a short socket path; unix socket paths are limited to
+// about 100 bytes, which t.TempDir() can exceed on macOS.
+func socketPath(t testing.TB) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "azr")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "d.sock")
+}
+
+// startServer serves tokens on a new socket and returns a client for it and
+// a channel that receives Serve's result.
+func startServer(t testing.TB, tokens TokenProvider, idle time.Duration) (*Client, string, <-chan error) {
+	t.Helper()
+	return startForwardingServer(t, tokens, nil, idle)
+}
+
+// startForwardingServer is startServer with requests forwarded through
+// transport.
+func startForwardingServer(t testing.TB, tokens TokenProvider, transport http.RoundTripper, idle time.Duration) (*Client, string, <-chan error) {
+	t.Helper()
+	path := socketPath(t)
+	l, err := Listen(path)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	done := make(chan error, 1)
+	go func() { done <- NewServer(tokens, transport, path, idle).Serve(ctx, l) }()
+	return NewClient(path), path, done
+}
+
+// tlsServer returns a TLS test server that echoes the method, a header, and
+// the body of each request, and a counter of the connections it accepted.
+func tlsServer(t testing.TB) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, "%s %s", r.Header.Get("X-Team"), body)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func TestDaemon_ServesTokens(t *testing.T) {
+	tokens := &fakeTokens{}
+	c, path, _ := startServer(t, tokens, 0)
+	ctx := context.Background()
+
+	token, err := c.GetToken(ctx, "https://management.azure.com/.default")
+	require.NoError(t, err)
+	assert.Equal(t, "token-for-https://management.azure.com/.default", token)
+
+	status, err := c.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.Equal(t, path, status.Socket)
+	assert.Equal(t, int64(1), status.TokenRequests)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestDaemon_ForwardsRequestsOverWarmConnections(t *testing.T) {
+	upstream, conns := tlsServer(t)
+	_, path, _ := startForwardingServer(t, &fakeTokens{}, upstream.Client().Transport, 0)
+
+	// Each run is a new CLI process with a client of its own.
+	for i := range 3 {
+		req, err := http.NewRequest(http.MethodPost, upstream.URL+"/items", strings.NewReader(fmt.Sprintf(`{"n":%d}`, i)))
+		require.NoError(t, err)
+		req.Header.Set("X-Team", "platform")
+		resp, err := (&http.Client{Transport: NewClient(path)}).Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, http.MethodPost, resp.Header.Get("X-Method"))
+		assert.Equal(t, fmt.Sprintf(`platform {"n":%d}`, i), string(body))
+	}
+	assert.Equal(t, int32(1), conns.Load(), "the daemon keeps one connection for every run")
+
+	status, err := NewClient(path).Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), status.ForwardedRequests)
+}
+
+func TestDaemon_ForwardErrors(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:1/", nil)
+	require.NoError(t, err)
+
+	c, _, _ := startServer(t, &fakeTokens{}, 0)
+	_, err = c.RoundTrip(req)
+	assert.ErrorContains(t, err, "forwarding is disabled")
+
+	c, _, _ = startForwardingServer(t, &fakeTokens{}, http.DefaultTransport, 0)
+	_, err = c.RoundTrip(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.False(t, errors.Is(err, ErrNotRunning), "the daemon answered")
+
+	_, err = NewClient(socketPath(t)).RoundTrip(req)
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestDaemon_TokenErrorIsReturned(t *testing.T) {
+	c, _, _ := startServer(t, &fakeTokens{err: errors.New("not logged in")}, 0)
+
+	_, err := c.GetToken(context.Background(), "scope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not logged in")
+	assert.False(t, errors.Is(err, ErrNotRunning))
+}
+
+func TestDaemon_Shutdown(t *testing.T) {
+	c, path, done := startServer(t, &fakeTokens{}, 0)
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket is removed")
+
+	_, err = c.Status(context.Background())
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestDaemon_IdleTimeout(t *testing.T) {
+	_, _, done := startServer(t, &fakeTokens{}, 50*time.Millisecond)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle daemon did not stop")
+	}
+}
+
+func TestListen(t *testing.T) {
+	_, path, _ := startServer(t, &fakeTokens{}, 0)
+
+	_, err := Listen(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already running")
+
+	// A socket file left behind by a crashed daemon is replaced.
+	stale := socketPath(t)
+	require.NoError(t, os.WriteFile(stale, nil, 0o600))
+	l, err := Listen(stale)
+	require.NoError(t, err)
+	_ = l.Close()
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv(SocketEnv, "/tmp/custom.sock")
+	path, err := SocketPath()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.sock", path)
+
+	t.Setenv(SocketEnv, "")
+	t.Setenv("AZD_CONFIG_DIR", "/cfg")
+	path, err = SocketPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/cfg", "rest", "daemon.sock"), path)
+}
+
+func TestConnect_NoDaemon(t *testing.T) {
+	t.Setenv(SocketEnv, socketPath(t))
+	assert.Nil(t, Connect(context.Background()))
+}
+
+// BenchmarkRequest compares a request from a new CLI process, which opens
+// its own TLS connection, with the same request forwarded through the
+// daemon's warm connection. Run it with:
+//
+//	go test -run ^$ -bench BenchmarkRequest ./src/internal/daemon/
+func BenchmarkRequest(b *testing.B) {
+	upstream, _ := tlsServer(b)
+	base := upstream.Client().Transport.(*http.Transport)
+	send := func(b *testing.B, rt http.RoundTripper) {
+		resp, err := (&http.Client{Transport: rt}).Get(upstream.URL)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	b.Run("direct", func(b *testing.B) {
+		for b.Loop() {
+			t := base.Clone()
+			send(b, t)
+			t.CloseIdleConnections()
+		}
+	})
+	b.Run("daemon", func(b *testing.B) {
+		_, path, _ := startForwardingServer(b, &fakeTokens{}, base.Clone(), 0)
+		for b.Loop() {
+			c := NewClient(path)
+			send(b, c)
+			c.http.CloseIdleConnections()
+		}
+	})
+}
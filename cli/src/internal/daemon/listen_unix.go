@@ -0,0 +1,42 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes the umask change in listenPrivate; the umask is process
+// wide.
+var umaskMu sync.Mutex
+
+// checkSocketDir refuses a directory another user could use to replace the
+// socket or reach it before it is restricted: one the current user does not
+// own, or one writable by group or others, such as /tmp.
+func checkSocketDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Geteuid() {
+		return fmt.Errorf("socket directory %s is not owned by the current user", dir)
+	}
+	if perm := info.Mode().Perm(); perm&0o022 != 0 {
+		return fmt.Errorf("socket directory %s is writable by other users (mode %#o); use a private directory", dir, perm)
+	}
+	return nil
+}
+
+// listenPrivate listens on the unix socket path with a umask that makes the
+// socket accessible to the current user only from the moment it is created.
+func listenPrivate(path string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_PrivateSocket(t *testing.T) {
+	path := socketPath(t)
+	l, err := Listen(path)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestListen_RefusesSharedDirectory(t *testing.T) {
+	dir := filepath.Dir(socketPath(t))
+	require.NoError(t, os.Chmod(dir, 0o777)) //nolint:gosec // G302: the test needs a world-writable directory
+	_, err := Listen(filepath.Join(dir, "d.sock"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "writable by other users")
+
+	require.NoError(t, os.Chmod(dir, 0o755)) //nolint:gosec // G302: readable by others is allowed
+	l, err := Listen(filepath.Join(dir, "d.sock"))
+	require.NoError(t, err)
+	_ = l.Close()
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package daemon
+
+import "net"
+
+// checkSocketDir accepts any directory; on Windows the socket inherits the
+// access control list of its directory, which mode bits do not describe.
+func checkSocketDir(string) error { return nil }
+
+// listenPrivate listens on the unix socket path.
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,75 @@
+package sas
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConnectionString = "Endpoint=sb://contoso.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=c2VjcmV0LWtleQ==;EntityPath=orders"
+
+func TestParseConnectionString(t *testing.T) {
+	cs, err := ParseConnectionString(testConnectionString)
+	require.NoError(t, err)
+	assert.Equal(t, "contoso.servicebus.windows.net", cs.Host)
+	assert.Equal(t, "RootManageSharedAccessKey", cs.KeyName)
+	assert.Equal(t, "c2VjcmV0LWtleQ==", cs.Key, "the = padding in the key survives parsing")
+	assert.Equal(t, "orders", cs.EntityPath)
+	assert.Equal(t, "https://contoso.servicebus.windows.net/orders", cs.ResourceURI())
+}
+
+func TestParseConnectionString_Errors(t *testing.T) {
+	cases := map[string]string{
+		"empty":        "",
+		"no endpoint":  "SharedAccessKeyName=a;SharedAccessKey=b",
+		"no key":       "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=a",
+		"not pairs":    "Endpoint=sb://ns.servicebus.windows.net/;garbage",
+		"bad endpoint": "Endpoint=::;SharedAccessKeyName=a;SharedAccessKey=b",
+	}
+	for name, s := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConnectionString(s)
+			require.Error(t, err)
+			assert.NotContains(t, err.Error(), "SharedAccessKey=b")
+		})
+	}
+}
+
+func TestResourceForURL(t *testing.T) {
+	cs, err := ParseConnectionString(testConnectionString)
+	require.NoError(t, err)
+
+	resource, err := cs.ResourceForURL("https://contoso.servicebus.windows.net/orders/messages?timeout=60")
+	require.NoError(t, err)
+	assert.Equal(t, "https://contoso.servicebus.windows.net/orders/messages", resource)
+
+	_, err = cs.ResourceForURL("https://evil.example.com/orders")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+}
+
+func TestToken(t *testing.T) {
+	cs, err := ParseConnectionString(testConnectionString)
+	require.NoError(t, err)
+	expiry := time.Unix(1700000000, 0)
+
+	token := cs.Token("https://contoso.servicebus.windows.net/orders", expiry)
+	require.True(t, strings.HasPrefix(token, "SharedAccessSignature "))
+
+	fields, err := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+	require.NoError(t, err)
+	assert.Equal(t, "https://contoso.servicebus.windows.net/orders", fields.Get("sr"))
+	assert.Equal(t, "1700000000", fields.Get("se"))
+	assert.Equal(t, "RootManageSharedAccessKey", fields.Get("skn"))
+
+	mac := hmac.New(sha256.New, []byte("c2VjcmV0LWtleQ=="))
+	_, _ = mac.Write([]byte(url.QueryEscape("https://contoso.servicebus.windows.net/orders") + "\n1700000000"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), fields.Get("sig"))
+}
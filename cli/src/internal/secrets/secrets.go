@@ -0,0 +1,130 @@
+// Package secrets stores and retrieves named secrets (API keys, client
+// secrets) in the OS credential manager: Windows Credential Manager (DPAPI),
+// the macOS Keychain, or the Secret Service (libsecret) on Linux.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// service is the credential manager service name that scopes every azd rest secret.
+const service = "azd-rest"
+
+// indexKey names the credential manager entry that records the names of the
+// stored secrets. The OS credential managers have no portable way to
+// enumerate entries, so List reads this index instead. It holds names only,
+// never values.
+const indexKey = "__index__"
+
+// ErrNotFound is returned when no secret exists under the requested name.
+var ErrNotFound = errors.New("secret not found")
+
+// validName restricts secret names to characters that are safe in a header
+// reference (@secret:<name>) and in every credential manager.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ValidateName reports whether name can be used as a secret name.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (use letters, digits, '.', '_', or '-')", name)
+	}
+	return nil
+}
+
+// Get returns the secret stored under name.
+func Get(name string) (string, error) {
+	value, err := keyring.Get(service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s from the OS keychain: %w", name, err)
+	}
+	return value, nil
+}
+
+// Set stores value under name, replacing any existing secret with that name.
+func Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret %s: value cannot be empty", name)
+	}
+	if err := keyring.Set(service, name, value); err != nil {
+		return fmt.Errorf("failed to store secret %s in the OS keychain: %w", name, err)
+	}
+	names, err := List()
+	if err != nil {
+		return err
+	}
+	for _, existing := range names {
+		if existing == name {
+			return nil
+		}
+	}
+	return writeIndex(append(names, name))
+}
+
+// Delete removes the secret stored under name.
+func Delete(name string) error {
+	err := keyring.Delete(service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete secret %s from the OS keychain: %w", name, err)
+	}
+	names, err := List()
+	if err != nil {
+		return err
+	}
+	kept := names[:0]
+	for _, existing := range names {
+		if existing != name {
+			kept = append(kept, existing)
+		}
+	}
+	return writeIndex(kept)
+}
+
+// List returns the sorted names of the stored secrets.
+func List() ([]string, error) {
+	raw, err := keyring.Get(service, indexKey)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secret index from the OS keychain: %w", err)
+	}
+	names := []string{}
+	for _, name := range strings.Split(raw, "\n") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeIndex stores the set of secret names, removing the index entry when
+// it is empty.
+func writeIndex(names []string) error {
+	if len(names) == 0 {
+		if err := keyring.Delete(service, indexKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("failed to update the secret index in the OS keychain: %w", err)
+		}
+		return nil
+	}
+	sort.Strings(names)
+	if err := keyring.Set(service, indexKey, strings.Join(names, "\n")); err != nil {
+		return fmt.Errorf("failed to update the secret index in the OS keychain: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestGet(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, keyring.Set(service, "api", "s3cr3t"))
+
+	value, err := Get("api")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	_, err = Get("missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestSetListDelete(t *testing.T) {
+	keyring.MockInit()
+
+	names, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	require.NoError(t, Set("myapi", "key-1"))
+	require.NoError(t, Set("another", "key-2"))
+	require.NoError(t, Set("myapi", "key-3"), "overwriting keeps a single index entry")
+
+	names, err = List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"another", "myapi"}, names)
+
+	value, err := Get("myapi")
+	require.NoError(t, err)
+	assert.Equal(t, "key-3", value)
+
+	require.NoError(t, Delete("myapi"))
+	names, err = List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"another"}, names)
+
+	err = Delete("myapi")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestSet_Validation(t *testing.T) {
+	keyring.MockInit()
+
+	for _, name := range []string{"", "has space", "a:b", "__index__", ".hidden"} {
+		assert.Error(t, Set(name, "v"), name)
+	}
+	assert.Error(t, Set("ok", ""), "empty values are rejected")
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// acrHostSuffix marks an Azure Container Registry data-plane host.
+const acrHostSuffix = ".azurecr.io"
+
+// isACRHost reports whether host is an Azure Container Registry login server.
+func isACRHost(host string) bool {
+	host = strings.ToLower(host)
+	return strings.HasSuffix(host, acrHostSuffix) && len(host) > len(acrHostSuffix)
+}
+
+// acrScope returns the registry permission a request needs, in the Docker
+// token scope format: registry:catalog:* for the catalog, and
+// repository:<name>:<actions> for a repository under /v2/ or /acr/v1/. It
+// returns "" for a path that needs no permission, such as the /v2/ check.
+func acrScope(method, path string) string {
+	path = strings.Trim(path, "/")
+	var rest string
+	var api string
+	switch {
+	case path == "v2", path == "acr/v1":
+		return ""
+	case path == "v2/_catalog", path == "acr/v1/_catalog":
+		return "registry:catalog:*"
+	case strings.HasPrefix(path, "v2/"):
+		api, rest = "v2", strings.TrimPrefix(path, "v2/")
+	case strings.HasPrefix(path, "acr/v1/"):
+		api, rest = "acr", strings.TrimPrefix(path, "acr/v1/")
+	default:
+		return ""
+	}
+
+	// A repository name may contain slashes, so it runs up to the first
+	// segment that is part of the API rather than the name.
+	segments := strings.Split(rest, "/")
+	end := len(segments)
+	for i, segment := range segments {
+		if (api == "v2" && (segment == "tags" || segment == "manifests" || segment == "blobs" || segment == "referrers")) ||
+			(api == "acr" && strings.HasPrefix(segment, "_")) {
+			end = i
+			break
+		}
+	}
+	name := strings.Join(segments[:end], "/")
+	if name == "" {
+		return ""
+	}
+
+	var actions string
+	switch method {
+	case http.MethodDelete:
+		actions = "delete"
+	case http.MethodGet, http.MethodHead:
+		actions = "pull"
+		if api == "acr" {
+			actions = "metadata_read"
+		}
+	default:
+		actions = "pull,push"
+		if api == "acr" {
+			actions = "metadata_write"
+		}
+	}
+	return "repository:" + name + ":" + actions
+}
+
+// applyACRExchange makes a request to a container registry send a registry
+// access token. The Azure token for the request scope is exchanged for an
+// ACR refresh token and then for an access token with the permission the
+// request needs. A --scope in the registry format, such as
+// repository:hello:pull, names the permission instead. The exchange goes to
+// the registry through the request's own transport (see
+// client.NewTokenExchangeClient).
+func applyACRExchange(cfg config.Config, method, requestURL string, opts *client.RequestOptions) error {
+	if opts.SkipAuth || opts.TokenProvider == nil || !challengeAuth(cfg, *opts) {
+		return nil
+	}
+	parsed, err := url.Parse(requestURL)
+	if err != nil || !isACRHost(parsed.Hostname()) {
+		return nil
+	}
+	httpClient, err := client.NewTokenExchangeClient(*opts, oauth2Timeout)
+	if err != nil {
+		return err
+	}
+	permission := acrScope(method, parsed.Path)
+	if strings.HasPrefix(cfg.Scope, "repository:") || strings.HasPrefix(cfg.Scope, "registry:") {
+		permission = cfg.Scope
+		opts.Scope, _ = client.DetectScope(requestURL)
+	}
+	opts.TokenProvider = &acrTokenProvider{
+		azure:      opts.TokenProvider,
+		registry:   parsed.Scheme + "://" + parsed.Host,
+		service:    parsed.Hostname(),
+		permission: permission,
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+	return nil
+}
+
+// acrTokenProvider implements TokenProvider with the ACR OAuth2 exchange.
+// The scope passed to GetToken is the Azure scope; the registry permission
+// is fixed per request. The refresh token and access token are cached in
+// memory, so pages and retries do not repeat the exchange.
+type acrTokenProvider struct {
+	azure      client.TokenProvider
+	registry   string
+	service    string
+	permission string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu           sync.Mutex
+	azureToken   string
+	refreshToken string
+	token        string
+	expiresAt    time.Time
+}
+
+// acrTokenResponse holds the fields of the /oauth2/exchange and
+// /oauth2/token responses, including ACR's error format.
+type acrTokenResponse struct {
+	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token"`
+	Errors       []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GetToken returns a registry access token for the request's permission.
+func (p *acrTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	azureToken, err := p.azure.GetToken(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if azureToken == p.azureToken && p.token != "" && p.now().Add(oauth2ExpirySkew).Before(p.expiresAt) {
+		return p.token, nil
+	}
+	if azureToken != p.azureToken || p.refreshToken == "" {
+		form := url.Values{"grant_type": {"access_token"}, "service": {p.service}, "access_token": {azureToken}}
+		if claims, ok := decodeTokenClaims(azureToken); ok && claims.TenantID != "" {
+			form.Set("tenant", claims.TenantID)
+		}
+		resp, err := p.post(ctx, "/oauth2/exchange", form)
+		if err != nil {
+			return "", err
+		}
+		if resp.RefreshToken == "" {
+			return "", fmt.Errorf("acr token exchange with %s: no refresh_token in response", p.service)
+		}
+		p.azureToken, p.refreshToken = azureToken, resp.RefreshToken
+	}
+
+	form := url.Values{"grant_type": {"refresh_token"}, "service": {p.service}, "refresh_token": {p.refreshToken}}
+	if p.permission != "" {
+		form.Set("scope", p.permission)
+	}
+	resp, err := p.post(ctx, "/oauth2/token", form)
+	if err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("acr token exchange with %s: no access_token in response", p.service)
+	}
+	p.token = resp.AccessToken
+	p.expiresAt = time.Time{}
+	if claims, ok := decodeTokenClaims(p.token); ok {
+		if exp, err := claims.Expires.Int64(); err == nil && exp > 0 {
+			p.expiresAt = time.Unix(exp, 0)
+		}
+	}
+	return p.token, nil
+}
+
+// post sends a form to a registry OAuth2 endpoint and decodes the reply.
+func (p *acrTokenProvider) post(ctx context.Context, path string, form url.Values) (acrTokenResponse, error) {
+	var tr acrTokenResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.registry+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tr, fmt.Errorf("acr token exchange with %s: %w", p.service, err)
+	}
+	req.Header.Set(contentTypeHeader, formURLEncoded)
+	req.Header.Set("Accept", applicationJSON)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return tr, fmt.Errorf("acr token exchange with %s failed: %w", p.service, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, oauth2MaxTokenResponse))
+	if err != nil {
+		return tr, fmt.Errorf("acr token exchange with %s: failed to read %s response: %w", p.service, path, err)
+	}
+	if err := json.Unmarshal(raw, &tr); err != nil {
+		return tr, fmt.Errorf("acr token exchange with %s: %s returned HTTP %d with a non-JSON body", p.service, path, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		detail := "no details"
+		if len(tr.Errors) > 0 {
+			detail = strings.TrimSpace(tr.Errors[0].Code + ": " + tr.Errors[0].Message)
+		}
+		return tr, fmt.Errorf("acr token exchange with %s: %s returned HTTP %d (%s)", p.service, path, resp.StatusCode, detail)
+	}
+	return tr, nil
+}
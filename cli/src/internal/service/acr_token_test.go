@@ -0,0 +1,124 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACRScope(t *testing.T) {
+	cases := []struct {
+		method, path, want string
+	}{
+		{"GET", "/v2/", ""},
+		{"GET", "/v2/_catalog", "registry:catalog:*"},
+		{"GET", "/acr/v1/_catalog", "registry:catalog:*"},
+		{"GET", "/v2/hello-world/tags/list", "repository:hello-world:pull"},
+		{"HEAD", "/v2/team/app/manifests/latest", "repository:team/app:pull"},
+		{"PUT", "/v2/team/app/manifests/v2", "repository:team/app:pull,push"},
+		{"POST", "/v2/team/app/blobs/uploads/", "repository:team/app:pull,push"},
+		{"DELETE", "/v2/team/app/manifests/sha256:abc", "repository:team/app:delete"},
+		{"GET", "/acr/v1/team/app/_tags", "repository:team/app:metadata_read"},
+		{"PATCH", "/acr/v1/team/app", "repository:team/app:metadata_write"},
+		{"DELETE", "/acr/v1/team/app", "repository:team/app:delete"},
+		{"GET", "/oauth2/token", ""},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, acrScope(tc.method, tc.path), tc.method+" "+tc.path)
+	}
+}
+
+func TestACRTokenProvider_Exchange(t *testing.T) {
+	access := fakeJWT(t, map[string]any{"exp": time.Now().Add(time.Hour).Unix()})
+	var forms []url.Values
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		forms = append(forms, r.PostForm)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/exchange":
+			_, _ = w.Write([]byte(`{"refresh_token":"acr-refresh"}`))
+		case "/oauth2/token":
+			_, _ = w.Write([]byte(`{"access_token":"` + access + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := &acrTokenProvider{
+		azure:      scopeTokens{},
+		registry:   srv.URL,
+		service:    "myreg.azurecr.io",
+		permission: "repository:hello:pull",
+		httpClient: srv.Client(),
+		now:        time.Now,
+	}
+	for range 2 {
+		tok, err := p.GetToken(t.Context(), "https://containerregistry.azure.net/.default")
+		require.NoError(t, err)
+		assert.Equal(t, access, tok)
+	}
+
+	require.Len(t, forms, 2, "the access token is cached until it expires")
+	assert.Equal(t, url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {"myreg.azurecr.io"},
+		"access_token": {"tok:https://containerregistry.azure.net/.default"},
+	}, forms[0])
+	assert.Equal(t, url.Values{
+		"grant_type":    {"refresh_token"},
+		"service":       {"myreg.azurecr.io"},
+		"refresh_token": {"acr-refresh"},
+		"scope":         {"repository:hello:pull"},
+	}, forms[1])
+}
+
+func TestACRTokenProvider_ExchangeError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+	}))
+	defer srv.Close()
+
+	p := &acrTokenProvider{azure: scopeTokens{}, registry: srv.URL, service: "myreg.azurecr.io", httpClient: srv.Client(), now: time.Now}
+	_, err := p.GetToken(t.Context(), "s")
+	assert.EqualError(t, err, "acr token exchange with myreg.azurecr.io: /oauth2/exchange returned HTTP 401 (UNAUTHORIZED: authentication required)")
+}
+
+func TestBuildRequestOptions_ACRExchange(t *testing.T) {
+	svc := newTestService()
+	svc.tokenProviderFactory = func() (client.TokenProvider, error) { return scopeTokens{}, nil }
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+
+	opts, _, err := svc.BuildRequestOptions(cfg, "GET", "https://myreg.azurecr.io/v2/_catalog")
+	require.NoError(t, err)
+	require.IsType(t, &acrTokenProvider{}, opts.TokenProvider)
+	p := opts.TokenProvider.(*acrTokenProvider)
+	assert.Equal(t, "registry:catalog:*", p.permission)
+	assert.Equal(t, "https://myreg.azurecr.io", p.registry)
+	assert.Equal(t, "https://containerregistry.azure.net/.default", opts.Scope)
+	assert.NotNil(t, p.httpClient.CheckRedirect, "the exchange uses the request's transport, not a bare client")
+
+	cfg.Scope = "repository:hello:pull,push"
+	opts, _, err = svc.BuildRequestOptions(cfg, "GET", "https://myreg.azurecr.io/v2/_catalog")
+	require.NoError(t, err)
+	assert.Equal(t, "repository:hello:pull,push", opts.TokenProvider.(*acrTokenProvider).permission, "--scope names the registry permission")
+	assert.Equal(t, "https://containerregistry.azure.net/.default", opts.Scope)
+
+	cfg.Scope = ""
+	opts, _, err = svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/subscriptions")
+	require.NoError(t, err)
+	assert.IsType(t, scopeTokens{}, opts.TokenProvider)
+
+	cfg.Headers = []string{"Authorization: Basic " + strings.Repeat("x", 8)}
+	opts, _, err = svc.BuildRequestOptions(cfg, "GET", "https://myreg.azurecr.io/v2/_catalog")
+	require.NoError(t, err)
+	assert.Nil(t, opts.TokenProvider, "an explicit Authorization header is sent as is")
+}
@@ -2,7 +2,8 @@ package service
 
 import (
 	"net/url"
-	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
 )
 
 // requestHostAllowed parses rawURL and reports whether its host matches the
@@ -19,30 +20,7 @@ func requestHostAllowed(rawURL string, patterns []string) (host string, allowed
 }
 
 // hostMatchesAllowlist reports whether host matches any pattern in the
-// allowlist. Matching is case insensitive. A pattern that begins with "*."
-// matches any subdomain of the remaining suffix, for example "*.vault.azure.net"
-// matches "kv.vault.azure.net" but not the bare "vault.azure.net". Any other
-// pattern must match the host exactly. Blank patterns are ignored.
+// allowlist, using the same rules the client applies to redirect targets.
 func hostMatchesAllowlist(host string, patterns []string) bool {
-	host = strings.ToLower(strings.TrimSpace(host))
-	if host == "" {
-		return false
-	}
-	for _, pattern := range patterns {
-		p := strings.ToLower(strings.TrimSpace(pattern))
-		if p == "" {
-			continue
-		}
-		if strings.HasPrefix(p, "*.") {
-			suffix := p[1:]
-			if len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
-				return true
-			}
-			continue
-		}
-		if host == p {
-			return true
-		}
-	}
-	return false
+	return client.HostMatchesPattern(host, patterns)
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/secrets"
+)
+
+// apimKeyHeader carries an API Management subscription key.
+const apimKeyHeader = "Ocp-Apim-Subscription-Key"
+
+// applyAPIMKey sets the API Management subscription key from --apim-key-env
+// or --apim-key-secret or, without either, from the config file entry for
+// the request host. A flag replaces a matching -H header; a config file
+// entry does not. The header is always redacted in verbose output.
+func (s *RequestService) applyAPIMKey(cfg config.Config, requestURL string, opts *client.RequestOptions) error {
+	header, key, err := s.apimKey(cfg, requestURL, opts)
+	if err != nil || key == "" {
+		return err
+	}
+	opts.Headers.Set(header, key)
+	opts.SecretHeaders = append(opts.SecretHeaders, header)
+	if strings.HasPrefix(strings.ToLower(requestURL), "http://") {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: the API Management subscription key is sent in clear text over http://\n")
+	}
+	return nil
+}
+
+// apimKey returns the header name and key to send, or an empty key when
+// none applies.
+func (s *RequestService) apimKey(cfg config.Config, requestURL string, opts *client.RequestOptions) (string, string, error) {
+	switch {
+	case cfg.APIMKeyEnv != "" && cfg.APIMKeySecret != "":
+		return "", "", &authModeError{fmt.Errorf("--apim-key-env and --apim-key-secret cannot be combined")}
+	case cfg.APIMKeyEnv != "":
+		key, ok := s.lookupEnv(cfg.APIMKeyEnv)
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return "", "", fmt.Errorf("--apim-key-env: environment variable %s is not set or empty", cfg.APIMKeyEnv)
+		}
+		return apimKeyHeader, key, nil
+	case cfg.APIMKeySecret != "":
+		key, err := s.apimSecret(cfg.APIMKeySecret)
+		if err != nil {
+			return "", "", fmt.Errorf("--apim-key-secret: %w", err)
+		}
+		return apimKeyHeader, key, nil
+	}
+
+	file, err := s.loadConfigFile()
+	if err != nil || len(file.APIM) == 0 {
+		return "", "", err
+	}
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	patterns := make([]string, 0, len(file.APIM))
+	for pattern := range file.APIM {
+		patterns = append(patterns, pattern)
+	}
+	matched := matchHostPatterns(parsed.Hostname(), patterns)
+	if len(matched) == 0 {
+		return "", "", nil
+	}
+	pattern := matched[len(matched)-1]
+	entry := file.APIM[pattern]
+	header := firstNonEmptyString(strings.TrimSpace(entry.Header), apimKeyHeader)
+	if hasHeader(opts.Headers, header) {
+		return "", "", nil
+	}
+
+	var key string
+	switch {
+	case entry.KeyEnv != "" && entry.KeySecret != "":
+		return "", "", fmt.Errorf("config file: apim[%q] sets both keyEnv and keySecret", pattern)
+	case entry.KeyEnv != "":
+		var ok bool
+		if key, ok = s.lookupEnv(entry.KeyEnv); !ok || strings.TrimSpace(key) == "" {
+			return "", "", fmt.Errorf("config file: apim[%q]: environment variable %s is not set or empty", pattern, entry.KeyEnv)
+		}
+	case entry.KeySecret != "":
+		if key, err = s.apimSecret(entry.KeySecret); err != nil {
+			return "", "", fmt.Errorf("config file: apim[%q]: %w", pattern, err)
+		}
+	default:
+		return "", "", fmt.Errorf("config file: apim[%q] needs keyEnv or keySecret", pattern)
+	}
+	return header, strings.TrimSpace(key), nil
+}
+
+// apimSecret reads a subscription key from the OS keychain.
+func (s *RequestService) apimSecret(name string) (string, error) {
+	if err := secrets.ValidateName(name); err != nil {
+		return "", err
+	}
+	key, err := s.getSecret(name)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(key) == "" {
+		return "", fmt.Errorf("secret %q is empty", name)
+	}
+	return strings.TrimSpace(key), nil
+}
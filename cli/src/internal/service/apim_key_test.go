@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apimConfigFile() config.File {
+	return config.File{APIM: map[string]config.APIMKey{
+		"*.azure-api.net":         {KeyEnv: "APIM_KEY"},
+		"orders.azure-api.net":    {KeySecret: "orders-apim", Header: "X-Orders-Key"},
+		"broken.contoso.com":      {},
+		"conflicting.contoso.com": {KeyEnv: "APIM_KEY", KeySecret: "orders-apim"},
+	}}
+}
+
+func TestBuildRequestOptions_APIMKeyFlags(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{}, map[string]string{"APIM_KEY": " env-key \n"})
+	svc.getSecret = secretStore(map[string]string{"apim": "keychain-key"})
+
+	cfg := baseTestConfig(t)
+	cfg.APIMKeyEnv = "APIM_KEY"
+	cfg.Headers = []string{"Ocp-Apim-Subscription-Key: inline"}
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://contoso.azure-api.net/echo")
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, []string{"env-key"}, opts.Headers.Values(apimKeyHeader), "the flag replaces a -H header")
+	assert.Contains(t, opts.SecretHeaders, apimKeyHeader)
+
+	cfg = baseTestConfig(t)
+	cfg.APIMKeySecret = "apim"
+	opts, _, err = svc.BuildRequestOptions(cfg, "GET", "https://contoso.azure-api.net/echo")
+	require.NoError(t, err)
+	assert.Equal(t, "keychain-key", opts.Headers.Get(apimKeyHeader))
+
+	invalid := map[string]config.Config{
+		"--apim-key-env and --apim-key-secret cannot be combined":              {APIMKeyEnv: "APIM_KEY", APIMKeySecret: "apim"},
+		"--apim-key-env: environment variable MISSING_KEY is not set or empty": {APIMKeyEnv: "MISSING_KEY"},
+		"--apim-key-secret: secret not found":                                  {APIMKeySecret: "other"},
+	}
+	for want, flags := range invalid {
+		cfg := baseTestConfig(t)
+		cfg.APIMKeyEnv, cfg.APIMKeySecret = flags.APIMKeyEnv, flags.APIMKeySecret
+		_, _, err := svc.BuildRequestOptions(cfg, "GET", "https://contoso.azure-api.net/echo")
+		require.Error(t, err, want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestBuildRequestOptions_APIMKeyConfigFile(t *testing.T) {
+	svc := serviceWithConfigFile(apimConfigFile(), map[string]string{"APIM_KEY": "env-key"})
+	svc.getSecret = secretStore(map[string]string{"orders-apim": "orders-key"})
+
+	opts, _, err := svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://billing.azure-api.net/invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", opts.Headers.Get(apimKeyHeader))
+
+	opts, _, err = svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://orders.azure-api.net/orders")
+	require.NoError(t, err)
+	assert.Equal(t, "orders-key", opts.Headers.Get("X-Orders-Key"), "the exact host wins over the wildcard")
+	assert.Empty(t, opts.Headers.Get(apimKeyHeader))
+	assert.Contains(t, opts.SecretHeaders, "X-Orders-Key")
+
+	cfg := baseTestConfig(t)
+	cfg.Headers = []string{"Ocp-Apim-Subscription-Key: inline"}
+	opts, _, err = svc.BuildRequestOptions(cfg, "GET", "https://billing.azure-api.net/invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "inline", opts.Headers.Get(apimKeyHeader), "a -H header wins over the config file")
+
+	opts, _, err = svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://example.com/")
+	require.NoError(t, err)
+	assert.Empty(t, opts.Headers.Get(apimKeyHeader))
+
+	_, _, err = svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://broken.contoso.com/")
+	assert.ErrorContains(t, err, `config file: apim["broken.contoso.com"] needs keyEnv or keySecret`)
+	_, _, err = svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://conflicting.contoso.com/")
+	assert.ErrorContains(t, err, `config file: apim["conflicting.contoso.com"] sets both keyEnv and keySecret`)
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// recordAudit appends a write request to the --audit-log file of cfg.
+func recordAudit(cfg config.Config, opts client.RequestOptions, started time.Time, resp *client.Response, reqErr error) error {
+	return RecordAudit(cfg.AuditLog, opts, started, resp, reqErr)
+}
+
+// RecordAudit appends a write request to the audit log at path, if any. It is
+// called by every command that sends its own requests, such as arm tag add,
+// so no write bypasses --audit-log. Read-only methods are not recorded. The
+// URL is redacted and error text is scrubbed of secrets, so the log holds no
+// credentials.
+func RecordAudit(path string, opts client.RequestOptions, started time.Time, resp *client.Response, reqErr error) error {
+	if path == "" || safeMethods[opts.Method] {
+		return nil
+	}
+	entry := audit.Entry{
+		Time:   started,
+		Method: opts.Method,
+		URL:    client.RedactValues(client.RedactURL(opts.URL), opts.SecretValues),
+	}
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	if reqErr != nil {
+		entry.Error = client.RedactSecrets(client.RedactValues(reqErr.Error(), opts.SecretValues))
+	}
+	return audit.Append(path, audit.Key(), entry)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecute_AuditLogRecordsWriteRequests(t *testing.T) {
+	t.Setenv(audit.KeyEnv, "")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.AuditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL+"/items"))
+	require.NoError(t, svc.Execute(context.Background(), cfg, "PUT", srv.URL+"/items/1?sig=secret"))
+	require.NoError(t, svc.Execute(context.Background(), cfg, "DELETE", srv.URL+"/items/1"))
+
+	data, err := os.ReadFile(cfg.AuditLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2, "GET is not recorded")
+	assert.Contains(t, lines[0], `"method":"PUT"`)
+	assert.Contains(t, lines[0], `"statusCode":201`)
+	assert.NotContains(t, lines[0], "sig=secret")
+	assert.Contains(t, lines[1], `"method":"DELETE"`)
+
+	n, err := audit.Verify(strings.NewReader(string(data)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestExecute_AuditLogFailureIsAnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.AuditLog = filepath.Join(t.TempDir(), "missing", "audit.jsonl")
+
+	err := svc.Execute(context.Background(), cfg, "POST", srv.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not recorded in the audit log")
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// Recognized values for the --auto-scope flag, which decides what happens
+// when a 401 response's WWW-Authenticate challenge names a resource other
+// than the one the token was requested for.
+const (
+	autoScopeSuggest = "suggest"
+	autoScopeRetry   = "retry"
+	autoScopeOff     = "off"
+)
+
+// validateAutoScope reports an error for an unrecognized --auto-scope value.
+// An empty value is treated as the default (suggest).
+func validateAutoScope(mode string) error {
+	switch mode {
+	case "", autoScopeSuggest, autoScopeRetry, autoScopeOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid --auto-scope value %q (expected suggest, retry, or off)", mode)
+	}
+}
+
+// bearerChallenge is what a Microsoft Entra protected API advertises in the
+// WWW-Authenticate header of a 401 response.
+type bearerChallenge struct {
+	scope  string
+	tenant string
+}
+
+// challengeParam matches a key=value or key="value" auth-param.
+var challengeParam = regexp.MustCompile(`([A-Za-z_]+)\s*=\s*(?:"([^"]*)"|([^\s,]+))`)
+
+// parseBearerChallenge reads the Bearer challenge from WWW-Authenticate
+// values. Services spell the resource differently: Key Vault sends
+// resource and authorization, Storage sends resource_id and
+// authorization_uri, and some APIs send scope directly. It reports false
+// when no challenge names a resource.
+func parseBearerChallenge(values []string) (bearerChallenge, bool) {
+	for _, value := range values {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			continue
+		}
+		params := map[string]string{}
+		for _, m := range challengeParam.FindAllStringSubmatch(rest, -1) {
+			params[strings.ToLower(m[1])] = m[2] + m[3]
+		}
+		// A Docker registry token challenge, as sent by a container
+		// registry, names a registry permission rather than a resource.
+		if params["service"] != "" {
+			continue
+		}
+
+		var ch bearerChallenge
+		if scopes := strings.Fields(params["scope"]); len(scopes) > 0 {
+			ch.scope = scopes[0]
+		} else if resource := firstNonEmptyString(params["resource"], params["resource_id"]); resource != "" && !strings.ContainsAny(resource, " \t") {
+			ch.scope = strings.TrimRight(resource, "/") + "/.default"
+		}
+		if ch.scope == "" {
+			continue
+		}
+		if u, err := url.Parse(firstNonEmptyString(params["authorization_uri"], params["authorization"])); err == nil {
+			tenant, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+			switch strings.ToLower(tenant) {
+			case "", "common", "organizations", "consumers":
+			default:
+				ch.tenant = tenant
+			}
+		}
+		return ch, true
+	}
+	return bearerChallenge{}, false
+}
+
+// challengeAuth reports whether --auto-scope applies: a token is being sent
+// and it comes from the Azure credential or a credential plugin, both of
+// which take a scope.
+func challengeAuth(cfg config.Config, opts client.RequestOptions) bool {
+	if opts.SkipAuth || opts.TokenProvider == nil {
+		return false
+	}
+	mode := strings.TrimSpace(cfg.Auth)
+	return mode == "" || mode == authModeAzure || strings.HasPrefix(mode, authExecPrefix)
+}
+
+// followAuthChallenge reads the WWW-Authenticate challenge of a 401 response
+// and points at the scope it advertises. With --auto-scope retry, a request
+// to a host with no known scope is sent again with a token for that scope,
+// so an unfamiliar Entra protected API works without knowing its scope.
+// opts is updated to describe the request that produced the returned
+// response.
+func (s *RequestService) followAuthChallenge(ctx context.Context, cfg config.Config, httpClient *client.Client, opts *client.RequestOptions, resp *client.Response) (*client.Response, error) {
+	if resp.StatusCode != http.StatusUnauthorized || cfg.AutoScope == autoScopeOff || !challengeAuth(cfg, *opts) {
+		return resp, nil
+	}
+	ch, ok := parseBearerChallenge(resp.Headers.Values("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+
+	if strings.EqualFold(ch.scope, opts.Scope) {
+		s.suggestChallengeTenant(ctx, cfg, *opts, ch)
+		return resp, nil
+	}
+	if cfg.AutoScope != autoScopeRetry || cfg.Scope != "" {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> The 401 challenge asks for a token for %s; rerun with --scope %s, or with --auto-scope retry to retry automatically\n", ch.scope, ch.scope)
+		return resp, nil
+	}
+
+	// Only a host with no known scope is retried, and never with a token
+	// for a well-known Azure service, so a server cannot ask for a token it
+	// should not receive.
+	reqURL, err := url.Parse(opts.URL)
+	if err != nil || !strings.EqualFold(reqURL.Scheme, "https") {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> Not retrying the 401: a token is only requested for an https URL\n")
+		return resp, nil
+	}
+	if detected, _ := client.DetectScope(opts.URL); detected != "" {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> Not retrying the 401: %s is an Azure service that takes %s; use --scope %s to override\n", reqURL.Host, detected, ch.scope)
+		return resp, nil
+	}
+	if known, _ := client.DetectScope(strings.TrimSuffix(ch.scope, "/.default")); known != "" {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: not retrying the 401: %s asks for a %s token, which is only sent to that service\n", reqURL.Host, ch.scope)
+		return resp, nil
+	}
+	if seeker, ok := opts.Body.(io.Seeker); opts.Body != nil && (!ok || seekStart(seeker) != nil) {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> Not retrying the 401: the request body cannot be sent again; rerun with --scope %s\n", ch.scope)
+		return resp, nil
+	}
+
+	writeDiagnostic(os.Stderr, cfg.Silent, "> Retrying with a token for %s (from the 401 challenge)\n", ch.scope)
+	opts.Scope = ch.scope
+	return httpClient.Execute(ctx, *opts)
+}
+
+func seekStart(s io.Seeker) error {
+	_, err := s.Seek(0, io.SeekStart)
+	return err
+}
+
+// suggestChallengeTenant points at --tenant when the token had the right
+// audience but was issued by a different tenant than the challenge names.
+func (s *RequestService) suggestChallengeTenant(ctx context.Context, cfg config.Config, opts client.RequestOptions, ch bearerChallenge) {
+	if ch.tenant == "" || strings.HasPrefix(strings.TrimSpace(cfg.Auth), authExecPrefix) {
+		return
+	}
+	token, err := opts.TokenProvider.GetToken(ctx, opts.Scope)
+	if err != nil {
+		return
+	}
+	if claims, ok := decodeTokenClaims(token); ok && claims.TenantID != "" && !strings.EqualFold(claims.TenantID, ch.tenant) {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> The 401 challenge names tenant %s, but the token is from tenant %s; rerun with --tenant %s\n", ch.tenant, claims.TenantID, ch.tenant)
+	}
+}
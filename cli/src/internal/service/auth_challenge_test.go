@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopeTokens issues a token that names the scope it was requested for.
+type scopeTokens struct{}
+
+func (scopeTokens) GetToken(_ context.Context, scope string) (string, error) {
+	return "tok:" + scope, nil
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	cases := map[string]struct {
+		header string
+		want   bearerChallenge
+		ok     bool
+	}{
+		"key vault": {
+			header: `Bearer authorization="https://login.microsoftonline.com/72f988bf-86f1-41af-91ab-2d7cd011db47", resource="https://vault.azure.net"`,
+			want:   bearerChallenge{scope: "https://vault.azure.net/.default", tenant: "72f988bf-86f1-41af-91ab-2d7cd011db47"},
+			ok:     true,
+		},
+		"storage, unquoted": {
+			header: `Bearer authorization_uri=https://login.microsoftonline.com/contoso.onmicrosoft.com/oauth2/authorize resource_id=https://storage.azure.com/`,
+			want:   bearerChallenge{scope: "https://storage.azure.com/.default", tenant: "contoso.onmicrosoft.com"},
+			ok:     true,
+		},
+		"scope and common tenant": {
+			header: `bearer realm="", scope="api://corp-api/.default offline_access", authorization_uri="https://login.microsoftonline.com/common/oauth2/authorize"`,
+			want:   bearerChallenge{scope: "api://corp-api/.default"},
+			ok:     true,
+		},
+		"no resource": {header: `Bearer authorization_uri="https://login.windows.net/", error="invalid_token"`},
+		"basic":       {header: `Basic realm="api"`},
+	}
+	for name, tc := range cases {
+		got, ok := parseBearerChallenge([]string{tc.header})
+		assert.Equal(t, tc.ok, ok, name)
+		assert.Equal(t, tc.want, got, name)
+	}
+}
+
+// challengeServer answers 401 with a challenge for api://corp-api unless the
+// request carries a token for it.
+func challengeServer(t *testing.T, auths *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*auths = append(*auths, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer tok:api://corp-api/.default" {
+			w.Header().Set("WWW-Authenticate", `Bearer authorization_uri="https://login.microsoftonline.com/common/oauth2/authorize", resource_id="api://corp-api"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExecute_AutoScopeRetry(t *testing.T) {
+	var auths []string
+	srv := challengeServer(t, &auths)
+
+	svc := newTestService()
+	svc.tokenProviderFactory = func() (client.TokenProvider, error) { return scopeTokens{}, nil }
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Fail = true
+	cfg.AutoScope = autoScopeRetry
+	cfg.Data = `{"name":"demo"}`
+
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodPost, srv.URL+"/items"))
+	assert.Equal(t, []string{"", "Bearer tok:api://corp-api/.default"}, auths)
+	out, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"ok": true`)
+
+	auths = nil
+	cfg.AutoScope = autoScopeSuggest
+	err = svc.Execute(t.Context(), cfg, http.MethodPost, srv.URL+"/items")
+	var failErr *httpFailError
+	require.ErrorAs(t, err, &failErr)
+	assert.Len(t, auths, 1, "the default only suggests the scope")
+}
+
+func TestFollowAuthChallenge_RefusesKnownAzureScope(t *testing.T) {
+	var sent int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Silent = true
+	cfg.AutoScope = autoScopeRetry
+	opts := client.RequestOptions{Method: http.MethodGet, URL: srv.URL, TokenProvider: scopeTokens{}}
+	challenge := &client.Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+		"Www-Authenticate": {`Bearer resource="https://management.azure.com/"`},
+	}}
+
+	got, err := svc.followAuthChallenge(t.Context(), cfg, DefaultHTTPClientFactory(scopeTokens{}, true, 0), &opts, challenge)
+	require.NoError(t, err)
+	assert.Same(t, challenge, got, "an ARM token is never sent to another host")
+	assert.Zero(t, sent)
+	assert.Empty(t, opts.Scope)
+}
+
+func TestValidateAutoScope(t *testing.T) {
+	for _, mode := range []string{"", "suggest", "retry", "off"} {
+		assert.NoError(t, validateAutoScope(mode))
+	}
+	assert.ErrorContains(t, validateAutoScope("always"), `invalid --auto-scope value "always" (expected suggest, retry, or off)`)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// Recognized --auth values. The default (empty or "azure") uses the Azure
+// credential chain with scope detection.
+const (
+	authModeAzure    = "azure"
+	authOAuth2Prefix = "oauth2:"
+	authSASPrefix    = "sas:"
+	authExecPrefix   = "exec:"
+)
+
+// authModeError signals invalid --auth usage: an unknown mode, a missing
+// profile, or a conflicting flag. It reports exit code 2 through the ExitCoder
+// contract so main can map it to a usage failure.
+type authModeError struct{ err error }
+
+// Error returns the underlying message.
+func (e *authModeError) Error() string { return e.err.Error() }
+
+// Unwrap exposes the wrapped error for errors.Is/As.
+func (e *authModeError) Unwrap() error { return e.err }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *authModeError) ExitCode() int { return 2 }
+
+// resolveAuthMode returns the token provider selected by --auth, or nil when
+// the default Azure credential applies. A non-nil provider replaces Azure scope
+// detection entirely, so a non-Entra API never receives an Azure token.
+func (s *RequestService) resolveAuthMode(cfg config.Config) (client.TokenProvider, error) {
+	mode := strings.TrimSpace(cfg.Auth)
+	if mode == "" || mode == authModeAzure {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(mode, authExecPrefix) {
+		// A credential plugin keeps scope detection; see resolveExecAuth.
+		return nil, nil
+	}
+	if !strings.HasPrefix(mode, authOAuth2Prefix) && !strings.HasPrefix(mode, authSASPrefix) {
+		return nil, &authModeError{fmt.Errorf("unknown --auth value %q (expected azure, oauth2:<profile>, sas:<profile>, or exec:<name>)", mode)}
+	}
+	prefix, name, _ := strings.Cut(mode, ":")
+	if cfg.NoAuth || cfg.Scope != "" || len(cfg.AuxTenants) > 0 {
+		return nil, &authModeError{fmt.Errorf("--auth %s cannot be combined with --no-auth, --scope, or --aux-tenant", mode)}
+	}
+	if name == "" {
+		return nil, &authModeError{fmt.Errorf("--auth %s needs a profile name (%s:<profile>)", prefix, prefix)}
+	}
+	if prefix+":" == authSASPrefix {
+		// SAS signs each request URL itself; see applySASAuth.
+		return nil, nil
+	}
+
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := file.OAuth2[name]
+	if !ok {
+		return nil, &authModeError{fmt.Errorf("oauth2 profile %q is not defined in the config file", name)}
+	}
+	return newOAuth2TokenProvider(name, profile, s.lookupEnv, s.getSecret)
+}
+
+// resolveExecAuth returns the credential plugin selected by --auth
+// exec:<name>, or nil for any other mode. Unlike an OAuth2 profile the plugin
+// replaces only the Azure credential: --scope and scope detection still
+// decide which scope it is asked for.
+func (s *RequestService) resolveExecAuth(cfg config.Config, requestURL string) (*execCredentialProvider, error) {
+	mode := strings.TrimSpace(cfg.Auth)
+	if !strings.HasPrefix(mode, authExecPrefix) {
+		return nil, nil
+	}
+	if cfg.NoAuth || len(cfg.AuxTenants) > 0 {
+		return nil, &authModeError{fmt.Errorf("--auth %s cannot be combined with --no-auth or --aux-tenant", mode)}
+	}
+	name := strings.TrimPrefix(mode, authExecPrefix)
+	if name == "" {
+		return nil, &authModeError{fmt.Errorf("--auth exec needs a plugin name (exec:<name>)")}
+	}
+	return s.resolveExecCredential(name, requestURL)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceWithConfigFile returns a test service that reads file instead of the
+// user's config file and resolves env vars from env.
+func serviceWithConfigFile(file config.File, env map[string]string) *RequestService {
+	svc := NewRequestService(
+		func() (client.TokenProvider, error) {
+			return nil, errors.New("azure credential must not be used")
+		},
+		DefaultHTTPClientFactory,
+	)
+	svc.loadConfigFile = func() (config.File, error) { return file, nil }
+	svc.lookupEnv = envLookup(env)
+	svc.getSecret = noSecrets
+	return svc
+}
+
+func TestResolveAuthMode_DefaultIsAzure(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{}, nil)
+	for _, mode := range []string{"", "azure", " azure "} {
+		tp, err := svc.resolveAuthMode(config.Config{Auth: mode})
+		require.NoError(t, err)
+		assert.Nil(t, tp)
+	}
+}
+
+func TestResolveAuthMode_Errors(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{OAuth2: map[string]config.OAuth2Profile{
+		"api": {TokenURL: "https://idp/token", ClientID: "id", ClientSecretEnv: "SECRET"},
+	}}, map[string]string{"SECRET": "s"})
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{"unknown mode", config.Config{Auth: "kerberos"}, "unknown --auth value"},
+		{"missing profile name", config.Config{Auth: "oauth2:"}, "needs a profile name"},
+		{"undefined profile", config.Config{Auth: "oauth2:other"}, `"other" is not defined`},
+		{"conflicts with no-auth", config.Config{Auth: "oauth2:api", NoAuth: true}, "cannot be combined"},
+		{"conflicts with scope", config.Config{Auth: "oauth2:api", Scope: "https://x/.default"}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.resolveAuthMode(tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			var coder exitCoder
+			if errors.As(err, &coder) {
+				assert.Equal(t, 2, coder.ExitCode())
+			}
+		})
+	}
+}
+
+func TestExecute_OAuth2Profile_SendsBearerToken(t *testing.T) {
+	var tokenCalls int32
+	tokenSrv := oauth2TokenServer(t, &tokenCalls, nil)
+
+	var gotAuth string
+	apiSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer apiSrv.Close()
+
+	svc := serviceWithConfigFile(config.File{OAuth2: map[string]config.OAuth2Profile{
+		"api": {TokenURL: tokenSrv.URL + "/token", ClientID: "id", ClientSecretEnv: "SECRET"},
+	}}, map[string]string{"SECRET": "s"})
+
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Auth = "oauth2:api"
+
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "GET", apiSrv.URL+"/data")
+	require.NoError(t, err)
+	defer cleanup()
+	require.IsType(t, &oauth2TokenProvider{}, opts.TokenProvider)
+	opts.TokenProvider.(*oauth2TokenProvider).httpClient = tokenSrv.Client()
+
+	httpClient := DefaultHTTPClientFactory(opts.TokenProvider, true, cfg.Timeout)
+	_, err = httpClient.Execute(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer tok-123", gotAuth)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// authorizationHeader is the canonical name of the Authorization header.
+const authorizationHeader = "Authorization"
+
+// applyAuthShortcuts sets the Authorization header from --user (HTTP Basic) or
+// --bearer-env (a pre-issued token read from an environment variable). Either
+// one supplies its own Authorization header, which also disables Azure token
+// acquisition. The credential never appears in an error message, and the
+// header is redacted in verbose output like any other Authorization header.
+func applyAuthShortcuts(cfg config.Config, headers http.Header, lookupEnv func(string) (string, bool)) error {
+	if cfg.User == "" && cfg.BearerEnv == "" {
+		return nil
+	}
+	if cfg.User != "" && cfg.BearerEnv != "" {
+		return &authModeError{fmt.Errorf("--user and --bearer-env cannot be combined")}
+	}
+	if hasHeader(headers, authorizationHeader) {
+		return &authModeError{fmt.Errorf("--user and --bearer-env cannot be combined with an Authorization header")}
+	}
+	if cfg.Auth != "" && cfg.Auth != authModeAzure {
+		return &authModeError{fmt.Errorf("--user and --bearer-env cannot be combined with --auth %s", cfg.Auth)}
+	}
+
+	if cfg.User != "" {
+		name, _, ok := strings.Cut(cfg.User, ":")
+		if !ok || name == "" {
+			return &authModeError{fmt.Errorf("invalid --user format (expected name:password)")}
+		}
+		headers.Set(authorizationHeader, "Basic "+base64.StdEncoding.EncodeToString([]byte(cfg.User)))
+		return nil
+	}
+
+	token, ok := lookupEnv(cfg.BearerEnv)
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		return fmt.Errorf("--bearer-env: environment variable %s is not set or empty", cfg.BearerEnv)
+	}
+	headers.Set(authorizationHeader, "Bearer "+strings.TrimPrefix(strings.TrimPrefix(token, "Bearer "), "bearer "))
+	return nil
+}
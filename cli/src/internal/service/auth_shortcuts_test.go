@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyAuthShortcuts_Basic(t *testing.T) {
+	headers := http.Header{}
+	err := applyAuthShortcuts(config.Config{User: "alice:p@ss:word"}, headers, envLookup(nil))
+	require.NoError(t, err)
+	// base64("alice:p@ss:word")
+	assert.Equal(t, "Basic YWxpY2U6cEBzczp3b3Jk", headers.Get("Authorization"))
+}
+
+func TestApplyAuthShortcuts_BearerEnv(t *testing.T) {
+	headers := http.Header{}
+	env := envLookup(map[string]string{"API_TOKEN": " Bearer abc123 "})
+	require.NoError(t, applyAuthShortcuts(config.Config{BearerEnv: "API_TOKEN"}, headers, env))
+	assert.Equal(t, "Bearer abc123", headers.Get("Authorization"), "an existing Bearer prefix is not doubled")
+}
+
+func TestApplyAuthShortcuts_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		headers http.Header
+		want    string
+	}{
+		{"both flags", config.Config{User: "a:b", BearerEnv: "X"}, nil, "cannot be combined"},
+		{"authorization header", config.Config{User: "a:b"}, http.Header{"Authorization": {"x"}}, "Authorization header"},
+		{"oauth2 profile", config.Config{BearerEnv: "X", Auth: "oauth2:api"}, nil, "--auth oauth2:api"},
+		{"missing colon", config.Config{User: "alice"}, nil, "expected name:password"},
+		{"empty name", config.Config{User: ":pw"}, nil, "expected name:password"},
+		{"unset env", config.Config{BearerEnv: "MISSING"}, nil, "MISSING is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.headers
+			if headers == nil {
+				headers = http.Header{}
+			}
+			err := applyAuthShortcuts(tt.cfg, headers, envLookup(nil))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.NotContains(t, err.Error(), ":pw")
+		})
+	}
+}
+
+func TestApplyAuthShortcuts_RedactedInVerbose(t *testing.T) {
+	headers := http.Header{}
+	require.NoError(t, applyAuthShortcuts(config.Config{User: "alice:secret"}, headers, envLookup(nil)))
+	redacted := client.RedactSensitiveHeader("Authorization", headers.Get("Authorization"))
+	assert.NotContains(t, redacted, "YWxpY2U6c2VjcmV0")
+}
+
+func TestExecute_BearerEnv_SkipsAzureAuth(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	svc := serviceWithConfigFile(config.File{}, map[string]string{"API_TOKEN": "pre-issued"})
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.BearerEnv = "API_TOKEN"
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Equal(t, "Bearer pre-issued", gotAuth)
+}
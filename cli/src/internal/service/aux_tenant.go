@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// maxAuxTenants is the number of auxiliary tenants Azure Resource Manager
+// accepts in the x-ms-authorization-auxiliary header.
+const maxAuxTenants = 3
+
+// TenantTokenProviderFactory creates a TokenProvider that issues tokens from a
+// specific Microsoft Entra tenant. It is used for --aux-tenant tokens.
+type TenantTokenProviderFactory func(tenantID string) (client.TokenProvider, error)
+
+// DefaultTenantTokenProviderFactory is the production factory. It builds an
+// Azure credential chain pinned to the given tenant.
+func DefaultTenantTokenProviderFactory(tenantID string) (client.TokenProvider, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &credentialTokenProvider{cred: cred}, nil
+}
+
+// credentialTokenProvider adapts an azcore.TokenCredential to TokenProvider.
+type credentialTokenProvider struct {
+	cred azcore.TokenCredential
+}
+
+// GetToken acquires a token for scope from the wrapped credential.
+func (p *credentialTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	tok, err := p.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		return "", err
+	}
+	return tok.Token, nil
+}
+
+// validateAuxTenants checks the --aux-tenant values before any token is
+// acquired: each must be non-empty, and ARM accepts at most three.
+func validateAuxTenants(tenants []string) error {
+	if len(tenants) > maxAuxTenants {
+		return fmt.Errorf("--aux-tenant accepts at most %d tenants, got %d", maxAuxTenants, len(tenants))
+	}
+	for _, tenant := range tenants {
+		if strings.TrimSpace(tenant) == "" {
+			return fmt.Errorf("--aux-tenant value cannot be empty")
+		}
+	}
+	return nil
+}
+
+// applyAuxiliaryTokens acquires a token for the request scope from each
+// auxiliary tenant and sets the x-ms-authorization-auxiliary header, which ARM
+// requires for cross-tenant operations such as resource moves and Azure
+// Lighthouse. The header value is a comma separated list of bearer tokens.
+func (s *RequestService) applyAuxiliaryTokens(ctx context.Context, tenants []string, opts *client.RequestOptions) error {
+	if len(tenants) == 0 {
+		return nil
+	}
+	if opts.SkipAuth || opts.Scope == "" {
+		return fmt.Errorf("--aux-tenant requires an authenticated request with a scope")
+	}
+
+	values := make([]string, 0, len(tenants))
+	for _, tenant := range tenants {
+		tenant = strings.TrimSpace(tenant)
+		tp, err := s.tenantTokenProviderFactory(tenant)
+		if err != nil {
+			return fmt.Errorf("failed to create token provider for auxiliary tenant %s: %w", tenant, err)
+		}
+		token, err := tp.GetToken(ctx, opts.Scope)
+		if err != nil {
+			return fmt.Errorf("failed to get token for auxiliary tenant %s: %w", tenant, err)
+		}
+		values = append(values, "Bearer "+token)
+	}
+	opts.Headers.Set(client.AuxiliaryAuthorizationHeader, strings.Join(values, ", "))
+	return nil
+}
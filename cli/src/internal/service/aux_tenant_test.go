@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantTokenStub returns a factory whose provider issues "token-<tenant>" so
+// tests can tell which tenant each auxiliary token came from.
+func tenantTokenStub(calls *[]string) TenantTokenProviderFactory {
+	return func(tenantID string) (client.TokenProvider, error) {
+		*calls = append(*calls, tenantID)
+		return &client.MockTokenProvider{Token: "token-" + tenantID}, nil
+	}
+}
+
+func TestValidateAuxTenants(t *testing.T) {
+	assert.NoError(t, validateAuxTenants(nil))
+	assert.NoError(t, validateAuxTenants([]string{"t1", "t2", "t3"}))
+
+	err := validateAuxTenants([]string{"t1", "t2", "t3", "t4"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at most 3")
+
+	err = validateAuxTenants([]string{" "})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be empty")
+}
+
+func TestApplyAuxiliaryTokens_SetsHeader(t *testing.T) {
+	var calls []string
+	svc := newTestService()
+	svc.tenantTokenProviderFactory = tenantTokenStub(&calls)
+
+	opts := client.RequestOptions{
+		Headers: http.Header{},
+		Scope:   "https://management.azure.com/.default",
+	}
+	err := svc.applyAuxiliaryTokens(context.Background(), []string{"tenant-a", " tenant-b "}, &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, calls)
+	assert.Equal(t, "Bearer token-tenant-a, Bearer token-tenant-b", opts.Headers.Get(client.AuxiliaryAuthorizationHeader))
+}
+
+func TestApplyAuxiliaryTokens_RequiresAuth(t *testing.T) {
+	var calls []string
+	svc := newTestService()
+	svc.tenantTokenProviderFactory = tenantTokenStub(&calls)
+
+	opts := client.RequestOptions{Headers: http.Header{}, SkipAuth: true}
+	err := svc.applyAuxiliaryTokens(context.Background(), []string{"tenant-a"}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires an authenticated request")
+	assert.Empty(t, calls, "no token should be acquired when auth is skipped")
+}
+
+func TestApplyAuxiliaryTokens_ProviderError(t *testing.T) {
+	svc := newTestService()
+	svc.tenantTokenProviderFactory = func(string) (client.TokenProvider, error) {
+		return &client.MockTokenProvider{Error: fmt.Errorf("tenant not allowed")}, nil
+	}
+
+	opts := client.RequestOptions{Headers: http.Header{}, Scope: "https://management.azure.com/.default"}
+	err := svc.applyAuxiliaryTokens(context.Background(), []string{"tenant-a"}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auxiliary tenant tenant-a")
+	assert.Contains(t, err.Error(), "tenant not allowed")
+}
+
+func TestExecute_AuxTenant_SendsHeader(t *testing.T) {
+	var got string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("x-ms-authorization-auxiliary")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var calls []string
+	svc := NewRequestService(
+		func() (client.TokenProvider, error) { return &client.MockTokenProvider{Token: "primary"}, nil },
+		DefaultHTTPClientFactory,
+	)
+	svc.tenantTokenProviderFactory = tenantTokenStub(&calls)
+
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Scope = "https://management.azure.com/.default"
+	cfg.AuxTenants = []string{"tenant-a"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL+"/subscriptions"))
+	assert.Equal(t, "Bearer token-tenant-a", got)
+}
+
+func TestExecute_AuxTenant_TooMany(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.AuxTenants = []string{"a", "b", "c", "d"}
+
+	err := newTestService().Execute(context.Background(), cfg, "GET", "http://127.0.0.1:1/unused")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at most 3")
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/jmespath-community/go-jmespath"
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// azdEnvKeyPattern matches a valid azd environment variable name.
+var azdEnvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// azdEnvAssignment is one parsed --set-azd-env or --ci-var KEY=<expression>
+// value.
+type azdEnvAssignment struct {
+	key        string
+	expression string
+	compiled   jmespath.JMESPath
+}
+
+// azdEnvValue is a key and the value extracted for it from a response.
+type azdEnvValue struct {
+	Key   string
+	Value string
+}
+
+// azdEnvError signals invalid --set-azd-env or --ci-var usage: a malformed
+// assignment, an invalid expression, or a conflicting flag. It reports exit
+// code 2 through the ExitCoder contract so main can map it to a usage failure.
+type azdEnvError struct{ err error }
+
+// Error returns the underlying message.
+func (e *azdEnvError) Error() string { return e.err.Error() }
+
+// Unwrap exposes the wrapped error for errors.Is/As.
+func (e *azdEnvError) Unwrap() error { return e.err }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *azdEnvError) ExitCode() int { return 2 }
+
+// parseAzdEnvAssignments validates each --set-azd-env value and compiles its
+// JMESPath expression, so a typo fails before any request is sent.
+func parseAzdEnvAssignments(specs []string) ([]azdEnvAssignment, error) {
+	return parseValueAssignments("--set-azd-env", specs)
+}
+
+// parseValueAssignments validates KEY=<expression> values given to flag and
+// compiles each expression.
+func parseValueAssignments(flag string, specs []string) ([]azdEnvAssignment, error) {
+	assignments := make([]azdEnvAssignment, 0, len(specs))
+	for _, spec := range specs {
+		key, expression, ok := strings.Cut(spec, "=")
+		key, expression = strings.TrimSpace(key), strings.TrimSpace(expression)
+		if !ok || expression == "" {
+			return nil, &azdEnvError{fmt.Errorf("invalid %s value %q (expected KEY=<expression>)", flag, spec)}
+		}
+		if !azdEnvKeyPattern.MatchString(key) {
+			return nil, &azdEnvError{fmt.Errorf("invalid %s key %q (letters, digits, and underscores; must not start with a digit)", flag, key)}
+		}
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return nil, &azdEnvError{fmt.Errorf("invalid %s expression for %s: %w", flag, key, err)}
+		}
+		assignments = append(assignments, azdEnvAssignment{key: key, expression: expression, compiled: compiled})
+	}
+	return assignments, nil
+}
+
+// extractAzdEnvValues evaluates each --set-azd-env assignment against a JSON
+// response body.
+func extractAzdEnvValues(body []byte, assignments []azdEnvAssignment) ([]azdEnvValue, error) {
+	return extractValues("--set-azd-env", body, assignments)
+}
+
+// extractValues evaluates each assignment given to flag against a JSON
+// response body. A string result is stored as is; any other result is stored
+// as compact JSON. An expression that matches nothing is an error rather than
+// an empty value.
+func extractValues(flag string, body []byte, assignments []azdEnvAssignment) ([]azdEnvValue, error) {
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("%s requires a JSON response: %w", flag, err)
+	}
+	values := make([]azdEnvValue, 0, len(assignments))
+	for _, a := range assignments {
+		result, err := a.compiled.Search(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", flag, a.key, err)
+		}
+		if result == nil {
+			return nil, fmt.Errorf("%s %s: expression %q matched nothing in the response", flag, a.key, a.expression)
+		}
+		value, ok := result.(string)
+		if !ok {
+			raw, err := json.Marshal(result)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", flag, a.key, err)
+			}
+			value = string(raw)
+		}
+		values = append(values, azdEnvValue{Key: a.key, Value: value})
+	}
+	return values, nil
+}
+
+// setAzdEnvValues writes values into the current azd environment through the
+// azd extension gRPC server. It requires running under azd, which provides the
+// server address and access token.
+func setAzdEnvValues(ctx context.Context, values []azdEnvValue) error {
+	azdClient, err := azdcontext.Connect()
+	if errors.Is(err, azdcontext.ErrNotExtension) {
+		return fmt.Errorf("--set-azd-env requires running as an azd extension (azd rest ...)")
+	}
+	if err != nil {
+		return err
+	}
+	defer azdClient.Close()
+
+	for _, v := range values {
+		if _, err := azdClient.Environment().SetValue(ctx, &azdext.SetEnvRequest{Key: v.Key, Value: v.Value}); err != nil {
+			return fmt.Errorf("failed to set azd environment value %s: %w", v.Key, err)
+		}
+	}
+	return nil
+}
+
+// updateAzdEnv applies --set-azd-env after a response has been written. Values
+// are only taken from a successful (2xx) response; otherwise the environment is
+// left untouched and a warning explains why.
+func (s *RequestService) updateAzdEnv(ctx context.Context, cfg config.Config, status int, body []byte, assignments []azdEnvAssignment) error {
+	if status < 200 || status > 299 {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: azd environment not updated (--set-azd-env) because the request returned HTTP %d\n", status)
+		return nil
+	}
+	values, err := extractAzdEnvValues(body, assignments)
+	if err != nil {
+		return err
+	}
+	if err := s.setAzdEnv(ctx, values); err != nil {
+		return err
+	}
+	for _, v := range values {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Set azd environment value %s\n", v.Key)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordAzdEnv replaces the azd environment writer with one that records the
+// values it receives.
+func recordAzdEnv(svc *RequestService) *[]azdEnvValue {
+	var got []azdEnvValue
+	svc.setAzdEnv = func(_ context.Context, values []azdEnvValue) error {
+		got = append(got, values...)
+		return nil
+	}
+	return &got
+}
+
+func TestParseAzdEnvAssignments(t *testing.T) {
+	got, err := parseAzdEnvAssignments([]string{"ENDPOINT=properties.endpoint", " ID = id "})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "ENDPOINT", got[0].key)
+	assert.Equal(t, "ID", got[1].key)
+	assert.Equal(t, "id", got[1].expression)
+
+	for _, spec := range []string{"NOEXPR", "KEY=", "1KEY=id", "BAD-KEY=id", "KEY=[?"} {
+		_, err := parseAzdEnvAssignments([]string{spec})
+		require.Error(t, err, spec)
+		var usage *azdEnvError
+		assert.True(t, errors.As(err, &usage), spec)
+	}
+}
+
+func TestExtractAzdEnvValues(t *testing.T) {
+	assignments, err := parseAzdEnvAssignments([]string{"NAME=name", "PORTS=ports", "COUNT=count"})
+	require.NoError(t, err)
+
+	got, err := extractAzdEnvValues([]byte(`{"name":"web","ports":[80,443],"count":2}`), assignments)
+	require.NoError(t, err)
+	assert.Equal(t, []azdEnvValue{{"NAME", "web"}, {"PORTS", "[80,443]"}, {"COUNT", "2"}}, got)
+
+	_, err = extractAzdEnvValues([]byte(`{"name":"web"}`), assignments)
+	assert.ErrorContains(t, err, "matched nothing")
+
+	_, err = extractAzdEnvValues([]byte(`not json`), assignments)
+	assert.ErrorContains(t, err, "requires a JSON response")
+}
+
+func TestExecute_SetAzdEnv(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"/subscriptions/s/resourceGroups/rg","properties":{"endpoint":"https://x.example"}}`))
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	got := recordAzdEnv(svc)
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.Query = "id"
+	cfg.SetAzdEnv = []string{"ENDPOINT=properties.endpoint"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Equal(t, []azdEnvValue{{"ENDPOINT", "https://x.example"}}, *got, "expressions see the full response, not the --query result")
+
+	out, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "/subscriptions/s/resourceGroups/rg")
+}
+
+func TestExecute_SetAzdEnv_SkipsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"NotFound"}}`))
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	got := recordAzdEnv(svc)
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.SetAzdEnv = []string{"CODE=error.code"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Empty(t, *got)
+}
+
+func TestExecute_SetAzdEnv_WithRepeat(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.Repeat = 2
+	cfg.SetAzdEnv = []string{"ID=id"}
+
+	err := newTestService().Execute(context.Background(), cfg, "GET", "https://example.invalid")
+	var usage *azdEnvError
+	require.True(t, errors.As(err, &usage))
+	assert.Equal(t, 2, usage.ExitCode())
+}
+
+func TestSetAzdEnvValues_RequiresAzd(t *testing.T) {
+	t.Setenv("AZD_SERVER", "")
+	err := setAzdEnvValues(context.Background(), []azdEnvValue{{"K", "v"}})
+	assert.ErrorContains(t, err, "requires running as an azd extension")
+}
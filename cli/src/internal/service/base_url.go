@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// applyBaseURL resolves a request path that starts with "/" against
+// --base-url, or baseUrl in the config file when the flag is not set. The
+// path is appended to the base, so a base with a path of its own, such as
+// https://example.com/api/v2, keeps it. An absolute URL is returned unchanged.
+func (s *RequestService) applyBaseURL(cfg config.Config, rawURL string) (string, error) {
+	if !strings.HasPrefix(rawURL, "/") {
+		return rawURL, nil
+	}
+	base := strings.TrimSpace(cfg.BaseURL)
+	if base == "" {
+		file, err := s.loadConfigFile()
+		if err != nil {
+			return "", err
+		}
+		base = strings.TrimSpace(file.BaseURL)
+	}
+	if base == "" {
+		return "", fmt.Errorf("%s is a relative path; set --base-url, AZD_REST_BASE_URL, or baseUrl in the config file, or pass a full URL", rawURL)
+	}
+	parsed, err := url.Parse(base)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q (expected an http:// or https:// URL)", base)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q (it must not have a query or fragment)", base)
+	}
+	return strings.TrimRight(base, "/") + rawURL, nil
+}
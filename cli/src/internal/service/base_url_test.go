@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBaseURL(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{}, nil)
+	tests := []struct {
+		base, url, want string
+	}{
+		{"https://graph.microsoft.com", "/v1.0/me", "https://graph.microsoft.com/v1.0/me"},
+		{"https://graph.microsoft.com/", "/v1.0/me", "https://graph.microsoft.com/v1.0/me"},
+		{"https://example.com/api/v2", "/items?top=5", "https://example.com/api/v2/items?top=5"},
+		{"https://graph.microsoft.com", "https://management.azure.com/subscriptions", "https://management.azure.com/subscriptions"},
+		{"", "https://management.azure.com/subscriptions", "https://management.azure.com/subscriptions"},
+	}
+	for _, tt := range tests {
+		got, err := svc.applyBaseURL(config.Config{BaseURL: tt.base}, tt.url)
+		require.NoError(t, err, tt.url)
+		assert.Equal(t, tt.want, got, tt.url)
+	}
+}
+
+func TestApplyBaseURL_FromConfigFile(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{BaseURL: "https://graph.microsoft.com"}, nil)
+	got, err := svc.applyBaseURL(config.Config{}, "/v1.0/me")
+	require.NoError(t, err)
+	assert.Equal(t, "https://graph.microsoft.com/v1.0/me", got)
+
+	got, err = svc.applyBaseURL(config.Config{BaseURL: "https://example.com"}, "/v1.0/me")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v1.0/me", got, "--base-url overrides the config file")
+}
+
+func TestApplyBaseURL_Errors(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{}, nil)
+	_, err := svc.applyBaseURL(config.Config{}, "/v1.0/me")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--base-url")
+
+	for _, base := range []string{"graph.microsoft.com", "ftp://example.com", "https://", "https://example.com?x=1"} {
+		_, err := svc.applyBaseURL(config.Config{BaseURL: base}, "/v1.0/me")
+		require.Error(t, err, base)
+		assert.Contains(t, err.Error(), "invalid base URL", base)
+	}
+}
+
+func TestExecute_RelativePathUsesBaseURL(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.BaseURL = server.URL + "/api"
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", "/items?top=5"))
+	assert.Equal(t, "/api/items?top=5", gotPath)
+}
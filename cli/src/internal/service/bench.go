@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// maxBenchConcurrency caps --concurrency for azd rest bench. It matches the
+// idle pool kept per host, so every worker can reuse a warm connection.
+const maxBenchConcurrency = 64
+
+// BenchOptions controls an azd rest bench run.
+type BenchOptions struct {
+	Method      string
+	URL         string
+	Requests    int
+	Concurrency int
+	// JSON writes the report as a JSON object instead of text.
+	JSON bool
+}
+
+// benchLatency holds latency statistics in milliseconds.
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// benchReport is the outcome of a bench run.
+type benchReport struct {
+	Method         string         `json:"method"`
+	URL            string         `json:"url"`
+	Requests       int            `json:"requests"`
+	Concurrency    int            `json:"concurrency"`
+	Succeeded      int            `json:"succeeded"`
+	Failed         int            `json:"failed"`
+	Errors         int            `json:"errors"`
+	DurationMs     float64        `json:"durationMs"`
+	RequestsPerSec float64        `json:"requestsPerSec"`
+	StatusCodes    map[string]int `json:"statusCodes"`
+	LatencyMs      *benchLatency  `json:"latencyMs,omitempty"`
+}
+
+// ExecuteBench sends opts.Requests copies of one request with at most
+// opts.Concurrency in flight and writes latency percentiles, throughput, and
+// the status code distribution to out. The request is built once, so the
+// token is acquired once and every request shares the same client and
+// connection pool. Response bodies are discarded. It returns an error when
+// every request failed to get a response.
+func (s *RequestService) ExecuteBench(ctx context.Context, cfg config.Config, opts BenchOptions, out io.Writer) error {
+	if opts.Requests < 1 {
+		return fmt.Errorf("--requests must be at least 1, got %d", opts.Requests)
+	}
+	if opts.Concurrency < 1 || opts.Concurrency > maxBenchConcurrency {
+		return fmt.Errorf("--concurrency must be between 1 and %d, got %d", maxBenchConcurrency, opts.Concurrency)
+	}
+	if err := validateAuxTenants(cfg.AuxTenants); err != nil {
+		return err
+	}
+	if err := s.configureTransport(cfg); err != nil {
+		return err
+	}
+
+	url, err := s.applyBaseURL(cfg, opts.URL)
+	if err != nil {
+		return err
+	}
+	url, err = s.injectSubscription(ctx, cfg, url)
+	if err != nil {
+		return err
+	}
+	reqOpts, cleanup, err := s.BuildRequestOptions(cfg, opts.Method, url)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := s.applyAuxiliaryTokens(ctx, cfg.AuxTenants, &reqOpts); err != nil {
+		return err
+	}
+
+	// Buffer the body so every request gets a fresh reader.
+	var bodyBytes []byte
+	if reqOpts.Body != nil {
+		if bodyBytes, err = io.ReadAll(reqOpts.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	if !safeMethods[reqOpts.Method] {
+		fmt.Fprintf(os.Stderr, "Warning: sending a %s request %d times may cause side effects.\n", reqOpts.Method, opts.Requests)
+	}
+
+	if cfg.MaxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.MaxTime)
+		defer cancel()
+	}
+	httpClient := s.httpClientFactory(reqOpts.TokenProvider, cfg.Insecure, cfg.Timeout)
+
+	var (
+		next     atomic.Int64
+		mu       sync.Mutex
+		stats    = repeatStats{total: opts.Requests, statusCounts: make(map[int]int), durations: make([]time.Duration, 0, opts.Requests)}
+		errCount int
+		firstErr error
+		auditErr error
+		wg       sync.WaitGroup
+	)
+	started := time.Now()
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next.Add(1) <= int64(opts.Requests) && ctx.Err() == nil {
+				o := reqOpts
+				if bodyBytes != nil {
+					o.Body = bytes.NewReader(bodyBytes)
+				}
+				sent := time.Now()
+				resp, err := httpClient.Execute(ctx, o)
+				recErr := recordAudit(cfg, o, sent, resp, err)
+
+				mu.Lock()
+				if recErr != nil && auditErr == nil {
+					auditErr = recErr
+				}
+				switch {
+				case err != nil:
+					errCount++
+					stats.failed++
+					if firstErr == nil {
+						firstErr = err
+					}
+				default:
+					stats.durations = append(stats.durations, resp.Duration)
+					stats.statusCounts[resp.StatusCode]++
+					if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+						stats.success++
+					} else {
+						stats.failed++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	if auditErr != nil {
+		return fmt.Errorf("bench requests sent but not recorded in the audit log: %w", auditErr)
+	}
+	report := newBenchReport(reqOpts, opts, stats, errCount, elapsed)
+	if err := writeBenchReport(out, report, opts.JSON); err != nil {
+		return fmt.Errorf("failed to write bench report: %w", err)
+	}
+	if len(stats.durations) == 0 {
+		if firstErr != nil {
+			return fmt.Errorf("all %d requests failed: %w", report.Requests, firstErr)
+		}
+		return ctx.Err()
+	}
+	if cfg.Fail && stats.failed > 0 {
+		return fmt.Errorf("%d of %d bench requests failed", stats.failed, report.Requests)
+	}
+	return nil
+}
+
+// newBenchReport summarizes the collected statistics. Requests counts the
+// requests actually sent, which is fewer than asked when --max-time or an
+// interrupt stopped the run early.
+func newBenchReport(reqOpts client.RequestOptions, opts BenchOptions, stats repeatStats, errCount int, elapsed time.Duration) benchReport {
+	sent := len(stats.durations) + errCount
+	report := benchReport{
+		Method:      reqOpts.Method,
+		URL:         client.RedactURL(reqOpts.URL),
+		Requests:    sent,
+		Concurrency: opts.Concurrency,
+		Succeeded:   stats.success,
+		Failed:      stats.failed,
+		Errors:      errCount,
+		DurationMs:  durationMs(elapsed),
+		StatusCodes: make(map[string]int, len(stats.statusCounts)),
+	}
+	if elapsed > 0 {
+		report.RequestsPerSec = float64(sent) / elapsed.Seconds()
+	}
+	for code, n := range stats.statusCounts {
+		report.StatusCodes[strconv.Itoa(code)] = n
+	}
+	if len(stats.durations) > 0 {
+		sorted := append([]time.Duration(nil), stats.durations...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		report.LatencyMs = &benchLatency{
+			Min:  durationMs(sorted[0]),
+			Mean: durationMs(meanDuration(sorted)),
+			P50:  durationMs(percentile(sorted, 50)),
+			P90:  durationMs(percentile(sorted, 90)),
+			P95:  durationMs(percentile(sorted, 95)),
+			P99:  durationMs(percentile(sorted, 99)),
+			Max:  durationMs(sorted[len(sorted)-1]),
+		}
+	}
+	return report
+}
+
+// writeBenchReport prints the report to w as text or indented JSON.
+func writeBenchReport(w io.Writer, r benchReport, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s\n", r.Method, r.URL)
+	fmt.Fprintf(&b, "  Requests:    %d (concurrency %d) in %.2fs\n", r.Requests, r.Concurrency, r.DurationMs/1000)
+	fmt.Fprintf(&b, "  Throughput:  %.2f req/s\n", r.RequestsPerSec)
+	fmt.Fprintf(&b, "  Success: %d   Failed: %d   Errors: %d\n", r.Succeeded, r.Failed, r.Errors)
+	if len(r.StatusCodes) > 0 {
+		codes := make([]string, 0, len(r.StatusCodes))
+		for code := range r.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		b.WriteString("  Status:")
+		for _, code := range codes {
+			fmt.Fprintf(&b, "  %s: %d", code, r.StatusCodes[code])
+		}
+		b.WriteString("\n")
+	}
+	if l := r.LatencyMs; l != nil {
+		fmt.Fprintf(&b, "  Latency: min %.2fms  mean %.2fms  p50 %.2fms  p90 %.2fms  p95 %.2fms  p99 %.2fms  max %.2fms\n",
+			l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// durationMs converts d to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteBench_ReportsLatencyAndStatus(t *testing.T) {
+	var hits, inFlight, peak atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if hits.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	var out bytes.Buffer
+	err := newTestService().ExecuteBench(context.Background(), cfg, BenchOptions{
+		Method: "GET", URL: srv.URL, Requests: 20, Concurrency: 4, JSON: true,
+	}, &out)
+	require.NoError(t, err)
+
+	var report benchReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, int64(20), hits.Load())
+	assert.LessOrEqual(t, peak.Load(), int64(4))
+	assert.Equal(t, 20, report.Requests)
+	assert.Equal(t, 15, report.Succeeded)
+	assert.Equal(t, 5, report.Failed)
+	assert.Equal(t, 0, report.Errors)
+	assert.Equal(t, map[string]int{"200": 15, "429": 5}, report.StatusCodes)
+	assert.Positive(t, report.RequestsPerSec)
+	require.NotNil(t, report.LatencyMs)
+	assert.LessOrEqual(t, report.LatencyMs.Min, report.LatencyMs.P50)
+	assert.LessOrEqual(t, report.LatencyMs.P50, report.LatencyMs.P99)
+	assert.LessOrEqual(t, report.LatencyMs.P99, report.LatencyMs.Max)
+}
+
+func TestExecuteBench_ResendsBodyAndHonorsFail(t *testing.T) {
+	var bodies atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, _ := io.ReadAll(r.Body); string(b) == `{"n":1}` {
+			bodies.Add(1)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Data = `{"n":1}`
+	cfg.Fail = true
+	var out bytes.Buffer
+	err := newTestService().ExecuteBench(context.Background(), cfg, BenchOptions{
+		Method: "GET", URL: srv.URL, Requests: 3, Concurrency: 2,
+	}, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 of 3 bench requests failed")
+	assert.Equal(t, int64(3), bodies.Load())
+	assert.Contains(t, out.String(), "Status:  400: 3")
+	assert.Contains(t, out.String(), "Latency: min")
+}
+
+func TestExecuteBench_AllRequestsFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("response larger than the limit"))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MaxResponseSize = 4
+	var out bytes.Buffer
+	err := newTestService().ExecuteBench(context.Background(), cfg, BenchOptions{
+		Method: "GET", URL: srv.URL, Requests: 2, Concurrency: 1,
+	}, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all 2 requests failed")
+	assert.Contains(t, out.String(), "Errors: 2")
+	assert.NotContains(t, out.String(), "Latency:")
+}
+
+func TestExecuteBench_ValidatesCounts(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	err := svc.ExecuteBench(context.Background(), cfg, BenchOptions{Method: "GET", URL: "https://example.com", Requests: 0, Concurrency: 1}, io.Discard)
+	require.ErrorContains(t, err, "--requests must be at least 1")
+	err = svc.ExecuteBench(context.Background(), cfg, BenchOptions{Method: "GET", URL: "https://example.com", Requests: 1, Concurrency: 65}, io.Discard)
+	require.ErrorContains(t, err, "--concurrency must be between 1 and 64")
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// templateVarNamePattern matches a --var name usable as {{ .name }}.
+var templateVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// bodyTemplateFuncs are the functions available in a body template. json
+// encodes a value as a JSON string, so a value with quotes or newlines cannot
+// break the surrounding document.
+var bodyTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+}
+
+// bodyTemplateValues returns the values a body template can reference: the
+// azd environment, which azd passes to the extension as environment
+// variables, overridden by each --var name=value.
+func (s *RequestService) bodyTemplateValues(vars []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, kv := range s.environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok && templateVarNamePattern.MatchString(name) {
+			values[name] = value
+		}
+	}
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || !templateVarNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid --var %q (expected name=value, where name is a letter or underscore followed by letters, digits, or underscores)", v)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// bodyTemplated reports whether the body is rendered as a template: only when
+// --var is set, so a body that happens to contain {{ is never rewritten, and
+// never for --data-raw, --data-binary, or --data-urlencode, which send their
+// content as given.
+func bodyTemplated(cfg config.Config) bool {
+	return len(cfg.TemplateVars) > 0 && cfg.DataRaw == "" && cfg.DataBinary == "" && len(cfg.DataURLEncode) == 0
+}
+
+// renderBodyTemplate renders a --data, --data-file, or --json body as a Go
+// template when bodyTemplated allows it, and returns it unchanged otherwise. A
+// reference to a name that has no value is an error rather than an empty
+// string.
+func (s *RequestService) renderBodyTemplate(cfg config.Config, body []byte) ([]byte, error) {
+	if !bodyTemplated(cfg) {
+		return body, nil
+	}
+	values, err := s.bodyTemplateValues(cfg.TemplateVars)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("body").Funcs(bodyTemplateFuncs).Option("missingkey=error").Parse(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the request body template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return nil, fmt.Errorf("failed to render the request body template: %w", err)
+	}
+	return out.Bytes(), nil
+}
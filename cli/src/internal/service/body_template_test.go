@@ -0,0 +1,94 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTemplateTestService(env ...string) *RequestService {
+	svc := newTestService()
+	svc.environ = func() []string { return env }
+	return svc
+}
+
+func TestBuildRequestOptions_BodyTemplate(t *testing.T) {
+	svc := newTemplateTestService("AZURE_LOCATION=eastus", "NAME=from-env")
+	cfg := baseTestConfig(t)
+	cfg.Data = `{"name":{{ json .NAME }},"location":"{{ .AZURE_LOCATION }}"}`
+	cfg.TemplateVars = []string{`NAME=say "hi"`}
+
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "PUT", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.JSONEq(t, `{"name":"say \"hi\"","location":"eastus"}`, string(body))
+}
+
+func TestBuildRequestOptions_BodyTemplateFromFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "body.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"sku":"{{ .sku }}"}`), 0o600))
+	yamlFile := filepath.Join(dir, "body.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("sku: {{ .sku }}\n"), 0o600))
+
+	for _, file := range []string{jsonFile, yamlFile} {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.DataFile = file
+			cfg.TemplateVars = []string{"sku=Standard_LRS"}
+
+			opts, cleanup, err := newTemplateTestService().BuildRequestOptions(cfg, "PUT", "https://example.com")
+			require.NoError(t, err)
+			defer cleanup()
+			body, _ := io.ReadAll(opts.Body)
+			assert.JSONEq(t, `{"sku":"Standard_LRS"}`, string(body))
+			assert.Equal(t, applicationJSON, opts.Headers.Get(contentTypeHeader))
+		})
+	}
+}
+
+func TestBuildRequestOptions_BodyTemplateOnlyWithVar(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.Data = `{"text":"{{ .unset }}"}`
+	opts, cleanup, err := newTemplateTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.Equal(t, `{"text":"{{ .unset }}"}`, string(body))
+
+	cfg = baseTestConfig(t)
+	cfg.DataRaw = `{{ .a }}`
+	cfg.TemplateVars = []string{"a=1"}
+	opts, cleanup, err = newTemplateTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ = io.ReadAll(opts.Body)
+	assert.Equal(t, `{{ .a }}`, string(body), "--data-raw is never templated")
+}
+
+func TestBuildRequestOptions_BodyTemplateErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		vars []string
+		want string
+	}{
+		{name: "missing value", data: `{{ .missing }}`, vars: []string{"a=1"}, want: "failed to render"},
+		{name: "bad syntax", data: `{{ .a `, vars: []string{"a=1"}, want: "failed to parse"},
+		{name: "no equals", data: `{{ .a }}`, vars: []string{"a"}, want: "invalid --var"},
+		{name: "bad name", data: `{{ .a }}`, vars: []string{"a-b=1"}, want: "invalid --var"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.Data = tt.data
+			cfg.TemplateVars = tt.vars
+			_, _, err := newTemplateTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
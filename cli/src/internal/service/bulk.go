@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// maxBulkConcurrency caps --concurrency for azd rest bulk.
+const maxBulkConcurrency = 64
+
+// maxBulkLineBytes is the longest NDJSON input line accepted.
+const maxBulkLineBytes = 16 << 20
+
+// bulkRequest is one NDJSON input line. Body may be any JSON value: a string
+// is sent as-is and anything else is sent as compact JSON. ID is optional and
+// echoed in the result so callers can correlate lines.
+type bulkRequest struct {
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// bulkResult is one NDJSON output line. Body holds the response as JSON when
+// it parses, otherwise as a JSON string.
+type bulkResult struct {
+	Line       int             `json:"line"`
+	ID         json.RawMessage `json:"id,omitempty"`
+	Method     string          `json:"method,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	Status     int             `json:"status,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// bulkJob pairs an input line number with its raw text.
+type bulkJob struct {
+	line int
+	raw  []byte
+}
+
+// BulkOptions controls how ExecuteBulk schedules requests.
+type BulkOptions struct {
+	// Concurrency is the maximum number of requests in flight.
+	Concurrency int
+	// CircuitBreaker opens a host's circuit after this many 5xx or 429
+	// responses in a row from it. Zero disables the breaker.
+	CircuitBreaker int
+	// CircuitCooldown is how long an open circuit pauses requests to its host
+	// before one probe request is sent. Zero skips every later request to the
+	// host instead.
+	CircuitCooldown time.Duration
+}
+
+// ExecuteBulk runs one request per NDJSON line of in with at most
+// opts.Concurrency requests in flight, and writes one NDJSON result per request to out in
+// completion order. Every request shares cfg's flags, with per-line headers
+// added after the global ones. A single token provider is shared so a token
+// is acquired once, not once per line, and the request variables, the
+// project's request directory, and the azd subscription are read once. It
+// returns an error when any line failed to run, or, with --fail, returned a
+// 4xx or 5xx status.
+func (s *RequestService) ExecuteBulk(ctx context.Context, cfg config.Config, in io.Reader, out io.Writer, opts BulkOptions) error {
+	if opts.Concurrency < 1 || opts.Concurrency > maxBulkConcurrency {
+		return fmt.Errorf("--concurrency must be between 1 and %d, got %d", maxBulkConcurrency, opts.Concurrency)
+	}
+	if opts.CircuitBreaker < 0 {
+		return fmt.Errorf("--circuit-breaker must be 0 or more, got %d", opts.CircuitBreaker)
+	}
+	if opts.CircuitCooldown < 0 {
+		return fmt.Errorf("--circuit-cooldown must not be negative, got %s", opts.CircuitCooldown)
+	}
+	if opts.CircuitCooldown > 0 && opts.CircuitBreaker == 0 {
+		return fmt.Errorf("--circuit-cooldown requires --circuit-breaker")
+	}
+	if err := validateAuxTenants(cfg.AuxTenants); err != nil {
+		return err
+	}
+	if err := s.configureTransport(cfg); err != nil {
+		return err
+	}
+	if cfg.MaxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.MaxTime)
+		defer cancel()
+	}
+
+	limiter, err := newHostLimiter(cfg.HostLimits)
+	if err != nil {
+		return err
+	}
+
+	shared := *s
+	shared.limiter = limiter
+	var (
+		tpOnce sync.Once
+		tp     client.TokenProvider
+		tpErr  error
+	)
+	shared.tokenProviderFactory = func() (client.TokenProvider, error) {
+		tpOnce.Do(func() { tp, tpErr = s.tokenProviderFactory() })
+		return tp, tpErr
+	}
+	var (
+		varsOnce sync.Once
+		vars     config.Vars
+		varsErr  error
+	)
+	shared.loadRequestVars = func() (config.Vars, error) {
+		varsOnce.Do(func() { vars, varsErr = s.loadRequestVars() })
+		return vars, varsErr
+	}
+	var (
+		projectOnce sync.Once
+		project     config.Vars
+		projectErr  error
+	)
+	shared.loadProjectVars = func() (config.Vars, error) {
+		projectOnce.Do(func() { project, projectErr = s.loadProjectVars() })
+		return project, projectErr
+	}
+	var (
+		subOnce sync.Once
+		sub     string
+		subErr  error
+	)
+	shared.subscriptionID = func(ctx context.Context) (string, error) {
+		subOnce.Do(func() { sub, subErr = s.subscriptionID(ctx) })
+		return sub, subErr
+	}
+
+	var (
+		outMu           sync.Mutex
+		total, failures int
+		encodeErr       error
+	)
+	emit := func(res bulkResult) {
+		outMu.Lock()
+		defer outMu.Unlock()
+		total++
+		if res.Error != "" || (cfg.Fail && res.Status >= 400) {
+			failures++
+		}
+		line, err := json.Marshal(res)
+		if err == nil {
+			_, err = fmt.Fprintf(out, "%s\n", line)
+		}
+		if err != nil && encodeErr == nil {
+			encodeErr = err
+		}
+	}
+
+	breaker := newCircuitBreaker(opts.CircuitBreaker, opts.CircuitCooldown)
+	jobs := make(chan bulkJob)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				emit(shared.runBulkLine(ctx, cfg, job, breaker))
+			}
+		}()
+	}
+
+	// An interrupt or --max-time stops reading input. Lines already sent
+	// finish or are canceled and still get a result, so every line before
+	// the first one not run has a result.
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBulkLineBytes)
+	lineNo, firstNotRun := 0, 0
+	for firstNotRun == 0 && scanner.Scan() {
+		lineNo++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		select {
+		case jobs <- bulkJob{line: lineNo, raw: append([]byte(nil), raw...)}:
+		case <-ctx.Done():
+			firstNotRun = lineNo
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read bulk input after line %d: %w", lineNo, err)
+	}
+	if encodeErr != nil {
+		return fmt.Errorf("failed to write bulk results: %w", encodeErr)
+	}
+	if !cfg.Silent {
+		limiter.writeSummary(os.Stderr)
+		breaker.writeSummary(os.Stderr)
+	}
+	if stopErr := stopError(ctx, cfg); stopErr != nil {
+		resume := "every line was sent"
+		if firstNotRun > 0 {
+			resume = fmt.Sprintf("lines from %d were not run", firstNotRun)
+		}
+		writeDiagnostic(os.Stderr, cfg.Silent, "Bulk: stopped (%s) after %d request(s), %d failed; %s\n", stopErr, total, failures, resume)
+		return fmt.Errorf("%w: %d bulk request(s) run, %s", stopErr, total, resume)
+	}
+	writeDiagnostic(os.Stderr, cfg.Silent, "Bulk: %d request(s), %d failed\n", total, failures)
+	if failures > 0 {
+		return fmt.Errorf("%d of %d bulk requests failed", failures, total)
+	}
+	return nil
+}
+
+// runBulkLine parses and executes one input line. Errors are reported in the
+// result rather than returned so one bad line does not stop the run. The line
+// waits for or is skipped by breaker when its host's circuit is open.
+func (s *RequestService) runBulkLine(ctx context.Context, cfg config.Config, job bulkJob, breaker *circuitBreaker) bulkResult {
+	res := bulkResult{Line: job.line}
+	var req bulkRequest
+	if err := json.Unmarshal(job.raw, &req); err != nil {
+		res.Error = fmt.Sprintf("invalid JSON: %v", err)
+		return res
+	}
+	res.ID = req.ID
+	res.Method = strings.ToUpper(strings.TrimSpace(req.Method))
+	if res.Method == "" {
+		res.Method = "GET"
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		res.Error = "url is required"
+		return res
+	}
+	res.URL = client.RedactURL(req.URL)
+
+	lineCfg := cfg
+	lineHeaders := make([]string, 0, len(req.Headers))
+	for k, v := range req.Headers {
+		lineHeaders = append(lineHeaders, k+": "+v)
+	}
+	// A line's header replaces the global -H of the same name.
+	lineCfg.Headers = overrideHeaderArgs(cfg.Headers, lineHeaders)
+	lineCfg.Data, lineCfg.DataFile = "", ""
+	lineCfg.DataRaw, lineCfg.DataBinary, lineCfg.JSONData, lineCfg.DataURLEncode = "", "", "", nil
+	lineCfg.FormFields, lineCfg.JSONFields, lineCfg.JSONFieldsRaw = nil, nil, nil
+	lineCfg.TemplateVars = nil
+	if len(req.Body) > 0 && string(req.Body) != "null" {
+		var text string
+		if json.Unmarshal(req.Body, &text) == nil {
+			lineCfg.Data = text
+		} else {
+			lineCfg.Data = string(req.Body)
+			// A global or per-line Content-Type still wins.
+			lineCfg.Headers = WithDefaultHeader(lineCfg.Headers, contentTypeHeader, applicationJSON)
+		}
+	}
+
+	host := s.bulkHost(lineCfg, req.URL)
+	if err := breaker.acquire(ctx, host); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	start := time.Now()
+	resp, err := s.doBulkRequest(ctx, lineCfg, res.Method, req.URL)
+	res.DurationMs = time.Since(start).Milliseconds()
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	breaker.record(host, status)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Status = resp.StatusCode
+	res.Body = bulkResultBody(resp.Body)
+	return res
+}
+
+// bulkHost returns the host a bulk request is sent to, or "" when its URL
+// cannot be resolved, which doBulkRequest then reports.
+func (s *RequestService) bulkHost(cfg config.Config, rawURL string) string {
+	resolved, err := s.applyBaseURL(cfg, rawURL)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(resolved)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// doBulkRequest builds and sends one request through the same option builder
+// as Execute, applying --query to the response.
+func (s *RequestService) doBulkRequest(ctx context.Context, cfg config.Config, method, rawURL string) (*client.Response, error) {
+	target, err := s.applyBaseURL(cfg, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	target, err = s.injectSubscription(ctx, cfg, target)
+	if err != nil {
+		return nil, err
+	}
+	opts, cleanup, err := s.BuildRequestOptions(cfg, method, target)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if err := s.applyAuxiliaryTokens(ctx, cfg.AuxTenants, &opts); err != nil {
+		return nil, err
+	}
+	var host string
+	if u, err := url.Parse(opts.URL); err == nil {
+		host = u.Hostname()
+	}
+	done, err := s.limiter.acquire(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	resp, err := s.httpClientFactory(opts.TokenProvider, cfg.Insecure, cfg.Timeout).Execute(ctx, opts)
+	done(resp)
+	if auditErr := recordAudit(cfg, opts, started, resp, err); auditErr != nil {
+		return nil, fmt.Errorf("request sent but not recorded in the audit log: %w", auditErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := applyQueryToResponse(resp, cfg.Query); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// bulkResultBody returns body as compact JSON when it is JSON, otherwise as a
+// JSON string. An empty body yields nil so the field is omitted.
+func bulkResultBody(body []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, trimmed) == nil {
+		return compact.Bytes()
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// CSVBulkOptions describes azd rest bulk --data-csv: one request per CSV row,
+// with the URL and body rendered from Go templates that reference the row's
+// columns by header name.
+type CSVBulkOptions struct {
+	Method       string
+	URLTemplate  string
+	BodyTemplate string
+}
+
+// BuildCSVBulkInput renders one bulk request per data row of the CSV in and
+// returns them as NDJSON for ExecuteBulk. Templates see the row's columns,
+// which take precedence over --var values and the azd environment. Every row
+// is rendered before any request is sent, so a bad row fails the run early
+// instead of halfway through.
+func (s *RequestService) BuildCSVBulkInput(cfg config.Config, in io.Reader, opts CSVBulkOptions) (io.Reader, error) {
+	urlTmpl, err := template.New("url").Funcs(bodyTemplateFuncs).Option("missingkey=error").Parse(opts.URLTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the --url template: %w", err)
+	}
+	bodyTmpl, err := template.New("map").Funcs(bodyTemplateFuncs).Option("missingkey=error").Parse(opts.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the --map template: %w", err)
+	}
+	base, err := s.bodyTemplateValues(cfg.TemplateVars)
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(in)
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return &bytes.Buffer{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		// Spreadsheet exports often start with a UTF-8 byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", row, err)
+		}
+		values := make(map[string]string, len(base)+len(header))
+		for k, v := range base {
+			values[k] = v
+		}
+		for i, name := range header {
+			values[name] = record[i]
+		}
+
+		var url, body bytes.Buffer
+		if err := urlTmpl.Execute(&url, values); err != nil {
+			return nil, fmt.Errorf("CSV row %d: failed to render the --url template: %w", row, err)
+		}
+		if err := bodyTmpl.Execute(&body, values); err != nil {
+			return nil, fmt.Errorf("CSV row %d: failed to render the --map template: %w", row, err)
+		}
+		req := bulkRequest{ID: json.RawMessage(fmt.Sprint(row)), Method: opts.Method, URL: url.String()}
+		if req.Body, err = csvBulkBody(body.Bytes()); err != nil {
+			return nil, fmt.Errorf("CSV row %d: %w", row, err)
+		}
+		if err := enc.Encode(req); err != nil {
+			return nil, fmt.Errorf("CSV row %d: %w", row, err)
+		}
+	}
+	return &out, nil
+}
+
+// csvBulkBody returns a rendered body as a bulk request body: compact JSON
+// when it parses, so it is sent as JSON, and a JSON string otherwise, so it
+// is sent as-is.
+func csvBulkBody(rendered []byte) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(rendered)) == 0 {
+		return nil, nil
+	}
+	if json.Valid(rendered) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, rendered); err != nil {
+			return nil, err
+		}
+		return compact.Bytes(), nil
+	}
+	return json.Marshal(string(rendered))
+}
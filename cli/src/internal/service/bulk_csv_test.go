@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCSVBulkInput_RunsOneRequestPerRow(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = map[string]string{}
+		types    = map[string]string{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.Method+" "+r.URL.Path] = string(body)
+		types[r.URL.Path] = r.Header.Get("Content-Type")
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	csvInput := "\ufeffname,ip\nwww,10.0.0.1\n\"api, v2\",10.0.0.2\n"
+	svc := newTemplateTestService("AZURE_LOCATION=eastus")
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.TemplateVars = []string{"ttl=3600"}
+
+	in, err := svc.BuildCSVBulkInput(cfg, strings.NewReader(csvInput), CSVBulkOptions{
+		Method:       "PUT",
+		URLTemplate:  srv.URL + "/records/{{ .name }}",
+		BodyTemplate: `{"ip": "{{ .ip }}", "name": {{ json .name }}, "ttl": {{ .ttl }}, "location": "{{ .AZURE_LOCATION }}"}`,
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, svc.ExecuteBulk(context.Background(), cfg, in, &out, BulkOptions{Concurrency: 2}))
+	results := decodeBulkResults(t, out.String())
+	require.Len(t, results, 2)
+	assert.JSONEq(t, `1`, string(results[0].ID))
+	assert.JSONEq(t, `2`, string(results[1].ID))
+
+	assert.JSONEq(t, `{"ip":"10.0.0.1","name":"www","ttl":3600,"location":"eastus"}`, received["PUT /records/www"])
+	assert.JSONEq(t, `{"ip":"10.0.0.2","name":"api, v2","ttl":3600,"location":"eastus"}`, received["PUT /records/api, v2"])
+	assert.Equal(t, applicationJSON, types["/records/www"])
+}
+
+func TestBuildCSVBulkInput_NonJSONBodySentAsIs(t *testing.T) {
+	in, err := newTemplateTestService().BuildCSVBulkInput(baseTestConfig(t), strings.NewReader("n\n1\n"), CSVBulkOptions{
+		Method:       "POST",
+		URLTemplate:  "https://example.com/{{ .n }}",
+		BodyTemplate: "value={{ .n }}",
+	})
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(in)
+	assert.JSONEq(t, `{"id":1,"method":"POST","url":"https://example.com/1","body":"value=1"}`, string(raw))
+}
+
+func TestBuildCSVBulkInput_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		csv  string
+		opts CSVBulkOptions
+		want string
+	}{
+		{name: "unknown column", csv: "a\n1\n", opts: CSVBulkOptions{URLTemplate: "https://x/{{ .b }}"}, want: "CSV row 1: failed to render the --url template"},
+		{name: "bad map template", csv: "a\n1\n", opts: CSVBulkOptions{URLTemplate: "https://x", BodyTemplate: "{{ .a"}, want: "failed to parse the --map template"},
+		{name: "ragged row", csv: "a,b\n1,2\n3\n", opts: CSVBulkOptions{URLTemplate: "https://x"}, want: "failed to read CSV row 2"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTemplateTestService().BuildCSVBulkInput(baseTestConfig(t), strings.NewReader(tt.csv), tt.opts)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestBuildCSVBulkInput_Empty(t *testing.T) {
+	in, err := newTemplateTestService().BuildCSVBulkInput(baseTestConfig(t), strings.NewReader(""), CSVBulkOptions{URLTemplate: "https://x"})
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(in)
+	assert.Empty(t, raw)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeBulkResults parses NDJSON results and orders them by input line.
+func decodeBulkResults(t *testing.T, out string) []bulkResult {
+	t.Helper()
+	var results []bulkResult
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var res bulkResult
+		require.NoError(t, json.Unmarshal([]byte(line), &res), line)
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	return results
+}
+
+func TestExecuteBulk_ResultsPerLine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/echo":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"method":"` + r.Method + `","tag":"` + r.Header.Get("X-Tag") + `","body":` + string(body) + `}`))
+		case "/text":
+			_, _ = w.Write([]byte("plain text"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	input := strings.Join([]string{
+		`{"id": "a", "method": "post", "url": "` + srv.URL + `/echo", "headers": {"X-Tag": "t1"}, "body": {"n": 1}}`,
+		``,
+		`{"url": "` + srv.URL + `/text"}`,
+		`not json`,
+		`{"method": "GET"}`,
+	}, "\n")
+
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	var out bytes.Buffer
+	err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(input), &out, BulkOptions{Concurrency: 2})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 of 4 bulk requests failed")
+
+	results := decodeBulkResults(t, out.String())
+	require.Len(t, results, 4)
+
+	assert.Equal(t, 1, results[0].Line)
+	assert.JSONEq(t, `"a"`, string(results[0].ID))
+	assert.Equal(t, "POST", results[0].Method)
+	assert.Equal(t, 200, results[0].Status)
+	assert.JSONEq(t, `{"method":"POST","tag":"t1","body":{"n":1}}`, string(results[0].Body))
+
+	assert.Equal(t, 3, results[1].Line, "blank lines keep their line numbers")
+	assert.Equal(t, "GET", results[1].Method)
+	assert.JSONEq(t, `"plain text"`, string(results[1].Body))
+
+	assert.Contains(t, results[2].Error, "invalid JSON")
+	assert.Equal(t, "url is required", results[3].Error)
+}
+
+func TestExecuteBulk_LineHeadersReplaceGlobal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string][]string{"accept": r.Header.Values("Accept"), "tag": r.Header.Values("X-Tag")})
+	}))
+	defer srv.Close()
+
+	input := `{"url": "` + srv.URL + `", "headers": {"accept": "text/csv"}}` + "\n" + `{"url": "` + srv.URL + `"}`
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.Headers = []string{"Accept: application/json", "X-Tag: global"}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(input), &out, BulkOptions{Concurrency: 1}))
+
+	results := decodeBulkResults(t, out.String())
+	require.Len(t, results, 2)
+	assert.JSONEq(t, `{"accept":["text/csv"],"tag":["global"]}`, string(results[0].Body), "a line's header replaces the global one")
+	assert.JSONEq(t, `{"accept":["application/json"],"tag":["global"]}`, string(results[1].Body))
+}
+
+func TestExecuteBulk_BoundsConcurrencyAndSharesToken(t *testing.T) {
+	var inFlight, peak int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		assert.Equal(t, "Bearer shared", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var factoryCalls int32
+	svc := NewRequestService(func() (client.TokenProvider, error) {
+		atomic.AddInt32(&factoryCalls, 1)
+		return &client.MockTokenProvider{Token: "shared"}, nil
+	}, DefaultHTTPClientFactory)
+
+	var lines []string
+	for i := 0; i < 8; i++ {
+		lines = append(lines, `{"url": "`+srv.URL+`/item"}`)
+	}
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Scope = "https://example.com/.default"
+
+	var out bytes.Buffer
+	require.NoError(t, svc.ExecuteBulk(context.Background(), cfg, strings.NewReader(strings.Join(lines, "\n")), &out, BulkOptions{Concurrency: 3}))
+	assert.Len(t, decodeBulkResults(t, out.String()), 8)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(3))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&factoryCalls))
+}
+
+func TestExecuteBulk_FailCountsErrorStatuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	input := `{"url": "` + srv.URL + `"}`
+
+	require.NoError(t, newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(input), io.Discard, BulkOptions{Concurrency: 1}))
+
+	cfg.Fail = true
+	err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(input), io.Discard, BulkOptions{Concurrency: 1})
+	assert.ErrorContains(t, err, "1 of 1 bulk requests failed")
+}
+
+func TestExecuteBulk_InvalidConcurrency(t *testing.T) {
+	cfg := baseTestConfig(t)
+	err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(""), io.Discard, BulkOptions{Concurrency: 0})
+	assert.ErrorContains(t, err, "--concurrency")
+}
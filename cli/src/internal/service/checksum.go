@@ -0,0 +1,128 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// checksumAlgorithms are the --checksum algorithms, keyed by name.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// checksumError signals invalid --checksum or --verify-checksum usage. It
+// reports exit code 2 through the ExitCoder contract.
+type checksumError struct{ err error }
+
+// Error returns the underlying message.
+func (e *checksumError) Error() string { return e.err.Error() }
+
+// Unwrap exposes the wrapped error for errors.Is/As.
+func (e *checksumError) Unwrap() error { return e.err }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *checksumError) ExitCode() int { return 2 }
+
+// bodyChecksum hashes a response body as it is read and checks it against
+// an expected digest.
+type bodyChecksum struct {
+	algorithm string
+	hash      hash.Hash
+	want      string
+	print     bool
+	name      string
+}
+
+// newBodyChecksum validates --checksum and --verify-checksum. The expected
+// digest is hex, optionally prefixed with its algorithm as in sha256:<hex>;
+// without --checksum or a prefix the algorithm follows from its length. It
+// returns nil when neither flag is set.
+func newBodyChecksum(cfg config.Config) (*bodyChecksum, error) {
+	if cfg.Checksum == "" && cfg.VerifyChecksum == "" {
+		return nil, nil
+	}
+	if cfg.Paginate || cfg.Repeat > 1 || cfg.Watch > 0 {
+		return nil, &checksumError{fmt.Errorf("--checksum and --verify-checksum cannot be combined with --paginate, --repeat, or --watch")}
+	}
+	algorithm := strings.ToLower(cfg.Checksum)
+	if algorithm != "" && checksumAlgorithms[algorithm] == nil {
+		return nil, &checksumError{fmt.Errorf("unsupported --checksum algorithm %q (use sha256, sha384, or sha512)", cfg.Checksum)}
+	}
+
+	want := strings.ToLower(strings.TrimSpace(cfg.VerifyChecksum))
+	if prefix, digest, ok := strings.Cut(want, ":"); ok {
+		if checksumAlgorithms[prefix] == nil {
+			return nil, &checksumError{fmt.Errorf("unsupported --verify-checksum algorithm %q (use sha256, sha384, or sha512)", prefix)}
+		}
+		if algorithm != "" && algorithm != prefix {
+			return nil, &checksumError{fmt.Errorf("--verify-checksum is a %s digest but --checksum is %s", prefix, algorithm)}
+		}
+		algorithm, want = prefix, digest
+	}
+	if cfg.VerifyChecksum != "" && want == "" {
+		return nil, &checksumError{fmt.Errorf("--verify-checksum needs a hex digest")}
+	}
+	if want != "" {
+		if _, err := hex.DecodeString(want); err != nil {
+			return nil, &checksumError{fmt.Errorf("--verify-checksum must be a hex digest: %w", err)}
+		}
+		if algorithm == "" {
+			algorithm = checksumAlgorithmForLength(len(want))
+		}
+		if algorithm == "" || len(want) != 2*checksumAlgorithms[algorithm]().Size() {
+			return nil, &checksumError{fmt.Errorf("--verify-checksum has %d hex digits, which does not match %s", len(want), checksumLengthHint(algorithm))}
+		}
+	}
+	outputName := cfg.OutputFile
+	if outputName == "" {
+		outputName = "-"
+	}
+	return &bodyChecksum{
+		algorithm: algorithm,
+		hash:      checksumAlgorithms[algorithm](),
+		want:      want,
+		print:     cfg.Checksum != "",
+		name:      outputName,
+	}, nil
+}
+
+// checksumAlgorithmForLength returns the algorithm whose hex digest has n
+// digits, or "" when none does.
+func checksumAlgorithmForLength(n int) string {
+	for name, newHash := range checksumAlgorithms {
+		if 2*newHash().Size() == n {
+			return name
+		}
+	}
+	return ""
+}
+
+func checksumLengthHint(algorithm string) string {
+	if algorithm == "" {
+		return "sha256 (64), sha384 (96), or sha512 (128)"
+	}
+	return fmt.Sprintf("%s (%d)", algorithm, 2*checksumAlgorithms[algorithm]().Size())
+}
+
+// check prints the digest in sha256sum format, next to the --output-file
+// name or "-" for stdout, when --checksum is set, and returns an error when
+// it differs from --verify-checksum.
+func (c *bodyChecksum) check(w io.Writer) error {
+	got := hex.EncodeToString(c.hash.Sum(nil))
+	if c.print {
+		fmt.Fprintf(w, "%s  %s\n", got, c.name)
+	}
+	if c.want != "" && got != c.want {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s; the response was not written", c.algorithm, c.want, got)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewBodyChecksum(t *testing.T) {
+	digest := sha256Hex([]byte("x"))
+
+	c, err := newBodyChecksum(config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = newBodyChecksum(config.Config{VerifyChecksum: digest})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", c.algorithm, "the algorithm follows from the digest length")
+
+	c, err = newBodyChecksum(config.Config{VerifyChecksum: "SHA256:" + digest, Checksum: "sha256"})
+	require.NoError(t, err)
+	assert.Equal(t, digest, c.want)
+
+	invalid := map[string]config.Config{
+		"unsupported --checksum algorithm":            {Checksum: "md5"},
+		"unsupported --verify-checksum algorithm":     {VerifyChecksum: "md5:abcd"},
+		"is a sha256 digest but --checksum is sha512": {VerifyChecksum: "sha256:" + digest, Checksum: "sha512"},
+		"must be a hex digest":                        {VerifyChecksum: "xyz"},
+		"has 4 hex digits":                            {VerifyChecksum: "abcd"},
+		"needs a hex digest":                          {VerifyChecksum: "sha256:"},
+		"cannot be combined with --paginate":          {Checksum: "sha256", Paginate: true},
+	}
+	for want, cfg := range invalid {
+		_, err := newBodyChecksum(cfg)
+		var usage *checksumError
+		require.ErrorAs(t, err, &usage, want)
+		assert.Contains(t, err.Error(), want)
+		assert.Equal(t, 2, usage.ExitCode())
+	}
+}
+
+func TestExecute_ChecksumPrintsAndVerifies(t *testing.T) {
+	payload := []byte{0x00, 0x01, 0xfe, 0xff, 'a', 'r', 't'}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Checksum = "sha256"
+	cfg.VerifyChecksum = sha256Hex(payload)
+	svc := newTestService()
+	var stderr bytes.Buffer
+	c, err := newBodyChecksum(cfg)
+	require.NoError(t, err)
+	_, _ = c.hash.Write(payload)
+	require.NoError(t, c.check(&stderr))
+	assert.Equal(t, sha256Hex(payload)+"  "+cfg.OutputFile+"\n", stderr.String())
+
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, payload, got)
+
+	cfg = baseTestConfig(t)
+	cfg.VerifyChecksum = "sha256:" + sha256Hex([]byte("something else"))
+	err = svc.Execute(t.Context(), cfg, http.MethodGet, server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sha256 checksum mismatch")
+	assert.NoFileExists(t, cfg.OutputFile, "a mismatched body is not written")
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// CI systems accepted by --ci-output.
+const (
+	ciOutputGitHub = "github"
+	ciOutputAzDO   = "azdo"
+)
+
+// githubOutputEnv names the file GitHub Actions reads step outputs from.
+const githubOutputEnv = "GITHUB_OUTPUT"
+
+// parseCIOutput validates --ci-output and --ci-var together and compiles each
+// --ci-var expression, so a typo fails before any request is sent.
+func parseCIOutput(cfg config.Config) ([]azdEnvAssignment, error) {
+	switch cfg.CIOutput {
+	case "":
+		if len(cfg.CIVars) > 0 {
+			return nil, &azdEnvError{fmt.Errorf("--ci-var requires --ci-output (%s or %s)", ciOutputGitHub, ciOutputAzDO)}
+		}
+		return nil, nil
+	case ciOutputGitHub, ciOutputAzDO:
+	default:
+		return nil, &azdEnvError{fmt.Errorf("invalid --ci-output %q (expected %s or %s)", cfg.CIOutput, ciOutputGitHub, ciOutputAzDO)}
+	}
+	if len(cfg.CIVars) == 0 {
+		return nil, &azdEnvError{fmt.Errorf("--ci-output requires at least one --ci-var NAME=<expression>")}
+	}
+	if cfg.Repeat > 1 {
+		return nil, &azdEnvError{fmt.Errorf("--ci-var cannot be combined with --repeat")}
+	}
+	return parseValueAssignments("--ci-var", cfg.CIVars)
+}
+
+// writeCIOutputs publishes --ci-var values after a response has been written.
+// Like --set-azd-env, values are only taken from a successful (2xx) response.
+func (s *RequestService) writeCIOutputs(cfg config.Config, status int, body []byte, assignments []azdEnvAssignment) error {
+	if status < 200 || status > 299 {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: pipeline outputs not set (--ci-var) because the request returned HTTP %d\n", status)
+		return nil
+	}
+	values, err := extractValues("--ci-var", body, assignments)
+	if err != nil {
+		return err
+	}
+	if cfg.CIOutput == ciOutputAzDO {
+		return writeAzDOVariables(s.stdout, values)
+	}
+	path, ok := s.lookupEnv(githubOutputEnv)
+	if !ok || path == "" {
+		return fmt.Errorf("--ci-output github requires $%s, which GitHub Actions sets for each step", githubOutputEnv)
+	}
+	if err := appendGitHubOutputs(path, values); err != nil {
+		return err
+	}
+	for _, v := range values {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Set step output %s\n", v.Key)
+	}
+	return nil
+}
+
+// appendGitHubOutputs appends values to the GitHub Actions output file. Each
+// value uses the multiline NAME<<DELIMITER form with a random delimiter, so a
+// value containing newlines cannot inject extra outputs.
+func appendGitHubOutputs(path string, values []azdEnvValue) error {
+	var b strings.Builder
+	for _, v := range values {
+		delimiter, err := githubDelimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", v.Key, delimiter, v.Value, delimiter)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) //nolint:gosec // G304: path is the runner's $GITHUB_OUTPUT
+	if err != nil {
+		return fmt.Errorf("failed to open $%s: %w", githubOutputEnv, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write $%s: %w", githubOutputEnv, err)
+	}
+	return nil
+}
+
+// githubDelimiter returns a heredoc delimiter that cannot appear in a value by
+// chance.
+func githubDelimiter() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate output delimiter: %w", err)
+	}
+	return "ghadelimiter_" + hex.EncodeToString(buf), nil
+}
+
+// azdoEscaper escapes a logging command value as the Azure Pipelines agent
+// expects, so a value cannot end the command early or start a new one.
+var azdoEscaper = strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A")
+
+// writeAzDOVariables prints one task.setvariable logging command per value.
+// The variables are output variables, so later jobs and stages can read them
+// as dependencies.<job>.outputs['<step>.<name>'].
+func writeAzDOVariables(w io.Writer, values []azdEnvValue) error {
+	for _, v := range values {
+		if _, err := fmt.Fprintf(w, "##vso[task.setvariable variable=%s;isOutput=true]%s\n", v.Key, azdoEscaper.Replace(v.Value)); err != nil {
+			return fmt.Errorf("failed to write pipeline variable %s: %w", v.Key, err)
+		}
+	}
+	return nil
+}
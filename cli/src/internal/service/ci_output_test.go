@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ciTestServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"name":"web","properties":{"endpoint":"https://x.example","note":"line1\nline2 100%"},"ports":[80,443]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExecute_CIOutputGitHub(t *testing.T) {
+	srv := ciTestServer(t, http.StatusOK)
+	outputFile := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(outputFile, []byte("EARLIER=1\n"), 0o600))
+
+	svc := newTestService()
+	svc.lookupEnv = func(key string) (string, bool) {
+		if key == githubOutputEnv {
+			return outputFile, true
+		}
+		return "", false
+	}
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.CIOutput = "github"
+	cfg.CIVars = []string{"ENDPOINT=properties.endpoint", "NOTE=properties.note", "PORTS=ports"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	pattern := regexp.MustCompile(`^EARLIER=1\n` +
+		`ENDPOINT<<(ghadelimiter_[0-9a-f]{32})\nhttps://x\.example\n(ghadelimiter_[0-9a-f]{32})\n` +
+		`NOTE<<(ghadelimiter_[0-9a-f]{32})\nline1\nline2 100%\n(ghadelimiter_[0-9a-f]{32})\n` +
+		`PORTS<<(ghadelimiter_[0-9a-f]{32})\n\[80,443\]\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	m := pattern.FindStringSubmatch(string(data))
+	require.NotNil(t, m, string(data))
+	assert.Equal(t, m[1], m[2])
+	assert.NotEqual(t, m[1], m[3], "each value gets its own delimiter")
+}
+
+func TestExecute_CIOutputAzDO(t *testing.T) {
+	srv := ciTestServer(t, http.StatusOK)
+	svc := newTestService()
+	var stdout bytes.Buffer
+	svc.stdout = &stdout
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.CIOutput = "azdo"
+	cfg.CIVars = []string{"NAME=name", "NOTE=properties.note"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Equal(t,
+		"##vso[task.setvariable variable=NAME;isOutput=true]web\n"+
+			"##vso[task.setvariable variable=NOTE;isOutput=true]line1%0Aline2 100%AZP25\n",
+		stdout.String())
+}
+
+func TestExecute_CIOutputSkipsErrorStatus(t *testing.T) {
+	srv := ciTestServer(t, http.StatusNotFound)
+	svc := newTestService()
+	var stdout bytes.Buffer
+	svc.stdout = &stdout
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.CIOutput = "azdo"
+	cfg.CIVars = []string{"NAME=name"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Empty(t, stdout.String())
+}
+
+func TestExecute_CIOutputGitHubRequiresOutputFile(t *testing.T) {
+	srv := ciTestServer(t, http.StatusOK)
+	svc := newTestService()
+	svc.lookupEnv = func(string) (string, bool) { return "", false }
+	cfg := baseTestConfig(t)
+	cfg.CIOutput = "github"
+	cfg.CIVars = []string{"NAME=name"}
+
+	err := svc.Execute(context.Background(), cfg, "GET", srv.URL)
+	assert.ErrorContains(t, err, "--ci-output github requires $GITHUB_OUTPUT")
+}
+
+func TestExecute_CIOutputUsageErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		vars     []string
+		repeat   int
+		contains string
+	}{
+		{name: "var without output", vars: []string{"A=a"}, contains: "--ci-var requires --ci-output"},
+		{name: "output without var", output: "github", contains: "requires at least one --ci-var"},
+		{name: "unknown system", output: "jenkins", vars: []string{"A=a"}, contains: `invalid --ci-output "jenkins"`},
+		{name: "bad name", output: "azdo", vars: []string{"1A=a"}, contains: "invalid --ci-var key"},
+		{name: "bad expression", output: "azdo", vars: []string{"A=[["}, contains: "invalid --ci-var expression"},
+		{name: "with repeat", output: "azdo", vars: []string{"A=a"}, repeat: 2, contains: "cannot be combined with --repeat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.CIOutput = tt.output
+			cfg.CIVars = tt.vars
+			if tt.repeat > 0 {
+				cfg.Repeat = tt.repeat
+			}
+
+			err := newTestService().Execute(context.Background(), cfg, "GET", "https://example.invalid")
+			require.ErrorContains(t, err, tt.contains)
+			var usage *azdEnvError
+			require.True(t, errors.As(err, &usage))
+			assert.Equal(t, 2, usage.ExitCode())
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// circuitPollInterval is how often a request waiting on a paused host checks
+// whether it may go.
+const circuitPollInterval = 50 * time.Millisecond
+
+// circuitBreaker stops a bulk run from hammering a host that keeps answering
+// with 5xx or 429. After threshold such responses in a row from one host the
+// host's circuit opens. With no cooldown, every later request to the host is
+// skipped. With a cooldown, requests to the host wait until it has passed,
+// then one probe request is sent: a success closes the circuit, and another
+// 5xx or 429 opens it for a further cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mu        sync.Mutex
+	hosts     map[string]*hostCircuit
+}
+
+// hostCircuit is the breaker state of one host.
+type hostCircuit struct {
+	failures  int
+	open      bool
+	reopensAt time.Time
+	probing   bool
+	trips     int
+	skipped   int
+}
+
+// errCircuitOpen is reported for a request skipped because its host's
+// circuit is open.
+type errCircuitOpen struct {
+	host      string
+	threshold int
+}
+
+func (e *errCircuitOpen) Error() string {
+	return fmt.Sprintf("skipped: circuit open for %s after %d consecutive 5xx or 429 responses", e.host, e.threshold)
+}
+
+// newCircuitBreaker returns a breaker that trips after threshold failures in
+// a row, or nil when threshold is 0.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, hosts: map[string]*hostCircuit{}}
+}
+
+// acquire waits until a request to host may be sent. It returns an
+// errCircuitOpen when the host's circuit is open and there is no cooldown.
+func (b *circuitBreaker) acquire(ctx context.Context, host string) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		hc := b.host(host)
+		switch {
+		case !hc.open:
+			b.mu.Unlock()
+			return nil
+		case b.cooldown == 0:
+			hc.skipped++
+			b.mu.Unlock()
+			return &errCircuitOpen{host: host, threshold: b.threshold}
+		case !hc.probing && !time.Now().Before(hc.reopensAt):
+			hc.probing = true
+			b.mu.Unlock()
+			return nil
+		}
+		wait := min(time.Until(hc.reopensAt), circuitPollInterval)
+		if hc.probing || wait <= 0 {
+			wait = circuitPollInterval
+		}
+		b.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// record counts the outcome of a request to host: its status, or 0 when it
+// got no response.
+func (b *circuitBreaker) record(host string, status int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hc := b.host(host)
+	probe := hc.probing
+	hc.probing = false
+	switch {
+	case status == http.StatusTooManyRequests || status >= 500 || (probe && status == 0):
+		hc.failures++
+		if probe || (!hc.open && hc.failures >= b.threshold) {
+			if !hc.open {
+				hc.trips++
+			}
+			hc.open = true
+			hc.reopensAt = time.Now().Add(b.cooldown)
+		}
+	case status != 0:
+		hc.failures = 0
+		hc.open = false
+	}
+}
+
+func (b *circuitBreaker) host(host string) *hostCircuit {
+	hc, ok := b.hosts[host]
+	if !ok {
+		hc = &hostCircuit{}
+		b.hosts[host] = hc
+	}
+	return hc
+}
+
+// writeSummary prints one line per host whose circuit opened during the run.
+func (b *circuitBreaker) writeSummary(w io.Writer) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hosts := make([]string, 0, len(b.hosts))
+	for host, hc := range b.hosts {
+		if hc.trips > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		hc := b.hosts[host]
+		state := "closed again"
+		if hc.open {
+			state = "still open"
+		}
+		fmt.Fprintf(w, "Circuit breaker: %s opened %d time(s) after %d consecutive 5xx or 429 responses, %s; %d request(s) skipped\n",
+			host, hc.trips, b.threshold, state, hc.skipped)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(2, 0)
+	ctx := context.Background()
+
+	require.NoError(t, b.acquire(ctx, "a"))
+	b.record("a", http.StatusServiceUnavailable)
+	b.record("a", http.StatusOK) // a success resets the count
+	b.record("a", http.StatusTooManyRequests)
+	b.record("b", http.StatusInternalServerError)
+	b.record("a", 0) // no response does not count
+	require.NoError(t, b.acquire(ctx, "a"))
+	b.record("a", http.StatusBadGateway)
+
+	var open *errCircuitOpen
+	require.ErrorAs(t, b.acquire(ctx, "a"), &open)
+	assert.Contains(t, open.Error(), "circuit open for a after 2 consecutive")
+	require.NoError(t, b.acquire(ctx, "b"), "hosts have separate circuits")
+
+	var summary bytes.Buffer
+	b.writeSummary(&summary)
+	assert.Equal(t, "Circuit breaker: a opened 1 time(s) after 2 consecutive 5xx or 429 responses, still open; 1 request(s) skipped\n", summary.String())
+}
+
+func TestCircuitBreaker_CooldownProbes(t *testing.T) {
+	b := newCircuitBreaker(1, 20*time.Millisecond)
+	ctx := context.Background()
+
+	b.record("a", http.StatusTooManyRequests)
+	start := time.Now()
+	require.NoError(t, b.acquire(ctx, "a"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Only the probe is let through while it is in flight.
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.acquire(waitCtx, "a"), context.DeadlineExceeded)
+
+	// A failed probe reopens the circuit, a successful one closes it.
+	b.record("a", http.StatusServiceUnavailable)
+	require.NoError(t, b.acquire(ctx, "a"))
+	b.record("a", http.StatusOK)
+	start = time.Now()
+	require.NoError(t, b.acquire(ctx, "a"))
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+
+	var summary bytes.Buffer
+	b.writeSummary(&summary)
+	assert.Contains(t, summary.String(), "a opened 1 time(s) after 1 consecutive 5xx or 429 responses, closed again; 0 request(s) skipped")
+}
+
+func TestCircuitBreaker_NilIsDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Second)
+	assert.Nil(t, b)
+	b.record("a", http.StatusServiceUnavailable)
+	assert.NoError(t, b.acquire(context.Background(), "a"))
+	b.writeSummary(io.Discard)
+}
+
+func TestExecuteBulk_CircuitBreakerSkipsHost(t *testing.T) {
+	var hits atomic.Int32
+	throttled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer throttled.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+
+	var lines []string
+	for i := 0; i < 6; i++ {
+		lines = append(lines, fmt.Sprintf(`{"id": %d, "url": "%s"}`, i, throttled.URL))
+	}
+	lines = append(lines, `{"id": 6, "url": "`+healthy.URL+`"}`)
+
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	var out bytes.Buffer
+	err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(strings.Join(lines, "\n")), &out, BulkOptions{Concurrency: 1, CircuitBreaker: 3})
+	assert.ErrorContains(t, err, "3 of 7 bulk requests failed")
+	assert.Equal(t, int32(3), hits.Load())
+
+	results := decodeBulkResults(t, out.String())
+	require.Len(t, results, 7)
+	for i, res := range results[:3] {
+		assert.Equal(t, http.StatusTooManyRequests, res.Status, i)
+	}
+	for i, res := range results[3:6] {
+		assert.Contains(t, res.Error, "circuit open", i)
+		assert.Zero(t, res.Status, i)
+	}
+	assert.Equal(t, http.StatusOK, results[6].Status)
+}
+
+func TestExecuteBulk_InvalidCircuitOptions(t *testing.T) {
+	cfg := baseTestConfig(t)
+	for _, tc := range []struct {
+		opts BulkOptions
+		want string
+	}{
+		{BulkOptions{Concurrency: 1, CircuitBreaker: -1}, "--circuit-breaker must be 0 or more"},
+		{BulkOptions{Concurrency: 1, CircuitBreaker: 1, CircuitCooldown: -time.Second}, "--circuit-cooldown must not be negative"},
+		{BulkOptions{Concurrency: 1, CircuitCooldown: time.Second}, "--circuit-cooldown requires --circuit-breaker"},
+	} {
+		err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(""), io.Discard, tc.opts)
+		assert.ErrorContains(t, err, tc.want)
+	}
+}
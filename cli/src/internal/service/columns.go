@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmespath-community/go-jmespath"
+)
+
+// columnNamePattern matches the NAME in a NAME=<JMESPath> --columns value.
+// Anything else is read as an expression named after itself, so a filter
+// such as tags[?k=='v'] is not split at its '='.
+var columnNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// outputColumn is one --columns value: a header and the JMESPath expression
+// evaluated against each row to fill it.
+type outputColumn struct {
+	name string
+	expr jmespath.JMESPath
+}
+
+// parseColumns compiles each --columns value, written as NAME=<JMESPath> or
+// as a bare expression that is also the header, so a typo fails before any
+// request is sent.
+func parseColumns(specs []string) ([]outputColumn, error) {
+	var columns []outputColumn
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, expression := spec, spec
+		if key, rest, ok := strings.Cut(spec, "="); ok && !strings.HasPrefix(rest, "=") && columnNamePattern.MatchString(strings.TrimSpace(key)) {
+			name, expression = strings.TrimSpace(key), strings.TrimSpace(rest)
+		}
+		if expression == "" {
+			return nil, fmt.Errorf("invalid --columns value %q (expected NAME=<JMESPath> or <JMESPath>)", spec)
+		}
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --columns expression %q: %w", expression, err)
+		}
+		columns = append(columns, outputColumn{name: name, expr: compiled})
+	}
+	return columns, nil
+}
+
+// columnSpec returns a --columns value that selects the key name as is.
+func columnSpec(name string) string {
+	quoted, _ := json.Marshal(name)
+	if columnNamePattern.MatchString(name) {
+		return name + "=" + string(quoted)
+	}
+	return string(quoted)
+}
+
+// projectRows evaluates each column against each row and returns the header
+// and cells. A column that matches nothing, or fails for a row, is empty in
+// that row.
+func projectRows(rows []any, columns []outputColumn) ([]string, [][]string) {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			if value, err := col.expr.Search(row); err == nil {
+				cells[i] = tableCellString(value)
+			}
+		}
+		data = append(data, cells)
+	}
+	return header, data
+}
+
+// flattenRows flattens each object row into dotted-path keys, as --flatten
+// does for a whole response, so nested properties become their own columns.
+// Rows that are not objects are left as they are.
+func flattenRows(rows []any) ([]any, error) {
+	flat := make([]any, len(rows))
+	for i, row := range rows {
+		if _, ok := row.(map[string]any); !ok {
+			flat[i] = row
+			continue
+		}
+		leaves := make(map[string]json.RawMessage)
+		if err := flattenValue("", row, leaves); err != nil {
+			return nil, err
+		}
+		obj := make(map[string]any, len(leaves))
+		for key, raw := range leaves {
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			obj[key] = value
+		}
+		flat[i] = obj
+	}
+	return flat, nil
+}
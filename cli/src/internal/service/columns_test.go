@@ -0,0 +1,93 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := parseColumns([]string{"name", " state=properties.provisioningState ", "", "tags[?k=='v'] | [0].k"})
+	require.NoError(t, err)
+	require.Len(t, columns, 3)
+	assert.Equal(t, "name", columns[0].name)
+	assert.Equal(t, "state", columns[1].name)
+	assert.Equal(t, "tags[?k=='v'] | [0].k", columns[2].name, "an expression with == is not split")
+
+	_, err = parseColumns([]string{"state="})
+	assert.EqualError(t, err, `invalid --columns value "state=" (expected NAME=<JMESPath> or <JMESPath>)`)
+
+	_, err = parseColumns([]string{"name=properties.["})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid --columns expression "properties.["`)
+}
+
+func TestRenderCSVWithColumns(t *testing.T) {
+	body := `{"value":[
+		{"name":"a","location":"eastus","properties":{"provisioningState":"Succeeded"},"tags":{"env":"dev"}},
+		{"name":"b","location":"westus","properties":{}}
+	]}`
+	columns, err := parseColumns([]string{"name", "state=properties.provisioningState", "env=tags.env"})
+	require.NoError(t, err)
+
+	out, err := renderCSVWithColumns([]byte(body), columns, false)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "state", "env"},
+		{"a", "Succeeded", "dev"},
+		{"b", "", ""},
+	}, parseCSV(t, out))
+}
+
+func TestRenderCSVWithColumns_Flatten(t *testing.T) {
+	body := `[{"name":"a","properties":{"sku":{"tier":"Basic"},"ports":[80,443]}}]`
+	out, err := renderCSVWithColumns([]byte(body), nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "properties.ports[0]", "properties.ports[1]", "properties.sku.tier"},
+		{"a", "80", "443", "Basic"},
+	}, parseCSV(t, out))
+}
+
+func TestExecute_ColumnsRejectedBeforeRequest(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"value":[{"id":"1","name":"x"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.OutputFormat = "csv"
+	cfg.Columns = []string{"bad=["}
+	err := newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL)
+	require.Error(t, err)
+	assert.Zero(t, calls)
+
+	cfg.Columns = []string{"id"}
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "id\n1\n", string(got))
+}
+
+func TestExecuteTransform_KeepsColumnOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`raw`))
+	}))
+	defer server.Close()
+
+	transform := func([]byte) ([]byte, []string, error) {
+		return []byte(`[{"z":1,"a":"x"}]`), []string{"z", "a"}, nil
+	}
+	cfg := baseTestConfig(t)
+	cfg.OutputFormat = "csv"
+	require.NoError(t, newTestService().ExecuteTransform(t.Context(), cfg, http.MethodPost, server.URL, transform))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "z,a\n1,x\n", string(got))
+}
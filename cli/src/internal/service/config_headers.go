@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// configHeaders returns the headers the config file sets for requestURL: the
+// default headers, then the headers of every host pattern that matches. A
+// wildcard pattern is applied before an exact one, and a shorter wildcard
+// before a longer one, so the most specific pattern wins a conflict.
+func (s *RequestService) configHeaders(requestURL string) (http.Header, error) {
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	headers := make(http.Header)
+	if err := setConfigHeaders(headers, "headers.default", file.Headers.Default); err != nil {
+		return nil, err
+	}
+	if len(file.Headers.Hosts) == 0 {
+		return headers, nil
+	}
+
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	patterns := make([]string, 0, len(file.Headers.Hosts))
+	for pattern := range file.Headers.Hosts {
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range matchHostPatterns(parsed.Hostname(), patterns) {
+		if err := setConfigHeaders(headers, fmt.Sprintf("headers.hosts[%q]", pattern), file.Headers.Hosts[pattern]); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// matchHostPatterns returns the patterns that match host, least specific
+// first: a wildcard before an exact name, and a shorter wildcard before a
+// longer one.
+func matchHostPatterns(host string, patterns []string) []string {
+	var matched []string
+	for _, pattern := range patterns {
+		if client.HostMatchesPattern(host, []string{pattern}) {
+			matched = append(matched, pattern)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		wi, wj := strings.HasPrefix(matched[i], "*."), strings.HasPrefix(matched[j], "*.")
+		if wi != wj {
+			return wi
+		}
+		return len(matched[i]) < len(matched[j])
+	})
+	return matched
+}
+
+// setConfigHeaders copies values into headers, replacing any earlier value
+// for the same name.
+func setConfigHeaders(headers http.Header, section string, values map[string]string) error {
+	for name, value := range values {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("config file: %s has an empty header name", section)
+		}
+		headers.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func configHeadersFile() config.File {
+	return config.File{Headers: config.HeaderSettings{
+		Default: map[string]string{"X-Team": "platform", "Accept": "application/json"},
+		Hosts: map[string]map[string]string{
+			"graph.microsoft.com": {"ConsistencyLevel": "eventual"},
+			"*.azure-api.net":     {"Ocp-Apim-Subscription-Key": "@secret:apim", "X-Tier": "wildcard"},
+			"prod.azure-api.net":  {"X-Tier": "exact"},
+			"*.eu.azure-api.net":  {"X-Tier": "longer wildcard"},
+			"other.example.com":   {"X-Other": "1"},
+		},
+	}}
+}
+
+func TestConfigHeaders_DefaultAndHost(t *testing.T) {
+	svc := serviceWithConfigFile(configHeadersFile(), nil)
+
+	got, err := svc.configHeaders("https://graph.microsoft.com/v1.0/users")
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{
+		"X-Team":           {"platform"},
+		"Accept":           {"application/json"},
+		"Consistencylevel": {"eventual"},
+	}, got)
+}
+
+func TestConfigHeaders_MostSpecificPatternWins(t *testing.T) {
+	svc := serviceWithConfigFile(configHeadersFile(), nil)
+
+	for host, want := range map[string]string{
+		"dev.azure-api.net":    "wildcard",
+		"api.eu.azure-api.net": "longer wildcard",
+		"prod.azure-api.net":   "exact",
+	} {
+		got, err := svc.configHeaders("https://" + host + "/orders")
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Get("X-Tier"), host)
+		assert.Empty(t, got.Get("X-Other"), host)
+	}
+}
+
+func TestConfigHeaders_EmptyName(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{Headers: config.HeaderSettings{
+		Hosts: map[string]map[string]string{"example.com": {" ": "x"}},
+	}}, nil)
+
+	_, err := svc.configHeaders("https://example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `headers.hosts["example.com"] has an empty header name`)
+}
+
+func TestBuildRequestOptions_ConfigHeadersPrecedence(t *testing.T) {
+	svc := serviceWithConfigFile(configHeadersFile(), nil)
+	svc.getSecret = secretStore(map[string]string{"apim": "sub-key"})
+	path := writeHeaderFile(t, "X-Team: from-file\n")
+
+	cfg := baseTestConfig(t)
+	cfg.HeaderFile = path
+	cfg.Headers = []string{"Accept: text/plain"}
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://prod.azure-api.net/orders")
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.Equal(t, "from-file", opts.Headers.Get("X-Team"))
+	assert.Equal(t, []string{"text/plain"}, opts.Headers.Values("Accept"))
+	assert.Equal(t, "exact", opts.Headers.Get("X-Tier"))
+	assert.Equal(t, "sub-key", opts.Headers.Get("Ocp-Apim-Subscription-Key"))
+	assert.Contains(t, opts.SecretHeaders, "Ocp-Apim-Subscription-Key")
+}
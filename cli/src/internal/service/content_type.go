@@ -0,0 +1,50 @@
+package service
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// dataFileMediaTypes maps --data-file extensions to the Content-Type sent when
+// no --header sets one. YAML files are converted to JSON first, so they are
+// sent as JSON.
+var dataFileMediaTypes = map[string]string{
+	".json": applicationJSON,
+	".xml":  "application/xml",
+	".yaml": applicationJSON,
+	".yml":  applicationJSON,
+	".bin":  "application/octet-stream",
+}
+
+// isYAMLDataFile reports whether --data-file names a .yaml or .yml file, which
+// is converted to JSON unless --data-format is given.
+func isYAMLDataFile(dataFile string) bool {
+	switch strings.ToLower(filepath.Ext(dataFile)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// applyBodyContentType sets Content-Type from the --data-file extension when no
+// --header sets one. Otherwise it warns that the body is sent without a
+// Content-Type, which many servers reject.
+func applyBodyContentType(cfg config.Config, headers http.Header) {
+	if hasHeader(headers, contentTypeHeader) {
+		return
+	}
+	switch {
+	case cfg.DataFile != "":
+		if mediaType, ok := dataFileMediaTypes[strings.ToLower(filepath.Ext(cfg.DataFile))]; ok {
+			headers.Set(contentTypeHeader, mediaType)
+			return
+		}
+	case cfg.Data == "":
+		return
+	}
+	writeDiagnostic(os.Stderr, cfg.Silent, "Warning: sending a request body without a Content-Type header; set one with --header \"Content-Type: <type>\"\n")
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRequestOptions_DataFileContentType(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		file    string
+		content string
+		headers []string
+		want    string
+	}{
+		{file: "body.json", content: `{"a":1}`, want: applicationJSON},
+		{file: "BODY.JSON", content: `{"a":1}`, want: applicationJSON},
+		{file: "body.xml", content: `<a/>`, want: "application/xml"},
+		{file: "body.bin", content: "\x00\x01", want: "application/octet-stream"},
+		{file: "body.txt", content: "hello", want: ""},
+		{file: "override.json", content: `{"a":1}`, headers: []string{"content-type: application/vnd.api+json"}, want: "application/vnd.api+json"},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			cfg := baseTestConfig(t)
+			cfg.Silent = true
+			cfg.DataFile = path
+			cfg.Headers = tt.headers
+
+			opts, cleanup, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, tt.want, opts.Headers.Get(contentTypeHeader))
+		})
+	}
+}
+
+func TestBuildRequestOptions_YAMLDataFileConverted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.yml")
+	require.NoError(t, os.WriteFile(path, []byte("name: example\ncount: 2\n"), 0o600))
+
+	cfg := baseTestConfig(t)
+	cfg.DataFile = path
+	opts, cleanup, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.JSONEq(t, `{"name":"example","count":2}`, string(body))
+	assert.Equal(t, applicationJSON, opts.Headers.Get(contentTypeHeader))
+
+	// An explicit --data-format json sends the file as-is.
+	cfg.DataFormat = dataFormatJSON
+	cfg.Headers = []string{"Content-Type: application/yaml"}
+	opts, cleanup, err = newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ = io.ReadAll(opts.Body)
+	assert.Equal(t, "name: example\ncount: 2\n", string(body))
+}
+
+func TestApplyBodyContentType_WarnsWithoutContentType(t *testing.T) {
+	old := os.Stderr
+	f, err := os.CreateTemp(t.TempDir(), "stderr-*.txt")
+	require.NoError(t, err)
+	os.Stderr = f
+	cfg := baseTestConfig(t)
+	cfg.Data = "plain text"
+	applyBodyContentType(cfg, http.Header{})
+	cfg.Data = ""
+	applyBodyContentType(cfg, http.Header{})
+	os.Stderr = old
+	_ = f.Close()
+
+	data, err := os.ReadFile(f.Name()) // #nosec G304 -- test-controlled temp path
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "without a Content-Type header"))
+}
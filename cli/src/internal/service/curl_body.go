@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// curlBodyFlags names every body flag for the conflict message.
+const curlBodyFlags = "--data, --data-file, --data-raw, --data-binary, --data-urlencode, and --json"
+
+// applyCurlBodyFlags turns the curl-compatible body flags into cfg.Data or
+// cfg.DataFile, so the rest of the request is built the same way:
+//
+//   - --data-raw sends its value literally, even when it starts with @.
+//   - --data-binary and --json read @file (or @- for stdin) as-is, like
+//     --data-file, and send any other value literally.
+//   - --data-urlencode encodes each value as a form field, as curl does.
+//
+// Only one body source may be given. It runs after applyStdinBody, so @- is
+// read here for the flags that accept it.
+func (s *RequestService) applyCurlBodyFlags(cfg config.Config) (config.Config, error) {
+	set := 0
+	for _, v := range []bool{cfg.DataRaw != "", cfg.DataBinary != "", cfg.JSONData != "", len(cfg.DataURLEncode) > 0} {
+		if v {
+			set++
+		}
+	}
+	if set == 0 {
+		return cfg, nil
+	}
+	if set > 1 || cfg.Data != "" || cfg.DataFile != "" {
+		return cfg, fmt.Errorf("only one of %s can be set", curlBodyFlags)
+	}
+
+	var err error
+	switch {
+	case cfg.DataRaw != "":
+		cfg.Data = cfg.DataRaw
+	case cfg.DataBinary != "":
+		cfg.Data, cfg.DataFile, err = s.bodyArg(cfg.DataBinary)
+	case cfg.JSONData != "":
+		cfg.Data, cfg.DataFile, err = s.bodyArg(cfg.JSONData)
+	default:
+		cfg.Data, err = s.encodeDataURLEncode(cfg.DataURLEncode)
+	}
+	return cfg, err
+}
+
+// bodyArg resolves a curl body value: @- reads stdin into data, @path names a
+// file to send, and anything else is the body itself.
+func (s *RequestService) bodyArg(value string) (data, file string, err error) {
+	switch {
+	case value == stdinBodyArg:
+		raw, err := s.readStdin()
+		return string(raw), "", err
+	case strings.HasPrefix(value, "@"):
+		return "", strings.TrimPrefix(value, "@"), nil
+	default:
+		return value, "", nil
+	}
+}
+
+// encodeDataURLEncode builds a form body from --data-urlencode values with
+// curl's rules: "name=content" and "=content" encode the content, "name@file"
+// and "@file" encode the file's content (- for stdin), and a value with
+// neither is encoded whole. The name is sent as given. Values are joined with
+// &.
+func (s *RequestService) encodeDataURLEncode(values []string) (string, error) {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		name, content, sep := v, "", byte(0)
+		if i := strings.IndexByte(v, '='); i >= 0 {
+			name, content, sep = v[:i], v[i+1:], '='
+		} else if i := strings.IndexByte(v, '@'); i >= 0 {
+			name, content, sep = v[:i], v[i+1:], '@'
+		}
+		switch sep {
+		case 0:
+			name, content = "", v
+		case '@':
+			raw, err := s.readBodyFile(content)
+			if err != nil {
+				return "", fmt.Errorf("--data-urlencode %s: %w", v, err)
+			}
+			content = string(raw)
+		}
+		if name == "" {
+			parts = append(parts, url.QueryEscape(content))
+		} else {
+			parts = append(parts, name+"="+url.QueryEscape(content))
+		}
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// readBodyFile reads path, or stdin when path is -.
+func (s *RequestService) readBodyFile(path string) ([]byte, error) {
+	if path == "-" {
+		return s.readStdin()
+	}
+	raw, err := os.ReadFile(path) // #nosec G304 -- User-specified file path via --data-urlencode is intentional.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	return raw, nil
+}
+
+// applyCurlBodyHeaders sets the media types the curl body flags imply,
+// unless a header already sets them: --json sends and accepts JSON, and
+// --data-urlencode sends a form.
+func applyCurlBodyHeaders(cfg config.Config, headers http.Header) {
+	switch {
+	case cfg.JSONData != "":
+		if !hasHeader(headers, contentTypeHeader) {
+			headers.Set(contentTypeHeader, applicationJSON)
+		}
+		if !hasHeader(headers, "Accept") {
+			headers.Set("Accept", applicationJSON)
+		}
+	case len(cfg.DataURLEncode) > 0:
+		if !hasHeader(headers, contentTypeHeader) {
+			headers.Set(contentTypeHeader, formURLEncoded)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRequestOptions_DataURLEncode(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "note.txt")
+	require.NoError(t, os.WriteFile(file, []byte("a&b c\n"), 0o600))
+
+	for _, tt := range []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{name: "name=content", values: []string{"q=a b&c", "n=1"}, want: "q=a+b%26c&n=1"},
+		{name: "=content", values: []string{"=x=y"}, want: "x%3Dy"},
+		{name: "content only", values: []string{"hello world"}, want: "hello+world"},
+		{name: "equals before at", values: []string{"mail=a@b.com"}, want: "mail=a%40b.com"},
+		{name: "name@file", values: []string{"note@" + file}, want: "note=a%26b+c%0A"},
+		{name: "@file", values: []string{"@" + file}, want: "a%26b+c%0A"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.DataURLEncode = tt.values
+
+			opts, cleanup, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+			require.NoError(t, err)
+			defer cleanup()
+			body, _ := io.ReadAll(opts.Body)
+			assert.Equal(t, tt.want, string(body))
+			assert.Equal(t, formURLEncoded, opts.Headers.Get(contentTypeHeader))
+		})
+	}
+}
+
+func TestBuildRequestOptions_DataURLEncodeMissingFile(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.DataURLEncode = []string{"x@" + filepath.Join(t.TempDir(), "missing.txt")}
+	_, _, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	assert.ErrorContains(t, err, "failed to read data file")
+}
+
+func TestBuildRequestOptions_DataRawIsLiteral(t *testing.T) {
+	svc := newTestService()
+	svc.stdin = strings.NewReader("should not be read")
+	cfg := baseTestConfig(t)
+	cfg.DataRaw = "@-"
+
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.Equal(t, "@-", string(body))
+}
+
+func TestBuildRequestOptions_DataBinary(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "payload.bin")
+	require.NoError(t, os.WriteFile(file, []byte("line1\r\nline2\n"), 0o600))
+
+	cfg := baseTestConfig(t)
+	cfg.DataBinary = "@" + file
+	opts, cleanup, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.Equal(t, "line1\r\nline2\n", string(body))
+
+	svc := newTestService()
+	svc.stdin = strings.NewReader("from stdin\n")
+	cfg = baseTestConfig(t)
+	cfg.DataBinary = "@-"
+	opts, cleanup, err = svc.BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ = io.ReadAll(opts.Body)
+	assert.Equal(t, "from stdin\n", string(body))
+}
+
+func TestBuildRequestOptions_JSONShortcut(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.JSONData = `{"a":1}`
+	opts, cleanup, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.Equal(t, `{"a":1}`, string(body))
+	assert.Equal(t, applicationJSON, opts.Headers.Get(contentTypeHeader))
+	assert.Equal(t, applicationJSON, opts.Headers.Get("Accept"))
+
+	cfg = baseTestConfig(t)
+	cfg.JSONData = `{"a":1}`
+	cfg.Headers = []string{"Accept: application/problem+json"}
+	opts, cleanup, err = newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, "application/problem+json", opts.Headers.Get("Accept"))
+	assert.Equal(t, applicationJSON, opts.Headers.Get(contentTypeHeader))
+}
+
+func TestBuildRequestOptions_CurlBodyConflicts(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.JSONData = `{}`
+	cfg.DataRaw = "x"
+	_, _, err := newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	assert.ErrorContains(t, err, "only one of")
+
+	cfg = baseTestConfig(t)
+	cfg.Data = "x"
+	cfg.DataURLEncode = []string{"a=b"}
+	_, _, err = newTestService().BuildRequestOptions(cfg, "POST", "https://example.com")
+	assert.ErrorContains(t, err, "only one of")
+}
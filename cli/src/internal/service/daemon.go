@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/daemon"
+)
+
+// daemonTokenProvider gets tokens from a running azd rest daemon. If the
+// daemon goes away mid-run it falls back to a local Azure token provider, so
+// stopping the daemon never fails a request.
+type daemonTokenProvider struct {
+	daemon *daemon.Client
+
+	once     sync.Once
+	fallback client.TokenProvider
+	err      error
+}
+
+func (p *daemonTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	token, err := p.daemon.GetToken(ctx, scope)
+	if err == nil || !errors.Is(err, daemon.ErrNotRunning) {
+		return token, err
+	}
+	p.once.Do(func() { p.fallback, p.err = client.NewAzureTokenProvider() })
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.fallback.GetToken(ctx, scope)
+}
+
+// daemonTransport sends requests through a running azd rest daemon, which
+// keeps its connections open between runs. If the daemon goes away mid-run, a
+// request it never received is sent through local instead, provided its body
+// can be read again.
+type daemonTransport struct {
+	daemon *daemon.Client
+	local  http.RoundTripper
+}
+
+func (t *daemonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.daemon.RoundTrip(req)
+	if err == nil || !errors.Is(err, daemon.ErrNotRunning) {
+		return resp, err
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.local.RoundTrip(retry)
+}
+
+// daemons caches the check for a running daemon per socket, so a run that
+// builds a client per request, such as bulk, probes the socket once.
+var daemons = struct {
+	sync.Mutex
+	bySocket map[string]*daemon.Client
+}{bySocket: map[string]*daemon.Client{}}
+
+// connectDaemon returns a client for the daemon on the current socket, or nil
+// when none is running.
+func connectDaemon() *daemon.Client {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return nil
+	}
+	daemons.Lock()
+	defer daemons.Unlock()
+	if d, ok := daemons.bySocket[socket]; ok {
+		return d
+	}
+	d := daemon.Connect(context.Background())
+	daemons.bySocket[socket] = d
+	return d
+}
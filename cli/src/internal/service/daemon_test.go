@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticTokens struct {
+	token string
+	err   error
+}
+
+func (s staticTokens) GetToken(context.Context, string) (string, error) { return s.token, s.err }
+
+// serveDaemon runs a daemon on a temporary socket for the test's lifetime.
+func serveDaemon(t *testing.T, tokens daemon.TokenProvider) string {
+	t.Helper()
+	return serveForwardingDaemon(t, tokens, nil)
+}
+
+// serveForwardingDaemon is serveDaemon with requests forwarded through
+// transport.
+func serveForwardingDaemon(t *testing.T, tokens daemon.TokenProvider, transport http.RoundTripper) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "azr")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+	l, err := daemon.Listen(socket)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func(l net.Listener) { _ = daemon.NewServer(tokens, transport, socket, 0).Serve(ctx, l) }(l)
+	return socket
+}
+
+func TestDefaultTokenProviderFactory_UsesDaemon(t *testing.T) {
+	t.Setenv(daemon.SocketEnv, serveDaemon(t, staticTokens{token: "warm-token"}))
+
+	tp, err := DefaultTokenProviderFactory()
+	require.NoError(t, err)
+	require.IsType(t, &daemonTokenProvider{}, tp)
+
+	token, err := tp.GetToken(context.Background(), "https://management.azure.com/.default")
+	require.NoError(t, err)
+	assert.Equal(t, "warm-token", token)
+}
+
+func TestDaemonTokenProvider_ReturnsDaemonErrors(t *testing.T) {
+	socket := serveDaemon(t, staticTokens{err: errors.New("AADSTS70043: refresh token expired")})
+	tp := &daemonTokenProvider{daemon: daemon.NewClient(socket)}
+
+	// A daemon that answers with an error is not a daemon that is gone, so
+	// the error is reported rather than retried locally.
+	_, err := tp.GetToken(context.Background(), "scope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AADSTS70043")
+	assert.Nil(t, tp.fallback)
+}
+
+func TestDefaultHTTPClientFactory_ForwardsThroughDaemon(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+	socket := serveForwardingDaemon(t, staticTokens{}, http.DefaultTransport)
+	t.Setenv(daemon.SocketEnv, socket)
+
+	resp, err := DefaultHTTPClientFactory(nil, false, 5*time.Second).Execute(context.Background(), client.RequestOptions{
+		Method: http.MethodPut, URL: upstream.URL, SkipAuth: true, Body: strings.NewReader(`{"a":1}`),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(resp.Body))
+
+	status, err := daemon.NewClient(socket).Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), status.ForwardedRequests)
+}
+
+func TestDaemonTransport_FallsBackWhenDaemonIsGone(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+	dir, err := os.MkdirTemp("", "azr")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	rt := &daemonTransport{daemon: daemon.NewClient(filepath.Join(dir, "gone.sock")), local: http.DefaultTransport}
+
+	req, err := http.NewRequest(http.MethodPost, upstream.URL, strings.NewReader("replayable"))
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "replayable", string(body))
+
+	// A body that cannot be read again is not sent twice.
+	req, err = http.NewRequest(http.MethodPost, upstream.URL, io.NopCloser(strings.NewReader("stream")))
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	assert.ErrorIs(t, err, daemon.ErrNotRunning)
+}
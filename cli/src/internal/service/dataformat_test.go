@@ -52,8 +52,8 @@ func TestBuildRequestOptions_DataFormatYAMLInline(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers[contentTypeHeader] != applicationJSON {
-		t.Errorf("Content-Type = %q, want %q", opts.Headers[contentTypeHeader], applicationJSON)
+	if opts.Headers.Get(contentTypeHeader) != applicationJSON {
+		t.Errorf("Content-Type = %q, want %q", opts.Headers.Get(contentTypeHeader), applicationJSON)
 	}
 	body, err := io.ReadAll(opts.Body)
 	if err != nil {
@@ -108,8 +108,8 @@ func TestBuildRequestOptions_DataFormatYAMLKeepsUserContentType(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers[contentTypeHeader] != "application/merge-patch+json" {
-		t.Errorf("Content-Type = %q, want application/merge-patch+json", opts.Headers[contentTypeHeader])
+	if opts.Headers.Get(contentTypeHeader) != "application/merge-patch+json" {
+		t.Errorf("Content-Type = %q, want application/merge-patch+json", opts.Headers.Get(contentTypeHeader))
 	}
 }
 
@@ -129,7 +129,7 @@ func TestBuildRequestOptions_DataFormatJSONUnchanged(t *testing.T) {
 
 	// Default JSON path is a raw passthrough that does not force a Content-Type.
 	if _, ok := opts.Headers[contentTypeHeader]; ok {
-		t.Errorf("Content-Type should not be set by default JSON path, got %q", opts.Headers[contentTypeHeader])
+		t.Errorf("Content-Type should not be set by default JSON path, got %q", opts.Headers.Get(contentTypeHeader))
 	}
 	body, err := io.ReadAll(opts.Body)
 	if err != nil {
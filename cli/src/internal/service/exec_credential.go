@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// execCredentialAPIVersion is the apiVersion of the JSON a credential plugin
+// reads and writes.
+const execCredentialAPIVersion = "azd-rest.credential/v1"
+
+// execCredentialPrefix names the PATH executable that serves --auth
+// exec:<name> when the config file does not define the name.
+const execCredentialPrefix = "azd-rest-credential-"
+
+// execCredentialTimeout bounds a plugin run unless its profile sets a
+// timeout. It leaves room for a broker that prompts the user.
+const execCredentialTimeout = 2 * time.Minute
+
+// execCredentialMaxOutput caps the plugin output that is read.
+const execCredentialMaxOutput = 1 << 20
+
+// execCredentialRequest is written to a plugin's stdin. Scope is the --scope
+// value or the detected Azure scope, and empty when neither applies; URL is
+// the scheme and host the token is for.
+type execCredentialRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Scope      string `json:"scope"`
+	URL        string `json:"url"`
+}
+
+// execCredentialResponse is read from a plugin's stdout. ExpiresOn is
+// RFC 3339; without it the token is reused for the rest of the invocation.
+type execCredentialResponse struct {
+	APIVersion string `json:"apiVersion"`
+	Token      string `json:"token"`
+	ExpiresOn  string `json:"expiresOn"`
+}
+
+// runCredentialPlugin runs a plugin with request on stdin and returns its
+// stdout. The plugin's stderr is passed through so it can prompt or explain a
+// failure.
+func runCredentialPlugin(ctx context.Context, plugin config.ExecCredential, request []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, plugin.Command, plugin.Args...) // #nosec G204 -- The credential plugin the user configured or installed.
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for name, value := range plugin.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, max: execCredentialMaxOutput}
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps at most max bytes and reports an error past that, so
+// a runaway plugin cannot exhaust memory.
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.max {
+		return 0, fmt.Errorf("output exceeds %d bytes", b.max)
+	}
+	return b.buf.Write(p)
+}
+
+// resolveExecCredential finds the plugin for --auth exec:<name>: the exec
+// entry of that name in the config file, or else azd-rest-credential-<name>
+// on PATH.
+func (s *RequestService) resolveExecCredential(name, requestURL string) (*execCredentialProvider, error) {
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	plugin, ok := file.Exec[name]
+	switch {
+	case ok && plugin.Command == "":
+		return nil, &authModeError{fmt.Errorf("exec credential %q has no command", name)}
+	case !ok:
+		path, err := s.lookPath(execCredentialPrefix + name)
+		if err != nil {
+			return nil, &authModeError{fmt.Errorf("exec credential %q is not defined in the config file and %s%s is not on PATH", name, execCredentialPrefix, name)}
+		}
+		plugin = config.ExecCredential{Command: path}
+	}
+	if plugin.Timeout <= 0 {
+		plugin.Timeout = execCredentialTimeout
+	}
+
+	origin := ""
+	if u, err := url.Parse(requestURL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+	return &execCredentialProvider{
+		name:   name,
+		plugin: plugin,
+		origin: origin,
+		run:    s.runCredentialPlugin,
+		now:    time.Now,
+		tokens: map[string]execCredentialToken{},
+	}, nil
+}
+
+// execCredentialToken is a cached plugin token. A zero expiresAt never
+// expires.
+type execCredentialToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// execCredentialProvider implements TokenProvider by running a credential
+// plugin. Tokens are cached per scope in memory until shortly before they
+// expire, so retries and pages do not run the plugin again.
+type execCredentialProvider struct {
+	name   string
+	plugin config.ExecCredential
+	origin string
+	run    func(context.Context, config.ExecCredential, []byte) ([]byte, error)
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]execCredentialToken
+}
+
+// GetToken returns a cached token or runs the plugin for one. The scope
+// --auth sets when none was detected is sent to the plugin as empty.
+func (p *execCredentialProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	if strings.HasPrefix(scope, authExecPrefix) {
+		scope = ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.tokens[scope]; ok && (cached.expiresAt.IsZero() || p.now().Add(oauth2ExpirySkew).Before(cached.expiresAt)) {
+		return cached.token, nil
+	}
+
+	request, err := json.Marshal(execCredentialRequest{APIVersion: execCredentialAPIVersion, Scope: scope, URL: p.origin})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.plugin.Timeout)
+	defer cancel()
+	out, err := p.run(ctx, p.plugin, request)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("exec credential %q: plugin did not respond within %s", p.name, p.plugin.Timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("exec credential %q: plugin failed: %w", p.name, err)
+	}
+
+	var resp execCredentialResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return "", fmt.Errorf("exec credential %q: plugin output is not JSON: %w", p.name, err)
+	}
+	if resp.APIVersion != "" && resp.APIVersion != execCredentialAPIVersion {
+		return "", fmt.Errorf("exec credential %q: plugin returned apiVersion %q (expected %s)", p.name, resp.APIVersion, execCredentialAPIVersion)
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("exec credential %q: plugin returned no token", p.name)
+	}
+	cached := execCredentialToken{token: resp.Token}
+	if resp.ExpiresOn != "" {
+		if cached.expiresAt, err = time.Parse(time.RFC3339, resp.ExpiresOn); err != nil {
+			return "", fmt.Errorf("exec credential %q: plugin returned an invalid expiresOn %q (expected RFC 3339)", p.name, resp.ExpiresOn)
+		}
+	}
+	p.tokens[scope] = cached
+	return cached.token, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCredentialPlugin records the requests a plugin receives and answers
+// with out.
+type fakeCredentialPlugin struct {
+	requests []execCredentialRequest
+	out      string
+	err      error
+}
+
+func (f *fakeCredentialPlugin) run(_ context.Context, _ config.ExecCredential, request []byte) ([]byte, error) {
+	var req execCredentialRequest
+	if err := json.Unmarshal(request, &req); err != nil {
+		return nil, err
+	}
+	f.requests = append(f.requests, req)
+	return []byte(f.out), f.err
+}
+
+func TestExecute_ExecCredential_SendsPluginToken(t *testing.T) {
+	var gotAuth string
+	apiSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer apiSrv.Close()
+
+	plugin := &fakeCredentialPlugin{out: `{"apiVersion":"azd-rest.credential/v1","token":"broker-tok"}`}
+	svc := serviceWithConfigFile(config.File{Exec: map[string]config.ExecCredential{
+		"broker": {Command: "corp-broker", Args: []string{"token"}},
+	}}, nil)
+	svc.runCredentialPlugin = plugin.run
+
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Auth = "exec:broker"
+
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodGet, apiSrv.URL+"/data"))
+	assert.Equal(t, "Bearer broker-tok", gotAuth)
+	require.Len(t, plugin.requests, 1)
+	assert.Equal(t, execCredentialRequest{APIVersion: execCredentialAPIVersion, Scope: "", URL: apiSrv.URL}, plugin.requests[0])
+
+	cfg.Scope = "api://corp-api/.default"
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodGet, apiSrv.URL+"/data"))
+	require.Len(t, plugin.requests, 2)
+	assert.Equal(t, "api://corp-api/.default", plugin.requests[1].Scope, "--scope is passed to the plugin")
+}
+
+func TestResolveExecAuth(t *testing.T) {
+	svc := serviceWithConfigFile(config.File{Exec: map[string]config.ExecCredential{"empty": {}}}, nil)
+	svc.lookPath = func(name string) (string, error) {
+		if name == "azd-rest-credential-vault" {
+			return "/usr/local/bin/azd-rest-credential-vault", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	p, err := svc.resolveExecAuth(config.Config{Auth: "exec:vault"}, "https://api.contoso.com/x?y=1")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/azd-rest-credential-vault", p.plugin.Command, "a name not in the config file is looked up on PATH")
+	assert.Equal(t, execCredentialTimeout, p.plugin.Timeout)
+	assert.Equal(t, "https://api.contoso.com", p.origin)
+
+	p, err = svc.resolveExecAuth(config.Config{Auth: "azure"}, "https://x")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	invalid := map[string]config.Config{
+		"is not defined in the config file and azd-rest-credential-other is not on PATH": {Auth: "exec:other"},
+		"has no command":      {Auth: "exec:empty"},
+		"needs a plugin name": {Auth: "exec:"},
+		"cannot be combined with --no-auth or --aux-tenant": {Auth: "exec:vault", NoAuth: true},
+	}
+	for want, cfg := range invalid {
+		_, err := svc.resolveExecAuth(cfg, "https://x")
+		var usage *authModeError
+		require.ErrorAs(t, err, &usage, want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestExecCredentialProvider_CachesUntilExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	plugin := &fakeCredentialPlugin{out: `{"token":"t1","expiresOn":"2026-10-16T09:10:00Z"}`}
+	p := &execCredentialProvider{
+		name:   "broker",
+		plugin: config.ExecCredential{Timeout: time.Second},
+		run:    plugin.run,
+		now:    func() time.Time { return now },
+		tokens: map[string]execCredentialToken{},
+	}
+
+	for range 2 {
+		tok, err := p.GetToken(t.Context(), "https://management.azure.com/.default")
+		require.NoError(t, err)
+		assert.Equal(t, "t1", tok)
+	}
+	assert.Len(t, plugin.requests, 1, "the token is cached per scope")
+
+	_, err := p.GetToken(t.Context(), "https://vault.azure.net/.default")
+	require.NoError(t, err)
+	assert.Len(t, plugin.requests, 2)
+
+	now = now.Add(9 * time.Minute)
+	_, err = p.GetToken(t.Context(), "https://management.azure.com/.default")
+	require.NoError(t, err)
+	assert.Len(t, plugin.requests, 3, "a token close to expiry is refreshed")
+}
+
+func TestExecCredentialProvider_Errors(t *testing.T) {
+	cases := map[string]*fakeCredentialPlugin{
+		"plugin failed: exit status 1":         {err: errors.New("exit status 1")},
+		"plugin output is not JSON":            {out: "token"},
+		"plugin returned no token":             {out: `{}`},
+		`plugin returned apiVersion "v2"`:      {out: `{"apiVersion":"v2","token":"t"}`},
+		"plugin returned an invalid expiresOn": {out: `{"token":"t","expiresOn":"soon"}`},
+	}
+	for want, plugin := range cases {
+		p := &execCredentialProvider{
+			name:   "broker",
+			plugin: config.ExecCredential{Timeout: time.Second},
+			run:    plugin.run,
+			now:    time.Now,
+			tokens: map[string]execCredentialToken{},
+		}
+		_, err := p.GetToken(t.Context(), "s")
+		require.Error(t, err, want)
+		assert.Contains(t, err.Error(), `exec credential "broker": `+want)
+	}
+}
+
+func TestRunCredentialPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	plugin := config.ExecCredential{
+		Command: "sh",
+		Args:    []string{"-c", `read -r req; printf '{"token":"%s"}' "$PREFIX"`},
+		Env:     map[string]string{"PREFIX": "from-env"},
+	}
+	out, err := runCredentialPlugin(t.Context(), plugin, []byte("{}\n"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"token":"from-env"}`, string(out))
+}
@@ -81,3 +81,29 @@ func TestExecute_Fail_DisabledLeavesExitUnchanged(t *testing.T) {
 	err := newTestService().Execute(context.Background(), cfg, "GET", srv.URL+"/boom")
 	require.NoError(t, err)
 }
+
+func TestExecute_OnlyStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"missing"}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.OnlyStatus = true
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+	data, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "404\n", string(data))
+
+	// --fail still sets the exit code after the status is printed.
+	cfg = baseTestConfig(t)
+	cfg.OnlyStatus = true
+	cfg.Fail = true
+	err = newTestService().Execute(context.Background(), cfg, "GET", srv.URL)
+	var failErr *httpFailError
+	require.ErrorAs(t, err, &failErr)
+	data, err = os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "404\n", string(data))
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 )
@@ -30,7 +31,7 @@ func encodeFormFields(fields []string) (string, error) {
 
 // hasHeader reports whether headers already contains name, matched
 // case-insensitively.
-func hasHeader(headers map[string]string, name string) bool {
+func hasHeader(headers http.Header, name string) bool {
 	for key := range headers {
 		if strings.EqualFold(key, name) {
 			return true
@@ -2,6 +2,7 @@ package service
 
 import (
 	"io"
+	"net/http"
 	"testing"
 
 	"github.com/jongio/azd-rest/src/internal/config"
@@ -44,7 +45,7 @@ func TestEncodeFormFields(t *testing.T) {
 }
 
 func TestHasHeader(t *testing.T) {
-	headers := map[string]string{"content-type": "text/plain"}
+	headers := http.Header{"content-type": {"text/plain"}}
 	if !hasHeader(headers, "Content-Type") {
 		t.Error("expected case-insensitive match for Content-Type")
 	}
@@ -66,8 +67,8 @@ func TestBuildRequestOptions_FormFieldsBody(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers[contentTypeHeader] != formURLEncoded {
-		t.Errorf("Content-Type = %q, want %q", opts.Headers[contentTypeHeader], formURLEncoded)
+	if opts.Headers.Get(contentTypeHeader) != formURLEncoded {
+		t.Errorf("Content-Type = %q, want %q", opts.Headers.Get(contentTypeHeader), formURLEncoded)
 	}
 
 	body, err := io.ReadAll(opts.Body)
@@ -94,8 +95,8 @@ func TestBuildRequestOptions_FormFieldsKeepsUserContentType(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers["Content-Type"] != "application/json" {
-		t.Errorf("Content-Type = %q, want application/json", opts.Headers["Content-Type"])
+	if opts.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", opts.Headers.Get("Content-Type"))
 	}
 }
 
@@ -17,6 +17,13 @@ import (
 // Nested objects and arrays are written as compact JSON inside the cell. An
 // empty result set produces no output.
 func renderCSV(body []byte) (string, error) {
+	return renderCSVWithColumns(body, nil, false)
+}
+
+// renderCSVWithColumns renders CSV like renderCSV, with one column per
+// --columns expression when columns is set. With flatten, nested properties
+// of each row become their own dotted-path columns before columns are chosen.
+func renderCSVWithColumns(body []byte, columns []outputColumn, flatten bool) (string, error) {
 	dec := json.NewDecoder(bytes.NewReader(body))
 	dec.UseNumber()
 
@@ -29,33 +36,42 @@ func renderCSV(body []byte) (string, error) {
 	if len(rows) == 0 {
 		return "", nil
 	}
-
-	var b strings.Builder
-	w := csv.NewWriter(&b)
-
-	columns, allObjects := tableColumns(rows)
-	if allObjects {
-		if err := writeCSVRecord(w, columns); err != nil {
-			return "", err
+	if flatten {
+		var err error
+		if rows, err = flattenRows(rows); err != nil {
+			return "", fmt.Errorf("failed to flatten csv rows: %w", err)
 		}
+	}
+
+	var header []string
+	var data [][]string
+	if len(columns) > 0 {
+		header, data = projectRows(rows, columns)
+	} else if names, allObjects := tableColumns(rows); allObjects {
+		header = names
 		for _, row := range rows {
 			obj, _ := row.(map[string]any)
-			cells := make([]string, len(columns))
-			for i, col := range columns {
+			cells := make([]string, len(names))
+			for i, col := range names {
 				cells[i] = tableCellString(obj[col])
 			}
-			if err := writeCSVRecord(w, cells); err != nil {
-				return "", err
-			}
+			data = append(data, cells)
 		}
 	} else {
-		if err := writeCSVRecord(w, []string{valueKey}); err != nil {
-			return "", err
-		}
+		header = []string{valueKey}
 		for _, row := range rows {
-			if err := writeCSVRecord(w, []string{tableCellString(row)}); err != nil {
-				return "", err
-			}
+			data = append(data, []string{tableCellString(row)})
+		}
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := writeCSVRecord(w, header); err != nil {
+		return "", err
+	}
+	for _, cells := range data {
+		if err := writeCSVRecord(w, cells); err != nil {
+			return "", err
 		}
 	}
 
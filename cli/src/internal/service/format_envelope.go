@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// formatEnvelope is the --format value that wraps the response in a JSON
+// envelope with its metadata.
+const formatEnvelope = "envelope"
+
+// requestIDHeaders are the response headers checked, in order, for the
+// envelope's requestId.
+var requestIDHeaders = []string{
+	"x-ms-request-id",
+	"x-ms-correlation-request-id",
+	"apim-request-id",
+	"request-id",
+	"x-request-id",
+	"x-ms-client-request-id",
+}
+
+// responseEnvelope is the stable shape printed by --format envelope. Body is
+// the JSON response as-is, a string for text, or base64 for binary content,
+// in which case BodyEncoding is "base64".
+type responseEnvelope struct {
+	Status       int               `json:"status"`
+	Headers      map[string]string `json:"headers"`
+	Body         json.RawMessage   `json:"body"`
+	BodyEncoding string            `json:"bodyEncoding,omitempty"`
+	DurationMs   int64             `json:"durationMs"`
+	RequestID    string            `json:"requestId,omitempty"`
+}
+
+// renderEnvelope renders resp as a response envelope, indented unless compact
+// is set. Header values are redacted as for --include, and repeated headers
+// are joined with ", ".
+func renderEnvelope(resp *client.Response, binary, compact bool) (string, error) {
+	env := responseEnvelope{
+		Status:     resp.StatusCode,
+		Headers:    redactedHeaderMap(resp.Headers),
+		DurationMs: resp.Duration.Milliseconds(),
+		RequestID:  envelopeRequestID(resp.Headers),
+	}
+
+	body, encoding, err := envelopeBody(resp.Body, binary || client.DetectContentType(resp.Body, resp.Headers.Get("Content-Type")))
+	if err != nil {
+		return "", err
+	}
+	env.Body, env.BodyEncoding = body, encoding
+
+	var out []byte
+	if compact {
+		out, err = json.Marshal(env)
+	} else {
+		out, err = json.MarshalIndent(env, "", "  ")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode response envelope: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+// envelopeBody returns body as a JSON value: the document itself when it is
+// JSON, null when it is empty, base64 when it is binary, and a string
+// otherwise.
+func envelopeBody(body []byte, binary bool) (json.RawMessage, string, error) {
+	trimmed := bytes.TrimSpace(body)
+	var (
+		raw      []byte
+		encoding string
+		err      error
+	)
+	switch {
+	case len(trimmed) == 0:
+		return json.RawMessage("null"), "", nil
+	case !binary && json.Valid(trimmed):
+		return json.RawMessage(trimmed), "", nil
+	case binary || !utf8.Valid(body):
+		raw, err = json.Marshal(base64.StdEncoding.EncodeToString(body))
+		encoding = "base64"
+	default:
+		raw, err = json.Marshal(string(body))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode response body: %w", err)
+	}
+	return raw, encoding, nil
+}
+
+// envelopeRequestID returns the first request ID header the service sent.
+func envelopeRequestID(headers http.Header) string {
+	for _, name := range requestIDHeaders {
+		if v := headers.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// redactedHeaderMap returns headers with sensitive values redacted as for
+// --include, and repeated headers joined with ", ".
+func redactedHeaderMap(headers http.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+	for key, values := range headers {
+		redacted := make([]string, len(values))
+		for i, v := range values {
+			redacted[i] = client.RedactHeaderValue(key, v)
+		}
+		out[key] = strings.Join(redacted, ", ")
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderEnvelope_JSONBody(t *testing.T) {
+	resp := &client.Response{
+		StatusCode: 201,
+		Headers: http.Header{
+			"Content-Type":                {"application/json"},
+			"X-Ms-Correlation-Request-Id": {"corr-1"},
+			"X-Ms-Request-Id":             {"req-1"},
+			"Vary":                        {"Accept", "Origin"},
+			"Authorization":               {"Bearer abcdefghijklmnopqrstuvwxyz"},
+		},
+		Body:     []byte(`{"id": 12345678901234567890, "ok": true}`),
+		Duration: 1500 * time.Millisecond,
+	}
+
+	out, err := renderEnvelope(resp, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(out, "\n"), "compact envelope is one line")
+
+	var env map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(out), &env))
+	assert.JSONEq(t, `201`, string(env["status"]))
+	assert.JSONEq(t, `1500`, string(env["durationMs"]))
+	assert.JSONEq(t, `"req-1"`, string(env["requestId"]))
+	assert.Equal(t, `{"id":12345678901234567890,"ok":true}`, string(env["body"]), "numbers keep their precision")
+	assert.NotContains(t, env, "bodyEncoding")
+
+	var headers map[string]string
+	require.NoError(t, json.Unmarshal(env["headers"], &headers))
+	assert.Equal(t, "Accept, Origin", headers["Vary"])
+	assert.Equal(t, "corr-1", headers["X-Ms-Correlation-Request-Id"])
+	assert.NotContains(t, headers["Authorization"], "ghijklmnopqrst")
+}
+
+func TestRenderEnvelope_NonJSONBodies(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         []byte
+		contentType  string
+		wantBody     string
+		wantEncoding string
+	}{
+		{name: "empty", body: nil, wantBody: `null`},
+		{name: "text", body: []byte("plain <text>\n"), contentType: "text/plain", wantBody: `"plain <text>\n"`},
+		{name: "binary", body: []byte{0x89, 'P', 'N', 'G', 0, 1}, contentType: "image/png", wantBody: `"iVBORwAB"`, wantEncoding: "base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &client.Response{StatusCode: 200, Headers: http.Header{"Content-Type": {tt.contentType}}, Body: tt.body}
+			out, err := renderEnvelope(resp, false, false)
+			require.NoError(t, err)
+			var env responseEnvelope
+			require.NoError(t, json.Unmarshal([]byte(out), &env))
+			assert.JSONEq(t, tt.wantBody, string(env.Body))
+			assert.Equal(t, tt.wantEncoding, env.BodyEncoding)
+			assert.Empty(t, env.RequestID)
+		})
+	}
+}
+
+func TestExecute_EnvelopeFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ms-request-id", "abc")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"NotFound","message":"gone"}}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.OutputFormat = formatEnvelope
+	cfg.Query = "error.code"
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+
+	data, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	var env responseEnvelope
+	require.NoError(t, json.Unmarshal(data, &env))
+	assert.Equal(t, 404, env.Status)
+	assert.Equal(t, "abc", env.RequestID)
+	assert.JSONEq(t, `"NotFound"`, string(env.Body))
+	assert.Contains(t, string(data), "\n  \"status\": 404,", "indented by default")
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// Media types the auto format renders on their own instead of as opaque text.
+const (
+	mediaProblemJSON = "application/problem+json"
+	mediaEventStream = "text/event-stream"
+)
+
+// ndjsonMediaTypes are the media types of newline-delimited JSON bodies.
+var ndjsonMediaTypes = map[string]bool{
+	"application/x-ndjson":    true,
+	"application/ndjson":      true,
+	"application/jsonl":       true,
+	"application/x-jsonlines": true,
+}
+
+// problemMembers are the RFC 7807 members of a problem details object, in the
+// order they are written.
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// renderMediaType renders a body by its media type for the auto format:
+// problem details with the RFC 7807 members first, a server-sent event stream
+// one event at a time with JSON data pretty-printed, and newline-delimited
+// JSON one pretty-printed value per line. ok is false for any other media
+// type, or when the body does not parse as that type, so the caller falls
+// back to the formatter.
+func renderMediaType(body []byte, contentType string) (out string, ok bool) {
+	mediaType := mediaTypeOf(contentType)
+	switch {
+	case mediaType == mediaProblemJSON:
+		return renderProblemJSON(body)
+	case mediaType == mediaEventStream:
+		return renderEventStream(body), true
+	case ndjsonMediaTypes[mediaType]:
+		return renderNDJSON(body), true
+	}
+	return "", false
+}
+
+// mediaTypeOf returns the lower-cased media type of a Content-Type value
+// without its parameters, or "" when it does not parse.
+func mediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// renderProblemJSON pretty-prints a problem details object with type, title,
+// status, detail, and instance first, followed by its extension members in
+// sorted order. Member values are written exactly as received.
+func renderProblemJSON(body []byte) (string, bool) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return "", false
+	}
+	keys := make([]string, 0, len(members))
+	for _, key := range problemMembers {
+		if _, found := members[key]; found {
+			keys = append(keys, key)
+		}
+	}
+	var extensions []string
+	for key := range members {
+		if !isProblemMember(key) {
+			extensions = append(extensions, key)
+		}
+	}
+	sort.Strings(extensions)
+	keys = append(keys, extensions...)
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return "", false
+		}
+		var value bytes.Buffer
+		if err := json.Indent(&value, members[key], jsonIndent, jsonIndent); err != nil {
+			return "", false
+		}
+		fmt.Fprintf(&b, "\n%s%s: %s", jsonIndent, name, value.String())
+	}
+	if len(keys) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), true
+}
+
+func isProblemMember(key string) bool {
+	for _, member := range problemMembers {
+		if key == member {
+			return true
+		}
+	}
+	return false
+}
+
+// problemSummary returns a one-line summary of a problem details body, such
+// as "Problem: Out of credit (403): Your balance is 30.", or "" when the body
+// has no title, status, or detail.
+func problemSummary(body []byte) string {
+	var problem struct {
+		Title  string          `json:"title"`
+		Status json.RawMessage `json:"status"`
+		Detail string          `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return ""
+	}
+	var parts []string
+	if problem.Title != "" {
+		parts = append(parts, problem.Title)
+	}
+	if status := strings.Trim(string(problem.Status), `"`); status != "" && status != "null" {
+		parts = append(parts, "("+status+")")
+	}
+	if len(parts) == 0 && problem.Detail == "" {
+		return ""
+	}
+	line := "Problem: " + strings.Join(parts, " ")
+	if problem.Detail != "" {
+		if len(parts) > 0 {
+			line += ": "
+		}
+		line += problem.Detail
+	}
+	return line
+}
+
+// renderEventStream renders a server-sent event stream one event per block.
+// The event, id, and retry fields are kept, comment lines are dropped, and
+// the data lines of each event are joined as the spec describes; data that is
+// JSON is pretty-printed below its "data:" line.
+func renderEventStream(body []byte) string {
+	var b strings.Builder
+	var fields []string
+	var data []string
+	hasData := false
+	flush := func() {
+		if len(fields) == 0 && !hasData {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		for _, field := range fields {
+			b.WriteString(field + "\n")
+		}
+		if hasData {
+			writeEventData(&b, strings.Join(data, "\n"))
+		}
+		fields, data, hasData = nil, nil, false
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		if name == "data" {
+			data = append(data, value)
+			hasData = true
+			continue
+		}
+		fields = append(fields, name+": "+value)
+	}
+	flush()
+	return b.String()
+}
+
+// writeEventData writes an event's data, pretty-printed below a bare "data:"
+// line when it is a JSON object or array and on one line otherwise.
+func writeEventData(b *strings.Builder, data string) {
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(trimmed), jsonIndent, jsonIndent) == nil {
+			b.WriteString("data:\n" + jsonIndent + indented.String() + "\n")
+			return
+		}
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+}
+
+// renderNDJSON pretty-prints each line of newline-delimited JSON. Blank lines
+// are skipped and a line that is not JSON is written unchanged, so a
+// truncated final line still shows up.
+func renderNDJSON(body []byte) string {
+	var b strings.Builder
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(line), "", jsonIndent); err != nil {
+			b.WriteString(line + "\n")
+			continue
+		}
+		b.WriteString(indented.String() + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMediaType_ProblemJSON(t *testing.T) {
+	body := `{"balance":30,"detail":"Your balance is 30.","title":"Out of credit","type":"https://example.com/credit","status":403,"accounts":["/a/1"]}`
+	out, ok := renderMediaType([]byte(body), "application/problem+json; charset=utf-8")
+	require.True(t, ok)
+	assert.Equal(t, `{
+  "type": "https://example.com/credit",
+  "title": "Out of credit",
+  "status": 403,
+  "detail": "Your balance is 30.",
+  "accounts": [
+    "/a/1"
+  ],
+  "balance": 30
+}
+`, out)
+
+	assert.Equal(t, "Problem: Out of credit (403): Your balance is 30.", problemSummary([]byte(body)))
+	assert.Equal(t, "Problem: Gone", problemSummary([]byte(`{"title":"Gone"}`)))
+	assert.Empty(t, problemSummary([]byte(`{"type":"about:blank"}`)))
+
+	_, ok = renderMediaType([]byte(`not json`), "application/problem+json")
+	assert.False(t, ok, "a body that is not an object falls back to the formatter")
+}
+
+func TestRenderMediaType_EventStream(t *testing.T) {
+	body := ": keep-alive\r\n" +
+		"event: update\r\nid: 1\r\ndata: {\"n\":1}\r\n\r\n" +
+		"data: first\ndata: second\n\n" +
+		"retry: 500\n"
+	out, ok := renderMediaType([]byte(body), "text/event-stream")
+	require.True(t, ok)
+	assert.Equal(t, `event: update
+id: 1
+data:
+  {
+    "n": 1
+  }
+
+data: first
+data: second
+
+retry: 500
+`, out)
+}
+
+func TestRenderMediaType_NDJSON(t *testing.T) {
+	body := "{\"a\":1}\n\n[2,3]\n{\"trunc"
+	out, ok := renderMediaType([]byte(body), "application/x-ndjson")
+	require.True(t, ok)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n[\n  2,\n  3\n]\n{\"trunc\n", out)
+
+	_, ok = renderMediaType([]byte(body), "application/json")
+	assert.False(t, ok)
+}
+
+func TestExecute_AutoFormatRendersEventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: done\ndata: {\"ok\":true}\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "event: done\ndata:\n  {\n    \"ok\": true\n  }\n", string(got))
+
+	cfg = baseTestConfig(t)
+	cfg.OutputFormat = formatRaw
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err = os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "event: done\ndata: {\"ok\":true}\n\n", string(got), "other formats leave the stream as received")
+}
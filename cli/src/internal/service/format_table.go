@@ -219,6 +219,12 @@ func formatTable(header []string, rows [][]string) string {
 	return b.String()
 }
 
+// FormatTable lays out the header and rows the way --format table does, for
+// commands that build their own rows.
+func FormatTable(header []string, rows [][]string) string {
+	return formatTable(header, rows)
+}
+
 // writeTableLine writes one padded, right-trimmed table row.
 func writeTableLine(b *strings.Builder, cells []string, widths []int) {
 	var parts []string
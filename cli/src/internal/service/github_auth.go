@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// gitHubAPIHost is the only host --github-auth sends a token to.
+const gitHubAPIHost = "api.github.com"
+
+// ghCLITimeout bounds the `gh auth token` fallback.
+const ghCLITimeout = 10 * time.Second
+
+// applyGitHubAuth handles --github-auth: for an https://api.github.com request
+// it sets Authorization to a bearer token from GH_TOKEN, GITHUB_TOKEN, or the
+// GitHub CLI, in that order. Any other host gets no token and a warning, so
+// the flag can stay on in scripts that mix GitHub and Azure calls.
+func (s *RequestService) applyGitHubAuth(cfg config.Config, requestURL string, headers http.Header) error {
+	if !cfg.GitHubAuth {
+		return nil
+	}
+	if cfg.User != "" || cfg.BearerEnv != "" || (cfg.Auth != "" && cfg.Auth != authModeAzure) {
+		return &authModeError{fmt.Errorf("--github-auth cannot be combined with --user, --bearer-env, or --auth %s", cfg.Auth)}
+	}
+	if hasHeader(headers, authorizationHeader) {
+		return &authModeError{fmt.Errorf("--github-auth cannot be combined with an Authorization header")}
+	}
+
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	if parsed.Scheme != "https" || !strings.EqualFold(parsed.Hostname(), gitHubAPIHost) {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: --github-auth applies only to https://%s; no GitHub token sent to %s\n", gitHubAPIHost, parsed.Hostname())
+		return nil
+	}
+
+	token, err := s.gitHubToken()
+	if err != nil {
+		return err
+	}
+	headers.Set(authorizationHeader, "Bearer "+token)
+	return nil
+}
+
+// gitHubToken returns the first non-empty token from GH_TOKEN, GITHUB_TOKEN,
+// or `gh auth token`.
+func (s *RequestService) gitHubToken() (string, error) {
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		if v, ok := s.lookupEnv(name); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	token, err := s.ghCLIToken()
+	if err != nil {
+		return "", fmt.Errorf("--github-auth: no token in GH_TOKEN or GITHUB_TOKEN, and the GitHub CLI fallback failed: %w", err)
+	}
+	return token, nil
+}
+
+// ghCLIToken runs `gh auth token` and returns its output. It is the default
+// for RequestService.ghCLIToken.
+func ghCLIToken() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ghCLITimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", "github.com").Output()
+	if err != nil {
+		return "", fmt.Errorf("gh auth token: %w", err)
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("gh auth token returned an empty token")
+	}
+	return token, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitHubTestService returns a service whose environment and gh CLI are stubbed.
+func gitHubTestService(env map[string]string, cliToken string) *RequestService {
+	svc := newTestService()
+	svc.lookupEnv = envLookup(env)
+	svc.ghCLIToken = func() (string, error) {
+		if cliToken == "" {
+			return "", errors.New("gh not installed")
+		}
+		return cliToken, nil
+	}
+	return svc
+}
+
+func TestApplyGitHubAuth_TokenSources(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		cli  string
+		want string
+	}{
+		{"GH_TOKEN first", map[string]string{"GH_TOKEN": "gh-1", "GITHUB_TOKEN": "gh-2"}, "cli", "Bearer gh-1"},
+		{"GITHUB_TOKEN", map[string]string{"GITHUB_TOKEN": "gh-2"}, "cli", "Bearer gh-2"},
+		{"gh CLI fallback", nil, "cli", "Bearer cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			svc := gitHubTestService(tt.env, tt.cli)
+			require.NoError(t, svc.applyGitHubAuth(config.Config{GitHubAuth: true}, "https://api.github.com/user", headers))
+			assert.Equal(t, tt.want, headers.Get("Authorization"))
+		})
+	}
+}
+
+func TestApplyGitHubAuth_OtherHostsGetNoToken(t *testing.T) {
+	svc := gitHubTestService(map[string]string{"GH_TOKEN": "gh-1"}, "")
+	for _, u := range []string{"https://management.azure.com/subscriptions", "http://api.github.com/user", "https://api.github.com.evil.example/user"} {
+		headers := http.Header{}
+		require.NoError(t, svc.applyGitHubAuth(config.Config{GitHubAuth: true, Silent: true}, u, headers))
+		assert.Empty(t, headers, u)
+	}
+}
+
+func TestApplyGitHubAuth_Errors(t *testing.T) {
+	svc := gitHubTestService(nil, "")
+
+	err := svc.applyGitHubAuth(config.Config{GitHubAuth: true}, "https://api.github.com/user", http.Header{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GitHub CLI fallback failed")
+
+	err = svc.applyGitHubAuth(config.Config{GitHubAuth: true}, "https://api.github.com/user", http.Header{"Authorization": {"token x"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Authorization header")
+
+	err = svc.applyGitHubAuth(config.Config{GitHubAuth: true, BearerEnv: "X"}, "https://api.github.com/user", http.Header{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined")
+}
+
+func TestApplyGitHubAuth_Disabled(t *testing.T) {
+	headers := http.Header{}
+	svc := gitHubTestService(map[string]string{"GH_TOKEN": "gh-1"}, "")
+	require.NoError(t, svc.applyGitHubAuth(config.Config{}, "https://api.github.com/user", headers))
+	assert.Empty(t, headers)
+}
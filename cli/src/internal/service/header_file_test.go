@@ -36,9 +36,9 @@ func TestLoadHeaderFile_ParsesAndSkipsCommentsAndBlanks(t *testing.T) {
 	got, err := loadHeaderFile(path)
 
 	require.NoError(t, err)
-	assert.Equal(t, testAcceptJSON, got["Accept"])
-	assert.Equal(t, "abc123", got["X-Trace"])
-	assert.Equal(t, "", got["X-Empty"])
+	assert.Equal(t, testAcceptJSON, got.Get("Accept"))
+	assert.Equal(t, "abc123", got.Get("X-Trace"))
+	assert.Equal(t, "", got.Get("X-Empty"))
 	assert.Len(t, got, 3)
 }
 
@@ -77,8 +77,8 @@ func TestBuildRequestOptions_HeaderFileLoadsHeaders(t *testing.T) {
 	}
 
 	require.NoError(t, err)
-	assert.Equal(t, testAcceptJSON, opts.Headers["Accept"])
-	assert.Equal(t, "abc123", opts.Headers["X-Trace"])
+	assert.Equal(t, testAcceptJSON, opts.Headers.Get("Accept"))
+	assert.Equal(t, "abc123", opts.Headers.Get("X-Trace"))
 }
 
 func TestBuildRequestOptions_InlineHeaderOverridesFile(t *testing.T) {
@@ -95,7 +95,36 @@ func TestBuildRequestOptions_InlineHeaderOverridesFile(t *testing.T) {
 	}
 
 	require.NoError(t, err)
-	assert.Equal(t, testAcceptJSON, opts.Headers["Accept"])
+	assert.Equal(t, testAcceptJSON, opts.Headers.Get("Accept"))
+}
+
+func TestBuildRequestOptions_RepeatedHeaderAddsValues(t *testing.T) {
+	path := writeHeaderFile(t, "Accept: application/xml\nX-Trace: a\nX-Trace: b\n")
+	cfg := config.Config{
+		NoAuth:     true,
+		HeaderFile: path,
+		Headers:    []string{"Accept: application/json", "accept: text/plain"},
+	}
+
+	opts, cleanup, err := newHeaderTestService().BuildRequestOptions(cfg, "GET", testHeaderURL)
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{testAcceptJSON, "text/plain"}, opts.Headers.Values("Accept"))
+	assert.Equal(t, []string{"a", "b"}, opts.Headers.Values("X-Trace"))
+}
+
+func TestWithDefaultHeader(t *testing.T) {
+	assert.Equal(t, []string{"Content-Type: application/json", "Accept: */*"},
+		WithDefaultHeader([]string{"Accept: */*"}, "Content-Type", "application/json"))
+
+	args := []string{"content-type: text/plain"}
+	got := WithDefaultHeader(args, "Content-Type", "application/json")
+	assert.Equal(t, args, got)
+	got[0] = "changed"
+	assert.Equal(t, "content-type: text/plain", args[0], "result must not alias the input")
 }
 
 func TestBuildRequestOptions_HeaderFileMissingReturnsError(t *testing.T) {
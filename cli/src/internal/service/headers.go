@@ -0,0 +1,40 @@
+package service
+
+import "strings"
+
+// hasHeaderArg reports whether a Key: Value header argument list sets name.
+func hasHeaderArg(headerArgs []string, name string) bool {
+	for _, h := range headerArgs {
+		key, _, _ := strings.Cut(h, ":")
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDefaultHeader returns headerArgs with name: value prepended, unless
+// headerArgs already sets name. Repeated headers add values rather than
+// replace them, so a default must be left out, not overridden, when the
+// caller supplies its own. The result never aliases headerArgs.
+func WithDefaultHeader(headerArgs []string, name, value string) []string {
+	if hasHeaderArg(headerArgs, name) {
+		return append([]string(nil), headerArgs...)
+	}
+	return append([]string{name + ": " + value}, headerArgs...)
+}
+
+// overrideHeaderArgs returns headerArgs without the arguments whose names
+// overrides sets, followed by overrides, so each header in overrides replaces
+// rather than adds to the one in headerArgs. The result never aliases
+// headerArgs.
+func overrideHeaderArgs(headerArgs, overrides []string) []string {
+	out := make([]string, 0, len(headerArgs)+len(overrides))
+	for _, h := range headerArgs {
+		key, _, _ := strings.Cut(h, ":")
+		if !hasHeaderArg(overrides, strings.TrimSpace(key)) {
+			out = append(out, h)
+		}
+	}
+	return append(out, overrides...)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// HookSectionPath is the azure.yaml section that declares lifecycle hook
+// requests, keyed by event name.
+const HookSectionPath = "rest.hooks"
+
+// HookEvents are the azd project events hook requests can run on.
+var HookEvents = []string{"postprovision", "postdeploy"}
+
+// varRefPattern matches a ${NAME} variable reference.
+var varRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// HookRequest is one REST call declared in azure.yaml for an azd lifecycle
+// event. URL, header values, and Body may reference azd environment values as
+// ${NAME}. Body may be any YAML value: a string is sent as-is and anything
+// else is sent as JSON.
+type HookRequest struct {
+	Name            string            `json:"name,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	URL             string            `json:"url"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            any               `json:"body,omitempty"`
+	Scope           string            `json:"scope,omitempty"`
+	NoAuth          bool              `json:"noAuth,omitempty"`
+	ExpectStatus    []int             `json:"expectStatus,omitempty"`
+	ContinueOnError bool              `json:"continueOnError,omitempty"`
+}
+
+// ParseHooks decodes the rest.hooks section into requests per event. Unknown
+// event names are an error so a typo does not silently skip a hook.
+func ParseHooks(section map[string]any) (map[string][]HookRequest, error) {
+	hooks := make(map[string][]HookRequest, len(section))
+	for event, raw := range section {
+		if !slices.Contains(HookEvents, event) {
+			return nil, fmt.Errorf("%s: unsupported event %q (expected one of %s)", HookSectionPath, event, strings.Join(HookEvents, ", "))
+		}
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", HookSectionPath, event, err)
+		}
+		var requests []HookRequest
+		if err := json.Unmarshal(encoded, &requests); err != nil {
+			return nil, fmt.Errorf("%s.%s must be a list of requests: %w", HookSectionPath, event, err)
+		}
+		for i, r := range requests {
+			if strings.TrimSpace(r.URL) == "" {
+				return nil, fmt.Errorf("%s.%s[%d]: url is required", HookSectionPath, event, i)
+			}
+		}
+		hooks[event] = requests
+	}
+	return hooks, nil
+}
+
+// RunHooks executes the requests declared for an event in order, expanding
+// ${NAME} references from values and logging one line per request to log. A
+// request fails on a transport error or an unexpected status (any non-2xx
+// unless expectStatus is set). A failure stops the run and is returned unless
+// the request sets continueOnError.
+func (s *RequestService) RunHooks(ctx context.Context, event string, requests []HookRequest, values map[string]string, log io.Writer) error {
+	for i, r := range requests {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		err := s.runHook(ctx, event, name, r, values, log)
+		if err == nil {
+			continue
+		}
+		if r.ContinueOnError {
+			fmt.Fprintf(log, "rest %s hook %s: %v (continuing)\n", event, name, err)
+			continue
+		}
+		return fmt.Errorf("rest %s hook %s: %w", event, name, err)
+	}
+	return nil
+}
+
+// runHook expands and sends one hook request.
+func (s *RequestService) runHook(ctx context.Context, event, name string, r HookRequest, values map[string]string, log io.Writer) error {
+	method := strings.ToUpper(strings.TrimSpace(r.Method))
+	if method == "" {
+		method = "GET"
+	}
+	url, err := expandHookTemplate(r.URL, values)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Defaults()
+	cfg.Silent = true
+	cfg.Scope = r.Scope
+	cfg.NoAuth = r.NoAuth
+	keys := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := expandHookTemplate(r.Headers[k], values)
+		if err != nil {
+			return err
+		}
+		cfg.Headers = append(cfg.Headers, k+": "+v)
+	}
+	if r.Body != nil {
+		body, ok := r.Body.(string)
+		if !ok {
+			raw, err := json.Marshal(r.Body)
+			if err != nil {
+				return fmt.Errorf("failed to encode body: %w", err)
+			}
+			body = string(raw)
+		}
+		if cfg.Data, err = expandHookTemplate(body, values); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	resp, err := s.doBulkRequest(ctx, cfg, method, url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "rest %s hook %s: %s %s -> %d (%dms)\n", event, name, method, client.RedactURL(url), resp.StatusCode, time.Since(start).Milliseconds())
+	if !hookStatusOK(resp.StatusCode, r.ExpectStatus) {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// hookStatusOK reports whether status is acceptable: listed in expect, or any
+// 2xx status when expect is empty.
+func hookStatusOK(status int, expect []int) bool {
+	if len(expect) == 0 {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(expect, status)
+}
+
+// expandHookTemplate replaces ${NAME} references with azd environment values.
+// A reference to a value that is not set is an error, so a hook never calls a
+// half-formed URL.
+func expandHookTemplate(s string, values map[string]string) (string, error) {
+	var missing []string
+	out := varRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := varRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("azd environment value(s) not set: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHooks(t *testing.T) {
+	hooks, err := ParseHooks(map[string]any{
+		"postprovision": []any{
+			map[string]any{"name": "warm", "method": "post", "url": "https://x", "body": map[string]any{"a": 1.0}, "expectStatus": []any{200.0, 202.0}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, hooks["postprovision"], 1)
+	assert.Equal(t, "warm", hooks["postprovision"][0].Name)
+	assert.Equal(t, []int{200, 202}, hooks["postprovision"][0].ExpectStatus)
+
+	_, err = ParseHooks(map[string]any{"postprovison": []any{}})
+	assert.ErrorContains(t, err, `unsupported event "postprovison"`)
+
+	_, err = ParseHooks(map[string]any{"postdeploy": []any{map[string]any{"name": "no url"}}})
+	assert.ErrorContains(t, err, "url is required")
+
+	_, err = ParseHooks(map[string]any{"postdeploy": "https://x"})
+	assert.ErrorContains(t, err, "must be a list of requests")
+}
+
+func TestExpandHookTemplate(t *testing.T) {
+	values := map[string]string{"HOST": "api.example.com", "ENV": "dev"}
+	got, err := expandHookTemplate("https://${HOST}/health?env=${ENV}&raw=$ENV", values)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/health?env=dev&raw=$ENV", got)
+
+	_, err = expandHookTemplate("https://${HOST}/${MISSING}/${ALSO}", values)
+	assert.ErrorContains(t, err, "MISSING, ALSO")
+}
+
+func TestRunHooks(t *testing.T) {
+	var gotBody, gotHeader, gotMethod string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/warm":
+			body, _ := io.ReadAll(r.Body)
+			gotMethod, gotBody, gotHeader = r.Method, string(body), r.Header.Get("X-Env")
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer srv.Close()
+	values := map[string]string{"BASE": srv.URL, "ENV": "dev"}
+
+	var log bytes.Buffer
+	err := newTestService().RunHooks(context.Background(), "postdeploy", []HookRequest{
+		{Name: "flaky", URL: "${BASE}/down", NoAuth: true, ContinueOnError: true},
+		{Name: "warm", Method: "post", URL: "${BASE}/warm", NoAuth: true, Headers: map[string]string{"X-Env": "${ENV}"}, Body: map[string]any{"env": "${ENV}"}},
+	}, values, &log)
+	require.NoError(t, err)
+	assert.Equal(t, "POST", gotMethod)
+	assert.JSONEq(t, `{"env":"dev"}`, gotBody)
+	assert.Equal(t, "dev", gotHeader)
+	assert.Contains(t, log.String(), "rest postdeploy hook flaky: unexpected status 409 (continuing)")
+	assert.Contains(t, log.String(), "rest postdeploy hook warm: POST "+srv.URL+"/warm -> 202")
+
+	err = newTestService().RunHooks(context.Background(), "postdeploy", []HookRequest{
+		{URL: "${BASE}/down", NoAuth: true},
+		{URL: "${BASE}/warm", NoAuth: true},
+	}, values, io.Discard)
+	assert.ErrorContains(t, err, "rest postdeploy hook #1: unexpected status 409")
+
+	err = newTestService().RunHooks(context.Background(), "postdeploy", []HookRequest{
+		{URL: "${BASE}/down", NoAuth: true, ExpectStatus: []int{409}},
+	}, values, io.Discard)
+	assert.NoError(t, err)
+}
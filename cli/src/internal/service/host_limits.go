@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// ARM-safe defaults applied to Azure Resource Manager hosts without a
+// --host-limit of their own. They stay under ARM's per-subscription write
+// throttling, the stricter of its read and write limits.
+const (
+	armDefaultRPS      = 10
+	armDefaultInFlight = 8
+)
+
+// Adaptive slowdown bounds. A 429 halves a host's rate, down to
+// minAdaptiveRPS; a host without a rate cap is slowed from adaptiveStartRPS.
+// Each later response speeds it up again by a tenth of its cap.
+const (
+	adaptiveStartRPS   = 10
+	minAdaptiveRPS     = 0.5
+	maxRetryAfterPause = 5 * time.Minute
+)
+
+// anyHost is the --host-limit host that applies to every host without a
+// limit of its own.
+const anyHost = "*"
+
+// hostLimit caps the requests sent to one host: rps per second and inFlight
+// at a time. Zero means no cap.
+type hostLimit struct {
+	rps      float64
+	inFlight int
+}
+
+// parseHostLimits parses --host-limit values, HOST=RPS[:IN_FLIGHT], on top of
+// the ARM defaults. HOST * applies to every other host.
+func parseHostLimits(specs []string) (map[string]hostLimit, error) {
+	limits := map[string]hostLimit{}
+	for _, host := range client.ARMHosts {
+		limits[host] = hostLimit{rps: armDefaultRPS, inFlight: armDefaultInFlight}
+	}
+	for _, spec := range specs {
+		invalid := fmt.Errorf("invalid --host-limit %q (expected HOST=RPS[:IN_FLIGHT], such as management.azure.com=20:8)", spec)
+		host, value, ok := strings.Cut(spec, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !ok || host == "" || strings.ContainsAny(host, "/ ") {
+			return nil, invalid
+		}
+		rpsText, inFlightText, hasInFlight := strings.Cut(strings.TrimSpace(value), ":")
+		var limit hostLimit
+		var err error
+		if limit.rps, err = strconv.ParseFloat(rpsText, 64); err != nil || limit.rps < 0 || limit.rps > 1e6 {
+			return nil, invalid
+		}
+		if hasInFlight {
+			if limit.inFlight, err = strconv.Atoi(inFlightText); err != nil || limit.inFlight < 0 {
+				return nil, invalid
+			}
+		}
+		limits[host] = limit
+	}
+	return limits, nil
+}
+
+// hostLimiter paces the requests of a bulk or workflow run per host. It
+// holds each host to its requests-per-second and in-flight caps and slows a
+// host down when it answers 429.
+type hostLimiter struct {
+	limits map[string]hostLimit
+	mu     sync.Mutex
+	hosts  map[string]*hostPace
+}
+
+// hostPace is the pacing state of one host. rate is the current requests per
+// second, below ceiling after a 429, and 0 when the host is not paced.
+type hostPace struct {
+	slots       chan struct{}
+	ceiling     float64
+	rate        float64
+	next        time.Time
+	pausedUntil time.Time
+	throttled   int
+	slowest     float64
+}
+
+// newHostLimiter returns a limiter for the --host-limit values specs.
+func newHostLimiter(specs []string) (*hostLimiter, error) {
+	limits, err := parseHostLimits(specs)
+	if err != nil {
+		return nil, err
+	}
+	return &hostLimiter{limits: limits, hosts: map[string]*hostPace{}}, nil
+}
+
+// acquire waits until a request may be sent to host and returns the function
+// to call with its response, or nil when it got none.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(*client.Response), error) {
+	if l == nil {
+		return func(*client.Response) {}, nil
+	}
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	p := l.pace(host)
+	l.mu.Unlock()
+
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if p.slots != nil {
+			<-p.slots
+		}
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if p.next.After(start) {
+		start = p.next
+	}
+	if p.pausedUntil.After(start) {
+		start = p.pausedUntil
+	}
+	if p.rate > 0 {
+		p.next = start.Add(time.Duration(float64(time.Second) / p.rate))
+	}
+	l.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return func(resp *client.Response) {
+		l.done(p, resp)
+		release()
+	}, nil
+}
+
+// pace returns host's state, creating it from its limit on first use. The
+// caller holds l.mu.
+func (l *hostLimiter) pace(host string) *hostPace {
+	if p, ok := l.hosts[host]; ok {
+		return p
+	}
+	limit, ok := l.limits[host]
+	if !ok {
+		limit = l.limits[anyHost]
+	}
+	p := &hostPace{ceiling: limit.rps, rate: limit.rps}
+	if limit.inFlight > 0 {
+		p.slots = make(chan struct{}, limit.inFlight)
+	}
+	l.hosts[host] = p
+	return p
+}
+
+// done adapts a host's rate to a response: a 429 halves it and honors
+// Retry-After, and any other response speeds it back up toward its cap.
+func (l *hostLimiter) done(p *hostPace, resp *client.Response) {
+	if resp == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		p.throttled++
+		rate := p.rate
+		if rate == 0 {
+			rate = adaptiveStartRPS
+		}
+		p.rate = max(rate/2, minAdaptiveRPS)
+		if p.slowest == 0 || p.rate < p.slowest {
+			p.slowest = p.rate
+		}
+		if delay := retryAfterDelay(resp.Headers); delay > 0 {
+			if until := time.Now().Add(min(delay, maxRetryAfterPause)); until.After(p.pausedUntil) {
+				p.pausedUntil = until
+			}
+		}
+		return
+	}
+	if p.rate == 0 || p.rate == p.ceiling {
+		return
+	}
+	if p.ceiling == 0 {
+		p.rate += adaptiveStartRPS / 10
+		if p.rate >= adaptiveStartRPS {
+			p.rate = 0
+		}
+		return
+	}
+	p.rate = min(p.rate+p.ceiling/10, p.ceiling)
+}
+
+// retryAfterDelay returns the delay a Retry-After header asks for, in
+// seconds or as an HTTP date, or 0 when there is none.
+func retryAfterDelay(headers http.Header) time.Duration {
+	value := strings.TrimSpace(headers.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// writeSummary prints one line per host that answered 429 during the run.
+func (l *hostLimiter) writeSummary(w io.Writer) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hosts := make([]string, 0, len(l.hosts))
+	for host, p := range l.hosts {
+		if p.throttled > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		p := l.hosts[host]
+		fmt.Fprintf(w, "Rate limit: %s returned 429 %d time(s); slowed to as low as %s request(s)/s\n",
+			host, p.throttled, strconv.FormatFloat(p.slowest, 'f', -1, 64))
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostLimits(t *testing.T) {
+	limits, err := parseHostLimits(nil)
+	require.NoError(t, err)
+	assert.Equal(t, hostLimit{rps: armDefaultRPS, inFlight: armDefaultInFlight}, limits["management.azure.com"])
+	assert.NotContains(t, limits, anyHost)
+
+	limits, err = parseHostLimits([]string{"Management.Azure.com=0", "graph.microsoft.com=2.5:3", "*=0:4"})
+	require.NoError(t, err)
+	assert.Equal(t, hostLimit{}, limits["management.azure.com"])
+	assert.Equal(t, hostLimit{rps: 2.5, inFlight: 3}, limits["graph.microsoft.com"])
+	assert.Equal(t, hostLimit{inFlight: 4}, limits[anyHost])
+
+	for _, spec := range []string{"example.com", "=5", "example.com=fast", "example.com=-1", "example.com=5:x", "example.com=5:-2", "https://example.com=5"} {
+		_, err := parseHostLimits([]string{spec})
+		assert.ErrorContains(t, err, "invalid --host-limit", spec)
+	}
+}
+
+func TestHostLimiter_PacesRequests(t *testing.T) {
+	l, err := newHostLimiter([]string{"a=50"})
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		done, err := l.acquire(context.Background(), "A")
+		require.NoError(t, err)
+		done(&client.Response{StatusCode: http.StatusOK})
+	}
+	// Four requests at 50 per second are spaced 20ms apart.
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		done, err := l.acquire(context.Background(), "b")
+		require.NoError(t, err)
+		done(nil)
+	}
+	assert.Less(t, time.Since(start), 20*time.Millisecond, "hosts without a limit are not paced")
+}
+
+func TestHostLimiter_CapsInFlight(t *testing.T) {
+	l, err := newHostLimiter([]string{"*=0:2"})
+	require.NoError(t, err)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := l.acquire(context.Background(), "a")
+			if !assert.NoError(t, err) {
+				return
+			}
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+			done(&client.Response{StatusCode: http.StatusOK})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+
+	// A request waiting for a slot gives up when its context ends.
+	hold, err := l.acquire(context.Background(), "b")
+	require.NoError(t, err)
+	hold2, err := l.acquire(context.Background(), "b")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "b")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	hold(nil)
+	hold2(nil)
+}
+
+func TestHostLimiter_SlowsDownOn429(t *testing.T) {
+	l, err := newHostLimiter([]string{"a=8"})
+	require.NoError(t, err)
+	throttled := &client.Response{StatusCode: http.StatusTooManyRequests, Headers: http.Header{}}
+	ok := &client.Response{StatusCode: http.StatusOK}
+
+	done, err := l.acquire(context.Background(), "a")
+	require.NoError(t, err)
+	done(throttled)
+	assert.Equal(t, 4.0, l.hosts["a"].rate)
+	done(throttled)
+	done(throttled)
+	done(throttled)
+	assert.Equal(t, 0.5, l.hosts["a"].rate)
+	done(throttled)
+	assert.Equal(t, minAdaptiveRPS, l.hosts["a"].rate, "the rate has a floor")
+	done(ok)
+	assert.Equal(t, 1.3, l.hosts["a"].rate)
+	for i := 0; i < 20; i++ {
+		done(ok)
+	}
+	assert.Equal(t, 8.0, l.hosts["a"].rate, "the rate recovers up to its cap")
+
+	// A host without a rate cap is slowed from adaptiveStartRPS and
+	// returns to unpaced once it recovers.
+	done, err = l.acquire(context.Background(), "b")
+	require.NoError(t, err)
+	done(throttled)
+	assert.Equal(t, adaptiveStartRPS/2.0, l.hosts["b"].rate)
+	for i := 0; i < 5; i++ {
+		done(ok)
+	}
+	assert.Zero(t, l.hosts["b"].rate)
+
+	var summary bytes.Buffer
+	l.writeSummary(&summary)
+	assert.Equal(t, "Rate limit: a returned 429 5 time(s); slowed to as low as 0.5 request(s)/s\n"+
+		"Rate limit: b returned 429 1 time(s); slowed to as low as 5 request(s)/s\n", summary.String())
+}
+
+func TestHostLimiter_HonorsRetryAfter(t *testing.T) {
+	l, err := newHostLimiter(nil)
+	require.NoError(t, err)
+	done, err := l.acquire(context.Background(), "a")
+	require.NoError(t, err)
+	done(&client.Response{StatusCode: http.StatusTooManyRequests, Headers: http.Header{"Retry-After": {"1"}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "requests wait out Retry-After")
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	assert.Zero(t, retryAfterDelay(http.Header{}))
+	assert.Zero(t, retryAfterDelay(http.Header{"Retry-After": {"soon"}}))
+	assert.Equal(t, 30*time.Second, retryAfterDelay(http.Header{"Retry-After": {"30"}}))
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	d := retryAfterDelay(http.Header{"Retry-After": {at}})
+	assert.InDelta(t, float64(time.Minute), float64(d), float64(2*time.Second))
+}
+
+func TestExecuteBulk_HostLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	var lines []string
+	for i := 0; i < 6; i++ {
+		lines = append(lines, fmt.Sprintf(`{"url": "%s"}`, srv.URL))
+	}
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.HostLimits = []string{"127.0.0.1=0:1"}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(strings.Join(lines, "\n")), &out, BulkOptions{Concurrency: 4}))
+	assert.Len(t, decodeBulkResults(t, out.String()), 6)
+	assert.Equal(t, int32(1), peak.Load())
+
+	cfg.HostLimits = []string{"127.0.0.1"}
+	err := newTestService().ExecuteBulk(context.Background(), cfg, strings.NewReader(""), &out, BulkOptions{Concurrency: 1})
+	assert.ErrorContains(t, err, "invalid --host-limit")
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// imdsEndpoint is the Azure Instance Metadata Service. It is a cloud
+	// metadata address, which redirects, the MCP server, and
+	// --block-private-networks refuse; the identity probe is exempt.
+	imdsEndpoint = "http://169.254.169.254"
+	// imdsTokenPath and imdsAPIVersion address the IMDS managed identity
+	// token endpoint.
+	imdsTokenPath  = "/metadata/identity/oauth2/token"
+	imdsAPIVersion = "2018-02-01"
+	// appServiceIdentityAPIVersion is the api-version of the App Service,
+	// Functions, and Container Apps identity endpoint.
+	appServiceIdentityAPIVersion = "2019-08-01"
+	// identityProbeDialTimeout bounds the connection to the identity
+	// endpoint, so a machine outside Azure fails fast.
+	identityProbeDialTimeout = 2 * time.Second
+	// identityProbeTimeout bounds each probe when --timeout is not set.
+	identityProbeTimeout = 10 * time.Second
+	// defaultIdentityResource is the resource tokens are requested for.
+	defaultIdentityResource = "https://management.azure.com/"
+)
+
+// Identity sources reported by the probe.
+const (
+	identitySourceIMDS       = "imds"
+	identitySourceAppService = "app-service"
+	identitySourceArc        = "azure-arc"
+)
+
+// IdentityProbeOptions controls an azd rest identity probe run.
+type IdentityProbeOptions struct {
+	// Resource is the resource or scope tokens are requested for. It
+	// defaults to Azure Resource Manager.
+	Resource string
+	// ClientIDs lists user-assigned identities to probe in addition to the
+	// default identity and AZURE_CLIENT_ID.
+	ClientIDs []string
+	// Timeout bounds each probe; zero uses a 10s default.
+	Timeout time.Duration
+	// JSON writes the results as a JSON array.
+	JSON bool
+}
+
+// identityProbe is the outcome of one token request. The token itself is
+// never reported.
+type identityProbe struct {
+	Source     string `json:"source"`
+	Endpoint   string `json:"endpoint"`
+	ClientID   string `json:"requestedClientId,omitempty"`
+	Available  bool   `json:"available"`
+	TenantID   string `json:"tenantId,omitempty"`
+	AppID      string `json:"clientId,omitempty"`
+	ObjectID   string `json:"objectId,omitempty"`
+	ResourceID string `json:"resourceId,omitempty"`
+	ExpiresOn  string `json:"expiresOn,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// unreachable is set when the endpoint could not be reached at all.
+	unreachable bool
+}
+
+// identityTokenResponse holds the fields of an identity endpoint reply,
+// including the error formats of IMDS and App Service.
+type identityTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Message          string `json:"message"`
+}
+
+// identityProber sends the probe requests. The endpoints and environment
+// are fields so tests can point them at a local server.
+type identityProber struct {
+	imds       string
+	getenv     func(string) string
+	httpClient *http.Client
+}
+
+// ProbeIdentities requests a token from the local managed identity endpoint
+// for the default identity, AZURE_CLIENT_ID, and each of opts.ClientIDs, and
+// reports which succeed. The endpoint is the App Service identity endpoint
+// when IDENTITY_ENDPOINT and IDENTITY_HEADER are set, and IMDS otherwise.
+//
+// IMDS is a cloud metadata address that --block-private-networks, the MCP
+// server, and the redirect policy all refuse. This command is exempt because
+// it only ever calls the fixed identity endpoints, with redirects and proxies
+// disabled. It returns an error when no identity is available.
+func ProbeIdentities(ctx context.Context, opts IdentityProbeOptions, out io.Writer) error {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = identityProbeTimeout
+	}
+	p := identityProber{
+		imds:   imdsEndpoint,
+		getenv: os.Getenv,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:       nil,
+				DialContext: (&net.Dialer{Timeout: identityProbeDialTimeout}).DialContext,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+	return p.run(ctx, opts, out)
+}
+
+func (p identityProber) run(ctx context.Context, opts IdentityProbeOptions, out io.Writer) error {
+	resource := opts.Resource
+	if resource == "" {
+		resource = defaultIdentityResource
+	}
+	resource = strings.TrimSuffix(resource, "/.default")
+
+	clientIDs := []string{""}
+	for _, id := range append([]string{p.getenv("AZURE_CLIENT_ID")}, opts.ClientIDs...) {
+		id = strings.TrimSpace(id)
+		if id != "" && !containsFold(clientIDs, id) {
+			clientIDs = append(clientIDs, id)
+		}
+	}
+
+	var probes []identityProbe
+	switch endpoint, header := p.getenv("IDENTITY_ENDPOINT"), p.getenv("IDENTITY_HEADER"); {
+	case endpoint != "" && header != "":
+		for _, id := range clientIDs {
+			probes = append(probes, p.probeAppService(ctx, endpoint, header, resource, id))
+		}
+	case endpoint != "" && p.getenv("IMDS_ENDPOINT") != "":
+		probes = append(probes, identityProbe{
+			Source:   identitySourceArc,
+			Endpoint: endpoint,
+			Error:    "the Azure Arc agent is installed; its challenge-based token flow is not probed",
+		})
+	default:
+		for _, id := range clientIDs {
+			probe := p.probeIMDS(ctx, resource, id)
+			probes = append(probes, probe)
+			// Without a reachable IMDS every other probe fails the same way.
+			if probe.unreachable {
+				break
+			}
+		}
+	}
+
+	if err := writeIdentityProbes(out, probes, opts.JSON); err != nil {
+		return err
+	}
+	for _, probe := range probes {
+		if probe.Available {
+			return nil
+		}
+	}
+	return fmt.Errorf("no managed identity is available")
+}
+
+// probeIMDS requests a token from IMDS.
+func (p identityProber) probeIMDS(ctx context.Context, resource, clientID string) identityProbe {
+	q := url.Values{"api-version": {imdsAPIVersion}, "resource": {resource}}
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	probe := identityProbe{Source: identitySourceIMDS, Endpoint: p.imds + imdsTokenPath, ClientID: clientID}
+	p.requestToken(ctx, &probe, probe.Endpoint+"?"+q.Encode(), http.Header{"Metadata": {"true"}})
+	return probe
+}
+
+// probeAppService requests a token from the App Service identity endpoint.
+// The endpoint must be a loopback address, so IDENTITY_HEADER is never sent
+// off the machine.
+func (p identityProber) probeAppService(ctx context.Context, endpoint, header, resource, clientID string) identityProbe {
+	probe := identityProbe{Source: identitySourceAppService, Endpoint: endpoint, ClientID: clientID}
+	u, err := url.Parse(endpoint)
+	if err != nil || !isLoopbackHost(u.Hostname()) {
+		probe.Error = "IDENTITY_ENDPOINT is not a loopback address; not sending IDENTITY_HEADER to it"
+		return probe
+	}
+	q := u.Query()
+	q.Set("api-version", appServiceIdentityAPIVersion)
+	q.Set("resource", resource)
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	u.RawQuery = q.Encode()
+	p.requestToken(ctx, &probe, u.String(), http.Header{"X-Identity-Header": {header}})
+	return probe
+}
+
+// requestToken sends one token request and fills in the probe from the
+// token's claims or the endpoint's error.
+func (p identityProber) requestToken(ctx context.Context, probe *identityProbe, rawURL string, headers http.Header) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		probe.Error = err.Error()
+		return
+	}
+	req.Header = headers
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || isDialError(err) {
+			probe.unreachable = true
+			probe.Error = "not reachable (this machine may not be Azure compute with a managed identity)"
+			return
+		}
+		probe.Error = err.Error()
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, oauth2MaxTokenResponse))
+	if err != nil {
+		probe.Error = fmt.Sprintf("failed to read response: %v", err)
+		return
+	}
+	var tr identityTokenResponse
+	_ = json.Unmarshal(raw, &tr)
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		detail := firstNonEmptyString(tr.ErrorDescription, tr.Message, tr.Error)
+		if detail == "" {
+			detail = "no access_token in response"
+		}
+		probe.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(detail))
+		return
+	}
+
+	probe.Available = true
+	if claims, ok := decodeTokenClaims(tr.AccessToken); ok {
+		probe.TenantID = claims.TenantID
+		probe.AppID = firstNonEmptyString(claims.AppID, claims.AZP)
+		probe.ObjectID = claims.ObjectID
+		probe.ResourceID = claims.ManagedIdentityID
+		if exp, err := claims.Expires.Int64(); err == nil && exp > 0 {
+			probe.ExpiresOn = time.Unix(exp, 0).UTC().Format(time.RFC3339)
+		}
+	}
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isDialError reports whether err happened while connecting, such as a
+// refused connection or an unreachable network.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIdentityProbes prints one block per probe, or the probes as JSON.
+func writeIdentityProbes(out io.Writer, probes []identityProbe, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(probes)
+	}
+	for _, probe := range probes {
+		identity := "default identity"
+		if probe.ClientID != "" {
+			identity = "client ID " + probe.ClientID
+		}
+		if !probe.Available {
+			fmt.Fprintf(out, "%s (%s): unavailable: %s\n", probe.Source, identity, probe.Error)
+			continue
+		}
+		fmt.Fprintf(out, "%s (%s): available\n", probe.Source, identity)
+		for _, field := range [][2]string{
+			{"client ID", probe.AppID},
+			{"object ID", probe.ObjectID},
+			{"tenant", probe.TenantID},
+			{"resource ID", probe.ResourceID},
+			{"expires", probe.ExpiresOn},
+		} {
+			if field[1] != "" {
+				fmt.Fprintf(out, "  %-12s %s\n", field[0]+":", field[1])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testProber(srvURL string, env map[string]string) identityProber {
+	return identityProber{
+		imds:       srvURL,
+		getenv:     func(k string) string { return env[k] },
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestProbeIdentities_IMDS(t *testing.T) {
+	token := fakeJWT(t, map[string]any{
+		"tid": "tenant-1", "appid": "system-app", "oid": "obj-1",
+		"xms_mirid": "/subscriptions/s/resourcegroups/rg/providers/Microsoft.Compute/virtualMachines/vm",
+		"exp":       1893456000,
+	})
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("client_id"))
+		if r.Header.Get("Metadata") != "true" || r.URL.Path != imdsTokenPath {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("client_id") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_request","error_description":"Identity not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"` + token + `","resource":"https://management.azure.com/"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	p := testProber(srv.URL, map[string]string{"AZURE_CLIENT_ID": "env-id"})
+	require.NoError(t, p.run(t.Context(), IdentityProbeOptions{ClientIDs: []string{"ENV-ID", "user-id"}}, &out))
+	assert.Equal(t, []string{"", "env-id", "user-id"}, requests, "duplicate client IDs are probed once")
+	assert.Equal(t, `imds (default identity): available
+  client ID:   system-app
+  object ID:   obj-1
+  tenant:      tenant-1
+  resource ID: /subscriptions/s/resourcegroups/rg/providers/Microsoft.Compute/virtualMachines/vm
+  expires:     2030-01-01T00:00:00Z
+imds (client ID env-id): unavailable: HTTP 400: Identity not found
+imds (client ID user-id): unavailable: HTTP 400: Identity not found
+`, out.String())
+	assert.NotContains(t, out.String(), token)
+
+	out.Reset()
+	require.NoError(t, p.run(t.Context(), IdentityProbeOptions{JSON: true}, &out))
+	var probes []identityProbe
+	require.NoError(t, json.Unmarshal(out.Bytes(), &probes))
+	require.Len(t, probes, 2)
+	assert.True(t, probes[0].Available)
+	assert.Equal(t, "obj-1", probes[0].ObjectID)
+}
+
+func TestProbeIdentities_AppService(t *testing.T) {
+	token := fakeJWT(t, map[string]any{"tid": "tenant-1", "appid": "app-1"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Identity-Header") != "secret" || r.URL.Query().Get("api-version") != appServiceIdentityAPIVersion {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "https://vault.azure.net", r.URL.Query().Get("resource"))
+		_, _ = w.Write([]byte(`{"access_token":"` + token + `"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	p := testProber("http://imds.invalid", map[string]string{"IDENTITY_ENDPOINT": srv.URL + "/msi/token", "IDENTITY_HEADER": "secret"})
+	require.NoError(t, p.run(t.Context(), IdentityProbeOptions{Resource: "https://vault.azure.net/.default"}, &out))
+	assert.Contains(t, out.String(), "app-service (default identity): available\n  client ID:   app-1\n")
+
+	out.Reset()
+	p = testProber("http://imds.invalid", map[string]string{"IDENTITY_ENDPOINT": "http://example.com/msi/token", "IDENTITY_HEADER": "secret"})
+	err := p.run(t.Context(), IdentityProbeOptions{}, &out)
+	assert.EqualError(t, err, "no managed identity is available")
+	assert.Contains(t, out.String(), "IDENTITY_ENDPOINT is not a loopback address")
+}
+
+func TestProbeIdentities_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	var out bytes.Buffer
+	p := testProber(srv.URL, map[string]string{"AZURE_CLIENT_ID": "env-id"})
+	err := p.run(t.Context(), IdentityProbeOptions{}, &out)
+	assert.EqualError(t, err, "no managed identity is available")
+	assert.Equal(t, "imds (default identity): unavailable: not reachable (this machine may not be Azure compute with a managed identity)\n", out.String(),
+		"other client IDs are not probed once IMDS is unreachable")
+}
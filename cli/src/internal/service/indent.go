@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// streamIndentThreshold is the body size above which JSON is pretty-printed
+// token by token instead of through azd-core's formatter, which decodes the
+// whole document into a value tree and re-encodes it.
+const streamIndentThreshold = 8 << 20 // 8 MiB
+
+// jsonIndent matches the indentation of the formatter.
+const jsonIndent = "  "
+
+// indentFrame tracks one open object or array while streaming.
+type indentFrame struct {
+	object   bool
+	members  int
+	afterKey bool
+}
+
+// indentJSONStream pretty-prints the JSON document read from r to w with the
+// same layout as json.MarshalIndent, holding only the current token in
+// memory. Unlike the formatter, object keys keep their order in the
+// response and numbers are written exactly as received.
+func indentJSONStream(w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	bw := bufio.NewWriterSize(w, 64<<10)
+	var stack []indentFrame
+
+	newline := func(depth int) {
+		bw.WriteByte('\n')
+		bw.WriteString(strings.Repeat(jsonIndent, depth))
+	}
+	writeString := func(s string) error {
+		quoted, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(quoted)
+		return err
+	}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			if len(stack) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if frame.members > 0 {
+				newline(len(stack))
+			}
+			bw.WriteByte(byte(d))
+			continue
+		}
+
+		// Place the token: a value after its key stays on the key's line,
+		// anything else starts a new line inside its container.
+		if len(stack) > 0 {
+			frame := &stack[len(stack)-1]
+			if frame.afterKey {
+				frame.afterKey = false
+			} else {
+				if frame.members > 0 {
+					bw.WriteByte(',')
+				}
+				newline(len(stack))
+				frame.members++
+				if frame.object {
+					if err := writeString(tok.(string)); err != nil {
+						return err
+					}
+					bw.WriteString(": ")
+					frame.afterKey = true
+					continue
+				}
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			bw.WriteByte(byte(v))
+			stack = append(stack, indentFrame{object: v == '{'})
+		case string:
+			if err := writeString(v); err != nil {
+				return err
+			}
+		case json.Number:
+			bw.WriteString(v.String())
+		case bool:
+			fmt.Fprint(bw, v)
+		case nil:
+			bw.WriteString("null")
+		}
+	}
+	return bw.Flush()
+}
+
+// writeStreamedJSON pretty-prints a large JSON response straight to stdout or
+// --output-file. It reports false, writing nothing, when the response should
+// take the formatter path instead: small, not JSON, or colorized.
+func writeStreamedJSON(cfg config.Config, resp *client.Response, headerBlock string) (bool, error) {
+	if len(resp.Body) <= streamIndentThreshold || shouldColorize(cfg, resp) {
+		return false, nil
+	}
+	switch cfg.OutputFormat {
+	case "", string(client.FormatAuto):
+		if !strings.Contains(resp.Headers.Get("Content-Type"), "application/json") {
+			return false, nil
+		}
+	case string(client.FormatJSON):
+	default:
+		return false, nil
+	}
+	// The formatter prints invalid JSON unchanged; check first so a bad
+	// document falls back to it rather than failing halfway through.
+	if !json.Valid(resp.Body) {
+		return false, nil
+	}
+
+	// With an empty body the formatter renders only the verbose preamble.
+	preamble := *verboseSafeResponse(cfg, resp)
+	preamble.Body = nil
+	prefix, err := client.NewFormatter(cfg.Verbose, cfg.OutputFormat).Format(&preamble)
+	if err != nil {
+		return true, fmt.Errorf("failed to format response: %w", err)
+	}
+
+	if cfg.OutputFile == "" {
+		return true, writeIndented(os.Stdout, headerBlock+prefix, resp.Body)
+	}
+	f, err := createOutputFile(cfg)
+	if err != nil {
+		return true, err
+	}
+	if err := writeIndented(f, headerBlock+prefix, resp.Body); err != nil {
+		f.abort()
+		return true, err
+	}
+	return true, f.commit()
+}
+
+// writeIndented writes prefix followed by body pretty-printed.
+func writeIndented(w io.Writer, prefix string, body []byte) error {
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return err
+	}
+	if err := indentJSONStream(w, bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("failed to format response: %w", err)
+	}
+	return nil
+}
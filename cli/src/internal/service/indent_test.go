@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndentJSONStream_MatchesMarshalIndent(t *testing.T) {
+	// Keys are sorted in the inputs so the formatter's map-based output,
+	// which sorts keys, is directly comparable.
+	docs := []string{
+		`{"a":1,"b":[true,false,null],"c":{"d":"x"}}`,
+		`[]`,
+		`{}`,
+		`{"empty":{},"list":[],"nested":[[],[{}]]}`,
+		`"just a string"`,
+		`42`,
+		`[{"esc":"tab\tnl\n","html":"<a href=\"x\">&</a>","unicode":"café ☕"}]`,
+		`{"n":-1.5e3,"z":0}`,
+	}
+	for _, doc := range docs {
+		var parsed any
+		require.NoError(t, json.Unmarshal([]byte(doc), &parsed), doc)
+		want, err := json.MarshalIndent(parsed, "", "  ")
+		require.NoError(t, err)
+
+		var got bytes.Buffer
+		require.NoError(t, indentJSONStream(&got, strings.NewReader(doc)), doc)
+		if doc == `{"n":-1.5e3,"z":0}` {
+			// Numbers are written as received rather than re-encoded.
+			assert.Equal(t, "{\n  \"n\": -1.5e3,\n  \"z\": 0\n}", got.String())
+			continue
+		}
+		assert.Equal(t, string(want), got.String(), doc)
+	}
+}
+
+func TestIndentJSONStream_KeepsKeyOrderAndPrecision(t *testing.T) {
+	var got bytes.Buffer
+	require.NoError(t, indentJSONStream(&got, strings.NewReader(`{"z":12345678901234567890,"a":1}`)))
+	assert.Equal(t, "{\n  \"z\": 12345678901234567890,\n  \"a\": 1\n}", got.String())
+}
+
+func TestIndentJSONStream_InvalidJSON(t *testing.T) {
+	var got bytes.Buffer
+	assert.Error(t, indentJSONStream(&got, strings.NewReader(`{"a":`)))
+}
+
+// largeJSONArray returns a JSON array larger than streamIndentThreshold.
+func largeJSONArray() []byte {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i := 0; b.Len() <= streamIndentThreshold; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"item-%d"}`, i, i)
+	}
+	b.WriteByte(']')
+	return b.Bytes()
+}
+
+func TestExecute_LargeJSONIsStreamed(t *testing.T) {
+	body := largeJSONArray()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	var parsed any
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	want, err := json.MarshalIndent(parsed, "", "  ")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestExecute_LargeInvalidJSONIsWrittenUnchanged(t *testing.T) {
+	body := append(largeJSONArray(), "garbage"...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, body, got)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// insecureAzureError signals that --insecure would send an Azure bearer token
+// over unverified TLS without --yes. It reports exit code 2 through the
+// ExitCoder contract, like other invalid flag combinations.
+type insecureAzureError struct{ host string }
+
+func (e *insecureAzureError) Error() string {
+	return fmt.Sprintf("refusing to send an Azure token to %s with --insecure: TLS certificate verification is disabled, so anyone who can intercept the connection can steal the token. Pass --yes (or set AZD_REST_YES=true) to continue anyway", e.host)
+}
+
+// ExitCode returns 2 for the unconfirmed --insecure combination.
+func (e *insecureAzureError) ExitCode() int { return 2 }
+
+// checkInsecureAzure guards --insecure on requests that would carry an Azure
+// bearer token. Without --yes the request is refused; with it, a warning names
+// the host. Requests without a token, or to hosts DetectScope does not know,
+// keep the general --insecure warning only.
+func checkInsecureAzure(cfg config.Config, requestURL string, opts client.RequestOptions) error {
+	if !cfg.Insecure || opts.SkipAuth {
+		return nil
+	}
+	scope, err := client.DetectScope(requestURL)
+	if err != nil || scope == "" {
+		return nil
+	}
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return nil
+	}
+	host := parsed.Hostname()
+	if !cfg.Yes {
+		return &insecureAzureError{host: host}
+	}
+	writeDiagnostic(os.Stderr, cfg.Silent, "WARNING: sending an Azure token to %s without TLS certificate verification (--insecure --yes).\n", host)
+	return nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRequestOptions_InsecureAzureRequiresYes(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+
+	_, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/subscriptions")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "management.azure.com")
+	assert.Contains(t, err.Error(), "--yes")
+	var ec interface{ ExitCode() int }
+	require.True(t, errors.As(err, &ec))
+	assert.Equal(t, 2, ec.ExitCode())
+}
+
+func TestBuildRequestOptions_InsecureAzureWithYes(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Yes = true
+	cfg.Silent = true
+
+	_, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/subscriptions")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.NoError(t, err)
+}
+
+func TestBuildRequestOptions_InsecureWithoutAzureToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		noAuth bool
+	}{
+		{"no auth", "https://management.azure.com/subscriptions", true},
+		{"non-azure host", "https://self-signed.example.com/api", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestService()
+			cfg := baseTestConfig(t)
+			cfg.NoAuth = tc.noAuth
+			cfg.Insecure = true
+
+			_, cleanup, err := svc.BuildRequestOptions(cfg, "GET", tc.url)
+			if cleanup != nil {
+				cleanup()
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// interruptExitCode is the exit code of a run stopped by Ctrl-C, 128 plus
+// SIGINT, as shells report it.
+const interruptExitCode = 130
+
+// interruptedError reports a run stopped by Ctrl-C. It reports exit code 130
+// through the ExitCoder contract.
+type interruptedError struct{}
+
+func (e *interruptedError) Error() string { return "interrupted" }
+
+// ExitCode returns 130, the conventional code for an interrupted process.
+func (e *interruptedError) ExitCode() int { return interruptExitCode }
+
+// stopError returns why ctx ended a run early: the --max-time budget or an
+// interrupt. It returns nil while ctx is live.
+func stopError(ctx context.Context, cfg config.Config) error {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) && cfg.MaxTime > 0:
+		return fmt.Errorf("overall time budget of %s exceeded (--max-time)", cfg.MaxTime)
+	case errors.Is(err, context.Canceled):
+		return &interruptedError{}
+	default:
+		return err
+	}
+}
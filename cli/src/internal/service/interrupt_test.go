@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopError(t *testing.T) {
+	cfg := config.Config{MaxTime: time.Second}
+	assert.NoError(t, stopError(context.Background(), cfg))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := stopError(ctx, cfg)
+	var interrupted *interruptedError
+	require.ErrorAs(t, err, &interrupted)
+	assert.Equal(t, "interrupted", err.Error())
+	assert.Equal(t, interruptExitCode, interrupted.ExitCode())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	assert.EqualError(t, stopError(ctx, cfg), "overall time budget of 1s exceeded (--max-time)")
+	assert.ErrorIs(t, stopError(ctx, config.Config{}), context.DeadlineExceeded)
+}
+
+func TestExecuteBulk_InterruptStopsReadingInput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 2 {
+			cancel()
+		}
+	}))
+	defer srv.Close()
+
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"id": %d, "url": "%s"}`, i, srv.URL))
+	}
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	var out bytes.Buffer
+	err := newTestService().ExecuteBulk(ctx, cfg, strings.NewReader(strings.Join(lines, "\n")), &out, BulkOptions{Concurrency: 1})
+
+	var interrupted *interruptedError
+	require.ErrorAs(t, err, &interrupted)
+	assert.Contains(t, err.Error(), "were not run")
+	results := decodeBulkResults(t, out.String())
+	assert.Less(t, len(results), 10)
+	assert.Equal(t, int32(2), hits.Load())
+	for i, res := range results {
+		assert.Equal(t, i+1, res.Line, "every line before the first not run has a result")
+	}
+}
+
+func TestExecuteWorkflow_InterruptStopsBeforeStep(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "first", SavedRequest: config.SavedRequest{URL: server.URL + "/a"}},
+		{Name: "second", SavedRequest: config.SavedRequest{URL: server.URL + "/b"}},
+	}}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out bytes.Buffer
+	err := newTestService().ExecuteWorkflow(ctx, baseTestConfig(t), project, "wf", &out, WorkflowOptions{})
+
+	assert.EqualError(t, err, "workflow wf: interrupted before step first")
+	assert.True(t, errors.As(err, new(*interruptedError)))
+	assert.Zero(t, calls.Load())
+	assert.Empty(t, out.String())
+}
+
+func TestExecuteRepeat_InterruptWritesSummaryAndLastResponse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 2 {
+			cancel()
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Repeat = 5
+	err := newTestService().Execute(ctx, cfg, http.MethodGet, server.URL)
+
+	assert.EqualError(t, err, "interrupted after 2 of 5 repeated requests")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWriteRepeatSummary_NotRun(t *testing.T) {
+	var buf bytes.Buffer
+	writeRepeatSummary(&buf, repeatStats{total: 5, success: 2, notRun: 3, statusCounts: map[int]int{}})
+	assert.Contains(t, buf.String(), "Not run: 3 (stopped early)")
+}
@@ -101,8 +101,8 @@ func TestBuildRequestOptions_JSONFieldsBody(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers[contentTypeHeader] != applicationJSON {
-		t.Errorf("Content-Type = %q, want %q", opts.Headers[contentTypeHeader], applicationJSON)
+	if opts.Headers.Get(contentTypeHeader) != applicationJSON {
+		t.Errorf("Content-Type = %q, want %q", opts.Headers.Get(contentTypeHeader), applicationJSON)
 	}
 	body, err := io.ReadAll(opts.Body)
 	if err != nil {
@@ -128,8 +128,8 @@ func TestBuildRequestOptions_JSONFieldsKeepsUserContentType(t *testing.T) {
 	}
 	defer cleanup()
 
-	if opts.Headers["Content-Type"] != "application/merge-patch+json" {
-		t.Errorf("Content-Type = %q, want application/merge-patch+json", opts.Headers["Content-Type"])
+	if opts.Headers.Get("Content-Type") != "application/merge-patch+json" {
+		t.Errorf("Content-Type = %q, want application/merge-patch+json", opts.Headers.Get("Content-Type"))
 	}
 }
 
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// sortJSONKeys re-encodes a JSON document with the members of every object
+// sorted by key, so two responses diff cleanly whatever order the server
+// wrote them in. Numbers are kept exactly as received.
+func sortJSONKeys(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// dropJSONNulls removes every object member whose value is null, at any
+// depth. Array elements are kept, null or not, so indexes still line up.
+// Member order and numbers are kept exactly as received.
+func dropJSONNulls(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := writeWithoutNulls(&b, dec, tok); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeWithoutNulls writes the value that starts with tok, reading the rest
+// of it from dec and skipping object members that are null.
+func writeWithoutNulls(b *bytes.Buffer, dec *json.Decoder, tok json.Token) error {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		scalar, err := marshalToken(tok)
+		if err != nil {
+			return err
+		}
+		b.Write(scalar)
+		return nil
+	}
+	object := delim == '{'
+	b.WriteRune(rune(delim))
+	first := true
+	for dec.More() {
+		var key []byte
+		if object {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if key, err = marshalToken(keyTok); err != nil {
+				return err
+			}
+		}
+		value, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if object && value == nil {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		if object {
+			b.Write(key)
+			b.WriteByte(':')
+		}
+		if err := writeWithoutNulls(b, dec, value); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if object {
+		b.WriteByte('}')
+	} else {
+		b.WriteByte(']')
+	}
+	return nil
+}
+
+// marshalToken encodes a scalar token or object key without escaping HTML
+// characters, matching the rest of the output.
+func marshalToken(tok json.Token) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(tok); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// rewriteJSONBody applies --drop-nulls and then --sort-keys to a JSON body.
+func rewriteJSONBody(body []byte, dropNulls, sortKeys bool) ([]byte, error) {
+	var err error
+	if dropNulls {
+		if body, err = dropJSONNulls(body); err != nil {
+			return nil, err
+		}
+	}
+	if sortKeys {
+		if body, err = sortJSONKeys(body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
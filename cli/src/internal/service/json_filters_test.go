@@ -0,0 +1,59 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortJSONKeys(t *testing.T) {
+	got, err := sortJSONKeys([]byte(`{"b":{"z":1,"a":"<x>"},"a":[{"d":12345678901234567890,"c":null}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[{"c":null,"d":12345678901234567890}],"b":{"a":"<x>","z":1}}`, string(got))
+
+	_, err = sortJSONKeys([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestDropJSONNulls(t *testing.T) {
+	got, err := dropJSONNulls([]byte(`{"z":null,"b":{"x":null,"y":[1,null,{"n":null}]},"a":1.50,"s":"a&b"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":{"y":[1,null,{}]},"a":1.50,"s":"a&b"}`, string(got), "member order and numbers are kept")
+
+	got, err = dropJSONNulls([]byte(`null`))
+	require.NoError(t, err)
+	assert.Equal(t, `null`, string(got))
+
+	_, err = dropJSONNulls([]byte(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestExecute_SortKeysAndDropNulls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"b":2,"gone":null,"a":{"d":null,"c":1}}`))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Compact = true
+	cfg.SortKeys = true
+	cfg.DropNulls = true
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":{\"c\":1},\"b\":2}\n", string(got))
+
+	cfg = baseTestConfig(t)
+	cfg.DropNulls = true
+	cfg.Flatten = true
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	got, err = os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(got), "gone")
+	assert.Contains(t, string(got), `"a.c": 1`)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// reportFormats are the formats accepted by --report.
+var reportFormats = []string{"junit"}
+
+// reportUsageError reports an invalid --report value. It reports exit code 2
+// through the ExitCoder contract so main can map it to a usage failure.
+type reportUsageError struct{ msg string }
+
+func (e *reportUsageError) Error() string { return e.msg }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *reportUsageError) ExitCode() int { return 2 }
+
+// ParseReportSpec parses a --report value of the form format=path and
+// returns the path. Only junit is supported.
+func ParseReportSpec(spec string) (string, error) {
+	format, path, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(path) == "" {
+		return "", &reportUsageError{msg: fmt.Sprintf("--report must be format=path, e.g. junit=report.xml, got %q", spec)}
+	}
+	if format != "junit" {
+		return "", &reportUsageError{msg: fmt.Sprintf("--report: unsupported format %q (expected one of %s)", format, strings.Join(reportFormats, ", "))}
+	}
+	return path, nil
+}
+
+// junitTestSuites is the root element of a JUnit XML report, in the schema
+// read by Azure DevOps PublishTestResults and common GitHub Actions
+// reporters.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr,omitempty"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the test cases of one run.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr,omitempty"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+
+	elapsed time.Duration
+}
+
+// junitTestCase is one assertion. A case with none of Failure, Error, and
+// Skipped passed.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+// junitSkipped marks a case that did not run.
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// junitProblem describes why a case did not pass. A failure is an assertion
+// that did not hold; an error is a case that could not be evaluated, such as
+// a request that got no response.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// newJUnitSuite returns a suite with its counts filled in from cases.
+func newJUnitSuite(name string, started time.Time, elapsed time.Duration, cases []junitTestCase) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(cases),
+		Time:      junitSeconds(elapsed),
+		Timestamp: started.UTC().Format("2006-01-02T15:04:05"),
+		Cases:     cases,
+		elapsed:   elapsed,
+	}
+	for _, c := range cases {
+		switch {
+		case c.Error != nil:
+			suite.Errors++
+		case c.Failure != nil:
+			suite.Failures++
+		case c.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// writeJUnitReport writes suites to path as JUnit XML.
+func writeJUnitReport(path string, suites ...junitTestSuite) error {
+	doc := junitTestSuites{Suites: suites}
+	var total time.Duration
+	for _, s := range suites {
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Errors += s.Errors
+		doc.Skipped += s.Skipped
+		total += s.elapsed
+	}
+	doc.Time = junitSeconds(total)
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+// junitSeconds formats d as the fractional seconds JUnit expects.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
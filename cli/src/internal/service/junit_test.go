@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReportSpec(t *testing.T) {
+	path, err := ParseReportSpec("junit=out/report.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "out/report.xml", path)
+
+	for _, spec := range []string{"report.xml", "junit=", "html=report.html"} {
+		_, err := ParseReportSpec(spec)
+		require.Error(t, err, spec)
+		var coder interface{ ExitCode() int }
+		require.True(t, errors.As(err, &coder), spec)
+		assert.Equal(t, 2, coder.ExitCode())
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	cases := []junitTestCase{
+		{Name: "passes", ClassName: "azd-rest.test", Time: "0.010"},
+		{Name: "fails", ClassName: "azd-rest.test", Time: "0.020", Failure: &junitProblem{Message: "unexpected status 500", Type: "status"}},
+		{Name: "errors", ClassName: "azd-rest.test", Time: "0.000", Error: &junitProblem{Message: "connection refused <&>", Type: "request"}},
+	}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "report.xml")
+	require.NoError(t, writeJUnitReport(path, newJUnitSuite("suite", started, 1500*time.Millisecond, cases)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, string(data), `message="connection refused &lt;&amp;&gt;"`)
+
+	var doc junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, 3, doc.Tests)
+	assert.Equal(t, 1, doc.Failures)
+	assert.Equal(t, 1, doc.Errors)
+	assert.Equal(t, "1.500", doc.Time)
+	require.Len(t, doc.Suites, 1)
+	assert.Equal(t, "2026-01-02T03:04:05", doc.Suites[0].Timestamp)
+	require.Len(t, doc.Suites[0].Cases, 3)
+	assert.Nil(t, doc.Suites[0].Cases[0].Failure)
+	assert.Equal(t, "status", doc.Suites[0].Cases[1].Failure.Type)
+}
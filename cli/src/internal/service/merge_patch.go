@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// mergePatchContentType is the RFC 7396 JSON Merge Patch media type.
+const mergePatchContentType = "application/merge-patch+json"
+
+// mergePatchError signals invalid --merge/--strategic usage: a patch that is
+// not a JSON object, or a conflicting body flag. It reports exit code 2
+// through the ExitCoder contract so main can map it to a usage failure.
+type mergePatchError struct{ err error }
+
+// Error returns the underlying message.
+func (e *mergePatchError) Error() string { return e.err.Error() }
+
+// Unwrap exposes the wrapped error for errors.Is/As.
+func (e *mergePatchError) Unwrap() error { return e.err }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *mergePatchError) ExitCode() int { return 2 }
+
+// prepareMergePatch turns --merge into the request to send. By default the
+// patch is sent as-is with the merge-patch media type. With --strategic the
+// resource is fetched, the patch is applied locally, and the merged document
+// is returned as a PUT body for APIs that lack PATCH support; the GET's ETag
+// is sent as If-Match so a concurrent change is not silently overwritten.
+func (s *RequestService) prepareMergePatch(ctx context.Context, cfg config.Config, method, url string) (config.Config, string, error) {
+	if cfg.MergePatch == "" {
+		if cfg.Strategic {
+			return cfg, method, &mergePatchError{fmt.Errorf("--strategic requires --merge")}
+		}
+		return cfg, method, nil
+	}
+	if cfg.Data != "" || cfg.DataFile != "" || cfg.DataRaw != "" || cfg.DataBinary != "" || cfg.JSONData != "" || len(cfg.DataURLEncode) > 0 ||
+		len(cfg.FormFields) > 0 || len(cfg.JSONFields) > 0 || len(cfg.JSONFieldsRaw) > 0 {
+		return cfg, method, &mergePatchError{fmt.Errorf("--merge cannot be combined with a request body flag, --form-field, or --json-field")}
+	}
+	var patch map[string]any
+	if err := decodeJSONNumbers([]byte(cfg.MergePatch), &patch); err != nil || patch == nil {
+		return cfg, method, &mergePatchError{fmt.Errorf("--merge must be a JSON object")}
+	}
+
+	if !cfg.Strategic {
+		cfg.Data = cfg.MergePatch
+		// An explicit --header Content-Type still wins.
+		cfg.Headers = WithDefaultHeader(cfg.Headers, contentTypeHeader, mergePatchContentType)
+		return cfg, method, nil
+	}
+
+	getCfg := cfg
+	getCfg.Paginate = false
+	opts, cleanup, err := s.BuildRequestOptions(getCfg, "GET", url)
+	if err != nil {
+		return cfg, method, err
+	}
+	defer cleanup()
+	if err := s.applyAuxiliaryTokens(ctx, cfg.AuxTenants, &opts); err != nil {
+		return cfg, method, err
+	}
+	resp, err := s.httpClientFactory(opts.TokenProvider, cfg.Insecure, cfg.Timeout).Execute(ctx, opts)
+	if err != nil {
+		return cfg, method, fmt.Errorf("--strategic: failed to read the current resource: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return cfg, method, fmt.Errorf("--strategic: GET returned %s; the resource must exist to merge into it", resp.Status)
+	}
+	var current any
+	if err := decodeJSONNumbers(resp.Body, &current); err != nil {
+		return cfg, method, fmt.Errorf("--strategic: the current resource is not JSON: %w", err)
+	}
+
+	merged, err := json.Marshal(applyMergePatch(current, patch))
+	if err != nil {
+		return cfg, method, fmt.Errorf("--strategic: failed to encode the merged resource: %w", err)
+	}
+	cfg.Data = string(merged)
+	cfg.Headers = WithDefaultHeader(cfg.Headers, contentTypeHeader, applicationJSON)
+	if etag := resp.Headers.Get("ETag"); etag != "" && !hasHeaderArg(cfg.Headers, "If-Match") {
+		cfg.Headers = append(cfg.Headers, "If-Match: "+etag)
+	}
+	return cfg, "PUT", nil
+}
+
+// decodeJSONNumbers decodes the single JSON document in data into v, keeping
+// numbers as json.Number so a large integer is written back exactly rather
+// than rounded through float64.
+func decodeJSONNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON document")
+	}
+	return nil
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to target: object members
+// are merged recursively, a null member deletes the key, and any other patch
+// value replaces the target value outright (arrays included).
+func applyMergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	var target, patch any
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"b","c":{"d":"e","f":"g"},"list":[1,2]}`), &target))
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"z","c":{"f":null,"h":1},"list":[3]}`), &patch))
+
+	got, err := json.Marshal(applyMergePatch(target, patch))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":"z","c":{"d":"e","h":1},"list":[3]}`, string(got))
+}
+
+func TestApplyMergePatch_NonObjectTarget(t *testing.T) {
+	got, err := json.Marshal(applyMergePatch("scalar", map[string]any{"a": 1.0}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(got))
+}
+
+func TestExecute_Merge_SendsMergePatch(t *testing.T) {
+	var gotMethod, gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotType, gotBody = r.Method, r.Header.Get("Content-Type"), string(body)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MergePatch = `{"tags":{"env":"dev"}}`
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "PATCH", srv.URL))
+
+	assert.Equal(t, "PATCH", gotMethod)
+	assert.Equal(t, mergePatchContentType, gotType)
+	assert.JSONEq(t, `{"tags":{"env":"dev"}}`, gotBody)
+}
+
+func TestExecute_Strategic_GetsMergesAndPuts(t *testing.T) {
+	var putBody, ifMatch string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"name":"r1","tags":{"owner":"me","old":"x"}}`))
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			putBody, ifMatch = string(body), r.Header.Get("If-Match")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MergePatch = `{"tags":{"env":"dev","old":null}}`
+	cfg.Strategic = true
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "PATCH", srv.URL))
+
+	assert.JSONEq(t, `{"name":"r1","tags":{"owner":"me","env":"dev"}}`, putBody)
+	assert.Equal(t, `"v1"`, ifMatch)
+}
+
+func TestExecute_Strategic_KeepsLargeNumbers(t *testing.T) {
+	var putBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"id":12345678901234567890,"ratio":0.1,"tags":{}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		putBody = string(body)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MergePatch = `{"tags":{"quota":98765432109876543210}}`
+	cfg.Strategic = true
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "PATCH", srv.URL))
+
+	assert.Contains(t, putBody, `"id":12345678901234567890`)
+	assert.Contains(t, putBody, `"quota":98765432109876543210`)
+	assert.Contains(t, putBody, `"ratio":0.1`)
+}
+
+func TestExecute_Strategic_MissingResource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MergePatch = `{"a":1}`
+	cfg.Strategic = true
+	err := newTestService().Execute(context.Background(), cfg, "PATCH", srv.URL)
+	assert.ErrorContains(t, err, "the resource must exist")
+}
+
+func TestExecute_Merge_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		merge string
+		data  string
+		strat bool
+		want  string
+	}{
+		{"not an object", `[1]`, "", false, "must be a JSON object"},
+		{"invalid JSON", `{`, "", false, "must be a JSON object"},
+		{"with --data", `{"a":1}`, `{}`, false, "cannot be combined"},
+		{"strategic alone", "", "", true, "--strategic requires --merge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.MergePatch, cfg.Data, cfg.Strategic = tt.merge, tt.data, tt.strat
+			err := newTestService().Execute(context.Background(), cfg, "PATCH", "https://example.invalid")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			var ec interface{ ExitCode() int }
+			require.True(t, errors.As(err, &ec))
+			assert.Equal(t, 2, ec.ExitCode())
+		})
+	}
+}
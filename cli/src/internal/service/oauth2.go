@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// oauth2ExpirySkew refreshes a cached OAuth2 token this long before it expires.
+const oauth2ExpirySkew = 2 * time.Minute
+
+// oauth2Timeout bounds a single token endpoint call.
+const oauth2Timeout = 30 * time.Second
+
+// oauth2MaxTokenResponse caps the token endpoint response that is read.
+const oauth2MaxTokenResponse = 1 << 20
+
+// oauth2TokenProvider implements TokenProvider with the OAuth2
+// client-credentials grant against a profile's token URL. Tokens are cached in
+// memory until shortly before they expire. The scope passed to GetToken is
+// ignored; the profile's scopes and audience define what is requested.
+type oauth2TokenProvider struct {
+	profileName  string
+	profile      config.OAuth2Profile
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	now          func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newOAuth2TokenProvider resolves a profile's client ID and secret and returns
+// a provider for it. The secret comes from the environment variable named by
+// clientSecretEnv or, failing that, from the OS keychain entry named by
+// clientSecretSecret.
+func newOAuth2TokenProvider(name string, profile config.OAuth2Profile, lookupEnv func(string) (string, bool), getSecret func(string) (string, error)) (*oauth2TokenProvider, error) {
+	if profile.TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 profile %q has no tokenUrl", name)
+	}
+	if parsed, err := url.Parse(profile.TokenURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("oauth2 profile %q: tokenUrl must be an absolute https URL", name)
+	}
+
+	clientID := profile.ClientID
+	if profile.ClientIDEnv != "" {
+		if v, ok := lookupEnv(profile.ClientIDEnv); ok && v != "" {
+			clientID = v
+		}
+	}
+	if clientID == "" {
+		return nil, fmt.Errorf("oauth2 profile %q has no client ID (set clientId or clientIdEnv)", name)
+	}
+
+	var clientSecret string
+	if profile.ClientSecretEnv != "" {
+		if v, ok := lookupEnv(profile.ClientSecretEnv); ok {
+			clientSecret = v
+		}
+	}
+	if clientSecret == "" && profile.ClientSecretSecret != "" {
+		v, err := getSecret(profile.ClientSecretSecret)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2 profile %q: %w", name, err)
+		}
+		clientSecret = v
+	}
+	if clientSecret == "" {
+		return nil, fmt.Errorf("oauth2 profile %q has no client secret (set clientSecretEnv or clientSecretSecret)", name)
+	}
+
+	return &oauth2TokenProvider{
+		profileName:  name,
+		profile:      profile,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: oauth2Timeout},
+		now:          time.Now,
+	}, nil
+}
+
+// oauth2TokenResponse is the subset of RFC 6749 section 5.1 fields used here.
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Error       string `json:"error"`
+	ErrorDesc   string `json:"error_description"`
+}
+
+// GetToken returns a cached token or requests a new one from the token URL.
+func (p *oauth2TokenProvider) GetToken(ctx context.Context, _ string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && p.now().Add(oauth2ExpirySkew).Before(p.expiresAt) {
+		return p.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	if len(p.profile.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.profile.Scopes, " "))
+	}
+	if p.profile.Audience != "" {
+		form.Set("audience", p.profile.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.profile.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("oauth2 profile %q: failed to create token request: %w", p.profileName, err)
+	}
+	req.Header.Set(contentTypeHeader, formURLEncoded)
+	req.Header.Set("Accept", applicationJSON)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 profile %q: token request failed: %w", p.profileName, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, oauth2MaxTokenResponse))
+	if err != nil {
+		return "", fmt.Errorf("oauth2 profile %q: failed to read token response: %w", p.profileName, err)
+	}
+
+	var tr oauth2TokenResponse
+	if err := json.Unmarshal(raw, &tr); err != nil {
+		return "", fmt.Errorf("oauth2 profile %q: token endpoint returned HTTP %d with a non-JSON body", p.profileName, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		detail := tr.Error
+		if tr.ErrorDesc != "" {
+			detail += ": " + tr.ErrorDesc
+		}
+		if detail == "" {
+			detail = "no access_token in response"
+		}
+		return "", fmt.Errorf("oauth2 profile %q: token endpoint returned HTTP %d (%s)", p.profileName, resp.StatusCode, detail)
+	}
+
+	p.token = tr.AccessToken
+	p.expiresAt = time.Time{}
+	if tr.ExpiresIn > 0 {
+		p.expiresAt = p.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return p.token, nil
+}
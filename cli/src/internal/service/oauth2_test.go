@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func noSecrets(name string) (string, error) {
+	return "", errors.New("secret not found: " + name)
+}
+
+// oauth2TokenServer serves client-credentials token responses and counts calls.
+func oauth2TokenServer(t *testing.T, calls *int32, check func(r *http.Request)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		require.NoError(t, r.ParseForm())
+		if check != nil {
+			check(r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok-123","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewOAuth2TokenProvider_Validation(t *testing.T) {
+	env := envLookup(map[string]string{"SECRET": "s"})
+	tests := []struct {
+		name    string
+		profile config.OAuth2Profile
+		want    string
+	}{
+		{"missing token url", config.OAuth2Profile{ClientID: "id", ClientSecretEnv: "SECRET"}, "no tokenUrl"},
+		{"http token url", config.OAuth2Profile{TokenURL: "http://idp/token", ClientID: "id", ClientSecretEnv: "SECRET"}, "absolute https URL"},
+		{"missing client id", config.OAuth2Profile{TokenURL: "https://idp/token", ClientSecretEnv: "SECRET"}, "no client ID"},
+		{"missing secret", config.OAuth2Profile{TokenURL: "https://idp/token", ClientID: "id"}, "no client secret"},
+		{"keychain error", config.OAuth2Profile{TokenURL: "https://idp/token", ClientID: "id", ClientSecretSecret: "api"}, "secret not found: api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newOAuth2TokenProvider("p", tt.profile, env, noSecrets)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestNewOAuth2TokenProvider_ResolvesCredentials(t *testing.T) {
+	env := envLookup(map[string]string{"CID": "env-id"})
+	p, err := newOAuth2TokenProvider("p", config.OAuth2Profile{
+		TokenURL:           "https://idp/token",
+		ClientID:           "inline-id",
+		ClientIDEnv:        "CID",
+		ClientSecretEnv:    "UNSET",
+		ClientSecretSecret: "api",
+	}, env, func(name string) (string, error) { return "keychain-" + name, nil })
+	require.NoError(t, err)
+	assert.Equal(t, "env-id", p.clientID, "clientIdEnv should win over the inline clientId")
+	assert.Equal(t, "keychain-api", p.clientSecret, "keychain is the fallback when the env var is unset")
+}
+
+func TestOAuth2TokenProvider_GetTokenCaches(t *testing.T) {
+	var calls int32
+	srv := oauth2TokenServer(t, &calls, func(r *http.Request) {
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "id", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		assert.Equal(t, "https://api.example.com", r.PostForm.Get("audience"))
+	})
+
+	p, err := newOAuth2TokenProvider("p", config.OAuth2Profile{
+		TokenURL:        srv.URL + "/token",
+		ClientID:        "id",
+		ClientSecretEnv: "SECRET",
+		Audience:        "https://api.example.com",
+		Scopes:          []string{"read", "write"},
+	}, envLookup(map[string]string{"SECRET": "secret"}), noSecrets)
+	require.NoError(t, err)
+	p.httpClient = srv.Client()
+
+	for i := 0; i < 2; i++ {
+		tok, err := p.GetToken(context.Background(), "ignored")
+		require.NoError(t, err)
+		assert.Equal(t, "tok-123", tok)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "second call should use the cached token")
+
+	// Move the clock past expiry so the token is requested again.
+	p.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = p.GetToken(context.Background(), "ignored")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestOAuth2TokenProvider_ErrorResponse(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad secret"}`))
+	}))
+	defer srv.Close()
+
+	p, err := newOAuth2TokenProvider("p", config.OAuth2Profile{
+		TokenURL: srv.URL, ClientID: "id", ClientSecretEnv: "SECRET",
+	}, envLookup(map[string]string{"SECRET": "secret"}), noSecrets)
+	require.NoError(t, err)
+	p.httpClient = srv.Client()
+
+	_, err = p.GetToken(context.Background(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+	assert.Contains(t, err.Error(), "invalid_client: bad secret")
+	assert.NotContains(t, err.Error(), "secret\"", "the client secret must not appear in errors")
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// defaultFileMode is the permission --output-file is written with unless
+// --file-mode says otherwise. Responses can hold secrets, so it is private.
+const defaultFileMode fs.FileMode = 0o600
+
+// outputFileMode parses --file-mode as octal permission bits.
+func outputFileMode(cfg config.Config) (fs.FileMode, error) {
+	if cfg.FileMode == "" {
+		return defaultFileMode, nil
+	}
+	mode, err := strconv.ParseUint(cfg.FileMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, &outputTargetError{msg: fmt.Sprintf("invalid --file-mode %q (expected octal permissions such as 0600 or 0644)", cfg.FileMode)}
+	}
+	return fs.FileMode(mode), nil
+}
+
+// validateOutputFile checks --append, --no-clobber, and --file-mode. A
+// --no-clobber target that already exists fails here, before the request is
+// sent.
+func validateOutputFile(cfg config.Config) error {
+	if _, err := outputFileMode(cfg); err != nil {
+		return err
+	}
+	switch {
+	case !cfg.Append && !cfg.NoClobber:
+		return nil
+	case cfg.OutputFile == "":
+		return &outputTargetError{msg: "--append and --no-clobber require --output-file"}
+	case cfg.Append && cfg.NoClobber:
+		return &outputTargetError{msg: "--append cannot be combined with --no-clobber"}
+	case cfg.NoClobber:
+		if _, err := os.Lstat(cfg.OutputFile); err == nil {
+			return &outputTargetError{msg: fmt.Sprintf("%s already exists (--no-clobber)", cfg.OutputFile)}
+		}
+	}
+	return nil
+}
+
+// outputFile writes --output-file. The response goes to a temp file in the
+// same directory that is renamed over the target by commit, so an
+// interrupted download never leaves a truncated file that looks complete.
+// With --append the target is written in place instead.
+type outputFile struct {
+	path      string
+	mode      fs.FileMode
+	noClobber bool
+	appending bool
+	created   bool
+
+	*os.File
+}
+
+// createOutputFile opens the file a response for cfg.OutputFile is written
+// to.
+func createOutputFile(cfg config.Config) (*outputFile, error) {
+	mode, err := outputFileMode(cfg)
+	if err != nil {
+		return nil, err
+	}
+	out := &outputFile{path: cfg.OutputFile, mode: mode, noClobber: cfg.NoClobber, appending: cfg.Append}
+	if cfg.Append {
+		_, statErr := os.Stat(out.path)
+		out.created = errors.Is(statErr, fs.ErrNotExist)
+		// #nosec G304 -- User-specified file path via --output-file flag is intentional.
+		out.File, err = os.OpenFile(out.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
+		return out, err
+	}
+	out.File, err = os.CreateTemp(filepath.Dir(out.path), "."+filepath.Base(out.path)+".*.tmp")
+	return out, err
+}
+
+// commit flushes the response to disk and moves it into place. With
+// --no-clobber a target created while the request ran is left alone.
+func (f *outputFile) commit() error {
+	err := f.Sync()
+	if err == nil && (!f.appending || f.created) {
+		err = f.Chmod(f.mode)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if f.appending {
+		return err
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if f.noClobber {
+		// A hard link fails if the target exists, unlike a rename.
+		err = os.Link(f.Name(), f.path)
+		_ = os.Remove(f.Name())
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists (--no-clobber); the response was not written", f.path)
+		}
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// abort discards a response that was not completely written. An appended
+// file keeps what was written before the failure.
+func (f *outputFile) abort() {
+	_ = f.Close()
+	if !f.appending {
+		_ = os.Remove(f.Name())
+	}
+}
+
+// writeOutputFile writes data to cfg.OutputFile through an outputFile.
+func writeOutputFile(cfg config.Config, data []byte) error {
+	f, err := createOutputFile(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
+
+// writeOutput writes formatted output to --output-file, or to stdout without
+// one.
+func writeOutput(cfg config.Config, output string) error {
+	return writeRawOutput(cfg, []byte(output))
+}
+
+// writeRawOutput writes data unchanged to --output-file, or to stdout
+// without one.
+func writeRawOutput(cfg config.Config, data []byte) error {
+	if cfg.OutputFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return writeOutputFile(cfg, data)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOutputFile_ReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{OutputFile: filepath.Join(dir, "out.json"), FileMode: "0640"}
+	require.NoError(t, os.WriteFile(cfg.OutputFile, []byte("old contents that are longer"), 0o600))
+
+	require.NoError(t, writeOutputFile(cfg, []byte("new\n")))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(got))
+	assert.Equal(t, []string{"out.json"}, dirNames(t, dir), "no temp file is left behind")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(cfg.OutputFile)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	}
+}
+
+func TestWriteOutputFile_Append(t *testing.T) {
+	cfg := config.Config{OutputFile: filepath.Join(t.TempDir(), "log.jsonl"), Append: true}
+	require.NoError(t, writeOutputFile(cfg, []byte("{\"n\":1}\n")))
+	require.NoError(t, writeOutputFile(cfg, []byte("{\"n\":2}\n")))
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"n\":1}\n{\"n\":2}\n", string(got))
+}
+
+func TestOutputFile_NoClobber(t *testing.T) {
+	cfg := config.Config{OutputFile: filepath.Join(t.TempDir(), "out.json"), NoClobber: true}
+	require.NoError(t, validateOutputFile(cfg))
+
+	f, err := createOutputFile(cfg)
+	require.NoError(t, err)
+	_, err = f.WriteString("mine")
+	require.NoError(t, err)
+	// Another process creates the file while the request is running.
+	require.NoError(t, os.WriteFile(cfg.OutputFile, []byte("theirs"), 0o600))
+	err = f.commit()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists (--no-clobber)")
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "theirs", string(got))
+	assert.Equal(t, []string{"out.json"}, dirNames(t, filepath.Dir(cfg.OutputFile)))
+
+	var usage *outputTargetError
+	require.ErrorAs(t, validateOutputFile(cfg), &usage)
+	assert.Contains(t, usage.Error(), "already exists (--no-clobber)")
+}
+
+func TestValidateOutputFile(t *testing.T) {
+	assert.NoError(t, validateOutputFile(config.Config{}))
+	assert.NoError(t, validateOutputFile(config.Config{OutputFile: "f", Append: true, Watch: 1, FileMode: "644"}))
+
+	invalid := map[string]config.Config{
+		"--append and --no-clobber require --output-file": {Append: true},
+		"--append cannot be combined with --no-clobber":   {OutputFile: "f", Append: true, NoClobber: true},
+		`invalid --file-mode "rw-r--r--"`:                 {FileMode: "rw-r--r--"},
+		`invalid --file-mode "1777"`:                      {FileMode: "1777"},
+	}
+	for want, cfg := range invalid {
+		err := validateOutputFile(cfg)
+		var usage *outputTargetError
+		require.ErrorAs(t, err, &usage, want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestPageStream_FailureLeavesNoPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{OutputFile: filepath.Join(dir, "out.json"), Compact: true}
+	require.NoError(t, os.WriteFile(cfg.OutputFile, []byte(`{"value":["previous"]}`), 0o600))
+
+	pages := newPageStream(cfg)
+	_, err := pages.Write([]byte(`{"value":[1,2`))
+	require.NoError(t, err)
+	require.NoError(t, pages.finish(errors.New("connection reset")))
+
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{"value":["previous"]}`, string(got), "an interrupted listing does not replace the file")
+	assert.Equal(t, []string{"out.json"}, dirNames(t, dir))
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// outputMetaSuffix is appended to the --output-file path to name the
+// --output-meta sidecar.
+const outputMetaSuffix = ".meta.json"
+
+// outputMeta is the sidecar written next to --output-file by --output-meta,
+// so an archived response can be read later without the command that made
+// it. Headers and the URL are redacted as for --include and --verbose.
+type outputMeta struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Status     int               `json:"status"`
+	StatusText string            `json:"statusText"`
+	Headers    map[string]string `json:"headers"`
+	RequestID  string            `json:"requestId,omitempty"`
+	StartedAt  string            `json:"startedAt"`
+	DurationMs int64             `json:"durationMs"`
+	File       string            `json:"file"`
+	FileBytes  int64             `json:"fileBytes"`
+}
+
+// validateOutputMeta rejects --output-meta without a file to sit next to.
+func validateOutputMeta(cfg config.Config) error {
+	switch {
+	case !cfg.OutputMeta:
+		return nil
+	case cfg.OutputFile == "":
+		return &outputTargetError{msg: "--output-meta requires --output-file"}
+	case cfg.Repeat > 1 || cfg.Watch > 0:
+		return &outputTargetError{msg: "--output-meta cannot be combined with --repeat or --watch"}
+	}
+	return nil
+}
+
+// writeOutputMeta writes <file>.meta.json for a response already written to
+// path.
+func writeOutputMeta(path, method, rawURL string, started time.Time, resp *client.Response) error {
+	meta := outputMeta{
+		Method:     method,
+		URL:        client.RedactSecrets(client.RedactURL(rawURL)),
+		Status:     resp.StatusCode,
+		StatusText: resp.Status,
+		Headers:    redactedHeaderMap(resp.Headers),
+		RequestID:  envelopeRequestID(resp.Headers),
+		StartedAt:  started.UTC().Format(time.RFC3339Nano),
+		DurationMs: resp.Duration.Milliseconds(),
+		File:       filepath.Base(path),
+	}
+	if info, err := os.Stat(path); err == nil {
+		meta.FileBytes = info.Size()
+	}
+
+	out, err := json.MarshalIndent(meta, "", jsonIndent)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path+outputMetaSuffix, err)
+	}
+	// #nosec G306 -- The sidecar sits next to the user-specified --output-file.
+	if err := os.WriteFile(path+outputMetaSuffix, append(out, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path+outputMetaSuffix, err)
+	}
+	return nil
+}
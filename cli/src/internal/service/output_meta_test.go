@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecute_OutputMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ms-request-id", "req-1")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.OutputMeta = true
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, server.URL+"/jobs?sig=abc"))
+
+	data, err := os.ReadFile(cfg.OutputFile + ".meta.json")
+	require.NoError(t, err)
+	var meta outputMeta
+	require.NoError(t, json.Unmarshal(data, &meta))
+	info, err := os.Stat(cfg.OutputFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodGet, meta.Method)
+	assert.NotContains(t, meta.URL, "abc", "query secrets are redacted")
+	assert.Equal(t, http.StatusAccepted, meta.Status)
+	assert.Equal(t, "202 Accepted", meta.StatusText)
+	assert.Equal(t, "req-1", meta.RequestID)
+	assert.NotEqual(t, "session=secret", meta.Headers["Set-Cookie"], "sensitive headers are redacted")
+	assert.NotEmpty(t, meta.StartedAt)
+	assert.Equal(t, "out.json", meta.File)
+	assert.Equal(t, info.Size(), meta.FileBytes)
+}
+
+func TestValidateOutputMeta(t *testing.T) {
+	assert.NoError(t, validateOutputMeta(config.Config{}))
+	assert.NoError(t, validateOutputMeta(config.Config{OutputMeta: true, OutputFile: "out.json"}))
+
+	err := validateOutputMeta(config.Config{OutputMeta: true})
+	var usage *outputTargetError
+	require.ErrorAs(t, err, &usage)
+	assert.EqualError(t, err, "--output-meta requires --output-file")
+	assert.EqualError(t, validateOutputMeta(config.Config{OutputMeta: true, OutputFile: "f", Watch: 1}), "--output-meta cannot be combined with --repeat or --watch")
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// OutputSink delivers a response that was written to a local file to the
+// destination named by an --output-file URL, such as az://container/path.
+type OutputSink interface {
+	Deliver(ctx context.Context, cfg config.Config, dest *url.URL, file string) error
+}
+
+// RegisterOutputSink routes --output-file values of the form scheme://... to
+// sink. The response is written to a temp file as usual and handed to the
+// sink once it is complete.
+func (s *RequestService) RegisterOutputSink(scheme string, sink OutputSink) {
+	if s.outputSinks == nil {
+		s.outputSinks = map[string]OutputSink{}
+	}
+	s.outputSinks[strings.ToLower(scheme)] = sink
+}
+
+// localOutputFile resolves the built-in --output-file sinks: "-" is stdout
+// and a file:// URL is a local path. Anything else is returned unchanged.
+func localOutputFile(outputFile string) (string, error) {
+	if outputFile == "-" {
+		return "", nil
+	}
+	if len(outputFile) < len("file://") || !strings.EqualFold(outputFile[:len("file://")], "file://") {
+		return outputFile, nil
+	}
+	u, err := url.Parse(outputFile)
+	if err != nil || (u.Host != "" && !strings.EqualFold(u.Host, "localhost")) || u.Path == "" {
+		return "", &outputTargetError{msg: fmt.Sprintf("invalid --output-file %q (expected file:///path)", outputFile)}
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// outputSinkFor returns the registered sink and destination for an
+// --output-file URL, or nil for a local path. A Windows drive letter such as
+// C:\out.json is a path, not a scheme.
+func (s *RequestService) outputSinkFor(outputFile string) (OutputSink, *url.URL, error) {
+	scheme, rest, ok := strings.Cut(outputFile, "://")
+	if !ok || len(scheme) < 2 || strings.ContainsAny(scheme, `/\`) {
+		return nil, nil, nil
+	}
+	sink := s.outputSinks[strings.ToLower(scheme)]
+	if sink == nil {
+		return nil, nil, &outputTargetError{msg: fmt.Sprintf("unsupported --output-file destination %s:// (expected %s)", scheme, s.sinkSchemes())}
+	}
+	dest, err := url.Parse(outputFile)
+	if err != nil || strings.Trim(rest, "/") == "" {
+		return nil, nil, &outputTargetError{msg: fmt.Sprintf("invalid --output-file %q", outputFile)}
+	}
+	return sink, dest, nil
+}
+
+// sinkSchemes lists the accepted --output-file forms for error messages.
+func (s *RequestService) sinkSchemes() string {
+	schemes := make([]string, 0, len(s.outputSinks))
+	for scheme := range s.outputSinks {
+		schemes = append(schemes, scheme+"://")
+	}
+	sort.Strings(schemes)
+	return strings.Join(append([]string{"a path", "-", "file://"}, schemes...), ", ")
+}
+
+// prepareOutputSink points cfg.OutputFile at a temp file when it names a
+// registered sink. The returned deliver function hands the finished file to
+// the sink, and cleanup removes it.
+func (s *RequestService) prepareOutputSink(cfg config.Config) (config.Config, func(context.Context) error, func(), error) {
+	noop := func() {}
+	sink, dest, err := s.outputSinkFor(cfg.OutputFile)
+	if err != nil || sink == nil {
+		return cfg, nil, noop, err
+	}
+	target := dest.Scheme + "://"
+	switch {
+	case cfg.Repeat > 1 || cfg.Watch > 0:
+		return cfg, nil, noop, &outputTargetError{msg: fmt.Sprintf("a %s --output-file cannot be combined with --repeat or --watch", target)}
+	case cfg.Append || cfg.NoClobber || cfg.OutputMeta:
+		return cfg, nil, noop, &outputTargetError{msg: fmt.Sprintf("a %s --output-file cannot be combined with --append, --no-clobber, or --output-meta", target)}
+	}
+
+	ext, ok := outputTargetExtensions[cfg.OutputFormat]
+	if !ok {
+		ext = ".json"
+	}
+	f, err := os.CreateTemp("", "azd-rest-*"+ext)
+	if err != nil {
+		return cfg, nil, noop, fmt.Errorf("failed to create a file for --output-file %s: %w", target, err)
+	}
+	_ = f.Close()
+	cfg.OutputFile = f.Name()
+	deliver := func(ctx context.Context) error {
+		return sink.Deliver(ctx, cfg, dest, f.Name())
+	}
+	return cfg, deliver, func() { _ = os.Remove(f.Name()) }, nil
+}
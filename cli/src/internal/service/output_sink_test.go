@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps what was delivered to it.
+type recordingSink struct {
+	dest string
+	body string
+}
+
+func (r *recordingSink) Deliver(_ context.Context, _ config.Config, dest *url.URL, file string) error {
+	data, err := os.ReadFile(file)
+	r.dest, r.body = dest.String(), string(data)
+	return err
+}
+
+func TestExecute_OutputSink(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	sink := &recordingSink{}
+	svc := newTestService()
+	svc.RegisterOutputSink("az", sink)
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = "az://archive/run.json"
+	cfg.Compact = true
+	cfg.Silent = true
+
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	assert.Equal(t, "az://archive/run.json", sink.dest)
+	assert.Equal(t, "{\"ok\":true}\n", sink.body)
+
+	*sink = recordingSink{}
+	status = http.StatusNotFound
+	require.NoError(t, svc.Execute(t.Context(), cfg, http.MethodGet, server.URL))
+	assert.Empty(t, sink.dest, "an error response is not uploaded")
+
+	cfg.OutputFile = "gs://bucket/run.json"
+	err := svc.Execute(t.Context(), cfg, http.MethodGet, server.URL)
+	var usage *outputTargetError
+	require.ErrorAs(t, err, &usage)
+	assert.EqualError(t, err, "unsupported --output-file destination gs:// (expected a path, -, file://, az://)")
+
+	cfg.OutputFile = "az://archive/run.json"
+	cfg.OutputMeta = true
+	assert.ErrorContains(t, svc.Execute(t.Context(), cfg, http.MethodGet, server.URL), "cannot be combined with --append, --no-clobber, or --output-meta")
+}
+
+func TestLocalOutputFile(t *testing.T) {
+	cases := map[string]string{
+		"-":                             "",
+		"out.json":                      "out.json",
+		"file:///tmp/out.json":          filepath.FromSlash("/tmp/out.json"),
+		"file://localhost/tmp/out.json": filepath.FromSlash("/tmp/out.json"),
+		"az://archive/x.json":           "az://archive/x.json",
+	}
+	for in, want := range cases {
+		got, err := localOutputFile(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := localOutputFile("file://server/share/out.json")
+	assert.ErrorContains(t, err, "expected file:///path")
+}
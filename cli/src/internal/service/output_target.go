@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// Values accepted by -o/--output besides azd's default.
+const (
+	outputClipboard = "clipboard"
+	outputEditor    = "editor"
+)
+
+// outputTargetError reports invalid -o/--output or --pipe usage. It reports
+// exit code 2 through the ExitCoder contract so main can map it to a usage
+// failure.
+type outputTargetError struct{ msg string }
+
+func (e *outputTargetError) Error() string { return e.msg }
+
+// ExitCode returns 2 to match the CLI's convention for invalid usage.
+func (e *outputTargetError) ExitCode() int { return 2 }
+
+// clipboardCommands lists the clipboard tools tried in order on each OS. On
+// Linux, clip.exe covers WSL.
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"},
+	},
+}
+
+// outputTargetExtensions names the temp file the response is written to, so
+// an editor picks the right syntax highlighting.
+var outputTargetExtensions = map[string]string{
+	"jsonl":        ".jsonl",
+	"yaml":         ".yaml",
+	"csv":          ".csv",
+	"table":        ".txt",
+	"raw":          ".txt",
+	formatEnvelope: ".json",
+}
+
+// runTool runs an external program with stdin, and its output on the
+// terminal.
+func runTool(ctx context.Context, stdin io.Reader, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 -- The clipboard tool, $VISUAL/$EDITOR, or the user's --pipe command.
+	cmd.Stdin = stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// prepareOutputTarget validates --output and --pipe and, for either, points
+// cfg.OutputFile at a temp file that the response is written to as usual. The
+// returned deliver function hands that file to the target once the response
+// has been written, and cleanup removes it; an editor's file is kept so
+// changes can be saved.
+func (s *RequestService) prepareOutputTarget(cfg config.Config) (config.Config, func(context.Context) error, func(), error) {
+	noop := func() {}
+	switch cfg.OutputTarget {
+	case "", outputClipboard, outputEditor:
+	default:
+		return cfg, nil, noop, &outputTargetError{msg: fmt.Sprintf("invalid --output %q (expected %s or %s)", cfg.OutputTarget, outputClipboard, outputEditor)}
+	}
+	flag := "--output " + cfg.OutputTarget
+	switch {
+	case cfg.OutputTarget == "" && cfg.Pipe == "":
+		return cfg, nil, noop, nil
+	case cfg.OutputTarget != "" && cfg.Pipe != "":
+		return cfg, nil, noop, &outputTargetError{msg: fmt.Sprintf("--pipe cannot be combined with %s", flag)}
+	case cfg.Pipe != "":
+		flag = "--pipe"
+	}
+	if cfg.OutputFile != "" {
+		return cfg, nil, noop, &outputTargetError{msg: flag + " cannot be combined with --output-file"}
+	}
+	if cfg.Repeat > 1 {
+		return cfg, nil, noop, &outputTargetError{msg: flag + " cannot be combined with --repeat"}
+	}
+
+	ext, ok := outputTargetExtensions[cfg.OutputFormat]
+	if !ok {
+		ext = ".json"
+	}
+	f, err := os.CreateTemp("", "azd-rest-*"+ext)
+	if err != nil {
+		return cfg, nil, noop, fmt.Errorf("failed to create a file for %s: %w", flag, err)
+	}
+	_ = f.Close()
+	cfg.OutputFile = f.Name()
+
+	cleanup := func() { _ = os.Remove(f.Name()) }
+	switch {
+	case cfg.Pipe != "":
+		return cfg, func(ctx context.Context) error { return s.pipeOutput(ctx, cfg.Pipe, f.Name()) }, cleanup, nil
+	case cfg.OutputTarget == outputEditor:
+		return cfg, func(ctx context.Context) error { return s.openInEditor(ctx, cfg, f.Name()) }, noop, nil
+	default:
+		return cfg, func(ctx context.Context) error { return s.copyToClipboard(ctx, cfg, f.Name()) }, cleanup, nil
+	}
+}
+
+// pipeError reports a --pipe command that exited non-zero. It reports the
+// command's own exit code through the ExitCoder contract, so a filter such as
+// grep -q can decide the exit status.
+type pipeError struct {
+	err  error
+	code int
+}
+
+func (e *pipeError) Error() string { return fmt.Sprintf("--pipe command failed: %v", e.err) }
+
+// Unwrap exposes the wrapped error for errors.Is/As.
+func (e *pipeError) Unwrap() error { return e.err }
+
+// ExitCode returns the command's exit code.
+func (e *pipeError) ExitCode() int { return e.code }
+
+// pipeOutput runs the --pipe command through the shell with the response file
+// as its stdin.
+func (s *RequestService) pipeOutput(ctx context.Context, command, path string) error {
+	f, err := os.Open(path) // #nosec G304 -- Temp file created by prepareOutputTarget.
+	if err != nil {
+		return fmt.Errorf("failed to read the response for --pipe: %w", err)
+	}
+	defer f.Close()
+
+	shell := []string{"sh", "-c"}
+	if runtime.GOOS == "windows" {
+		shell = []string{"cmd", "/C"}
+	}
+	if err := s.runTool(ctx, f, shell[0], shell[1], command); err != nil {
+		code := 1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			code = exitErr.ExitCode()
+		}
+		return &pipeError{err: err, code: code}
+	}
+	return nil
+}
+
+// copyToClipboard pipes the response file to the first clipboard tool found.
+func (s *RequestService) copyToClipboard(ctx context.Context, cfg config.Config, path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- Temp file created by prepareOutputTarget.
+	if err != nil {
+		return fmt.Errorf("failed to read the response for --output clipboard: %w", err)
+	}
+	var tried []string
+	for _, tool := range clipboardCommands[runtime.GOOS] {
+		tried = append(tried, tool[0])
+		if _, err := s.lookPath(tool[0]); err != nil {
+			continue
+		}
+		if err := s.runTool(ctx, bytes.NewReader(data), tool[0], tool[1:]...); err != nil {
+			return fmt.Errorf("failed to copy the response to the clipboard with %s: %w", tool[0], err)
+		}
+		writeDiagnostic(os.Stderr, cfg.Silent, "Copied %d bytes to the clipboard\n", len(data))
+		return nil
+	}
+	if len(tried) == 0 {
+		return fmt.Errorf("--output clipboard is not supported on %s", runtime.GOOS)
+	}
+	return fmt.Errorf("--output clipboard needs one of %s on PATH", strings.Join(tried, ", "))
+}
+
+// openInEditor opens the response file in $VISUAL or $EDITOR, falling back to
+// notepad on Windows and vi elsewhere, and waits for the editor to exit.
+func (s *RequestService) openInEditor(ctx context.Context, cfg config.Config, path string) error {
+	var editor []string
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if v, ok := s.lookupEnv(name); ok && strings.TrimSpace(v) != "" {
+			editor = strings.Fields(v)
+			break
+		}
+	}
+	if editor == nil {
+		editor = []string{"vi"}
+		if runtime.GOOS == "windows" {
+			editor = []string{"notepad"}
+		}
+	}
+	writeDiagnostic(os.Stderr, cfg.Silent, "Opening %s in %s\n", path, editor[0])
+	if err := s.runTool(ctx, os.Stdin, editor[0], append(editor[1:], path)...); err != nil {
+		return fmt.Errorf("failed to open the response in %s: %w", editor[0], err)
+	}
+	return nil
+}
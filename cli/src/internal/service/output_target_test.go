@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedTool captures one runTool call.
+type recordedTool struct {
+	name  string
+	args  []string
+	stdin string
+}
+
+func newOutputTargetTestService(t *testing.T, env map[string]string) (*RequestService, *[]recordedTool) {
+	t.Helper()
+	var calls []recordedTool
+	svc := newTestService()
+	svc.lookupEnv = func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	svc.lookPath = func(name string) (string, error) { return name, nil }
+	svc.runTool = func(_ context.Context, stdin io.Reader, name string, args ...string) error {
+		call := recordedTool{name: name, args: args}
+		if f, ok := stdin.(*os.File); !ok || f != os.Stdin {
+			b, _ := io.ReadAll(stdin)
+			call.stdin = string(b)
+		}
+		calls = append(calls, call)
+		return nil
+	}
+	return svc, &calls
+}
+
+func newOutputTargetServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExecute_OutputClipboard(t *testing.T) {
+	if len(clipboardCommands[runtime.GOOS]) == 0 {
+		t.Skip("no clipboard tool on this OS")
+	}
+	svc, calls := newOutputTargetTestService(t, nil)
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = ""
+	cfg.OutputFormat = "json"
+	cfg.Silent = true
+	cfg.OutputTarget = outputClipboard
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", newOutputTargetServer(t).URL))
+	require.Len(t, *calls, 1)
+	assert.Equal(t, clipboardCommands[runtime.GOOS][0][0], (*calls)[0].name)
+	assert.JSONEq(t, `{"ok":true}`, (*calls)[0].stdin)
+}
+
+func TestExecute_OutputClipboardNoTool(t *testing.T) {
+	if len(clipboardCommands[runtime.GOOS]) == 0 {
+		t.Skip("no clipboard tool on this OS")
+	}
+	svc, _ := newOutputTargetTestService(t, nil)
+	svc.lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = ""
+	cfg.OutputTarget = outputClipboard
+
+	err := svc.Execute(context.Background(), cfg, "GET", newOutputTargetServer(t).URL)
+	assert.ErrorContains(t, err, "--output clipboard needs one of")
+}
+
+func TestExecute_OutputEditor(t *testing.T) {
+	svc, calls := newOutputTargetTestService(t, map[string]string{"EDITOR": "code --wait"})
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = ""
+	cfg.OutputFormat = "yaml"
+	cfg.Silent = true
+	cfg.OutputTarget = outputEditor
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", newOutputTargetServer(t).URL))
+	require.Len(t, *calls, 1)
+	call := (*calls)[0]
+	assert.Equal(t, "code", call.name)
+	require.Len(t, call.args, 2)
+	assert.Equal(t, "--wait", call.args[0])
+	assert.Equal(t, ".yaml", call.args[1][len(call.args[1])-5:])
+
+	// The file is kept so edits can be saved.
+	data, err := os.ReadFile(call.args[1])
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(call.args[1]) })
+	assert.Contains(t, string(data), "ok: true")
+}
+
+func TestExecute_OutputTargetUsageErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		target string
+		file   bool
+		repeat int
+		want   string
+	}{
+		{name: "unknown target", target: "printer", want: "invalid --output"},
+		{name: "with output file", target: outputEditor, file: true, want: "cannot be combined with --output-file"},
+		{name: "with repeat", target: outputClipboard, repeat: 2, want: "cannot be combined with --repeat"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, calls := newOutputTargetTestService(t, nil)
+			cfg := baseTestConfig(t)
+			if !tt.file {
+				cfg.OutputFile = ""
+			}
+			if tt.repeat > 0 {
+				cfg.Repeat = tt.repeat
+			}
+			cfg.OutputTarget = tt.target
+
+			err := svc.Execute(context.Background(), cfg, "GET", "https://example.com")
+			var exitCoder interface{ ExitCode() int }
+			require.ErrorAs(t, err, &exitCoder)
+			assert.Equal(t, 2, exitCoder.ExitCode())
+			assert.ErrorContains(t, err, tt.want)
+			assert.Empty(t, *calls)
+		})
+	}
+}
+
+func TestExecute_Pipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	old := os.Stdout
+	f, err := os.CreateTemp(t.TempDir(), "stdout-*.txt")
+	require.NoError(t, err)
+	os.Stdout = f
+	defer func() { os.Stdout = old }()
+
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = ""
+	cfg.OutputFormat = "json"
+	cfg.Pipe = "tr a-z A-Z"
+	execErr := newTestService().Execute(context.Background(), cfg, "GET", newOutputTargetServer(t).URL)
+	os.Stdout = old
+	_ = f.Close()
+	require.NoError(t, execErr)
+
+	data, err := os.ReadFile(f.Name()) // #nosec G304 -- test-controlled temp path
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"OK": TRUE`)
+}
+
+func TestExecute_PipeExitCodes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name string
+		path string
+		fail bool
+		want int
+	}{
+		{name: "command exit code", path: "/", want: 3},
+		{name: "fail wins", path: "/missing", fail: true, want: httpFailExitCode},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseTestConfig(t)
+			cfg.OutputFile = ""
+			cfg.Fail = tt.fail
+			cfg.Pipe = "cat >/dev/null; exit 3"
+			err := newTestService().Execute(context.Background(), cfg, "GET", srv.URL+tt.path)
+			var exitCoder interface{ ExitCode() int }
+			require.ErrorAs(t, err, &exitCoder)
+			assert.Equal(t, tt.want, exitCoder.ExitCode())
+		})
+	}
+}
+
+func TestExecute_PipeUsageErrors(t *testing.T) {
+	cfg := baseTestConfig(t)
+	cfg.Pipe = "jq ."
+	err := newTestService().Execute(context.Background(), cfg, "GET", "https://example.com")
+	assert.ErrorContains(t, err, "--pipe cannot be combined with --output-file")
+
+	cfg.OutputFile = ""
+	cfg.OutputTarget = outputClipboard
+	err = newTestService().Execute(context.Background(), cfg, "GET", "https://example.com")
+	assert.ErrorContains(t, err, "--pipe cannot be combined with --output clipboard")
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"io"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// canStreamPages reports whether --paginate output can be written to
+// --output-file page by page. Streaming needs the merged JSON to reach the
+// file as-is apart from layout, so any option that reads or rewrites the
+// whole body, or prints around it, keeps the in-memory path.
+func canStreamPages(cfg config.Config) bool {
+	if !cfg.Paginate || cfg.OutputFile == "" || cfg.Repeat > 1 {
+		return false
+	}
+	if cfg.Query != "" || len(cfg.Redact) > 0 || cfg.Flatten || cfg.SortKeys || cfg.DropNulls || cfg.RawOutput || cfg.Binary ||
+		cfg.Include || cfg.Verbose || cfg.WriteOut != "" || len(cfg.SetAzdEnv) > 0 || len(cfg.CIVars) > 0 || len(cfg.ShowHeaders) > 0 || cfg.OnlyStatus {
+		return false
+	}
+	switch cfg.OutputFormat {
+	case "", string(client.FormatAuto), string(client.FormatJSON):
+		return true
+	}
+	return false
+}
+
+// pageStream is the PageSink for --paginate with --output-file. It opens the
+// file on the first write, so a response that is not streamed leaves the
+// file to the usual output path. With --compact the merged JSON is written
+// as received; otherwise it is pretty-printed on the way through.
+type pageStream struct {
+	cfg     config.Config
+	compact bool
+
+	file *outputFile
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func newPageStream(cfg config.Config) *pageStream {
+	return &pageStream{cfg: cfg, compact: cfg.Compact}
+}
+
+// started reports whether any of the response was written.
+func (p *pageStream) started() bool { return p.file != nil }
+
+func (p *pageStream) Write(b []byte) (int, error) {
+	if p.file == nil {
+		f, err := createOutputFile(p.cfg)
+		if err != nil {
+			return 0, err
+		}
+		p.file = f
+		if !p.compact {
+			pr, pw := io.Pipe()
+			p.pw, p.done = pw, make(chan error, 1)
+			go func() {
+				err := indentJSONStream(f, pr)
+				_ = pr.CloseWithError(err)
+				p.done <- err
+			}()
+		}
+	}
+	if p.pw != nil {
+		return p.pw.Write(b)
+	}
+	return p.file.Write(b)
+}
+
+// finish completes the file once the client has written every page. When
+// the request failed the partial listing is discarded rather than moved into
+// place.
+func (p *pageStream) finish(requestErr error) error {
+	if p.file == nil {
+		return nil
+	}
+	var err error
+	if p.pw != nil {
+		_ = p.pw.Close()
+		err = <-p.done
+	} else if requestErr == nil {
+		// Match the trailing newline of --compact output.
+		_, err = p.file.WriteString("\n")
+	}
+	if requestErr != nil || err != nil {
+		p.file.abort()
+		return err
+	}
+	return p.file.commit()
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagedTestServer serves three pages of two items each.
+func newPagedTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		body := map[string]any{"value": []any{
+			map[string]any{"id": page*2 - 1},
+			map[string]any{"id": page * 2},
+		}}
+		if page < 3 {
+			body["nextLink"] = srv.URL + "/?page=" + strconv.Itoa(page+1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCanStreamPages(t *testing.T) {
+	base := config.Config{Paginate: true, OutputFile: "out.json", Repeat: 1}
+	assert.True(t, canStreamPages(base))
+
+	for name, mutate := range map[string]func(*config.Config){
+		"no paginate":   func(c *config.Config) { c.Paginate = false },
+		"stdout":        func(c *config.Config) { c.OutputFile = "" },
+		"query":         func(c *config.Config) { c.Query = "value[0]" },
+		"redact":        func(c *config.Config) { c.Redact = []string{"id"} },
+		"verbose":       func(c *config.Config) { c.Verbose = true },
+		"table":         func(c *config.Config) { c.OutputFormat = "table" },
+		"raw":           func(c *config.Config) { c.OutputFormat = formatRaw },
+		"set azd env":   func(c *config.Config) { c.SetAzdEnv = []string{"X=id"} },
+		"repeat":        func(c *config.Config) { c.Repeat = 2 },
+		"include":       func(c *config.Config) { c.Include = true },
+		"write out":     func(c *config.Config) { c.WriteOut = "%{http_code}" },
+		"flatten":       func(c *config.Config) { c.Flatten = true },
+		"binary output": func(c *config.Config) { c.Binary = true },
+	} {
+		cfg := base
+		mutate(&cfg)
+		assert.False(t, canStreamPages(cfg), name)
+	}
+}
+
+func TestExecute_PaginateStreamsToOutputFile(t *testing.T) {
+	srv := newPagedTestServer(t)
+	cfg := baseTestConfig(t)
+	cfg.Paginate = true
+
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "value": [
+    {
+      "id": 1
+    },
+    {
+      "id": 2
+    },
+    {
+      "id": 3
+    },
+    {
+      "id": 4
+    },
+    {
+      "id": 5
+    },
+    {
+      "id": 6
+    }
+  ]
+}`, string(got))
+}
+
+func TestExecute_PaginateStreamsCompact(t *testing.T) {
+	srv := newPagedTestServer(t)
+	cfg := baseTestConfig(t)
+	cfg.Paginate = true
+	cfg.Compact = true
+
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", srv.URL))
+
+	got, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{"value":[{"id":1},{"id":2},{"id":3},{"id":4},{"id":5},{"id":6}]}`+"\n", string(got))
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// PingOptions controls an azd rest ping run.
+type PingOptions struct {
+	URL      string
+	Count    int
+	Interval time.Duration
+	// ExpectStatus lists the status codes that pass. When empty, any status
+	// below 400 passes.
+	ExpectStatus []int
+	// JSON writes one JSON report at the end instead of a line per attempt.
+	JSON bool
+	// JUnitReport, when set, is a path to write the attempts to as JUnit XML.
+	JUnitReport string
+}
+
+// pingAttempt is the outcome of one ping.
+type pingAttempt struct {
+	Seq        int     `json:"seq"`
+	Status     int     `json:"status,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+	OK         bool    `json:"ok"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// pingReport is the outcome of a ping run.
+type pingReport struct {
+	URL      string        `json:"url"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	OK       bool          `json:"ok"`
+	Attempts []pingAttempt `json:"attempts"`
+}
+
+// ExecutePing sends a GET to opts.URL opts.Count times, opts.Interval apart,
+// and reports each attempt's status and latency to out. An attempt passes
+// when the request succeeds and its status is allowed by opts.ExpectStatus.
+// The request is built once, so the token is acquired once. It returns an
+// error when any attempt failed, so the exit code can gate a deployment.
+func (s *RequestService) ExecutePing(ctx context.Context, cfg config.Config, opts PingOptions, out io.Writer) error {
+	if opts.Count < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", opts.Count)
+	}
+	if opts.Interval < 0 {
+		return fmt.Errorf("--interval must not be negative, got %s", opts.Interval)
+	}
+	if err := validateAuxTenants(cfg.AuxTenants); err != nil {
+		return err
+	}
+	if err := s.configureTransport(cfg); err != nil {
+		return err
+	}
+	if cfg.MaxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.MaxTime)
+		defer cancel()
+	}
+
+	url, err := s.applyBaseURL(cfg, opts.URL)
+	if err != nil {
+		return err
+	}
+	url, err = s.injectSubscription(ctx, cfg, url)
+	if err != nil {
+		return err
+	}
+	reqOpts, cleanup, err := s.BuildRequestOptions(cfg, "GET", url)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := s.applyAuxiliaryTokens(ctx, cfg.AuxTenants, &reqOpts); err != nil {
+		return err
+	}
+	httpClient := s.httpClientFactory(reqOpts.TokenProvider, cfg.Insecure, cfg.Timeout)
+
+	started := time.Now()
+	report := pingReport{URL: client.RedactURL(reqOpts.URL), Attempts: make([]pingAttempt, 0, opts.Count)}
+	if !opts.JSON {
+		fmt.Fprintf(out, "PING %s\n", report.URL)
+	}
+	for i := 1; i <= opts.Count; i++ {
+		if i > 1 && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.Interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		attempt := pingAttempt{Seq: i}
+		resp, err := httpClient.Execute(ctx, reqOpts)
+		switch {
+		case err != nil:
+			attempt.Error = err.Error()
+		default:
+			attempt.Status = resp.StatusCode
+			attempt.DurationMs = durationMs(resp.Duration)
+			attempt.OK = statusExpected(resp.StatusCode, opts.ExpectStatus)
+			if !attempt.OK {
+				attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+			}
+		}
+		if attempt.OK {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Attempts = append(report.Attempts, attempt)
+		if !opts.JSON {
+			writePingAttempt(out, attempt, opts.Count)
+		}
+	}
+	// Attempts cut short by --max-time or an interrupt count as failures.
+	report.Failed += opts.Count - len(report.Attempts)
+	report.OK = report.Failed == 0
+
+	if opts.JSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("failed to write ping report: %w", err)
+		}
+	} else {
+		writePingSummary(out, report, opts.Count)
+	}
+	if opts.JUnitReport != "" {
+		suite := newJUnitSuite("azd rest ping "+report.URL, started, time.Since(started), pingJUnitCases(report, opts.Count))
+		if err := writeJUnitReport(opts.JUnitReport, suite); err != nil {
+			return err
+		}
+	}
+	if !report.OK {
+		return fmt.Errorf("ping failed: %d of %d attempts did not pass", report.Failed, opts.Count)
+	}
+	return nil
+}
+
+// pingJUnitCases returns one test case per attempt, including attempts that
+// never ran because the run was cut short.
+func pingJUnitCases(r pingReport, count int) []junitTestCase {
+	cases := make([]junitTestCase, 0, count)
+	for i := 1; i <= count; i++ {
+		c := junitTestCase{Name: fmt.Sprintf("attempt %d: GET %s", i, r.URL), ClassName: "azd-rest.ping", Time: junitSeconds(0)}
+		if i > len(r.Attempts) {
+			c.Error = &junitProblem{Message: "not run: the run was stopped early", Type: "canceled"}
+			cases = append(cases, c)
+			continue
+		}
+		a := r.Attempts[i-1]
+		c.Time = junitSeconds(time.Duration(a.DurationMs * float64(time.Millisecond)))
+		switch {
+		case a.Status == 0:
+			c.Error = &junitProblem{Message: a.Error, Type: "request"}
+		case !a.OK:
+			c.Failure = &junitProblem{Message: a.Error, Type: "status"}
+		}
+		cases = append(cases, c)
+	}
+	return cases
+}
+
+// statusExpected reports whether code passes: it is listed in expect, or
+// expect is empty and code is below 400.
+func statusExpected(code int, expect []int) bool {
+	if len(expect) == 0 {
+		return code < 400
+	}
+	return slices.Contains(expect, code)
+}
+
+// writePingAttempt prints one attempt line.
+func writePingAttempt(w io.Writer, a pingAttempt, count int) {
+	result := "ok"
+	if !a.OK {
+		result = "FAIL: " + a.Error
+	}
+	if a.Status == 0 {
+		fmt.Fprintf(w, "  %d/%d  %s\n", a.Seq, count, result)
+		return
+	}
+	fmt.Fprintf(w, "  %d/%d  %d  %.2fms  %s\n", a.Seq, count, a.Status, a.DurationMs, result)
+}
+
+// writePingSummary prints the pass count and the latency of the attempts
+// that got a response.
+func writePingSummary(w io.Writer, r pingReport, count int) {
+	var b bytes.Buffer
+	verdict := "PASS"
+	if !r.OK {
+		verdict = "FAIL"
+	}
+	fmt.Fprintf(&b, "%s: %d/%d passed", verdict, r.Passed, count)
+
+	var durations []time.Duration
+	for _, a := range r.Attempts {
+		if a.Status != 0 {
+			durations = append(durations, time.Duration(a.DurationMs*float64(time.Millisecond)))
+		}
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		fmt.Fprintf(&b, "  latency min %s  mean %s  max %s",
+			formatDuration(durations[0]),
+			formatDuration(meanDuration(durations)),
+			formatDuration(durations[len(durations)-1]),
+		)
+	}
+	b.WriteString("\n")
+	_, _ = w.Write(b.Bytes())
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutePing_AllAttemptsPass(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := newTestService().ExecutePing(context.Background(), baseTestConfig(t), PingOptions{
+		URL: srv.URL, Count: 3, Interval: 10 * time.Millisecond,
+	}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), hits.Load())
+	assert.Contains(t, out.String(), "PING "+srv.URL)
+	assert.Contains(t, out.String(), "  1/3  204  ")
+	assert.Contains(t, out.String(), "  3/3  204  ")
+	assert.Contains(t, out.String(), "PASS: 3/3 passed  latency min ")
+}
+
+func TestExecutePing_UnexpectedStatusFails(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 2 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := newTestService().ExecutePing(context.Background(), baseTestConfig(t), PingOptions{
+		URL: srv.URL, Count: 3, ExpectStatus: []int{200}, JSON: true,
+	}, &out)
+	require.EqualError(t, err, "ping failed: 1 of 3 attempts did not pass")
+
+	var report pingReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.False(t, report.OK)
+	assert.Equal(t, 2, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Attempts, 3)
+	assert.Equal(t, 202, report.Attempts[1].Status)
+	assert.Equal(t, "unexpected status 202", report.Attempts[1].Error)
+}
+
+func TestExecutePing_MaxTimeCountsSkippedAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.MaxTime = 100 * time.Millisecond
+	report := filepath.Join(t.TempDir(), "report.xml")
+	var out bytes.Buffer
+	err := newTestService().ExecutePing(context.Background(), cfg, PingOptions{
+		URL: srv.URL, Count: 3, Interval: time.Hour, JUnitReport: report,
+	}, &out)
+	require.EqualError(t, err, "ping failed: 2 of 3 attempts did not pass")
+	assert.Contains(t, out.String(), "FAIL: 1/3 passed")
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	var doc junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, 3, doc.Tests)
+	assert.Equal(t, 2, doc.Errors)
+	require.Len(t, doc.Suites, 1)
+	assert.Equal(t, "attempt 1: GET "+srv.URL, doc.Suites[0].Cases[0].Name)
+	assert.Nil(t, doc.Suites[0].Cases[0].Error)
+	assert.Equal(t, "canceled", doc.Suites[0].Cases[2].Error.Type)
+}
+
+func TestExecutePing_JUnitReportRecordsStatusFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	report := filepath.Join(t.TempDir(), "report.xml")
+	err := newTestService().ExecutePing(context.Background(), baseTestConfig(t), PingOptions{
+		URL: srv.URL, Count: 2, ExpectStatus: []int{200}, JUnitReport: report,
+	}, &bytes.Buffer{})
+	require.Error(t, err)
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	var doc junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, 2, doc.Failures)
+	assert.Equal(t, 0, doc.Errors)
+	assert.Equal(t, "unexpected status 404", doc.Suites[0].Cases[1].Failure.Message)
+}
+
+func TestExecutePing_Validation(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	err := svc.ExecutePing(context.Background(), cfg, PingOptions{URL: "https://example.com", Count: 0}, &bytes.Buffer{})
+	require.ErrorContains(t, err, "--count must be at least 1")
+	err = svc.ExecutePing(context.Background(), cfg, PingOptions{URL: "https://example.com", Count: 1, Interval: -time.Second}, &bytes.Buffer{})
+	require.ErrorContains(t, err, "--interval must not be negative")
+}
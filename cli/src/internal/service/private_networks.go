@@ -0,0 +1,35 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdext"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// privateNetworksBlocked reports whether private network blocking is on,
+// either from --block-private-networks or from blockPrivateNetworks in the
+// config file. The file is only read when the flag is off.
+func (s *RequestService) privateNetworksBlocked(cfg config.Config) (bool, error) {
+	if cfg.BlockPrivate {
+		return true, nil
+	}
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return false, err
+	}
+	return file.BlockPrivateNetworks, nil
+}
+
+// checkPrivateNetworks refuses a URL whose host is, or resolves to, a private,
+// loopback, link-local, or cloud metadata address. It uses the same blocked
+// ranges and hosts as the MCP server. A host that does not resolve is refused.
+// Redirects need no extra setup: once the request host is known to be public,
+// the client's default redirect policy refuses hops into private networks.
+func checkPrivateNetworks(rawURL string) error {
+	policy := azdext.NewMCPSecurityPolicy().BlockMetadataEndpoints().BlockPrivateNetworks()
+	if err := policy.CheckURL(rawURL); err != nil {
+		return fmt.Errorf("request blocked by --block-private-networks: %w", err)
+	}
+	return nil
+}
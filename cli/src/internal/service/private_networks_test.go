@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRequestOptions_BlockPrivateNetworks(t *testing.T) {
+	cases := map[string]bool{
+		"http://127.0.0.1:8080/probe":                  true,
+		"http://10.1.2.3/admin":                        true,
+		"http://169.254.169.254/metadata/instance":     true,
+		"http://[::1]/":                                true,
+		"https://20.1.2.3/subscriptions?api-version=1": false,
+	}
+	for rawURL, blocked := range cases {
+		svc := newTestService()
+		cfg := baseTestConfig(t)
+		cfg.BlockPrivate = true
+
+		_, cleanup, err := svc.BuildRequestOptions(cfg, "GET", rawURL)
+		if cleanup != nil {
+			cleanup()
+		}
+		if blocked {
+			require.Error(t, err, rawURL)
+			assert.Contains(t, err.Error(), "--block-private-networks", rawURL)
+		} else {
+			assert.NoError(t, err, rawURL)
+		}
+	}
+}
+
+func TestBuildRequestOptions_BlockPrivateNetworksFromConfigFile(t *testing.T) {
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{BlockPrivateNetworks: true}, nil }
+
+	_, cleanup, err := svc.BuildRequestOptions(baseTestConfig(t), "GET", "http://127.0.0.1:8080/probe")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blocked")
+}
+
+func TestBuildRequestOptions_PrivateNetworksAllowedByDefault(t *testing.T) {
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, nil }
+
+	_, cleanup, err := svc.BuildRequestOptions(baseTestConfig(t), "GET", "http://127.0.0.1:8080/probe")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.NoError(t, err)
+}
+
+func TestBuildRequestOptions_BlockPrivateNetworksConfigError(t *testing.T) {
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, errors.New("failed to parse config file") }
+
+	_, cleanup, err := svc.BuildRequestOptions(baseTestConfig(t), "GET", "http://127.0.0.1:8080/probe")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+}
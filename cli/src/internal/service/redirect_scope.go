@@ -0,0 +1,22 @@
+package service
+
+import "fmt"
+
+// Recognized values for the --redirect-scope flag, which decides what happens
+// when a redirect that keeps the bearer token lands on an Azure host with a
+// different scope.
+const (
+	redirectScopeRefuse    = "refuse"
+	redirectScopeReacquire = "reacquire"
+)
+
+// validateRedirectScope reports an error for an unrecognized --redirect-scope
+// value. An empty value is treated as the default (refuse).
+func validateRedirectScope(mode string) error {
+	switch mode {
+	case "", redirectScopeRefuse, redirectScopeReacquire:
+		return nil
+	default:
+		return fmt.Errorf("invalid --redirect-scope value %q (expected refuse or reacquire)", mode)
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRedirectScope(t *testing.T) {
+	for _, m := range []string{"", "refuse", "reacquire"} {
+		assert.NoError(t, validateRedirectScope(m), m)
+	}
+	err := validateRedirectScope("ignore")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--redirect-scope")
+}
+
+func TestBuildRequestOptions_RedirectScopeReacquire(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	cfg.RedirectScope = "reacquire"
+
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/subscriptions")
+	if cleanup != nil {
+		cleanup()
+	}
+	require.NoError(t, err)
+	assert.True(t, opts.RedirectPolicy.ReacquireToken)
+}
@@ -7,8 +7,10 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jongio/azd-rest/src/internal/client"
 	"github.com/jongio/azd-rest/src/internal/config"
 )
@@ -26,6 +28,7 @@ type repeatStats struct {
 	total        int
 	success      int
 	failed       int
+	notRun       int
 	statusCounts map[int]int
 	durations    []time.Duration
 }
@@ -56,13 +59,33 @@ func (s *RequestService) executeRepeat(ctx context.Context, cfg config.Config, h
 		durations:    make([]time.Duration, 0, cfg.Repeat),
 	}
 
+	// Each repeat is a deliberate new request, so --idempotency-key auto gets
+	// a fresh key per iteration; the retries of one iteration keep its key.
+	autoKey := strings.EqualFold(cfg.IdempotencyKey, idempotencyKeyAuto)
+	if autoKey {
+		opts.Headers = opts.Headers.Clone()
+	}
+
 	var lastResp *client.Response
 	for i := 0; i < cfg.Repeat; i++ {
+		// An interrupt or --max-time ends the run early with a summary of
+		// the requests sent so far.
+		if ctx.Err() != nil {
+			stats.notRun = cfg.Repeat - i
+			break
+		}
 		if bodyBytes != nil {
 			opts.Body = bytes.NewReader(bodyBytes)
 		}
+		if autoKey && i > 0 {
+			opts.Headers.Set(client.IdempotencyKeyHeader, uuid.NewString())
+		}
 
+		started := time.Now()
 		resp, err := httpClient.Execute(ctx, opts)
+		if auditErr := recordAudit(cfg, opts, started, resp, err); auditErr != nil {
+			return fmt.Errorf("request %d/%d sent but not recorded in the audit log: %w", i+1, cfg.Repeat, auditErr)
+		}
 		if err != nil {
 			stats.failed++
 			fmt.Fprintf(os.Stderr, "Request %d/%d failed: %v\n", i+1, cfg.Repeat, err)
@@ -81,6 +104,14 @@ func (s *RequestService) executeRepeat(ctx context.Context, cfg config.Config, h
 
 	writeRepeatSummary(os.Stderr, stats)
 
+	if stopErr := stopError(ctx, cfg); stopErr != nil {
+		if lastResp != nil {
+			if err := s.writeResponseOutput(cfg, lastResp); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("%w after %d of %d repeated requests", stopErr, cfg.Repeat-stats.notRun, cfg.Repeat)
+	}
 	if lastResp == nil {
 		return fmt.Errorf("all %d requests failed", cfg.Repeat)
 	}
@@ -92,6 +123,9 @@ func (s *RequestService) executeRepeat(ctx context.Context, cfg config.Config, h
 func writeRepeatSummary(w io.Writer, stats repeatStats) {
 	fmt.Fprintf(w, "\nRepeat summary (%d requests):\n", stats.total)
 	fmt.Fprintf(w, "  Success: %d   Failed: %d\n", stats.success, stats.failed)
+	if stats.notRun > 0 {
+		fmt.Fprintf(w, "  Not run: %d (stopped early)\n", stats.notRun)
+	}
 
 	if len(stats.statusCounts) > 0 {
 		codes := make([]int, 0, len(stats.statusCounts))
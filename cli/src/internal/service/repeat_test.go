@@ -2,9 +2,16 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPercentile(t *testing.T) {
@@ -135,3 +142,34 @@ func TestWriteRepeatSummaryNoDurations(t *testing.T) {
 		t.Errorf("expected no latency line when there are no durations, got:\n%s", out)
 	}
 }
+
+func TestExecuteRepeat_AutoIdempotencyKeyPerIteration(t *testing.T) {
+	var keys []string
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		keys = append(keys, r.Header.Get(client.IdempotencyKeyHeader))
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Repeat = 3
+	cfg.Retry = 1
+	cfg.IdempotencyKey = "auto"
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "POST", server.URL))
+
+	require.Len(t, keys, 4)
+	assert.Equal(t, keys[0], keys[1], "a retry keeps its iteration's key")
+	assert.NotEmpty(t, keys[1])
+	assert.NotEqual(t, keys[1], keys[2])
+	assert.NotEqual(t, keys[2], keys[3])
+	assert.NotEqual(t, keys[1], keys[3])
+
+	keys = nil
+	cfg.IdempotencyKey = "order-42"
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "POST", server.URL))
+	assert.Equal(t, []string{"order-42", "order-42", "order-42"}, keys, "a fixed key is sent on every repeat")
+}
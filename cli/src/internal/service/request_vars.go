@@ -0,0 +1,73 @@
+package service
+
+import (
+	"os"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// applyRequestVars expands ${NAME} references in the URL, header values, and
+// inline body flags with the variables from the current azd environment's
+// azd-rest.env.yaml, so one saved command runs against any environment. The
+// project's .azd-rest/variables.yaml supplies values the environment's file
+// does not define. References to names neither file defines are left as they
+// are, so a literal ${...} in a body is never rewritten unexpectedly.
+func (s *RequestService) applyRequestVars(cfg config.Config, url string) (config.Config, string, error) {
+	vars, err := s.loadRequestVars()
+	if err != nil {
+		return cfg, url, err
+	}
+	project, err := s.loadProjectVars()
+	if err != nil {
+		return cfg, url, err
+	}
+	if len(vars.Values) == 0 && len(project.Values) == 0 {
+		return cfg, url, nil
+	}
+	values := make(map[string]string, len(project.Values)+len(vars.Values))
+	for name, v := range project.Values {
+		values[name] = v
+	}
+	for name, v := range vars.Values {
+		values[name] = v
+	}
+	if cfg.Verbose {
+		if len(project.Values) > 0 {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> Using %d variable(s) from %s\n", len(project.Values), project.Path)
+		}
+		if len(vars.Values) > 0 {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> Using %d variable(s) from %s\n", len(vars.Values), vars.Path)
+		}
+	}
+
+	expand := func(s string) string { return expandVarRefs(s, values) }
+	expandAll := func(list []string) []string {
+		if len(list) == 0 {
+			return list
+		}
+		out := make([]string, len(list))
+		for i, v := range list {
+			out[i] = expand(v)
+		}
+		return out
+	}
+
+	cfg.Headers = expandAll(cfg.Headers)
+	cfg.URLParams = expandAll(cfg.URLParams)
+	cfg.FormFields = expandAll(cfg.FormFields)
+	cfg.JSONFields = expandAll(cfg.JSONFields)
+	cfg.JSONFieldsRaw = expandAll(cfg.JSONFieldsRaw)
+	cfg.Data = expand(cfg.Data)
+	return cfg, expand(url), nil
+}
+
+// expandVarRefs replaces ${NAME} references whose name is in values and leaves
+// every other reference untouched.
+func expandVarRefs(s string, values map[string]string) string {
+	return varRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := values[varRefPattern.FindStringSubmatch(ref)[1]]; ok {
+			return v
+		}
+		return ref
+	})
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandVarRefs(t *testing.T) {
+	values := map[string]string{"HOST": "api.example.com"}
+	assert.Equal(t, "https://api.example.com/${UNKNOWN}?x=$HOST", expandVarRefs("https://${HOST}/${UNKNOWN}?x=$HOST", values))
+}
+
+func TestExecute_RequestVars(t *testing.T) {
+	var gotPath, gotHeader, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotHeader, gotBody = r.URL.RequestURI(), r.Header.Get("X-Tenant"), string(body)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	svc.loadRequestVars = func() (config.Vars, error) {
+		return config.Vars{Path: "vars.yaml", Values: map[string]string{"BASE": srv.URL, "TENANT": "contoso", "ENV": "stage"}}, nil
+	}
+	cfg := baseTestConfig(t)
+	cfg.Headers = []string{"X-Tenant: ${TENANT}"}
+	cfg.URLParams = []string{"env=${ENV}"}
+	cfg.Data = `{"env":"${ENV}","keep":"${NOT_DEFINED}"}`
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "POST", "${BASE}/items"))
+	assert.Equal(t, "/items?env=stage", gotPath)
+	assert.Equal(t, "contoso", gotHeader)
+	assert.JSONEq(t, `{"env":"stage","keep":"${NOT_DEFINED}"}`, gotBody)
+}
+
+func TestBuildRequestOptions_RequestVarsError(t *testing.T) {
+	svc := newTestService()
+	svc.loadRequestVars = func() (config.Vars, error) { return config.Vars{}, errors.New("bad vars file") }
+	_, _, err := svc.BuildRequestOptions(baseTestConfig(t), "GET", "https://example.com")
+	assert.ErrorContains(t, err, "bad vars file")
+}
+
+func TestApplyRequestVars_ProjectVariables(t *testing.T) {
+	svc := newTestService()
+	svc.loadRequestVars = func() (config.Vars, error) {
+		return config.Vars{Path: "env.yaml", Values: map[string]string{"ENV": "stage"}}, nil
+	}
+	svc.loadProjectVars = func() (config.Vars, error) {
+		return config.Vars{Path: "variables.yaml", Values: map[string]string{"ENV": "dev", "HOST": "api.example.com"}}, nil
+	}
+	_, url, err := svc.applyRequestVars(baseTestConfig(t), "https://${HOST}/${ENV}")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/stage", url, "the environment's file overrides the project's variables")
+}
+
+func TestExecute_IgnoresInvalidProjectWorkflow(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	t.Setenv(config.ProjectDirEnv, root)
+	dir := filepath.Join(root, config.RequestDirName)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "workflows"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.yaml"), []byte("ITEM: widgets\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "workflows", "wip.yaml"), []byte("steps: []\n"), 0o600))
+
+	svc := newTestService()
+	svc.loadRequestVars = func() (config.Vars, error) { return config.Vars{}, nil }
+	require.NoError(t, svc.Execute(context.Background(), baseTestConfig(t), "GET", srv.URL+"/${ITEM}"))
+	assert.Equal(t, "/widgets", gotPath)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/cache"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// azdEnvironmentName returns the current azd environment name, which
+// partitions the response cache.
+func azdEnvironmentName(ctx context.Context) (string, error) {
+	azdCtx, err := azdcontext.Load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return azdCtx.EnvironmentName, nil
+}
+
+// cacheable reports whether --cache applies to a request: a GET or HEAD
+// without a body whose pages are not streamed to --output-file and whose
+// body is not checked with --checksum.
+func cacheable(cfg config.Config, opts client.RequestOptions, streaming bool) bool {
+	return cfg.Cache > 0 && (opts.Method == http.MethodGet || opts.Method == http.MethodHead) && opts.Body == nil && opts.BodyHash == nil && !streaming
+}
+
+// responseCacheKey identifies a request in the response cache by its method,
+// URL, headers, credential, tenants, and pagination settings. The correlation ID is
+// left out because it changes on every request.
+func responseCacheKey(cfg config.Config, opts client.RequestOptions) string {
+	parts := []string{
+		opts.Method, opts.URL, opts.Scope, strconv.FormatBool(opts.SkipAuth),
+		cfg.Auth, cfg.User, cfg.BearerEnv, strconv.FormatBool(cfg.GitHubAuth),
+		cfg.Tenant, strings.Join(cfg.AuxTenants, ","),
+		strconv.FormatBool(opts.Paginate), strconv.Itoa(opts.MaxPages), strconv.Itoa(opts.MaxItems),
+	}
+	names := make([]string, 0, len(opts.Headers))
+	for name := range opts.Headers {
+		if !strings.EqualFold(name, clientRequestIDHeader) {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+": "+strings.Join(opts.Headers.Values(name), ", "))
+	}
+	return cache.Key(parts...)
+}
+
+// storable reports whether a response may be written to the response cache.
+// A response marked Cache-Control no-store or private is not, nor is a Key
+// Vault secret read, whose value would otherwise sit on disk in plaintext.
+func storable(opts client.RequestOptions, resp *client.Response) bool {
+	for _, value := range resp.Headers.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+				return false
+			}
+		}
+	}
+	return !isKeyVaultSecretURL(opts.URL)
+}
+
+// isKeyVaultSecretURL reports whether raw addresses the secrets, or deleted
+// secrets, of a Key Vault.
+func isKeyVaultSecretURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	path := strings.ToLower(strings.TrimPrefix(u.Path, "/"))
+	for _, suffix := range keyVaultSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return strings.HasPrefix(path, "secrets") || strings.HasPrefix(path, "deletedsecrets")
+		}
+	}
+	return false
+}
+
+// executeCached returns a cached response younger than --cache from the
+// current azd environment's partition, or sends the request and caches a 2xx
+// response that storable allows. A cache that cannot be written only costs the next request a
+// network call, so write errors are reported with --verbose and not returned.
+func (s *RequestService) executeCached(ctx context.Context, cfg config.Config, httpClient *client.Client, opts client.RequestOptions) (*client.Response, error) {
+	dir, err := s.responseCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	env, err := s.environmentName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := responseCacheKey(cfg, opts)
+	if e, ok := cache.Get(dir, env, key, cfg.Cache); ok {
+		if cfg.Verbose {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> Cache hit (stored %s ago)\n", time.Since(e.StoredAt).Round(time.Second))
+		}
+		return &client.Response{StatusCode: e.StatusCode, Status: e.Status, Headers: e.Headers, Body: e.Body}, nil
+	}
+
+	resp, err := httpClient.Execute(ctx, opts)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 || !storable(opts, resp) {
+		return resp, err
+	}
+	entry := cache.Entry{StatusCode: resp.StatusCode, Status: resp.Status, Headers: resp.Headers, Body: resp.Body, StoredAt: time.Now()}
+	if err := cache.Put(dir, env, key, entry); err != nil && cfg.Verbose {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> Response not cached: %v\n", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/cache"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cachingTestService returns a service whose response cache is in a temp
+// directory and whose azd environment is *env.
+func cachingTestService(t *testing.T, env *string) *RequestService {
+	t.Helper()
+	svc := newTestService()
+	dir := t.TempDir()
+	svc.responseCacheDir = func() (string, error) { return dir, nil }
+	svc.environmentName = func(context.Context) (string, error) { return *env, nil }
+	return svc
+}
+
+func TestExecute_CacheServesRepeatGets(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"calls":1}`))
+	}))
+	defer server.Close()
+
+	env := "dev"
+	svc := cachingTestService(t, &env)
+	cfg := baseTestConfig(t)
+	cfg.Cache = time.Minute
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL+"/items"))
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL+"/items"))
+	assert.Equal(t, 1, calls)
+	out, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"calls": 1`)
+
+	env = "prod"
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL+"/items"))
+	assert.Equal(t, 2, calls, "switching environments never serves another environment's response")
+
+	cfg.Cache = 0
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL+"/items"))
+	assert.Equal(t, 3, calls, "without --cache every request is sent")
+}
+
+func TestExecute_CacheSkipsErrorsAndWrites(t *testing.T) {
+	calls := 0
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	env := "dev"
+	svc := cachingTestService(t, &env)
+	cfg := baseTestConfig(t)
+	cfg.Cache = time.Minute
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL))
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL))
+	assert.Equal(t, 2, calls, "an error response is not cached")
+
+	status = http.StatusOK
+	require.NoError(t, svc.Execute(context.Background(), cfg, "POST", server.URL))
+	require.NoError(t, svc.Execute(context.Background(), cfg, "POST", server.URL))
+	assert.Equal(t, 4, calls, "a POST is never cached")
+}
+
+func TestExecute_CacheSkipsNoStore(t *testing.T) {
+	calls := 0
+	cacheControl := "no-store"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", cacheControl)
+		_, _ = w.Write([]byte(`{"value":"s3cret"}`))
+	}))
+	defer server.Close()
+
+	env := "dev"
+	svc := cachingTestService(t, &env)
+	dir, err := svc.responseCacheDir()
+	require.NoError(t, err)
+	cfg := baseTestConfig(t)
+	cfg.Cache = time.Minute
+
+	for _, cacheControl = range []string{"no-store", "private, max-age=60"} {
+		require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL))
+		require.NoError(t, svc.Execute(context.Background(), cfg, "GET", server.URL))
+	}
+	assert.Equal(t, 4, calls, "a no-store or private response is not cached")
+	stats, err := cache.ReadStats(dir)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Zero(t, stats[0].Entries, "nothing is written to the cache directory")
+}
+
+func TestStorable(t *testing.T) {
+	ok := &client.Response{StatusCode: http.StatusOK, Headers: http.Header{"Cache-Control": {"no-cache"}}}
+	assert.True(t, storable(client.RequestOptions{URL: "https://management.azure.com/subscriptions"}, ok))
+	assert.True(t, storable(client.RequestOptions{URL: "https://kv.vault.azure.net/keys/k1"}, ok))
+	assert.False(t, storable(client.RequestOptions{URL: "https://kv.vault.azure.net/secrets/db-password?api-version=7.4"}, ok), "Key Vault secret reads are never cached")
+	assert.False(t, storable(client.RequestOptions{URL: "https://KV.vault.azure.cn/deletedsecrets/old"}, ok))
+	assert.False(t, storable(client.RequestOptions{URL: "https://example.com"}, &client.Response{Headers: http.Header{"Cache-Control": {"max-age=0, No-Store"}}}))
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	opts := client.RequestOptions{Method: "GET", URL: "https://example.com", Headers: http.Header{"Accept": {"application/json"}}}
+	base := responseCacheKey(config.Config{}, opts)
+
+	withID := opts
+	withID.Headers = http.Header{"Accept": {"application/json"}, "X-Ms-Client-Request-Id": {"abc"}}
+	assert.Equal(t, base, responseCacheKey(config.Config{}, withID), "the correlation ID does not change the key")
+
+	other := opts
+	other.Headers = http.Header{"Accept": {"text/csv"}}
+	assert.NotEqual(t, base, responseCacheKey(config.Config{}, other))
+	assert.NotEqual(t, base, responseCacheKey(config.Config{Auth: "oauth2:partner"}, opts))
+
+	tenantA := responseCacheKey(config.Config{Tenant: "tenant-a"}, opts)
+	assert.NotEqual(t, base, tenantA)
+	assert.NotEqual(t, tenantA, responseCacheKey(config.Config{Tenant: "tenant-b"}, opts), "a response is never served to another tenant")
+	assert.NotEqual(t, tenantA, responseCacheKey(config.Config{Tenant: "tenant-a", AuxTenants: []string{"tenant-c"}}, opts))
+}
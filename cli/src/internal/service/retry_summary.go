@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+)
+
+// formatRetrySummary returns the one-line --summary of a request: attempts,
+// total backoff, the status of each attempt, and the rate-limit headers seen.
+// It returns "" when no request was sent, as for a cache hit.
+func formatRetrySummary(stats *client.RetryStats, err error) string {
+	if stats == nil || stats.Attempts == 0 {
+		return ""
+	}
+	statuses := make([]string, len(stats.Statuses))
+	for i, status := range stats.Statuses {
+		if status == 0 {
+			statuses[i] = "no response"
+		} else {
+			statuses[i] = fmt.Sprintf("HTTP %d", status)
+		}
+	}
+	final := statuses[len(statuses)-1]
+	if err != nil {
+		final = "failed: " + client.RedactSecrets(err.Error())
+	}
+	line := fmt.Sprintf("Summary: %d attempt(s), %s backoff, %s", stats.Attempts, stats.Backoff, final)
+	if len(statuses) > 1 {
+		line += " (attempts: " + strings.Join(statuses, ", ") + ")"
+	}
+	if len(stats.RateLimit) > 0 {
+		names := make([]string, 0, len(stats.RateLimit))
+		for name := range stats.RateLimit {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pairs := make([]string, len(names))
+		for i, name := range names {
+			pairs[i] = strings.ToLower(name) + "=" + strings.Join(stats.RateLimit[name], ",")
+		}
+		line += "; rate limit: " + strings.Join(pairs, " ")
+	}
+	return line
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRetrySummary(t *testing.T) {
+	assert.Empty(t, formatRetrySummary(nil, nil))
+	assert.Empty(t, formatRetrySummary(&client.RetryStats{}, nil), "a cache hit sends nothing")
+
+	assert.Equal(t, "Summary: 1 attempt(s), 0s backoff, HTTP 200",
+		formatRetrySummary(&client.RetryStats{Attempts: 1, Statuses: []int{200}}, nil))
+
+	stats := &client.RetryStats{
+		Attempts:  3,
+		Backoff:   3 * time.Second,
+		Statuses:  []int{0, 429, 200},
+		RateLimit: http.Header{"Retry-After": {"2"}, "X-Ms-Ratelimit-Remaining-Subscription-Reads": {"11999"}},
+	}
+	assert.Equal(t, "Summary: 3 attempt(s), 3s backoff, HTTP 200 (attempts: no response, HTTP 429, HTTP 200); "+
+		"rate limit: retry-after=2 x-ms-ratelimit-remaining-subscription-reads=11999", formatRetrySummary(stats, nil))
+
+	failed := &client.RetryStats{Attempts: 2, Backoff: time.Second, Statuses: []int{0, 0}}
+	assert.Equal(t, "Summary: 2 attempt(s), 1s backoff, failed: connection refused (attempts: no response, no response)",
+		formatRetrySummary(failed, errors.New("connection refused")))
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/sas"
+	"github.com/jongio/azd-rest/src/internal/secrets"
+)
+
+// applySASAuth handles --auth sas:<profile>. It signs the request URL with the
+// profile's shared access key and sets the Authorization header to the
+// resulting SharedAccessSignature token, which also disables Azure token
+// acquisition for the request.
+func (s *RequestService) applySASAuth(cfg config.Config, requestURL string, headers http.Header) error {
+	mode := strings.TrimSpace(cfg.Auth)
+	if !strings.HasPrefix(mode, authSASPrefix) {
+		return nil
+	}
+	if hasHeader(headers, authorizationHeader) {
+		return &authModeError{fmt.Errorf("--auth %s cannot be combined with an Authorization header", mode)}
+	}
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return err
+	}
+	cs, err := resolveSASProfile(strings.TrimPrefix(mode, authSASPrefix), file, s.lookupEnv, s.getSecret)
+	if err != nil {
+		return err
+	}
+	resource, err := cs.ResourceForURL(requestURL)
+	if err != nil {
+		return err
+	}
+	headers.Set(authorizationHeader, cs.Token(resource, time.Now().Add(sas.DefaultExpiry)))
+	return nil
+}
+
+// LoadSASConnectionString resolves the named SAS profile from the config file
+// using the process environment and the OS keychain.
+func LoadSASConnectionString(name string) (sas.ConnectionString, error) {
+	file, err := config.LoadFile()
+	if err != nil {
+		return sas.ConnectionString{}, err
+	}
+	return resolveSASProfile(name, file, os.LookupEnv, secrets.Get)
+}
+
+// resolveSASProfile reads a SAS profile's connection string from the
+// environment variable named by connectionStringEnv or, failing that, from the
+// OS keychain entry named by connectionStringSecret, and parses it.
+func resolveSASProfile(name string, file config.File, lookupEnv func(string) (string, bool), getSecret func(string) (string, error)) (sas.ConnectionString, error) {
+	profile, ok := file.SAS[name]
+	if !ok {
+		return sas.ConnectionString{}, &authModeError{fmt.Errorf("sas profile %q is not defined in the config file", name)}
+	}
+	var raw string
+	if profile.ConnectionStringEnv != "" {
+		if v, ok := lookupEnv(profile.ConnectionStringEnv); ok {
+			raw = v
+		}
+	}
+	if raw == "" && profile.ConnectionStringSecret != "" {
+		v, err := getSecret(profile.ConnectionStringSecret)
+		if err != nil {
+			return sas.ConnectionString{}, fmt.Errorf("sas profile %q: %w", name, err)
+		}
+		raw = v
+	}
+	if raw == "" {
+		return sas.ConnectionString{}, fmt.Errorf("sas profile %q has no connection string (set connectionStringEnv or connectionStringSecret)", name)
+	}
+	cs, err := sas.ParseConnectionString(raw)
+	if err != nil {
+		return sas.ConnectionString{}, fmt.Errorf("sas profile %q: %w", name, err)
+	}
+	return cs, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSASConnectionString = "Endpoint=sb://contoso.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=abc123"
+
+func sasConfigFile() config.File {
+	return config.File{SAS: map[string]config.SASProfile{
+		"orders": {ConnectionStringEnv: "ORDERS_SB", ConnectionStringSecret: "orders-sb"},
+	}}
+}
+
+func TestApplySASAuth_SignsRequestURL(t *testing.T) {
+	svc := serviceWithConfigFile(sasConfigFile(), map[string]string{"ORDERS_SB": testSASConnectionString})
+	headers := http.Header{}
+
+	err := svc.applySASAuth(config.Config{Auth: "sas:orders"}, "https://contoso.servicebus.windows.net/orders/messages?timeout=60", headers)
+	require.NoError(t, err)
+
+	token := headers.Get("Authorization")
+	require.True(t, strings.HasPrefix(token, "SharedAccessSignature "))
+	fields, err := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+	require.NoError(t, err)
+	assert.Equal(t, "https://contoso.servicebus.windows.net/orders/messages", fields.Get("sr"))
+	assert.Equal(t, "send", fields.Get("skn"))
+}
+
+func TestApplySASAuth_SecretFallback(t *testing.T) {
+	svc := serviceWithConfigFile(sasConfigFile(), nil)
+	svc.getSecret = secretStore(map[string]string{"orders-sb": testSASConnectionString})
+	headers := http.Header{}
+
+	require.NoError(t, svc.applySASAuth(config.Config{Auth: "sas:orders"}, "https://contoso.servicebus.windows.net/orders", headers))
+	assert.Contains(t, headers.Get("Authorization"), "skn=send")
+}
+
+func TestApplySASAuth_Errors(t *testing.T) {
+	env := map[string]string{"ORDERS_SB": testSASConnectionString}
+	tests := []struct {
+		name    string
+		auth    string
+		url     string
+		headers http.Header
+		want    string
+	}{
+		{"unknown profile", "sas:missing", "https://contoso.servicebus.windows.net/q", nil, `sas profile "missing" is not defined`},
+		{"host mismatch", "sas:orders", "https://other.servicebus.windows.net/q", nil, "does not match"},
+		{"authorization header", "sas:orders", "https://contoso.servicebus.windows.net/q", http.Header{"Authorization": {"x"}}, "Authorization header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := serviceWithConfigFile(sasConfigFile(), env)
+			headers := tt.headers
+			if headers == nil {
+				headers = http.Header{}
+			}
+			err := svc.applySASAuth(config.Config{Auth: tt.auth}, tt.url, headers)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.NotContains(t, err.Error(), "abc123")
+		})
+	}
+}
+
+func TestResolveAuthMode_SASConflicts(t *testing.T) {
+	svc := serviceWithConfigFile(sasConfigFile(), nil)
+
+	_, err := svc.resolveAuthMode(config.Config{Auth: "sas:orders", NoAuth: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined")
+
+	_, err = svc.resolveAuthMode(config.Config{Auth: "sas:"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sas:<profile>")
+
+	tp, err := svc.resolveAuthMode(config.Config{Auth: "sas:orders"})
+	require.NoError(t, err)
+	assert.Nil(t, tp, "SAS does not use a token provider")
+}
+
+func TestExecute_SASAuth_SkipsAzureAuth(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	svc := serviceWithConfigFile(sasConfigFile(), map[string]string{
+		"ORDERS_SB": "Endpoint=sb://" + host + "/;SharedAccessKeyName=send;SharedAccessKey=abc123",
+	})
+	cfg := baseTestConfig(t)
+	cfg.NoAuth = false
+	cfg.Insecure = true
+	cfg.Silent = true
+	cfg.Auth = "sas:orders"
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL+"/orders"))
+	assert.True(t, strings.HasPrefix(gotAuth, "SharedAccessSignature "), gotAuth)
+}
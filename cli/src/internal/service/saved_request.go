@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// hasBodyFlag reports whether any request body flag is set.
+func hasBodyFlag(cfg config.Config) bool {
+	return cfg.Data != "" || cfg.DataFile != "" || cfg.DataRaw != "" || cfg.DataBinary != "" ||
+		cfg.JSONData != "" || len(cfg.DataURLEncode) > 0 || len(cfg.FormFields) > 0 ||
+		len(cfg.JSONFields) > 0 || len(cfg.JSONFieldsRaw) > 0
+}
+
+// ApplySavedRequest layers a saved request under the command-line flags in
+// cfg and returns the method and URL to send. A -H header replaces a saved
+// header of the same name, a body flag replaces the saved body, and --query
+// and --scope replace the saved ones.
+func ApplySavedRequest(cfg config.Config, req config.SavedRequest) (config.Config, string, string, error) {
+	method := strings.ToUpper(strings.TrimSpace(req.Method))
+	if method == "" {
+		method = "GET"
+	}
+
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg.Headers = WithDefaultHeader(cfg.Headers, name, req.Headers[name])
+	}
+
+	if req.Body != nil && !hasBodyFlag(cfg) {
+		if text, ok := req.Body.(string); ok {
+			cfg.Data = text
+		} else {
+			raw, err := json.Marshal(req.Body)
+			if err != nil {
+				return cfg, "", "", fmt.Errorf("failed to encode the saved request body as JSON: %w", err)
+			}
+			cfg.Data = string(raw)
+			cfg.Headers = WithDefaultHeader(cfg.Headers, contentTypeHeader, applicationJSON)
+		}
+	}
+	if cfg.Query == "" {
+		cfg.Query = req.Query
+	}
+	if cfg.Scope == "" {
+		cfg.Scope = req.Scope
+	}
+	return cfg, method, strings.TrimSpace(req.URL), nil
+}
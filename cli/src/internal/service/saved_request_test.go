@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySavedRequest(t *testing.T) {
+	req := config.SavedRequest{
+		Method:  "patch",
+		URL:     " https://example.com/items/1 ",
+		Headers: map[string]string{"If-Match": "*", "X-Team": "platform"},
+		Body:    map[string]any{"tags": map[string]any{"env": "dev"}},
+		Query:   "tags",
+		Scope:   "api://example/.default",
+	}
+	cfg, method, url, err := ApplySavedRequest(config.Config{}, req)
+	require.NoError(t, err)
+	assert.Equal(t, "PATCH", method)
+	assert.Equal(t, "https://example.com/items/1", url)
+	assert.Equal(t, []string{"Content-Type: application/json", "X-Team: platform", "If-Match: *"}, cfg.Headers)
+	assert.JSONEq(t, `{"tags":{"env":"dev"}}`, cfg.Data)
+	assert.Equal(t, "tags", cfg.Query)
+	assert.Equal(t, "api://example/.default", cfg.Scope)
+}
+
+func TestApplySavedRequest_FlagsTakePrecedence(t *testing.T) {
+	req := config.SavedRequest{
+		URL:     "https://example.com/items",
+		Headers: map[string]string{"X-Team": "platform"},
+		Body:    "saved",
+		Query:   "saved",
+		Scope:   "saved",
+	}
+	cfg, method, _, err := ApplySavedRequest(config.Config{
+		Headers:  []string{"x-team: ops"},
+		DataFile: "body.json",
+		Query:    "flag",
+		Scope:    "flag",
+	}, req)
+	require.NoError(t, err)
+	assert.Equal(t, "GET", method)
+	assert.Equal(t, []string{"x-team: ops"}, cfg.Headers)
+	assert.Empty(t, cfg.Data)
+	assert.Equal(t, "flag", cfg.Query)
+	assert.Equal(t, "flag", cfg.Scope)
+}
+
+func TestApplySavedRequest_StringBody(t *testing.T) {
+	cfg, _, _, err := ApplySavedRequest(config.Config{}, config.SavedRequest{URL: "https://example.com", Body: "a=1&b=2"})
+	require.NoError(t, err)
+	assert.Equal(t, "a=1&b=2", cfg.Data)
+	assert.Empty(t, cfg.Headers, "a string body is sent as-is, without a JSON Content-Type")
+}
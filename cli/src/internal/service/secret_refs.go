@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/secrets"
+)
+
+// secretRefPrefix marks a header value that names a secret in the OS keychain
+// rather than holding the value itself, as in -H "x-api-key: @secret:myapi".
+const secretRefPrefix = "@secret:"
+
+// resolveSecretRefs replaces every @secret:<name> header value with the secret
+// stored under that name and returns the names of the headers it resolved so
+// verbose output can redact them regardless of header name. Resolution happens
+// at request time, so the key never appears in shell history or config files.
+func resolveSecretRefs(headers http.Header, getSecret func(string) (string, error)) ([]string, error) {
+	var resolved []string
+	for key, values := range headers {
+		isSecret := false
+		for i, value := range values {
+			if !strings.HasPrefix(value, secretRefPrefix) {
+				continue
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(value, secretRefPrefix))
+			if err := secrets.ValidateName(name); err != nil {
+				return nil, fmt.Errorf("header %s: %w", key, err)
+			}
+			secret, err := getSecret(name)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %w", key, err)
+			}
+			values[i] = secret
+			isSecret = true
+		}
+		if isSecret {
+			resolved = append(resolved, key)
+		}
+	}
+	return resolved, nil
+}
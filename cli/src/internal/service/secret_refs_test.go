@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretStore returns a getSecret function backed by a map.
+func secretStore(values map[string]string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		if v, ok := values[name]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("%w: %s", secrets.ErrNotFound, name)
+	}
+}
+
+func TestResolveSecretRefs(t *testing.T) {
+	headers := http.Header{
+		"X-Api-Key":    {"@secret:myapi"},
+		"Content-Type": {"application/json"},
+	}
+	resolved, err := resolveSecretRefs(headers, secretStore(map[string]string{"myapi": "k-123"}))
+	require.NoError(t, err)
+	assert.Equal(t, "k-123", headers.Get("X-Api-Key"))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, []string{"X-Api-Key"}, resolved)
+}
+
+func TestResolveSecretRefs_Errors(t *testing.T) {
+	_, err := resolveSecretRefs(http.Header{"api-key": {"@secret:missing"}}, secretStore(nil))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, secrets.ErrNotFound))
+	assert.Contains(t, err.Error(), "api-key")
+
+	_, err = resolveSecretRefs(http.Header{"api-key": {"@secret:"}}, secretStore(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid secret name")
+}
+
+func TestExecute_SecretHeaderResolved(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("api-key")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	svc := newTestService()
+	svc.getSecret = secretStore(map[string]string{"openai": "sk-live"})
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	cfg.Headers = []string{"api-key: @secret:openai"}
+
+	require.NoError(t, svc.Execute(context.Background(), cfg, "GET", srv.URL))
+	assert.Equal(t, "sk-live", gotKey)
+}
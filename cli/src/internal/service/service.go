@@ -11,21 +11,29 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmespath-community/go-jmespath"
 	"github.com/jongio/azd-core/auth"
+	"github.com/jongio/azd-rest/src/internal/cache"
 	"github.com/jongio/azd-rest/src/internal/client"
 	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/secrets"
 )
 
 // clientRequestIDHeader is the Azure correlation header set by --client-request-id.
 const clientRequestIDHeader = "x-ms-client-request-id"
 
+// idempotencyKeyAuto is the --idempotency-key value that generates a key.
+const idempotencyKeyAuto = "auto"
+
 // TokenProviderFactory creates a TokenProvider. Abstracting this allows tests
 // to inject mocks without touching real Azure credentials.
 type TokenProviderFactory func() (client.TokenProvider, error)
@@ -36,39 +44,93 @@ type HTTPClientFactory func(tp client.TokenProvider, insecure bool, timeout time
 // RequestService encapsulates the business logic for building and executing
 // HTTP requests. It receives its dependencies via constructor injection (#43).
 type RequestService struct {
-	tokenProviderFactory TokenProviderFactory
-	httpClientFactory    HTTPClientFactory
+	tokenProviderFactory       TokenProviderFactory
+	httpClientFactory          HTTPClientFactory
+	tenantTokenProviderFactory TenantTokenProviderFactory
+	loadConfigFile             func() (config.File, error)
+	lookupEnv                  func(string) (string, bool)
+	environ                    func() []string
+	lookPath                   func(string) (string, error)
+	runTool                    func(context.Context, io.Reader, string, ...string) error
+	runCredentialPlugin        func(context.Context, config.ExecCredential, []byte) ([]byte, error)
+	getSecret                  func(string) (string, error)
+	getKeyVaultSecret          func(context.Context, client.TokenProvider, client.RequestOptions) (string, error)
+	ghCLIToken                 func() (string, error)
+	setAzdEnv                  func(context.Context, []azdEnvValue) error
+	loadRequestVars            func() (config.Vars, error)
+	outputSinks                map[string]OutputSink
+	loadProjectVars            func() (config.Vars, error)
+	subscriptionID             func(context.Context) (string, error)
+	tenantID                   func(context.Context) (string, error)
+	environmentName            func(context.Context) (string, error)
+	responseCacheDir           func() (string, error)
+	stdin                      io.Reader
+	stdout                     io.Writer
+	// secretValues are values a workflow step resolved from secret
+	// references. They are redacted from verbose output and the audit log.
+	secretValues []string
+	// limiter paces the requests of a bulk or workflow run per host.
+	limiter *hostLimiter
 }
 
 // NewRequestService constructs a RequestService with injected dependencies.
 func NewRequestService(tpf TokenProviderFactory, hcf HTTPClientFactory) *RequestService {
 	return &RequestService{
-		tokenProviderFactory: tpf,
-		httpClientFactory:    hcf,
+		tokenProviderFactory:       tpf,
+		httpClientFactory:          hcf,
+		tenantTokenProviderFactory: DefaultTenantTokenProviderFactory,
+		loadConfigFile:             config.LoadFile,
+		lookupEnv:                  os.LookupEnv,
+		environ:                    os.Environ,
+		lookPath:                   exec.LookPath,
+		runTool:                    runTool,
+		runCredentialPlugin:        runCredentialPlugin,
+		getSecret:                  secrets.Get,
+		getKeyVaultSecret:          fetchKeyVaultSecret,
+		ghCLIToken:                 ghCLIToken,
+		setAzdEnv:                  setAzdEnvValues,
+		loadRequestVars:            config.LoadVars,
+		loadProjectVars:            config.LoadProjectVars,
+		subscriptionID:             azdSubscriptionID,
+		tenantID:                   azdTenantID,
+		environmentName:            azdEnvironmentName,
+		responseCacheDir:           cache.Dir,
+		stdin:                      os.Stdin,
+		stdout:                     os.Stdout,
 	}
 }
 
 // DefaultTokenProviderFactory is the production factory using Azure credentials.
+// When azd rest daemon is running, tokens come from its warm provider instead.
 func DefaultTokenProviderFactory() (client.TokenProvider, error) {
-	return auth.NewAzureTokenProvider()
+	if d := connectDaemon(); d != nil {
+		return &daemonTokenProvider{daemon: d}, nil
+	}
+	return client.NewAzureTokenProvider()
 }
 
 // DefaultHTTPClientFactory is the production factory using the real HTTP client.
+// When azd rest daemon is running, requests go over its warm connections.
 func DefaultHTTPClientFactory(tp client.TokenProvider, insecure bool, timeout time.Duration) *client.Client {
+	if d := connectDaemon(); d != nil {
+		return client.NewForwardingClient(tp, insecure, timeout, func(local http.RoundTripper) http.RoundTripper {
+			return &daemonTransport{daemon: d, local: local}
+		})
+	}
 	return client.NewClient(tp, insecure, timeout)
 }
 
 // loadHeaderFile reads headers from a file, one "Key: Value" per line. Blank
 // lines and lines beginning with "#" are ignored. It returns a clear error for
 // a missing file or a malformed line.
-func loadHeaderFile(path string) (map[string]string, error) {
+func loadHeaderFile(path string) (http.Header, error) {
 	file, err := os.Open(path) // #nosec G304 -- User-specified file path via --header-file flag is intentional.
 	if err != nil {
 		return nil, fmt.Errorf("failed to open header file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	result := make(map[string]string)
+	result := make(http.Header)
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
 	for scanner.Scan() {
@@ -85,7 +147,7 @@ func loadHeaderFile(path string) (map[string]string, error) {
 		if key == "" {
 			return nil, fmt.Errorf("invalid header on line %d of %s: %q (empty header name)", lineNum, path, line)
 		}
-		result[key] = strings.TrimSpace(parts[1])
+		result.Add(key, strings.TrimSpace(parts[1]))
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("failed to read header file: %w", err)
@@ -123,12 +185,13 @@ func applyQueryToResponse(resp *client.Response, expression string) error {
 // writeDiagnostic writes a non-error advisory message (warning or notice) to w
 // unless silent mode is enabled. It is only for informational diagnostics;
 // errors and response output must never be routed through it, so silencing
-// diagnostics can never hide a genuine failure (#171).
+// diagnostics can never hide a genuine failure (#171). Secrets in the message,
+// such as a token echoed from a response, are redacted.
 func writeDiagnostic(w io.Writer, silent bool, format string, args ...any) {
 	if silent {
 		return
 	}
-	fmt.Fprintf(w, format, args...)
+	_, _ = io.WriteString(w, client.RedactSecrets(fmt.Sprintf(format, args...)))
 }
 
 func applyAPIVersion(rawURL, apiVersion string) (string, error) {
@@ -183,6 +246,17 @@ func applyURLParams(rawURL string, params []string) (string, error) {
 // the file after the request completes. The returned cleanup function handles
 // this - call it on error paths. On success paths the caller should defer it.
 func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url string) (client.RequestOptions, func(), error) {
+	cfg, url, err := s.applyRequestVars(cfg, url)
+	if err != nil {
+		return client.RequestOptions{}, nil, err
+	}
+	if cfg, err = s.applyStdinBody(cfg); err != nil {
+		return client.RequestOptions{}, nil, err
+	}
+	if cfg, err = s.applyCurlBodyFlags(cfg); err != nil {
+		return client.RequestOptions{}, nil, err
+	}
+
 	requestURL, err := applyAPIVersion(url, cfg.APIVersion)
 	if err != nil {
 		return client.RequestOptions{}, nil, err
@@ -204,43 +278,79 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		if !allowed {
 			return client.RequestOptions{}, nil, fmt.Errorf("host %q is not in the --allow-host allowlist", host)
 		}
-		if cfg.FollowRedirects {
-			writeDiagnostic(os.Stderr, cfg.Silent, "> --allow-host is set and redirects are enabled; redirect targets are bounded by --max-redirects but are not checked against the allowlist\n")
+	}
+
+	// --pinnedpubkey is parsed here so a malformed pin fails before any token
+	// is acquired; the client checks it on every TLS connection.
+	var pins [][]byte
+	if cfg.PinnedPubKey != "" {
+		pins, err = client.ParsePinnedPublicKeys(cfg.PinnedPubKey)
+		if err != nil {
+			return client.RequestOptions{}, nil, fmt.Errorf("invalid --pinnedpubkey: %w", err)
+		}
+	}
+
+	// --block-private-networks applies the MCP server's address policy to
+	// the CLI. Like the allowlist, it runs before any token is acquired.
+	blockPrivate, err := s.privateNetworksBlocked(cfg)
+	if err != nil {
+		return client.RequestOptions{}, nil, err
+	}
+	if blockPrivate {
+		if err := checkPrivateNetworks(requestURL); err != nil {
+			return client.RequestOptions{}, nil, err
 		}
 	}
 
 	opts := client.RequestOptions{
 		Method:          method,
 		URL:             requestURL,
-		Headers:         make(map[string]string),
+		Headers:         make(http.Header),
 		Scope:           cfg.Scope,
 		SkipAuth:        cfg.NoAuth,
 		Verbose:         cfg.Verbose,
+		VerboseLevel:    cfg.VerboseLevel,
 		Timeout:         cfg.Timeout,
 		Insecure:        cfg.Insecure,
 		FollowRedirects: cfg.FollowRedirects,
 		MaxRedirects:    cfg.MaxRedirects,
+		// Redirect targets must match the same allowlist as the request.
+		RedirectPolicy: client.RedirectPolicy{
+			AllowHosts:      cfg.AllowedHosts,
+			LocationTrusted: cfg.LocationTrusted,
+			ReacquireToken:  cfg.RedirectScope == redirectScopeReacquire,
+		},
 		OutputFile:      cfg.OutputFile,
 		Format:          cfg.OutputFormat,
 		Binary:          cfg.Binary,
+		RawBytes:        cfg.RawBytes,
 		Retry:           cfg.Retry,
 		MaxResponseSize: cfg.MaxResponseSize,
+		MaxHeaderSize:   cfg.MaxHeaderSize,
+		MaxHeaderCount:  cfg.MaxHeaders,
 		Paginate:        cfg.Paginate,
+		MaxPages:        cfg.MaxPages,
 	}
+	opts.PinnedPublicKeys = pins
 
-	// Load headers from --header-file first so an inline -H header with the
-	// same key wins on conflict (parsed below).
+	// Default headers from the config file come first, then --header-file,
+	// so an inline -H header with the same key wins on conflict (parsed below).
+	if opts.Headers, err = s.configHeaders(requestURL); err != nil {
+		return opts, nil, err
+	}
 	if cfg.HeaderFile != "" {
 		fileHeaders, err := loadHeaderFile(cfg.HeaderFile)
 		if err != nil {
 			return opts, nil, err
 		}
-		for key, value := range fileHeaders {
-			opts.Headers[key] = value
+		for key, values := range fileHeaders {
+			opts.Headers[key] = values
 		}
 	}
 
-	// Parse headers
+	// Parse headers. A repeated -H adds a value, as curl does, and replaces
+	// any values for that name from the config file or --header-file.
+	inline := make(http.Header)
 	for _, header := range cfg.Headers {
 		parts := strings.SplitN(header, ":", 2)
 		if len(parts) != 2 {
@@ -248,8 +358,20 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		}
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
-		opts.Headers[key] = value
+		inline.Add(key, value)
 	}
+	for key, values := range inline {
+		opts.Headers[key] = values
+	}
+
+	// Header values of the form @secret:<name> are read from the OS keychain.
+	secretHeaders, err := resolveSecretRefs(opts.Headers, s.getSecret)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.SecretHeaders = secretHeaders
+	opts.SecretValues = s.secretValues
+	applyCurlBodyHeaders(cfg, opts.Headers)
 
 	// --data-format (#236) selects how --data / --data-file is interpreted before
 	// it is sent. The default is JSON (raw passthrough). YAML is parsed and
@@ -264,6 +386,10 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 	if dataFormat == dataFormatYAML && (len(cfg.JSONFields) > 0 || len(cfg.JSONFieldsRaw) > 0 || len(cfg.FormFields) > 0) {
 		return opts, nil, &dataFormatError{fmt.Errorf("--data-format yaml cannot be combined with --form-field, --json-field, or --json-field-raw")}
 	}
+	// A .yaml or .yml --data-file is converted unless --data-format is given.
+	if cfg.DataFormat == "" && len(cfg.FormFields) == 0 && isYAMLDataFile(cfg.DataFile) {
+		dataFormat = dataFormatYAML
+	}
 
 	// JSON body fields (#215): assemble a JSON body from repeatable --json-field
 	// and --json-field-raw flags. This is mutually exclusive with other bodies.
@@ -277,14 +403,41 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		}
 		opts.Body = bytes.NewReader(jsonBody)
 		if !hasHeader(opts.Headers, contentTypeHeader) {
-			opts.Headers[contentTypeHeader] = applicationJSON
+			opts.Headers.Set(contentTypeHeader, applicationJSON)
 		}
 	}
 
+	// --user and --bearer-env supply the Authorization header directly.
+	if err := applyAuthShortcuts(cfg, opts.Headers, s.lookupEnv); err != nil {
+		return opts, nil, err
+	}
+	if err := s.applyGitHubAuth(cfg, requestURL, opts.Headers); err != nil {
+		return opts, nil, err
+	}
+	// --apim-key-env, --apim-key-secret, or the config file's apim entries
+	// supply the API Management subscription key.
+	if err := s.applyAPIMKey(cfg, requestURL, &opts); err != nil {
+		return opts, nil, err
+	}
+	if cfg.User != "" && strings.HasPrefix(strings.ToLower(requestURL), "http://") {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: --user sends credentials in clear text over http://\n")
+	}
+
 	// The --client-request-id flag is authoritative and overrides a matching -H header.
 	if cfg.ClientRequestID != "" {
-		opts.Headers[clientRequestIDHeader] = cfg.ClientRequestID
+		opts.Headers.Set(clientRequestIDHeader, cfg.ClientRequestID)
+	}
+
+	// --idempotency-key also overrides a matching -H header. A key lets the
+	// client retry a POST or PATCH after a network error.
+	if cfg.IdempotencyKey != "" {
+		key := cfg.IdempotencyKey
+		if strings.EqualFold(key, idempotencyKeyAuto) {
+			key = uuid.NewString()
+		}
+		opts.Headers.Set(client.IdempotencyKeyHeader, key)
 	}
+	opts.RetryNonIdempotent = cfg.RetryAllMethods
 
 	// Form fields (#202): build an application/x-www-form-urlencoded body from
 	// repeatable --form-field flags. This is mutually exclusive with a raw body.
@@ -298,7 +451,7 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		}
 		opts.Body = strings.NewReader(encoded)
 		if !hasHeader(opts.Headers, contentTypeHeader) {
-			opts.Headers[contentTypeHeader] = formURLEncoded
+			opts.Headers.Set(contentTypeHeader, formURLEncoded)
 		}
 	}
 
@@ -314,6 +467,9 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		if readErr != nil {
 			return opts, nil, readErr
 		}
+		if raw, err = s.renderBodyTemplate(cfg, raw); err != nil {
+			return opts, nil, err
+		}
 		if len(raw) > 0 {
 			jsonBody, convErr := yamlToJSON(raw)
 			if convErr != nil {
@@ -321,9 +477,21 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 			}
 			opts.Body = bytes.NewReader(jsonBody)
 			if !hasHeader(opts.Headers, contentTypeHeader) {
-				opts.Headers[contentTypeHeader] = applicationJSON
+				opts.Headers.Set(contentTypeHeader, applicationJSON)
 			}
 		}
+	case bodyTemplated(cfg) && (cfg.DataFile != "" || cfg.Data != ""):
+		// A templated body is read and rendered in full, so no file handle is
+		// kept open here either.
+		raw, readErr := readRequestBody(cfg)
+		if readErr != nil {
+			return opts, nil, readErr
+		}
+		if raw, err = s.renderBodyTemplate(cfg, raw); err != nil {
+			return opts, nil, err
+		}
+		opts.Body = bytes.NewReader(raw)
+		applyBodyContentType(cfg, opts.Headers)
 	case cfg.DataFile != "":
 		filePath := cfg.DataFile
 		if strings.HasPrefix(cfg.DataFile, "@") {
@@ -335,8 +503,10 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		}
 		bodyFile = file
 		opts.Body = file
+		applyBodyContentType(cfg, opts.Headers)
 	case cfg.Data != "":
 		opts.Body = strings.NewReader(cfg.Data)
+		applyBodyContentType(cfg, opts.Headers)
 	}
 
 	// cleanup closes the file handle if one was opened. The caller owns this.
@@ -346,9 +516,36 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		}
 	}
 
+	// --auth selects a non-Azure token provider. It replaces scope detection,
+	// so the scope is set to the mode itself to make the client attach a token.
+	err = validateTenant(cfg)
+	var authProvider client.TokenProvider
+	if err == nil {
+		authProvider, err = s.resolveAuthMode(cfg)
+	}
+	if err != nil {
+		cleanup()
+		return opts, nil, err
+	}
+	if authProvider != nil {
+		opts.Scope = strings.TrimSpace(cfg.Auth)
+	}
+	execProvider, err := s.resolveExecAuth(cfg, requestURL)
+	if err != nil {
+		cleanup()
+		return opts, nil, err
+	}
+
+	// --auth sas:<profile> signs the request URL and sends the token as the
+	// Authorization header instead of using a token provider.
+	if err := s.applySASAuth(cfg, requestURL, opts.Headers); err != nil {
+		cleanup()
+		return opts, nil, err
+	}
+
 	// Detect scope if not provided
 	if opts.Scope == "" && !opts.SkipAuth {
-		detectedScope, err := auth.DetectScope(requestURL)
+		detectedScope, err := client.DetectScope(requestURL)
 		if err != nil {
 			cleanup()
 			return opts, nil, fmt.Errorf("failed to detect scope: %w", err)
@@ -364,8 +561,21 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 	opts.SkipAuth = client.ShouldSkipAuth(url, opts.Headers, cfg.NoAuth)
 
 	// Create token provider only when authentication is needed
-	if !opts.SkipAuth {
-		tokenProvider, err := s.tokenProviderFactory()
+	if !opts.SkipAuth && authProvider != nil {
+		opts.TokenProvider = authProvider
+	} else if !opts.SkipAuth && execProvider != nil {
+		// The plugin is asked for an empty scope when none was detected.
+		opts.TokenProvider = execProvider
+		if opts.Scope == "" {
+			opts.Scope = strings.TrimSpace(cfg.Auth)
+		}
+	} else if !opts.SkipAuth {
+		var tokenProvider client.TokenProvider
+		if cfg.Tenant != "" {
+			tokenProvider, err = s.tenantTokenProviderFactory(cfg.Tenant)
+		} else {
+			tokenProvider, err = s.tokenProviderFactory()
+		}
 		if err != nil {
 			cleanup()
 			return opts, nil, fmt.Errorf("failed to create token provider: %w", err)
@@ -373,11 +583,40 @@ func (s *RequestService) BuildRequestOptions(cfg config.Config, method, url stri
 		opts.TokenProvider = tokenProvider
 	}
 
+	// --verbose describes each access token and warns about a tenant
+	// mismatch or an expiring token.
+	if opts.TokenProvider != nil && cfg.Verbose {
+		opts.TokenProvider = s.inspectTokens(opts.TokenProvider, cfg.Tenant, cfg.Silent)
+	}
+	// A container registry takes its own access token, exchanged for the
+	// Azure one.
+	if err := applyACRExchange(cfg, method, requestURL, &opts); err != nil {
+		cleanup()
+		return opts, nil, err
+	}
+
+	if err := checkInsecureAzure(cfg, requestURL, opts); err != nil {
+		cleanup()
+		return opts, nil, err
+	}
+
 	return opts, cleanup, nil
 }
 
+// ResponseTransform rewrites a 2xx response body before --query and
+// formatting, such as a helper command turning a service's result frames
+// into rows. It returns the column order of the rows, if any, so table and
+// CSV output keep it.
+type ResponseTransform func(body []byte) ([]byte, []string, error)
+
 // Execute performs the full request lifecycle: build options, execute, format output.
 func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method, url string) error {
+	return s.ExecuteTransform(ctx, cfg, method, url, nil)
+}
+
+// ExecuteTransform is Execute with transform applied to a 2xx response body.
+// It does not apply to --repeat or --watch, which callers reject.
+func (s *RequestService) ExecuteTransform(ctx context.Context, cfg config.Config, method, url string, transform ResponseTransform) error {
 	// Warn prominently when TLS verification is disabled.
 	if cfg.Insecure {
 		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: TLS certificate verification is disabled (--insecure). Do not use this flag in production.\n")
@@ -387,16 +626,116 @@ func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method,
 		return fmt.Errorf("--repeat must be at least 1, got %d", cfg.Repeat)
 	}
 
+	until, err := validateWatch(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MaxHeaderSize < 1 || cfg.MaxHeaderSize > client.MaxHeaderSizeLimit {
+		return fmt.Errorf("--max-header-size must be between 1 and %d, got %d", client.MaxHeaderSizeLimit, cfg.MaxHeaderSize)
+	}
+	if cfg.MaxHeaders < 1 {
+		return fmt.Errorf("--max-headers must be at least 1, got %d", cfg.MaxHeaders)
+	}
+
 	if err := validateColorMode(cfg.Color); err != nil {
 		return err
 	}
 
+	if err := validateRedirectScope(cfg.RedirectScope); err != nil {
+		return err
+	}
+	if err := validateAutoScope(cfg.AutoScope); err != nil {
+		return err
+	}
+
 	// --raw-output (#234) only makes sense with --query. Reject the combination
 	// up front (exit 2, no network call) so the flag never silently does nothing.
 	if cfg.RawOutput && cfg.Query == "" {
 		return &rawOutputUsageError{msg: "--raw-output requires --query"}
 	}
 
+	if err := validateAuxTenants(cfg.AuxTenants); err != nil {
+		return err
+	}
+
+	if err := s.configureTransport(cfg); err != nil {
+		return err
+	}
+
+	azdEnvAssignments, err := parseAzdEnvAssignments(cfg.SetAzdEnv)
+	if err != nil {
+		return err
+	}
+	if len(azdEnvAssignments) > 0 && cfg.Repeat > 1 {
+		return &azdEnvError{fmt.Errorf("--set-azd-env cannot be combined with --repeat")}
+	}
+	if len(azdEnvAssignments) > 0 && cfg.Watch > 0 {
+		return &azdEnvError{fmt.Errorf("--set-azd-env cannot be combined with --watch")}
+	}
+
+	ciVars, err := parseCIOutput(cfg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := parseColumns(cfg.Columns); err != nil {
+		return err
+	}
+
+	// --output-file - is stdout and file:// a local path; other URLs name a
+	// registered sink.
+	if cfg.OutputFile, err = localOutputFile(cfg.OutputFile); err != nil {
+		return err
+	}
+	splitter, err := newResponseSplitter(cfg)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := newBodyChecksum(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := validateOutputMeta(cfg); err != nil {
+		return err
+	}
+	if err := validateOutputFile(cfg); err != nil {
+		return err
+	}
+	outputFile := cfg.OutputFile
+
+	// An --output-file sink such as az://, --output clipboard or editor, and
+	// --pipe write the response to a temp file and hand it over once the
+	// response has been written.
+	cfg, deliverSink, removeSink, err := s.prepareOutputSink(cfg)
+	if err != nil {
+		return err
+	}
+	defer removeSink()
+	cfg, deliverOutput, removeOutput, err := s.prepareOutputTarget(cfg)
+	if err != nil {
+		return err
+	}
+	defer removeOutput()
+
+	url, err = s.applyBaseURL(cfg, url)
+	if err != nil {
+		return err
+	}
+	url, err = s.injectSubscription(ctx, cfg, url)
+	if err != nil {
+		return err
+	}
+
+	// --merge (and --strategic) rewrite the body, headers, and possibly the
+	// method before the request is built.
+	cfg, method, err = s.prepareMergePatch(ctx, cfg, method, url)
+	if err != nil {
+		return err
+	}
+
 	// Echo the correlation ID so it can be quoted in an Azure support request.
 	if cfg.ClientRequestID != "" {
 		fmt.Fprintf(os.Stderr, "%s: %s\n", clientRequestIDHeader, cfg.ClientRequestID)
@@ -408,6 +747,12 @@ func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method,
 	}
 	defer cleanup()
 
+	// Auxiliary tenant tokens are acquired once up front so every attempt,
+	// page, and repeat carries the same x-ms-authorization-auxiliary header.
+	if err := s.applyAuxiliaryTokens(ctx, cfg.AuxTenants, &opts); err != nil {
+		return err
+	}
+
 	// --max-time bounds the whole operation (retries and pagination included).
 	// A value of zero leaves the context untouched, preserving prior behavior.
 	if cfg.MaxTime > 0 {
@@ -425,17 +770,100 @@ func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method,
 	if cfg.Repeat > 1 {
 		return s.executeRepeat(ctx, cfg, httpClient, opts)
 	}
+	if cfg.Watch > 0 {
+		return s.executeWatch(ctx, cfg, httpClient, opts, until)
+	}
+
+	// --paginate with --output-file writes pages to the file as they arrive,
+	// so a large listing is never held in memory as a whole.
+	// --output-dir writes each page, or each of its items, to a file as the
+	// page arrives, and the merged listing is discarded.
+	var pages *pageStream
+	if splitter != nil {
+		opts.OnPage = splitter.page
+		if cfg.Paginate {
+			opts.PageSink = io.Discard
+		}
+	} else if canStreamPages(cfg) {
+		pages = newPageStream(cfg)
+		opts.PageSink = pages
+	}
 
-	resp, err := httpClient.Execute(ctx, opts)
+	if cfg.Summary || cfg.Verbose {
+		opts.Stats = &client.RetryStats{}
+	}
+	if checksum != nil {
+		opts.BodyHash = checksum.hash
+	}
+	started := time.Now()
+	var resp *client.Response
+	if cacheable(cfg, opts, pages != nil || splitter != nil) {
+		resp, err = s.executeCached(ctx, cfg, httpClient, opts)
+	} else {
+		resp, err = httpClient.Execute(ctx, opts)
+	}
+	// A 401 whose challenge names another resource is explained or, with
+	// --auto-scope retry, sent again with a token for that resource.
+	if err == nil && (pages == nil || !pages.started()) {
+		resp, err = s.followAuthChallenge(ctx, cfg, httpClient, &opts, resp)
+	}
+	if pages != nil {
+		if finishErr := pages.finish(err); err == nil && finishErr != nil {
+			err = fmt.Errorf("failed to write %s: %w", cfg.OutputFile, finishErr)
+		}
+	}
+	// The audit record is written before any output so a failure to record
+	// a write request is never hidden behind a successful exit.
+	if auditErr := recordAudit(cfg, opts, started, resp, err); auditErr != nil {
+		return fmt.Errorf("request sent but not recorded in the audit log: %w", auditErr)
+	}
+	if summary := formatRetrySummary(opts.Stats, err); summary != "" {
+		writeDiagnostic(os.Stderr, cfg.Silent, "%s\n", summary)
+	}
 	if err != nil {
 		// Distinguish the overall budget from a per-attempt timeout: when the
 		// max-time context is the one that fired, ctx.Err() is non-nil here.
 		if cfg.MaxTime > 0 && ctx.Err() != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			return fmt.Errorf("overall time budget of %s exceeded (--max-time): %w", cfg.MaxTime, err)
 		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return &interruptedError{}
+		}
 		return err
 	}
 
+	// --checksum and --verify-checksum cover the body as downloaded. A
+	// mismatch fails before anything is written, so a corrupt artifact never
+	// lands in --output-file.
+	if checksum != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := checksum.check(os.Stderr); err != nil {
+			return err
+		}
+	}
+
+	if transform != nil && resp.Body != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		body, columns, err := transform(resp.Body)
+		if err != nil {
+			return err
+		}
+		resp.Body = body
+		// The rows keep their column order unless --query reshapes them.
+		if len(columns) > 0 && cfg.Query == "" {
+			if len(cfg.TableColumns) == 0 {
+				cfg.TableColumns = columns
+			}
+			if len(cfg.Columns) == 0 {
+				for _, name := range columns {
+					cfg.Columns = append(cfg.Columns, columnSpec(name))
+				}
+			}
+		}
+	}
+
+	// --set-azd-env and --ci-var expressions run against the full response,
+	// not the --query result.
+	responseBody := resp.Body
+
 	if cfg.Query != "" {
 		if err := applyQueryToResponse(resp, cfg.Query); err != nil {
 			return err
@@ -452,14 +880,62 @@ func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method,
 		}
 	}
 
-	if err := s.writeResponseOutput(cfg, resp); err != nil {
-		return err
+	switch {
+	case splitter != nil && resp.StatusCode >= 200 && resp.StatusCode < 300:
+		note, err := splitter.finish(resp.Body)
+		if err != nil {
+			return err
+		}
+		writeDiagnostic(os.Stderr, cfg.Silent, "%s", note)
+	case pages == nil || !pages.started():
+		if err := s.writeResponseOutput(cfg, resp); err != nil {
+			return err
+		}
+	}
+
+	// --output-meta records the status, headers, and timing next to the file.
+	if cfg.OutputMeta {
+		if err := writeOutputMeta(outputFile, opts.Method, opts.URL, started, resp); err != nil {
+			return err
+		}
+	}
+
+	// An error response is not uploaded over what a sink already holds.
+	if deliverSink != nil {
+		if resp.StatusCode >= 400 {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> Not uploading the %d response to %s\n", resp.StatusCode, outputFile)
+		} else if err := deliverSink(ctx); err != nil {
+			return fmt.Errorf("failed to upload the response to %s: %w", outputFile, err)
+		}
+	}
+
+	if deliverOutput != nil {
+		// The editor waits for the user, so it is not bound by --max-time.
+		if err := deliverOutput(context.WithoutCancel(ctx)); err != nil {
+			// --fail keeps its exit code when a --pipe command also fails.
+			if cfg.Fail && resp.StatusCode >= 400 {
+				return &httpFailError{status: resp.StatusCode}
+			}
+			return err
+		}
 	}
 
 	if cfg.WriteOut != "" {
 		fmt.Fprint(os.Stderr, ExpandWriteOut(cfg.WriteOut, opts.Method, opts.URL, resp))
 	}
 
+	if len(azdEnvAssignments) > 0 {
+		if err := s.updateAzdEnv(ctx, cfg, resp.StatusCode, responseBody, azdEnvAssignments); err != nil {
+			return err
+		}
+	}
+
+	if len(ciVars) > 0 {
+		if err := s.writeCIOutputs(cfg, resp.StatusCode, responseBody, ciVars); err != nil {
+			return err
+		}
+	}
+
 	// --fail (#233): after the body and metadata have been written, return a
 	// non-zero exit for an error status so scripts and CI can detect failures.
 	if cfg.Fail && resp.StatusCode >= 400 {
@@ -474,12 +950,26 @@ func (s *RequestService) Execute(ctx context.Context, cfg config.Config, method,
 func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Response) error {
 	formatter := client.NewFormatter(cfg.Verbose, cfg.OutputFormat)
 
+	// --only-status replaces the body with the status code.
+	if cfg.OnlyStatus {
+		return writeOutput(cfg, fmt.Sprintf("%d\n", resp.StatusCode))
+	}
+
+	// --show-header replaces the body with the named header values.
+	if len(cfg.ShowHeaders) > 0 {
+		out, err := shownHeaderValues(resp.Headers, cfg.ShowHeaders)
+		if err != nil {
+			return err
+		}
+		return writeOutput(cfg, out)
+	}
+
 	// --raw-output (#234): after --query, print a string result unquoted and an
 	// array of strings one per line. Other shapes fall through to JSON so
 	// nothing is silently mangled.
 	if cfg.RawOutput {
 		if text, ok := rawOutputText(resp.Body); ok {
-			return formatter.WriteRawOutput([]byte(text), cfg.OutputFile)
+			return writeRawOutput(cfg, []byte(text))
 		}
 	}
 
@@ -497,13 +987,28 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		}
 	}
 
+	// --drop-nulls and --sort-keys rewrite a JSON response for stable diffs.
+	// Like --flatten they need the JSON output path, and they run first so
+	// flattened keys leave out null members.
+	if cfg.DropNulls || cfg.SortKeys {
+		isBinary := cfg.Binary || client.DetectContentType(resp.Body, resp.Headers.Get("Content-Type"))
+		onJSONPath := cfg.OutputFormat == string(client.FormatAuto) || cfg.OutputFormat == string(client.FormatJSON) || cfg.OutputFormat == formatEnvelope
+		if isBinary || !onJSONPath {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> --drop-nulls and --sort-keys need the JSON output path; leaving this response unchanged\n")
+		} else if rewritten, err := rewriteJSONBody(resp.Body, cfg.DropNulls, cfg.SortKeys); err != nil {
+			writeDiagnostic(os.Stderr, cfg.Silent, "> --drop-nulls and --sort-keys could not parse the response as JSON; leaving it unchanged\n")
+		} else {
+			resp.Body = rewritten
+		}
+	}
+
 	// Flatten (#237): collapse a JSON response into a single-level object keyed
 	// by dotted paths. Like redaction it needs the JSON output path, so binary,
-	// raw, and the structured formats (table, jsonl, yaml, csv) are left
-	// unchanged with a note on stderr.
-	if cfg.Flatten {
+	// raw, and the structured formats (table, jsonl, yaml) are left unchanged
+	// with a note on stderr. The csv format flattens each row instead.
+	if cfg.Flatten && cfg.OutputFormat != "csv" {
 		isBinary := cfg.Binary || client.DetectContentType(resp.Body, resp.Headers.Get("Content-Type"))
-		onJSONPath := cfg.OutputFormat == string(client.FormatAuto) || cfg.OutputFormat == string(client.FormatJSON)
+		onJSONPath := cfg.OutputFormat == string(client.FormatAuto) || cfg.OutputFormat == string(client.FormatJSON) || cfg.OutputFormat == formatEnvelope
 		switch {
 		case isBinary || !onJSONPath:
 			writeDiagnostic(os.Stderr, cfg.Silent, "> --flatten needs the JSON output path; leaving this response unchanged\n")
@@ -516,6 +1021,17 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		}
 	}
 
+	// --format envelope wraps the body with its status, headers, and timing
+	// in one JSON object. The headers are part of it, so --include adds
+	// nothing.
+	if cfg.OutputFormat == formatEnvelope {
+		out, err := renderEnvelope(resp, cfg.Binary, cfg.Compact)
+		if err != nil {
+			return err
+		}
+		return writeOutput(cfg, out)
+	}
+
 	// When --include is set, prepend the HTTP status line and response headers
 	// to the output (curl -i style). Sensitive header values are redacted.
 	var headerBlock string
@@ -531,9 +1047,9 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 			data := make([]byte, 0, len(headerBlock)+len(resp.Body))
 			data = append(data, headerBlock...)
 			data = append(data, resp.Body...)
-			return formatter.WriteRawOutput(data, cfg.OutputFile)
+			return writeRawOutput(cfg, data)
 		}
-		return formatter.WriteRawOutput(resp.Body, cfg.OutputFile)
+		return writeRawOutput(cfg, resp.Body)
 	}
 
 	// azd-rest renders formats that azd-core's formatter does not support
@@ -543,7 +1059,7 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		if err != nil {
 			return err
 		}
-		return formatter.WriteOutput(out, cfg.OutputFile)
+		return writeOutput(cfg, out)
 	}
 
 	if cfg.OutputFormat == "jsonl" {
@@ -551,7 +1067,7 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		if err != nil {
 			return err
 		}
-		return formatter.WriteOutput(out, cfg.OutputFile)
+		return writeOutput(cfg, out)
 	}
 
 	if cfg.OutputFormat == "yaml" {
@@ -559,15 +1075,32 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		if err != nil {
 			return err
 		}
-		return formatter.WriteOutput(out, cfg.OutputFile)
+		return writeOutput(cfg, out)
 	}
 
 	if cfg.OutputFormat == "csv" {
-		out, err := renderCSV(resp.Body)
+		columns, err := parseColumns(cfg.Columns)
+		if err != nil {
+			return err
+		}
+		out, err := renderCSVWithColumns(resp.Body, columns, cfg.Flatten)
 		if err != nil {
 			return err
 		}
-		return formatter.WriteOutput(out, cfg.OutputFile)
+		return writeOutput(cfg, out)
+	}
+
+	// The auto format renders problem details, server-sent events, and
+	// newline-delimited JSON by their content type rather than as opaque
+	// text. --compact keeps these on the JSON path below.
+	if cfg.OutputFormat == string(client.FormatAuto) && !cfg.Compact {
+		contentType := resp.Headers.Get("Content-Type")
+		if out, ok := renderMediaType(resp.Body, contentType); ok {
+			if summary := problemSummary(resp.Body); summary != "" && mediaTypeOf(contentType) == mediaProblemJSON {
+				writeDiagnostic(os.Stderr, cfg.Silent, "> %s\n", summary)
+			}
+			return writeOutput(cfg, headerBlock+out)
+		}
 	}
 
 	// --compact (#235): minify JSON to a single line for the auto and json
@@ -575,12 +1108,18 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 	// left untouched. A non-JSON body is left unchanged with a note on stderr.
 	if cfg.Compact && cfg.OutputFormat != formatRaw {
 		if compacted, ok := compactJSONBody(resp.Body); ok {
-			return formatter.WriteOutput(headerBlock+compacted+"\n", cfg.OutputFile)
+			return writeOutput(cfg, headerBlock+compacted+"\n")
 		}
 		writeDiagnostic(os.Stderr, cfg.Silent, "> --compact needs a JSON response; leaving output unchanged\n")
 	}
 
-	formatted, err := formatter.Format(resp)
+	// Large JSON bodies are pretty-printed as a stream so memory stays close
+	// to the size of the body itself.
+	if streamed, err := writeStreamedJSON(cfg, resp, headerBlock); streamed {
+		return err
+	}
+
+	formatted, err := formatter.Format(verboseSafeResponse(cfg, resp))
 	if err != nil {
 		return fmt.Errorf("failed to format response: %w", err)
 	}
@@ -590,7 +1129,24 @@ func (s *RequestService) writeResponseOutput(cfg config.Config, resp *client.Res
 		return nil
 	}
 
-	return formatter.WriteOutput(headerBlock+formatted, cfg.OutputFile)
+	return writeOutput(cfg, headerBlock+formatted)
+}
+
+// verboseSafeResponse returns resp with secrets redacted from its header
+// values when verbose output will print them. The body is the requested data
+// and is left untouched.
+func verboseSafeResponse(cfg config.Config, resp *client.Response) *client.Response {
+	if !cfg.Verbose {
+		return resp
+	}
+	scrubbed := *resp
+	scrubbed.Headers = make(http.Header, len(resp.Headers))
+	for key, values := range resp.Headers {
+		for _, value := range values {
+			scrubbed.Headers.Add(key, client.RedactHeaderValue(key, value))
+		}
+	}
+	return &scrubbed
 }
 
 // buildResponseHeaderBlock renders the HTTP status line and response headers as
@@ -608,7 +1164,7 @@ func buildResponseHeaderBlock(resp *client.Response) string {
 
 	for _, key := range keys {
 		for _, value := range resp.Headers[key] {
-			fmt.Fprintf(&b, "%s: %s\n", key, client.RedactSensitiveHeader(key, value))
+			fmt.Fprintf(&b, "%s: %s\n", key, client.RedactHeaderValue(key, value))
 		}
 	}
 	b.WriteString("\n")
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// shownHeaderValues returns the values of the --show-header names, one per
+// line in the order the names were given, with every value of a repeated
+// header on its own line. Values are printed as sent, without redaction, so
+// a Location or Azure-AsyncOperation URL can be passed straight to a
+// follow-up command. A header missing from the response is an error, so a
+// script does not go on with an empty value.
+func shownHeaderValues(headers http.Header, names []string) (string, error) {
+	var b strings.Builder
+	var missing []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		values := headers.Values(name)
+		if len(values) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+		for _, v := range values {
+			b.WriteString(v)
+			b.WriteString("\n")
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("response has no %s header", strings.Join(missing, " or "))
+	}
+	return b.String(), nil
+}
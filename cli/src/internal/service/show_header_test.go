@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShownHeaderValues(t *testing.T) {
+	headers := http.Header{
+		"Location":             {"https://management.azure.com/op/1?sig=abc"},
+		"Azure-Asyncoperation": {"https://management.azure.com/async/1"},
+		"Vary":                 {"Accept", "Origin"},
+	}
+
+	out, err := shownHeaderValues(headers, []string{"azure-asyncoperation", " Location ", "vary"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.azure.com/async/1\nhttps://management.azure.com/op/1?sig=abc\nAccept\nOrigin\n", out)
+
+	_, err = shownHeaderValues(headers, []string{"Location", "Retry-After", "ETag"})
+	assert.EqualError(t, err, "response has no Retry-After or ETag header")
+}
+
+func TestExecute_ShowHeaderReplacesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "https://example.com/operations/42")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"Accepted"}`))
+	}))
+	defer srv.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.ShowHeaders = []string{"location"}
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "POST", srv.URL))
+
+	data, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/operations/42\n", string(data))
+}
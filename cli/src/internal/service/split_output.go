@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// Default --split-name templates.
+const (
+	defaultPageName = `page-{{printf "%04d" .Page}}.json`
+	defaultItemName = `item-{{printf "%06d" .Index}}.json`
+)
+
+// splitName is the data a --split-name template is rendered with. Page counts
+// pages from 1. Index counts files from 1 across all pages, and Item is the
+// item being written with --split-items, or the whole page with --split-pages.
+type splitName struct {
+	Page  int
+	Index int
+	Item  any
+}
+
+// responseSplitter writes each page, or each item of each page, of a
+// response to its own file in --output-dir.
+type responseSplitter struct {
+	dir     string
+	items   bool
+	compact bool
+	name    *template.Template
+	pages   int
+	files   int
+	written map[string]bool
+}
+
+// newResponseSplitter validates the --output-dir options and creates the
+// directory. It returns nil when --output-dir is not set.
+func newResponseSplitter(cfg config.Config) (*responseSplitter, error) {
+	if cfg.OutputDir == "" {
+		switch {
+		case cfg.SplitPages || cfg.SplitItems:
+			return nil, &outputTargetError{msg: "--split-pages and --split-items require --output-dir"}
+		case cfg.SplitName != "":
+			return nil, &outputTargetError{msg: "--split-name requires --output-dir"}
+		}
+		return nil, nil
+	}
+	switch {
+	case cfg.SplitPages == cfg.SplitItems:
+		return nil, &outputTargetError{msg: "--output-dir requires exactly one of --split-pages or --split-items"}
+	case cfg.OutputFile != "":
+		return nil, &outputTargetError{msg: "--output-dir cannot be combined with --output-file"}
+	case cfg.Repeat > 1 || cfg.Watch > 0:
+		return nil, &outputTargetError{msg: "--output-dir cannot be combined with --repeat or --watch"}
+	case cfg.Query != "":
+		return nil, &outputTargetError{msg: "--output-dir writes responses as received and cannot be combined with --query"}
+	}
+
+	text := cfg.SplitName
+	if text == "" {
+		text = defaultPageName
+		if cfg.SplitItems {
+			text = defaultItemName
+		}
+	}
+	name, err := template.New("split-name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --split-name template: %w", err)
+	}
+	if err := os.MkdirAll(cfg.OutputDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create --output-dir: %w", err)
+	}
+	return &responseSplitter{
+		dir:     cfg.OutputDir,
+		items:   cfg.SplitItems,
+		compact: cfg.Compact,
+		name:    name,
+		written: map[string]bool{},
+	}, nil
+}
+
+// page writes one page: the whole body with --split-pages, or each item of
+// its value array (or of a top-level array) with --split-items.
+func (s *responseSplitter) page(body []byte) error {
+	s.pages++
+	if !s.items {
+		return s.write(body)
+	}
+	items, err := splitItems(body)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := s.write(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitItems returns the elements of a top-level JSON array, or of the array
+// under a wrapper key such as value, or else the body as a single item.
+func splitItems(body []byte) ([]json.RawMessage, error) {
+	if !json.Valid(body) {
+		return nil, errors.New("--split-items requires a JSON response")
+	}
+	var items []json.RawMessage
+	if json.Unmarshal(body, &items) == nil {
+		return items, nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) == nil {
+		for _, key := range listWrapperKeys {
+			if json.Unmarshal(fields[key], &items) == nil && items != nil {
+				return items, nil
+			}
+		}
+	}
+	return []json.RawMessage{body}, nil
+}
+
+// write renders the file name for the next file and writes data to it,
+// pretty-printed unless --compact is set. A body that is not JSON is written
+// as it is.
+func (s *responseSplitter) write(data []byte) error {
+	s.files++
+	var item any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	_ = dec.Decode(&item)
+	var name strings.Builder
+	if err := s.name.Execute(&name, splitName{Page: s.pages, Index: s.files, Item: item}); err != nil {
+		return fmt.Errorf("--split-name: %w", err)
+	}
+	file := strings.NewReplacer("/", "_", `\`, "_").Replace(strings.TrimSpace(name.String()))
+	if file == "" || file == "." || file == ".." {
+		return fmt.Errorf("--split-name rendered an invalid file name %q for file %d", name.String(), s.files)
+	}
+	if s.written[file] {
+		return fmt.Errorf("--split-name rendered %q more than once; include {{.Index}} to keep names unique", file)
+	}
+	s.written[file] = true
+
+	trimmed := bytes.TrimSpace(data)
+	var out bytes.Buffer
+	var err error
+	if s.compact {
+		err = json.Compact(&out, trimmed)
+	} else {
+		err = json.Indent(&out, trimmed, "", jsonIndent)
+	}
+	if err != nil {
+		out.Reset()
+		out.Write(data)
+	} else {
+		out.WriteByte('\n')
+	}
+	// #nosec G304 -- The directory comes from the --output-dir flag and the name has no path separators.
+	if err := os.WriteFile(filepath.Join(s.dir, file), out.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Join(s.dir, file), err)
+	}
+	return nil
+}
+
+// finish writes body as the only page when no page was handed over while
+// paginating, as for a request without --paginate, and reports what was
+// written.
+func (s *responseSplitter) finish(body []byte) (string, error) {
+	if s.pages == 0 {
+		if err := s.page(body); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("> Wrote %d file(s) from %d page(s) to %s\n", s.files, s.pages, s.dir), nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedListServer serves three pages of two named items each.
+func pagedListServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		body := map[string]any{"value": []any{
+			map[string]any{"name": "vm" + strconv.Itoa(page*2-1)},
+			map[string]any{"name": "vm" + strconv.Itoa(page*2)},
+		}}
+		if page < 3 {
+			body["nextLink"] = srv.URL + "/?page=" + strconv.Itoa(page+1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func splitTestConfig(t *testing.T) config.Config {
+	t.Helper()
+	cfg := baseTestConfig(t)
+	cfg.OutputFile = ""
+	cfg.OutputDir = filepath.Join(t.TempDir(), "out")
+	cfg.Paginate = true
+	cfg.Silent = true
+	return cfg
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+func TestExecute_SplitPages(t *testing.T) {
+	srv := pagedListServer(t)
+	cfg := splitTestConfig(t)
+	cfg.SplitPages = true
+
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, srv.URL))
+	assert.Equal(t, []string{"page-0001.json", "page-0002.json", "page-0003.json"}, dirNames(t, cfg.OutputDir))
+	got, err := os.ReadFile(filepath.Join(cfg.OutputDir, "page-0003.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"value\": [\n    {\n      \"name\": \"vm5\"\n    },\n    {\n      \"name\": \"vm6\"\n    }\n  ]\n}\n", string(got))
+}
+
+func TestExecute_SplitItemsWithNameTemplate(t *testing.T) {
+	srv := pagedListServer(t)
+	cfg := splitTestConfig(t)
+	cfg.SplitItems = true
+	cfg.Compact = true
+	cfg.SplitName = "{{.Item.name}}-p{{.Page}}.json"
+
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, srv.URL))
+	assert.Equal(t, []string{"vm1-p1.json", "vm2-p1.json", "vm3-p2.json", "vm4-p2.json", "vm5-p3.json", "vm6-p3.json"}, dirNames(t, cfg.OutputDir))
+	got, err := os.ReadFile(filepath.Join(cfg.OutputDir, "vm4-p2.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"vm4\"}\n", string(got))
+}
+
+func TestExecute_SplitItemsWithoutPaginate(t *testing.T) {
+	srv := pagedListServer(t)
+	cfg := splitTestConfig(t)
+	cfg.Paginate = false
+	cfg.SplitItems = true
+
+	require.NoError(t, newTestService().Execute(t.Context(), cfg, http.MethodGet, srv.URL))
+	assert.Equal(t, []string{"item-000001.json", "item-000002.json"}, dirNames(t, cfg.OutputDir))
+}
+
+func TestResponseSplitter_NameErrors(t *testing.T) {
+	cfg := splitTestConfig(t)
+	cfg.SplitItems = true
+	cfg.SplitName = "same.json"
+	splitter, err := newResponseSplitter(cfg)
+	require.NoError(t, err)
+	err = splitter.page([]byte(`[1,2]`))
+	assert.EqualError(t, err, `--split-name rendered "same.json" more than once; include {{.Index}} to keep names unique`)
+
+	cfg.SplitName = "{{.Item.missing}}"
+	splitter, err = newResponseSplitter(cfg)
+	require.NoError(t, err)
+	err = splitter.page([]byte(`[{"name":"a"}]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--split-name")
+
+	cfg.OutputDir = t.TempDir()
+	cfg.SplitName = "../{{.Index}}"
+	splitter, err = newResponseSplitter(cfg)
+	require.NoError(t, err)
+	require.NoError(t, splitter.page([]byte(`[1]`)))
+	assert.Equal(t, []string{".._1"}, dirNames(t, cfg.OutputDir), "path separators are replaced")
+}
+
+func TestNewResponseSplitter_Validation(t *testing.T) {
+	cases := map[string]struct {
+		cfg  config.Config
+		want string
+	}{
+		"split without dir": {config.Config{SplitPages: true}, "--split-pages and --split-items require --output-dir"},
+		"name without dir":  {config.Config{SplitName: "x"}, "--split-name requires --output-dir"},
+		"dir without split": {config.Config{OutputDir: "d"}, "--output-dir requires exactly one of --split-pages or --split-items"},
+		"both splits":       {config.Config{OutputDir: "d", SplitPages: true, SplitItems: true}, "--output-dir requires exactly one of --split-pages or --split-items"},
+		"with output file":  {config.Config{OutputDir: "d", SplitPages: true, OutputFile: "f"}, "--output-dir cannot be combined with --output-file"},
+		"with query":        {config.Config{OutputDir: "d", SplitPages: true, Query: "value"}, "--output-dir writes responses as received and cannot be combined with --query"},
+		"with repeat":       {config.Config{OutputDir: "d", SplitPages: true, Repeat: 2}, "--output-dir cannot be combined with --repeat or --watch"},
+		"bad name template": {config.Config{OutputDir: t.TempDir(), SplitPages: true, SplitName: "{{"}, "invalid --split-name template"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newResponseSplitter(tc.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+
+	splitter, err := newResponseSplitter(config.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, splitter)
+}
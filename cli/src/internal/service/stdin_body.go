@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// stdinBodyArg is the --data and --data-file value that reads the body from
+// stdin. --data-file also accepts a bare "-".
+const stdinBodyArg = "@-"
+
+// bodyFromStdin reports whether --data or --data-file asks for the request
+// body on stdin.
+func bodyFromStdin(cfg config.Config) bool {
+	return cfg.Data == stdinBodyArg || cfg.DataFile == stdinBodyArg || cfg.DataFile == "-"
+}
+
+// applyStdinBody reads stdin into cfg.Data when the body comes from stdin, so
+// the rest of the request is built as if it had been given inline. The body is
+// read once, before any request is sent, so retries and --repeat resend the
+// same bytes. Like a --data-file body, it is not expanded with request
+// variables.
+func (s *RequestService) applyStdinBody(cfg config.Config) (config.Config, error) {
+	if !bodyFromStdin(cfg) {
+		return cfg, nil
+	}
+	if cfg.Data != "" && cfg.DataFile != "" {
+		return cfg, fmt.Errorf("--data and --data-file cannot both be set when reading the body from stdin")
+	}
+	raw, err := s.readStdin()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Data, cfg.DataFile = string(raw), ""
+	return cfg, nil
+}
+
+// readStdin reads the whole request body from stdin. It fails rather than
+// waiting for input when stdin is a terminal.
+func (s *RequestService) readStdin() ([]byte, error) {
+	if f, ok := s.stdin.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return nil, fmt.Errorf("the request body is read from stdin, but stdin is a terminal; pipe the body in, e.g. jq ... | azd rest post <url> -d @-")
+		}
+	}
+	raw, err := io.ReadAll(s.stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body from stdin: %w", err)
+	}
+	return raw, nil
+}
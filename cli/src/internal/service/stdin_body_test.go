@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecute_BodyFromStdin(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		data     string
+		dataFile string
+	}{
+		{name: "data @-", data: "@-"},
+		{name: "data-file -", dataFile: "-"},
+		{name: "data-file @-", dataFile: "@-"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				got = string(b)
+			}))
+			defer srv.Close()
+
+			svc := newTestService()
+			svc.stdin = strings.NewReader(`{"name":"${NOT_EXPANDED}"}`)
+			cfg := baseTestConfig(t)
+			cfg.Data, cfg.DataFile = tt.data, tt.dataFile
+
+			require.NoError(t, svc.Execute(context.Background(), cfg, "POST", srv.URL))
+			assert.Equal(t, `{"name":"${NOT_EXPANDED}"}`, got)
+		})
+	}
+}
+
+func TestBuildRequestOptions_StdinBodyConflicts(t *testing.T) {
+	svc := newTestService()
+	svc.stdin = strings.NewReader("a=1")
+
+	cfg := baseTestConfig(t)
+	cfg.Data = "@-"
+	cfg.FormFields = []string{"b=2"}
+	_, _, err := svc.BuildRequestOptions(cfg, "POST", "https://example.com")
+	assert.ErrorContains(t, err, "--form-field cannot be combined with --data")
+
+	cfg = baseTestConfig(t)
+	cfg.Data = "@-"
+	cfg.DataFile = "body.json"
+	_, _, err = svc.BuildRequestOptions(cfg, "POST", "https://example.com")
+	assert.ErrorContains(t, err, "--data and --data-file cannot both be set")
+}
+
+func TestBuildRequestOptions_LiteralAtDataUnchanged(t *testing.T) {
+	svc := newTestService()
+	svc.stdin = strings.NewReader("should not be read")
+	cfg := baseTestConfig(t)
+	cfg.Data = "@handle"
+
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "POST", "https://example.com")
+	require.NoError(t, err)
+	defer cleanup()
+	body, _ := io.ReadAll(opts.Body)
+	assert.Equal(t, "@handle", string(body))
+}
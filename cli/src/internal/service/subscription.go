@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// subscriptionPlaceholderPattern matches the {subscriptionId} placeholder,
+// raw or percent-encoded, in any letter case.
+var subscriptionPlaceholderPattern = regexp.MustCompile(`(?i)(\{|%7B)subscriptionId(\}|%7D)`)
+
+// azdSubscriptionID returns AZURE_SUBSCRIPTION_ID from the current azd
+// environment, or from the process environment outside azd.
+func azdSubscriptionID(ctx context.Context) (string, error) {
+	azdCtx, err := azdcontext.Load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return azdCtx.SubscriptionID, nil
+}
+
+// injectSubscription fills an empty /subscriptions// segment or a
+// {subscriptionId} placeholder in a Resource Manager URL with the azd
+// environment's subscription, so everyday ARM calls need no pasted GUIDs. The
+// subscription is only looked up when the URL needs it, and other hosts are
+// left alone.
+func (s *RequestService) injectSubscription(ctx context.Context, cfg config.Config, rawURL string) (string, error) {
+	if !needsSubscription(rawURL) {
+		return rawURL, nil
+	}
+	subscriptionID, err := s.subscriptionID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the azd subscription for the URL: %w", err)
+	}
+	if subscriptionID == "" {
+		return "", fmt.Errorf("the URL needs a subscription ID, but %s is not set in the azd environment", azdcontext.SubscriptionIDKey)
+	}
+
+	injected := subscriptionPlaceholderPattern.ReplaceAllString(rawURL, subscriptionID)
+	injected = strings.Replace(injected, "/subscriptions//", "/subscriptions/"+subscriptionID+"/", 1)
+	if cfg.Verbose {
+		writeDiagnostic(os.Stderr, cfg.Silent, "> Using subscription %s from the azd environment (%s)\n", subscriptionID, azdcontext.SubscriptionIDKey)
+	}
+	return injected, nil
+}
+
+// needsSubscription reports whether rawURL is a Resource Manager URL with an
+// empty subscription segment or a {subscriptionId} placeholder.
+func needsSubscription(rawURL string) bool {
+	if !strings.Contains(rawURL, "/subscriptions//") && !subscriptionPlaceholderPattern.MatchString(rawURL) {
+		return false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		// A raw {placeholder} can fail strict parsing; fall back to the prefix.
+		lower := strings.ToLower(rawURL)
+		for _, host := range client.ARMHosts {
+			if strings.HasPrefix(lower, "https://"+host+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	return client.IsARMHost(parsed.Hostname())
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSubscription = "00000000-1111-2222-3333-444444444444"
+
+func TestNeedsSubscription(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://management.azure.com/subscriptions//resourceGroups?api-version=2021-04-01", true},
+		{"https://management.azure.com/subscriptions/{subscriptionId}/resourceGroups", true},
+		{"https://MANAGEMENT.azure.com/subscriptions/%7BSubscriptionId%7D/providers", true},
+		{"https://management.usgovcloudapi.net/subscriptions//resourceGroups", true},
+		{"https://management.azure.com/subscriptions?api-version=2020-01-01", false},
+		{"https://management.azure.com/subscriptions/abc/resourceGroups", false},
+		{"https://example.com/subscriptions//x", false},
+		{"https://example.com/{subscriptionId}", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, needsSubscription(tt.url), tt.url)
+	}
+}
+
+func TestInjectSubscription(t *testing.T) {
+	svc := newTestService()
+	calls := 0
+	svc.subscriptionID = func(context.Context) (string, error) {
+		calls++
+		return testSubscription, nil
+	}
+	cfg := baseTestConfig(t)
+
+	got, err := svc.injectSubscription(context.Background(), cfg, "https://management.azure.com/subscriptions//resourceGroups/rg?api-version=2021-04-01")
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.azure.com/subscriptions/"+testSubscription+"/resourceGroups/rg?api-version=2021-04-01", got)
+
+	got, err = svc.injectSubscription(context.Background(), cfg, "https://management.azure.com/subscriptions/{subscriptionId}/providers")
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.azure.com/subscriptions/"+testSubscription+"/providers", got)
+
+	got, err = svc.injectSubscription(context.Background(), cfg, "https://management.azure.com/subscriptions?api-version=2020-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.azure.com/subscriptions?api-version=2020-01-01", got)
+	assert.Equal(t, 2, calls, "the subscription is only resolved when the URL needs it")
+}
+
+func TestInjectSubscription_Unavailable(t *testing.T) {
+	svc := newTestService()
+	cfg := baseTestConfig(t)
+	const armURL = "https://management.azure.com/subscriptions//resourceGroups"
+
+	svc.subscriptionID = func(context.Context) (string, error) { return "", nil }
+	_, err := svc.injectSubscription(context.Background(), cfg, armURL)
+	assert.ErrorContains(t, err, "AZURE_SUBSCRIPTION_ID is not set")
+
+	svc.subscriptionID = func(context.Context) (string, error) { return "", errors.New("azd unavailable") }
+	_, err = svc.injectSubscription(context.Background(), cfg, armURL)
+	assert.ErrorContains(t, err, "azd unavailable")
+}
+
+func TestAzdSubscriptionID_FromEnv(t *testing.T) {
+	t.Setenv("AZD_SERVER", "")
+	t.Setenv("AZURE_SUBSCRIPTION_ID", testSubscription)
+	got, err := azdSubscriptionID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, testSubscription, got)
+}
+
+func TestExecuteBulk_InjectsSubscriptionOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	svc := newTestService()
+	calls := 0
+	svc.subscriptionID = func(context.Context) (string, error) {
+		calls++
+		return testSubscription, nil
+	}
+	cfg := baseTestConfig(t)
+	cfg.Silent = true
+	// --allow-host rejects the ARM lines after injection, so no request leaves
+	// the machine.
+	in := `{"url":"https://management.azure.com/subscriptions//x"}` + "\n" +
+		`{"url":"https://management.azure.com/subscriptions/{subscriptionId}/y"}` + "\n" +
+		`{"url":"` + srv.URL + `"}` + "\n"
+	cfg.AllowedHosts = []string{"127.0.0.1"}
+
+	_ = svc.ExecuteBulk(context.Background(), cfg, strings.NewReader(in), io.Discard, BulkOptions{Concurrency: 2})
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/azdcontext"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// tokenExpiryWarning is how close to its expiry a token is reported as about
+// to expire.
+const tokenExpiryWarning = 5 * time.Minute
+
+// tokenClaims is the subset of access token claims shown with --verbose.
+type tokenClaims struct {
+	TenantID string      `json:"tid"`
+	AppID    string      `json:"appid"`
+	AZP      string      `json:"azp"`
+	Audience any         `json:"aud"`
+	Scopes   string      `json:"scp"`
+	Roles    []string    `json:"roles"`
+	Expires  json.Number `json:"exp"`
+	// ObjectID and ManagedIdentityID identify the principal; the latter is
+	// the resource ID of a managed identity.
+	ObjectID          string `json:"oid"`
+	ManagedIdentityID string `json:"xms_mirid"`
+}
+
+// decodeTokenClaims reads the claims of a JWT access token without
+// verifying it. It reports false for a token that is not a JWT, such as an
+// opaque OAuth2 token.
+func decodeTokenClaims(token string) (tokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return tokenClaims{}, false
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, false
+	}
+	return claims, true
+}
+
+// audience returns the aud claim, which may be a string or an array.
+func (c tokenClaims) audience() string {
+	switch aud := c.Audience.(type) {
+	case string:
+		return aud
+	case []any:
+		parts := make([]string, 0, len(aud))
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+// inspectingTokenProvider prints the claims of each new access token with
+// --verbose, and warns when the token is from a different tenant than
+// expected or is about to expire. The claims are decoded without
+// verification; they explain a 403, they do not authorize anything.
+type inspectingTokenProvider struct {
+	client.TokenProvider
+	w              io.Writer
+	silent         bool
+	expectedTenant func(context.Context) (tenant, source string)
+	now            func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// inspectTokens wraps tp so that --verbose describes the tokens it issues.
+// The expected tenant is --tenant or, without it, the azd environment's
+// AZURE_TENANT_ID.
+func (s *RequestService) inspectTokens(tp client.TokenProvider, tenant string, silent bool) client.TokenProvider {
+	expected := func(ctx context.Context) (string, string) {
+		if tenant != "" {
+			return tenant, "--tenant"
+		}
+		if id, err := s.tenantID(ctx); err == nil && id != "" {
+			return id, "the azd environment (" + azdcontext.TenantIDKey + ")"
+		}
+		return "", ""
+	}
+	return &inspectingTokenProvider{
+		TokenProvider:  tp,
+		w:              os.Stderr,
+		silent:         silent,
+		expectedTenant: expected,
+		now:            time.Now,
+		seen:           map[string]bool{},
+	}
+}
+
+// GetToken returns the wrapped provider's token, describing it the first
+// time it is seen.
+func (p *inspectingTokenProvider) GetToken(ctx context.Context, scope string) (string, error) {
+	token, err := p.TokenProvider.GetToken(ctx, scope)
+	if err != nil {
+		return token, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.seen[token] {
+		return token, nil
+	}
+	p.seen[token] = true
+
+	claims, ok := decodeTokenClaims(token)
+	if !ok {
+		writeDiagnostic(p.w, p.silent, "> Token: not a JWT; claims not shown\n")
+		return token, nil
+	}
+	p.describe(ctx, claims)
+	return token, nil
+}
+
+func (p *inspectingTokenProvider) describe(ctx context.Context, claims tokenClaims) {
+	rows := [][2]string{
+		{"tenant", claims.TenantID},
+		{"app ID", firstNonEmptyString(claims.AppID, claims.AZP)},
+		{"audience", claims.audience()},
+		{"scopes", claims.Scopes},
+		{"roles", strings.Join(claims.Roles, " ")},
+	}
+	for _, row := range rows {
+		if row[1] != "" {
+			writeDiagnostic(p.w, p.silent, "> Token %-9s %s\n", row[0]+":", row[1])
+		}
+	}
+
+	if exp, err := claims.Expires.Int64(); err == nil && exp > 0 {
+		expires := time.Unix(exp, 0)
+		left := expires.Sub(p.now()).Round(time.Second)
+		if left <= 0 {
+			writeDiagnostic(p.w, p.silent, "> Token %-9s %s (expired)\n", "expires:", expires.UTC().Format(time.RFC3339))
+			writeDiagnostic(p.w, p.silent, "Warning: the access token has expired; the service will reject it\n")
+		} else {
+			writeDiagnostic(p.w, p.silent, "> Token %-9s %s (in %s)\n", "expires:", expires.UTC().Format(time.RFC3339), left)
+			if left < tokenExpiryWarning {
+				writeDiagnostic(p.w, p.silent, "Warning: the access token expires in %s\n", left)
+			}
+		}
+	}
+
+	if claims.TenantID == "" {
+		return
+	}
+	if want, source := p.expectedTenant(ctx); want != "" && !strings.EqualFold(want, claims.TenantID) {
+		writeDiagnostic(p.w, p.silent, "Warning: the access token is from tenant %s, but %s expects tenant %s; a 401 or 403 may mean the wrong account or tenant is signed in\n", claims.TenantID, source, want)
+	}
+}
+
+// azdTenantID returns the azd environment's tenant ID.
+func azdTenantID(ctx context.Context) (string, error) {
+	azdCtx, err := azdcontext.Load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return azdCtx.TenantID, nil
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// validateTenant rejects --tenant where the Azure credential is not used.
+func validateTenant(cfg config.Config) error {
+	mode := strings.TrimSpace(cfg.Auth)
+	switch {
+	case cfg.Tenant == "":
+		return nil
+	case cfg.NoAuth:
+		return &authModeError{fmt.Errorf("--tenant cannot be combined with --no-auth")}
+	case mode != "" && mode != authModeAzure:
+		return &authModeError{fmt.Errorf("--tenant selects the Azure credential's tenant and cannot be combined with --auth %s", mode)}
+	}
+	return nil
+}
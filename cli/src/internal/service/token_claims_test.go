@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJWT returns an unsigned JWT carrying claims.
+func fakeJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc(payload) + ".sig"
+}
+
+func newInspectingProvider(token, expected string, now time.Time, w *bytes.Buffer) *inspectingTokenProvider {
+	return &inspectingTokenProvider{
+		TokenProvider:  &client.MockTokenProvider{Token: token},
+		w:              w,
+		expectedTenant: func(context.Context) (string, string) { return expected, "--tenant" },
+		now:            func() time.Time { return now },
+		seen:           map[string]bool{},
+	}
+}
+
+func TestInspectingTokenProvider_DescribesClaims(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	token := fakeJWT(t, map[string]any{
+		"tid":   "tenant-a",
+		"appid": "04b07795-8ddb-461a-bbee-02f9e1bf7b46",
+		"aud":   "https://management.azure.com",
+		"scp":   "user_impersonation",
+		"roles": []string{"Reader", "Contributor"},
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	var out bytes.Buffer
+	p := newInspectingProvider(token, "tenant-a", now, &out)
+
+	got, err := p.GetToken(t.Context(), "https://management.azure.com/.default")
+	require.NoError(t, err)
+	assert.Equal(t, token, got)
+	assert.Equal(t, "> Token tenant:   tenant-a\n"+
+		"> Token app ID:   04b07795-8ddb-461a-bbee-02f9e1bf7b46\n"+
+		"> Token audience: https://management.azure.com\n"+
+		"> Token scopes:   user_impersonation\n"+
+		"> Token roles:    Reader Contributor\n"+
+		"> Token expires:  2026-10-16T10:00:00Z (in 1h0m0s)\n", out.String())
+
+	out.Reset()
+	_, err = p.GetToken(t.Context(), "https://management.azure.com/.default")
+	require.NoError(t, err)
+	assert.Empty(t, out.String(), "a token is described once")
+}
+
+func TestInspectingTokenProvider_Warnings(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	p := newInspectingProvider(fakeJWT(t, map[string]any{"tid": "tenant-b", "exp": now.Add(2 * time.Minute).Unix()}), "TENANT-A", now, &out)
+	_, err := p.GetToken(t.Context(), "s")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Warning: the access token expires in 2m0s\n")
+	assert.Contains(t, out.String(), "Warning: the access token is from tenant tenant-b, but --tenant expects tenant TENANT-A")
+
+	out.Reset()
+	p = newInspectingProvider(fakeJWT(t, map[string]any{"tid": "tenant-a", "exp": now.Add(-time.Minute).Unix()}), "tenant-a", now, &out)
+	_, err = p.GetToken(t.Context(), "s")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "(expired)\nWarning: the access token has expired")
+	assert.NotContains(t, out.String(), "from tenant", "tenant IDs compare case-insensitively")
+
+	out.Reset()
+	p = newInspectingProvider("opaque-token", "tenant-a", now, &out)
+	_, err = p.GetToken(t.Context(), "s")
+	require.NoError(t, err)
+	assert.Equal(t, "> Token: not a JWT; claims not shown\n", out.String())
+}
+
+func TestBuildRequestOptions_Tenant(t *testing.T) {
+	svc := newTestService()
+	var gotTenant string
+	svc.tenantTokenProviderFactory = func(tenantID string) (client.TokenProvider, error) {
+		gotTenant = tenantID
+		return &client.MockTokenProvider{Token: "t"}, nil
+	}
+
+	cfg := config.Defaults()
+	cfg.Tenant = "contoso.onmicrosoft.com"
+	cfg.Verbose = true
+	opts, cleanup, err := svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/subscriptions?api-version=2022-12-01")
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, "contoso.onmicrosoft.com", gotTenant, "--tenant selects the credential's tenant")
+	require.IsType(t, &inspectingTokenProvider{}, opts.TokenProvider, "--verbose inspects tokens")
+
+	cfg.Auth = "oauth2:api"
+	_, _, err = svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/")
+	var usage *authModeError
+	require.ErrorAs(t, err, &usage)
+	assert.ErrorContains(t, err, "--tenant selects the Azure credential's tenant and cannot be combined with --auth oauth2:api")
+
+	cfg.Auth = ""
+	cfg.NoAuth = true
+	_, _, err = svc.BuildRequestOptions(cfg, "GET", "https://management.azure.com/")
+	assert.ErrorContains(t, err, "--tenant cannot be combined with --no-auth")
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// configureTransport applies the transport settings from the config file and
+// the --connect-timeout, --ipv4, and --ipv6 flags to the connection pool
+// shared by every request in the run.
+func (s *RequestService) configureTransport(cfg config.Config) error {
+	if cfg.ConnectTimeout < 0 {
+		return fmt.Errorf("--connect-timeout must not be negative, got %s", cfg.ConnectTimeout)
+	}
+	if cfg.IPv4 && cfg.IPv6 {
+		return fmt.Errorf("--ipv4 and --ipv6 cannot be combined")
+	}
+	network := ""
+	switch {
+	case cfg.IPv4:
+		network = "tcp4"
+	case cfg.IPv6:
+		network = "tcp6"
+	}
+	file, err := s.loadConfigFile()
+	if err != nil {
+		return err
+	}
+	t := file.Transport
+	for _, field := range []struct {
+		name  string
+		value int64
+	}{
+		{"maxIdleConns", int64(t.MaxIdleConns)},
+		{"maxIdleConnsPerHost", int64(t.MaxIdleConnsPerHost)},
+		{"maxConnsPerHost", int64(t.MaxConnsPerHost)},
+		{"idleConnTimeout", int64(t.IdleConnTimeout)},
+		{"tlsHandshakeTimeout", int64(t.TLSHandshakeTimeout)},
+	} {
+		if field.value < 0 {
+			return fmt.Errorf("config file: transport.%s must not be negative", field.name)
+		}
+	}
+	client.ConfigureTransport(client.TransportOptions{
+		MaxIdleConns:        t.MaxIdleConns,
+		MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     t.MaxConnsPerHost,
+		IdleConnTimeout:     t.IdleConnTimeout,
+		TLSHandshakeTimeout: t.TLSHandshakeTimeout,
+		ConnectTimeout:      cfg.ConnectTimeout,
+		Network:             network,
+	})
+	return nil
+}
+
+// BaseRequestOptions returns the options for a request that a command builds
+// itself, such as the PATCH of arm tag add, with only the connection settings
+// of cfg applied: --timeout, --retry, --insecure, --pinnedpubkey, and
+// --block-private-networks. Headers, body, and output flags are left to the
+// caller. Proxies come from the environment, as for every request.
+func (s *RequestService) BaseRequestOptions(cfg config.Config, method, rawURL string) (client.RequestOptions, error) {
+	if err := s.configureTransport(cfg); err != nil {
+		return client.RequestOptions{}, err
+	}
+	opts := client.RequestOptions{
+		Method:   method,
+		URL:      rawURL,
+		Timeout:  cfg.Timeout,
+		Insecure: cfg.Insecure,
+		Retry:    cfg.Retry,
+	}
+	if cfg.PinnedPubKey != "" {
+		pins, err := client.ParsePinnedPublicKeys(cfg.PinnedPubKey)
+		if err != nil {
+			return client.RequestOptions{}, fmt.Errorf("invalid --pinnedpubkey: %w", err)
+		}
+		opts.PinnedPublicKeys = pins
+	}
+	blockPrivate, err := s.privateNetworksBlocked(cfg)
+	if err != nil {
+		return client.RequestOptions{}, err
+	}
+	if blockPrivate {
+		if err := checkPrivateNetworks(rawURL); err != nil {
+			return client.RequestOptions{}, err
+		}
+	}
+	return opts, nil
+}
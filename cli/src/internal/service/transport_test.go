@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTransport(t *testing.T) {
+	t.Cleanup(func() { client.ConfigureTransport(client.DefaultTransportOptions()) })
+	svc := newTestService()
+
+	svc.loadConfigFile = func() (config.File, error) {
+		return config.File{Transport: config.TransportSettings{MaxIdleConnsPerHost: 12, IdleConnTimeout: 3 * time.Minute}}, nil
+	}
+	require.NoError(t, svc.configureTransport(config.Config{}))
+
+	svc.loadConfigFile = func() (config.File, error) {
+		return config.File{Transport: config.TransportSettings{MaxConnsPerHost: -1}}, nil
+	}
+	err := svc.configureTransport(config.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transport.maxConnsPerHost must not be negative")
+}
+
+func TestConfigureTransport_ConnectFlags(t *testing.T) {
+	t.Cleanup(func() { client.ConfigureTransport(client.DefaultTransportOptions()) })
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, nil }
+
+	require.NoError(t, svc.configureTransport(config.Config{ConnectTimeout: 2 * time.Second, IPv4: true}))
+	assert.EqualError(t, svc.configureTransport(config.Config{IPv4: true, IPv6: true}), "--ipv4 and --ipv6 cannot be combined")
+	assert.EqualError(t, svc.configureTransport(config.Config{ConnectTimeout: -time.Second}), "--connect-timeout must not be negative, got -1s")
+}
+
+func TestExecute_TransportConfigError(t *testing.T) {
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, errors.New("failed to parse config file") }
+
+	err := svc.Execute(t.Context(), baseTestConfig(t), "GET", "https://example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+}
+
+func TestBaseRequestOptions(t *testing.T) {
+	t.Cleanup(func() { client.ConfigureTransport(client.DefaultTransportOptions()) })
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, nil }
+
+	cfg := config.Config{
+		Timeout:      5 * time.Second,
+		Retry:        2,
+		Insecure:     true,
+		PinnedPubKey: "sha256//AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		Headers:      []string{"X-Ignored: yes"},
+	}
+	opts, err := svc.BaseRequestOptions(cfg, "PATCH", "https://management.azure.com/x")
+	require.NoError(t, err)
+	assert.Equal(t, "PATCH", opts.Method)
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, 2, opts.Retry)
+	assert.True(t, opts.Insecure)
+	assert.Len(t, opts.PinnedPublicKeys, 1)
+	assert.Empty(t, opts.Headers, "request flags are left to the caller")
+
+	_, err = svc.BaseRequestOptions(config.Config{PinnedPubKey: "nope"}, "GET", "https://management.azure.com/x")
+	assert.ErrorContains(t, err, "invalid --pinnedpubkey")
+	_, err = svc.BaseRequestOptions(config.Config{BlockPrivate: true}, "GET", "http://127.0.0.1:8080/x")
+	assert.ErrorContains(t, err, "--block-private-networks")
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jmespath-community/go-jmespath"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// webhookTimeout bounds the --notify-webhook POST sent when a watch stops.
+const webhookTimeout = 30 * time.Second
+
+// validateWatch checks the --watch flags and returns the compiled --until
+// expression, or nil when there is none.
+func validateWatch(cfg config.Config) (jmespath.JMESPath, error) {
+	if cfg.Watch < 0 {
+		return nil, fmt.Errorf("--watch must not be negative, got %s", cfg.Watch)
+	}
+	if cfg.Watch == 0 {
+		if cfg.Until != "" || cfg.WatchMaxErrors != 0 || cfg.NotifyWebhook != "" {
+			return nil, errors.New("--until, --watch-max-errors, and --notify-webhook require --watch")
+		}
+		return nil, nil
+	}
+	if cfg.Repeat > 1 {
+		return nil, errors.New("--watch cannot be combined with --repeat")
+	}
+	if cfg.WatchMaxErrors < 0 {
+		return nil, fmt.Errorf("--watch-max-errors must be 0 or more, got %d", cfg.WatchMaxErrors)
+	}
+	if cfg.NotifyWebhook != "" {
+		// The webhook URL is a credential, so it is never echoed.
+		u, err := url.Parse(cfg.NotifyWebhook)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, errors.New("--notify-webhook must be an http or https URL")
+		}
+	}
+	if cfg.Until == "" {
+		return nil, nil
+	}
+	expr, err := jmespath.Compile(cfg.Until)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --until expression: %w", err)
+	}
+	return expr, nil
+}
+
+// executeWatch sends the request every cfg.Watch until the until expression
+// over the response body is truthy or cfg.WatchMaxErrors requests in a row
+// have failed, printing one status line per request to stderr. A failed
+// request is one that could not be sent or returned a 4xx or 5xx status. The
+// last response is written to the configured output and --notify-webhook is
+// told why the watch stopped. An interrupt ends the watch without a
+// notification.
+func (s *RequestService) executeWatch(ctx context.Context, cfg config.Config, httpClient *client.Client, opts client.RequestOptions, until jmespath.JMESPath) error {
+	// Buffer the body so each request gets a fresh reader.
+	var bodyBytes []byte
+	if opts.Body != nil {
+		b, err := io.ReadAll(opts.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		bodyBytes = b
+	}
+
+	if !safeMethods[opts.Method] {
+		writeDiagnostic(os.Stderr, cfg.Silent, "Warning: watching a %s request sends it every %s and may cause side effects.\n", opts.Method, cfg.Watch)
+	}
+
+	target := opts.Method + " " + client.RedactSecrets(client.RedactURL(opts.URL))
+	var lastResp *client.Response
+	var lastFailure string
+	failures := 0
+	for n := 1; ; n++ {
+		if bodyBytes != nil {
+			opts.Body = bytes.NewReader(bodyBytes)
+		}
+
+		started := time.Now()
+		resp, err := httpClient.Execute(ctx, opts)
+		if auditErr := recordAudit(cfg, opts, started, resp, err); auditErr != nil {
+			return fmt.Errorf("request %d sent but not recorded in the audit log: %w", n, auditErr)
+		}
+		if ctx.Err() != nil {
+			return s.stopWatch(ctx, cfg, lastResp)
+		}
+		if err != nil {
+			failures++
+			lastFailure = client.RedactSecrets(err.Error())
+			writeDiagnostic(os.Stderr, cfg.Silent, "Watch %d: %s\n", n, lastFailure)
+		} else {
+			lastResp = resp
+			writeDiagnostic(os.Stderr, cfg.Silent, "Watch %d: HTTP %d in %s\n", n, resp.StatusCode, formatDuration(resp.Duration))
+			if resp.StatusCode >= 400 {
+				failures++
+				lastFailure = fmt.Sprintf("HTTP %d", resp.StatusCode)
+			} else {
+				failures = 0
+			}
+			if until != nil {
+				met, err := evalCondition(until, decodedBody(resp.Body))
+				if err != nil {
+					return fmt.Errorf("--until: %w", err)
+				}
+				if met {
+					message := fmt.Sprintf("%s met --until %s after %d request(s) (HTTP %d)", target, cfg.Until, n, resp.StatusCode)
+					return s.finishWatch(ctx, cfg, resp, message, nil)
+				}
+			}
+		}
+		if cfg.WatchMaxErrors > 0 && failures >= cfg.WatchMaxErrors {
+			message := fmt.Sprintf("%s stopped after %d failed request(s) in a row (last: %s)", target, failures, lastFailure)
+			return s.finishWatch(ctx, cfg, lastResp, message, fmt.Errorf("watch stopped after %d failed requests in a row: %s", failures, lastFailure))
+		}
+
+		select {
+		case <-ctx.Done():
+			return s.stopWatch(ctx, cfg, lastResp)
+		case <-time.After(cfg.Watch):
+		}
+	}
+}
+
+// finishWatch reports why a watch stopped on stderr and to --notify-webhook,
+// writes the last response, and returns result, or the notification error
+// when the webhook could not be reached.
+func (s *RequestService) finishWatch(ctx context.Context, cfg config.Config, resp *client.Response, message string, result error) error {
+	writeDiagnostic(os.Stderr, cfg.Silent, "Watch: %s\n", message)
+	if resp != nil {
+		if err := s.writeResponseOutput(cfg, resp); err != nil {
+			return err
+		}
+	}
+	if cfg.NotifyWebhook != "" {
+		if err := notifyWebhook(ctx, cfg.NotifyWebhook, "azd rest: "+message); err != nil {
+			return errors.Join(result, fmt.Errorf("failed to send the --notify-webhook message: %w", err))
+		}
+	}
+	if result == nil && cfg.Fail && resp != nil && resp.StatusCode >= 400 {
+		return &httpFailError{status: resp.StatusCode}
+	}
+	return result
+}
+
+// stopWatch ends a watch whose context is done. An interrupt is a normal way
+// to stop watching, but --max-time running out is an error.
+func (s *RequestService) stopWatch(ctx context.Context, cfg config.Config, resp *client.Response) error {
+	if resp != nil {
+		if err := s.writeResponseOutput(cfg, resp); err != nil {
+			return err
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("overall time budget of %s exceeded (--max-time) while watching", cfg.MaxTime)
+	}
+	return nil
+}
+
+// notifyWebhook posts text to a Teams or Slack incoming webhook. Both accept
+// a JSON object with a text field. Errors never include the webhook URL,
+// which carries its credential in the path.
+func notifyWebhook(ctx context.Context, webhookURL, text string) error {
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := client.NewClient(nil, false, webhookTimeout).Execute(context.WithoutCancel(ctx), client.RequestOptions{
+		Method:   http.MethodPost,
+		URL:      webhookURL,
+		Headers:  http.Header{contentTypeHeader: {applicationJSON}},
+		Body:     bytes.NewReader(payload),
+		SkipAuth: true,
+	})
+	if err != nil {
+		return errors.New(client.RedactValues(err.Error(), []string{webhookURL}))
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookRecorder returns a server that records the text of each webhook
+// message it receives.
+func webhookRecorder(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct{ Text string }
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg.Text)
+	}))
+	t.Cleanup(server.Close)
+	return server, &messages
+}
+
+func TestExecuteWatch_StopsWhenUntilIsMet(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		state := "Running"
+		if calls.Add(1) == 3 {
+			state = "Succeeded"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"` + state + `"}`))
+	}))
+	defer server.Close()
+	webhook, messages := webhookRecorder(t)
+
+	cfg := baseTestConfig(t)
+	cfg.Watch = 10 * time.Millisecond
+	cfg.Until = "status == 'Succeeded'"
+	cfg.NotifyWebhook = webhook.URL + "/hooks/secret-token"
+	require.NoError(t, newTestService().Execute(context.Background(), cfg, "GET", server.URL+"/op"))
+
+	assert.Equal(t, int32(3), calls.Load())
+	out, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "Succeeded")
+	require.Len(t, *messages, 1)
+	assert.Contains(t, (*messages)[0], "met --until status == 'Succeeded' after 3 request(s) (HTTP 200)")
+}
+
+func TestExecuteWatch_StopsAfterMaxErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	webhook, messages := webhookRecorder(t)
+
+	cfg := baseTestConfig(t)
+	cfg.Watch = 10 * time.Millisecond
+	cfg.WatchMaxErrors = 2
+	cfg.NotifyWebhook = webhook.URL
+	err := newTestService().Execute(context.Background(), cfg, "GET", server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "watch stopped after 2 failed requests in a row: HTTP 404")
+	assert.Equal(t, int32(3), calls.Load())
+	require.Len(t, *messages, 1)
+	assert.Contains(t, (*messages)[0], "stopped after 2 failed request(s) in a row (last: HTTP 404)")
+}
+
+func TestExecuteWatch_WebhookFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"done":true}`))
+	}))
+	defer server.Close()
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer webhook.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Watch = 10 * time.Millisecond
+	cfg.Until = "done"
+	cfg.NotifyWebhook = webhook.URL + "/hooks/secret-token"
+	err := newTestService().Execute(context.Background(), cfg, "GET", server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to send the --notify-webhook message: webhook returned HTTP 403")
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestExecuteWatch_InterruptStopsQuietly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		cancel()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cfg := baseTestConfig(t)
+	cfg.Watch = time.Hour
+	require.NoError(t, newTestService().Execute(ctx, cfg, "GET", server.URL))
+}
+
+func TestExecuteWatch_SideEffectWarningHonorsSilent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"done":true}`))
+	}))
+	defer server.Close()
+
+	stderr := func(silent bool) string {
+		old := os.Stderr
+		f, err := os.CreateTemp(t.TempDir(), "stderr-*.txt")
+		require.NoError(t, err)
+		os.Stderr = f
+		cfg := baseTestConfig(t)
+		cfg.Silent = silent
+		cfg.Watch = 10 * time.Millisecond
+		cfg.Until = "done"
+		err = newTestService().Execute(context.Background(), cfg, "POST", server.URL)
+		os.Stderr = old
+		_ = f.Close()
+		require.NoError(t, err)
+		data, err := os.ReadFile(f.Name()) // #nosec G304 -- test-controlled temp path
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Contains(t, stderr(false), "Warning: watching a POST request")
+	assert.NotContains(t, stderr(true), "Warning")
+}
+
+func TestValidateWatch(t *testing.T) {
+	watching := config.Config{Watch: time.Second, Repeat: 1}
+	tests := []struct {
+		name string
+		cfg  func(config.Config) config.Config
+		want string
+	}{
+		{"until without watch", func(c config.Config) config.Config { c.Watch = 0; c.Until = "done"; return c }, "require --watch"},
+		{"negative watch", func(c config.Config) config.Config { c.Watch = -time.Second; return c }, "must not be negative"},
+		{"with repeat", func(c config.Config) config.Config { c.Repeat = 2; return c }, "cannot be combined with --repeat"},
+		{"negative max errors", func(c config.Config) config.Config { c.WatchMaxErrors = -1; return c }, "must be 0 or more"},
+		{"bad webhook", func(c config.Config) config.Config { c.NotifyWebhook = "ftp://example.com/x"; return c }, "http or https URL"},
+		{"bad until", func(c config.Config) config.Config { c.Until = "[?"; return c }, "invalid --until expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateWatch(tt.cfg(watching))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	expr, err := validateWatch(config.Config{Repeat: 1})
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+}
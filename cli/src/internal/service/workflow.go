@@ -0,0 +1,666 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmespath-community/go-jmespath"
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+)
+
+// Defaults for a step's until polling.
+const (
+	defaultUntilInterval    = 5 * time.Second
+	defaultUntilMaxAttempts = 60
+)
+
+// defaultParallelConcurrency is how many steps of a parallel block run at
+// once when the block sets no concurrency.
+const defaultParallelConcurrency = 4
+
+// workflowStepResult is one NDJSON output line of a workflow run. Body holds
+// the response as JSON when it parses, otherwise as a JSON string.
+type workflowStepResult struct {
+	Step       string          `json:"step"`
+	Method     string          `json:"method,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	Status     int             `json:"status,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+	Attempts   int             `json:"attempts,omitempty"`
+	Skipped    bool            `json:"skipped,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// failure returns why the step failed, or "" when it succeeded or was skipped.
+func (r workflowStepResult) failure() string {
+	if r.Error != "" {
+		return r.Error
+	}
+	if r.Status >= 400 {
+		return fmt.Sprintf("HTTP %d", r.Status)
+	}
+	return ""
+}
+
+// WorkflowOptions controls an azd rest run of a workflow.
+type WorkflowOptions struct {
+	// JUnitReport, when set, is a path to write one test case per step to as
+	// JUnit XML.
+	JUnitReport string
+}
+
+// workflowJUnitCase returns the test case of a finished step: a failure when
+// it failed, skipped when its when expression was false.
+func workflowJUnitCase(workflow string, res workflowStepResult) junitTestCase {
+	c := junitTestCase{
+		Name:      res.Step,
+		ClassName: "azd-rest.workflow." + workflow,
+		Time:      junitSeconds(time.Duration(res.DurationMs) * time.Millisecond),
+	}
+	switch reason := res.failure(); {
+	case res.Skipped:
+		c.Skipped = &junitSkipped{Message: "when expression was false"}
+	case reason != "":
+		c.Failure = &junitProblem{Message: reason, Type: "step"}
+	}
+	return c
+}
+
+// workflowSkippedCases returns a skipped test case with message for each of
+// steps, and for the steps of a parallel block.
+func workflowSkippedCases(workflow string, steps []workflowStep, message string) []junitTestCase {
+	var cases []junitTestCase
+	for _, step := range steps {
+		if step.parallel != nil {
+			cases = append(cases, workflowSkippedCases(workflow, step.parallel, message)...)
+			continue
+		}
+		cases = append(cases, junitTestCase{
+			Name:      step.name,
+			ClassName: "azd-rest.workflow." + workflow,
+			Time:      junitSeconds(0),
+			Skipped:   &junitSkipped{Message: message},
+		})
+	}
+	return cases
+}
+
+// workflowStep is a step resolved to the request it sends, with its
+// expressions compiled. A parallel block has steps in parallel instead of a
+// request.
+type workflowStep struct {
+	name        string
+	request     config.SavedRequest
+	captures    []azdEnvAssignment
+	when        jmespath.JMESPath
+	until       jmespath.JMESPath
+	parallel    []workflowStep
+	concurrency int
+	config.WorkflowStep
+}
+
+// ExecuteWorkflow runs the steps of the named workflow in project in order and
+// writes one NDJSON result per step to out. Every step shares cfg's flags,
+// which take precedence over the step's request as for a saved request. A
+// value captured from a step's response is referenced by later steps as
+// ${name}, and a step's when expression sees earlier results as
+// steps.<name>.status, .body, .error, and .skipped, and captured values as
+// vars.<name>. The steps of a parallel block run concurrently; their results
+// are written in order once all have finished, followed by one for the block.
+// The run stops at the first step that fails to send or returns a 4xx or 5xx
+// status, unless the step sets continueOnError. Every step is validated
+// before any request is sent. With opts.JUnitReport the steps are also
+// written as JUnit XML, those not run included.
+func (s *RequestService) ExecuteWorkflow(ctx context.Context, cfg config.Config, project config.Project, name string, out io.Writer, opts WorkflowOptions) (err error) {
+	wf, ok := project.Workflows[name]
+	if !ok {
+		return fmt.Errorf("no workflow named %q", name)
+	}
+	steps, err := resolveWorkflowSteps(project, name, wf)
+	if err != nil {
+		return err
+	}
+	if err := validateAuxTenants(cfg.AuxTenants); err != nil {
+		return err
+	}
+	if err := s.configureTransport(cfg); err != nil {
+		return err
+	}
+	if cfg.MaxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.MaxTime)
+		defer cancel()
+	}
+
+	limiter, err := newHostLimiter(cfg.HostLimits)
+	if err != nil {
+		return err
+	}
+
+	shared := *s
+	shared.limiter = limiter
+	var (
+		tpOnce sync.Once
+		tp     client.TokenProvider
+		tpErr  error
+	)
+	shared.tokenProviderFactory = func() (client.TokenProvider, error) {
+		tpOnce.Do(func() { tp, tpErr = s.tokenProviderFactory() })
+		return tp, tpErr
+	}
+	resolver := newWorkflowSecretResolver(&shared, cfg)
+
+	captured := map[string]string{}
+	results := map[string]any{}
+	var succeeded, skipped, failed int
+	var cases []junitTestCase
+	var notRun []workflowStep
+	if opts.JUnitReport != "" {
+		started := time.Now()
+		defer func() {
+			suite := newJUnitSuite("azd rest run "+name, started, time.Since(started), append(cases, workflowSkippedCases(name, notRun, "not run: the workflow stopped earlier")...))
+			if reportErr := writeJUnitReport(opts.JUnitReport, suite); reportErr != nil && err == nil {
+				err = reportErr
+			}
+		}()
+	}
+	// record keeps a finished step's state for later when expressions,
+	// writes its result, and counts it. It returns why the run must stop, or
+	// "" when it goes on. A parallel block counts through its steps.
+	record := func(step workflowStep, res workflowStepResult, body any) (string, error) {
+		results[step.name] = stepState(res, body)
+		if values := resolver.resolved(); len(values) > 0 {
+			res.URL = client.RedactValues(res.URL, values)
+			res.Error = client.RedactValues(res.Error, values)
+			if res.Body != nil {
+				res.Body = redactWorkflowBody(res.Body, values)
+			}
+		}
+		// A parallel block is reported through its steps, which record
+		// themselves when the block runs them.
+		switch {
+		case step.parallel == nil:
+			cases = append(cases, workflowJUnitCase(name, res))
+		case res.Skipped:
+			cases = append(cases, workflowSkippedCases(name, step.parallel, "when expression was false")...)
+		case res.Body == nil:
+			cases = append(cases, workflowJUnitCase(name, res))
+		}
+		line, err := json.Marshal(res)
+		if err == nil {
+			_, err = fmt.Fprintf(out, "%s\n", line)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write workflow results: %w", err)
+		}
+
+		reason := res.failure()
+		switch {
+		case res.Skipped:
+			skipped++
+		case step.parallel != nil:
+		case reason == "":
+			succeeded++
+		default:
+			failed++
+		}
+		if reason != "" && step.ContinueOnError {
+			writeDiagnostic(os.Stderr, cfg.Silent, "Workflow %s: step %s failed (%s); continuing\n", name, step.name, reason)
+			return "", nil
+		}
+		return reason, nil
+	}
+
+	// stop reports where the run stopped and how much of it ran.
+	stop := func(where string, notRun int) {
+		if !cfg.Silent {
+			limiter.writeSummary(os.Stderr)
+		}
+		writeDiagnostic(os.Stderr, cfg.Silent, "Workflow %s: stopped %s; %d succeeded, %d skipped, %d failed, %d not run\n", name, where, succeeded, skipped, failed, notRun)
+	}
+
+	for i, step := range steps {
+		if stopErr := stopError(ctx, cfg); stopErr != nil {
+			notRun = steps[i:]
+			stop(fmt.Sprintf("before step %s (%d of %d)", step.name, i+1, len(steps)), len(steps)-i)
+			return fmt.Errorf("workflow %s: %w before step %s", name, stopErr, step.name)
+		}
+		state := workflowState(results, captured)
+		var res workflowStepResult
+		var body any
+		if step.parallel != nil {
+			if res, body, err = shared.runParallelSteps(ctx, cfg, step, state, captured, resolver, record); err != nil {
+				return err
+			}
+		} else {
+			var values map[string]string
+			res, body, values = shared.runDueStep(ctx, cfg, step, state, captured, resolver)
+			for k, v := range values {
+				captured[k] = v
+			}
+		}
+		reason, err := record(step, res, body)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			notRun = steps[i+1:]
+			stop(fmt.Sprintf("at step %s (%d of %d)", step.name, i+1, len(steps)), len(steps)-i-1)
+			if stopErr := stopError(ctx, cfg); stopErr != nil {
+				return fmt.Errorf("workflow %s: %w at step %s", name, stopErr, step.name)
+			}
+			return fmt.Errorf("workflow %s: step %s failed: %s", name, step.name, reason)
+		}
+	}
+	if !cfg.Silent {
+		limiter.writeSummary(os.Stderr)
+	}
+	writeDiagnostic(os.Stderr, cfg.Silent, "Workflow %s: %d succeeded, %d skipped, %d failed\n", name, succeeded, skipped, failed)
+	return nil
+}
+
+// runDueStep runs a step when its when expression over state is truthy and
+// returns its result, its decoded response body, and the values it captured.
+func (s *RequestService) runDueStep(ctx context.Context, cfg config.Config, step workflowStep, state map[string]any, captured map[string]string, resolver *workflowSecretResolver) (workflowStepResult, any, map[string]string) {
+	if step.when != nil {
+		run, err := evalCondition(step.when, state)
+		if err != nil {
+			return workflowStepResult{Step: step.name, Error: fmt.Sprintf("when: %v", err)}, nil, nil
+		}
+		if !run {
+			return workflowStepResult{Step: step.name, Skipped: true}, nil, nil
+		}
+	}
+	return s.runWorkflowStep(ctx, cfg, step, captured, resolver)
+}
+
+// runParallelSteps runs the steps of a parallel block with at most its
+// concurrency in flight. Each step sees the results and values captured
+// before the block, and once all have finished their values are captured
+// and their results recorded in step order. The block's result maps each
+// step's name to its status, null when it has none, and fails when a step
+// failed without continueOnError.
+func (s *RequestService) runParallelSteps(ctx context.Context, cfg config.Config, block workflowStep, state map[string]any, captured map[string]string, resolver *workflowSecretResolver, record func(workflowStep, workflowStepResult, any) (string, error)) (workflowStepResult, any, error) {
+	res := workflowStepResult{Step: block.name}
+	if block.when != nil {
+		run, err := evalCondition(block.when, state)
+		if err != nil {
+			res.Error = fmt.Sprintf("when: %v", err)
+			return res, nil, nil
+		}
+		if !run {
+			res.Skipped = true
+			return res, nil, nil
+		}
+	}
+
+	type outcome struct {
+		res    workflowStepResult
+		body   any
+		values map[string]string
+	}
+	outcomes := make([]outcome, len(block.parallel))
+	slots := make(chan struct{}, block.concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for j, step := range block.parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			o := &outcomes[j]
+			o.res, o.body, o.values = s.runDueStep(ctx, cfg, step, state, captured, resolver)
+		}()
+	}
+	wg.Wait()
+	res.DurationMs = time.Since(start).Milliseconds()
+
+	statuses := make(map[string]any, len(block.parallel))
+	var failed []string
+	for j, step := range block.parallel {
+		o := outcomes[j]
+		for k, v := range o.values {
+			captured[k] = v
+		}
+		reason, err := record(step, o.res, o.body)
+		if err != nil {
+			return res, nil, err
+		}
+		if reason != "" {
+			failed = append(failed, step.name)
+		}
+		statuses[step.name] = nil
+		if o.res.Status != 0 {
+			statuses[step.name] = float64(o.res.Status)
+		}
+	}
+	res.Body, _ = json.Marshal(statuses)
+	if len(failed) > 0 {
+		res.Error = fmt.Sprintf("parallel step(s) %s failed", strings.Join(failed, ", "))
+	}
+	return res, statuses, nil
+}
+
+// resolveWorkflowSteps looks up each step's saved request and compiles its
+// captures and conditions. Step names must be unique across the workflow,
+// including the steps of parallel blocks.
+func resolveWorkflowSteps(project config.Project, name string, wf config.Workflow) ([]workflowStep, error) {
+	steps := make([]workflowStep, len(wf.Steps))
+	seen := map[string]bool{}
+	for i, st := range wf.Steps {
+		stepName := st.Name
+		if stepName == "" {
+			stepName = strconv.Itoa(i + 1)
+		}
+		step, err := resolveWorkflowStep(project, name, stepName, st, seen)
+		if err != nil {
+			return nil, err
+		}
+		if st.Parallel == nil {
+			steps[i] = step
+			continue
+		}
+		step.parallel = make([]workflowStep, len(st.Parallel))
+		for j, child := range st.Parallel {
+			if child.Parallel != nil {
+				return nil, fmt.Errorf("workflow %s: step %s: parallel blocks cannot be nested", name, stepName)
+			}
+			childName := child.Name
+			if childName == "" {
+				childName = stepName + "." + strconv.Itoa(j+1)
+			}
+			if step.parallel[j], err = resolveWorkflowStep(project, name, childName, child, seen); err != nil {
+				return nil, err
+			}
+		}
+		steps[i] = step
+	}
+	return steps, nil
+}
+
+// resolveWorkflowStep resolves and validates one step named stepName. A
+// parallel block is checked for its own settings only; its steps are
+// resolved by the caller.
+func resolveWorkflowStep(project config.Project, name, stepName string, st config.WorkflowStep, seen map[string]bool) (workflowStep, error) {
+	step := workflowStep{name: stepName, request: st.SavedRequest, WorkflowStep: st}
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("workflow %s: step %s: %s", name, step.name, fmt.Sprintf(format, args...))
+	}
+	if seen[step.name] {
+		return step, fmt.Errorf("workflow %s: more than one step is named %s", name, step.name)
+	}
+	seen[step.name] = true
+
+	var err error
+	if st.When != "" {
+		if step.when, err = jmespath.Compile(st.When); err != nil {
+			return step, fail("invalid when expression: %v", err)
+		}
+	}
+	if st.Parallel != nil {
+		if st.Request != "" || st.URL != "" || st.Method != "" || st.Headers != nil || st.Body != nil ||
+			st.Query != "" || st.Scope != "" || st.Capture != nil || st.Until != "" || st.Interval != 0 ||
+			st.MaxAttempts != 0 || st.Retry != nil || st.Timeout != 0 {
+			return step, fail("a parallel block sets only name, when, concurrency, and continueOnError")
+		}
+		if len(st.Parallel) == 0 {
+			return step, fail("parallel block has no steps")
+		}
+		if st.Concurrency < 0 || st.Concurrency > maxBulkConcurrency {
+			return step, fail("concurrency must be between 1 and %d, got %d", maxBulkConcurrency, st.Concurrency)
+		}
+		step.concurrency = st.Concurrency
+		if step.concurrency == 0 {
+			step.concurrency = defaultParallelConcurrency
+		}
+		return step, nil
+	}
+	if st.Concurrency != 0 {
+		return step, fail("concurrency needs a parallel block")
+	}
+
+	if st.Request != "" {
+		if st.URL != "" {
+			return step, fail("sets both request and url")
+		}
+		saved, ok := project.Requests[st.Request]
+		if !ok {
+			return step, fail("no saved request named %q", st.Request)
+		}
+		step.request = saved
+	}
+	if strings.TrimSpace(step.request.URL) == "" {
+		return step, fail("needs a request or a url")
+	}
+	reqCfg, _, reqURL, err := ApplySavedRequest(config.Config{}, step.request)
+	if err != nil {
+		return step, fail("%v", err)
+	}
+	for _, text := range append([]string{reqURL, reqCfg.Data}, reqCfg.Headers...) {
+		if err := checkWorkflowSecretRefs(text); err != nil {
+			return step, fail("%v", err)
+		}
+	}
+	if st.Retry != nil && *st.Retry < 0 {
+		return step, fail("retry must be 0 or more, got %d", *st.Retry)
+	}
+	if st.Timeout < 0 || st.Interval < 0 || st.MaxAttempts < 0 {
+		return step, fail("timeout, interval, and maxAttempts must not be negative")
+	}
+	if st.Until == "" && (st.Interval != 0 || st.MaxAttempts != 0) {
+		return step, fail("interval and maxAttempts need until")
+	}
+	if st.Until != "" {
+		if step.until, err = jmespath.Compile(st.Until); err != nil {
+			return step, fail("invalid until expression: %v", err)
+		}
+	}
+
+	keys := make([]string, 0, len(st.Capture))
+	for key := range st.Capture {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	specs := make([]string, len(keys))
+	for j, key := range keys {
+		specs[j] = key + "=" + st.Capture[key]
+	}
+	if step.captures, err = parseValueAssignments("capture", specs); err != nil {
+		return step, fail("%v", err)
+	}
+	return step, nil
+}
+
+// runWorkflowStep sends one step with the values captured so far and its
+// secret references expanded in its URL, headers, and body, repeating it while
+// an until condition is not met. It returns the result, the full response body
+// decoded for later when expressions, and the step's captured values. It
+// never writes to captured, so steps of a parallel block can share it. Errors
+// are reported in the result.
+func (s *RequestService) runWorkflowStep(ctx context.Context, cfg config.Config, step workflowStep, captured map[string]string, resolver *workflowSecretResolver) (workflowStepResult, any, map[string]string) {
+	res := workflowStepResult{Step: step.name}
+	stepCfg, method, url, err := ApplySavedRequest(cfg, step.request)
+	if err != nil {
+		res.Error = err.Error()
+		return res, nil, nil
+	}
+	url = expandVarRefs(url, captured)
+	headers := make([]string, len(stepCfg.Headers))
+	for i, h := range stepCfg.Headers {
+		headers[i] = expandVarRefs(h, captured)
+	}
+	stepCfg.Headers = headers
+	stepCfg.Data = expandVarRefs(stepCfg.Data, captured)
+	if step.Retry != nil {
+		stepCfg.Retry = *step.Retry
+	}
+	if step.Timeout > 0 {
+		stepCfg.Timeout = step.Timeout
+	}
+	res.Method, res.URL = method, client.RedactURL(url)
+
+	// Secrets are expanded last, so the result shows the reference rather
+	// than the value, and the values are redacted from verbose output.
+	if url, err = resolver.expandURL(ctx, url); err == nil {
+		if stepCfg.Data, err = resolver.expand(ctx, stepCfg.Data); err == nil {
+			for i, h := range stepCfg.Headers {
+				if stepCfg.Headers[i], err = resolver.expand(ctx, h); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res, nil, nil
+	}
+	svc := *s
+	svc.secretValues = resolver.resolved()
+	s = &svc
+
+	maxAttempts, interval := 1, step.Interval
+	if step.until != nil {
+		maxAttempts = step.MaxAttempts
+		if maxAttempts == 0 {
+			maxAttempts = defaultUntilMaxAttempts
+		}
+		if interval == 0 {
+			interval = defaultUntilInterval
+		}
+	}
+
+	// Captures and conditions read the whole response, so the step's query is
+	// applied afterwards and only shapes the output.
+	query := stepCfg.Query
+	stepCfg.Query = ""
+	start := time.Now()
+	var resp *client.Response
+	var body any
+	for attempt := 1; ; attempt++ {
+		if step.until != nil {
+			res.Attempts = attempt
+		}
+		resp, err = s.doBulkRequest(ctx, stepCfg, method, url)
+		res.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			res.Error = err.Error()
+			return res, nil, nil
+		}
+		res.Status = resp.StatusCode
+		res.Body = bulkResultBody(resp.Body)
+		body = decodedBody(resp.Body)
+		if resp.StatusCode >= 400 {
+			return res, body, nil
+		}
+		if step.until == nil {
+			break
+		}
+		done, err := evalCondition(step.until, body)
+		if err != nil {
+			res.Error = fmt.Sprintf("until: %v", err)
+			return res, body, nil
+		}
+		if done {
+			break
+		}
+		if attempt >= maxAttempts {
+			res.Error = fmt.Sprintf("until condition not met after %d attempts", attempt)
+			return res, body, nil
+		}
+		select {
+		case <-ctx.Done():
+			res.Error = ctx.Err().Error()
+			return res, body, nil
+		case <-time.After(interval):
+		}
+	}
+	captures := map[string]string{}
+	if len(step.captures) > 0 {
+		values, err := extractValues("capture", resp.Body, step.captures)
+		if err != nil {
+			res.Error = err.Error()
+			return res, body, nil
+		}
+		for _, v := range values {
+			captures[v.Key] = v.Value
+		}
+	}
+	if err := applyQueryToResponse(resp, query); err != nil {
+		res.Error = err.Error()
+		return res, body, nil
+	}
+	res.Body = bulkResultBody(resp.Body)
+	return res, body, captures
+}
+
+// decodedBody returns a response body as a decoded JSON value, or as a string
+// when it is not JSON. An empty body yields nil.
+func decodedBody(raw []byte) any {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil
+	}
+	var v any
+	if json.Unmarshal(raw, &v) == nil {
+		return v
+	}
+	return string(raw)
+}
+
+// stepState is what a when expression sees of a finished step.
+func stepState(res workflowStepResult, body any) map[string]any {
+	state := map[string]any{"skipped": res.Skipped}
+	if res.Status != 0 {
+		state["status"] = float64(res.Status)
+	}
+	if body != nil {
+		state["body"] = body
+	}
+	if res.Error != "" {
+		state["error"] = res.Error
+	}
+	return state
+}
+
+// workflowState is the document a when expression is evaluated against.
+func workflowState(results map[string]any, captured map[string]string) map[string]any {
+	vars := make(map[string]any, len(captured))
+	for k, v := range captured {
+		vars[k] = v
+	}
+	return map[string]any{"steps": results, "vars": vars}
+}
+
+// evalCondition evaluates expr against data and reports whether the result
+// is truthy in the JMESPath sense: not null, false, or an empty string,
+// array, or object.
+func evalCondition(expr jmespath.JMESPath, data any) (bool, error) {
+	result, err := expr.Search(data)
+	if err != nil {
+		return false, err
+	}
+	switch v := result.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return v != "", nil
+	case []any:
+		return len(v) > 0, nil
+	case map[string]any:
+		return len(v) > 0, nil
+	default:
+		return true, nil
+	}
+}
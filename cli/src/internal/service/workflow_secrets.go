@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/jongio/azd-rest/src/internal/secrets"
+)
+
+// workflowSecretPattern matches a secret reference in a workflow step:
+// {{secret "name"}} reads the OS keychain and {{keyvault "https://..."}} reads
+// a Key Vault secret. The quotes may be JSON escaped, as they are once a
+// structured saved request body has been encoded.
+var workflowSecretPattern = regexp.MustCompile(`\{\{\s*(secret|keyvault)\s+(\\?)"([^"\\]*)\\?"\s*\}\}`)
+
+// keyVaultAPIVersion is the Key Vault data plane version used to read secrets.
+const keyVaultAPIVersion = "7.4"
+
+// keyVaultTimeout bounds one Key Vault secret read.
+const keyVaultTimeout = 30 * time.Second
+
+// keyVaultSuffixes lists the Key Vault DNS suffixes of the Azure clouds. The
+// token audience is the suffix without its leading dot.
+var keyVaultSuffixes = []string{".vault.azure.net", ".vault.azure.cn", ".vault.usgovcloudapi.net"}
+
+// checkWorkflowSecretRefs validates the secret references in text without
+// reading them, so a workflow with a bad reference sends no request.
+func checkWorkflowSecretRefs(text string) error {
+	for _, m := range workflowSecretPattern.FindAllStringSubmatch(text, -1) {
+		var err error
+		if m[1] == "secret" {
+			err = secrets.ValidateName(m[3])
+		} else {
+			_, err = keyVaultSecretScope(m[3])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyVaultSecretScope validates a Key Vault secret URL,
+// https://<vault>.vault.azure.net/secrets/<name>[/<version>], and returns the
+// token scope for its cloud.
+func keyVaultSecretScope(raw string) (string, error) {
+	invalid := fmt.Errorf("invalid Key Vault secret URL %q (expected https://<vault>.vault.azure.net/secrets/<name>[/<version>])", raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", invalid
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "secrets" || parts[1] == "" || (len(parts) == 3 && parts[2] == "") {
+		return "", invalid
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, suffix := range keyVaultSuffixes {
+		if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			return "https://" + suffix[1:] + "/.default", nil
+		}
+	}
+	return "", invalid
+}
+
+// fetchKeyVaultSecret reads the current value of the Key Vault secret at
+// opts.URL with a token from tp. opts carries the run's transport settings
+// (see RequestService.BaseRequestOptions), so the read honors --insecure,
+// --pinnedpubkey, the proxy, and --block-private-networks like any step.
+func fetchKeyVaultSecret(ctx context.Context, tp client.TokenProvider, opts client.RequestOptions) (string, error) {
+	scope, err := keyVaultSecretScope(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	opts.URL = strings.TrimRight(opts.URL, "/") + "?api-version=" + keyVaultAPIVersion
+	opts.Scope = scope
+	resp, err := client.NewClient(tp, opts.Insecure, keyVaultTimeout).Execute(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP %d from Key Vault", resp.StatusCode)
+	}
+	var secret struct {
+		Value *string `json:"value"`
+	}
+	if err := json.Unmarshal(resp.Body, &secret); err != nil || secret.Value == nil {
+		return "", errors.New("no secret value in the Key Vault response")
+	}
+	return *secret.Value, nil
+}
+
+// workflowSecretResolver resolves the secret references of one workflow run.
+// Each secret is read once per run, when a step that uses it is about to be
+// sent, and every value read is kept so it can be redacted.
+type workflowSecretResolver struct {
+	svc    *RequestService
+	cfg    config.Config
+	mu     sync.Mutex
+	cache  map[string]string
+	values []string
+}
+
+func newWorkflowSecretResolver(svc *RequestService, cfg config.Config) *workflowSecretResolver {
+	return &workflowSecretResolver{svc: svc, cfg: cfg, cache: map[string]string{}}
+}
+
+// expand replaces the secret references in text with their values. A value
+// substituted for a reference with JSON escaped quotes is JSON escaped too.
+func (r *workflowSecretResolver) expand(ctx context.Context, text string) (string, error) {
+	var firstErr error
+	out := workflowSecretPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		m := workflowSecretPattern.FindStringSubmatch(ref)
+		value, err := r.lookup(ctx, m[1], m[3])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return ref
+		}
+		if m[2] != "" {
+			value = jsonEscaped(value)
+		}
+		return value
+	})
+	return out, firstErr
+}
+
+// expandURL replaces the secret references in rawURL with their values,
+// escaped for the part of the URL they appear in, so a value containing &, #,
+// ?, or % cannot change the URL's structure.
+func (r *workflowSecretResolver) expandURL(ctx context.Context, rawURL string) (string, error) {
+	query := strings.IndexByte(rawURL, '?')
+	var b strings.Builder
+	var firstErr error
+	last := 0
+	for _, loc := range workflowSecretPattern.FindAllStringSubmatchIndex(rawURL, -1) {
+		b.WriteString(rawURL[last:loc[0]])
+		last = loc[1]
+		value, err := r.lookup(ctx, rawURL[loc[2]:loc[3]], rawURL[loc[6]:loc[7]])
+		switch {
+		case err != nil:
+			if firstErr == nil {
+				firstErr = err
+			}
+			b.WriteString(rawURL[loc[0]:loc[1]])
+		case query >= 0 && loc[0] > query:
+			b.WriteString(url.QueryEscape(value))
+		default:
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+	b.WriteString(rawURL[last:])
+	return b.String(), firstErr
+}
+
+// lookup returns the value of one reference, reading it on first use.
+func (r *workflowSecretResolver) lookup(ctx context.Context, kind, target string) (string, error) {
+	key := kind + " " + target
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value, ok := r.cache[key]; ok {
+		return value, nil
+	}
+	var value string
+	var err error
+	if kind == "secret" {
+		value, err = r.svc.getSecret(target)
+	} else {
+		var tp client.TokenProvider
+		var opts client.RequestOptions
+		if tp, err = r.svc.tokenProviderFactory(); err == nil {
+			if opts, err = r.svc.BaseRequestOptions(r.cfg, http.MethodGet, target); err == nil {
+				value, err = r.svc.getKeyVaultSecret(ctx, tp, opts)
+			}
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s %q: %w", kind, target, err)
+	}
+	r.cache[key] = value
+	r.values = append(r.values, value)
+	for _, escaped := range []string{jsonEscaped(value), url.QueryEscape(value), url.PathEscape(value)} {
+		if !slices.Contains(r.values, escaped) {
+			r.values = append(r.values, escaped)
+		}
+	}
+	return value, nil
+}
+
+// jsonEscaped returns value as it appears inside a JSON string.
+func jsonEscaped(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// resolved returns every secret value read so far, with the JSON and URL
+// escaped forms of any value that differs, as a JSON body or URL shows it.
+func (r *workflowSecretResolver) resolved() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.values...)
+}
+
+// redactWorkflowBody redacts values from a JSON response body without breaking
+// it: strings, keys, numbers, and booleans are redacted after decoding, so a
+// short secret such as 1 cannot corrupt the document. A body that is not
+// valid JSON is returned as a redacted JSON string.
+func redactWorkflowBody(body json.RawMessage, values []string) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(redactWorkflowValue(v, values)); err == nil {
+			return bytes.TrimRight(b.Bytes(), "\n")
+		}
+	}
+	out, _ := json.Marshal(client.RedactValues(string(body), values))
+	return out
+}
+
+// redactWorkflowValue returns v with values redacted from every string, key,
+// number, and boolean. A number or boolean that shows a value becomes the
+// redacted string.
+func redactWorkflowValue(v any, values []string) any {
+	switch t := v.(type) {
+	case string:
+		return client.RedactValues(t, values)
+	case json.Number:
+		if redacted := client.RedactValues(t.String(), values); redacted != t.String() {
+			return redacted
+		}
+	case bool:
+		if slices.Contains(values, strconv.FormatBool(t)) {
+			return client.RedactValues(strconv.FormatBool(t), values)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactWorkflowValue(t[i], values)
+		}
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, item := range t {
+			out[client.RedactValues(k, values)] = redactWorkflowValue(item, values)
+		}
+		return out
+	}
+	return v
+}
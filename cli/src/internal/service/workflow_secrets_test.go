@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jongio/azd-rest/src/internal/client"
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteWorkflow_ResolvesSecrets(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("X-Api-Key"))
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	reads := 0
+	svc := newTestService()
+	svc.getSecret = func(name string) (string, error) {
+		reads++
+		return secretStore(map[string]string{"api": "k-123"})(name)
+	}
+	svc.getKeyVaultSecret = func(_ context.Context, _ client.TokenProvider, opts client.RequestOptions) (string, error) {
+		assert.Equal(t, "https://kv1.vault.azure.net/secrets/db", opts.URL)
+		return `db"Pw1`, nil
+	}
+	step := config.SavedRequest{
+		Method:  "POST",
+		URL:     server.URL + "/echo",
+		Headers: map[string]string{"X-Api-Key": `{{secret "api"}}`},
+		Body:    map[string]any{"password": `{{ keyvault "https://kv1.vault.azure.net/secrets/db" }}`},
+	}
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "first", SavedRequest: step},
+		{Name: "second", SavedRequest: step},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, svc.ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+
+	assert.Equal(t, []string{"k-123", "k-123"}, keys)
+	assert.Equal(t, 1, reads, "each secret is read once per run")
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 2)
+	assert.JSONEq(t, `{"password":"***REDACTED***"}`, string(results[0].Body))
+	assert.NotContains(t, out.String(), "k-123")
+	assert.NotContains(t, out.String(), "Pw1")
+}
+
+func TestWorkflowSecretResolver_KeyVaultUsesRunTransport(t *testing.T) {
+	t.Cleanup(func() { client.ConfigureTransport(client.DefaultTransportOptions()) })
+	var got client.RequestOptions
+	svc := newTestService()
+	svc.loadConfigFile = func() (config.File, error) { return config.File{}, nil }
+	svc.getKeyVaultSecret = func(_ context.Context, _ client.TokenProvider, opts client.RequestOptions) (string, error) {
+		got = opts
+		return "v", nil
+	}
+	cfg := baseTestConfig(t)
+	cfg.Insecure = true
+	cfg.PinnedPubKey = "sha256//AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	_, err := newWorkflowSecretResolver(svc, cfg).expand(context.Background(), `{{keyvault "https://kv1.vault.azure.net/secrets/db"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, got.Method)
+	assert.Equal(t, "https://kv1.vault.azure.net/secrets/db", got.URL)
+	assert.True(t, got.Insecure)
+	assert.Len(t, got.PinnedPublicKeys, 1)
+
+	cfg.PinnedPubKey = "nope"
+	_, err = newWorkflowSecretResolver(svc, cfg).expand(context.Background(), `{{keyvault "https://kv1.vault.azure.net/secrets/other"}}`)
+	assert.ErrorContains(t, err, "invalid --pinnedpubkey")
+}
+
+func TestExecuteWorkflow_EscapesSecretsInURL(t *testing.T) {
+	var gotPath, gotKey, gotOther string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey, gotOther = r.URL.Path, r.URL.Query().Get("key"), r.URL.Query().Get("other")
+	}))
+	defer server.Close()
+
+	svc := newTestService()
+	svc.getSecret = secretStore(map[string]string{"key": "a&other=b#c%d", "seg": "x/y?z"})
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{SavedRequest: config.SavedRequest{URL: server.URL + `/items/{{secret "seg"}}?key={{secret "key"}}`}},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, svc.ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+
+	assert.Equal(t, "/items/x/y?z", gotPath)
+	assert.Equal(t, "a&other=b#c%d", gotKey)
+	assert.Empty(t, gotOther, "a secret cannot add query parameters")
+}
+
+func TestExecuteWorkflow_RedactsNumericSecretInBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count":1,"total":21,"name":"v1","ok":true}`))
+	}))
+	defer server.Close()
+
+	svc := newTestService()
+	svc.getSecret = secretStore(map[string]string{"tenant": "1"})
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{SavedRequest: config.SavedRequest{URL: server.URL + `/?tenant={{secret "tenant"}}`}},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, svc.ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 1)
+	assert.JSONEq(t, `{"count":"***REDACTED***","total":"2***REDACTED***","name":"v***REDACTED***","ok":true}`, string(results[0].Body))
+}
+
+func TestRedactWorkflowBody_NotJSON(t *testing.T) {
+	assert.JSONEq(t, `"key=***REDACTED***"`, string(redactWorkflowBody(json.RawMessage(`key=s3`), []string{"s3"})))
+}
+
+func TestWorkflowSecretResolver_ResolvedIncludesEscapedForms(t *testing.T) {
+	svc := newTestService()
+	svc.getSecret = secretStore(map[string]string{"key": "a&b c"})
+	r := newWorkflowSecretResolver(svc, baseTestConfig(t))
+	got, err := r.expandURL(context.Background(), `https://example.com/?key={{secret "key"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/?key=a%26b+c", got)
+	assert.ElementsMatch(t, []string{"a&b c", `a\u0026b c`, "a%26b+c", "a&b%20c"}, r.resolved())
+}
+
+func TestExecuteWorkflow_SecretErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls++ }))
+	defer server.Close()
+
+	svc := newTestService()
+	svc.getSecret = secretStore(nil)
+	project := config.Project{Workflows: map[string]config.Workflow{
+		"missing": {Steps: []config.WorkflowStep{{SavedRequest: config.SavedRequest{URL: server.URL + `/?key={{secret "nope"}}`}}}},
+		"invalid": {Steps: []config.WorkflowStep{{SavedRequest: config.SavedRequest{URL: server.URL, Body: `{{keyvault "https://example.com/secrets/x"}}`}}}},
+	}}
+	var out bytes.Buffer
+	err := svc.ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "missing", &out, WorkflowOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `secret "nope"`)
+
+	err = svc.ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "invalid", &out, WorkflowOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid Key Vault secret URL")
+	assert.Equal(t, 0, calls)
+}
+
+func TestKeyVaultSecretScope(t *testing.T) {
+	valid := map[string]string{
+		"https://kv1.vault.azure.net/secrets/db":          "https://vault.azure.net/.default",
+		"https://kv1.vault.azure.net/secrets/db/0123abcd": "https://vault.azure.net/.default",
+		"https://kv1.vault.azure.cn/secrets/db":           "https://vault.azure.cn/.default",
+		"https://KV1.vault.usgovcloudapi.net/secrets/db/": "https://vault.usgovcloudapi.net/.default",
+	}
+	for raw, want := range valid {
+		got, err := keyVaultSecretScope(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	for _, raw := range []string{
+		"http://kv1.vault.azure.net/secrets/db",
+		"https://vault.azure.net/secrets/db",
+		"https://kv1.vault.azure.net.evil.com/secrets/db",
+		"https://kv1.vault.azure.net/keys/db",
+		"https://kv1.vault.azure.net/secrets/",
+		"https://kv1.vault.azure.net/secrets/db?api-version=7.4",
+	} {
+		_, err := keyVaultSecretScope(raw)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jongio/azd-rest/src/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeWorkflowResults parses the NDJSON output of a workflow run.
+func decodeWorkflowResults(t *testing.T, out string) []workflowStepResult {
+	t.Helper()
+	var results []workflowStepResult
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var res workflowStepResult
+		require.NoError(t, json.Unmarshal([]byte(line), &res), line)
+		results = append(results, res)
+	}
+	return results
+}
+
+func TestExecuteWorkflow_CapturesValues(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/me":
+			_, _ = w.Write([]byte(`{"id":"u1","displayName":"Ada"}`))
+		default:
+			_, _ = w.Write([]byte(`{"value":[{"id":"g1"},{"id":"g2"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	project := config.Project{
+		Requests: map[string]config.SavedRequest{"me": {URL: server.URL + "/me"}},
+		Workflows: map[string]config.Workflow{"groups": {Steps: []config.WorkflowStep{
+			{Request: "me", Capture: map[string]string{"userId": "id"}},
+			{Name: "groups", SavedRequest: config.SavedRequest{URL: server.URL + "/users/${userId}/memberOf", Query: "value[].id"}},
+		}}},
+	}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "groups", &out, WorkflowOptions{}))
+
+	assert.Equal(t, []string{"/me", "/users/u1/memberOf"}, paths)
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 2)
+	assert.Equal(t, "1", results[0].Step)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, "groups", results[1].Step)
+	assert.JSONEq(t, `["g1","g2"]`, string(results[1].Body))
+}
+
+func TestExecuteWorkflow_StopsAtFailedStep(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "first", SavedRequest: config.SavedRequest{URL: server.URL + "/a"}},
+		{Name: "second", SavedRequest: config.SavedRequest{URL: server.URL + "/b"}},
+	}}}}
+	var out bytes.Buffer
+	err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step first failed: HTTP 404")
+	assert.Equal(t, 1, calls)
+	assert.Len(t, decodeWorkflowResults(t, out.String()), 1)
+}
+
+func TestExecuteWorkflow_ValidatesBeforeSending(t *testing.T) {
+	tests := []struct {
+		name string
+		step config.WorkflowStep
+		want string
+	}{
+		{"unknown request", config.WorkflowStep{Request: "missing"}, `no saved request named "missing"`},
+		{"request and url", config.WorkflowStep{Request: "me", SavedRequest: config.SavedRequest{URL: "https://example.com"}}, "sets both request and url"},
+		{"no url", config.WorkflowStep{}, "needs a request or a url"},
+		{"bad capture", config.WorkflowStep{Request: "me", Capture: map[string]string{"id": "[?"}}, "invalid capture expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := config.Project{
+				Requests: map[string]config.SavedRequest{"me": {URL: "http://127.0.0.1:1/me"}},
+				Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+					{SavedRequest: config.SavedRequest{URL: "http://127.0.0.1:1/first"}},
+					tt.step,
+				}}},
+			}
+			var out bytes.Buffer
+			err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.Empty(t, out.String())
+		})
+	}
+
+	err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), config.Project{}, "nope", &bytes.Buffer{}, WorkflowOptions{})
+	assert.ErrorContains(t, err, `no workflow named "nope"`)
+}
+
+func TestExecuteWorkflow_WhenSkipsSteps(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"state":"exists"}`))
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "check", SavedRequest: config.SavedRequest{URL: server.URL + "/check"}, Capture: map[string]string{"state": "state"}},
+		{Name: "create", SavedRequest: config.SavedRequest{URL: server.URL + "/create"}, When: "steps.check.body.state != 'exists'"},
+		{Name: "verify", SavedRequest: config.SavedRequest{URL: server.URL + "/verify"}, When: "steps.create.skipped && vars.state == 'exists' && steps.check.status == `200`"},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+
+	assert.Equal(t, []string{"/check", "/verify"}, paths)
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 3)
+	assert.True(t, results[1].Skipped)
+	assert.Zero(t, results[1].Status)
+	assert.False(t, results[2].Skipped)
+}
+
+func TestExecuteWorkflow_ContinueOnError(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/delete" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "cleanup", SavedRequest: config.SavedRequest{URL: server.URL + "/delete"}, ContinueOnError: true},
+		{Name: "report", SavedRequest: config.SavedRequest{URL: server.URL + "/report"}, When: "steps.cleanup.status == `404`"},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+	assert.Equal(t, []string{"/delete", "/report"}, paths)
+}
+
+func TestExecuteWorkflow_JUnitReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "pass", SavedRequest: config.SavedRequest{URL: server.URL + "/pass"}},
+		{Name: "fail", SavedRequest: config.SavedRequest{URL: server.URL + "/fail"}, ContinueOnError: true},
+		{Name: "skip", SavedRequest: config.SavedRequest{URL: server.URL + "/skip"}, When: "steps.fail.status == `200`"},
+		{Name: "stop", SavedRequest: config.SavedRequest{URL: server.URL + "/fail"}},
+		{Name: "after", SavedRequest: config.SavedRequest{URL: server.URL + "/after"}},
+	}}}}
+	report := filepath.Join(t.TempDir(), "report.xml")
+	err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &bytes.Buffer{}, WorkflowOptions{JUnitReport: report})
+	require.ErrorContains(t, err, "step stop failed")
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	var doc junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	require.Len(t, doc.Suites, 1)
+	suite := doc.Suites[0]
+	assert.Equal(t, "azd rest run wf", suite.Name)
+	assert.Equal(t, 5, suite.Tests)
+	assert.Equal(t, 2, suite.Failures)
+	assert.Equal(t, 2, suite.Skipped)
+
+	cases := suite.Cases
+	require.Len(t, cases, 5)
+	assert.Equal(t, "pass", cases[0].Name)
+	assert.Nil(t, cases[0].Failure)
+	assert.Nil(t, cases[0].Skipped)
+	assert.Equal(t, "azd-rest.workflow.wf", cases[0].ClassName)
+	assert.Equal(t, "HTTP 409", cases[1].Failure.Message)
+	assert.Equal(t, "when expression was false", cases[2].Skipped.Message)
+	assert.Equal(t, "HTTP 409", cases[3].Failure.Message)
+	assert.Equal(t, "after", cases[4].Name)
+	assert.Contains(t, cases[4].Skipped.Message, "not run")
+}
+
+func TestExecuteWorkflow_UntilPolls(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		status := "Running"
+		if calls == 3 {
+			status = "Succeeded"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"` + status + `"}`))
+	}))
+	defer server.Close()
+
+	step := config.WorkflowStep{Name: "poll", SavedRequest: config.SavedRequest{URL: server.URL}, Until: "status == 'Succeeded'", Interval: time.Millisecond}
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{step}}}}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 1)
+	assert.Equal(t, 3, results[0].Attempts)
+	assert.JSONEq(t, `{"status":"Succeeded"}`, string(results[0].Body))
+
+	calls = 0
+	step.MaxAttempts = 2
+	project.Workflows["wf"] = config.Workflow{Steps: []config.WorkflowStep{step}}
+	err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &bytes.Buffer{}, WorkflowOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "until condition not met after 2 attempts")
+}
+
+func TestExecuteWorkflow_StepTimeoutOverridesFlag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "slow", SavedRequest: config.SavedRequest{URL: server.URL}, Timeout: 5 * time.Second},
+	}}}}
+	cfg := baseTestConfig(t)
+	cfg.Timeout = 10 * time.Millisecond
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), cfg, project, "wf", &bytes.Buffer{}, WorkflowOptions{}))
+}
+
+func TestExecuteWorkflow_ParallelSteps(t *testing.T) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/after" {
+			// Each parallel request waits for the other, so they must be in
+			// flight together.
+			arrived.Done()
+			arrived.Wait()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":%q}`, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer server.Close()
+
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{
+		{Name: "checks", Parallel: []config.WorkflowStep{
+			{Name: "a", SavedRequest: config.SavedRequest{URL: server.URL + "/a"}, Capture: map[string]string{"first": "id"}},
+			{Name: "b", SavedRequest: config.SavedRequest{URL: server.URL + "/b"}, Capture: map[string]string{"second": "id"}},
+		}},
+		{Name: "after", SavedRequest: config.SavedRequest{URL: server.URL + "/after?x=${first}${second}"}, When: "steps.checks.body.a == `200`"},
+	}}}}
+	var out bytes.Buffer
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+
+	results := decodeWorkflowResults(t, out.String())
+	require.Len(t, results, 4)
+	assert.Equal(t, []string{"a", "b", "checks", "after"}, []string{results[0].Step, results[1].Step, results[2].Step, results[3].Step})
+	assert.JSONEq(t, `{"a":200,"b":200}`, string(results[2].Body))
+	assert.Equal(t, server.URL+"/after?x=ab", results[3].URL)
+}
+
+func TestExecuteWorkflow_ParallelConcurrencyAndFailure(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	block := config.WorkflowStep{Name: "block", Concurrency: 1, Parallel: []config.WorkflowStep{
+		{Name: "ok1", SavedRequest: config.SavedRequest{URL: server.URL + "/ok"}},
+		{Name: "bad", SavedRequest: config.SavedRequest{URL: server.URL + "/bad"}},
+		{Name: "ok2", SavedRequest: config.SavedRequest{URL: server.URL + "/ok"}},
+	}}
+	next := config.WorkflowStep{Name: "next", SavedRequest: config.SavedRequest{URL: server.URL + "/ok"}}
+	project := config.Project{Workflows: map[string]config.Workflow{"wf": {Steps: []config.WorkflowStep{block, next}}}}
+
+	var out bytes.Buffer
+	err := newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step block failed: parallel step(s) bad failed")
+	assert.Equal(t, int32(1), peak.Load())
+	assert.Len(t, decodeWorkflowResults(t, out.String()), 4, "every step of the block runs")
+
+	block.ContinueOnError = true
+	project.Workflows["wf"] = config.Workflow{Steps: []config.WorkflowStep{block, next}}
+	out.Reset()
+	require.NoError(t, newTestService().ExecuteWorkflow(context.Background(), baseTestConfig(t), project, "wf", &out, WorkflowOptions{}))
+	assert.Len(t, decodeWorkflowResults(t, out.String()), 5)
+}
+
+func TestResolveWorkflowSteps_Errors(t *testing.T) {
+	url := config.SavedRequest{URL: "https://example.com"}
+	negative := -1
+	tests := []struct {
+		name  string
+		steps []config.WorkflowStep
+		want  string
+	}{
+		{"duplicate name", []config.WorkflowStep{{Name: "a", SavedRequest: url}, {Name: "a", SavedRequest: url}}, "more than one step is named a"},
+		{"bad when", []config.WorkflowStep{{SavedRequest: url, When: "[?"}}, "invalid when expression"},
+		{"bad until", []config.WorkflowStep{{SavedRequest: url, Until: "[?"}}, "invalid until expression"},
+		{"interval without until", []config.WorkflowStep{{SavedRequest: url, Interval: time.Second}}, "need until"},
+		{"negative retry", []config.WorkflowStep{{SavedRequest: url, Retry: &negative}}, "retry must be 0 or more"},
+		{"empty parallel", []config.WorkflowStep{{Parallel: []config.WorkflowStep{}}}, "parallel block has no steps"},
+		{"parallel with url", []config.WorkflowStep{{SavedRequest: url, Parallel: []config.WorkflowStep{{SavedRequest: url}}}}, "sets only name"},
+		{"nested parallel", []config.WorkflowStep{{Parallel: []config.WorkflowStep{{Parallel: []config.WorkflowStep{{SavedRequest: url}}}}}}, "cannot be nested"},
+		{"concurrency without parallel", []config.WorkflowStep{{SavedRequest: url, Concurrency: 2}}, "concurrency needs a parallel block"},
+		{"duplicate name in parallel", []config.WorkflowStep{{Name: "a", SavedRequest: url}, {Parallel: []config.WorkflowStep{{Name: "a", SavedRequest: url}}}}, "more than one step is named a"},
+		{"parallel step without url", []config.WorkflowStep{{Parallel: []config.WorkflowStep{{}}}}, "step 1.1: needs a request or a url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveWorkflowSteps(config.Project{}, "wf", config.Workflow{Steps: tt.steps})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}